@@ -1,12 +1,42 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"math"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
 	"unsafe"
 )
 
 func main() {
+	printTypes()
+	printLimits()
+
+	// Create a reader for reading commands from standard input
+	reader := bufio.NewReader(os.Stdin)
+	printHelp()
+	for {
+		fmt.Print("> ")
+		line, err := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line != "" {
+			if quit := runCommand(line); quit {
+				return
+			}
+		}
+		if err != nil { // End of input (Ctrl+D)
+			fmt.Println()
+			return
+		}
+	}
+}
+
+// printTypes shows the value, type and size of variables of different types
+func printTypes() {
 	// Declare variables of different types
 	var (
 		intVar    int     = 42
@@ -24,8 +54,10 @@ func main() {
 	fmt.Printf("%-10s: %v\t(Type: %T, Size: %d bytes)\n", "String", stringVar, stringVar, unsafe.Sizeof(stringVar))
 	fmt.Printf("%-10s: %v (%c)\t(Type: %T, Size: %d bytes)\n", "Rune", runeVar, runeVar, runeVar, unsafe.Sizeof(runeVar))
 	fmt.Printf("%-10s: %v\t(Type: %T, Size: %d bytes)\n", "Byte", byteVar, byteVar, unsafe.Sizeof(byteVar))
+}
 
-	// Show limits of different numeric types
+// printLimits shows the limits of the numeric types
+func printLimits() {
 	fmt.Println("\n--- Numeric Type Limits ---")
 	fmt.Printf("int8    : %d to %d\n", math.MinInt8, math.MaxInt8)
 	fmt.Printf("uint8   : 0 to %d\n", math.MaxUint8)
@@ -34,5 +66,355 @@ func main() {
 	fmt.Printf("int32   : %d to %d\n", math.MinInt32, math.MaxInt32)
 	fmt.Printf("uint32  : 0 to %d\n", math.MaxUint32)
 	fmt.Printf("int64   : %d to %d\n", math.MinInt64, math.MaxInt64)
-	// Note: MaxUint64 doesn't fit in a signed int64, so we'd need special handling
+	// MaxUint64 doesn't fit in an int, the untyped constant needs a uint64
+	fmt.Printf("uint64  : 0 to %d\n", uint64(math.MaxUint64))
+	fmt.Printf("float32 : ±%g (smallest %g), %d bits of mantissa\n", math.MaxFloat32, math.SmallestNonzeroFloat32, 24)
+	fmt.Printf("float64 : ±%g (smallest %g), %d bits of mantissa\n", math.MaxFloat64, math.SmallestNonzeroFloat64, 53)
+}
+
+func printHelp() {
+	fmt.Println("\n--- Conversion Playground ---")
+	fmt.Println("Commands:")
+	fmt.Println("  <value> <type> [<type>...]    convert a value, such as: 300 int8 uint8 float32")
+	fmt.Println("  <a> <op> <b> in <type>        compute in a type, such as: 127 + 1 in int8")
+	fmt.Println("  types                         list the types")
+	fmt.Println("  limits                        show the limits of the types")
+	fmt.Println("  help, quit")
+	fmt.Println("Values can be written 255, -1, 3.99, 1e10, 0xff or 0b1010.")
+}
+
+// typeNames are the types the playground converts to
+var typeNames = []string{
+	"int8", "int16", "int32", "int64", "int",
+	"uint8", "uint16", "uint32", "uint64", "uint",
+	"float32", "float64", "byte", "rune",
+}
+
+// runCommand runs a line typed by the user and reports whether to quit
+func runCommand(line string) bool {
+	fields := strings.Fields(line)
+	switch fields[0] {
+	case "quit", "exit":
+		return true
+	case "help":
+		printHelp()
+		return false
+	case "types":
+		fmt.Println(strings.Join(typeNames, ", "))
+		return false
+	case "limits":
+		printLimits()
+		return false
+	}
+
+	var err error
+	if len(fields) == 5 && fields[3] == "in" {
+		err = calculate(fields[0], fields[1], fields[2], fields[4])
+	} else if len(fields) >= 2 {
+		err = convertAll(fields[0], fields[1:])
+	} else {
+		err = errors.New("expected a value and a type, type help for examples")
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+	}
+	return false
+}
+
+// input is a value typed by the user, kept in the Go type able to hold it
+// exactly, and as an exact big.Float to compare the conversions with
+type input struct {
+	kind  string // "int", "uint" or "float"
+	i     int64
+	u     uint64
+	f     float64
+	exact *big.Float
+}
+
+func parseInput(s string) (input, error) {
+	var in input
+	exact, ok := new(big.Float).SetPrec(256).SetString(s)
+	if !ok {
+		return in, fmt.Errorf("%q is not a number", s)
+	}
+	in.exact = exact
+
+	// Base 0 accepts the 0x, 0o and 0b prefixes, like Go literals
+	if i, err := strconv.ParseInt(s, 0, 64); err == nil {
+		in.kind, in.i = "int", i
+		return in, nil
+	}
+	if u, err := strconv.ParseUint(s, 0, 64); err == nil {
+		in.kind, in.u = "uint", u
+		return in, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return in, fmt.Errorf("%q is not a number", s)
+	}
+	if exact.IsInt() && !strings.ContainsAny(s, ".eEpP") {
+		return in, fmt.Errorf("%s doesn't fit in 64 bits", s)
+	}
+	in.kind, in.f = "float", f
+	return in, nil
+}
+
+// number is the set of types the playground converts to
+type number interface {
+	~int8 | ~int16 | ~int32 | ~int64 | ~int |
+		~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uint |
+		~float32 | ~float64
+}
+
+// convert performs the conversion the way a Go program would: T(value)
+func convert[T number](in input) T {
+	switch in.kind {
+	case "int":
+		return T(in.i)
+	case "uint":
+		return T(in.u)
+	}
+	return T(in.f)
+}
+
+func convertAll(value string, targets []string) error {
+	in, err := parseInput(value)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s parsed as %s\n", value, in.kind+"64")
+	if in.kind == "float" && in.exact.Cmp(big.NewFloat(in.f)) != 0 {
+		fmt.Printf("  note: %s has no exact float64 representation, it is stored as %s\n",
+			value, new(big.Float).SetFloat64(in.f).Text('g', 25))
+	}
+	for _, target := range targets {
+		if err := convertTo(in, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// convertTo converts the input to the type named target and describes the result
+func convertTo(in input, target string) error {
+	switch target {
+	case "int8":
+		describe(in, convert[int8](in))
+	case "int16":
+		describe(in, convert[int16](in))
+	case "int32":
+		describe(in, convert[int32](in))
+	case "int64":
+		describe(in, convert[int64](in))
+	case "int":
+		describe(in, convert[int](in))
+	case "uint8":
+		describe(in, convert[uint8](in))
+	case "uint16":
+		describe(in, convert[uint16](in))
+	case "uint32":
+		describe(in, convert[uint32](in))
+	case "uint64":
+		describe(in, convert[uint64](in))
+	case "uint":
+		describe(in, convert[uint](in))
+	case "float32":
+		describe(in, convert[float32](in))
+	case "float64":
+		describe(in, convert[float64](in))
+	case "byte":
+		describe(in, convert[byte](in))
+	case "rune":
+		r := convert[rune](in)
+		describe(in, r)
+		fmt.Printf("           as a character: %q\n", r)
+	default:
+		return fmt.Errorf("unknown type %q, type types for the list", target)
+	}
+	return nil
+}
+
+// describe prints a converted value, what the conversion lost and its bits
+func describe[T number](in input, v T) {
+	fmt.Printf("  %-8T %-24v %s\n", v, v, explain(in, v))
+	fmt.Printf("           %s\n", representation(v))
+}
+
+// explain compares the converted value with the exact input
+func explain[T number](in input, v T) string {
+	got := toBig(v)
+	if got.Cmp(in.exact) == 0 {
+		return "exact"
+	}
+	if isFloat(v) {
+		diff := new(big.Float).Sub(got, in.exact)
+		if got.IsInf() {
+			return "overflow: out of the range of the type"
+		}
+		return "precision lost, off by " + diff.Text('g', 6)
+	}
+
+	// An integer type: was the fraction dropped, or the value out of range?
+	truncated, _ := in.exact.Int(nil)
+	if new(big.Float).SetInt(truncated).Cmp(got) == 0 {
+		return "fraction truncated toward zero"
+	}
+	if in.kind == "float" {
+		return "out of range: converting a float is implementation-specific, don't rely on this value"
+	}
+	if in.exact.Sign() < 0 && got.Sign() > 0 {
+		return "overflow: the sign bit became a value bit"
+	}
+	if in.exact.Sign() > 0 && got.Sign() < 0 {
+		return "overflow: the top bit became the sign bit"
+	}
+	return "overflow: the bits that don't fit were dropped"
+}
+
+// calculate computes a op b in the type named target, where overflows wrap around
+func calculate(a, op, b, target string) error {
+	x, err := parseInput(a)
+	if err != nil {
+		return err
+	}
+	y, err := parseInput(b)
+	if err != nil {
+		return err
+	}
+	switch target {
+	case "int8":
+		return printResult(convert[int8](x), op, convert[int8](y))
+	case "int16":
+		return printResult(convert[int16](x), op, convert[int16](y))
+	case "int32", "rune":
+		return printResult(convert[int32](x), op, convert[int32](y))
+	case "int64":
+		return printResult(convert[int64](x), op, convert[int64](y))
+	case "int":
+		return printResult(convert[int](x), op, convert[int](y))
+	case "uint8", "byte":
+		return printResult(convert[uint8](x), op, convert[uint8](y))
+	case "uint16":
+		return printResult(convert[uint16](x), op, convert[uint16](y))
+	case "uint32":
+		return printResult(convert[uint32](x), op, convert[uint32](y))
+	case "uint64":
+		return printResult(convert[uint64](x), op, convert[uint64](y))
+	case "uint":
+		return printResult(convert[uint](x), op, convert[uint](y))
+	case "float32":
+		return printResult(convert[float32](x), op, convert[float32](y))
+	case "float64":
+		return printResult(convert[float64](x), op, convert[float64](y))
+	}
+	return fmt.Errorf("unknown type %q, type types for the list", target)
+}
+
+func printResult[T number](x T, op string, y T) error {
+	var r T
+	switch op {
+	case "+":
+		r = x + y
+	case "-":
+		r = x - y
+	case "*":
+		r = x * y
+	case "/":
+		if y == 0 && !isFloat(x) {
+			return errors.New("integer division by zero panics at run time")
+		}
+		r = x / y
+	default:
+		return fmt.Errorf("unknown operator %q, use + - * /", op)
+	}
+
+	exact := new(big.Float).SetPrec(256)
+	switch op {
+	case "+":
+		exact.Add(toBig(x), toBig(y))
+	case "-":
+		exact.Sub(toBig(x), toBig(y))
+	case "*":
+		exact.Mul(toBig(x), toBig(y))
+	case "/":
+		if y != 0 {
+			exact.Quo(toBig(x), toBig(y))
+		}
+	}
+
+	fmt.Printf("  %v %s %v = %v (%T)\n", x, op, y, r, r)
+	if op != "/" || y != 0 {
+		if got := toBig(r); got.Cmp(exact) != 0 {
+			switch {
+			case op == "/" && !isFloat(r):
+				fmt.Printf("  note: integer division truncates, the exact result is %s\n", exact.Text('g', 10))
+			case isFloat(r):
+				fmt.Printf("  note: rounded, the exact result with the stored operands is %s\n", exact.Text('g', 20))
+			default:
+				fmt.Printf("  note: overflow, the exact result %s wrapped around\n", exact.Text('f', 0))
+			}
+		}
+	}
+	fmt.Printf("           %s\n", representation(r))
+	return nil
+}
+
+// toBig returns a value as an exact big.Float
+func toBig[T number](v T) *big.Float {
+	f := new(big.Float).SetPrec(256)
+	switch x := any(v).(type) {
+	case float32:
+		return f.SetFloat64(float64(x))
+	case float64:
+		if math.IsInf(x, 0) || math.IsNaN(x) {
+			return f.SetInf(x < 0)
+		}
+		return f.SetFloat64(x)
+	}
+	if isSigned(v) {
+		return f.SetInt64(int64(v))
+	}
+	return f.SetUint64(uint64(v))
+}
+
+func isFloat[T number](v T) bool {
+	switch any(v).(type) {
+	case float32, float64:
+		return true
+	}
+	return false
+}
+
+func isSigned[T number](v T) bool {
+	var zero T
+	return zero-1 < zero // Unsigned types wrap around to their maximum
+}
+
+// representation returns the bits of a value in binary and in hexadecimal.
+// Floats are split in sign, exponent and mantissa (IEEE 754).
+func representation[T number](v T) string {
+	switch x := any(v).(type) {
+	case float32:
+		bits := math.Float32bits(x)
+		s := fmt.Sprintf("%032b", bits)
+		return fmt.Sprintf("bin %s %s %s  hex 0x%08x", s[:1], s[1:9], s[9:], bits)
+	case float64:
+		bits := math.Float64bits(x)
+		s := fmt.Sprintf("%064b", bits)
+		return fmt.Sprintf("bin %s %s %s  hex 0x%016x", s[:1], s[1:12], s[12:], bits)
+	}
+
+	size := int(unsafe.Sizeof(v)) * 8
+	bits := uint64(v) & (math.MaxUint64 >> (64 - size)) // Two's complement of negative values
+	return fmt.Sprintf("bin %s  hex 0x%0*x", groupBits(fmt.Sprintf("%0*b", size, bits)), size/4, bits)
+}
+
+// groupBits separates the bytes of a binary number
+func groupBits(s string) string {
+	var groups []string
+	for len(s) > 8 {
+		groups = append(groups, s[:8])
+		s = s[8:]
+	}
+	return strings.Join(append(groups, s), "_")
 }
@@ -0,0 +1,3 @@
+module golang-training/module-01/exercise-4
+
+go 1.25
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+)
+
+func main() {
+	fmt.Println("=== Floating Point Pitfalls ===")
+	a, b := 0.1, 0.2
+	fmt.Printf("0.1 + 0.2 = %.17f, equal to 0.3: %t\n", a+b, a+b == 0.3)
+
+	price := 0.29
+	fmt.Printf("int64(0.29 * 100) = %d cents\n", int64(price*100))
+
+	total := 0.0
+	for range 1_000_000 {
+		total += 0.01
+	}
+	fmt.Printf("0.01 added a million times = %.10f\n", total)
+
+	var cents Cents
+	for range 1_000_000 {
+		cents++
+	}
+	fmt.Printf("1 cent added a million times = %s\n", cents)
+
+	fmt.Println("\n=== Invoices: float64, fixed-point and big.Rat ===")
+	invoices := []struct {
+		name string
+		inv  Invoice
+	}{
+		{"Module 09 cart, 10% off, 8.25% tax", Invoice{
+			Lines:    []Line{{"1200.00", 2}, {"150.00", 2}, {"50.00", 3}},
+			Discount: "10",
+			Tax:      "8.25",
+		}},
+		{"1.15 at 50% off", Invoice{Lines: []Line{{"1.15", 1}}, Discount: "50"}},
+		{"10.05 with 10% tax", Invoice{Lines: []Line{{"10.05", 1}}, Tax: "10"}},
+		{"1.45 at 10% off, 5% tax", Invoice{Lines: []Line{{"1.45", 1}}, Discount: "10", Tax: "5"}},
+		{"3 x 19.99, 7% tax, shipping", Invoice{Lines: []Line{{"19.99", 3}}, Tax: "7", Shipping: "4.99"}},
+		{"100.00 with 8.875% tax", Invoice{Lines: []Line{{"100.00", 1}}, Tax: "8.875"}},
+	}
+
+	fmt.Printf("%-36s %10s %10s %10s\n", "Invoice", "float64", "fixed", "big.Rat")
+	for _, tc := range invoices {
+		float := result(FloatTotal(tc.inv))
+		fixed := result(FixedTotal(tc.inv))
+		exact := result(RatTotal(tc.inv))
+		marker := ""
+		if float != exact {
+			marker = "  <- float64 is off"
+		}
+		fmt.Printf("%-36s %10s %10s %10s%s\n", tc.name, float, fixed, exact, marker)
+	}
+
+	fmt.Println("\n=== Exact Fractions ===")
+	third := big.NewRat(100, 3)
+	fmt.Printf("100 / 3 = %s exactly, %s with 10 decimals, %s rounded to the cent\n",
+		third, third.FloatString(10), RoundRat(third))
+}
+
+// result formats the total of an invoice, or why it couldn't be computed
+func result(t Total, err error) string {
+	if err != nil {
+		return "error"
+	}
+	return t.Total.String()
+}
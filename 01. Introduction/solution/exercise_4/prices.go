@@ -0,0 +1,233 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Line is a line of an invoice: a unit price and a quantity.
+// Amounts are written as strings, like in a price list or a JSON payload, so
+// each implementation parses them without going through float64.
+type Line struct {
+	Price    string
+	Quantity int
+}
+
+// Invoice is the checkout of the e-commerce exercise of module 09: items, a
+// discount and a tax in percent, and the shipping.
+// The discount and the tax are each rounded to the cent, half away from zero,
+// as they are printed on the invoice.
+type Invoice struct {
+	Lines    []Line
+	Discount string // Percent, such as "10"
+	Tax      string // Percent, such as "8.25"
+	Shipping string
+}
+
+// Total is the result of an invoice, in cents.
+type Total struct {
+	Subtotal Cents
+	Discount Cents
+	Tax      Cents
+	Total    Cents
+}
+
+// Float implementation
+
+// roundCents rounds an amount in dollars to the cent, half away from zero.
+func roundCents(x float64) float64 {
+	return math.Round(x*100) / 100
+}
+
+// FloatTotal computes the invoice with float64, the way most code starts.
+// Prices like 0.10 have no exact binary representation, so the results can
+// be off by a cent after rounding.
+func FloatTotal(inv Invoice) (Total, error) {
+	subtotal := 0.0
+	for _, line := range inv.Lines {
+		price, err := strconv.ParseFloat(line.Price, 64)
+		if err != nil {
+			return Total{}, err
+		}
+		subtotal += price * float64(line.Quantity)
+	}
+	discountRate, err := strconv.ParseFloat(orZero(inv.Discount), 64)
+	if err != nil {
+		return Total{}, err
+	}
+	taxRate, err := strconv.ParseFloat(orZero(inv.Tax), 64)
+	if err != nil {
+		return Total{}, err
+	}
+	shipping, err := strconv.ParseFloat(orZero(inv.Shipping), 64)
+	if err != nil {
+		return Total{}, err
+	}
+
+	discount := roundCents(subtotal * discountRate / 100)
+	tax := roundCents((subtotal - discount) * taxRate / 100)
+	total := subtotal - discount + tax + shipping
+
+	return Total{
+		Subtotal: floatCents(subtotal),
+		Discount: floatCents(discount),
+		Tax:      floatCents(tax),
+		Total:    floatCents(total),
+	}, nil
+}
+
+// floatCents converts dollars to cents. It must round: a plain conversion
+// truncates, and 0.29 * 100 is 28.999999999999996.
+func floatCents(x float64) Cents {
+	return Cents(math.Round(x * 100))
+}
+
+// Fixed-point implementation
+
+// Cents is a fixed-point amount: an integer number of cents.
+type Cents int64
+
+// ParseCents parses a decimal amount with at most two decimals, such as
+// "19.99". Percents are parsed the same way, "8.25" giving 825 basis points.
+func ParseCents(s string) (Cents, error) {
+	whole, fraction, _ := strings.Cut(strings.TrimSpace(s), ".")
+	if len(fraction) > 2 {
+		return 0, fmt.Errorf("%q has more than two decimals", s)
+	}
+	fraction += strings.Repeat("0", 2-len(fraction))
+	n, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil || whole == "" || strings.HasPrefix(whole, "-") {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return Cents(n), nil
+}
+
+// String formats the cents as dollars, such as "19.99".
+func (c Cents) String() string {
+	sign := ""
+	if c < 0 {
+		sign, c = "-", -c
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, c/100, c%100)
+}
+
+// percentOf returns rate percent of c, rate in basis points (hundredths of a
+// percent), rounded to the cent half away from zero with integers only.
+func percentOf(c Cents, rate Cents) Cents {
+	const basis = 100 * 100 // 100% is 10000 basis points
+	return (c*rate + basis/2) / basis
+}
+
+// FixedTotal computes the invoice with integer cents: every step is exact,
+// and the only rounding is the one the invoice asks for.
+func FixedTotal(inv Invoice) (Total, error) {
+	var subtotal Cents
+	for _, line := range inv.Lines {
+		price, err := ParseCents(line.Price)
+		if err != nil {
+			return Total{}, err
+		}
+		subtotal += price * Cents(line.Quantity)
+	}
+	discountRate, err := ParseCents(orZero(inv.Discount))
+	if err != nil {
+		return Total{}, err
+	}
+	taxRate, err := ParseCents(orZero(inv.Tax))
+	if err != nil {
+		return Total{}, err
+	}
+	shipping, err := ParseCents(orZero(inv.Shipping))
+	if err != nil {
+		return Total{}, err
+	}
+
+	discount := percentOf(subtotal, discountRate)
+	tax := percentOf(subtotal-discount, taxRate)
+	return Total{
+		Subtotal: subtotal,
+		Discount: discount,
+		Tax:      tax,
+		Total:    subtotal - discount + tax + shipping,
+	}, nil
+}
+
+// big.Rat implementation
+
+// parseRat parses a decimal amount as an exact fraction.
+func parseRat(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	return r, nil
+}
+
+// RoundRat rounds an exact amount in dollars to the cent, half away from zero.
+func RoundRat(r *big.Rat) Cents {
+	cents := new(big.Rat).Mul(r, big.NewRat(100, 1))
+	// Quo truncates toward zero: add half a cent first, in the direction of
+	// the sign
+	half := big.NewRat(1, 2)
+	if cents.Sign() < 0 {
+		half.Neg(half)
+	}
+	cents.Add(cents, half)
+	q := new(big.Int).Quo(cents.Num(), cents.Denom())
+	return Cents(q.Int64())
+}
+
+// RatTotal computes the invoice with exact fractions. It is the slowest, but
+// any rate can be used, even one with more decimals than cents.
+func RatTotal(inv Invoice) (Total, error) {
+	subtotal := new(big.Rat)
+	for _, line := range inv.Lines {
+		price, err := parseRat(line.Price)
+		if err != nil {
+			return Total{}, err
+		}
+		subtotal.Add(subtotal, price.Mul(price, big.NewRat(int64(line.Quantity), 1)))
+	}
+	discountRate, err := parseRat(orZero(inv.Discount))
+	if err != nil {
+		return Total{}, err
+	}
+	taxRate, err := parseRat(orZero(inv.Tax))
+	if err != nil {
+		return Total{}, err
+	}
+	shipping, err := parseRat(orZero(inv.Shipping))
+	if err != nil {
+		return Total{}, err
+	}
+
+	hundred := big.NewRat(100, 1)
+	discount := new(big.Rat).Mul(subtotal, discountRate)
+	discount = ratFromCents(RoundRat(discount.Quo(discount, hundred)))
+	taxable := new(big.Rat).Sub(subtotal, discount)
+	tax := new(big.Rat).Mul(taxable, taxRate)
+	tax = ratFromCents(RoundRat(tax.Quo(tax, hundred)))
+
+	total := new(big.Rat).Add(taxable, tax)
+	total.Add(total, shipping)
+	return Total{
+		Subtotal: RoundRat(subtotal),
+		Discount: RoundRat(discount),
+		Tax:      RoundRat(tax),
+		Total:    RoundRat(total),
+	}, nil
+}
+
+func ratFromCents(c Cents) *big.Rat {
+	return big.NewRat(int64(c), 100)
+}
+
+func orZero(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return s
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"math/big"
+	"math/rand/v2"
+	"testing"
+)
+
+// invoiceTests are computed by hand, rounding the discount and the tax to the
+// cent, half away from zero
+var invoiceTests = []struct {
+	name string
+	inv  Invoice
+	want Total
+}{
+	{
+		name: "module 09 cart",
+		inv: Invoice{
+			Lines:    []Line{{"1200.00", 2}, {"150.00", 2}, {"50.00", 3}},
+			Discount: "10",
+			Tax:      "8.25",
+		},
+		// Tax: 2565.00 * 8.25% = 211.6125
+		want: Total{Subtotal: 285000, Discount: 28500, Tax: 21161, Total: 277661},
+	},
+	{
+		name: "half cent discount",
+		inv:  Invoice{Lines: []Line{{"1.15", 1}}, Discount: "50"},
+		// Discount: 0.575, rounded up
+		want: Total{Subtotal: 115, Discount: 58, Total: 57},
+	},
+	{
+		name: "half cent tax",
+		inv:  Invoice{Lines: []Line{{"10.05", 1}}, Tax: "10"},
+		// Tax: 1.005, rounded up
+		want: Total{Subtotal: 1005, Tax: 101, Total: 1106},
+	},
+	{
+		name: "ten dimes",
+		inv: Invoice{Lines: []Line{
+			{"0.10", 1}, {"0.10", 1}, {"0.10", 1}, {"0.10", 1}, {"0.10", 1},
+			{"0.10", 1}, {"0.10", 1}, {"0.10", 1}, {"0.10", 1}, {"0.10", 1},
+		}},
+		want: Total{Subtotal: 100, Total: 100},
+	},
+	{
+		name: "tax and shipping",
+		inv:  Invoice{Lines: []Line{{"19.99", 3}}, Tax: "7", Shipping: "4.99"},
+		// Tax: 59.97 * 7% = 4.1979
+		want: Total{Subtotal: 5997, Tax: 420, Total: 6916},
+	},
+}
+
+func TestFixedTotal(t *testing.T) {
+	for _, tt := range invoiceTests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FixedTotal(tt.inv)
+			if err != nil {
+				t.Fatalf("FixedTotal() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("FixedTotal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRatTotal(t *testing.T) {
+	for _, tt := range invoiceTests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RatTotal(tt.inv)
+			if err != nil {
+				t.Fatalf("RatTotal() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("RatTotal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFloatTotal documents the pitfall: float64 gets the half cents wrong,
+// because 1.15 is stored as 1.149999999999999911...
+func TestFloatTotal(t *testing.T) {
+	wrong := map[string]bool{"half cent discount": true, "half cent tax": true}
+	for _, tt := range invoiceTests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FloatTotal(tt.inv)
+			if err != nil {
+				t.Fatalf("FloatTotal() failed: %v", err)
+			}
+			if wrong[tt.name] {
+				if diff := got.Total - tt.want.Total; diff != 1 && diff != -1 {
+					t.Fatalf("FloatTotal() = %v, expected to be a cent off %v", got.Total, tt.want.Total)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Fatalf("FloatTotal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFixedAndRatAgree compares both exact implementations on random invoices
+func TestFixedAndRatAgree(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	cents := func(max int) string {
+		return Cents(rng.IntN(max)).String()
+	}
+	for range 1000 {
+		var inv Invoice
+		for range 1 + rng.IntN(5) {
+			inv.Lines = append(inv.Lines, Line{Price: cents(100000), Quantity: 1 + rng.IntN(10)})
+		}
+		inv.Discount = cents(5001) // Up to 50%
+		inv.Tax = cents(2501)      // Up to 25%
+		inv.Shipping = cents(5000)
+
+		fixed, err := FixedTotal(inv)
+		if err != nil {
+			t.Fatalf("FixedTotal(%+v) failed: %v", inv, err)
+		}
+		exact, err := RatTotal(inv)
+		if err != nil {
+			t.Fatalf("RatTotal(%+v) failed: %v", inv, err)
+		}
+		if fixed != exact {
+			t.Fatalf("%+v: FixedTotal() = %+v, RatTotal() = %+v", inv, fixed, exact)
+		}
+	}
+}
+
+// TestRatTotalPrecision uses a tax rate with three decimals, which cents
+// can't hold
+func TestRatTotalPrecision(t *testing.T) {
+	inv := Invoice{Lines: []Line{{"100.00", 1}}, Tax: "8.875"}
+	if _, err := FixedTotal(inv); err == nil {
+		t.Fatalf("FixedTotal() accepted a rate of 8.875%%")
+	}
+	got, err := RatTotal(inv)
+	if err != nil {
+		t.Fatalf("RatTotal() failed: %v", err)
+	}
+	want := Total{Subtotal: 10000, Tax: 888, Total: 10888}
+	if got != want {
+		t.Fatalf("RatTotal() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseCents(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Cents
+		ok   bool
+	}{
+		{"19.99", 1999, true},
+		{"5", 500, true},
+		{"0.5", 50, true},
+		{" 8.25 ", 825, true},
+		{"1.005", 0, false},
+		{"-1.00", 0, false},
+		{"12,50", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseCents(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseCents(%q) = %v, %v, want %v, ok %v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRoundRat(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Cents
+	}{
+		{"1.005", 101},
+		{"1.00499", 100},
+		{"-1.005", -101},
+		{"211.6125", 21161},
+		{"1/3", 33},
+	}
+	for _, tt := range tests {
+		r, _ := new(big.Rat).SetString(tt.in)
+		if got := RoundRat(r); got != tt.want {
+			t.Errorf("RoundRat(%s) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The states of the vending machine
+type state int
+
+const (
+	stateIdle       state = iota // Waiting for a coin
+	stateAccepting               // Credit inserted, waiting for more coins or a selection
+	stateDispensing              // Dropping the selected item
+	stateChange                  // Giving the change back
+)
+
+func (s state) String() string {
+	switch s {
+	case stateIdle:
+		return "idle"
+	case stateAccepting:
+		return "accepting coins"
+	case stateDispensing:
+		return "dispensing"
+	case stateChange:
+		return "giving change"
+	}
+	return "unknown"
+}
+
+// item is a slot of the machine. Amounts are in cents, so they add up exactly.
+type item struct {
+	name  string
+	price int
+	count int
+}
+
+// coins are the accepted coins in cents, largest first
+var coins = []int{100, 25, 10, 5}
+
+// maxCredit is the most credit the machine accepts
+const maxCredit = 500
+
+func main() {
+	slots := map[string]*item{
+		"A1": {name: "Cola", price: 125, count: 3},
+		"A2": {name: "Water", price: 90, count: 5},
+		"B1": {name: "Chips", price: 150, count: 1},
+		"B2": {name: "Candy bar", price: 65, count: 0},
+		"C1": {name: "Gum", price: 35, count: 10},
+	}
+	// The coins the machine holds to give change with
+	bank := map[int]int{100: 2, 25: 4, 10: 5, 5: 2}
+
+	current := stateIdle
+	credit := 0
+	selected := ""
+
+	fmt.Println("=== Vending Machine ===")
+	printSlots(slots)
+	printHelp()
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		// The dispensing and change states run without waiting for input
+		switch current {
+		case stateDispensing:
+			slot := slots[selected]
+			slot.count--
+			credit -= slot.price
+			fmt.Printf("*clunk* %s dispensed.\n", slot.name)
+			selected = ""
+			if credit > 0 {
+				current = stateChange
+			} else {
+				current = stateIdle
+			}
+			continue
+
+		case stateChange:
+			change, ok := makeChange(credit, bank)
+			if !ok {
+				// Doesn't happen: a sale checks the change first, and on
+				// cancel the inserted coins are still in the bank
+				fmt.Printf("Sorry, cannot give back %s. Please call the operator.\n", formatCents(credit))
+			} else {
+				for _, coin := range coins {
+					bank[coin] -= change[coin]
+				}
+				fmt.Printf("Change: %s (%s)\n", formatCents(credit), formatCoins(change))
+			}
+			credit = 0
+			current = stateIdle
+			continue
+		}
+
+		fmt.Printf("[%s, credit %s] > ", current, formatCents(credit))
+		if !scanner.Scan() {
+			fmt.Println()
+			if credit > 0 {
+				// Don't keep the coins of a customer who walked away
+				current = stateChange
+				continue
+			}
+			return
+		}
+		fields := strings.Fields(strings.ToUpper(scanner.Text()))
+		if len(fields) == 0 {
+			continue
+		}
+		command, arg := fields[0], ""
+		if len(fields) > 1 {
+			arg = fields[1]
+		}
+
+		// Commands available in every state
+		switch command {
+		case "HELP":
+			printHelp()
+			continue
+		case "LIST":
+			printSlots(slots)
+			continue
+		case "QUIT":
+			if credit > 0 {
+				fmt.Println("Please take your money back first: type cancel.")
+				continue
+			}
+			fmt.Println("Goodbye!")
+			return
+		}
+
+		switch current {
+		case stateIdle, stateAccepting:
+			switch command {
+			case "COIN":
+				coin, err := strconv.Atoi(arg)
+				switch {
+				case err != nil:
+					fmt.Printf("%q is not a coin. Insert 5, 10, 25 or 100.\n", arg)
+				case !isCoin(coin):
+					fmt.Printf("Coin of %d cents rejected. Insert 5, 10, 25 or 100.\n", coin)
+				case credit+coin > maxCredit:
+					fmt.Printf("Coin rejected: the credit can't exceed %s.\n", formatCents(maxCredit))
+				default:
+					credit += coin
+					bank[coin]++
+					current = stateAccepting
+				}
+
+			case "SELECT":
+				slot, ok := slots[arg]
+				switch {
+				case !ok:
+					fmt.Printf("There is no slot %q.\n", arg)
+				case slot.count == 0:
+					fmt.Printf("%s is sold out.\n", slot.name)
+				case credit < slot.price:
+					fmt.Printf("%s costs %s: insert %s more.\n", slot.name, formatCents(slot.price), formatCents(slot.price-credit))
+				default:
+					// Only sell when the change can be given
+					if _, ok := makeChange(credit-slot.price, bank); !ok {
+						fmt.Printf("Cannot give %s of change: insert the exact amount or cancel.\n", formatCents(credit-slot.price))
+						continue
+					}
+					selected = arg
+					current = stateDispensing
+				}
+
+			case "CANCEL":
+				if credit == 0 {
+					fmt.Println("Nothing to return.")
+					continue
+				}
+				fmt.Println("Returning your money.")
+				current = stateChange
+
+			default:
+				fmt.Printf("Unknown command %q, type help.\n", strings.ToLower(command))
+			}
+		}
+	}
+}
+
+// isCoin reports whether a value is an accepted coin
+func isCoin(value int) bool {
+	for _, coin := range coins {
+		if value == coin {
+			return true
+		}
+	}
+	return false
+}
+
+// makeChange finds the coins giving amount with the coins in the bank. It
+// tries the largest coins first and backtracks when it gets stuck: with no
+// nickel, 30 cents can't start with a quarter but can be three dimes.
+func makeChange(amount int, bank map[int]int) (map[int]int, bool) {
+	change := make(map[int]int)
+	var search func(remaining, index int) bool
+	search = func(remaining, index int) bool {
+		if remaining == 0 {
+			return true
+		}
+		if index == len(coins) {
+			return false
+		}
+		coin := coins[index]
+		most := min(remaining/coin, bank[coin])
+		for n := most; n >= 0; n-- {
+			change[coin] = n
+			if search(remaining-n*coin, index+1) {
+				return true
+			}
+		}
+		change[coin] = 0
+		return false
+	}
+	return change, search(amount, 0)
+}
+
+func formatCents(cents int) string {
+	return fmt.Sprintf("$%d.%02d", cents/100, cents%100)
+}
+
+func formatCoins(change map[int]int) string {
+	var parts []string
+	for _, coin := range coins {
+		if change[coin] > 0 {
+			parts = append(parts, fmt.Sprintf("%d x %s", change[coin], formatCents(coin)))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func printSlots(slots map[string]*item) {
+	for _, name := range slices.Sorted(maps.Keys(slots)) {
+		slot := slots[name]
+		stock := fmt.Sprintf("%d left", slot.count)
+		if slot.count == 0 {
+			stock = "SOLD OUT"
+		}
+		fmt.Printf("  %s  %-10s %s  %s\n", name, slot.name, formatCents(slot.price), stock)
+	}
+}
+
+func printHelp() {
+	fmt.Println("Commands: coin <5|10|25|100>, select <slot>, cancel, list, help, quit")
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// alignment of the text in a column
+type alignment int
+
+const (
+	alignLeft alignment = iota
+	alignRight
+	alignCenter
+)
+
+// style holds the characters used to draw the tables and charts
+type style struct {
+	horizontal, vertical                  string
+	topLeft, topMiddle, topRight          string
+	middleLeft, middle, middleRight       string
+	bottomLeft, bottomMiddle, bottomRight string
+	ellipsis                              string   // Replaces the end of truncated text
+	blocks                                []string // Bar pieces from 1/n to a full block
+}
+
+var styles = map[string]style{
+	"ascii": {
+		horizontal: "-", vertical: "|",
+		topLeft: "+", topMiddle: "+", topRight: "+",
+		middleLeft: "+", middle: "+", middleRight: "+",
+		bottomLeft: "+", bottomMiddle: "+", bottomRight: "+",
+		ellipsis: "~",
+		blocks:   []string{"#"},
+	},
+	"unicode": {
+		horizontal: "─", vertical: "│",
+		topLeft: "┌", topMiddle: "┬", topRight: "┐",
+		middleLeft: "├", middle: "┼", middleRight: "┤",
+		bottomLeft: "└", bottomMiddle: "┴", bottomRight: "┘",
+		ellipsis: "…",
+		// Eighths of a block give bars a finer resolution than a character
+		blocks: []string{"▏", "▎", "▍", "▌", "▋", "▊", "▉", "█"},
+	},
+}
+
+func main() {
+	styleName := flag.String("style", "unicode", "drawing characters: ascii or unicode")
+	maxWidth := flag.Int("width", 18, "maximum width of a column, longer text is truncated")
+	numbers := flag.String("align", "right", "alignment of the numeric columns: left, right or center")
+	barWidth := flag.Int("bar", 40, "width of the longest bar of the charts")
+	flag.Parse()
+
+	st, ok := styles[*styleName]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown style %q, use ascii or unicode\n", *styleName)
+		os.Exit(2)
+	}
+	var numberAlign alignment
+	switch *numbers {
+	case "left":
+		numberAlign = alignLeft
+	case "right":
+		numberAlign = alignRight
+	case "center":
+		numberAlign = alignCenter
+	default:
+		fmt.Fprintf(os.Stderr, "unknown alignment %q, use left, right or center\n", *numbers)
+		os.Exit(2)
+	}
+	if *maxWidth < 3 || *barWidth < 1 {
+		fmt.Fprintln(os.Stderr, "the width must be at least 3 and the bar at least 1")
+		os.Exit(2)
+	}
+
+	// The student grades of module 05
+	grades := map[string][]int{
+		"Alice":                     {92, 88, 95, 89},
+		"Bob":                       {75, 82, 79},
+		"Charlie":                   {90, 93, 88, 97, 91},
+		"Diana":                     {65, 72, 80, 75},
+		"Zoë":                       {58, 64, 71},
+		"Maximilian Alexander Long": {85, 79, 90, 84},
+	}
+
+	// Rank the students by average
+	students := make([]string, 0, len(grades))
+	averages := make(map[string]float64)
+	for student, studentGrades := range grades {
+		total := 0
+		for _, grade := range studentGrades {
+			total += grade
+		}
+		averages[student] = float64(total) / float64(len(studentGrades))
+		students = append(students, student)
+	}
+	slices.SortFunc(students, func(a, b string) int {
+		return cmp.Or(cmp.Compare(averages[b], averages[a]), cmp.Compare(a, b))
+	})
+
+	headers := []string{"#", "Student", "Grades", "Count", "Average", "Best"}
+	aligns := []alignment{alignRight, alignLeft, alignLeft, numberAlign, numberAlign, numberAlign}
+	var rows [][]string
+	for rank, student := range students {
+		best := 0
+		texts := make([]string, 0, len(grades[student]))
+		for _, grade := range grades[student] {
+			best = max(best, grade)
+			texts = append(texts, fmt.Sprint(grade))
+		}
+		rows = append(rows, []string{
+			fmt.Sprint(rank + 1),
+			student,
+			strings.Join(texts, " "),
+			fmt.Sprint(len(grades[student])),
+			fmt.Sprintf("%.2f", averages[student]),
+			fmt.Sprint(best),
+		})
+	}
+
+	fmt.Println("Student Grades")
+	fmt.Print(renderTable(headers, rows, aligns, *maxWidth, st))
+
+	fmt.Println("\nAverage Grade")
+	values := make([]float64, len(students))
+	for i, student := range students {
+		values[i] = averages[student]
+	}
+	fmt.Print(renderBars(students, values, 100, *barWidth, *maxWidth, st))
+
+	// Count the grades in buckets of ten points
+	buckets := []string{"50s", "60s", "70s", "80s", "90s"}
+	counts := make([]int, len(buckets))
+	for _, studentGrades := range grades {
+		for _, grade := range studentGrades {
+			index := min(max(grade/10-5, 0), len(buckets)-1)
+			counts[index]++
+		}
+	}
+	fmt.Println("\nGrade Distribution")
+	fmt.Print(renderColumns(buckets, counts, st))
+}
+
+// renderTable draws rows of cells with a header. Each column is as wide as
+// its longest cell, up to maxWidth.
+func renderTable(headers []string, rows [][]string, aligns []alignment, maxWidth int, st style) string {
+	widths := make([]int, len(headers))
+	for col, header := range headers {
+		widths[col] = min(utf8.RuneCountInString(header), maxWidth)
+	}
+	for _, row := range rows {
+		for col, cell := range row {
+			widths[col] = min(max(widths[col], utf8.RuneCountInString(cell)), maxWidth)
+		}
+	}
+
+	var b strings.Builder
+	border := func(left, middle, right string) {
+		b.WriteString(left)
+		for col, width := range widths {
+			if col > 0 {
+				b.WriteString(middle)
+			}
+			b.WriteString(strings.Repeat(st.horizontal, width+2))
+		}
+		b.WriteString(right + "\n")
+	}
+	line := func(cells []string, aligns []alignment) {
+		b.WriteString(st.vertical)
+		for col, width := range widths {
+			cell := truncate(cells[col], width, st.ellipsis)
+			b.WriteString(" " + pad(cell, width, aligns[col]) + " " + st.vertical)
+		}
+		b.WriteString("\n")
+	}
+
+	headerAligns := make([]alignment, len(headers))
+	for col := range headerAligns {
+		headerAligns[col] = alignCenter
+	}
+
+	border(st.topLeft, st.topMiddle, st.topRight)
+	line(headers, headerAligns)
+	border(st.middleLeft, st.middle, st.middleRight)
+	for _, row := range rows {
+		line(row, aligns)
+	}
+	border(st.bottomLeft, st.bottomMiddle, st.bottomRight)
+	return b.String()
+}
+
+// renderBars draws a horizontal bar per value, scaled so that scale fills
+// barWidth characters
+func renderBars(labels []string, values []float64, scale float64, barWidth, maxLabel int, st style) string {
+	labelWidth := 0
+	for _, label := range labels {
+		labelWidth = max(labelWidth, min(utf8.RuneCountInString(label), maxLabel))
+	}
+
+	var b strings.Builder
+	for i, label := range labels {
+		// The length of the bar in pieces: a piece is a fraction of a character
+		pieces := len(st.blocks)
+		length := int(values[i] / scale * float64(barWidth*pieces))
+		full, rest := length/pieces, length%pieces
+
+		bar := strings.Repeat(st.blocks[pieces-1], full)
+		if rest > 0 {
+			bar += st.blocks[rest-1]
+		}
+		b.WriteString(pad(truncate(label, labelWidth, st.ellipsis), labelWidth, alignRight))
+		b.WriteString(" " + st.vertical + bar)
+		fmt.Fprintf(&b, " %.1f\n", values[i])
+	}
+	b.WriteString(strings.Repeat(" ", labelWidth+1) + st.bottomLeft + strings.Repeat(st.horizontal, barWidth) + "\n")
+	return b.String()
+}
+
+// renderColumns draws a vertical bar chart, one line per count from the top
+func renderColumns(labels []string, counts []int, st style) string {
+	highest := 0
+	for _, count := range counts {
+		highest = max(highest, count)
+	}
+	const columnWidth = 5
+
+	var b strings.Builder
+	for level := highest; level >= 1; level-- {
+		fmt.Fprintf(&b, "%2d %s", level, st.vertical)
+		for _, count := range counts {
+			cell := ""
+			if count >= level {
+				cell = strings.Repeat(st.blocks[len(st.blocks)-1], 3)
+			}
+			b.WriteString(pad(cell, columnWidth, alignCenter))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("   " + st.bottomLeft + strings.Repeat(st.horizontal, columnWidth*len(counts)) + "\n    ")
+	for _, label := range labels {
+		b.WriteString(pad(label, columnWidth, alignCenter))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// truncate shortens text to width characters, ending with the ellipsis
+func truncate(text string, width int, ellipsis string) string {
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:width-utf8.RuneCountInString(ellipsis)]) + ellipsis
+}
+
+// pad fills text with spaces up to width characters. The width is counted in
+// runes, not bytes: %-10s would misalign "Zoë", whose ë takes two bytes.
+func pad(text string, width int, align alignment) string {
+	gap := width - utf8.RuneCountInString(text)
+	if gap <= 0 {
+		return text
+	}
+	switch align {
+	case alignRight:
+		return strings.Repeat(" ", gap) + text
+	case alignCenter:
+		left := gap / 2
+		return strings.Repeat(" ", left) + text + strings.Repeat(" ", gap-left)
+	}
+	return text + strings.Repeat(" ", gap)
+}
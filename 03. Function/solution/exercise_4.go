@@ -0,0 +1,211 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Stats counts how the calls of a memoized function were answered
+type Stats struct {
+	Hits      int // Answered from the cache
+	Misses    int // Computed by the wrapped function
+	Evictions int // Results dropped to stay within the limit
+}
+
+// HitRate returns the share of the calls answered from the cache
+func (s Stats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// entry is a cached result. The ready channel is closed once the value is
+// computed, so the callers asking for the same key meanwhile wait for it
+// instead of computing it again.
+type entry[K comparable, V any] struct {
+	key    K
+	value  V
+	failed bool // fn panicked: there is no value, the callers waiting compute it again
+	ready  chan struct{}
+}
+
+// Memoize wraps a pure function with a cache of its results. The cache keeps
+// at most limit results, dropping the least recently used one when full; a
+// limit of 0 means no limit. The returned function is safe for concurrent use,
+// and stats reports its hits, misses and evictions.
+//
+// The cache, the recency list and the counters are variables of Memoize: the
+// returned closures capture them, so they live as long as the closures do.
+func Memoize[K comparable, V any](fn func(K) V, limit int) (memoized func(K) V, stats func() Stats) {
+	var (
+		mu      sync.Mutex
+		cache   = make(map[K]*list.Element)
+		recency = list.New() // Most recently used at the front
+		counts  Stats
+	)
+
+	memoized = func(key K) V {
+		mu.Lock()
+		if element, ok := cache[key]; ok {
+			counts.Hits++
+			recency.MoveToFront(element)
+			e := element.Value.(*entry[K, V])
+			mu.Unlock()
+			<-e.ready
+			if e.failed {
+				return memoized(key)
+			}
+			return e.value
+		}
+
+		counts.Misses++
+		e := &entry[K, V]{key: key, ready: make(chan struct{})}
+		cache[key] = recency.PushFront(e)
+		if limit > 0 && recency.Len() > limit {
+			oldest := recency.Back()
+			recency.Remove(oldest)
+			delete(cache, oldest.Value.(*entry[K, V]).key)
+			counts.Evictions++
+		}
+		// Unlock before computing: fn may be slow, and a recursive fn calls
+		// memoized again
+		mu.Unlock()
+
+		// If fn panics, the entry is removed before the panic goes on: left in
+		// the cache, it would make every later call with the key wait forever
+		defer func() {
+			if r := recover(); r != nil {
+				mu.Lock()
+				if element, ok := cache[key]; ok && element.Value.(*entry[K, V]) == e {
+					recency.Remove(element)
+					delete(cache, key)
+				}
+				mu.Unlock()
+				e.failed = true
+				close(e.ready)
+				panic(r)
+			}
+		}()
+		e.value = fn(key)
+		close(e.ready)
+		return e.value
+	}
+
+	stats = func() Stats {
+		mu.Lock()
+		defer mu.Unlock()
+		return counts
+	}
+	return memoized, stats
+}
+
+// slowFibonacci computes the nth Fibonacci number the naive way, calling
+// itself twice per number
+func slowFibonacci(n int) uint64 {
+	if n < 2 {
+		return uint64(n)
+	}
+	return slowFibonacci(n-1) + slowFibonacci(n-2)
+}
+
+// Shapes used as cache keys: a struct of comparable fields is comparable
+type rectangle struct{ width, height float64 }
+type triangle struct{ a, b, c float64 }
+
+// The area calculators sleep to stand for an expensive computation
+const work = 50 * time.Millisecond
+
+func circleArea(radius float64) float64 {
+	time.Sleep(work)
+	return math.Pi * radius * radius
+}
+
+func rectangleArea(r rectangle) float64 {
+	time.Sleep(work)
+	return r.width * r.height
+}
+
+// triangleArea uses Heron's formula with the lengths of the three sides
+func triangleArea(t triangle) float64 {
+	time.Sleep(work)
+	s := (t.a + t.b + t.c) / 2
+	return math.Sqrt(s * (s - t.a) * (s - t.b) * (s - t.c))
+}
+
+func printStats(name string, s Stats) {
+	fmt.Printf("  %-10s hits %3d, misses %3d, evictions %2d, hit rate %5.1f%%\n",
+		name, s.Hits, s.Misses, s.Evictions, s.HitRate()*100)
+}
+
+func main() {
+	fmt.Println("=== Fibonacci ===")
+	start := time.Now()
+	fmt.Printf("Naive fib(35) = %d in %v\n", slowFibonacci(35), time.Since(start).Round(time.Millisecond))
+
+	// The memoized function calls itself through the variable, so the
+	// recursive calls use the cache too
+	var fibonacci func(int) uint64
+	fibonacci, fibStats := Memoize(func(n int) uint64 {
+		if n < 2 {
+			return uint64(n)
+		}
+		return fibonacci(n-1) + fibonacci(n-2)
+	}, 0)
+
+	start = time.Now()
+	fmt.Printf("Memoized fib(35) = %d in %v\n", fibonacci(35), time.Since(start).Round(time.Microsecond))
+	fmt.Printf("Memoized fib(90) = %d\n", fibonacci(90))
+	printStats("fibonacci", fibStats())
+
+	fmt.Println("\n=== Shape areas ===")
+	circle, circleStats := Memoize(circleArea, 0)
+	rect, rectStats := Memoize(rectangleArea, 0)
+	tri, triStats := Memoize(triangleArea, 0)
+
+	for round := 1; round <= 2; round++ {
+		start = time.Now()
+		fmt.Printf("Round %d:\n", round)
+		fmt.Printf("  circle r=2:          %.2f\n", circle(2))
+		fmt.Printf("  rectangle 3x4:       %.2f\n", rect(rectangle{3, 4}))
+		fmt.Printf("  triangle 3, 4, 5:    %.2f\n", tri(triangle{3, 4, 5}))
+		fmt.Printf("  took %v\n", time.Since(start).Round(time.Millisecond))
+	}
+	printStats("circle", circleStats())
+	printStats("rectangle", rectStats())
+	printStats("triangle", triStats())
+
+	fmt.Println("\n=== Cache limit ===")
+	// A cache of three radii: asking for a fourth drops the least recently used
+	limited, limitedStats := Memoize(circleArea, 3)
+	for _, radius := range []float64{1, 2, 3, 1, 4, 2, 1} {
+		before := limitedStats()
+		limited(radius)
+		result := "miss"
+		if limitedStats().Hits > before.Hits {
+			result = "hit"
+		}
+		fmt.Printf("  r=%v: %s\n", radius, result)
+	}
+	printStats("limited", limitedStats())
+
+	fmt.Println("\n=== Concurrent calls ===")
+	// 100 goroutines ask for 5 radii: each area is computed once, the other
+	// callers of a radius wait for it
+	shared, sharedStats := Memoize(circleArea, 0)
+	var wg sync.WaitGroup
+	start = time.Now()
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			shared(float64(i % 5))
+		}()
+	}
+	wg.Wait()
+	fmt.Printf("  100 calls in %v\n", time.Since(start).Round(time.Millisecond))
+	printStats("shared", sharedStats())
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// Client sends requests to a service, retrying the failed ones. Its fields
+// are unexported: a Client is only configured through the options given to
+// NewClient, so it can't be changed once created.
+type Client struct {
+	name    string
+	timeout time.Duration
+	retries int
+	backoff time.Duration
+	logger  *log.Logger
+}
+
+// Option configures a Client. An option is a function that changes the
+// client under construction, and returns an error for an invalid value.
+type Option func(*Client) error
+
+// WithTimeout limits the time of one attempt
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) error {
+		if timeout <= 0 {
+			return fmt.Errorf("timeout must be positive, got %v", timeout)
+		}
+		c.timeout = timeout
+		return nil
+	}
+}
+
+// WithRetries sets how many times a failed request is sent again, waiting
+// backoff before the first retry and twice as long before each next one
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) error {
+		if retries < 0 {
+			return fmt.Errorf("retries can't be negative, got %d", retries)
+		}
+		c.retries = retries
+		c.backoff = backoff
+		return nil
+	}
+}
+
+// WithLogger sets where the client logs its attempts
+func WithLogger(logger *log.Logger) Option {
+	return func(c *Client) error {
+		if logger == nil {
+			return errors.New("logger can't be nil")
+		}
+		c.logger = logger
+		return nil
+	}
+}
+
+// WithOptions groups several options into one, to share a configuration
+func WithOptions(opts ...Option) Option {
+	return func(c *Client) error {
+		for _, opt := range opts {
+			if err := opt(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// NewClient creates a client with default settings, then applies the
+// options in order: a later option overrides an earlier one
+func NewClient(name string, opts ...Option) (*Client, error) {
+	c := &Client{
+		name:    name,
+		timeout: time.Second,
+		retries: 0,
+		logger:  log.New(io.Discard, "", 0),
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, fmt.Errorf("client %s: %w", name, err)
+		}
+	}
+	return c, nil
+}
+
+// ErrAttemptTimeout is returned for an attempt that took too long
+var ErrAttemptTimeout = errors.New("attempt timed out")
+
+// Do runs a request, given the number of the attempt, until it succeeds or
+// the retries are used up
+func (c *Client) Do(request func(attempt int) error) error {
+	wait := c.backoff
+	var err error
+	for attempt := 1; attempt <= c.retries+1; attempt++ {
+		if attempt > 1 {
+			c.logger.Printf("%s: retrying in %v", c.name, wait)
+			time.Sleep(wait)
+			wait *= 2
+		}
+
+		err = c.attempt(attempt, request)
+		if err == nil {
+			c.logger.Printf("%s: attempt %d succeeded", c.name, attempt)
+			return nil
+		}
+		c.logger.Printf("%s: attempt %d failed: %v", c.name, attempt, err)
+	}
+	return fmt.Errorf("%s: giving up after %d attempts: %w", c.name, c.retries+1, err)
+}
+
+// attempt runs the request once, stopping to wait for it after the timeout
+func (c *Client) attempt(attempt int, request func(int) error) error {
+	// The channel is buffered so the request can finish and exit after a
+	// timeout, when nobody receives anymore
+	done := make(chan error, 1)
+	go func() {
+		done <- request(attempt)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(c.timeout):
+		return fmt.Errorf("%w after %v", ErrAttemptTimeout, c.timeout)
+	}
+}
+
+func (c *Client) String() string {
+	return fmt.Sprintf("%s (timeout %v, %d retries, backoff %v)", c.name, c.timeout, c.retries, c.backoff)
+}
+
+// flaky returns a request failing until the given attempt
+func flaky(succeedAt int) func(int) error {
+	return func(attempt int) error {
+		if attempt < succeedAt {
+			return errors.New("service unavailable")
+		}
+		return nil
+	}
+}
+
+// slow returns a request taking a given time
+func slow(duration time.Duration) func(int) error {
+	return func(int) error {
+		time.Sleep(duration)
+		return nil
+	}
+}
+
+func main() {
+	logger := log.New(os.Stdout, "  ", log.Lmicroseconds)
+
+	fmt.Println("=== Defaults ===")
+	plain, _ := NewClient("plain")
+	fmt.Println(plain)
+	fmt.Println("  flaky request:", plain.Do(flaky(2)))
+
+	fmt.Println("\n=== With options ===")
+	resilient, err := NewClient("resilient",
+		WithTimeout(200*time.Millisecond),
+		WithRetries(3, 50*time.Millisecond),
+		WithLogger(logger),
+	)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(resilient)
+	fmt.Println("  flaky request:", resilient.Do(flaky(3)))
+	fmt.Println("  slow request:", resilient.Do(slow(time.Second)))
+
+	fmt.Println("\n=== Shared configuration ===")
+	// A group of options is itself an option, and later options override it
+	production := WithOptions(WithTimeout(5*time.Second), WithRetries(5, time.Second), WithLogger(logger))
+	payments, _ := NewClient("payments", production)
+	search, _ := NewClient("search", production, WithRetries(1, 10*time.Millisecond))
+	fmt.Println(payments)
+	fmt.Println(search)
+
+	fmt.Println("\n=== Invalid options ===")
+	if _, err := NewClient("broken", WithTimeout(0)); err != nil {
+		fmt.Println("Error:", err)
+	}
+	if _, err := NewClient("broken", WithRetries(-1, 0)); err != nil {
+		fmt.Println("Error:", err)
+	}
+	if _, err := NewClient("broken", production, WithLogger(nil)); err != nil {
+		fmt.Println("Error:", err)
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ===== Deferred cleanup =====
+
+// resource stands for something to release, such as a file or a lock
+type resource struct {
+	name string
+}
+
+func open(name string) *resource {
+	fmt.Printf("  open %s\n", name)
+	return &resource{name: name}
+}
+
+func (r *resource) Close() {
+	fmt.Printf("  close %s\n", r.name)
+}
+
+// cleanupOrder opens three resources: the deferred calls run when the
+// function returns, last deferred first, so each resource is closed before
+// the ones it may depend on
+func cleanupOrder() {
+	db := open("database")
+	defer db.Close()
+	tx := open("transaction")
+	defer tx.Close()
+	file := open("export file")
+	defer file.Close()
+	fmt.Println("  working...")
+}
+
+// argumentsAtDefer shows that the arguments of a deferred call are evaluated
+// when defer runs, while a deferred closure reads the variables when it runs
+func argumentsAtDefer() {
+	count := 1
+	defer fmt.Println("  deferred call sees count =", count)
+	defer func() { fmt.Println("  deferred closure sees count =", count) }()
+	count = 2
+}
+
+// deferredResult doubles its named result after the return statement has
+// set it: a deferred closure can change what the caller receives
+func deferredResult() (result int) {
+	defer func() { result *= 2 }()
+	return 21
+}
+
+// cleanupOnPanic shows that deferred calls still run when the function panics
+func cleanupOnPanic() {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Println("  recovered:", r)
+		}
+	}()
+	lock := open("lock")
+	defer lock.Close()
+	panic("something went wrong while holding the lock")
+}
+
+// ===== Panics inside a library =====
+
+// parseError is the panic value of the parser. Only this type is recovered:
+// any other panic is a bug and keeps going up.
+type parseError struct {
+	pos int
+	msg string
+}
+
+// parser evaluates arithmetic expressions such as "2 * (3 + 4)". Deep inside
+// the recursion, reporting a problem with a panic is simpler than returning
+// an error from every function; Evaluate turns it back into an error, so the
+// panics never leave the package. encoding/json and text/template do the same.
+type parser struct {
+	input string
+	pos   int
+}
+
+// Evaluate returns the value of an expression
+func Evaluate(expression string) (value float64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			perr, ok := r.(parseError)
+			if !ok {
+				panic(r)
+			}
+			err = fmt.Errorf("position %d: %s", perr.pos, perr.msg)
+		}
+	}()
+
+	p := &parser{input: expression}
+	value = p.expression()
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		p.fail("unexpected %q", p.input[p.pos])
+	}
+	return value, nil
+}
+
+func (p *parser) fail(format string, args ...any) {
+	panic(parseError{pos: p.pos, msg: fmt.Sprintf(format, args...)})
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// next returns the next character without consuming it, 0 at the end
+func (p *parser) next() byte {
+	p.skipSpaces()
+	if p.pos == len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+// expression = term { ("+" | "-") term }
+func (p *parser) expression() float64 {
+	value := p.term()
+	for {
+		switch p.next() {
+		case '+':
+			p.pos++
+			value += p.term()
+		case '-':
+			p.pos++
+			value -= p.term()
+		default:
+			return value
+		}
+	}
+}
+
+// term = factor { ("*" | "/") factor }
+func (p *parser) term() float64 {
+	value := p.factor()
+	for {
+		switch p.next() {
+		case '*':
+			p.pos++
+			value *= p.factor()
+		case '/':
+			p.pos++
+			divisor := p.factor()
+			if divisor == 0 {
+				p.fail("division by zero")
+			}
+			value /= divisor
+		default:
+			return value
+		}
+	}
+}
+
+// factor = number | "(" expression ")" | "-" factor
+func (p *parser) factor() float64 {
+	switch c := p.next(); {
+	case c == '(':
+		p.pos++
+		value := p.expression()
+		if p.next() != ')' {
+			p.fail("missing )")
+		}
+		p.pos++
+		return value
+	case c == '-':
+		p.pos++
+		return -p.factor()
+	case unicode.IsDigit(rune(c)):
+		start := p.pos
+		for p.pos < len(p.input) && (unicode.IsDigit(rune(p.input[p.pos])) || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		value, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			p.fail("invalid number %q", p.input[start:p.pos])
+		}
+		return value
+	case c == 0:
+		p.fail("unexpected end of expression")
+	default:
+		p.fail("unexpected %q", c)
+	}
+	return 0 // Not reached: fail panics
+}
+
+// ===== SafeCall =====
+
+// PanicError is the error returned by SafeCall when the function panics
+type PanicError struct {
+	Value any    // The value given to panic
+	Stack []byte // The stack of the goroutine when it panicked
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value when it is an error, such as a
+// runtime.Error, so errors.Is and errors.As see it
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// SafeCall runs fn and turns a panic into a *PanicError, so a failing call
+// can't crash the program. It is meant for code you don't control, such as
+// plugins or handlers; in your own code, return errors instead.
+func SafeCall[T any](fn func() (T, error)) (result T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			// The stack is taken in the deferred function, before it unwinds,
+			// so it still shows where the panic happened
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
+func main() {
+	fmt.Println("=== Cleanup order ===")
+	cleanupOrder()
+
+	fmt.Println("\n=== Arguments are evaluated at defer ===")
+	argumentsAtDefer()
+
+	fmt.Println("\n=== Deferred closures change named results ===")
+	fmt.Println("  deferredResult() =", deferredResult())
+
+	fmt.Println("\n=== Cleanup runs on panic ===")
+	cleanupOnPanic()
+
+	fmt.Println("\n=== Panics inside a library become errors ===")
+	for _, expression := range []string{"2 * (3 + 4)", "-1.5 + 10 / 4", "2 * (3 + 4", "8 / (2 - 2)", "3 + x"} {
+		value, err := Evaluate(expression)
+		if err != nil {
+			fmt.Printf("  %-15s error: %v\n", expression, err)
+			continue
+		}
+		fmt.Printf("  %-15s = %g\n", expression, value)
+	}
+
+	fmt.Println("\n=== SafeCall ===")
+	calls := []struct {
+		name string
+		fn   func() (int, error)
+	}{
+		{"works", func() (int, error) { return 42, nil }},
+		{"returns an error", func() (int, error) { return 0, errors.New("not available") }},
+		{"panics with a string", func() (int, error) { panic("plugin is broken") }},
+		{"indexes out of range", func() (int, error) {
+			var values []int
+			index := 3
+			return values[index], nil
+		}},
+		{"writes a nil map", func() (int, error) {
+			var counts map[string]int
+			counts["x"]++
+			return counts["x"], nil
+		}},
+	}
+	for _, call := range calls {
+		result, err := SafeCall(call.fn)
+
+		var panicErr *PanicError
+		var runtimeErr runtime.Error
+		switch {
+		case err == nil:
+			fmt.Printf("  %-22s result %d\n", call.name, result)
+		case errors.As(err, &runtimeErr):
+			// Checked before PanicError: a runtime error is a PanicError too
+			fmt.Printf("  %-22s %v\n", call.name, runtimeErr)
+		case errors.As(err, &panicErr):
+			fmt.Printf("  %-22s recovered %v, %s\n", call.name, panicErr.Value, panicFrame(panicErr.Stack))
+		default:
+			fmt.Printf("  %-22s error: %v\n", call.name, err)
+		}
+	}
+	fmt.Println("The program is still running.")
+}
+
+// panicFrame returns the function that called panic, read from a stack trace
+func panicFrame(stack []byte) string {
+	lines := strings.Split(string(stack), "\n")
+	for i, line := range lines {
+		// The frame after panic is the function that panicked
+		if strings.HasPrefix(line, "panic(") && i+2 < len(lines) {
+			return "raised in " + lines[i+2]
+		}
+	}
+	return "raised in an unknown function"
+}
@@ -1,10 +1,18 @@
 package main
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 )
 
+var (
+	// ErrStackEmpty is returned when reading from an empty stack
+	ErrStackEmpty = errors.New("stack is empty")
+	// ErrStackFull is returned when pushing to a bounded stack at capacity
+	ErrStackFull = errors.New("stack is full")
+)
+
 // Node represents an element in the stack
 type Node struct {
 	Value interface{}
@@ -29,7 +37,7 @@ func (s *Stack) Push(value interface{}) {
 // Pop removes and returns the top value from the stack
 func (s *Stack) Pop() (interface{}, error) {
 	if s.size == 0 {
-		return nil, errors.New("stack is empty")
+		return nil, ErrStackEmpty
 	}
 
 	value := s.top.Value
@@ -42,7 +50,7 @@ func (s *Stack) Pop() (interface{}, error) {
 // Peek returns the top value without removing it
 func (s *Stack) Peek() (interface{}, error) {
 	if s.size == 0 {
-		return nil, errors.New("stack is empty")
+		return nil, ErrStackEmpty
 	}
 
 	return s.top.Value, nil
@@ -58,6 +66,96 @@ func (s *Stack) IsEmpty() bool {
 	return s.size == 0
 }
 
+// minNode stores, with each value, the minimum of the values below it
+type minNode[T cmp.Ordered] struct {
+	value T
+	min   T
+	next  *minNode[T]
+}
+
+// MinStack is a stack that returns its minimum in O(1). Every node remembers
+// the minimum at the time it was pushed, so popping a node restores the
+// previous minimum without searching.
+type MinStack[T cmp.Ordered] struct {
+	top  *minNode[T]
+	size int
+}
+
+// Push adds a new value to the top of the stack
+func (s *MinStack[T]) Push(value T) {
+	node := &minNode[T]{value: value, min: value, next: s.top}
+	if s.top != nil {
+		node.min = min(value, s.top.min)
+	}
+	s.top = node
+	s.size++
+}
+
+// Pop removes and returns the top value from the stack
+func (s *MinStack[T]) Pop() (T, error) {
+	if s.top == nil {
+		var zero T
+		return zero, ErrStackEmpty
+	}
+
+	value := s.top.value
+	s.top = s.top.next
+	s.size--
+
+	return value, nil
+}
+
+// GetMin returns the smallest value in the stack
+func (s *MinStack[T]) GetMin() (T, error) {
+	if s.top == nil {
+		var zero T
+		return zero, ErrStackEmpty
+	}
+
+	return s.top.min, nil
+}
+
+// Size returns the number of elements in the stack
+func (s *MinStack[T]) Size() int {
+	return s.size
+}
+
+// BoundedStack is a stack with a maximum size
+type BoundedStack struct {
+	stack    Stack
+	capacity int
+}
+
+// NewBoundedStack creates a stack holding at most capacity elements
+func NewBoundedStack(capacity int) *BoundedStack {
+	return &BoundedStack{capacity: capacity}
+}
+
+// Push adds a new value to the top of the stack, or returns ErrStackFull
+func (s *BoundedStack) Push(value interface{}) error {
+	if s.IsFull() {
+		return fmt.Errorf("pushing %v: %w (capacity %d)", value, ErrStackFull, s.capacity)
+	}
+
+	s.stack.Push(value)
+	return nil
+}
+
+// Pop removes and returns the top value from the stack
+func (s *BoundedStack) Pop() (interface{}, error) {
+	return s.stack.Pop()
+}
+
+// Size returns the number of elements in the stack
+func (s *BoundedStack) Size() int {
+	return s.stack.Size()
+}
+
+// IsFull returns true if the stack is at capacity
+func (s *BoundedStack) IsFull() bool {
+	return s.stack.Size() >= s.capacity
+}
+
 func main() {
 	stack := Stack{}
 
@@ -81,4 +179,39 @@ func main() {
 	// Try to pop from empty stack
 	_, err := stack.Pop()
 	fmt.Println("Error:", err)
+
+	// MinStack: the minimum follows pushes and pops
+	fmt.Println("\nMinStack:")
+	var prices MinStack[int]
+	for _, price := range []int{42, 17, 23, 8, 15} {
+		prices.Push(price)
+		minimum, _ := prices.GetMin()
+		fmt.Printf("Pushed %2d, min %2d\n", price, minimum)
+	}
+	for prices.Size() > 0 {
+		minimum, _ := prices.GetMin()
+		price, _ := prices.Pop()
+		fmt.Printf("Popped %2d, min was %2d\n", price, minimum)
+	}
+	if _, err := prices.GetMin(); errors.Is(err, ErrStackEmpty) {
+		fmt.Println("Error:", err)
+	}
+
+	// BoundedStack: pushing past the capacity fails
+	fmt.Println("\nBoundedStack:")
+	undo := NewBoundedStack(3)
+	for _, action := range []string{"type", "bold", "delete", "paste"} {
+		if err := undo.Push(action); err != nil {
+			if errors.Is(err, ErrStackFull) {
+				fmt.Println("Error:", err)
+			}
+			continue
+		}
+		fmt.Printf("Pushed %q, size %d, full: %t\n", action, undo.Size(), undo.IsFull())
+	}
+	last, _ := undo.Pop()
+	fmt.Println("Undo:", last)
+	if err := undo.Push("paste"); err == nil {
+		fmt.Println("Pushed \"paste\" after the undo")
+	}
 }
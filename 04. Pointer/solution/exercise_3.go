@@ -1,6 +1,14 @@
 package main
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// ErrUnbalanced is returned by Verify when a tree breaks an AVL invariant
+var ErrUnbalanced = errors.New("invalid AVL tree")
 
 // TreeNode represents a node in a binary search tree
 type TreeNode struct {
@@ -77,6 +85,283 @@ func inOrderRecursive(node *TreeNode, visit func(int)) {
 	}
 }
 
+// Height returns the number of levels of the tree
+func (bst *BinarySearchTree) Height() int {
+	return heightRecursive(bst.Root)
+}
+
+// heightRecursive is a helper function for Height
+func heightRecursive(node *TreeNode) int {
+	if node == nil {
+		return 0
+	}
+	return 1 + max(heightRecursive(node.Left), heightRecursive(node.Right))
+}
+
+// AVLNode is a node of an AVL tree. It stores the height of its subtree so
+// the balance of a node is known without walking the tree.
+type AVLNode struct {
+	Value  int
+	Height int
+	Left   *AVLNode
+	Right  *AVLNode
+}
+
+// AVLTree is a self-balancing binary search tree: the heights of the two
+// subtrees of every node differ by at most one, so the tree height stays
+// O(log n) whatever the insertion order
+type AVLTree struct {
+	Root      *AVLNode
+	size      int
+	Rotations []string // Rotations done by the last Insert or Delete
+}
+
+// height returns the height of a subtree, 0 for an empty one
+func height(node *AVLNode) int {
+	if node == nil {
+		return 0
+	}
+	return node.Height
+}
+
+// updateHeight recomputes the height of a node from its children
+func updateHeight(node *AVLNode) {
+	node.Height = 1 + max(height(node.Left), height(node.Right))
+}
+
+// balanceFactor is positive when the left subtree is taller
+func balanceFactor(node *AVLNode) int {
+	return height(node.Left) - height(node.Right)
+}
+
+// rotateRight lifts the left child of node and returns it as the new root
+// of the subtree:
+//
+//	   node         left
+//	   /  \         /  \
+//	 left  C  ->   A   node
+//	 /  \             /  \
+//	A    B           B    C
+func (t *AVLTree) rotateRight(node *AVLNode) *AVLNode {
+	t.Rotations = append(t.Rotations, fmt.Sprintf("right at %d", node.Value))
+	left := node.Left
+	node.Left = left.Right
+	left.Right = node
+	updateHeight(node)
+	updateHeight(left)
+	return left
+}
+
+// rotateLeft is the mirror of rotateRight
+func (t *AVLTree) rotateLeft(node *AVLNode) *AVLNode {
+	t.Rotations = append(t.Rotations, fmt.Sprintf("left at %d", node.Value))
+	right := node.Right
+	node.Right = right.Left
+	right.Left = node
+	updateHeight(node)
+	updateHeight(right)
+	return right
+}
+
+// rebalance restores the AVL invariant of a node whose subtrees differ in
+// height by two, and returns the new root of the subtree
+func (t *AVLTree) rebalance(node *AVLNode) *AVLNode {
+	updateHeight(node)
+
+	switch bf := balanceFactor(node); {
+	case bf > 1:
+		// Left-right case: rotate the child first so both imbalances point the same way
+		if balanceFactor(node.Left) < 0 {
+			node.Left = t.rotateLeft(node.Left)
+		}
+		return t.rotateRight(node)
+	case bf < -1:
+		// Right-left case
+		if balanceFactor(node.Right) > 0 {
+			node.Right = t.rotateRight(node.Right)
+		}
+		return t.rotateLeft(node)
+	}
+	return node
+}
+
+// Insert adds a value to the tree, returning false if it was already present
+func (t *AVLTree) Insert(value int) bool {
+	t.Rotations = nil
+	inserted := false
+	t.Root = t.insert(t.Root, value, &inserted)
+	if inserted {
+		t.size++
+	}
+	return inserted
+}
+
+// insert adds a value below node and returns the new root of the subtree.
+// Every node on the way back up is rebalanced.
+func (t *AVLTree) insert(node *AVLNode, value int, inserted *bool) *AVLNode {
+	if node == nil {
+		*inserted = true
+		return &AVLNode{Value: value, Height: 1}
+	}
+
+	switch {
+	case value < node.Value:
+		node.Left = t.insert(node.Left, value, inserted)
+	case value > node.Value:
+		node.Right = t.insert(node.Right, value, inserted)
+	default:
+		return node // Duplicate
+	}
+	return t.rebalance(node)
+}
+
+// Delete removes a value from the tree, returning false if it was absent
+func (t *AVLTree) Delete(value int) bool {
+	t.Rotations = nil
+	deleted := false
+	t.Root = t.delete(t.Root, value, &deleted)
+	if deleted {
+		t.size--
+	}
+	return deleted
+}
+
+// delete removes a value below node and returns the new root of the subtree
+func (t *AVLTree) delete(node *AVLNode, value int, deleted *bool) *AVLNode {
+	if node == nil {
+		return nil
+	}
+
+	switch {
+	case value < node.Value:
+		node.Left = t.delete(node.Left, value, deleted)
+	case value > node.Value:
+		node.Right = t.delete(node.Right, value, deleted)
+	default:
+		*deleted = true
+		if node.Left == nil {
+			return node.Right
+		}
+		if node.Right == nil {
+			return node.Left
+		}
+		// Two children: take the value of the smallest node on the right,
+		// then delete that node, which has at most one child
+		successor := node.Right
+		for successor.Left != nil {
+			successor = successor.Left
+		}
+		node.Value = successor.Value
+		node.Right = t.delete(node.Right, successor.Value, deleted)
+	}
+	return t.rebalance(node)
+}
+
+// Find checks if a value exists in the tree
+func (t *AVLTree) Find(value int) bool {
+	node := t.Root
+	for node != nil {
+		switch {
+		case value < node.Value:
+			node = node.Left
+		case value > node.Value:
+			node = node.Right
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// Size returns the number of values in the tree
+func (t *AVLTree) Size() int {
+	return t.size
+}
+
+// Verify checks the invariants of the tree: values are ordered, stored
+// heights are correct, subtree heights differ by at most one, and the size
+// matches the number of nodes
+func (t *AVLTree) Verify() error {
+	count := 0
+	if _, err := verifyNode(t.Root, nil, nil, &count); err != nil {
+		return err
+	}
+	if count != t.size {
+		return fmt.Errorf("%w: size is %d but the tree has %d nodes", ErrUnbalanced, t.size, count)
+	}
+	return nil
+}
+
+// verifyNode checks a subtree whose values must be between low and high
+// (nil means unbounded) and returns its real height
+func verifyNode(node *AVLNode, low, high *int, count *int) (int, error) {
+	if node == nil {
+		return 0, nil
+	}
+	*count++
+
+	if (low != nil && node.Value <= *low) || (high != nil && node.Value >= *high) {
+		return 0, fmt.Errorf("%w: %d is out of order", ErrUnbalanced, node.Value)
+	}
+	left, err := verifyNode(node.Left, low, &node.Value, count)
+	if err != nil {
+		return 0, err
+	}
+	right, err := verifyNode(node.Right, &node.Value, high, count)
+	if err != nil {
+		return 0, err
+	}
+
+	if h := 1 + max(left, right); node.Height != h {
+		return 0, fmt.Errorf("%w: node %d stores height %d, real height %d", ErrUnbalanced, node.Value, node.Height, h)
+	}
+	if left-right > 1 || right-left > 1 {
+		return 0, fmt.Errorf("%w: node %d has subtrees of height %d and %d", ErrUnbalanced, node.Value, left, right)
+	}
+	return 1 + max(left, right), nil
+}
+
+// String draws the tree, each node with its height. L and R mark the left
+// and right children, a missing child is shown when its sibling exists.
+//
+//	40 (h3)
+//	├── L 20 (h2)
+//	│   └── R 30 (h1)
+//	└── R 50 (h1)
+func (t *AVLTree) String() string {
+	if t.Root == nil {
+		return "(empty)\n"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d (h%d)\n", t.Root.Value, t.Root.Height)
+	printChildren(&b, t.Root, "")
+	return b.String()
+}
+
+// printChildren draws the children of node below it
+func printChildren(b *strings.Builder, node *AVLNode, prefix string) {
+	if node.Left == nil && node.Right == nil {
+		return
+	}
+	children := []struct {
+		side string
+		node *AVLNode
+	}{{"L", node.Left}, {"R", node.Right}}
+
+	for i, child := range children {
+		connector, indent := "├── ", "│   "
+		if i == len(children)-1 {
+			connector, indent = "└── ", "    "
+		}
+		if child.node == nil {
+			fmt.Fprintf(b, "%s%s%s ·\n", prefix, connector, child.side)
+			continue
+		}
+		fmt.Fprintf(b, "%s%s%s %d (h%d)\n", prefix, connector, child.side, child.node.Value, child.node.Height)
+		printChildren(b, child.node, prefix+indent)
+	}
+}
+
 func main() {
 	bst := BinarySearchTree{}
 
@@ -101,4 +386,56 @@ func main() {
 			fmt.Printf("Value %d NOT found in tree\n", v)
 		}
 	}
+
+	// Sorted input is the worst case of a plain binary search tree
+	sorted := BinarySearchTree{}
+	avl := AVLTree{}
+	for v := 10; v <= 70; v += 10 {
+		sorted.Insert(v)
+		avl.Insert(v)
+		fmt.Printf("\nAVL after inserting %d, rotations %v:\n%s", v, avl.Rotations, &avl)
+		if err := avl.Verify(); err != nil {
+			fmt.Println("Error:", err)
+		}
+	}
+	fmt.Printf("\nHeight with sorted input: BST %d, AVL %d\n", sorted.Height(), height(avl.Root))
+
+	// Left-right case: the new value is on the right of the left child
+	zigzag := AVLTree{}
+	for _, v := range []int{30, 10, 20} {
+		zigzag.Insert(v)
+	}
+	fmt.Printf("\nAfter inserting 30, 10, 20, rotations %v:\n%s", zigzag.Rotations, &zigzag)
+
+	// Deleting can unbalance the tree too
+	for _, v := range []int{10, 30, 20} {
+		avl.Delete(v)
+		fmt.Printf("\nAVL after deleting %d, rotations %v:\n%s", v, avl.Rotations, &avl)
+		if err := avl.Verify(); err != nil {
+			fmt.Println("Error:", err)
+		}
+	}
+
+	// Verify catches a broken invariant
+	avl.Root.Left.Height = 5
+	fmt.Println("\nAfter corrupting a height:", avl.Verify())
+
+	// Random operations, checking the invariants after each one
+	random := rand.New(rand.NewSource(42))
+	big := AVLTree{}
+	plain := BinarySearchTree{}
+	for range 10000 {
+		v := random.Intn(100000)
+		big.Insert(v)
+		plain.Insert(v)
+	}
+	for range 5000 {
+		big.Delete(random.Intn(100000))
+		if err := big.Verify(); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+	}
+	fmt.Printf("\n%d random values: AVL height %d, plain BST height %d, invariants hold\n",
+		big.Size(), height(big.Root), plain.Height())
 }
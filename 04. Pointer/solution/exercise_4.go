@@ -0,0 +1,227 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnbalanced is returned when the brackets of a source don't match
+var ErrUnbalanced = errors.New("unbalanced brackets")
+
+// Position is a location in the source, starting at line 1, column 1
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// bracket is an opening bracket waiting for its closing one
+type bracket struct {
+	char rune
+	pos  Position
+}
+
+// Node represents an element in the stack
+type Node struct {
+	Value bracket
+	Next  *Node
+}
+
+// Stack is the LIFO stack of exercise 1, holding brackets
+type Stack struct {
+	top  *Node
+	size int
+}
+
+// Push adds a new value to the top of the stack
+func (s *Stack) Push(value bracket) {
+	s.top = &Node{Value: value, Next: s.top}
+	s.size++
+}
+
+// Pop removes and returns the top value from the stack
+func (s *Stack) Pop() (bracket, bool) {
+	if s.top == nil {
+		return bracket{}, false
+	}
+
+	value := s.top.Value
+	s.top = s.top.Next
+	s.size--
+
+	return value, true
+}
+
+// BracketError describes the first bracket that doesn't match
+type BracketError struct {
+	Pos      Position
+	Found    rune     // The bracket found, 0 at the end of the source
+	Expected rune     // The closing bracket expected, 0 if none was open
+	Opened   Position // Where the expected bracket was opened
+}
+
+func (e *BracketError) Error() string {
+	switch {
+	case e.Expected == 0:
+		return fmt.Sprintf("%s: unexpected %q, no bracket is open", e.Pos, e.Found)
+	case e.Found == 0:
+		return fmt.Sprintf("%s: missing %q for the bracket opened at %s", e.Pos, e.Expected, e.Opened)
+	default:
+		return fmt.Sprintf("%s: found %q, expected %q for the bracket opened at %s", e.Pos, e.Found, e.Expected, e.Opened)
+	}
+}
+
+func (e *BracketError) Unwrap() error {
+	return ErrUnbalanced
+}
+
+// pairs maps each opening bracket to its closing bracket
+var pairs = map[rune]rune{'(': ')', '[': ']', '{': '}'}
+
+// isClosing reports whether a rune is a closing bracket
+func isClosing(r rune) bool {
+	return r == ')' || r == ']' || r == '}'
+}
+
+// CheckBrackets reports the first unbalanced bracket of Go-like source code.
+// Brackets inside comments, string literals and rune literals are ignored.
+func CheckBrackets(source string) error {
+	var stack Stack
+	runes := []rune(source)
+	pos := Position{Line: 1, Column: 1}
+
+	// advance moves past the current rune and returns it
+	i := 0
+	advance := func() rune {
+		r := runes[i]
+		i++
+		if r == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
+		return r
+	}
+	// skipUntil advances past the end delimiter, escapes are skipped in quoted literals
+	skipUntil := func(end string, escapes bool) {
+		for i < len(runes) {
+			if escapes && runes[i] == '\\' && i+1 < len(runes) {
+				advance()
+				advance()
+				continue
+			}
+			if string(runes[i:min(i+len(end), len(runes))]) == end {
+				for range end {
+					advance()
+				}
+				return
+			}
+			advance()
+		}
+	}
+
+	for i < len(runes) {
+		start := pos
+		r := advance()
+
+		switch {
+		case r == '/' && i < len(runes) && runes[i] == '/':
+			skipUntil("\n", false)
+		case r == '/' && i < len(runes) && runes[i] == '*':
+			advance()
+			skipUntil("*/", false)
+		case r == '"':
+			skipUntil(`"`, true)
+		case r == '\'':
+			skipUntil(`'`, true)
+		case r == '`':
+			skipUntil("`", false)
+		case pairs[r] != 0:
+			stack.Push(bracket{char: r, pos: start})
+		case isClosing(r):
+			open, ok := stack.Pop()
+			if !ok {
+				return &BracketError{Pos: start, Found: r}
+			}
+			if pairs[open.char] != r {
+				return &BracketError{Pos: start, Found: r, Expected: pairs[open.char], Opened: open.pos}
+			}
+		}
+	}
+
+	if open, ok := stack.Pop(); ok {
+		return &BracketError{Pos: pos, Expected: pairs[open.char], Opened: open.pos}
+	}
+	return nil
+}
+
+func main() {
+	sources := []struct {
+		name   string
+		source string
+	}{
+		{"balanced", `func main() {
+	values := []int{1, 2, 3}
+	fmt.Println(values[0], len(values))
+}`},
+		{"brackets in strings and comments", `func main() {
+	// A comment with an unbalanced ( bracket
+	fmt.Println("a string with ] and {", '(', ` + "`raw ) string`" + `)
+	/* a block comment } */
+}`},
+		{"escaped quote", `s := "say \"hi\" (twice"; f(s)`},
+		{"mismatched", `if x > 0 {
+	values[x) = 1
+}`},
+		{"unexpected closing", `fmt.Println("done"))`},
+		{"missing closing", `func main() {
+	for i := 0; i < 3; i++ {
+		fmt.Println(i)
+	}
+`},
+		{"empty", ``},
+	}
+
+	for _, s := range sources {
+		err := CheckBrackets(s.source)
+		if err == nil {
+			fmt.Printf("%-34s OK\n", s.name)
+			continue
+		}
+
+		fmt.Printf("%-34s %v\n", s.name, err)
+		var bracketErr *BracketError
+		if errors.As(err, &bracketErr) {
+			// For a missing bracket, show where it was opened
+			line := bracketErr.Pos.Line
+			if bracketErr.Found == 0 {
+				line = bracketErr.Opened.Line
+			}
+			fmt.Printf("%-34s line %d: %s\n", "", line, lineOf(s.source, line))
+		}
+	}
+}
+
+// lineOf returns a line of the source, starting at 1
+func lineOf(source string, line int) string {
+	current := 1
+	start := 0
+	for i, r := range source {
+		if r != '\n' {
+			continue
+		}
+		if current == line {
+			return source[start:i]
+		}
+		current++
+		start = i + 1
+	}
+	if current == line {
+		return source[start:]
+	}
+	return ""
+}
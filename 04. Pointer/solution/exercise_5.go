@@ -0,0 +1,330 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// Element is a node of a doubly linked list
+type Element[T any] struct {
+	Value      T
+	prev, next *Element[T]
+	list       *List[T]
+}
+
+// Next returns the next element, or nil at the end of the list
+func (e *Element[T]) Next() *Element[T] {
+	if e.list == nil || e.next == &e.list.tail {
+		return nil
+	}
+	return e.next
+}
+
+// Prev returns the previous element, or nil at the start of the list
+func (e *Element[T]) Prev() *Element[T] {
+	if e.list == nil || e.prev == &e.list.head {
+		return nil
+	}
+	return e.prev
+}
+
+// List is a doubly linked list with two sentinel nodes. The head and tail
+// sentinels hold no value and are always present, so inserting and removing
+// never have to check for nil neighbours or update a first or last pointer.
+type List[T any] struct {
+	head, tail Element[T]
+	len        int
+}
+
+// NewList creates an empty list
+func NewList[T any]() *List[T] {
+	l := &List[T]{}
+	l.head.next = &l.tail
+	l.tail.prev = &l.head
+	return l
+}
+
+// Len returns the number of elements
+func (l *List[T]) Len() int {
+	return l.len
+}
+
+// Front returns the first element, or nil if the list is empty
+func (l *List[T]) Front() *Element[T] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.head.next
+}
+
+// Back returns the last element, or nil if the list is empty
+func (l *List[T]) Back() *Element[T] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.tail.prev
+}
+
+// insertAfter links e after at
+func (l *List[T]) insertAfter(e, at *Element[T]) *Element[T] {
+	e.prev = at
+	e.next = at.next
+	at.next.prev = e
+	at.next = e
+	e.list = l
+	l.len++
+	return e
+}
+
+// unlink removes e from the list, its neighbours point to each other
+func (l *List[T]) unlink(e *Element[T]) {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	e.prev, e.next = nil, nil // Help the garbage collector and catch misuse
+	l.len--
+}
+
+// PushFront adds a value at the start of the list
+func (l *List[T]) PushFront(value T) *Element[T] {
+	return l.insertAfter(&Element[T]{Value: value}, &l.head)
+}
+
+// PushBack adds a value at the end of the list
+func (l *List[T]) PushBack(value T) *Element[T] {
+	return l.insertAfter(&Element[T]{Value: value}, l.tail.prev)
+}
+
+// Remove removes an element of the list and returns its value
+func (l *List[T]) Remove(e *Element[T]) T {
+	if e.list == l {
+		l.unlink(e)
+		e.list = nil
+	}
+	return e.Value
+}
+
+// MoveToFront moves an element of the list to the start
+func (l *List[T]) MoveToFront(e *Element[T]) {
+	if e.list != l || l.head.next == e {
+		return
+	}
+	l.unlink(e)
+	l.insertAfter(e, &l.head)
+}
+
+// Values returns the values from front to back
+func (l *List[T]) Values() []T {
+	values := make([]T, 0, l.len)
+	for e := l.Front(); e != nil; e = e.Next() {
+		values = append(values, e.Value)
+	}
+	return values
+}
+
+// entry is a cached key and value, stored in the list so an evicted element
+// tells which key to delete from the map
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// LRUCache keeps the most recently used values up to a capacity. The map
+// finds an entry in O(1) and the list keeps the usage order, most recent
+// first: a hit moves its element to the front, and the back is evicted.
+type LRUCache[K comparable, V any] struct {
+	capacity int
+	items    map[K]*Element[entry[K, V]]
+	order    *List[entry[K, V]]
+	onEvict  func(key K, value V)
+
+	hits, misses, evictions int
+}
+
+// NewLRUCache creates a cache holding at most capacity values. onEvict, if
+// not nil, is called with every entry evicted to make room for a new one.
+func NewLRUCache[K comparable, V any](capacity int, onEvict func(key K, value V)) *LRUCache[K, V] {
+	if capacity < 1 {
+		panic(fmt.Sprintf("lru: capacity must be positive, got %d", capacity))
+	}
+	return &LRUCache[K, V]{
+		capacity: capacity,
+		items:    make(map[K]*Element[entry[K, V]], capacity),
+		order:    NewList[entry[K, V]](),
+		onEvict:  onEvict,
+	}
+}
+
+// Get returns the value of a key and marks it as the most recently used
+func (c *LRUCache[K, V]) Get(key K) (V, bool) {
+	e, ok := c.items[key]
+	if !ok {
+		c.misses++
+		var zero V
+		return zero, false
+	}
+
+	c.hits++
+	c.order.MoveToFront(e)
+	return e.Value.value, true
+}
+
+// Put adds or replaces the value of a key, evicting the least recently used
+// entry when the cache is full
+func (c *LRUCache[K, V]) Put(key K, value V) {
+	if e, ok := c.items[key]; ok {
+		e.Value.value = value
+		c.order.MoveToFront(e)
+		return
+	}
+
+	if c.order.Len() == c.capacity {
+		oldest := c.order.Remove(c.order.Back())
+		delete(c.items, oldest.key)
+		c.evictions++
+		if c.onEvict != nil {
+			c.onEvict(oldest.key, oldest.value)
+		}
+	}
+	c.items[key] = c.order.PushFront(entry[K, V]{key: key, value: value})
+}
+
+// Remove deletes a key, without calling the eviction callback
+func (c *LRUCache[K, V]) Remove(key K) bool {
+	e, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	c.order.Remove(e)
+	delete(c.items, key)
+	return true
+}
+
+// Len returns the number of cached values
+func (c *LRUCache[K, V]) Len() int {
+	return c.order.Len()
+}
+
+// Keys returns the keys from the most to the least recently used
+func (c *LRUCache[K, V]) Keys() []K {
+	keys := make([]K, 0, c.order.Len())
+	for e := c.order.Front(); e != nil; e = e.Next() {
+		keys = append(keys, e.Value.key)
+	}
+	return keys
+}
+
+// Stats returns the number of hits, misses and evictions
+func (c *LRUCache[K, V]) Stats() (hits, misses, evictions int) {
+	return c.hits, c.misses, c.evictions
+}
+
+// sliceLRU is a naive LRU cache keeping the keys in a slice, most recent
+// first. Every access searches and shifts the slice in O(n), to compare with
+// the map and list version.
+type sliceLRU[K comparable, V any] struct {
+	capacity int
+	entries  []entry[K, V]
+}
+
+func (c *sliceLRU[K, V]) Get(key K) (V, bool) {
+	for i, e := range c.entries {
+		if e.key == key {
+			copy(c.entries[1:i+1], c.entries[:i])
+			c.entries[0] = e
+			return e.value, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+func (c *sliceLRU[K, V]) Put(key K, value V) {
+	if _, ok := c.Get(key); ok {
+		c.entries[0].value = value
+		return
+	}
+	if len(c.entries) < c.capacity {
+		c.entries = append(c.entries, entry[K, V]{})
+	}
+	copy(c.entries[1:], c.entries)
+	c.entries[0] = entry[K, V]{key: key, value: value}
+}
+
+// cache is the interface shared by both implementations for the benchmark
+type cache interface {
+	Get(key int) (int, bool)
+	Put(key, value int)
+}
+
+// benchmark measures a mix of reads and writes on random keys, twice as many
+// as the capacity, so about half of the reads are hits
+func benchmark(newCache func(capacity int) cache, capacity int) testing.BenchmarkResult {
+	return testing.Benchmark(func(b *testing.B) {
+		c := newCache(capacity)
+		random := rand.New(rand.NewSource(1))
+		keys := make([]int, 4096)
+		for i := range keys {
+			keys[i] = random.Intn(2 * capacity)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			key := keys[i%len(keys)]
+			if _, ok := c.Get(key); !ok {
+				c.Put(key, i)
+			}
+		}
+	})
+}
+
+func main() {
+	// Doubly linked list
+	list := NewList[string]()
+	list.PushBack("b")
+	list.PushBack("c")
+	first := list.PushFront("a")
+	last := list.PushBack("d")
+	fmt.Println("List:", list.Values(), "len", list.Len())
+
+	list.MoveToFront(last)
+	fmt.Println("After moving d to the front:", list.Values())
+	list.Remove(first)
+	fmt.Println("After removing a:", list.Values())
+	fmt.Print("Backward: ")
+	for e := list.Back(); e != nil; e = e.Prev() {
+		fmt.Print(e.Value, " ")
+	}
+	fmt.Println()
+
+	// LRU cache with an eviction callback
+	fmt.Println("\nLRU cache of 3 pages:")
+	pages := NewLRUCache(3, func(url string, html string) {
+		fmt.Printf("  evicted %s (%d bytes)\n", url, len(html))
+	})
+	visit := func(url string) {
+		if _, ok := pages.Get(url); ok {
+			fmt.Printf("hit  %-7s %v\n", url, pages.Keys())
+			return
+		}
+		pages.Put(url, "<html>"+url+"</html>")
+		fmt.Printf("miss %-7s %v\n", url, pages.Keys())
+	}
+	for _, url := range []string{"/", "/about", "/blog", "/", "/shop", "/about", "/blog", "/"} {
+		visit(url)
+	}
+	pages.Remove("/")
+	fmt.Println("After removing /:", pages.Keys())
+	hits, misses, evictions := pages.Stats()
+	fmt.Printf("Hits %d, misses %d, evictions %d\n", hits, misses, evictions)
+
+	// Benchmark: map and list against a slice
+	// For a few entries, scanning a small slice beats hashing and allocating
+	// list elements; the slice gets slower as the capacity grows
+	fmt.Println("\nBenchmark (random keys, twice the capacity):")
+	for _, capacity := range []int{10, 100, 1000} {
+		lru := benchmark(func(n int) cache { return NewLRUCache[int, int](n, nil) }, capacity)
+		naive := benchmark(func(n int) cache { return &sliceLRU[int, int]{capacity: n} }, capacity)
+		fmt.Printf("capacity %4d: map+list %6d ns/op, slice %6d ns/op\n",
+			capacity, lru.NsPerOp(), naive.NsPerOp())
+	}
+}
@@ -1,8 +1,9 @@
 package main
 
 import (
+	"cmp"
 	"fmt"
-	"sort"
+	"slices"
 )
 
 func main() {
@@ -55,8 +56,8 @@ func main() {
 	}
 
 	// Sort by average (descending)
-	sort.Slice(studentList, func(i, j int) bool {
-		return studentList[i].Average > studentList[j].Average
+	slices.SortFunc(studentList, func(a, b StudentAvg) int {
+		return cmp.Compare(b.Average, a.Average)
 	})
 
 	fmt.Println("\nRanked Students:")
@@ -1,13 +1,32 @@
 package main
 
 import (
+	"cmp"
 	"fmt"
 	"os"
 	"regexp"
-	"sort"
+	"slices"
 	"strings"
 )
 
+// Set is a collection of distinct values, see exercise 5 for the full type
+type Set[T comparable] map[T]struct{}
+
+// NewSet creates a set holding the given values, without their duplicates
+func NewSet[T comparable](values ...T) Set[T] {
+	s := make(Set[T], len(values))
+	for _, v := range values {
+		s[v] = struct{}{}
+	}
+	return s
+}
+
+// Contains reports whether a value is in the set
+func (s Set[T]) Contains(v T) bool {
+	_, ok := s[v]
+	return ok
+}
+
 func main() {
 	// Sample text or you could read from a file
 	text := `Go is an open source programming language that makes it easy to build 
@@ -31,11 +50,12 @@ func main() {
 	}
 
 	// Filter out common words (simplified stop words list)
-	stopWords := map[string]bool{
-		"the": true, "and": true, "is": true, "to": true, "of": true,
-		"a": true, "in": true, "but": true, "with": true, "by": true,
-		"was": true, "its": true,
-	}
+	stopWords := NewSet(
+		"the", "and", "is", "to", "of",
+		"a", "in", "but", "with", "by",
+		"was", "its",
+	)
+	fmt.Printf("%d words, %d distinct\n\n", len(words), len(NewSet(words...)))
 
 	// Create a list of word-frequency pairs
 	type WordFreq struct {
@@ -45,14 +65,14 @@ func main() {
 
 	var wordFreqs []WordFreq
 	for word, count := range frequencies {
-		if !stopWords[word] && len(word) > 1 {
+		if !stopWords.Contains(word) && len(word) > 1 {
 			wordFreqs = append(wordFreqs, WordFreq{word, count})
 		}
 	}
 
-	// Sort by frequency (descending)
-	sort.Slice(wordFreqs, func(i, j int) bool {
-		return wordFreqs[i].Count > wordFreqs[j].Count
+	// Sort by frequency (descending), then alphabetically so ties always print in the same order
+	slices.SortFunc(wordFreqs, func(a, b WordFreq) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Word, b.Word))
 	})
 
 	// Print top N words
@@ -1,8 +1,9 @@
 package main
 
 import (
+	"cmp"
 	"fmt"
-	"sort"
+	"slices"
 	"strings"
 )
 
@@ -37,6 +38,11 @@ func getContactKey(c Contact) string {
 	return strings.ToLower(c.FirstName + ":" + c.LastName)
 }
 
+// byName orders contacts by last name, then first name
+func byName(a, b Contact) int {
+	return cmp.Or(cmp.Compare(a.LastName, b.LastName), cmp.Compare(a.FirstName, b.FirstName))
+}
+
 // FindContact searches for contacts by name
 func (cb *ContactBook) FindContact(name string) []Contact {
 	var results []Contact
@@ -52,12 +58,7 @@ func (cb *ContactBook) FindContact(name string) []Contact {
 	}
 
 	// Sort results by last name, then first name
-	sort.Slice(results, func(i, j int) bool {
-		if results[i].LastName == results[j].LastName {
-			return results[i].FirstName < results[j].FirstName
-		}
-		return results[i].LastName < results[j].LastName
-	})
+	slices.SortFunc(results, byName)
 
 	return results
 }
@@ -81,12 +82,7 @@ func (cb *ContactBook) ListAllContacts() []Contact {
 	}
 
 	// Sort by last name, then first name
-	sort.Slice(allContacts, func(i, j int) bool {
-		if allContacts[i].LastName == allContacts[j].LastName {
-			return allContacts[i].FirstName < allContacts[j].FirstName
-		}
-		return allContacts[i].LastName < allContacts[j].LastName
-	})
+	slices.SortFunc(allContacts, byName)
 
 	return allContacts
 }
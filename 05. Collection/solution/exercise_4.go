@@ -0,0 +1,189 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Product is an item of the inventory
+type Product struct {
+	SKU      string
+	Name     string
+	Category string
+	Price    float64
+	Stock    int
+}
+
+// Unique removes duplicates and keeps the first occurrence of each value, in order
+func Unique[T comparable](values []T) []T {
+	seen := make(map[T]bool, len(values))
+	result := make([]T, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// SortedUnique returns the distinct values in ascending order. Sorting puts
+// equal values next to each other, so Compact removes the duplicates.
+func SortedUnique[T cmp.Ordered](values []T) []T {
+	sorted := slices.Clone(values) // Don't reorder the caller's slice
+	slices.Sort(sorted)
+	return slices.Compact(sorted)
+}
+
+// InsertSorted adds a value to a sorted slice, keeping it sorted
+func InsertSorted[T cmp.Ordered](sorted []T, value T) []T {
+	i, _ := slices.BinarySearch(sorted, value)
+	return slices.Insert(sorted, i, value)
+}
+
+// Clamp limits a value to the range [low, high]
+func Clamp[T cmp.Ordered](value, low, high T) T {
+	return min(max(value, low), high)
+}
+
+// byCategoryThenPrice orders products by category, then by price from the
+// most expensive, then by name. cmp.Or returns the first non-zero comparison.
+func byCategoryThenPrice(a, b Product) int {
+	return cmp.Or(
+		cmp.Compare(a.Category, b.Category),
+		cmp.Compare(b.Price, a.Price), // b first: descending
+		cmp.Compare(a.Name, b.Name),
+	)
+}
+
+// bySKU orders products by SKU, the order needed by BinarySearchFunc
+func bySKU(a, b Product) int {
+	return strings.Compare(a.SKU, b.SKU)
+}
+
+// findBySKU looks up a product in a slice sorted by SKU in O(log n)
+func findBySKU(products []Product, sku string) (Product, bool) {
+	i, found := slices.BinarySearchFunc(products, sku, func(p Product, sku string) int {
+		return strings.Compare(p.SKU, sku)
+	})
+	if !found {
+		return Product{}, false
+	}
+	return products[i], true
+}
+
+func printProducts(products []Product) {
+	for _, p := range products {
+		fmt.Printf("  %-7s %-22s %-12s %8.2f %4d\n", p.SKU, p.Name, p.Category, p.Price, p.Stock)
+	}
+}
+
+func main() {
+	inventory := []Product{
+		{"KB-104", "Mechanical Keyboard", "Peripherals", 89.90, 12},
+		{"MS-201", "Wireless Mouse", "Peripherals", 24.50, 40},
+		{"MN-270", "27\" Monitor", "Displays", 279.00, 5},
+		{"CB-010", "USB-C Cable", "Accessories", 9.99, 150},
+		{"MN-240", "24\" Monitor", "Displays", 179.00, 0},
+		{"HS-330", "Headset", "Audio", 59.00, 18},
+		{"SP-120", "Speakers", "Audio", 59.00, 7},
+		{"DK-500", "Docking Station", "Accessories", 149.00, 3},
+		{"WC-080", "Webcam", "Peripherals", 49.90, 0},
+	}
+
+	// 1. Sorting structs with several keys
+	fmt.Println("--- Sorted by Category, Price (Descending), Name ---")
+	byCategory := slices.Clone(inventory)
+	slices.SortFunc(byCategory, byCategoryThenPrice)
+	printProducts(byCategory)
+
+	// A stable sort keeps the previous order of equal elements: products with
+	// the same stock stay sorted by category
+	fmt.Println("\n--- Sorted by Stock, Stable ---")
+	byStock := slices.Clone(byCategory)
+	slices.SortStableFunc(byStock, func(a, b Product) int {
+		return cmp.Compare(a.Stock, b.Stock)
+	})
+	printProducts(byStock)
+
+	// 2. Binary search
+	fmt.Println("\n--- Binary Search ---")
+	bySKUList := slices.Clone(inventory)
+	slices.SortFunc(bySKUList, bySKU)
+	for _, sku := range []string{"HS-330", "MN-240", "XX-999"} {
+		if p, ok := findBySKU(bySKUList, sku); ok {
+			fmt.Printf("%s: %s, %d in stock\n", sku, p.Name, p.Stock)
+		} else {
+			fmt.Printf("%s: not found\n", sku)
+		}
+	}
+
+	prices := []int{10, 25, 50, 100, 250}
+	i, found := slices.BinarySearch(prices, 75)
+	fmt.Printf("75 in %v: found=%t, insert at index %d\n", prices, found, i)
+	prices = InsertSorted(prices, 75)
+	prices = InsertSorted(prices, 5)
+	fmt.Println("After inserting 75 and 5:", prices)
+
+	// 3. Searching without sorting
+	fmt.Println("\n--- Searching ---")
+	cheapest := slices.MinFunc(inventory, func(a, b Product) int { return cmp.Compare(a.Price, b.Price) })
+	priciest := slices.MaxFunc(inventory, func(a, b Product) int { return cmp.Compare(a.Price, b.Price) })
+	fmt.Printf("Cheapest: %s (%.2f), most expensive: %s (%.2f)\n", cheapest.Name, cheapest.Price, priciest.Name, priciest.Price)
+
+	outOfStock := func(p Product) bool { return p.Stock == 0 }
+	if i := slices.IndexFunc(inventory, outOfStock); i >= 0 {
+		fmt.Println("First product out of stock:", inventory[i].Name)
+	}
+	fmt.Println("Anything above 500?", slices.ContainsFunc(inventory, func(p Product) bool { return p.Price > 500 }))
+	available := slices.DeleteFunc(slices.Clone(inventory), outOfStock)
+	fmt.Printf("%d of %d products available\n", len(available), len(inventory))
+
+	// 4. Compact and Unique
+	fmt.Println("\n--- Compact and Unique ---")
+	events := []string{"start", "start", "tick", "tick", "tick", "stop", "start", "start"}
+	fmt.Println("Compact (consecutive duplicates):", slices.Compact(slices.Clone(events)))
+	fmt.Println("Unique (first occurrence, in order):", Unique(events))
+	fmt.Println("SortedUnique:", SortedUnique(events))
+
+	tags := []string{"Go", "go", "GO", "Rust", "rust", "Go"}
+	tags = slices.CompactFunc(tags, strings.EqualFold)
+	fmt.Println("CompactFunc, ignoring case:", tags)
+
+	categories := make([]string, 0, len(inventory))
+	for _, p := range inventory {
+		categories = append(categories, p.Category)
+	}
+	fmt.Println("Categories:", SortedUnique(categories))
+
+	// 5. Iterating maps in order
+	fmt.Println("\n--- Stock Value by Category ---")
+	value := make(map[string]float64)
+	for _, p := range inventory {
+		value[p.Category] += p.Price * float64(p.Stock)
+	}
+	for _, category := range slices.Sorted(maps.Keys(value)) {
+		fmt.Printf("  %-12s %9.2f\n", category, value[category])
+	}
+
+	// Keys sorted by value: collect, then sort with a comparison function
+	ranked := slices.SortedFunc(maps.Keys(value), func(a, b string) int {
+		return cmp.Compare(value[b], value[a])
+	})
+	fmt.Println("Most valuable category:", ranked[0])
+
+	// maps.Clone, maps.DeleteFunc and maps.Equal
+	small := maps.Clone(value)
+	maps.DeleteFunc(small, func(category string, total float64) bool { return total > 1500 })
+	fmt.Println("Categories under 1500:", slices.Sorted(maps.Keys(small)))
+	fmt.Printf("The clone has %d categories, the original still has %d, equal: %t\n",
+		len(small), len(value), maps.Equal(value, small))
+
+	// 6. Generic helpers with cmp.Ordered work with any ordered type
+	fmt.Println("\n--- cmp.Ordered ---")
+	fmt.Println(Clamp(120, 0, 100), Clamp(2.5, 0.0, 1.0), Clamp("m", "a", "k"))
+	fmt.Println(SortedUnique([]float64{3.5, 1.25, 3.5, 2}))
+}
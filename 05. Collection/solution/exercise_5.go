@@ -0,0 +1,216 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Set is an unordered collection of distinct values. It is a map with empty
+// struct values: struct{} takes no memory, and the keys are the set.
+type Set[T comparable] map[T]struct{}
+
+// NewSet creates a set holding the given values, without their duplicates
+func NewSet[T comparable](values ...T) Set[T] {
+	s := make(Set[T], len(values))
+	s.Add(values...)
+	return s
+}
+
+// Add puts values in the set. Values already in the set are ignored.
+func (s Set[T]) Add(values ...T) {
+	for _, v := range values {
+		s[v] = struct{}{}
+	}
+}
+
+// Remove takes values out of the set
+func (s Set[T]) Remove(values ...T) {
+	for _, v := range values {
+		delete(s, v)
+	}
+}
+
+// Contains reports whether a value is in the set
+func (s Set[T]) Contains(v T) bool {
+	_, ok := s[v]
+	return ok
+}
+
+// Len returns the number of values in the set
+func (s Set[T]) Len() int {
+	return len(s)
+}
+
+// Clone returns a copy of the set
+func (s Set[T]) Clone() Set[T] {
+	return maps.Clone(s)
+}
+
+// Union returns the values in s, in other, or in both
+func (s Set[T]) Union(other Set[T]) Set[T] {
+	result := s.Clone()
+	for v := range other {
+		result.Add(v)
+	}
+	return result
+}
+
+// Intersection returns the values in both s and other
+func (s Set[T]) Intersection(other Set[T]) Set[T] {
+	// Loop over the smaller set: the result can't be larger
+	small, large := s, other
+	if small.Len() > large.Len() {
+		small, large = large, small
+	}
+	result := make(Set[T])
+	for v := range small {
+		if large.Contains(v) {
+			result.Add(v)
+		}
+	}
+	return result
+}
+
+// Difference returns the values in s that are not in other
+func (s Set[T]) Difference(other Set[T]) Set[T] {
+	result := make(Set[T])
+	for v := range s {
+		if !other.Contains(v) {
+			result.Add(v)
+		}
+	}
+	return result
+}
+
+// SymmetricDifference returns the values in exactly one of the two sets
+func (s Set[T]) SymmetricDifference(other Set[T]) Set[T] {
+	return s.Difference(other).Union(other.Difference(s))
+}
+
+// IsSubset reports whether every value of s is in other
+func (s Set[T]) IsSubset(other Set[T]) bool {
+	if s.Len() > other.Len() {
+		return false
+	}
+	for v := range s {
+		if !other.Contains(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal reports whether both sets hold the same values
+func (s Set[T]) Equal(other Set[T]) bool {
+	return s.Len() == other.Len() && s.IsSubset(other)
+}
+
+// All returns an iterator over the values, in the random order of the map
+func (s Set[T]) All() iter.Seq[T] {
+	return maps.Keys(s)
+}
+
+// SortedFunc returns the values ordered by a comparison function, for
+// values that have no natural order or to sort them differently
+func (s Set[T]) SortedFunc(compare func(a, b T) int) []T {
+	return slices.SortedFunc(s.All(), compare)
+}
+
+// Sorted returns the values of a set of ordered values, in ascending order.
+// It is a function, not a method: a method can't constrain T further than
+// the Set type does.
+func Sorted[T cmp.Ordered](s Set[T]) []T {
+	return slices.Sorted(s.All())
+}
+
+// String formats the set with its values sorted, so printing the same set
+// always gives the same result. Numbers are sorted by value and other values
+// by their text.
+func (s Set[T]) String() string {
+	texts := make([]string, 0, s.Len())
+	for v := range s {
+		texts = append(texts, fmt.Sprint(v))
+	}
+	slices.SortFunc(texts, func(a, b string) int {
+		x, errA := strconv.ParseFloat(a, 64)
+		y, errB := strconv.ParseFloat(b, 64)
+		if errA == nil && errB == nil {
+			return cmp.Compare(x, y)
+		}
+		return cmp.Compare(a, b)
+	})
+	return "{" + strings.Join(texts, ", ") + "}"
+}
+
+// stopWords are common words that don't say what a text is about
+var stopWords = NewSet(
+	"the", "and", "is", "to", "of", "a", "in", "but", "with", "by",
+	"was", "its", "an", "it", "that", "at", "for", "as", "are", "on",
+)
+
+var wordPattern = regexp.MustCompile(`[a-z]+`)
+
+// vocabulary returns the distinct meaningful words of a text
+func vocabulary(text string) Set[string] {
+	words := NewSet(wordPattern.FindAllString(strings.ToLower(text), -1)...)
+	return words.Difference(stopWords)
+}
+
+func main() {
+	fmt.Println("=== Set algebra ===")
+	evens := NewSet(2, 4, 6, 8, 10)
+	primes := NewSet(2, 3, 5, 7)
+	fmt.Println("evens:               ", evens)
+	fmt.Println("primes:              ", primes)
+	fmt.Println("union:               ", evens.Union(primes))
+	fmt.Println("intersection:        ", evens.Intersection(primes))
+	fmt.Println("evens - primes:      ", evens.Difference(primes))
+	fmt.Println("symmetric difference:", evens.SymmetricDifference(primes))
+	fmt.Println("{2, 4} subset of evens:", NewSet(2, 4).IsSubset(evens))
+	fmt.Println("evens equal to {10, 8, 6, 4, 2}:", evens.Equal(NewSet(10, 8, 6, 4, 2)))
+
+	clone := evens.Clone()
+	clone.Add(12, 12, 14)
+	clone.Remove(2)
+	fmt.Println("clone with 12 and 14 added and 2 removed:", clone, "- evens unchanged:", evens)
+
+	fmt.Println("\n=== Iteration order ===")
+	colors := NewSet("red", "Green", "blue", "Yellow", "cyan")
+	fmt.Print("map order (changes between runs):")
+	for color := range colors.All() {
+		fmt.Print(" ", color)
+	}
+	fmt.Println()
+	fmt.Println("sorted:                    ", Sorted(colors))
+	fmt.Println("case insensitive:          ", colors.SortedFunc(func(a, b string) int {
+		return cmp.Compare(strings.ToLower(a), strings.ToLower(b))
+	}))
+	fmt.Println("by length, then name:      ", colors.SortedFunc(func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(a), len(b)), cmp.Compare(a, b))
+	}))
+
+	fmt.Println("\n=== Comparing the words of two texts ===")
+	golang := `Go is an open source programming language that makes it easy to build
+    simple, reliable, and efficient software. Go was designed at Google in 2007
+    by Robert Griesemer, Rob Pike, and Ken Thompson.`
+	rust := `Rust is a programming language designed for performance and safety,
+    especially safe concurrency. Rust is syntactically similar to C++, and makes
+    memory safety possible without garbage collection.`
+
+	allWords := wordPattern.FindAllString(strings.ToLower(golang), -1)
+	goWords := vocabulary(golang)
+	rustWords := vocabulary(rust)
+	fmt.Printf("Go text: %d words, %d distinct without stop words\n", len(allWords), goWords.Len())
+	fmt.Println("In both texts:   ", goWords.Intersection(rustWords))
+	fmt.Println("Only in Go text: ", goWords.Difference(rustWords))
+	fmt.Println("Only in Rust text:", rustWords.Difference(goWords))
+
+	shared := goWords.Intersection(rustWords).Len()
+	fmt.Printf("Similarity (Jaccard index): %.2f\n", float64(shared)/float64(goWords.Union(rustWords).Len()))
+}
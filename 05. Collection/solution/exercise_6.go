@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"strings"
+)
+
+// pair is a key and its value, stored in the list of an OrderedMap
+type pair[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// OrderedMap is a map that remembers the order its keys were added in.
+// Ranging over a Go map visits the keys in a random order, which changes from
+// one run to the next; an OrderedMap always gives them back in insertion order.
+//
+// The values are kept in a doubly linked list, in order, and the map points
+// to the elements of the list: finding, adding and deleting a key are all
+// done in constant time.
+type OrderedMap[K comparable, V any] struct {
+	elements map[K]*list.Element
+	order    *list.List
+}
+
+// NewOrderedMap creates an empty ordered map
+func NewOrderedMap[K comparable, V any]() *OrderedMap[K, V] {
+	return &OrderedMap[K, V]{
+		elements: make(map[K]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Set adds a key at the end, or replaces the value of an existing key
+// without moving it
+func (m *OrderedMap[K, V]) Set(key K, value V) {
+	if element, ok := m.elements[key]; ok {
+		element.Value.(*pair[K, V]).value = value
+		return
+	}
+	m.elements[key] = m.order.PushBack(&pair[K, V]{key: key, value: value})
+}
+
+// Get returns the value of a key, and whether the key is present
+func (m *OrderedMap[K, V]) Get(key K) (V, bool) {
+	element, ok := m.elements[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return element.Value.(*pair[K, V]).value, true
+}
+
+// Delete removes a key, and reports whether it was present
+func (m *OrderedMap[K, V]) Delete(key K) bool {
+	element, ok := m.elements[key]
+	if !ok {
+		return false
+	}
+	m.order.Remove(element)
+	delete(m.elements, key)
+	return true
+}
+
+// Len returns the number of keys
+func (m *OrderedMap[K, V]) Len() int {
+	return len(m.elements)
+}
+
+// All returns an iterator over the keys and values in insertion order
+func (m *OrderedMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for element := m.order.Front(); element != nil; element = element.Next() {
+			p := element.Value.(*pair[K, V])
+			if !yield(p.key, p.value) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over the keys and values, newest first
+func (m *OrderedMap[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for element := m.order.Back(); element != nil; element = element.Prev() {
+			p := element.Value.(*pair[K, V])
+			if !yield(p.key, p.value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns the keys in insertion order
+func (m *OrderedMap[K, V]) Keys() []K {
+	keys := make([]K, 0, m.Len())
+	for key := range m.All() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// MarshalJSON writes the map as a JSON object with its keys in insertion
+// order. encoding/json sorts the keys of a Go map instead.
+func (m *OrderedMap[K, V]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for key, value := range m.All() {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+
+		// JSON object keys are strings: other keys, such as numbers, are
+		// written as text like encoding/json does
+		keyJSON, err := json.Marshal(fmt.Sprint(key))
+		if err != nil {
+			return nil, err
+		}
+		valueJSON, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("value of key %v: %w", key, err)
+		}
+		buf.Write(keyJSON)
+		buf.WriteByte(':')
+		buf.Write(valueJSON)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON reads a JSON object, keeping the keys in the order they
+// appear in the document
+func (m *OrderedMap[K, V]) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != json.Delim('{') {
+		return fmt.Errorf("expected a JSON object, got %v", token)
+	}
+
+	*m = *NewOrderedMap[K, V]()
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		text := token.(string) // Object keys are always strings
+		key, err := parseKey[K](text)
+		if err != nil {
+			return err
+		}
+
+		var value V
+		if err := decoder.Decode(&value); err != nil {
+			return fmt.Errorf("value of key %q: %w", text, err)
+		}
+		m.Set(key, value)
+	}
+	_, err = decoder.Token() // The closing }
+	return err
+}
+
+// parseKey converts a JSON object key to K: a string key is used as is, and
+// other keys, such as numbers, are parsed from their text
+func parseKey[K comparable](text string) (K, error) {
+	var key K
+	if s, ok := any(&key).(*string); ok {
+		*s = text
+		return key, nil
+	}
+	if err := json.Unmarshal([]byte(text), &key); err != nil {
+		return key, fmt.Errorf("invalid key %q: %w", text, err)
+	}
+	return key, nil
+}
+
+// Contact holds information about a person, as in exercise 3
+type Contact struct {
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+}
+
+func (c Contact) FullName() string {
+	return c.FirstName + " " + c.LastName
+}
+
+// ContactBook keeps the contacts in the order they were added: the first
+// contact is the first on the speed dial, and the book always prints the same
+type ContactBook struct {
+	contacts *OrderedMap[string, Contact]
+}
+
+// NewContactBook creates a new contact book
+func NewContactBook() *ContactBook {
+	return &ContactBook{contacts: NewOrderedMap[string, Contact]()}
+}
+
+// AddContact adds a contact, or updates it in place when it already exists
+func (cb *ContactBook) AddContact(contact Contact) {
+	cb.contacts.Set(strings.ToLower(contact.FirstName+":"+contact.LastName), contact)
+}
+
+// DeleteContact removes a contact by their full name
+func (cb *ContactBook) DeleteContact(firstName, lastName string) bool {
+	return cb.contacts.Delete(strings.ToLower(firstName + ":" + lastName))
+}
+
+// GroupByLetter groups the contacts by the first letter of their last name.
+// The groups are in the order their first contact was added.
+func (cb *ContactBook) GroupByLetter() *OrderedMap[string, []string] {
+	groups := NewOrderedMap[string, []string]()
+	for _, contact := range cb.contacts.All() {
+		letter := strings.ToUpper(contact.LastName[:1])
+		names, _ := groups.Get(letter)
+		groups.Set(letter, append(names, contact.FullName()))
+	}
+	return groups
+}
+
+func (cb *ContactBook) Print() {
+	i := 1
+	for _, contact := range cb.contacts.All() {
+		fmt.Printf("  %d. %-14s %-24s %s\n", i, contact.FullName(), contact.Email, contact.Phone)
+		i++
+	}
+}
+
+func main() {
+	fmt.Println("=== A Go map has no order ===")
+	plain := map[string]int{"one": 1, "two": 2, "three": 3, "four": 4, "five": 5}
+	for run := 1; run <= 3; run++ {
+		fmt.Printf("  range %d:", run)
+		for key := range plain {
+			fmt.Print(" ", key)
+		}
+		fmt.Println()
+	}
+	plainJSON, _ := json.Marshal(plain)
+	fmt.Println("  JSON, keys sorted:", string(plainJSON))
+
+	fmt.Println("\n=== An ordered map keeps the insertion order ===")
+	ordered := NewOrderedMap[string, int]()
+	for _, key := range []string{"one", "two", "three", "four", "five"} {
+		ordered.Set(key, len(key))
+	}
+	ordered.Set("two", 22) // Updating keeps the position
+	ordered.Delete("four")
+	ordered.Set("six", 3)
+	for run := 1; run <= 3; run++ {
+		fmt.Printf("  range %d: %v\n", run, ordered.Keys())
+	}
+	fmt.Print("  backward:")
+	for key, value := range ordered.Backward() {
+		fmt.Printf(" %s=%d", key, value)
+	}
+	fmt.Println()
+	orderedJSON, _ := json.Marshal(ordered)
+	fmt.Println("  JSON, keys in order:", string(orderedJSON))
+
+	// Keys other than strings are written as text, and parsed back
+	squares := NewOrderedMap[int, int]()
+	for _, n := range []int{3, 1, 2} {
+		squares.Set(n, n*n)
+	}
+	squaresJSON, _ := json.Marshal(squares)
+	var decodedSquares OrderedMap[int, int]
+	if err := json.Unmarshal(squaresJSON, &decodedSquares); err != nil {
+		fmt.Println("  Error:", err)
+	}
+	fmt.Printf("  int keys: %s, decoded keys %v\n", squaresJSON, decodedSquares.Keys())
+
+	fmt.Println("\n=== Contact book ===")
+	book := NewContactBook()
+	book.AddContact(Contact{"John", "Doe", "john.doe@example.com", "555-1234"})
+	book.AddContact(Contact{"Jane", "Smith", "jane.smith@example.com", "555-5678"})
+	book.AddContact(Contact{"Alice", "Johnson", "alice.j@example.com", "555-9012"})
+	book.AddContact(Contact{"Bob", "Brown", "bob.brown@example.com", "555-3456"})
+	book.AddContact(Contact{"John", "Smith", "john.smith@example.com", "555-7890"})
+	book.Print()
+
+	fmt.Println("\nAfter updating Jane's phone and deleting John Doe:")
+	book.AddContact(Contact{"Jane", "Smith", "jane.smith@example.com", "555-0000"})
+	book.DeleteContact("John", "Doe")
+	book.Print()
+
+	fmt.Println("\nGrouped by last name:")
+	for letter, names := range book.GroupByLetter().All() {
+		fmt.Printf("  %s: %s\n", letter, strings.Join(names, ", "))
+	}
+
+	fmt.Println("\nExported as JSON:")
+	data, err := json.MarshalIndent(book.contacts, "  ", "  ")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Println("  " + string(data))
+
+	// Importing the JSON gives the contacts back in the same order
+	imported := NewContactBook()
+	if err := json.Unmarshal(data, imported.contacts); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Println("\nImported from JSON:")
+	imported.Print()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"io"
+	"math/rand/v2"
+	"strings"
+)
+
+var syllables = []string{"ka", "lo", "mi", "ne", "ru", "sa", "to", "vi", "go", "pe", "da", "fu"}
+
+// vocabulary makes n distinct words from syllables
+func vocabulary(n int) []string {
+	words := make([]string, n)
+	for i := range words {
+		var b strings.Builder
+		// Write i in base len(syllables), one syllable per digit
+		for v := i + 1; v > 0; v /= len(syllables) {
+			b.WriteString(syllables[v%len(syllables)])
+		}
+		words[i] = b.String()
+	}
+	return words
+}
+
+// corpus is a generated text read word by word
+type corpus struct {
+	words []string
+	zipf  *rand.Zipf
+	left  int
+	buf   []byte
+}
+
+// NewCorpus returns a reader of a text of length words drawn from a
+// vocabulary of distinct words. Like in real texts, the frequencies follow
+// Zipf's law: the second most common word appears about half as often as the
+// first, the third a third as often, and so on. The same seed gives the same
+// text.
+func NewCorpus(length, distinct int, seed uint64) io.Reader {
+	r := rand.New(rand.NewPCG(seed, seed))
+	return &corpus{
+		words: vocabulary(distinct),
+		zipf:  rand.NewZipf(r, 1.07, 2, uint64(distinct-1)),
+		left:  length,
+	}
+}
+
+func (c *corpus) Read(p []byte) (int, error) {
+	for len(c.buf) < len(p) && c.left > 0 {
+		c.buf = append(c.buf, c.words[c.zipf.Uint64()]...)
+		c.buf = append(c.buf, ' ')
+		c.left--
+	}
+	if len(c.buf) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+func main() {
+	fmt.Println("=== Top words of a short text ===")
+	text := `Go is an open source programming language that makes it easy to build
+    simple, reliable, and efficient software. Go was designed at Google in 2007
+    by Robert Griesemer, Rob Pike, and Ken Thompson. Go is syntactically similar
+    to C, but with memory safety, garbage collection, structural typing, and
+    CSP-style concurrency. The language is often referred to as Golang because of
+    its former domain name, golang.org, but the proper name is Go.`
+	counts, err := CountWords(strings.NewReader(text))
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	printTop(TopKByHeap(counts, 5))
+
+	fmt.Println("\n=== Top words of a large generated corpus ===")
+	const length, distinct, k = 2_000_000, 500_000, 10
+	start := time.Now()
+	counts, err = CountWords(NewCorpus(length, distinct, 42))
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Printf("Counted %d words, %d distinct, in %v\n", length, len(counts), time.Since(start).Round(time.Millisecond))
+
+	start = time.Now()
+	bySort := TopKBySort(counts, k)
+	sortTime := time.Since(start)
+
+	start = time.Now()
+	byHeap := TopKByHeap(counts, k)
+	heapTime := time.Since(start)
+
+	printTop(byHeap)
+	fmt.Printf("\nSorting all words: %v\n", sortTime.Round(time.Microsecond))
+	fmt.Printf("Heap of %d words:  %v\n", k, heapTime.Round(time.Microsecond))
+	fmt.Println("Same result:", slices.Equal(bySort, byHeap))
+}
+
+func printTop(top []WordCount) {
+	fmt.Printf("%-4s %-15s %s\n", "RANK", "WORD", "COUNT")
+	for i, wc := range top {
+		fmt.Printf("%-4d %-15s %d\n", i+1, wc.Word, wc.Count)
+	}
+}
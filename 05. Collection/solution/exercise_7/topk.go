@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"container/heap"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// WordCount is a word and the number of times it appears
+type WordCount struct {
+	Word  string
+	Count int
+}
+
+// byRank orders the most frequent words first, and words with the same
+// count alphabetically, so the result doesn't depend on the map order
+func byRank(a, b WordCount) int {
+	return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Word, b.Word))
+}
+
+// rankHeap implements heap.Interface. Its root is the lowest ranked word,
+// the first one to drop when a better word comes.
+type rankHeap []WordCount
+
+func (h rankHeap) Len() int           { return len(h) }
+func (h rankHeap) Less(i, j int) bool { return byRank(h[i], h[j]) > 0 }
+func (h rankHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *rankHeap) Push(x any) { *h = append(*h, x.(WordCount)) }
+
+func (h *rankHeap) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
+
+// TopK keeps the k highest ranked words of a stream. Each word costs
+// O(log k): the heap never holds more than k words, however long the stream.
+type TopK struct {
+	k    int
+	heap rankHeap
+}
+
+// NewTopK creates a selector of the k highest ranked words
+func NewTopK(k int) *TopK {
+	return &TopK{k: k, heap: make(rankHeap, 0, k)}
+}
+
+// Add offers a word to the selector
+func (t *TopK) Add(wc WordCount) {
+	switch {
+	case t.k <= 0:
+		return
+	case len(t.heap) < t.k:
+		heap.Push(&t.heap, wc)
+	case byRank(wc, t.heap[0]) < 0:
+		// Better than the lowest of the top k: it takes its place
+		t.heap[0] = wc
+		heap.Fix(&t.heap, 0)
+	}
+}
+
+// Result returns the words kept, highest ranked first. Sorting k words is
+// cheap next to sorting them all.
+func (t *TopK) Result() []WordCount {
+	result := slices.Clone(t.heap)
+	slices.SortFunc(result, byRank)
+	return result
+}
+
+// TopKByHeap returns the k most frequent words in O(n log k)
+func TopKByHeap(counts map[string]int, k int) []WordCount {
+	top := NewTopK(k)
+	for word, count := range counts {
+		top.Add(WordCount{word, count})
+	}
+	return top.Result()
+}
+
+// TopKBySort returns the k most frequent words by sorting them all, in
+// O(n log n), as exercise 2 does
+func TopKBySort(counts map[string]int, k int) []WordCount {
+	all := make([]WordCount, 0, len(counts))
+	for word, count := range counts {
+		all = append(all, WordCount{word, count})
+	}
+	slices.SortFunc(all, byRank)
+	return all[:min(k, len(all))]
+}
+
+// CountWords counts the words read from r, one word at a time, without
+// holding the text in memory
+func CountWords(r io.Reader) (map[string]int, error) {
+	counts := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimFunc(scanner.Text(), func(r rune) bool {
+			return !unicode.IsLetter(r)
+		}))
+		if word != "" {
+			counts[word]++
+		}
+	}
+	return counts, scanner.Err()
+}
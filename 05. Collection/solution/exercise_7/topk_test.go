@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTopKMatchesSort(t *testing.T) {
+	counts, err := CountWords(NewCorpus(50_000, 5_000, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []int{0, 1, 10, 100, len(counts), len(counts) + 10} {
+		got, want := TopKByHeap(counts, k), TopKBySort(counts, k)
+		if !slices.Equal(got, want) {
+			t.Errorf("k=%d: heap and sort disagree\nheap: %v\nsort: %v", k, got[:min(5, len(got))], want[:min(5, len(want))])
+		}
+	}
+}
+
+func TestTopKTies(t *testing.T) {
+	// Words with the same count are ranked alphabetically, whatever the order
+	// they arrive in
+	top := NewTopK(3)
+	for _, wc := range []WordCount{{"pear", 2}, {"fig", 5}, {"apple", 2}, {"kiwi", 2}, {"date", 1}} {
+		top.Add(wc)
+	}
+	want := []WordCount{{"fig", 5}, {"apple", 2}, {"kiwi", 2}}
+	if got := top.Result(); !slices.Equal(got, want) {
+		t.Errorf("Result() = %v, want %v", got, want)
+	}
+}
+
+func TestCountWords(t *testing.T) {
+	counts, err := CountWords(strings.NewReader("Go, go! GO? It's golang.org -- go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"go": 4, "it's": 1, "golang.org": 1}
+	if len(counts) != len(want) {
+		t.Fatalf("CountWords() = %v, want %v", counts, want)
+	}
+	for word, count := range want {
+		if counts[word] != count {
+			t.Errorf("count of %q = %d, want %d", word, counts[word], count)
+		}
+	}
+}
+
+// The heap pulls ahead as the number of distinct words grows, since sorting
+// costs O(n log n) and the heap O(n log k). Run with:
+//
+//	go test -bench . -benchmem
+func BenchmarkTopK(b *testing.B) {
+	for _, distinct := range []int{10_000, 100_000, 1_000_000} {
+		counts, err := CountWords(NewCorpus(2*distinct, distinct, 7))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("sort/%d", distinct), func(b *testing.B) {
+			for b.Loop() {
+				TopKBySort(counts, 10)
+			}
+		})
+		b.Run(fmt.Sprintf("heap/%d", distinct), func(b *testing.B) {
+			for b.Loop() {
+				TopKByHeap(counts, 10)
+			}
+		})
+	}
+}
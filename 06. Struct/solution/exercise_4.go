@@ -0,0 +1,372 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Date is a calendar day, written in JSON as "2006-01-02". time.Time would
+// write a full timestamp such as "2024-03-01T00:00:00Z", which says more
+// than a hire date means.
+type Date struct {
+	time.Time
+}
+
+const dateLayout = "2006-01-02"
+
+// NewDate creates a date in UTC
+func NewDate(year int, month time.Month, day int) Date {
+	return Date{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// MarshalJSON writes the date as "2006-01-02", or null for the zero date
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.Format(dateLayout))
+}
+
+// UnmarshalJSON reads a date written by MarshalJSON
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = Date{}
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("date must be a string: %w", err)
+	}
+	t, err := time.Parse(dateLayout, text)
+	if err != nil {
+		return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", text)
+	}
+	*d = Date{t}
+	return nil
+}
+
+func (d Date) String() string {
+	return d.Format(dateLayout)
+}
+
+// ===== Library (exercise 1) =====
+
+// Book represents a book in the library
+type Book struct {
+	ID            string `json:"id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	PublishedYear int    `json:"published_year,omitempty"` // Left out when unknown
+	Available     bool   `json:"available"`
+}
+
+// Member represents a library member
+type Member struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Email    string `json:"email,omitempty"`
+	JoinedOn Date   `json:"joined_on"`
+	BooksOut int    `json:"-"` // Not saved: counted again from the borrow records
+	MaxBooks int    `json:"max_books"`
+}
+
+// BorrowRecord tracks a book being borrowed
+type BorrowRecord struct {
+	BookID     string     `json:"book_id"`
+	MemberID   string     `json:"member_id"`
+	BorrowedOn time.Time  `json:"borrowed_on"` // An instant: a full RFC 3339 timestamp
+	DueDate    Date       `json:"due_date"`
+	ReturnedOn *time.Time `json:"returned_on,omitempty"` // Left out while the book is out
+}
+
+// Library manages the book collection and members
+type Library struct {
+	Name    string             `json:"name"`
+	Books   map[string]*Book   `json:"books"`
+	Members map[string]*Member `json:"members"`
+	Borrows []BorrowRecord     `json:"borrows"`
+}
+
+// countBooksOut sets the BooksOut of the members from the borrow records,
+// after loading a library
+func (l *Library) countBooksOut() {
+	for _, member := range l.Members {
+		member.BooksOut = 0
+	}
+	for _, record := range l.Borrows {
+		if member, ok := l.Members[record.MemberID]; ok && record.ReturnedOn == nil {
+			member.BooksOut++
+		}
+	}
+}
+
+// ===== Company (exercise 2) =====
+
+// Address represents a physical address. The db tags name the columns of a
+// database table; packages such as sqlx read them, like encoding/json reads
+// the json tags.
+type Address struct {
+	Street     string `json:"street" db:"street"`
+	City       string `json:"city" db:"city"`
+	State      string `json:"state,omitempty" db:"state"`
+	PostalCode string `json:"postal_code" db:"postal_code"`
+	Country    string `json:"country" db:"country"`
+}
+
+// Employee defines the base employee structure
+type Employee struct {
+	ID         string  `json:"id" db:"id"`
+	FirstName  string  `json:"first_name" db:"first_name"`
+	LastName   string  `json:"last_name" db:"last_name"`
+	Email      string  `json:"email" db:"email"`
+	HireDate   Date    `json:"hire_date" db:"hire_date"`
+	Address    Address `json:"address"`
+	Position   string  `json:"position" db:"position"`
+	Salary     float64 `json:"salary" db:"salary"`
+	ManagerID  string  `json:"manager_id,omitempty" db:"manager_id"` // The CEO has no manager
+	Department string  `json:"department" db:"department"`
+	IsActive   bool    `json:"is_active" db:"is_active"`
+}
+
+// Company contains all employees and departments
+type Company struct {
+	Name        string               `json:"name"`
+	Employees   map[string]*Employee `json:"employees"`
+	Departments map[string][]string  `json:"departments"` // Department name -> employee IDs
+}
+
+// ===== Inventory (exercise 3) =====
+
+// Product represents an item in the inventory
+type Product struct {
+	SKU          string  `json:"sku"`
+	Name         string  `json:"name"`
+	Description  string  `json:"description,omitempty"`
+	Category     string  `json:"category"`
+	Price        float64 `json:"price"`
+	Cost         float64 `json:"cost"`
+	StockLevel   int     `json:"stock_level"`
+	ReorderLevel int     `json:"reorder_level"`
+	Supplier     string  `json:"supplier,omitempty"`
+	DateAdded    Date    `json:"date_added,omitzero"` // omitempty never leaves out a struct, omitzero does
+}
+
+// Transaction represents an inventory transaction
+type Transaction struct {
+	ID         string    `json:"id"`
+	ProductSKU string    `json:"product_sku"`
+	Type       string    `json:"type"` // "purchase", "sale", "adjustment"
+	Quantity   int       `json:"quantity"`
+	Date       time.Time `json:"date"`
+	Reference  string    `json:"reference,omitempty"` // Invoice or order number
+}
+
+// Inventory manages the product catalog and transactions
+type Inventory struct {
+	Products     map[string]*Product `json:"products"`
+	Transactions []Transaction       `json:"transactions"`
+}
+
+// ===== Persistence =====
+
+// snapshotVersion is increased when the file format changes
+const snapshotVersion = 1
+
+// Snapshot is the content of a save file
+type Snapshot struct {
+	Version   int        `json:"version"`
+	SavedAt   time.Time  `json:"saved_at"`
+	Library   *Library   `json:"library"`
+	Company   *Company   `json:"company"`
+	Inventory *Inventory `json:"inventory"`
+}
+
+// Save writes the snapshot as indented JSON. It writes a temporary file and
+// renames it, so a crash while writing never leaves half a file behind.
+func Save(path string, snapshot *Snapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".snapshot-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // Fails harmlessly once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load reads a snapshot written by Save. Unknown fields are refused, so a
+// misspelled field in a hand-edited file is an error instead of being
+// silently dropped.
+func Load(path string) (*Snapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	var snapshot Snapshot
+	if err := decoder.Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", filepath.Base(path), err)
+	}
+	if snapshot.Version != snapshotVersion {
+		return nil, fmt.Errorf("%s has version %d, expected %d", filepath.Base(path), snapshot.Version, snapshotVersion)
+	}
+	if snapshot.Library != nil {
+		snapshot.Library.countBooksOut()
+	}
+	return &snapshot, nil
+}
+
+// columns lists the database columns of a struct from its db tags, the way
+// a database package would. Nested structs add their own columns.
+func columns(t reflect.Type) []string {
+	var names []string
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if column, ok := field.Tag.Lookup("db"); ok {
+			names = append(names, column)
+		} else if field.Type.Kind() == reflect.Struct {
+			names = append(names, columns(field.Type)...)
+		}
+	}
+	return names
+}
+
+func sampleSnapshot() *Snapshot {
+	borrowed := time.Date(2025, time.March, 3, 14, 30, 0, 0, time.UTC)
+	returned := borrowed.Add(6 * 24 * time.Hour)
+
+	library := &Library{
+		Name: "City Library",
+		Books: map[string]*Book{
+			"B001": {ID: "B001", Title: "The Go Programming Language", Author: "Alan Donovan", PublishedYear: 2015},
+			"B002": {ID: "B002", Title: "Learning Go", Author: "Jon Bodner", PublishedYear: 2021, Available: true},
+			"B003": {ID: "B003", Title: "Old Manuscript", Author: "Unknown", Available: true},
+		},
+		Members: map[string]*Member{
+			"M001": {ID: "M001", Name: "Alice", Email: "alice@example.com", JoinedOn: NewDate(2024, time.January, 15), MaxBooks: 3, BooksOut: 1},
+			"M002": {ID: "M002", Name: "Bob", JoinedOn: NewDate(2024, time.June, 2), MaxBooks: 2},
+		},
+		Borrows: []BorrowRecord{
+			{BookID: "B001", MemberID: "M001", BorrowedOn: borrowed, DueDate: NewDate(2025, time.March, 17)},
+			{BookID: "B002", MemberID: "M002", BorrowedOn: borrowed, DueDate: NewDate(2025, time.March, 17), ReturnedOn: &returned},
+		},
+	}
+
+	company := &Company{
+		Name: "Acme Corp",
+		Employees: map[string]*Employee{
+			"E001": {
+				ID: "E001", FirstName: "Grace", LastName: "Hopper", Email: "grace@acme.example",
+				HireDate: NewDate(2018, time.May, 1), Position: "CEO", Salary: 250000,
+				Department: "Executive", IsActive: true,
+				Address: Address{Street: "1 Main St", City: "Springfield", State: "IL", PostalCode: "62701", Country: "USA"},
+			},
+			"E002": {
+				ID: "E002", FirstName: "Linus", LastName: "Berg", Email: "linus@acme.example",
+				HireDate: NewDate(2021, time.September, 13), Position: "Engineer", Salary: 120000,
+				ManagerID: "E001", Department: "Engineering", IsActive: true,
+				Address: Address{Street: "5 Fjord Rd", City: "Bergen", PostalCode: "5003", Country: "Norway"},
+			},
+		},
+		Departments: map[string][]string{"Executive": {"E001"}, "Engineering": {"E002"}},
+	}
+
+	inventory := &Inventory{
+		Products: map[string]*Product{
+			"P001": {SKU: "P001", Name: "Laptop", Description: "14 inch, 16 GB", Category: "Electronics", Price: 1200, Cost: 900, StockLevel: 8, ReorderLevel: 5, Supplier: "TechCo", DateAdded: NewDate(2025, time.January, 10)},
+			"P002": {SKU: "P002", Name: "Mouse", Category: "Electronics", Price: 25, Cost: 10, StockLevel: 40, ReorderLevel: 10},
+		},
+		Transactions: []Transaction{
+			{ID: "T001", ProductSKU: "P001", Type: "purchase", Quantity: 10, Date: borrowed, Reference: "PO-1001"},
+			{ID: "T002", ProductSKU: "P001", Type: "sale", Quantity: 2, Date: borrowed.Add(time.Hour)},
+		},
+	}
+
+	return &Snapshot{Version: snapshotVersion, SavedAt: borrowed, Library: library, Company: company, Inventory: inventory}
+}
+
+func main() {
+	snapshot := sampleSnapshot()
+
+	fmt.Println("=== Tags rename fields and leave out empty ones ===")
+	for _, v := range []any{snapshot.Library.Books["B003"], snapshot.Library.Members["M002"], snapshot.Library.Borrows[0], snapshot.Inventory.Products["P002"]} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Printf("%T\n  %s\n", v, data)
+	}
+
+	fmt.Println("\n=== Dates ===")
+	employee, _ := json.Marshal(snapshot.Company.Employees["E001"])
+	fmt.Printf("Employee with a Date hire date:\n  %s\n", employee)
+	var date Date
+	err := json.Unmarshal([]byte(`"2025-02-30"`), &date)
+	fmt.Println("Invalid date:", err)
+
+	fmt.Println("\n=== Database columns from db tags ===")
+	fmt.Println("employees:", strings.Join(columns(reflect.TypeOf(Employee{})), ", "))
+
+	fmt.Println("\n=== Round trip through a file ===")
+	dir, err := os.MkdirTemp("", "structs-")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot.json")
+
+	if err := Save(path, snapshot); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	info, _ := os.Stat(path)
+	fmt.Printf("Saved %s (%d bytes)\n", filepath.Base(path), info.Size())
+
+	loaded, err := Load(path)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Println("Loaded snapshot equals the saved one:", reflect.DeepEqual(snapshot, loaded))
+	fmt.Printf("Alice has %d book out, counted from the borrow records\n", loaded.Library.Members["M001"].BooksOut)
+
+	fmt.Println("\n=== Invalid files ===")
+	invalid := map[string]string{
+		"typo.json":    `{"version": 1, "libary": {}}`,
+		"version.json": `{"version": 2}`,
+		"date.json":    `{"version": 1, "company": {"employees": {"E9": {"hire_date": "03/01/2024"}}}}`,
+	}
+	for _, name := range []string{"typo.json", "version.json", "date.json"} {
+		badPath := filepath.Join(dir, name)
+		if err := os.WriteFile(badPath, []byte(invalid[name]), 0o644); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		_, err := Load(badPath)
+		fmt.Println("Error:", err)
+	}
+
+	_, err = Load(filepath.Join(dir, "missing.json"))
+	fmt.Println("Missing file:", errors.Is(err, os.ErrNotExist))
+}
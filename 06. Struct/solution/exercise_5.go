@@ -0,0 +1,338 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Address represents a physical address
+type Address struct {
+	Street     string
+	City       string
+	State      string
+	PostalCode string
+	Country    string
+}
+
+// Employee defines the base employee structure, as in exercise 2
+type Employee struct {
+	ID         string
+	FirstName  string
+	LastName   string
+	Email      string
+	HireDate   time.Time
+	Address    Address
+	Position   string
+	Salary     float64
+	ManagerID  string
+	Department string
+	IsActive   bool
+}
+
+// Product represents an item in the inventory, as in exercise 3
+type Product struct {
+	SKU          string
+	Name         string
+	Description  string
+	Category     string
+	Price        float64
+	Cost         float64
+	StockLevel   int
+	ReorderLevel int
+	Supplier     string
+	DateAdded    time.Time
+}
+
+// ===== Employee builder =====
+
+// EmployeeBuilder builds an Employee step by step. Each method returns the
+// builder, so the calls can be chained; the problems are collected and
+// reported together by Build, instead of one error per call.
+type EmployeeBuilder struct {
+	employee Employee
+	errs     []error
+}
+
+// NewEmployeeBuilder starts an employee with the defaults: hired today,
+// active, in the General department
+func NewEmployeeBuilder(id string) *EmployeeBuilder {
+	return &EmployeeBuilder{employee: Employee{
+		ID:         id,
+		HireDate:   time.Now().Truncate(24 * time.Hour),
+		Department: "General",
+		IsActive:   true,
+	}}
+}
+
+func (b *EmployeeBuilder) fail(format string, args ...any) *EmployeeBuilder {
+	b.errs = append(b.errs, fmt.Errorf(format, args...))
+	return b
+}
+
+func (b *EmployeeBuilder) Name(first, last string) *EmployeeBuilder {
+	b.employee.FirstName = strings.TrimSpace(first)
+	b.employee.LastName = strings.TrimSpace(last)
+	return b
+}
+
+func (b *EmployeeBuilder) Email(email string) *EmployeeBuilder {
+	address, err := mail.ParseAddress(email)
+	if err != nil {
+		// Kept as given, so Build reports it as invalid rather than missing
+		b.employee.Email = email
+		return b.fail("invalid email %q", email)
+	}
+	b.employee.Email = strings.ToLower(address.Address)
+	return b
+}
+
+func (b *EmployeeBuilder) HiredOn(date time.Time) *EmployeeBuilder {
+	if date.After(time.Now()) {
+		return b.fail("hire date %s is in the future", date.Format("2006-01-02"))
+	}
+	b.employee.HireDate = date
+	return b
+}
+
+func (b *EmployeeBuilder) LivesAt(street, city, postalCode, country string) *EmployeeBuilder {
+	b.employee.Address = Address{Street: street, City: city, PostalCode: postalCode, Country: country}
+	return b
+}
+
+// Job sets the position, the department and the yearly salary
+func (b *EmployeeBuilder) Job(position, department string, salary float64) *EmployeeBuilder {
+	if salary <= 0 {
+		b.fail("salary must be positive, got %.2f", salary)
+	}
+	b.employee.Position = position
+	b.employee.Department = department
+	b.employee.Salary = salary
+	return b
+}
+
+func (b *EmployeeBuilder) ReportsTo(managerID string) *EmployeeBuilder {
+	b.employee.ManagerID = managerID
+	return b
+}
+
+// Clone copies the builder, to use it as a template for similar employees
+func (b *EmployeeBuilder) Clone(id string) *EmployeeBuilder {
+	clone := &EmployeeBuilder{employee: b.employee, errs: append([]error(nil), b.errs...)}
+	clone.employee.ID = id
+	return clone
+}
+
+// Build checks the required fields and returns the employee, or all the
+// problems found. The builder can be used again: the employee is a copy.
+func (b *EmployeeBuilder) Build() (Employee, error) {
+	errs := append([]error(nil), b.errs...)
+	e := b.employee
+	if e.ID == "" {
+		errs = append(errs, errors.New("ID is required"))
+	}
+	if e.FirstName == "" || e.LastName == "" {
+		errs = append(errs, errors.New("first and last name are required"))
+	}
+	if e.Email == "" {
+		errs = append(errs, errors.New("email is required"))
+	}
+	if e.Position == "" {
+		errs = append(errs, errors.New("job is required"))
+	}
+	if e.ManagerID == e.ID && e.ID != "" {
+		errs = append(errs, errors.New("an employee can't report to themselves"))
+	}
+	if len(errs) > 0 {
+		return Employee{}, fmt.Errorf("employee %s: %w", e.ID, errors.Join(errs...))
+	}
+	return e, nil
+}
+
+// ===== Product builder =====
+
+// ProductBuilder builds a Product step by step, like EmployeeBuilder
+type ProductBuilder struct {
+	product Product
+	errs    []error
+}
+
+// NewProductBuilder starts a product with its required SKU and name. It
+// defaults to the Uncategorized category, added today.
+func NewProductBuilder(sku, name string) *ProductBuilder {
+	return &ProductBuilder{product: Product{
+		SKU:       strings.ToUpper(sku),
+		Name:      name,
+		Category:  "Uncategorized",
+		DateAdded: time.Now(),
+	}}
+}
+
+func (b *ProductBuilder) Describe(category, description string) *ProductBuilder {
+	b.product.Category = category
+	b.product.Description = description
+	return b
+}
+
+// Pricing sets the selling price and the cost
+func (b *ProductBuilder) Pricing(price, cost float64) *ProductBuilder {
+	if price < 0 || cost < 0 {
+		b.errs = append(b.errs, fmt.Errorf("price and cost can't be negative, got %.2f and %.2f", price, cost))
+	}
+	b.product.Price = price
+	b.product.Cost = cost
+	return b
+}
+
+// Stock sets the stock level. The reorder level defaults to a fifth of it.
+func (b *ProductBuilder) Stock(level int) *ProductBuilder {
+	if level < 0 {
+		b.errs = append(b.errs, fmt.Errorf("stock can't be negative, got %d", level))
+	}
+	b.product.StockLevel = level
+	return b
+}
+
+func (b *ProductBuilder) ReorderAt(level int) *ProductBuilder {
+	b.product.ReorderLevel = level
+	return b
+}
+
+func (b *ProductBuilder) SuppliedBy(supplier string) *ProductBuilder {
+	b.product.Supplier = supplier
+	return b
+}
+
+// Build checks the product and fills in the defaults that depend on other
+// fields
+func (b *ProductBuilder) Build() (Product, error) {
+	errs := append([]error(nil), b.errs...)
+	p := b.product
+	if p.SKU == "" || p.Name == "" {
+		errs = append(errs, errors.New("SKU and name are required"))
+	}
+	if p.Price == 0 {
+		errs = append(errs, errors.New("pricing is required"))
+	}
+	if p.Price < p.Cost {
+		errs = append(errs, fmt.Errorf("price %.2f is below the cost %.2f", p.Price, p.Cost))
+	}
+	if len(errs) > 0 {
+		return Product{}, fmt.Errorf("product %s: %w", p.SKU, errors.Join(errs...))
+	}
+	if p.ReorderLevel == 0 {
+		p.ReorderLevel = max(1, p.StockLevel/5)
+	}
+	return p, nil
+}
+
+// ===== Functional options, for comparison =====
+
+// ProductOption configures a product created by NewProduct
+type ProductOption func(*Product)
+
+func WithStock(level, reorderAt int) ProductOption {
+	return func(p *Product) {
+		p.StockLevel = level
+		p.ReorderLevel = reorderAt
+	}
+}
+
+func WithSupplier(supplier string) ProductOption {
+	return func(p *Product) {
+		p.Supplier = supplier
+	}
+}
+
+// NewProduct takes the required fields as parameters and the optional ones
+// as options
+func NewProduct(sku, name string, price, cost float64, opts ...ProductOption) Product {
+	p := Product{SKU: sku, Name: name, Category: "Uncategorized", Price: price, Cost: cost, DateAdded: time.Now()}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
+
+func printEmployee(e Employee) {
+	fmt.Printf("  %s %s %s <%s>, %s in %s, $%.0f, hired %s, active %t, manager %q\n",
+		e.ID, e.FirstName, e.LastName, e.Email, e.Position, e.Department,
+		e.Salary, e.HireDate.Format("2006-01-02"), e.IsActive, e.ManagerID)
+}
+
+func printProduct(p Product) {
+	fmt.Printf("  %s %s (%s): price $%.2f, cost $%.2f, stock %d, reorder at %d, supplier %q\n",
+		p.SKU, p.Name, p.Category, p.Price, p.Cost, p.StockLevel, p.ReorderLevel, p.Supplier)
+}
+
+func main() {
+	fmt.Println("=== Struct literal ===")
+	// Nothing says a field was forgotten: the employee has no email, no
+	// department, and is not active because false is the zero value
+	literal := Employee{ID: "E100", FirstName: "Ada", LastName: "Lovelace", Position: "Analyst", Salary: 90000}
+	printEmployee(literal)
+
+	fmt.Println("\n=== Employee builder ===")
+	ceo, err := NewEmployeeBuilder("E001").
+		Name("Grace", "Hopper").
+		Email("Grace.Hopper@Acme.example").
+		HiredOn(time.Date(2018, time.May, 1, 0, 0, 0, 0, time.UTC)).
+		LivesAt("1 Main St", "Springfield", "62701", "USA").
+		Job("CEO", "Executive", 250000).
+		Build()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	printEmployee(ceo)
+
+	// A builder with the shared fields is a template for a team
+	engineer := NewEmployeeBuilder("").
+		Job("Engineer", "Engineering", 120000).
+		ReportsTo(ceo.ID)
+	for _, person := range []struct{ id, first, last string }{{"E002", "Linus", "Berg"}, {"E003", "Ken", "Ito"}} {
+		e, err := engineer.Clone(person.id).
+			Name(person.first, person.last).
+			Email(strings.ToLower(person.first) + "@acme.example").
+			Build()
+		if err != nil {
+			fmt.Println("Error:", err)
+			continue
+		}
+		printEmployee(e)
+	}
+
+	fmt.Println("\nAll the problems are reported at once:")
+	_, err = NewEmployeeBuilder("E009").
+		Name("", "Nobody").
+		Email("not an email").
+		HiredOn(time.Now().AddDate(1, 0, 0)).
+		Job("Intern", "Engineering", -1).
+		ReportsTo("E009").
+		Build()
+	fmt.Println("Error:", err)
+
+	fmt.Println("\n=== Product builder ===")
+	laptop, err := NewProductBuilder("p001", "Laptop").
+		Describe("Electronics", "14 inch, 16 GB").
+		Pricing(1200, 900).
+		Stock(50).
+		SuppliedBy("TechCo").
+		Build()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	printProduct(laptop)
+
+	_, err = NewProductBuilder("P002", "Mouse").Pricing(10, 25).Stock(-3).Build()
+	fmt.Println("Error:", err)
+
+	fmt.Println("\n=== Functional options ===")
+	// Options suit a few optional settings; a builder suits many fields,
+	// validation across fields and templates
+	mouse := NewProduct("P002", "Mouse", 25, 10, WithStock(40, 10), WithSupplier("ClickCo"))
+	printProduct(mouse)
+}
@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"log"
 	"net/http"
+	"os"
 	"time"
 )
 
@@ -37,16 +40,91 @@ type APIClient struct {
 	BaseURL    string
 	HTTPClient *http.Client
 	AuthToken  string
+	Retries    int // Times a request is sent again after a network or server error
+	Logger     *log.Logger
+
+	timeout time.Duration // Set by WithTimeout, applied by NewAPIClient
 }
 
-// NewAPIClient creates a new client with default settings
-func NewAPIClient(baseURL, token string) *APIClient {
-	return &APIClient{
-		BaseURL: baseURL,
-		HTTPClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		AuthToken: token,
+// ClientOption configures an APIClient created by NewAPIClient
+type ClientOption func(*APIClient)
+
+// WithAuthToken sends a bearer token with every request
+func WithAuthToken(token string) ClientOption {
+	return func(c *APIClient) {
+		c.AuthToken = token
+	}
+}
+
+// WithTimeout limits the time of a request, including reading the response,
+// whatever the order of the options
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *APIClient) {
+		c.timeout = timeout
+	}
+}
+
+// WithHTTPClient replaces the default HTTP client, for example to use a
+// custom transport; a nil client keeps the default one. The client is copied,
+// so WithTimeout never changes a shared client such as http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *APIClient) {
+		if httpClient != nil {
+			c.HTTPClient = httpClient
+		}
+	}
+}
+
+// WithRetries sets how many times a request is sent again after a network
+// error or a server error (5xx)
+func WithRetries(retries int) ClientOption {
+	return func(c *APIClient) {
+		c.Retries = retries
+	}
+}
+
+// WithLogger logs the failed attempts
+func WithLogger(logger *log.Logger) ClientOption {
+	return func(c *APIClient) {
+		c.Logger = logger
+	}
+}
+
+// NewAPIClient creates a new client with default settings, changed by the
+// options: no token, a 10 second timeout, no retries and no logs
+func NewAPIClient(baseURL string, opts ...ClientOption) *APIClient {
+	c := &APIClient{
+		BaseURL:    baseURL,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		Logger:     log.New(io.Discard, "", 0),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	// The timeout is applied once every option ran, to a copy of the client
+	httpClient := *c.HTTPClient
+	if c.timeout > 0 {
+		httpClient.Timeout = c.timeout
+	}
+	c.HTTPClient = &httpClient
+	return c
+}
+
+// send makes a request, sending it again up to c.Retries times after a
+// network error or a server error
+func (c *APIClient) send(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := c.HTTPClient.Do(req)
+		if attempt > c.Retries || (err == nil && resp.StatusCode < 500) {
+			return resp, err
+		}
+		if err != nil {
+			c.Logger.Printf("%s %s: attempt %d failed: %v", req.Method, req.URL, attempt, err)
+		} else {
+			c.Logger.Printf("%s %s: attempt %d failed with status %d", req.Method, req.URL, attempt, resp.StatusCode)
+			resp.Body.Close()
+		}
+		time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
 	}
 }
 
@@ -66,7 +144,7 @@ func (c *APIClient) GetUser(userID string) (map[string]interface{}, error) {
 	}
 
 	// Make the request
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
 		// Handle timeout specifically
 		if errors.Is(err, http.ErrHandlerTimeout) {
@@ -121,7 +199,12 @@ func (c *APIClient) GetUser(userID string) (map[string]interface{}, error) {
 
 func main() {
 	// Create a client
-	client := NewAPIClient("https://api.example.com", "valid-token")
+	client := NewAPIClient("https://api.example.com",
+		WithAuthToken("valid-token"),
+		WithTimeout(5*time.Second),
+		WithRetries(2),
+		WithLogger(log.New(os.Stderr, "api: ", log.LstdFlags)),
+	)
 
 	// Make a request
 	user, err := client.GetUser("123")
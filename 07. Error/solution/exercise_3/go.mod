@@ -0,0 +1,7 @@
+module golang-training/module-07/exercise-3
+
+go 1.25
+
+require github.com/fsnotify/fsnotify v1.10.1
+
+require golang.org/x/sys v0.13.0 // indirect
@@ -0,0 +1,954 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogLevel defines different logging severity levels
+type LogLevel int
+
+const (
+	DEBUG LogLevel = iota
+	INFO
+	WARNING
+	ERROR
+	FATAL
+)
+
+func (l LogLevel) String() string {
+	return [...]string{"DEBUG", "INFO", "WARNING", "ERROR", "FATAL"}[l]
+}
+
+// MarshalText writes the level name in JSON
+func (l LogLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// ParseLogLevel converts a level name, in any case, to a LogLevel
+func ParseLogLevel(name string) (LogLevel, error) {
+	for level := DEBUG; level <= FATAL; level++ {
+		if strings.EqualFold(level.String(), name) {
+			return level, nil
+		}
+	}
+	return DEBUG, fmt.Errorf("unknown log level %q", name)
+}
+
+// Attr is a key-value pair attached to a log entry by structured logging
+type Attr struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+// LogEntry is a single log message
+type LogEntry struct {
+	Time    time.Time `json:"time"`
+	Level   LogLevel  `json:"level"`
+	Message string    `json:"message"`
+	Attrs   []Attr    `json:"attrs,omitempty"`
+}
+
+func (e LogEntry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] [%s] %s", e.Time.Format("2006-01-02 15:04:05.000"), e.Level, e.Message)
+	for _, attr := range e.Attrs {
+		value := fmt.Sprint(attr.Value)
+		if strings.ContainsAny(value, " =\"") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&b, " %s=%s", attr.Key, value)
+	}
+	return b.String()
+}
+
+// Sink is a destination for log entries
+type Sink interface {
+	WriteEntry(entry LogEntry) error
+}
+
+// WriterSink writes entries as text lines, to the console or a file
+type WriterSink struct {
+	w io.Writer
+}
+
+// NewWriterSink creates a sink writing to w, closing it with the logger if
+// it is an io.Closer
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func (s *WriterSink) WriteEntry(entry LogEntry) error {
+	_, err := fmt.Fprintln(s.w, entry)
+	return err
+}
+
+func (s *WriterSink) Close() error {
+	if closer, ok := s.w.(io.Closer); ok && s.w != os.Stdout && s.w != os.Stderr {
+		return closer.Close()
+	}
+	return nil
+}
+
+// RingBuffer keeps the last entries in memory, the oldest is overwritten
+// when it is full. It is an http.Handler exposing them as JSON.
+type RingBuffer struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	next    int // Index of the next write
+	total   int // Entries written since the start
+}
+
+// NewRingBuffer creates a buffer keeping the last size entries
+func NewRingBuffer(size int) *RingBuffer {
+	return &RingBuffer{entries: make([]LogEntry, size)}
+}
+
+func (b *RingBuffer) WriteEntry(entry LogEntry) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	b.total++
+	return nil
+}
+
+// Recent returns up to n entries of at least minLevel, oldest first
+func (b *RingBuffer) Recent(n int, minLevel LogLevel) []LogEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stored := min(b.total, len(b.entries))
+	result := make([]LogEntry, 0, min(n, stored))
+	// Walk backwards from the newest entry, then reverse
+	for i := 1; i <= stored && len(result) < n; i++ {
+		entry := b.entries[(b.next-i+len(b.entries))%len(b.entries)]
+		if entry.Level >= minLevel {
+			result = append(result, entry)
+		}
+	}
+	slices.Reverse(result)
+	return result
+}
+
+// ServeHTTP answers GET requests with the recent entries as JSON. The query
+// parameters n (default 50) and level (default DEBUG) filter them.
+func (b *RingBuffer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := 50
+	if value := r.URL.Query().Get("n"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			http.Error(w, "n must be a positive number", http.StatusBadRequest)
+			return
+		}
+		n = parsed
+	}
+	minLevel := DEBUG
+	if value := r.URL.Query().Get("level"); value != "" {
+		level, err := ParseLogLevel(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		minLevel = level
+	}
+
+	entries := b.Recent(n, minLevel)
+	b.mu.Lock()
+	dropped := max(b.total-len(b.entries), 0)
+	b.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"entries": entries,
+		"dropped": dropped, // Entries overwritten since the start
+	})
+}
+
+// levelSink is a sink with its own minimum level
+type levelSink struct {
+	sink     Sink
+	minLevel LogLevel
+}
+
+// Logger provides structured logging functionality. Each sink has its own
+// minimum level, so the console can show warnings while a file keeps
+// everything.
+type Logger struct {
+	mu    sync.Mutex
+	sinks []levelSink
+}
+
+// NewLogger creates a logger writing entries of at least level to the
+// console, and to a file if logPath is not empty
+func NewLogger(level LogLevel, logPath string) (*Logger, error) {
+	logger := &Logger{}
+	logger.AddSink(NewWriterSink(os.Stdout), level)
+
+	if logPath != "" {
+		if err := logger.AddFile(logPath, level); err != nil {
+			return nil, err
+		}
+	}
+	return logger, nil
+}
+
+// AddSink sends the entries of at least minLevel to a sink
+func (l *Logger) AddSink(sink Sink, minLevel LogLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sinks = append(l.sinks, levelSink{sink: sink, minLevel: minLevel})
+}
+
+// AddFile appends the entries of at least minLevel to a file
+func (l *Logger) AddFile(path string, minLevel LogLevel) error {
+	logFile, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	l.AddSink(NewWriterSink(logFile), minLevel)
+	return nil
+}
+
+// Log writes a log entry with the given level and message to every sink
+// accepting its level
+func (l *Logger) Log(level LogLevel, format string, args ...interface{}) {
+	l.Write(LogEntry{
+		Time:    time.Now(),
+		Level:   level,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Enabled reports whether a sink accepts entries of a level
+func (l *Logger) Enabled(level LogLevel) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range l.sinks {
+		if level >= s.minLevel {
+			return true
+		}
+	}
+	return false
+}
+
+// Write sends an entry to every sink accepting its level
+func (l *Logger) Write(entry LogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range l.sinks {
+		if entry.Level < s.minLevel {
+			continue
+		}
+		if err := s.sink.WriteEntry(entry); err != nil {
+			// A broken sink must not stop the others, report it on stderr
+			fmt.Fprintf(os.Stderr, "log sink %T failed: %v\n", s.sink, err)
+		}
+	}
+}
+
+// Close closes the sinks that need it, such as files
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var errs []error
+	for _, s := range l.sinks {
+		if closer, ok := s.sink.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SlogHandler is a slog.Handler writing to a Logger, so code using the
+// standard log/slog API keeps the sinks and levels of the Logger. Groups
+// become key prefixes: "request.method".
+type SlogHandler struct {
+	logger *Logger
+	attrs  []Attr // Added with WithAttrs, already prefixed
+	prefix string // Groups opened with WithGroup, "request." for example
+}
+
+// NewSlogHandler creates a handler for slog.New
+func NewSlogHandler(logger *Logger) *SlogHandler {
+	return &SlogHandler{logger: logger}
+}
+
+// levelFromSlog maps the slog levels to the Logger levels. slog levels are
+// numbers with gaps, such as slog.LevelInfo+2, so ranges are compared.
+func levelFromSlog(level slog.Level) LogLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return DEBUG
+	case level < slog.LevelWarn:
+		return INFO
+	case level < slog.LevelError:
+		return WARNING
+	default:
+		return ERROR
+	}
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Enabled(levelFromSlog(level))
+}
+
+func (h *SlogHandler) Handle(_ context.Context, record slog.Record) error {
+	entry := LogEntry{
+		Time:    record.Time,
+		Level:   levelFromSlog(record.Level),
+		Message: record.Message,
+		Attrs:   slices.Clone(h.attrs),
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		entry.Attrs = appendAttr(entry.Attrs, h.prefix, attr)
+		return true
+	})
+	h.logger.Write(entry)
+	return nil
+}
+
+// WithAttrs returns a handler adding attrs to every entry. The handler is
+// copied: the loggers created from the same parent don't share attributes.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = slices.Clip(h.attrs) // Appending must not write in the parent's array
+	for _, attr := range attrs {
+		clone.attrs = appendAttr(clone.attrs, h.prefix, attr)
+	}
+	return &clone
+}
+
+// WithGroup returns a handler putting the next attributes in a group
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// appendAttr flattens an attribute, following the rules of slog.Handler:
+// empty attributes are ignored, groups are expanded with their key as a
+// prefix and groups without a key are inlined
+func appendAttr(attrs []Attr, prefix string, attr slog.Attr) []Attr {
+	attr.Value = attr.Value.Resolve() // Call LogValuer implementations
+	if attr.Equal(slog.Attr{}) {
+		return attrs
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindGroup:
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, member := range attr.Value.Group() {
+			attrs = appendAttr(attrs, prefix, member)
+		}
+		return attrs
+	case slog.KindDuration:
+		return append(attrs, Attr{Key: prefix + attr.Key, Value: attr.Value.Duration().String()})
+	case slog.KindTime:
+		return append(attrs, Attr{Key: prefix + attr.Key, Value: attr.Value.Time().Format(time.RFC3339)})
+	}
+
+	value := attr.Value.Any()
+	if err, ok := value.(error); ok {
+		value = err.Error() // An error would be encoded as {} in JSON
+	}
+	return append(attrs, Attr{Key: prefix + attr.Key, Value: value})
+}
+
+func (l *Logger) Debug(format string, args ...interface{}) {
+	l.Log(DEBUG, format, args...)
+}
+
+func (l *Logger) Info(format string, args ...interface{}) {
+	l.Log(INFO, format, args...)
+}
+
+func (l *Logger) Warning(format string, args ...interface{}) {
+	l.Log(WARNING, format, args...)
+}
+
+func (l *Logger) Error(format string, args ...interface{}) {
+	l.Log(ERROR, format, args...)
+}
+
+func (l *Logger) Fatal(format string, args ...interface{}) {
+	l.Log(FATAL, format, args...)
+}
+
+// FileError defines custom error types for file processing
+type FileError struct {
+	Path    string
+	Op      string
+	Message string
+	Err     error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("file error [%s] on %s: %s", e.Op, e.Path, e.Message)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// Specific file error types
+type ReadError struct {
+	FileError
+	Line int
+}
+
+func (e *ReadError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("read error at line %d in %s: %s", e.Line, e.Path, e.Message)
+	}
+	return fmt.Sprintf("read error in %s: %s", e.Path, e.Message)
+}
+
+type ParseError struct {
+	FileError
+	Line    int
+	Column  string // Set when a schema was checked
+	Content string
+}
+
+func (e *ParseError) Error() string {
+	if e.Column != "" {
+		return fmt.Sprintf("parse error at line %d, column %s in %s: %s (content: %q)",
+			e.Line, e.Column, e.Path, e.Message, e.Content)
+	}
+	return fmt.Sprintf("parse error at line %d in %s: %s (content: %q)",
+		e.Line, e.Path, e.Message, e.Content)
+}
+
+type WriteError struct {
+	FileError
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("write error in %s: %s", e.Path, e.Message)
+}
+
+// BatchError collects multiple errors from batch operations
+type BatchError struct {
+	Errors []error
+}
+
+func (e *BatchError) Error() string {
+	if len(e.Errors) == 1 {
+		return fmt.Sprintf("batch operation failed with 1 error: %v", e.Errors[0])
+	}
+	return fmt.Sprintf("batch operation failed with %d errors", len(e.Errors))
+}
+
+// Unwrap lets errors.Is and errors.As look into every collected error
+func (e *BatchError) Unwrap() []error {
+	return e.Errors
+}
+
+func (e *BatchError) AddError(err error) {
+	e.Errors = append(e.Errors, err)
+}
+
+func (e *BatchError) HasErrors() bool {
+	return len(e.Errors) > 0
+}
+
+// Define sentinel errors
+var (
+	ErrFileNotFound = errors.New("file not found")
+	ErrPermission   = errors.New("permission denied")
+	ErrFormat       = errors.New("invalid file format")
+	ErrEmpty        = errors.New("file is empty")
+)
+
+// FileProcessor handles file processing operations
+type FileProcessor struct {
+	Logger    *Logger
+	OutputDir string  // Where the processed files go, next to the input if empty
+	Schema    *Schema // Checked for every row if not nil
+	Workers   int     // Files processed at the same time by ProcessFiles
+
+	mu      sync.Mutex
+	reports map[string]*ValidationReport
+}
+
+// NewFileProcessor creates a new file processor
+func NewFileProcessor(logger *Logger) *FileProcessor {
+	return &FileProcessor{
+		Logger:  logger,
+		Workers: 1,
+		reports: make(map[string]*ValidationReport),
+	}
+}
+
+// Reports returns the validation reports of the files processed with a
+// schema, sorted by path
+func (p *FileProcessor) Reports() []*ValidationReport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	reports := slices.Collect(maps.Values(p.reports))
+	slices.SortFunc(reports, func(a, b *ValidationReport) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return reports
+}
+
+// OutputPath returns the path of the processed version of a file
+func (p *FileProcessor) OutputPath(path string) string {
+	dir := p.OutputDir
+	if dir == "" {
+		dir = filepath.Dir(path)
+	}
+	return filepath.Join(dir, fmt.Sprintf("processed_%s", filepath.Base(path)))
+}
+
+// ProcessFile reads a file and performs line-by-line processing
+func (p *FileProcessor) ProcessFile(path string) error {
+	p.Logger.Info("Processing file: %s", path)
+
+	// Check if the file exists
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return &FileError{
+			Path:    path,
+			Op:      "check",
+			Message: "file does not exist",
+			Err:     ErrFileNotFound,
+		}
+	}
+
+	// Open the file
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsPermission(err) {
+			return &FileError{
+				Path:    path,
+				Op:      "open",
+				Message: "permission denied",
+				Err:     ErrPermission,
+			}
+		}
+		return &FileError{
+			Path:    path,
+			Op:      "open",
+			Message: err.Error(),
+			Err:     err,
+		}
+	}
+	defer file.Close()
+
+	// Prepare output file
+	outputPath := p.OutputPath(path)
+
+	outputFile, err := os.Create(outputPath)
+	if err != nil {
+		return &WriteError{
+			FileError: FileError{
+				Path:    outputPath,
+				Op:      "create",
+				Message: err.Error(),
+				Err:     err,
+			},
+		}
+	}
+	defer outputFile.Close()
+
+	writer := bufio.NewWriter(outputFile)
+	defer writer.Flush()
+
+	// Process the file line by line
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+
+	var report *ValidationReport
+	headerPending := false
+	if p.Schema != nil {
+		report = newValidationReport(path, p.Schema)
+		headerPending = p.Schema.Header
+	}
+
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+
+		if line == "" {
+			p.Logger.Debug("Skipping empty line %d", lineNum)
+			continue
+		}
+
+		// Process the line, against the schema if there is one
+		var processed string
+		var errs []error
+		switch {
+		case p.Schema == nil:
+			var err error
+			processed, err = p.processLine(path, lineNum, line)
+			if err != nil {
+				errs = append(errs, err)
+			}
+
+		case headerPending:
+			// The file can't be read with a wrong header, stop here
+			headerPending = false
+			fields := splitFields(line)
+			if err := p.Schema.ValidateHeader(path, fields); err != nil {
+				return err
+			}
+			processed = strings.Join(fields, "|")
+
+		default:
+			fields := splitFields(line)
+			errs = p.Schema.ValidateRow(path, lineNum, fields)
+			report.add(errs)
+			processed = strings.Join(fields, "|")
+		}
+
+		if len(errs) > 0 {
+			for _, err := range errs {
+				// Log the error but continue processing
+				p.Logger.Warning("Error processing line %d: %v", lineNum, err)
+
+				// Write error comment to output
+				fmt.Fprintf(writer, "# ERROR Line %d: %s\n", lineNum, err.Error())
+			}
+			continue
+		}
+
+		// Write the processed line
+		if _, err := fmt.Fprintln(writer, processed); err != nil {
+			return &WriteError{
+				FileError: FileError{
+					Path:    outputPath,
+					Op:      "write",
+					Message: err.Error(),
+					Err:     err,
+				},
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return &ReadError{
+			FileError: FileError{
+				Path:    path,
+				Op:      "scan",
+				Message: err.Error(),
+				Err:     err,
+			},
+			Line: lineNum,
+		}
+	}
+
+	if lineNum == 0 {
+		return &FileError{
+			Path:    path,
+			Op:      "process",
+			Message: "file is empty",
+			Err:     ErrEmpty,
+		}
+	}
+
+	if report != nil {
+		p.mu.Lock()
+		p.reports[path] = report
+		p.mu.Unlock()
+		p.Logger.Info("Validated %s: %d rows ok, %d rows failed", path, report.RowsOK, report.RowsFailed)
+	}
+
+	p.Logger.Info("Successfully processed %s, wrote output to %s", path, outputPath)
+	return nil
+}
+
+// processLine handles a single line of text
+func (p *FileProcessor) processLine(path string, lineNum int, line string) (string, error) {
+	// Example processing: Convert CSV to pipe-delimited format
+	if !strings.Contains(line, ",") {
+		return "", &ParseError{
+			FileError: FileError{
+				Path:    path,
+				Op:      "parse",
+				Message: "line does not contain delimiters",
+				Err:     ErrFormat,
+			},
+			Line:    lineNum,
+			Content: line,
+		}
+	}
+
+	// Split by comma and rejoin with pipe
+	return strings.Join(splitFields(line), "|"), nil
+}
+
+// splitFields splits a line on commas and trims whitespace from each field
+func splitFields(line string) []string {
+	fields := strings.Split(line, ",")
+	for i, field := range fields {
+		fields[i] = strings.TrimSpace(field)
+	}
+	return fields
+}
+
+// ProcessFiles processes multiple files and collects errors
+func (p *FileProcessor) ProcessFiles(paths []string) error {
+	if len(paths) == 0 {
+		return errors.New("no files to process")
+	}
+
+	// Process up to Workers files at the same time. Each worker stores the
+	// error at the index of its file, so the errors keep the order of paths.
+	errs := make([]error, len(paths))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(max(p.Workers, 1), len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = p.ProcessFile(paths[i])
+				if errs[i] != nil {
+					p.Logger.Error("Failed to process %s: %v", paths[i], errs[i])
+				}
+			}
+		}()
+	}
+	for i := range paths {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	batchErr := &BatchError{}
+	for _, err := range errs {
+		if err != nil {
+			batchErr.AddError(err)
+		}
+	}
+
+	if batchErr.HasErrors() {
+		return batchErr
+	}
+
+	return nil
+}
+
+func main() {
+	watchDir := flag.String("watch", "", "watch a directory and process the files as they appear")
+	debounce := flag.Duration("debounce", 500*time.Millisecond, "time without writes before a file is processed")
+	schemaPath := flag.String("schema", "schema.json", "JSON schema of the CSV files to validate")
+	workers := flag.Int("workers", runtime.NumCPU(), "files validated at the same time")
+	flag.Parse()
+
+	if *watchDir != "" {
+		if err := runWatcher(*watchDir, *debounce); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Create a logger: the console only shows problems, the file keeps
+	// everything and the ring buffer the last entries from INFO
+	logger, err := NewLogger(WARNING, "")
+	if err != nil {
+		fmt.Printf("Failed to create logger: %v\n", err)
+		return
+	}
+	defer logger.Close()
+	if err := logger.AddFile("file_processor.log", DEBUG); err != nil {
+		fmt.Printf("Failed to create logger: %v\n", err)
+		return
+	}
+	recent := NewRingBuffer(20)
+	logger.AddSink(recent, INFO)
+
+	processor := NewFileProcessor(logger)
+
+	// Create some test files
+	testDir := "test_files"
+	os.Mkdir(testDir, 0755)
+
+	files := map[string][]string{
+		"valid.csv": {
+			"Name, Age, City",
+			"John, 30, New York",
+			"Alice, 25, London",
+			"Bob, 40, Paris",
+		},
+		"empty.csv": {},
+		"invalid.txt": {
+			"This is not a CSV file",
+			"It has no commas at all",
+		},
+		"partially_valid.csv": {
+			"Product, Price, Quantity",
+			"Apple, 1.20, 10",
+			"Invalid line with no commas",
+			"Orange, 0.80, 5",
+		},
+	}
+
+	var filePaths []string
+
+	for name, content := range files {
+		path := filepath.Join(testDir, name)
+		filePaths = append(filePaths, path)
+
+		// Create the file
+		file, err := os.Create(path)
+		if err != nil {
+			logger.Error("Failed to create test file %s: %v", path, err)
+			continue
+		}
+
+		// Write content
+		for _, line := range content {
+			fmt.Fprintln(file, line)
+		}
+
+		file.Close()
+	}
+
+	// Process all files
+	started := time.Now()
+	err = processor.ProcessFiles(filePaths)
+
+	// Code using the standard log/slog API writes to the same sinks through
+	// the adapter, with structured attributes
+	summary := slog.New(NewSlogHandler(logger)).With("dir", testDir).WithGroup("batch")
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelWarn
+	}
+	summary.Log(context.Background(), level, "Batch finished",
+		"files", len(filePaths),
+		slog.Duration("took", time.Since(started).Round(time.Microsecond)),
+		slog.Group("result", "ok", err == nil, "error", err))
+
+	// Handle batch errors
+	if err != nil {
+		var batchErr *BatchError
+		if errors.As(err, &batchErr) {
+			logger.Error("Batch processing completed with %d errors", len(batchErr.Errors))
+
+			for i, err := range batchErr.Errors {
+				var fileErr *FileError
+				var readErr *ReadError
+				var parseErr *ParseError
+				var writeErr *WriteError
+
+				switch {
+				case errors.As(err, &parseErr):
+					logger.Error("Error %d: Parse error at line %d in %s: %s",
+						i+1, parseErr.Line, parseErr.Path, parseErr.Message)
+
+				case errors.As(err, &readErr):
+					logger.Error("Error %d: Read error at line %d in %s: %s",
+						i+1, readErr.Line, readErr.Path, readErr.Message)
+
+				case errors.As(err, &writeErr):
+					logger.Error("Error %d: Write error in %s: %s",
+						i+1, writeErr.Path, writeErr.Message)
+
+				case errors.As(err, &fileErr):
+					logger.Error("Error %d: File error [%s] on %s: %s",
+						i+1, fileErr.Op, fileErr.Path, fileErr.Message)
+
+				default:
+					logger.Error("Error %d: %v", i+1, err)
+				}
+			}
+		} else {
+			logger.Error("Error processing files: %v", err)
+		}
+	} else {
+		logger.Info("All files processed successfully")
+	}
+
+	// The ring buffer is an http.Handler, a server would mount it with
+	// http.Handle("/debug/logs", recent). Query it as a client would.
+	for _, target := range []string{"/debug/logs?n=3", "/debug/logs?level=error&n=2", "/debug/logs?level=loud"} {
+		rec := httptest.NewRecorder()
+		recent.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		fmt.Printf("\nGET %s -> %d\n%s", target, rec.Code, rec.Body)
+	}
+
+	// Validate files against a schema, several at a time
+	fmt.Println("\n--- Schema validation ---")
+	schema, err := LoadSchema(*schemaPath)
+	if err != nil {
+		logger.Error("Schema validation skipped: %v", err)
+		return
+	}
+
+	peopleDir := filepath.Join(testDir, "people")
+	os.Mkdir(peopleDir, 0755)
+	people := map[string][]string{
+		"members.csv": {
+			"Name, Age, City, Member, Joined",
+			"John, 30, New York, true, 2021-04-12",
+			"Alice, 25, London, false, 2023-09-01",
+			"Bob, 40, , true, 2019-01-30",
+		},
+		"signups.csv": {
+			"name, age, city, member, joined",
+			"Eve, , Rome, true, 2024-03-01",
+			"Frank, forty, Oslo, yes, 2024-13-01",
+			"Grace, 29, Lima",
+			"Heidi, 35, Berlin, false, 2024-05-17",
+		},
+		"legacy.csv": {
+			"Name, Age, Town",
+			"Ivan, 51, Kyiv",
+		},
+	}
+	var peoplePaths []string
+	for name, lines := range people {
+		path := filepath.Join(peopleDir, name)
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+			logger.Error("Failed to create test file %s: %v", path, err)
+			continue
+		}
+		peoplePaths = append(peoplePaths, path)
+	}
+	slices.Sort(peoplePaths)
+
+	validator := NewFileProcessor(logger)
+	validator.Schema = schema
+	validator.Workers = *workers
+	err = validator.ProcessFiles(peoplePaths)
+
+	for _, report := range validator.Reports() {
+		fmt.Print(report)
+	}
+	// BatchError unwraps to every file error, and schema errors to ErrFormat
+	if errors.Is(err, ErrHeader) {
+		var parseErr *ParseError
+		errors.As(err, &parseErr)
+		fmt.Printf("%s was rejected: %s\n", parseErr.Path, parseErr.Message)
+	}
+}
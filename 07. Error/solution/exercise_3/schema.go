@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema errors wrap ErrFormat, so errors.Is(err, ErrFormat) still matches
+// every invalid line
+var (
+	ErrColumnCount  = fmt.Errorf("%w: wrong number of columns", ErrFormat)
+	ErrMissingValue = fmt.Errorf("%w: missing required value", ErrFormat)
+	ErrInvalidValue = fmt.Errorf("%w: invalid value", ErrFormat)
+	ErrHeader       = fmt.Errorf("%w: header doesn't match the schema", ErrFormat)
+	ErrSchema       = errors.New("invalid schema")
+)
+
+// ColumnType is the type of the values of a column
+type ColumnType string
+
+const (
+	TypeString ColumnType = "string"
+	TypeInt    ColumnType = "int"
+	TypeFloat  ColumnType = "float"
+	TypeBool   ColumnType = "bool"
+	TypeDate   ColumnType = "date" // 2006-01-02
+)
+
+// check returns an error if a value is not of the type
+func (t ColumnType) check(value string) error {
+	var err error
+	switch t {
+	case TypeString:
+	case TypeInt:
+		_, err = strconv.Atoi(value)
+	case TypeFloat:
+		_, err = strconv.ParseFloat(value, 64)
+	case TypeBool:
+		_, err = strconv.ParseBool(value)
+	case TypeDate:
+		_, err = time.Parse(time.DateOnly, value)
+	}
+	if err != nil {
+		return fmt.Errorf("%q is not a valid %s", value, t)
+	}
+	return nil
+}
+
+// Column describes a column of the CSV files
+type Column struct {
+	Name     string     `json:"name"`
+	Type     ColumnType `json:"type"`
+	Required bool       `json:"required"`
+}
+
+// Schema describes the columns of the CSV files, in order
+type Schema struct {
+	Header  bool     `json:"header"` // The first line holds the column names
+	Columns []Column `json:"columns"`
+}
+
+// LoadSchema reads a schema from a JSON file
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, &FileError{Path: path, Op: "open", Message: "schema does not exist", Err: ErrFileNotFound}
+		}
+		return nil, &ReadError{FileError: FileError{Path: path, Op: "read", Message: err.Error(), Err: err}}
+	}
+
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, &FileError{Path: path, Op: "parse", Message: err.Error(), Err: ErrSchema}
+	}
+	if len(schema.Columns) == 0 {
+		return nil, &FileError{Path: path, Op: "parse", Message: "no columns", Err: ErrSchema}
+	}
+	for i, column := range schema.Columns {
+		switch column.Type {
+		case TypeString, TypeInt, TypeFloat, TypeBool, TypeDate:
+		case "":
+			schema.Columns[i].Type = TypeString
+		default:
+			return nil, &FileError{
+				Path:    path,
+				Op:      "parse",
+				Message: fmt.Sprintf("column %s has an unknown type %q", column.Name, column.Type),
+				Err:     ErrSchema,
+			}
+		}
+	}
+	return &schema, nil
+}
+
+// ValidateHeader checks the column names of the first line, ignoring case
+func (s *Schema) ValidateHeader(path string, fields []string) error {
+	names := make([]string, len(s.Columns))
+	for i, column := range s.Columns {
+		names[i] = column.Name
+	}
+	if len(fields) == len(names) {
+		match := true
+		for i := range names {
+			match = match && strings.EqualFold(fields[i], names[i])
+		}
+		if match {
+			return nil
+		}
+	}
+	return &ParseError{
+		FileError: FileError{
+			Path:    path,
+			Op:      "validate",
+			Message: fmt.Sprintf("expected columns %s", strings.Join(names, ", ")),
+			Err:     ErrHeader,
+		},
+		Line:    1,
+		Content: strings.Join(fields, ","),
+	}
+}
+
+// ValidateRow checks every value of a row and returns one *ParseError per
+// invalid value, with the name of its column
+func (s *Schema) ValidateRow(path string, line int, fields []string) []error {
+	content := strings.Join(fields, ",")
+	if len(fields) != len(s.Columns) {
+		return []error{&ParseError{
+			FileError: FileError{
+				Path:    path,
+				Op:      "validate",
+				Message: fmt.Sprintf("expected %d columns, got %d", len(s.Columns), len(fields)),
+				Err:     ErrColumnCount,
+			},
+			Line:    line,
+			Content: content,
+		}}
+	}
+
+	var errs []error
+	for i, column := range s.Columns {
+		value := fields[i]
+		var message string
+		var sentinel error
+		switch {
+		case value == "" && column.Required:
+			message, sentinel = "value is required", ErrMissingValue
+		case value == "":
+			continue
+		default:
+			if err := column.Type.check(value); err != nil {
+				message, sentinel = err.Error(), ErrInvalidValue
+			}
+		}
+		if sentinel != nil {
+			errs = append(errs, &ParseError{
+				FileError: FileError{Path: path, Op: "validate", Message: message, Err: sentinel},
+				Line:      line,
+				Column:    column.Name,
+				Content:   content,
+			})
+		}
+	}
+	return errs
+}
+
+// ColumnStats counts the valid and invalid values of a column
+type ColumnStats struct {
+	OK     int
+	Failed int
+}
+
+// ValidationReport summarises the validation of a file
+type ValidationReport struct {
+	Path       string
+	RowsOK     int
+	RowsFailed int
+	Columns    []string // In schema order
+	Stats      map[string]*ColumnStats
+}
+
+// newValidationReport creates an empty report for the columns of a schema
+func newValidationReport(path string, schema *Schema) *ValidationReport {
+	report := &ValidationReport{Path: path, Stats: make(map[string]*ColumnStats)}
+	for _, column := range schema.Columns {
+		report.Columns = append(report.Columns, column.Name)
+		report.Stats[column.Name] = &ColumnStats{}
+	}
+	return report
+}
+
+// add counts a validated row from its errors
+func (r *ValidationReport) add(errs []error) {
+	if len(errs) == 0 {
+		r.RowsOK++
+		for _, stats := range r.Stats {
+			stats.OK++
+		}
+		return
+	}
+
+	r.RowsFailed++
+	failed := make(map[string]bool)
+	for _, err := range errs {
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) && parseErr.Column != "" {
+			failed[parseErr.Column] = true
+		}
+	}
+	if len(failed) == 0 {
+		return // Wrong number of columns: no value was checked
+	}
+	for name, stats := range r.Stats {
+		if failed[name] {
+			stats.Failed++
+		} else {
+			stats.OK++
+		}
+	}
+}
+
+func (r *ValidationReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d rows ok, %d rows failed\n", r.Path, r.RowsOK, r.RowsFailed)
+	for _, name := range r.Columns {
+		stats := r.Stats[name]
+		fmt.Fprintf(&b, "  %-10s %4d ok %4d failed\n", name, stats.OK, stats.Failed)
+	}
+	return b.String()
+}
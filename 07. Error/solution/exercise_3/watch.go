@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watcher turns the FileProcessor into an ingestion daemon: it processes the
+// files appearing in a directory, then moves them to a done or failed folder
+type Watcher struct {
+	Processor *FileProcessor
+	Logger    *Logger
+	InputDir  string
+	DoneDir   string
+	FailedDir string
+	Debounce  time.Duration // Time without writes before a file is processed
+}
+
+// NewWatcher creates a watcher of inputDir, with the done and failed folders
+// inside it. The processed files are written to the done folder, outside of
+// the watched directory, so they don't trigger new events.
+func NewWatcher(processor *FileProcessor, inputDir string, debounce time.Duration) (*Watcher, error) {
+	w := &Watcher{
+		Processor: processor,
+		Logger:    processor.Logger,
+		InputDir:  inputDir,
+		DoneDir:   filepath.Join(inputDir, "done"),
+		FailedDir: filepath.Join(inputDir, "failed"),
+		Debounce:  debounce,
+	}
+	for _, dir := range []string{w.DoneDir, w.FailedDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, &FileError{Path: dir, Op: "mkdir", Message: err.Error(), Err: err}
+		}
+	}
+	processor.OutputDir = w.DoneDir
+	return w, nil
+}
+
+// Run watches the directory until ctx is cancelled. A file is processed once
+// no event was received for it during Debounce: a file copied or uploaded in
+// several writes is only read when complete. On shutdown, the file being
+// processed is finished and the pending ones are left for the next start.
+func (w *Watcher) Run(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer watcher.Close()
+	if err := watcher.Add(w.InputDir); err != nil {
+		return &FileError{Path: w.InputDir, Op: "watch", Message: err.Error(), Err: err}
+	}
+
+	// A single worker processes the files one at a time, in order
+	queue := make(chan string, 100)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for path := range queue {
+			w.handle(path)
+		}
+	}()
+
+	// Last event of each file waiting for the end of its writes
+	pending := make(map[string]time.Time)
+
+	// The files copied while the daemon was stopped
+	entries, err := os.ReadDir(w.InputDir)
+	if err != nil {
+		return &FileError{Path: w.InputDir, Op: "list", Message: err.Error(), Err: err}
+	}
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && !ignored(entry.Name()) {
+			pending[filepath.Join(w.InputDir, entry.Name())] = time.Time{}
+		}
+	}
+
+	w.Logger.Info("Watching %s (done: %s, failed: %s)", w.InputDir, w.DoneDir, w.FailedDir)
+	ticker := time.NewTicker(max(w.Debounce/4, 10*time.Millisecond))
+	defer ticker.Stop()
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				break loop
+			}
+			if ignored(filepath.Base(event.Name)) {
+				continue
+			}
+			switch {
+			case event.Has(fsnotify.Create), event.Has(fsnotify.Write):
+				w.Logger.Debug("Event %s", event)
+				pending[event.Name] = time.Now()
+			case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+				delete(pending, event.Name) // Moved away before the end of the writes
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				break loop
+			}
+			// Events were lost, for example when the kernel queue overflowed
+			w.Logger.Error("Watcher error: %v", err)
+
+		case now := <-ticker.C:
+			for path, last := range pending {
+				if now.Sub(last) < w.Debounce {
+					continue
+				}
+				delete(pending, path)
+				select {
+				case queue <- path:
+				case <-ctx.Done():
+					break loop
+				}
+			}
+		}
+	}
+
+	if len(pending) > 0 {
+		w.Logger.Info("Leaving %d pending files for the next start", len(pending))
+	}
+	close(queue)
+	<-done
+	w.Logger.Info("Watcher stopped")
+	return nil
+}
+
+// handle processes a file and moves it to the done or failed folder
+func (w *Watcher) handle(path string) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return // Removed, or queued twice and already moved
+	}
+	if err != nil || !info.Mode().IsRegular() {
+		return // Directories, such as done and failed
+	}
+
+	err = w.Processor.ProcessFile(path)
+	destDir := w.DoneDir
+	if err != nil {
+		w.Logger.Error("Failed to process %s: %v", path, err)
+		destDir = w.FailedDir
+		os.Remove(w.Processor.OutputPath(path)) // Don't leave a partial output in done
+	}
+
+	dest, moveErr := moveFile(path, destDir)
+	if moveErr != nil {
+		w.Logger.Error("Failed to move %s: %v", path, moveErr)
+		return
+	}
+	if err != nil {
+		// Keep the reason next to the file, for whoever fixes it
+		os.WriteFile(dest+".error", []byte(err.Error()+"\n"), 0644)
+	}
+	w.Logger.Info("Moved %s to %s", filepath.Base(path), dest)
+}
+
+// moveFile moves a file to a directory, adding a timestamp to the name if a
+// file with the same name is already there
+func moveFile(path, dir string) (string, error) {
+	name := filepath.Base(path)
+	dest := filepath.Join(dir, name)
+	if _, err := os.Stat(dest); err == nil {
+		ext := filepath.Ext(name)
+		stamp := time.Now().Format("20060102-150405.000")
+		dest = filepath.Join(dir, fmt.Sprintf("%s_%s%s", strings.TrimSuffix(name, ext), stamp, ext))
+	}
+	if err := os.Rename(path, dest); err != nil {
+		return "", &FileError{Path: path, Op: "move", Message: err.Error(), Err: err}
+	}
+	return dest, nil
+}
+
+// ignored reports whether a file name is a hidden or temporary file. Writers
+// often write to a temporary name and rename it once complete.
+func ignored(name string) bool {
+	return strings.HasPrefix(name, ".") ||
+		strings.HasSuffix(name, "~") ||
+		strings.HasSuffix(name, ".tmp") ||
+		strings.HasSuffix(name, ".part")
+}
+
+// runWatcher starts the daemon and stops it on Ctrl+C or SIGTERM
+func runWatcher(dir string, debounce time.Duration) error {
+	logger, err := NewLogger(INFO, "")
+	if err != nil {
+		return err
+	}
+	defer logger.Close()
+	if err := logger.AddFile("file_processor.log", DEBUG); err != nil {
+		return err
+	}
+
+	watcher, err := NewWatcher(NewFileProcessor(logger), dir, debounce)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return watcher.Run(ctx)
+}
@@ -0,0 +1,596 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIError is the error returned for every non-2xx response. The error body
+// sent by the server is decoded into Code, Message and Fields.
+type APIError struct {
+	StatusCode int
+	URL        string
+	Code       string
+	Message    string
+	Fields     map[string]string // Invalid fields of a validation error
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("API error (%d) on %s: %s", e.StatusCode, e.URL, e.Message)
+	if e.Code != "" {
+		msg += " [" + e.Code + "]"
+	}
+	return msg
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Define sentinel errors
+var (
+	ErrNotFound     = errors.New("resource not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrTimeout      = errors.New("request timed out")
+	ErrUnexpected   = errors.New("unexpected API response")
+
+	// ErrInvalidRequest is returned before sending anything when a request
+	// struct can't be turned into a URL
+	ErrInvalidRequest = errors.New("invalid request")
+)
+
+// statusErrors maps the HTTP status codes to sentinel errors
+var statusErrors = map[int]error{
+	http.StatusNotFound:            ErrNotFound,
+	http.StatusUnauthorized:        ErrUnauthorized,
+	http.StatusConflict:            ErrConflict,
+	http.StatusUnprocessableEntity: ErrValidation,
+}
+
+// APIClient for making HTTP requests
+type APIClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	AuthToken  string
+
+	timeout time.Duration // Set by WithTimeout, applied by NewAPIClient
+}
+
+// ClientOption configures an APIClient created by NewAPIClient
+type ClientOption func(*APIClient)
+
+// WithAuthToken sends a bearer token with every request
+func WithAuthToken(token string) ClientOption {
+	return func(c *APIClient) {
+		c.AuthToken = token
+	}
+}
+
+// WithTimeout limits the time of a request, including reading the response,
+// whatever the order of the options
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *APIClient) {
+		c.timeout = timeout
+	}
+}
+
+// WithHTTPClient replaces the default HTTP client, for example to use a
+// custom transport; a nil client keeps the default one. The client is copied,
+// so WithTimeout never changes a shared client such as http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *APIClient) {
+		if httpClient != nil {
+			c.HTTPClient = httpClient
+		}
+	}
+}
+
+// NewAPIClient creates a new client with default settings, changed by the
+// options: no token and a 10 second timeout
+func NewAPIClient(baseURL string, opts ...ClientOption) *APIClient {
+	c := &APIClient{
+		BaseURL:    baseURL,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	// The timeout is applied once every option ran, to a copy of the client
+	httpClient := *c.HTTPClient
+	if c.timeout > 0 {
+		httpClient.Timeout = c.timeout
+	}
+	c.HTTPClient = &httpClient
+	return c
+}
+
+// Endpoint describes an API operation. Req is the request struct: fields
+// tagged `path:"name"` replace {name} in Path, fields tagged
+// `query:"name,omitempty"` go in the query string, and for POST, PUT and
+// PATCH the struct is also sent as the JSON body (tag path and query fields
+// with `json:"-"`). Resp is the type the JSON response is decoded into.
+type Endpoint[Req, Resp any] struct {
+	Method string
+	Path   string
+}
+
+// NoContent is the response type of endpoints without a response body
+type NoContent struct{}
+
+// Call sends the request described by an endpoint and decodes the response.
+// It is a function because Go methods can't have type parameters.
+func Call[Req, Resp any](ctx context.Context, c *APIClient, e Endpoint[Req, Resp], req Req) (Resp, error) {
+	var result Resp
+
+	path, query, err := encodeRequest(e.Path, req)
+	if err != nil {
+		return result, fmt.Errorf("%s %s: %w", e.Method, e.Path, err)
+	}
+	fullURL := c.BaseURL + path
+	if len(query) > 0 {
+		fullURL += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	if e.Method == http.MethodPost || e.Method == http.MethodPut || e.Method == http.MethodPatch {
+		data, err := json.Marshal(req)
+		if err != nil {
+			return result, fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, e.Method, fullURL, body)
+	if err != nil {
+		return result, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.AuthToken != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.AuthToken)
+	}
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || os.IsTimeout(err) {
+			return result, fmt.Errorf("%s %s: %w", e.Method, fullURL, ErrTimeout)
+		}
+		return result, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return result, decodeError(resp, fullURL)
+	}
+	if _, empty := any(result).(NoContent); empty || resp.StatusCode == http.StatusNoContent {
+		return result, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return result, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return result, nil
+}
+
+// errorBody is the JSON error format of the API:
+// {"error": {"code": "...", "message": "...", "fields": {...}}}
+type errorBody struct {
+	Error struct {
+		Code    string            `json:"code"`
+		Message string            `json:"message"`
+		Fields  map[string]string `json:"fields"`
+	} `json:"error"`
+}
+
+// decodeError turns an error response into an *APIError wrapping the
+// sentinel error of its status code
+func decodeError(resp *http.Response, url string) error {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		URL:        url,
+		Message:    http.StatusText(resp.StatusCode),
+		Err:        ErrUnexpected,
+	}
+	if sentinel, ok := statusErrors[resp.StatusCode]; ok {
+		apiErr.Err = sentinel
+	}
+
+	// The body is optional and may not be JSON, for example from a proxy
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return apiErr
+	}
+	var body errorBody
+	if json.Unmarshal(data, &body) == nil && body.Error.Message != "" {
+		apiErr.Code = body.Error.Code
+		apiErr.Message = body.Error.Message
+		apiErr.Fields = body.Error.Fields
+	}
+	return apiErr
+}
+
+// encodeRequest substitutes the path parameters and builds the query string
+// from the tagged fields of req
+func encodeRequest(pathTemplate string, req any) (string, url.Values, error) {
+	params := make(map[string]string)
+	query := url.Values{}
+
+	v := reflect.ValueOf(req)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Struct {
+		t := v.Type()
+		for i := range t.NumField() {
+			field, value := t.Field(i), v.Field(i)
+
+			if name, ok := field.Tag.Lookup("path"); ok {
+				if value.IsZero() {
+					continue // Reported below as a missing parameter
+				}
+				values, err := formatValue(value)
+				if err != nil || len(values) != 1 {
+					return "", nil, fmt.Errorf("%w: path parameter %s must be a single value", ErrInvalidRequest, name)
+				}
+				params[name] = values[0]
+			}
+
+			if tag, ok := field.Tag.Lookup("query"); ok {
+				name, options, _ := strings.Cut(tag, ",")
+				if options == "omitempty" && value.IsZero() {
+					continue
+				}
+				values, err := formatValue(value)
+				if err != nil {
+					return "", nil, fmt.Errorf("%w: query parameter %s: %v", ErrInvalidRequest, name, err)
+				}
+				for _, s := range values {
+					query.Add(name, s)
+				}
+			}
+		}
+	}
+
+	// Replace every {name} of the template, escaping the values
+	var path strings.Builder
+	rest := pathTemplate
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			path.WriteString(rest)
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return "", nil, fmt.Errorf("%w: unclosed { in %s", ErrInvalidRequest, pathTemplate)
+		}
+		name := rest[start+1 : start+end]
+		value, ok := params[name]
+		if !ok {
+			return "", nil, fmt.Errorf("%w: missing path parameter %s", ErrInvalidRequest, name)
+		}
+		path.WriteString(rest[:start])
+		path.WriteString(url.PathEscape(value))
+		rest = rest[start+end+1:]
+	}
+	return path.String(), query, nil
+}
+
+// formatValue converts a field to query or path values. Slices give one
+// value per element.
+func formatValue(v reflect.Value) ([]string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return []string{v.String()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return []string{strconv.FormatInt(v.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return []string{strconv.FormatUint(v.Uint(), 10)}, nil
+	case reflect.Bool:
+		return []string{strconv.FormatBool(v.Bool())}, nil
+	case reflect.Float32, reflect.Float64:
+		return []string{strconv.FormatFloat(v.Float(), 'f', -1, 64)}, nil
+	case reflect.Slice:
+		values := make([]string, 0, v.Len())
+		for i := range v.Len() {
+			element, err := formatValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, element...)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
+
+// User is the resource of the example API
+type User struct {
+	ID    int      `json:"id"`
+	Name  string   `json:"name"`
+	Email string   `json:"email"`
+	Role  string   `json:"role"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// UserList is one page of users
+type UserList struct {
+	Users []User `json:"users"`
+	Total int    `json:"total"`
+}
+
+// Request structs: the tags say where each field goes
+type (
+	GetUserRequest struct {
+		ID int `path:"id"`
+	}
+
+	ListUsersRequest struct {
+		Role  string   `query:"role,omitempty"`
+		Tags  []string `query:"tag,omitempty"`
+		Page  int      `query:"page,omitempty"`
+		Limit int      `query:"limit,omitempty"`
+	}
+
+	CreateUserRequest struct {
+		Name  string   `json:"name"`
+		Email string   `json:"email"`
+		Role  string   `json:"role"`
+		Tags  []string `json:"tags,omitempty"`
+	}
+
+	UpdateUserRequest struct {
+		ID   int    `path:"id" json:"-"`
+		Role string `json:"role"`
+	}
+
+	DeleteUserRequest struct {
+		ID int `path:"id"`
+	}
+)
+
+// The API, described declaratively
+var (
+	GetUser    = Endpoint[GetUserRequest, User]{Method: http.MethodGet, Path: "/users/{id}"}
+	ListUsers  = Endpoint[ListUsersRequest, UserList]{Method: http.MethodGet, Path: "/users"}
+	CreateUser = Endpoint[CreateUserRequest, User]{Method: http.MethodPost, Path: "/users"}
+	UpdateUser = Endpoint[UpdateUserRequest, User]{Method: http.MethodPatch, Path: "/users/{id}"}
+	DeleteUser = Endpoint[DeleteUserRequest, NoContent]{Method: http.MethodDelete, Path: "/users/{id}"}
+	SlowReport = Endpoint[struct{}, map[string]int]{Method: http.MethodGet, Path: "/reports/slow"}
+)
+
+func main() {
+	server := httptest.NewServer(newUserAPI("valid-token"))
+	defer server.Close()
+
+	client := NewAPIClient(server.URL, WithAuthToken("valid-token"))
+	ctx := context.Background()
+
+	// Create
+	created, err := Call(ctx, client, CreateUser, CreateUserRequest{Name: "Carol", Email: "carol@example.com", Role: "admin", Tags: []string{"ops"}})
+	report("Create Carol", created, err)
+
+	// Path parameters
+	user, err := Call(ctx, client, GetUser, GetUserRequest{ID: created.ID})
+	report("Get user", user, err)
+
+	// Query parameters from a struct, the slice becomes repeated values
+	list, err := Call(ctx, client, ListUsers, ListUsersRequest{Role: "admin", Tags: []string{"ops", "oncall"}})
+	report("List admins tagged ops or oncall", list, err)
+
+	// A body and a path parameter in the same struct
+	updated, err := Call(ctx, client, UpdateUser, UpdateUserRequest{ID: 1, Role: "viewer"})
+	report("Update user 1", updated, err)
+
+	// No response body
+	_, err = Call(ctx, client, DeleteUser, DeleteUserRequest{ID: 2})
+	report("Delete user 2", "deleted", err)
+
+	// Typed errors decoded from the error responses
+	_, err = Call(ctx, client, GetUser, GetUserRequest{ID: 2})
+	report("Get deleted user", nil, err)
+	_, err = Call(ctx, client, CreateUser, CreateUserRequest{Name: "", Email: "not-an-email"})
+	report("Create invalid user", nil, err)
+	_, err = Call(ctx, client, CreateUser, CreateUserRequest{Name: "Carol", Email: "carol@example.com", Role: "admin"})
+	report("Create duplicate", nil, err)
+	_, err = Call(ctx, NewAPIClient(server.URL, WithAuthToken("expired")), ListUsers, ListUsersRequest{})
+	report("List with a bad token", nil, err)
+
+	// Errors detected before sending anything
+	_, err = Call(ctx, client, GetUser, GetUserRequest{})
+	report("Get without an ID", nil, err)
+
+	// Timeout
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = Call(timeoutCtx, client, SlowReport, struct{}{})
+	report("Slow report", nil, err)
+}
+
+// report prints the result of a call, handling errors with type checks
+func report(action string, result any, err error) {
+	fmt.Printf("%-34s ", action+":")
+	if err == nil {
+		data, _ := json.Marshal(result)
+		fmt.Println(string(data))
+		return
+	}
+
+	var apiErr *APIError
+	switch {
+	case errors.Is(err, ErrValidation) && errors.As(err, &apiErr):
+		fmt.Printf("invalid input: %v\n", apiErr.Fields)
+	case errors.Is(err, ErrNotFound):
+		fmt.Println("not found:", err)
+	case errors.Is(err, ErrConflict):
+		fmt.Println("already exists:", err)
+	case errors.Is(err, ErrUnauthorized):
+		fmt.Println("please log in again:", err)
+	case errors.Is(err, ErrTimeout):
+		fmt.Println("timed out, please try again:", err)
+	case errors.Is(err, ErrInvalidRequest):
+		fmt.Println("bug in the caller:", err)
+	default:
+		fmt.Println("unexpected error:", err)
+	}
+}
+
+// newUserAPI is a small in-memory users API answering with JSON errors
+func newUserAPI(token string) http.Handler {
+	var mu sync.Mutex
+	users := map[int]User{
+		1: {ID: 1, Name: "Alice", Email: "alice@example.com", Role: "admin", Tags: []string{"oncall"}},
+		2: {ID: 2, Name: "Bob", Email: "bob@example.com", Role: "editor"},
+	}
+	nextID := 3
+
+	writeJSON := func(w http.ResponseWriter, status int, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+	writeError := func(w http.ResponseWriter, status int, code, message string, fields map[string]string) {
+		var body errorBody
+		body.Error.Code, body.Error.Message, body.Error.Fields = code, message, fields
+		writeJSON(w, status, body)
+	}
+	findUser := func(w http.ResponseWriter, r *http.Request) (User, bool) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		user, ok := users[id]
+		if !ok {
+			writeError(w, http.StatusNotFound, "user_not_found", fmt.Sprintf("no user with ID %q", r.PathValue("id")), nil)
+		}
+		return user, ok
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if user, ok := findUser(w, r); ok {
+			writeJSON(w, http.StatusOK, user)
+		}
+	})
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		role, tags := r.URL.Query().Get("role"), r.URL.Query()["tag"]
+		list := UserList{Users: []User{}}
+		for id := 1; id < nextID; id++ {
+			user, ok := users[id]
+			if !ok || (role != "" && user.Role != role) {
+				continue
+			}
+			if len(tags) > 0 && !hasAny(user.Tags, tags) {
+				continue
+			}
+			list.Users = append(list.Users, user)
+		}
+		list.Total = len(list.Users)
+		writeJSON(w, http.StatusOK, list)
+	})
+	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {
+		var req CreateUserRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "bad_json", err.Error(), nil)
+			return
+		}
+		fields := make(map[string]string)
+		if req.Name == "" {
+			fields["name"] = "is required"
+		}
+		if !strings.Contains(req.Email, "@") {
+			fields["email"] = "must be an email address"
+		}
+		if req.Role == "" {
+			fields["role"] = "is required"
+		}
+		if len(fields) > 0 {
+			writeError(w, http.StatusUnprocessableEntity, "validation_failed", "the user is invalid", fields)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, user := range users {
+			if user.Email == req.Email {
+				writeError(w, http.StatusConflict, "email_taken", req.Email+" is already registered", nil)
+				return
+			}
+		}
+		user := User{ID: nextID, Name: req.Name, Email: req.Email, Role: req.Role, Tags: req.Tags}
+		users[user.ID] = user
+		nextID++
+		writeJSON(w, http.StatusCreated, user)
+	})
+	mux.HandleFunc("PATCH /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		user, ok := findUser(w, r)
+		if !ok {
+			return
+		}
+		var req UpdateUserRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "bad_json", err.Error(), nil)
+			return
+		}
+		user.Role = req.Role
+		users[user.ID] = user
+		writeJSON(w, http.StatusOK, user)
+	})
+	mux.HandleFunc("DELETE /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if user, ok := findUser(w, r); ok {
+			delete(users, user.ID)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	mux.HandleFunc("GET /reports/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+			writeJSON(w, http.StatusOK, map[string]int{"users": len(users)})
+		case <-r.Context().Done():
+		}
+	})
+
+	// Every route requires the token
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			writeError(w, http.StatusUnauthorized, "invalid_token", "the token is invalid or expired", nil)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// hasAny reports whether values contains any of wanted
+func hasAny(values, wanted []string) bool {
+	for _, v := range values {
+		for _, w := range wanted {
+			if v == w {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,514 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// APIError is returned for every unexpected response status
+type APIError struct {
+	StatusCode int
+	URL        string
+	Message    string
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error (%d) on %s: %s", e.StatusCode, e.URL, e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Define sentinel errors
+var (
+	ErrNotFound     = errors.New("resource not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrTimeout      = errors.New("request timed out")
+	ErrUnexpected   = errors.New("unexpected API response")
+
+	// ErrInterrupted means the connection broke during the download. The
+	// bytes received are kept, so downloading again to the same file resumes.
+	ErrInterrupted = errors.New("download interrupted")
+
+	// ErrChecksumMismatch means the downloaded content is not the expected one
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+// APIClient for making HTTP requests
+type APIClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	AuthToken  string
+
+	timeout time.Duration // Set by WithTimeout, applied by NewAPIClient
+}
+
+// ClientOption configures an APIClient created by NewAPIClient
+type ClientOption func(*APIClient)
+
+// WithAuthToken sends a bearer token with every request
+func WithAuthToken(token string) ClientOption {
+	return func(c *APIClient) {
+		c.AuthToken = token
+	}
+}
+
+// WithTimeout limits the time of a request, including reading the response,
+// whatever the order of the options
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *APIClient) {
+		c.timeout = timeout
+	}
+}
+
+// WithHTTPClient replaces the default HTTP client, for example to use a
+// custom transport; a nil client keeps the default one. The client is copied,
+// so WithTimeout never changes a shared client such as http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *APIClient) {
+		if httpClient != nil {
+			c.HTTPClient = httpClient
+		}
+	}
+}
+
+// NewAPIClient creates a new client with default settings, changed by the
+// options. There is no client timeout by default, as it would also limit
+// the time to read a large body: downloads are limited with the context
+// instead.
+func NewAPIClient(baseURL string, opts ...ClientOption) *APIClient {
+	c := &APIClient{
+		BaseURL:    baseURL,
+		HTTPClient: &http.Client{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	// The timeout is applied once every option ran, to a copy of the client
+	httpClient := *c.HTTPClient
+	if c.timeout > 0 {
+		httpClient.Timeout = c.timeout
+	}
+	c.HTTPClient = &httpClient
+	return c
+}
+
+// Progress is reported to the progress callback of a download
+type Progress struct {
+	Written int64 // Bytes written, including the resumed ones
+	Total   int64 // Size of the file, -1 if the server didn't tell
+	Resumed int64 // Bytes already present before this download
+}
+
+// Percent returns the completion percentage, or -1 if the size is unknown
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return -1
+	}
+	return float64(p.Written) * 100 / float64(p.Total)
+}
+
+// DownloadOptions configures a download. The zero value downloads without
+// progress, throttling or verification.
+type DownloadOptions struct {
+	OnProgress     func(Progress)
+	BytesPerSecond int64  // Bandwidth limit, 0 for none
+	SHA256         string // Expected hex checksum of the whole file, "" to skip
+}
+
+// Download streams a file to w and returns the number of bytes written by
+// this call. When w is a file (an io.ReadWriteSeeker) that already holds part
+// of the content, only the rest is requested with a Range header. The body is
+// never held in memory: it flows through a chain of readers, each adding one
+// feature.
+func (c *APIClient) Download(ctx context.Context, url string, w io.Writer, opts DownloadOptions) (int64, error) {
+	var checksum hash.Hash
+	if opts.SHA256 != "" {
+		checksum = sha256.New()
+	}
+
+	offset, err := resumeOffset(w, checksum)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare resume: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.AuthToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.AuthToken)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || os.IsTimeout(err) {
+			return 0, fmt.Errorf("download %s: %w", url, ErrTimeout)
+		}
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	total := resp.ContentLength
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		start, size, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			return 0, fmt.Errorf("invalid Content-Range %q: %w", resp.Header.Get("Content-Range"), ErrUnexpected)
+		}
+		total = size
+
+	case resp.StatusCode == http.StatusOK:
+		// The server ignored the Range header: start again from the beginning
+		if offset > 0 {
+			if err := restart(w, checksum); err != nil {
+				return 0, fmt.Errorf("failed to restart download: %w", err)
+			}
+			offset = 0
+		}
+
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// Nothing left to download if the file is already complete
+		_, size, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil || size != offset {
+			return 0, &APIError{StatusCode: resp.StatusCode, URL: url, Message: "the local file is larger than the remote one", Err: ErrUnexpected}
+		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(Progress{Written: offset, Total: size, Resumed: offset})
+		}
+		return 0, verify(checksum, opts.SHA256)
+
+	default:
+		return 0, statusError(resp, url)
+	}
+
+	// Compose the readers: throttle the body, hash what passes through and
+	// count it for the progress callback
+	var body io.Reader = resp.Body
+	if opts.BytesPerSecond > 0 {
+		body = NewThrottledReader(ctx, body, opts.BytesPerSecond)
+	}
+	if checksum != nil {
+		body = io.TeeReader(body, checksum)
+	}
+	if opts.OnProgress != nil {
+		body = &progressReader{
+			r:        body,
+			progress: Progress{Written: offset, Total: total, Resumed: offset},
+			report:   opts.OnProgress,
+		}
+	}
+
+	written, err := io.Copy(w, body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return written, fmt.Errorf("download %s after %d bytes: %w", url, offset+written, ctx.Err())
+		}
+		return written, fmt.Errorf("%w after %d bytes: %v", ErrInterrupted, offset+written, err)
+	}
+	if total >= 0 && offset+written != total {
+		return written, fmt.Errorf("%w: got %d of %d bytes", ErrInterrupted, offset+written, total)
+	}
+	return written, verify(checksum, opts.SHA256)
+}
+
+// resumeOffset returns the number of bytes already in w, and feeds them to
+// the checksum. Only a writer that can be read and seeked can be resumed.
+func resumeOffset(w io.Writer, checksum hash.Hash) (int64, error) {
+	file, ok := w.(io.ReadWriteSeeker)
+	if !ok {
+		return 0, nil
+	}
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil || size == 0 || checksum == nil {
+		return size, err
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if _, err := io.CopyN(checksum, file, size); err != nil {
+		return 0, err
+	}
+	return file.Seek(0, io.SeekEnd)
+}
+
+// restart empties w to download the whole file again
+func restart(w io.Writer, checksum hash.Hash) error {
+	if checksum != nil {
+		checksum.Reset()
+	}
+	if truncater, ok := w.(interface{ Truncate(size int64) error }); ok {
+		if err := truncater.Truncate(0); err != nil {
+			return err
+		}
+	}
+	_, err := w.(io.Seeker).Seek(0, io.SeekStart)
+	return err
+}
+
+// verify compares the checksum of the content with the expected one
+func verify(checksum hash.Hash, expected string) error {
+	if checksum == nil {
+		return nil
+	}
+	got := hex.EncodeToString(checksum.Sum(nil))
+	if !strings.EqualFold(got, expected) {
+		return fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, expected, got)
+	}
+	return nil
+}
+
+// parseContentRange parses "bytes 100-199/1000" or "bytes */1000" and
+// returns the first byte and the size of the file
+func parseContentRange(value string) (start, size int64, err error) {
+	rangePart, sizePart, ok := strings.Cut(strings.TrimPrefix(value, "bytes "), "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", value)
+	}
+	if size, err = strconv.ParseInt(sizePart, 10, 64); err != nil {
+		return 0, 0, err
+	}
+	if rangePart == "*" {
+		return 0, size, nil
+	}
+	first, _, _ := strings.Cut(rangePart, "-")
+	start, err = strconv.ParseInt(first, 10, 64)
+	return start, size, err
+}
+
+// statusError turns an unexpected response into an *APIError
+func statusError(resp *http.Response, url string) error {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		URL:        url,
+		Message:    fmt.Sprintf("API returned status %d", resp.StatusCode),
+		Err:        ErrUnexpected,
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		apiErr.Message, apiErr.Err = "File not found", ErrNotFound
+	case http.StatusUnauthorized:
+		apiErr.Message, apiErr.Err = "Invalid or expired token", ErrUnauthorized
+	}
+	return apiErr
+}
+
+// ThrottledReader limits the rate of an io.Reader. It never reads more than
+// a tenth of a second of data at once, then sleeps until the average rate is
+// back under the limit.
+type ThrottledReader struct {
+	ctx            context.Context
+	r              io.Reader
+	bytesPerSecond int64
+	start          time.Time
+	read           int64
+}
+
+// NewThrottledReader limits r to bytesPerSecond, the wait is cancelled with ctx
+func NewThrottledReader(ctx context.Context, r io.Reader, bytesPerSecond int64) *ThrottledReader {
+	return &ThrottledReader{ctx: ctx, r: r, bytesPerSecond: bytesPerSecond, start: time.Now()}
+}
+
+func (t *ThrottledReader) Read(p []byte) (int, error) {
+	if chunk := max(t.bytesPerSecond/10, 1); int64(len(p)) > chunk {
+		p = p[:chunk]
+	}
+	n, err := t.r.Read(p)
+	t.read += int64(n)
+
+	// Time the bytes read so far should have taken at the limited rate
+	due := time.Duration(float64(t.read) / float64(t.bytesPerSecond) * float64(time.Second))
+	if wait := due - time.Since(t.start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-t.ctx.Done():
+			return n, t.ctx.Err()
+		}
+	}
+	return n, err
+}
+
+// progressReader reports the progress after every read
+type progressReader struct {
+	r        io.Reader
+	progress Progress
+	report   func(Progress)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.progress.Written += int64(n)
+		p.report(p.progress)
+	}
+	return n, err
+}
+
+// progressBar returns a callback printing a bar every 25%
+func progressBar(name string) func(Progress) {
+	next := 0.0
+	return func(p Progress) {
+		percent := p.Percent()
+		if percent < next {
+			return
+		}
+		filled := int(percent / 10)
+		fmt.Printf("  %-10s [%-10s] %3.0f%% %7d/%d bytes\n", name, strings.Repeat("#", filled), percent, p.Written, p.Total)
+		next = float64(int(percent/25)+1) * 25
+	}
+}
+
+func main() {
+	// A file of 256 KiB and its checksum
+	content := make([]byte, 256<<10)
+	rand.New(rand.NewSource(1)).Read(content)
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	server := httptest.NewServer(newFileServer(content, "valid-token"))
+	defer server.Close()
+	client := NewAPIClient(server.URL, WithAuthToken("valid-token"))
+	ctx := context.Background()
+
+	// 1. Download to memory with a progress bar, limited to 128 KiB/s
+	fmt.Println("1. Throttled download to memory")
+	var buf bytes.Buffer
+	start := time.Now()
+	n, err := client.Download(ctx, server.URL+"/files/data.bin", &buf, DownloadOptions{
+		OnProgress:     progressBar("data.bin"),
+		BytesPerSecond: 128 << 10,
+		SHA256:         checksum,
+	})
+	report(n, err)
+	fmt.Printf("  took %v, about 2s expected\n", time.Since(start).Round(100*time.Millisecond))
+
+	// 2. The connection breaks: download again to the same file to resume
+	fmt.Println("\n2. Resuming an interrupted download")
+	file, err := os.CreateTemp("", "download-*.bin")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		n, err = client.Download(ctx, server.URL+"/files/flaky.bin", file, DownloadOptions{
+			OnProgress: progressBar("flaky.bin"),
+			SHA256:     checksum,
+		})
+		report(n, err)
+		if !errors.Is(err, ErrInterrupted) {
+			break
+		}
+		fmt.Printf("  attempt %d interrupted, retrying\n", attempt)
+	}
+
+	// 3. The file is complete: the server answers 416 and nothing is downloaded
+	fmt.Println("\n3. Downloading a complete file again")
+	n, err = client.Download(ctx, server.URL+"/files/data.bin", file, DownloadOptions{SHA256: checksum})
+	report(n, err)
+
+	// 4. A server ignoring Range headers sends everything again
+	fmt.Println("\n4. Server without range support")
+	file.Truncate(100 << 10)
+	n, err = client.Download(ctx, server.URL+"/files/no-range.bin", file, DownloadOptions{SHA256: checksum})
+	report(n, err)
+
+	// 5. Errors
+	fmt.Println("\n5. Errors")
+	n, err = client.Download(ctx, server.URL+"/files/data.bin", io.Discard, DownloadOptions{SHA256: strings.Repeat("0", 64)})
+	report(n, err)
+	n, err = client.Download(ctx, server.URL+"/files/missing.bin", io.Discard, DownloadOptions{})
+	report(n, err)
+	n, err = NewAPIClient(server.URL, WithAuthToken("expired")).Download(ctx, server.URL+"/files/data.bin", io.Discard, DownloadOptions{})
+	report(n, err)
+
+	// The context stops a throttled download while it waits
+	timeoutCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	n, err = client.Download(timeoutCtx, server.URL+"/files/data.bin", io.Discard, DownloadOptions{BytesPerSecond: 64 << 10})
+	report(n, err)
+}
+
+// report prints the result of a download, handling errors with type checks
+func report(n int64, err error) {
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		fmt.Printf("  OK, %d bytes downloaded\n", n)
+	case errors.Is(err, ErrInterrupted):
+		fmt.Printf("  %v (%d bytes received by this call)\n", err, n)
+	case errors.Is(err, ErrChecksumMismatch):
+		fmt.Println("  corrupted download:", err)
+	case errors.Is(err, context.DeadlineExceeded):
+		fmt.Printf("  stopped by the context after %d bytes: %v\n", n, err)
+	case errors.As(err, &apiErr):
+		fmt.Printf("  API error (%d): %s\n", apiErr.StatusCode, apiErr.Message)
+	default:
+		fmt.Println("  unexpected error:", err)
+	}
+}
+
+// newFileServer serves the same content under several behaviours:
+// data.bin supports ranges, flaky.bin breaks the connection twice and
+// no-range.bin ignores Range headers
+func newFileServer(content []byte, token string) http.Handler {
+	modified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var flakyRequests atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /files/data.bin", func(w http.ResponseWriter, r *http.Request) {
+		// ServeContent handles Range, Content-Range and 416 responses
+		http.ServeContent(w, r, "data.bin", modified, bytes.NewReader(content))
+	})
+	mux.HandleFunc("GET /files/flaky.bin", func(w http.ResponseWriter, r *http.Request) {
+		if flakyRequests.Add(1) > 2 {
+			http.ServeContent(w, r, "flaky.bin", modified, bytes.NewReader(content))
+			return
+		}
+		// Announce the whole file but stop after 100 KiB: the client gets
+		// an unexpected EOF
+		start, _, _ := parseContentRange("bytes " + strings.TrimPrefix(r.Header.Get("Range"), "bytes=") + "/0")
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)-int(start)))
+		if start > 0 {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		w.Write(content[start:min(start+100<<10, int64(len(content)))])
+	})
+	mux.HandleFunc("GET /files/no-range.bin", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Write(content)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			http.Error(w, "invalid token", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
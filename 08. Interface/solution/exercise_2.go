@@ -1,9 +1,10 @@
 package main
 
 import (
+	"cmp"
 	"fmt"
 	"math"
-	"sort"
+	"slices"
 )
 
 // Shape interface defines methods all shapes must implement
@@ -122,8 +123,8 @@ type ShapeProcessor struct{}
 
 // SortByArea sorts shapes by their area
 func (sp ShapeProcessor) SortByArea(shapes []Shape) {
-	sort.Slice(shapes, func(i, j int) bool {
-		return shapes[i].Area() < shapes[j].Area()
+	slices.SortFunc(shapes, func(a, b Shape) int {
+		return cmp.Compare(a.Area(), b.Area())
 	})
 }
 
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"runtime"
+	"runtime/debug"
 	"time"
 )
 
@@ -143,6 +146,57 @@ func (p TimerPlugin) Version() string {
 	return "1.0.0"
 }
 
+// StatsPlugin computes the mean of whole numbers. It has a bug: an empty
+// list divides by zero, which panics.
+type StatsPlugin struct{}
+
+func (p StatsPlugin) Name() string {
+	return "Stats"
+}
+
+func (p StatsPlugin) Execute(data map[string]interface{}) (interface{}, error) {
+	values, ok := data["values"].([]int)
+	if !ok {
+		return nil, fmt.Errorf("values is required and must be a list of integers")
+	}
+
+	sum := 0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / len(values), nil
+}
+
+func (p StatsPlugin) Version() string {
+	return "0.1.0"
+}
+
+// PanicError is the error returned by SafeCall when the function panics
+type PanicError struct {
+	Value interface{} // The value given to panic
+	Stack []byte      // The stack of the goroutine when it panicked
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value when it is an error, such as a runtime.Error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// SafeCall runs fn and turns a panic into a *PanicError
+func SafeCall[T any](fn func() (T, error)) (result T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
 // PluginManager handles registration and execution of plugins
 type PluginManager struct {
 	plugins map[string]Plugin
@@ -178,7 +232,16 @@ func (pm *PluginManager) ExecutePlugin(name string, data map[string]interface{})
 		return nil, fmt.Errorf("plugin '%s' not found", name)
 	}
 
-	return plugin.Execute(data)
+	// A plugin is code the manager doesn't control: a panic inside it must
+	// not crash the manager and the other plugins
+	result, err := SafeCall(func() (interface{}, error) {
+		return plugin.Execute(data)
+	})
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		return nil, fmt.Errorf("plugin '%s' crashed: %w", name, err)
+	}
+	return result, err
 }
 
 // ListPlugins returns the names of all registered plugins
@@ -240,6 +303,26 @@ func main() {
 		fmt.Printf("Expected error: %v\n", err)
 	}
 
+	// A buggy plugin panics, and the manager keeps running
+	manager.RegisterPlugin(StatsPlugin{})
+	result, err = manager.ExecutePlugin("Stats", map[string]interface{}{
+		"values": []int{4, 8, 15, 16, 23, 42},
+	})
+	if err == nil {
+		fmt.Printf("Stats result: %v\n", result)
+	}
+	_, err = manager.ExecutePlugin("Stats", map[string]interface{}{
+		"values": []int{},
+	})
+	var panicErr *PanicError
+	var runtimeErr runtime.Error
+	if errors.As(err, &panicErr) {
+		fmt.Printf("Recovered: %v\n", err)
+		if errors.As(err, &runtimeErr) {
+			fmt.Println("The plugin has a bug: it caused a runtime error")
+		}
+	}
+
 	manager.RegisterPlugin(TimerPlugin{})
 
 	fmt.Println("\nPlugins after adding new one:")
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// The types below wrap an io.Reader or an io.Writer and are one themselves,
+// so they plug into anything taking the interface: io.Copy, bufio, gzip,
+// http bodies, and each other.
+
+// LineCounter counts the lines of the content read through it
+type LineCounter struct {
+	r     io.Reader
+	Lines int
+	Bytes int64
+}
+
+func NewLineCounter(r io.Reader) *LineCounter {
+	return &LineCounter{r: r}
+}
+
+func (c *LineCounter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	// Only the n bytes read are valid, even when err is not nil
+	c.Lines += bytes.Count(p[:n], []byte{'\n'})
+	c.Bytes += int64(n)
+	return n, err
+}
+
+// RateLimitedWriter writes at most a number of bytes per second, by waiting
+// between chunks
+type RateLimitedWriter struct {
+	w              io.Writer
+	bytesPerSecond int
+	start          time.Time
+	written        int64
+}
+
+func NewRateLimitedWriter(w io.Writer, bytesPerSecond int) *RateLimitedWriter {
+	return &RateLimitedWriter{w: w, bytesPerSecond: bytesPerSecond}
+}
+
+func (l *RateLimitedWriter) Write(p []byte) (int, error) {
+	if l.start.IsZero() {
+		l.start = time.Now()
+	}
+	total := 0
+	for len(p) > 0 {
+		// Write at most a tenth of a second worth of bytes at a time
+		chunk := p[:min(len(p), max(1, l.bytesPerSecond/10))]
+		n, err := l.w.Write(chunk)
+		total += n
+		l.written += int64(n)
+		if err != nil {
+			return total, err
+		}
+		p = p[n:]
+
+		// Wait until the bytes written are allowed by the rate
+		due := l.start.Add(time.Duration(l.written) * time.Second / time.Duration(l.bytesPerSecond))
+		time.Sleep(time.Until(due))
+	}
+	return total, nil
+}
+
+// ChecksumReader computes the SHA-256 of the content read through it. It is
+// an io.TeeReader writing everything read into the hash, so the content is
+// hashed while it flows to its destination, without being read twice.
+type ChecksumReader struct {
+	io.Reader // The TeeReader: ChecksumReader gets its Read method
+	hash      hash.Hash
+}
+
+func NewChecksumReader(r io.Reader) *ChecksumReader {
+	h := sha256.New()
+	return &ChecksumReader{Reader: io.TeeReader(r, h), hash: h}
+}
+
+// Sum returns the hex checksum of the content read so far
+func (c *ChecksumReader) Sum() string {
+	return hex.EncodeToString(c.hash.Sum(nil))
+}
+
+// rot13Reader applies the ROT13 cipher, rotating letters by 13 places.
+// Applying it twice gives the original text back.
+type rot13Reader struct {
+	r io.Reader
+}
+
+func rot13(b byte) byte {
+	switch {
+	case b >= 'a' && b <= 'z':
+		return 'a' + (b-'a'+13)%26
+	case b >= 'A' && b <= 'Z':
+		return 'A' + (b-'A'+13)%26
+	}
+	return b
+}
+
+func (r rot13Reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	for i := range p[:n] {
+		p[i] = rot13(p[i])
+	}
+	return n, err
+}
+
+// upload copies the content to dst and returns its checksum: the pipeline
+// of the upload exercises of modules 12 and 13
+func upload(dst io.Writer, src io.Reader) (int64, string, error) {
+	checksum := NewChecksumReader(src)
+	n, err := io.Copy(dst, checksum)
+	return n, checksum.Sum(), err
+}
+
+const poem = `Gur Mra bs Tb
+Fvzcyr vf orggre guna pbzcyrk.
+Pyrne vf orggre guna pyrire.
+Reebef ner inyhrf.
+`
+
+func main() {
+	fmt.Println("=== rot13 and line counting ===")
+	// strings.Reader -> rot13 -> LineCounter -> os.Stdout
+	counter := NewLineCounter(rot13Reader{strings.NewReader(poem)})
+	if _, err := io.Copy(os.Stdout, counter); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Printf("(%d lines, %d bytes)\n", counter.Lines, counter.Bytes)
+
+	fmt.Println("\n=== Checksum while copying ===")
+	var stored bytes.Buffer
+	n, sum, err := upload(&stored, strings.NewReader(poem))
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	direct := sha256.Sum256([]byte(poem))
+	fmt.Printf("Uploaded %d bytes, sha256 %s...\n", n, sum[:16])
+	fmt.Println("Same as hashing the whole content:", sum == hex.EncodeToString(direct[:]))
+
+	fmt.Println("\n=== Rate-limited writer ===")
+	// Only the first line, typed out at 40 bytes per second
+	firstLine := io.LimitReader(rot13Reader{strings.NewReader(poem)}, 14)
+	start := time.Now()
+	if _, err := io.Copy(NewRateLimitedWriter(os.Stdout, 40), firstLine); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Printf("(14 bytes in %v)\n", time.Since(start).Round(10*time.Millisecond))
+
+	fmt.Println("\n=== One pipeline ===")
+	// Decode, count and hash the poem while writing it to two places: a
+	// buffered, rate-limited stdout and a copy in memory
+	decoded := NewLineCounter(rot13Reader{strings.NewReader(poem)})
+	checksum := NewChecksumReader(decoded)
+	var copyBuf bytes.Buffer
+	out := bufio.NewWriter(NewRateLimitedWriter(os.Stdout, 400))
+	if _, err := io.Copy(io.MultiWriter(out, &copyBuf), checksum); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	if err := out.Flush(); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Printf("%d lines, sha256 %s..., copy of %d bytes\n", decoded.Lines, checksum.Sum()[:16], copyBuf.Len())
+
+	// rot13 twice is the identity
+	again, _ := io.ReadAll(rot13Reader{rot13Reader{strings.NewReader(poem)}})
+	fmt.Println("rot13 applied twice gives the original:", string(again) == poem)
+}
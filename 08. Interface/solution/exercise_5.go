@@ -0,0 +1,211 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Product represents an item in the inventory
+type Product struct {
+	SKU      string
+	Name     string
+	Category string
+	Price    float64
+	Stock    int
+}
+
+// Transaction represents an inventory transaction
+type Transaction struct {
+	ID         string
+	ProductSKU string
+	Type       string // "purchase", "sale", "adjustment"
+	Quantity   int
+	Date       time.Time
+}
+
+// ByCategoryPrice implements sort.Interface: products are ordered by
+// category, then by price within a category, then by name so the order is
+// fully determined
+type ByCategoryPrice []Product
+
+func (p ByCategoryPrice) Len() int      { return len(p) }
+func (p ByCategoryPrice) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p ByCategoryPrice) Less(i, j int) bool {
+	if p[i].Category != p[j].Category {
+		return p[i].Category < p[j].Category
+	}
+	if p[i].Price != p[j].Price {
+		return p[i].Price < p[j].Price
+	}
+	return p[i].Name < p[j].Name
+}
+
+// reverse wraps a sort.Interface and inverts its order. Embedding the
+// interface gives reverse the Len and Swap methods of the wrapped value;
+// only Less is replaced. sort.Reverse is written the same way.
+type reverse struct {
+	sort.Interface
+}
+
+func (r reverse) Less(i, j int) bool {
+	return r.Interface.Less(j, i)
+}
+
+// lessFunc compares two products on one key
+type lessFunc func(a, b *Product) bool
+
+// multiSorter sorts products by a list of keys: a key is only looked at
+// when the products are equal on all the keys before it
+type multiSorter struct {
+	products []Product
+	less     []lessFunc
+}
+
+// OrderedBy returns a sorter using the keys in order
+func OrderedBy(less ...lessFunc) *multiSorter {
+	return &multiSorter{less: less}
+}
+
+// Sort sorts the products with the keys of the sorter
+func (ms *multiSorter) Sort(products []Product) {
+	ms.products = products
+	sort.Sort(ms)
+}
+
+func (ms *multiSorter) Len() int { return len(ms.products) }
+func (ms *multiSorter) Swap(i, j int) {
+	ms.products[i], ms.products[j] = ms.products[j], ms.products[i]
+}
+
+func (ms *multiSorter) Less(i, j int) bool {
+	a, b := &ms.products[i], &ms.products[j]
+	for _, less := range ms.less {
+		switch {
+		case less(a, b):
+			return true
+		case less(b, a):
+			return false
+		}
+	}
+	return false
+}
+
+func printProducts(title string, products []Product) {
+	fmt.Println(title)
+	for _, p := range products {
+		fmt.Printf("  %-12s %-10s $%8.2f  stock %d\n", p.Category, p.Name, p.Price, p.Stock)
+	}
+}
+
+// sampleTransactions creates transactions in date order. There are enough of
+// them to show the unstable sorts: on short slices, they sort by insertion,
+// which happens to keep equal elements in order.
+func sampleTransactions() []Transaction {
+	types := []string{"sale", "purchase", "sale", "adjustment", "sale"}
+	start := time.Date(2025, time.March, 1, 9, 0, 0, 0, time.UTC)
+	var transactions []Transaction
+	for i := range 40 {
+		transactions = append(transactions, Transaction{
+			ID:         fmt.Sprintf("T%03d", i+1),
+			ProductSKU: fmt.Sprintf("P%03d", i%4+1),
+			Type:       types[(i*7)%len(types)],
+			Quantity:   i%9 + 1,
+			Date:       start.Add(time.Duration(i) * 3 * time.Hour),
+		})
+	}
+	return transactions
+}
+
+// keepsDateOrder reports whether the transactions of each type are still in
+// date order
+func keepsDateOrder(transactions []Transaction) bool {
+	last := make(map[string]time.Time)
+	for _, t := range transactions {
+		if t.Date.Before(last[t.Type]) {
+			return false
+		}
+		last[t.Type] = t.Date
+	}
+	return true
+}
+
+func main() {
+	products := []Product{
+		{"P001", "Laptop", "Electronics", 1200.00, 8},
+		{"P002", "Mouse", "Electronics", 25.00, 40},
+		{"P003", "Desk", "Furniture", 350.00, 5},
+		{"P004", "Pen", "Office", 1.50, 500},
+		{"P005", "Keyboard", "Electronics", 75.00, 25},
+		{"P006", "Chair", "Furniture", 150.00, 12},
+		{"P007", "Notebook", "Office", 4.00, 200},
+		{"P008", "Monitor", "Electronics", 300.00, 15},
+		{"P009", "Stapler", "Office", 12.00, 30},
+		{"P010", "Lamp", "Furniture", 45.00, 20},
+		{"P011", "Cable", "Electronics", 25.00, 100},
+	}
+
+	fmt.Println("=== sort.Interface ===")
+	sorted := slices.Clone(products)
+	sort.Sort(ByCategoryPrice(sorted))
+	printProducts("By category, then price:", sorted)
+
+	sort.Sort(reverse{ByCategoryPrice(sorted)})
+	printProducts("\nReversed with our adapter:", sorted[:4])
+
+	sort.Sort(sort.Reverse(ByCategoryPrice(sorted)))
+	printProducts("\nReversed with sort.Reverse, same order:", sorted[:4])
+
+	fmt.Println("\n=== Keys chosen at run time ===")
+	category := func(a, b *Product) bool { return a.Category < b.Category }
+	price := func(a, b *Product) bool { return a.Price < b.Price }
+	name := func(a, b *Product) bool { return a.Name < b.Name }
+	OrderedBy(price, name).Sort(sorted)
+	printProducts("By price, then name:", sorted[:4])
+	OrderedBy(category, name).Sort(sorted)
+	printProducts("By category, then name:", sorted)
+
+	fmt.Println("\n=== slices.SortFunc ===")
+	// The same order as ByCategoryPrice, with one comparison function and no
+	// new type: cmp.Or returns the first comparison that is not equal
+	bySortFunc := slices.Clone(products)
+	slices.SortFunc(bySortFunc, func(a, b Product) int {
+		return cmp.Or(
+			cmp.Compare(a.Category, b.Category),
+			cmp.Compare(a.Price, b.Price),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+	byInterface := slices.Clone(products)
+	sort.Sort(ByCategoryPrice(byInterface))
+	fmt.Println("Same order as sort.Interface:", slices.Equal(bySortFunc, byInterface))
+
+	fmt.Println("\n=== Stable sorting of transactions ===")
+	// The transactions are in date order. Sorting them by type should keep
+	// the transactions of a type in date order, which only a stable sort
+	// guarantees.
+	byType := func(a, b Transaction) int { return cmp.Compare(a.Type, b.Type) }
+
+	unstable := sampleTransactions()
+	slices.SortFunc(unstable, byType)
+	fmt.Println("slices.SortFunc keeps the date order:      ", keepsDateOrder(unstable))
+
+	stable := sampleTransactions()
+	slices.SortStableFunc(stable, byType)
+	fmt.Println("slices.SortStableFunc keeps the date order:", keepsDateOrder(stable))
+
+	legacy := sampleTransactions()
+	sort.SliceStable(legacy, func(i, j int) bool { return legacy[i].Type < legacy[j].Type })
+	fmt.Println("sort.SliceStable keeps the date order:     ", keepsDateOrder(legacy))
+
+	fmt.Println("\nFirst transactions of each type, stable:")
+	shown := make(map[string]int)
+	for _, t := range stable {
+		if shown[t.Type] < 3 {
+			shown[t.Type]++
+			fmt.Printf("  %-10s %s %s qty %d\n", t.Type, t.ID, t.Date.Format("Jan 02 15:04"), t.Quantity)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// The fmt package looks for these interfaces on the values it prints:
+//
+//	fmt.Stringer    String() string                 %v and %s
+//	error           Error() string                  %v and %s, before String
+//	fmt.GoStringer  GoString() string               %#v
+//	fmt.Formatter   Format(f fmt.State, verb rune)  every verb, replacing the others
+//
+// Stringer is enough for most types. Formatter is for types printed in
+// several ways, such as a short and a detailed form.
+
+// ===== LogLevel: Stringer, plus Formatter for the numeric verbs =====
+
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return "LogLevel(" + strconv.Itoa(int(l)) + ")"
+}
+
+// Format prints the name for %v, %s and %q, and the number for %d. The flags
+// and width of the verb, such as %-5v, are kept: fmt.FormatString rebuilds
+// the directive, which is then applied to the name or the number.
+func (l LogLevel) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'd':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), int(l))
+	case 'v', 's', 'q':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), l.String())
+	default:
+		fmt.Fprintf(f, "%%!%c(LogLevel=%d)", verb, int(l))
+	}
+}
+
+// ===== Money: every verb means something =====
+
+// Money is an amount in the minor unit of its currency, such as cents
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// currency describes how the amounts of a currency are written, as in the
+// models package of module 09
+type currency struct {
+	digits int // Digits of the minor unit: 2 for cents, 0 for yen
+	symbol string
+}
+
+var currencies = map[string]currency{
+	"USD": {digits: 2, symbol: "$"},
+	"EUR": {digits: 2, symbol: "€"},
+	"GBP": {digits: 2, symbol: "£"},
+	"CAD": {digits: 2, symbol: "CA$"},
+	"JPY": {digits: 0, symbol: "¥"},
+}
+
+// decimal returns the amount as "1234.50", or "500" for yen
+func (m Money) decimal() string {
+	digits := currencies[m.Currency].digits
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	if digits == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+	unit := int64(math.Pow10(digits))
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/unit, digits, amount%unit)
+}
+
+// String returns the amount for display, such as "$1,234.50" or "-¥500".
+// Currencies without a symbol are written "12.00 XYZ".
+func (m Money) String() string {
+	cur, ok := currencies[m.Currency]
+	if !ok {
+		return m.decimal() + " " + m.Currency
+	}
+
+	whole, fraction, hasFraction := strings.Cut(strings.TrimPrefix(m.decimal(), "-"), ".")
+	var b strings.Builder
+	if m.Amount < 0 {
+		b.WriteString("-")
+	}
+	b.WriteString(cur.symbol)
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(",")
+		}
+		b.WriteRune(r)
+	}
+	if hasFraction {
+		b.WriteString("." + fraction)
+	}
+	return b.String()
+}
+
+// GoString returns Go syntax creating the value, printed by %#v
+func (m Money) GoString() string {
+	return fmt.Sprintf("Money{Amount: %d, Currency: %q}", m.Amount, m.Currency)
+}
+
+// Format prints:
+//
+//	%v, %s  $19.99
+//	%+v     19.99 USD, unambiguous in logs
+//	%#v     Money{Amount: 1999, Currency: "USD"}
+//	%d      1999, the amount in minor units
+//	%q      "$19.99"
+func (m Money) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('#'):
+		fmt.Fprint(f, m.GoString())
+	case verb == 'v' && f.Flag('+'):
+		fmt.Fprintf(f, "%s %s", m.decimal(), m.Currency)
+	case verb == 'v', verb == 's', verb == 'q':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), m.String())
+	case verb == 'd':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), m.Amount)
+	default:
+		fmt.Fprintf(f, "%%!%c(Money=%s)", verb, m.String())
+	}
+}
+
+// ===== Book: Stringer and GoStringer only =====
+
+type Book struct {
+	ID     string
+	Title  string
+	Author string
+	Year   int
+}
+
+func (b Book) String() string {
+	// fmt.Sprintf("%v", b) here would call String again, forever: print the
+	// fields instead
+	return fmt.Sprintf("%q by %s (%d)", b.Title, b.Author, b.Year)
+}
+
+// GoString prints the struct without calling String. The local type has the
+// fields of Book but none of its methods, so %#v formats it field by field.
+func (b Book) GoString() string {
+	type plain Book
+	return strings.Replace(fmt.Sprintf("%#v", plain(b)), "main.plain", "main.Book", 1)
+}
+
+// ===== Order: short and detailed forms =====
+
+type OrderItem struct {
+	Name     string
+	Quantity int
+	Price    Money
+}
+
+type Order struct {
+	ID       string
+	Customer string
+	Items    []OrderItem
+	Status   string
+}
+
+func (o Order) Total() Money {
+	total := Money{Currency: "USD"}
+	for _, item := range o.Items {
+		total.Amount += item.Price.Amount * int64(item.Quantity)
+	}
+	return total
+}
+
+func (o Order) String() string {
+	return fmt.Sprintf("order %s (%s, %d items, %s)", o.ID, o.Status, len(o.Items), o.Total())
+}
+
+// Format prints the short form for %v and %s, and every item for %+v
+func (o Order) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('+'):
+		fmt.Fprintf(f, "Order %s for %s, %s\n", o.ID, o.Customer, o.Status)
+		for _, item := range o.Items {
+			fmt.Fprintf(f, "  %2d x %-10s %8s\n", item.Quantity, item.Name, item.Price)
+		}
+		fmt.Fprintf(f, "  Total %19s", o.Total())
+	case verb == 'v' && f.Flag('#'):
+		// As in Book.GoString, a type without methods avoids calling Format again
+		type plain Order
+		fmt.Fprint(f, strings.Replace(fmt.Sprintf("%#v", plain(o)), "main.plain", "main.Order", 1))
+	case verb == 'v', verb == 's', verb == 'q':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), o.String())
+	default:
+		fmt.Fprintf(f, "%%!%c(Order=%s)", verb, o.ID)
+	}
+}
+
+// ===== OrderError: the error interface =====
+
+// OrderError is an error about an order. fmt prefers Error over String.
+type OrderError struct {
+	OrderID string
+	Err     error
+}
+
+func (e *OrderError) Error() string {
+	return "order " + e.OrderID + ": " + e.Err.Error()
+}
+
+func (e *OrderError) Unwrap() error {
+	return e.Err
+}
+
+var ErrOutOfStock = errors.New("out of stock")
+
+func main() {
+	fmt.Println("=== LogLevel ===")
+	for _, level := range []LogLevel{LevelDebug, LevelInfo, LevelWarn, LevelError, 7} {
+		fmt.Printf("[%-5v] %d %q\n", level, level, level)
+	}
+
+	fmt.Println("\n=== Money ===")
+	price := Money{Amount: 1999, Currency: "USD"}
+	refund := Money{Amount: -450, Currency: "EUR"}
+	for _, format := range []string{"%v", "%s", "%+v", "%#v", "%d", "%q", "[%10v]", "[%-10v]", "%x"} {
+		fmt.Printf("  %-8s %-40s %s\n", format, fmt.Sprintf(format, price), fmt.Sprintf(format, refund))
+	}
+	// Thousands separators, and currencies without cents
+	fmt.Println(" ", Money{123450, "USD"}, Money{-1234567, "CAD"}, Money{500, "JPY"}, Money{1200, "XYZ"})
+
+	fmt.Println("\n=== Book ===")
+	book := Book{ID: "B001", Title: "The Go Programming Language", Author: "Alan Donovan", Year: 2015}
+	fmt.Printf("  %%v   %v\n", book)
+	// Without a Formatter, %+v calls String too: the field names are lost
+	fmt.Printf("  %%+v  %+v\n", book)
+	fmt.Printf("  %%#v  %#v\n", book)
+	// Printing a slice formats each element
+	fmt.Printf("  %%v of a slice: %v\n", []Book{book, {Title: "Learning Go", Author: "Jon Bodner", Year: 2021}})
+
+	fmt.Println("\n=== Order ===")
+	order := Order{
+		ID:       "A1001",
+		Customer: "Alice",
+		Status:   "paid",
+		Items: []OrderItem{
+			{"Laptop", 1, Money{120000, "USD"}},
+			{"Mouse", 2, Money{2500, "USD"}},
+		},
+	}
+	fmt.Printf("%%v:  %v\n", order)
+	fmt.Printf("%%q:  %q\n", order)
+	fmt.Printf("%%+v:\n%+v\n", order)
+	fmt.Printf("%%#v: %#v\n", order)
+
+	fmt.Println("\n=== OrderError ===")
+	err := fmt.Errorf("checkout failed: %w", &OrderError{OrderID: order.ID, Err: ErrOutOfStock})
+	fmt.Printf("%%v: %v\n", err)
+	fmt.Println("is out of stock:", errors.Is(err, ErrOutOfStock))
+}
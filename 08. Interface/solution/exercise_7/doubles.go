@@ -0,0 +1,298 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Test doubles written by hand. Each one is a small type implementing one
+// of the interfaces above; no code generator or mocking library is needed
+// because the interfaces are small. The kinds used here:
+//
+//	stub  answers calls with canned values       QueryExecutorFunc
+//	spy   a stub that also records its calls     GatewaySpy, QuerySpy
+//	fake  a working, simplified implementation   FakeTodoRepository
+
+// Call is a call recorded by a double
+type Call struct {
+	Method string
+	Args   []any
+}
+
+func (c Call) String() string {
+	args := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		if s, ok := arg.(string); ok {
+			args[i] = strconv.Quote(s)
+		} else {
+			args[i] = fmt.Sprint(arg)
+		}
+	}
+	return c.Method + "(" + strings.Join(args, ", ") + ")"
+}
+
+// ===== PaymentGateway =====
+
+// ChargeResult is a scripted answer to a Charge call
+type ChargeResult struct {
+	Charge *Charge
+	Err    error
+}
+
+// GatewaySpy is a PaymentGateway answering Charge calls from a script, in
+// order, and recording every request it receives
+type GatewaySpy struct {
+	mu        sync.Mutex
+	script    []ChargeResult
+	refundErr error
+	charges   []ChargeRequest
+	refunds   []string
+}
+
+// NewGatewaySpy creates a spy giving the results in order. A Charge call
+// after the last result is an error: the code made a call nobody expected.
+func NewGatewaySpy(results ...ChargeResult) *GatewaySpy {
+	return &GatewaySpy{script: results}
+}
+
+// FailRefunds makes every Refund call return err
+func (g *GatewaySpy) FailRefunds(err error) *GatewaySpy {
+	g.refundErr = err
+	return g
+}
+
+func (g *GatewaySpy) Charge(req ChargeRequest) (*Charge, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.charges = append(g.charges, req)
+	if len(g.script) == 0 {
+		return nil, fmt.Errorf("GatewaySpy: unexpected Charge call %d for order %s", len(g.charges), req.OrderID)
+	}
+	next := g.script[0]
+	g.script = g.script[1:]
+	return next.Charge, next.Err
+}
+
+func (g *GatewaySpy) Refund(chargeID string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.refunds = append(g.refunds, chargeID)
+	return g.refundErr
+}
+
+// ChargeCalls returns the requests received by Charge, in order
+func (g *GatewaySpy) ChargeCalls() []ChargeRequest {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return slices.Clone(g.charges)
+}
+
+// RefundCalls returns the charge IDs received by Refund, in order
+func (g *GatewaySpy) RefundCalls() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return slices.Clone(g.refunds)
+}
+
+// ===== TodoRepository =====
+
+// FakeTodoRepository is a TodoRepository keeping the todos in a map. Tests
+// get a repository that behaves like the real one, without a database, and
+// can make any method fail with FailOn.
+type FakeTodoRepository struct {
+	mu     sync.Mutex
+	todos  map[uint]Todo
+	nextID uint
+	fail   map[string]error
+	calls  []Call
+}
+
+// NewFakeTodoRepository creates a repository holding the todos, given IDs
+// from 1
+func NewFakeTodoRepository(todos ...Todo) *FakeTodoRepository {
+	r := &FakeTodoRepository{todos: make(map[uint]Todo), fail: make(map[string]error)}
+	for _, todo := range todos {
+		r.nextID++
+		todo.ID = r.nextID
+		r.todos[todo.ID] = todo
+	}
+	return r
+}
+
+// FailOn makes every call to the method return err
+func (r *FakeTodoRepository) FailOn(method string, err error) *FakeTodoRepository {
+	r.fail[method] = err
+	return r
+}
+
+// record logs a call and returns the error set by FailOn for its method.
+// The caller holds the lock.
+func (r *FakeTodoRepository) record(method string, args ...any) error {
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+	return r.fail[method]
+}
+
+// Calls returns the calls received, in order
+func (r *FakeTodoRepository) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.calls)
+}
+
+func (r *FakeTodoRepository) FindAll(completed *bool) ([]Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var filter any
+	if completed != nil {
+		filter = *completed
+	}
+	if err := r.record("FindAll", filter); err != nil {
+		return nil, err
+	}
+	todos := []Todo{}
+	for _, todo := range r.todos {
+		if completed == nil || todo.Completed == *completed {
+			todos = append(todos, todo)
+		}
+	}
+	slices.SortFunc(todos, func(a, b Todo) int { return int(a.ID) - int(b.ID) })
+	return todos, nil
+}
+
+func (r *FakeTodoRepository) FindByID(id uint) (*Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record("FindByID", id); err != nil {
+		return nil, err
+	}
+	todo, ok := r.todos[id]
+	if !ok {
+		return nil, ErrTodoNotFound
+	}
+	// A copy, as a database returns: changing it doesn't change the stored todo
+	return &todo, nil
+}
+
+func (r *FakeTodoRepository) Create(todo *Todo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record("Create", todo.Title); err != nil {
+		return err
+	}
+	r.nextID++
+	todo.ID = r.nextID
+	r.todos[todo.ID] = *todo
+	return nil
+}
+
+func (r *FakeTodoRepository) Update(todo *Todo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record("Update", todo.ID); err != nil {
+		return err
+	}
+	if _, ok := r.todos[todo.ID]; !ok {
+		return ErrTodoNotFound
+	}
+	r.todos[todo.ID] = *todo
+	return nil
+}
+
+func (r *FakeTodoRepository) Delete(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record("Delete", id); err != nil {
+		return err
+	}
+	if _, ok := r.todos[id]; !ok {
+		return ErrTodoNotFound
+	}
+	delete(r.todos, id)
+	return nil
+}
+
+func (r *FakeTodoRepository) ToggleAll() ([]Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.record("ToggleAll"); err != nil {
+		return nil, err
+	}
+	// As in module 14: all completed if any is open, else all open
+	anyOpen := false
+	for _, todo := range r.todos {
+		anyOpen = anyOpen || !todo.Completed
+	}
+	todos := []Todo{}
+	for id, todo := range r.todos {
+		todo.Completed = anyOpen
+		r.todos[id] = todo
+		todos = append(todos, todo)
+	}
+	slices.SortFunc(todos, func(a, b Todo) int { return int(a.ID) - int(b.ID) })
+	return todos, nil
+}
+
+// ===== QueryExecutor =====
+
+// response is a scripted answer to the queries starting with a prefix
+type response struct {
+	prefix string
+	result any
+	err    error
+}
+
+// QuerySpy is a QueryExecutor answering queries by their first words and
+// recording every query it runs
+type QuerySpy struct {
+	mu        sync.Mutex
+	responses []response
+	calls     []Call
+}
+
+// On sets the answer to the queries starting with prefix. The first
+// matching prefix is used; an unknown query is an error.
+func (s *QuerySpy) On(prefix string, result any, err error) *QuerySpy {
+	s.responses = append(s.responses, response{prefix: prefix, result: result, err: err})
+	return s
+}
+
+func (s *QuerySpy) Execute(query string, args ...any) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, Call{Method: query, Args: args})
+	for _, r := range s.responses {
+		if strings.HasPrefix(query, r.prefix) {
+			return r.result, r.err
+		}
+	}
+	return nil, fmt.Errorf("QuerySpy: no response for %q", query)
+}
+
+// Queries returns the queries run, without their arguments, in order
+func (s *QuerySpy) Queries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queries := make([]string, len(s.calls))
+	for i, call := range s.calls {
+		queries[i] = call.Method
+	}
+	return queries
+}
+
+// Calls returns the queries run with their arguments, in order
+func (s *QuerySpy) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.calls)
+}
+
+// Compile-time checks that the doubles implement the interfaces
+var (
+	_ PaymentGateway = (*GatewaySpy)(nil)
+	_ TodoRepository = (*FakeTodoRepository)(nil)
+	_ QueryExecutor  = (*QuerySpy)(nil)
+	_ QueryExecutor  = QueryExecutorFunc(nil)
+)
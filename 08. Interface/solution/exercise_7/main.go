@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+func main() {
+	fmt.Println("=== Checkout with a scripted gateway ===")
+	// The gateway times out twice, then accepts the charge
+	gateway := NewGatewaySpy(
+		ChargeResult{Err: ErrUnavailable},
+		ChargeResult{Err: ErrUnavailable},
+		ChargeResult{Charge: &Charge{ID: "ch_1", OrderID: "A1001", Amount: 4999}},
+	)
+	var waited time.Duration
+	checkout := &Checkout{Gateway: gateway, MaxAttempts: 3, Sleep: func(d time.Duration) { waited += d }}
+	charge, err := checkout.Pay("A1001", 4999)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Printf("Paid with %s, after waiting %v without sleeping\n", charge.ID, waited)
+	for i, req := range gateway.ChargeCalls() {
+		fmt.Printf("  call %d: %+v\n", i+1, req)
+	}
+	if err := checkout.Cancel(charge); err != nil {
+		fmt.Println("Error:", err)
+	}
+	fmt.Println("  refunds:", gateway.RefundCalls())
+
+	declined := NewGatewaySpy(ChargeResult{Err: fmt.Errorf("card expired: %w", ErrDeclined)})
+	_, err = (&Checkout{Gateway: declined, MaxAttempts: 3}).Pay("A1002", 1500)
+	fmt.Printf("Declined: %v (%d call, not retried)\n", err, len(declined.ChargeCalls()))
+
+	fmt.Println("\n=== Todos with a fake repository ===")
+	repo := NewFakeTodoRepository(
+		Todo{Title: "Write the tests"},
+		Todo{Title: "Review the PR", Completed: true},
+		Todo{Title: "Deploy"},
+	)
+	if _, err := Complete(repo, 1); err != nil {
+		fmt.Println("Error:", err)
+	}
+	deleted, err := ClearCompleted(repo)
+	fmt.Printf("Cleared %d todos, error %v\n", deleted, err)
+	_, err = Complete(repo, 42)
+	fmt.Println("Missing todo:", err, "| not found:", errors.Is(err, ErrTodoNotFound))
+	fmt.Println("Calls received:")
+	for _, call := range repo.Calls() {
+		fmt.Println(" ", call)
+	}
+
+	broken := NewFakeTodoRepository(Todo{Title: "Done", Completed: true}).FailOn("Delete", errors.New("database is locked"))
+	deleted, err = ClearCompleted(broken)
+	fmt.Printf("With failing deletes: %d deleted, %v\n", deleted, err)
+
+	fmt.Println("\n=== Queries with a spy and a stub ===")
+	db := new(QuerySpy).
+		On("BEGIN", nil, nil).
+		On("UPDATE accounts SET balance = balance +", nil, errors.New("account B2 is frozen")).
+		On("UPDATE", 1, nil).
+		On("ROLLBACK", nil, nil)
+	err = Transfer(db, "A1", "B2", 2500)
+	fmt.Println("Transfer:", err)
+	for _, call := range db.Calls() {
+		fmt.Printf("  %-54s %v\n", call.Method, call.Args)
+	}
+
+	// A closure is enough to stub a one-method interface
+	stub := QueryExecutorFunc(func(query string, args ...any) (any, error) {
+		return 7, nil
+	})
+	count, err := CountOrders(stub, "C001")
+	fmt.Printf("Orders of C001: %d, error %v\n", count, err)
+	wrongType := QueryExecutorFunc(func(string, ...any) (any, error) { return "7", nil })
+	_, err = CountOrders(wrongType, "C001")
+	fmt.Println("Wrong result type:", err)
+}
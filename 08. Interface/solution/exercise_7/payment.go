@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Errors returned by payment gateways, as in module 9
+var (
+	// ErrDeclined is final: retrying the same charge gives the same answer
+	ErrDeclined = errors.New("payment declined")
+
+	// ErrUnavailable is temporary: the charge can be retried
+	ErrUnavailable = errors.New("payment provider unavailable")
+)
+
+// ChargeRequest asks a gateway to take a payment. Sending the same
+// IdempotencyKey again returns the first result instead of charging twice.
+type ChargeRequest struct {
+	OrderID        string
+	Amount         int64 // In cents
+	IdempotencyKey string
+}
+
+// Charge is a payment taken by a gateway
+type Charge struct {
+	ID      string
+	OrderID string
+	Amount  int64
+}
+
+// PaymentGateway is implemented by every payment provider, as in module 9
+type PaymentGateway interface {
+	Charge(req ChargeRequest) (*Charge, error)
+	Refund(chargeID string) error
+}
+
+// Checkout takes the payment of orders through a gateway
+type Checkout struct {
+	Gateway     PaymentGateway
+	MaxAttempts int
+
+	// Sleep waits between attempts. It is time.Sleep when nil; tests replace
+	// it so they don't wait.
+	Sleep func(time.Duration)
+}
+
+// Pay charges an order, retrying with a growing delay while the gateway is
+// unavailable. Every attempt uses the same idempotency key, so a retry after
+// a lost response can't charge twice.
+func (c *Checkout) Pay(orderID string, amount int64) (*Charge, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("order %s: invalid amount %d", orderID, amount)
+	}
+	sleep := c.Sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+
+	req := ChargeRequest{OrderID: orderID, Amount: amount, IdempotencyKey: "pay-" + orderID}
+	var err error
+	for attempt := 1; attempt <= c.MaxAttempts; attempt++ {
+		var charge *Charge
+		charge, err = c.Gateway.Charge(req)
+		if err == nil {
+			return charge, nil
+		}
+		if !errors.Is(err, ErrUnavailable) {
+			return nil, fmt.Errorf("order %s: %w", orderID, err)
+		}
+		if attempt < c.MaxAttempts {
+			sleep(time.Duration(attempt) * 100 * time.Millisecond)
+		}
+	}
+	return nil, fmt.Errorf("order %s: giving up after %d attempts: %w", orderID, c.MaxAttempts, err)
+}
+
+// Cancel gives the money of a charge back
+func (c *Checkout) Cancel(charge *Charge) error {
+	if err := c.Gateway.Refund(charge.ID); err != nil {
+		return fmt.Errorf("cancel order %s: %w", charge.OrderID, err)
+	}
+	return nil
+}
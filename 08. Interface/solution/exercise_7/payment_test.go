@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// noSleep records the delays instead of waiting
+func noSleep(delays *[]time.Duration) func(time.Duration) {
+	return func(d time.Duration) { *delays = append(*delays, d) }
+}
+
+func TestPayRetriesWithTheSameKey(t *testing.T) {
+	gateway := NewGatewaySpy(
+		ChargeResult{Err: ErrUnavailable},
+		ChargeResult{Charge: &Charge{ID: "ch_1", OrderID: "A1", Amount: 500}},
+	)
+	var delays []time.Duration
+	checkout := &Checkout{Gateway: gateway, MaxAttempts: 3, Sleep: noSleep(&delays)}
+
+	charge, err := checkout.Pay("A1", 500)
+	if err != nil {
+		t.Fatalf("Pay() error = %v", err)
+	}
+	if charge.ID != "ch_1" {
+		t.Errorf("charge ID = %q, want ch_1", charge.ID)
+	}
+
+	calls := gateway.ChargeCalls()
+	want := ChargeRequest{OrderID: "A1", Amount: 500, IdempotencyKey: "pay-A1"}
+	if len(calls) != 2 || calls[0] != want || calls[1] != want {
+		t.Errorf("Charge calls = %+v, want twice %+v", calls, want)
+	}
+	if !slices.Equal(delays, []time.Duration{100 * time.Millisecond}) {
+		t.Errorf("delays = %v, want one of 100ms", delays)
+	}
+}
+
+func TestPayDoesNotRetryDeclines(t *testing.T) {
+	gateway := NewGatewaySpy(ChargeResult{Err: ErrDeclined})
+	checkout := &Checkout{Gateway: gateway, MaxAttempts: 3, Sleep: func(time.Duration) {
+		t.Error("a declined charge must not wait for a retry")
+	}}
+
+	_, err := checkout.Pay("A2", 500)
+	if !errors.Is(err, ErrDeclined) {
+		t.Errorf("Pay() error = %v, want ErrDeclined", err)
+	}
+	if n := len(gateway.ChargeCalls()); n != 1 {
+		t.Errorf("Charge called %d times, want 1", n)
+	}
+}
+
+func TestPayGivesUp(t *testing.T) {
+	gateway := NewGatewaySpy(
+		ChargeResult{Err: ErrUnavailable},
+		ChargeResult{Err: ErrUnavailable},
+		ChargeResult{Err: ErrUnavailable},
+	)
+	var delays []time.Duration
+	checkout := &Checkout{Gateway: gateway, MaxAttempts: 3, Sleep: noSleep(&delays)}
+
+	_, err := checkout.Pay("A3", 500)
+	if !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Pay() error = %v, want ErrUnavailable", err)
+	}
+	if n := len(gateway.ChargeCalls()); n != 3 {
+		t.Errorf("Charge called %d times, want 3", n)
+	}
+	// No wait after the last attempt
+	if len(delays) != 2 {
+		t.Errorf("delays = %v, want 2", delays)
+	}
+}
+
+func TestPayRejectsInvalidAmounts(t *testing.T) {
+	// An empty script: any call to the gateway fails the test
+	gateway := NewGatewaySpy()
+	checkout := &Checkout{Gateway: gateway, MaxAttempts: 3}
+
+	if _, err := checkout.Pay("A4", 0); err == nil {
+		t.Error("Pay() with a zero amount succeeded")
+	}
+	if calls := gateway.ChargeCalls(); len(calls) != 0 {
+		t.Errorf("gateway called with %+v", calls)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	refundErr := errors.New("charge not found")
+	gateway := NewGatewaySpy().FailRefunds(refundErr)
+	checkout := &Checkout{Gateway: gateway}
+
+	err := checkout.Cancel(&Charge{ID: "ch_9", OrderID: "A9"})
+	if !errors.Is(err, refundErr) {
+		t.Errorf("Cancel() error = %v, want %v", err, refundErr)
+	}
+	if got := gateway.RefundCalls(); !slices.Equal(got, []string{"ch_9"}) {
+		t.Errorf("Refund calls = %v, want [ch_9]", got)
+	}
+}
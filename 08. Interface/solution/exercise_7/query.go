@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// QueryExecutor runs database queries, as in module 7
+type QueryExecutor interface {
+	Execute(query string, args ...any) (any, error)
+}
+
+// QueryExecutorFunc lets an ordinary function be used as a QueryExecutor,
+// as http.HandlerFunc does for HTTP handlers. A one-method interface can
+// always be stubbed this way, with a closure and no new type.
+type QueryExecutorFunc func(query string, args ...any) (any, error)
+
+func (f QueryExecutorFunc) Execute(query string, args ...any) (any, error) {
+	return f(query, args...)
+}
+
+// CountOrders returns the number of orders of a customer
+func CountOrders(db QueryExecutor, customerID string) (int, error) {
+	result, err := db.Execute("SELECT COUNT(*) FROM orders WHERE customer_id = ?", customerID)
+	if err != nil {
+		return 0, fmt.Errorf("count orders of %s: %w", customerID, err)
+	}
+	count, ok := result.(int)
+	if !ok {
+		return 0, fmt.Errorf("count orders of %s: unexpected result of type %T", customerID, result)
+	}
+	return count, nil
+}
+
+// Transfer moves an amount between two accounts in a transaction, rolled
+// back if one of the updates fails
+func Transfer(db QueryExecutor, from, to string, amount int64) error {
+	if _, err := db.Execute("BEGIN"); err != nil {
+		return fmt.Errorf("transfer: %w", err)
+	}
+	updates := []struct {
+		query string
+		args  []any
+	}{
+		{"UPDATE accounts SET balance = balance - ? WHERE id = ?", []any{amount, from}},
+		{"UPDATE accounts SET balance = balance + ? WHERE id = ?", []any{amount, to}},
+	}
+	for _, update := range updates {
+		if _, err := db.Execute(update.query, update.args...); err != nil {
+			if _, rollbackErr := db.Execute("ROLLBACK"); rollbackErr != nil {
+				err = errors.Join(err, fmt.Errorf("rollback: %w", rollbackErr))
+			}
+			return fmt.Errorf("transfer from %s to %s: %w", from, to, err)
+		}
+	}
+	if _, err := db.Execute("COMMIT"); err != nil {
+		return fmt.Errorf("transfer: %w", err)
+	}
+	return nil
+}
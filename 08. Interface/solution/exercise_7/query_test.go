@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestCountOrders(t *testing.T) {
+	var gotArgs []any
+	db := QueryExecutorFunc(func(query string, args ...any) (any, error) {
+		gotArgs = args
+		return 4, nil
+	})
+	count, err := CountOrders(db, "C001")
+	if err != nil || count != 4 {
+		t.Errorf("CountOrders() = %d, %v, want 4, nil", count, err)
+	}
+	if !slices.Equal(gotArgs, []any{"C001"}) {
+		t.Errorf("args = %v, want [C001]", gotArgs)
+	}
+}
+
+func TestCountOrdersErrors(t *testing.T) {
+	errDown := errors.New("connection refused")
+	tests := []struct {
+		name   string
+		result any
+		err    error
+	}{
+		{"query fails", nil, errDown},
+		{"unexpected type", "4", nil},
+		{"no result", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := QueryExecutorFunc(func(string, ...any) (any, error) { return tt.result, tt.err })
+			if _, err := CountOrders(db, "C001"); err == nil {
+				t.Error("CountOrders() succeeded")
+			} else if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestTransferCommits(t *testing.T) {
+	db := new(QuerySpy).On("BEGIN", nil, nil).On("UPDATE", 1, nil).On("COMMIT", nil, nil)
+
+	if err := Transfer(db, "A1", "B2", 2500); err != nil {
+		t.Fatalf("Transfer() error = %v", err)
+	}
+	want := []string{
+		"BEGIN",
+		"UPDATE accounts SET balance = balance - ? WHERE id = ?",
+		"UPDATE accounts SET balance = balance + ? WHERE id = ?",
+		"COMMIT",
+	}
+	if got := db.Queries(); !slices.Equal(got, want) {
+		t.Errorf("queries = %q, want %q", got, want)
+	}
+	if calls := db.Calls(); !slices.Equal(calls[1].Args, []any{int64(2500), "A1"}) {
+		t.Errorf("debit args = %v, want [2500 A1]", calls[1].Args)
+	}
+}
+
+func TestTransferRollsBack(t *testing.T) {
+	errFrozen := errors.New("account frozen")
+	errRollback := errors.New("connection lost")
+	db := new(QuerySpy).
+		On("BEGIN", nil, nil).
+		On("UPDATE accounts SET balance = balance -", nil, errFrozen).
+		On("ROLLBACK", nil, errRollback)
+
+	err := Transfer(db, "A1", "B2", 2500)
+	// Both errors are reported
+	if !errors.Is(err, errFrozen) || !errors.Is(err, errRollback) {
+		t.Errorf("Transfer() error = %v, want both %v and %v", err, errFrozen, errRollback)
+	}
+	want := []string{"BEGIN", "UPDATE accounts SET balance = balance - ? WHERE id = ?", "ROLLBACK"}
+	if got := db.Queries(); !slices.Equal(got, want) {
+		t.Errorf("queries = %q, want %q", got, want)
+	}
+}
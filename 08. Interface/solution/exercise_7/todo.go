@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTodoNotFound is returned when no todo has the requested ID
+var ErrTodoNotFound = errors.New("todo not found")
+
+// Todo represents a todo item
+type Todo struct {
+	ID        uint
+	Title     string
+	Completed bool
+}
+
+// TodoRepository persists todos, as in module 14 where GORM implements it
+type TodoRepository interface {
+	FindAll(completed *bool) ([]Todo, error)
+	FindByID(id uint) (*Todo, error)
+	Create(todo *Todo) error
+	Update(todo *Todo) error
+	Delete(id uint) error
+	ToggleAll() ([]Todo, error)
+}
+
+// todoUpdater is the part of TodoRepository that Complete uses. Declared
+// next to its only user, it tells the reader what Complete can do with the
+// repository, and a test double for it needs two methods instead of six.
+type todoUpdater interface {
+	FindByID(id uint) (*Todo, error)
+	Update(todo *Todo) error
+}
+
+// Complete marks a todo as completed. A todo already completed is not
+// saved again.
+func Complete(repo todoUpdater, id uint) (*Todo, error) {
+	todo, err := repo.FindByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("complete todo %d: %w", id, err)
+	}
+	if todo.Completed {
+		return todo, nil
+	}
+	todo.Completed = true
+	if err := repo.Update(todo); err != nil {
+		return nil, fmt.Errorf("complete todo %d: %w", id, err)
+	}
+	return todo, nil
+}
+
+// ClearCompleted deletes the completed todos and returns how many were
+// deleted. It stops at the first todo that can't be deleted.
+func ClearCompleted(repo TodoRepository) (int, error) {
+	completed := true
+	todos, err := repo.FindAll(&completed)
+	if err != nil {
+		return 0, fmt.Errorf("clear completed todos: %w", err)
+	}
+	deleted := 0
+	for _, todo := range todos {
+		if err := repo.Delete(todo.ID); err != nil {
+			return deleted, fmt.Errorf("clear completed todos: todo %d: %w", todo.ID, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// todoUpdaterStub implements the two methods Complete needs with functions,
+// so each test writes only the behaviour it cares about
+type todoUpdaterStub struct {
+	findByID func(id uint) (*Todo, error)
+	update   func(todo *Todo) error
+}
+
+func (s todoUpdaterStub) FindByID(id uint) (*Todo, error) { return s.findByID(id) }
+func (s todoUpdaterStub) Update(todo *Todo) error         { return s.update(todo) }
+
+func TestCompleteSavesTheTodo(t *testing.T) {
+	var saved *Todo
+	stub := todoUpdaterStub{
+		findByID: func(id uint) (*Todo, error) { return &Todo{ID: id, Title: "Write tests"}, nil },
+		update:   func(todo *Todo) error { saved = todo; return nil },
+	}
+
+	todo, err := Complete(stub, 3)
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if !todo.Completed || saved == nil || !saved.Completed || saved.ID != 3 {
+		t.Errorf("saved %+v, want todo 3 completed", saved)
+	}
+}
+
+func TestCompleteSkipsCompletedTodos(t *testing.T) {
+	stub := todoUpdaterStub{
+		findByID: func(id uint) (*Todo, error) { return &Todo{ID: id, Completed: true}, nil },
+		update: func(*Todo) error {
+			t.Error("a completed todo was saved again")
+			return nil
+		},
+	}
+	if _, err := Complete(stub, 1); err != nil {
+		t.Errorf("Complete() error = %v", err)
+	}
+}
+
+func TestCompleteErrors(t *testing.T) {
+	repo := NewFakeTodoRepository(Todo{Title: "Deploy"})
+	if _, err := Complete(repo, 7); !errors.Is(err, ErrTodoNotFound) {
+		t.Errorf("Complete() of a missing todo error = %v, want ErrTodoNotFound", err)
+	}
+
+	errLocked := errors.New("database is locked")
+	repo.FailOn("Update", errLocked)
+	if _, err := Complete(repo, 1); !errors.Is(err, errLocked) {
+		t.Errorf("Complete() error = %v, want %v", err, errLocked)
+	}
+	// The failed update didn't change the stored todo
+	if todo, _ := repo.FindByID(1); todo.Completed {
+		t.Error("todo completed although Update failed")
+	}
+}
+
+func TestClearCompleted(t *testing.T) {
+	repo := NewFakeTodoRepository(
+		Todo{Title: "Write tests", Completed: true},
+		Todo{Title: "Review"},
+		Todo{Title: "Deploy", Completed: true},
+	)
+
+	deleted, err := ClearCompleted(repo)
+	if err != nil || deleted != 2 {
+		t.Fatalf("ClearCompleted() = %d, %v, want 2, nil", deleted, err)
+	}
+	left, _ := repo.FindAll(nil)
+	if len(left) != 1 || left[0].Title != "Review" {
+		t.Errorf("todos left = %+v, want only Review", left)
+	}
+
+	// The fake records the calls, so the test can check what was asked of
+	// the repository, not only the result
+	want := []string{"FindAll(true)", "Delete(1)", "Delete(3)", "FindAll(<nil>)"}
+	calls := repo.Calls()
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+	for i, call := range calls {
+		if call.String() != want[i] {
+			t.Errorf("call %d = %s, want %s", i, call, want[i])
+		}
+	}
+}
+
+func TestClearCompletedStopsAtTheFirstError(t *testing.T) {
+	errLocked := errors.New("database is locked")
+	repo := NewFakeTodoRepository(Todo{Completed: true}, Todo{Completed: true}).FailOn("Delete", errLocked)
+
+	deleted, err := ClearCompleted(repo)
+	if deleted != 0 || !errors.Is(err, errLocked) {
+		t.Errorf("ClearCompleted() = %d, %v, want 0 and %v", deleted, err, errLocked)
+	}
+	if n := len(repo.Calls()); n != 2 {
+		t.Errorf("%d calls, want FindAll and one Delete", n)
+	}
+}
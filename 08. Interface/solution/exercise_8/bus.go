@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// EventBus is the bus of exercise 1, sending envelopes instead of values.
+// Every event goes through its encoded form, as it would through a queue or
+// an event store, so handlers can't depend on anything the payload doesn't
+// carry.
+type EventBus struct {
+	registry *Registry
+	handlers map[string][]EventHandler
+	mu       sync.RWMutex
+
+	// Sent receives every envelope before it is delivered, such as a queue
+	// or a log. It may be nil.
+	Sent func(data []byte)
+}
+
+func NewEventBus(registry *Registry) *EventBus {
+	return &EventBus{registry: registry, handlers: make(map[string][]EventHandler)}
+}
+
+// Subscribe registers a handler for an event type, or "*" for all of them
+func (b *EventBus) Subscribe(eventType string, handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// SubscribeFunc is a convenience method for function-based handlers
+func (b *EventBus) SubscribeFunc(eventType string, handler func(ctx context.Context, event Event) error) {
+	b.Subscribe(eventType, EventHandlerFunc(handler))
+}
+
+// Publish seals an event in an envelope and delivers it. The envelope takes
+// the trace ID of the context, or starts a new trace.
+func (b *EventBus) Publish(ctx context.Context, event Event) error {
+	traceID := TraceID(ctx)
+	if traceID == "" {
+		traceID = newID(16)
+	}
+	env, err := b.registry.Seal(event, traceID)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	if b.Sent != nil {
+		b.Sent(data)
+	}
+	return b.Deliver(ctx, data)
+}
+
+// Deliver decodes an envelope, brings its payload to the latest version and
+// dispatches it. Envelopes read from a queue or replayed from a store enter
+// the bus here, whatever version wrote them.
+func (b *EventBus) Deliver(ctx context.Context, data []byte) error {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return fmt.Errorf("decode envelope: %w", err)
+	}
+	msg, err := b.registry.Open(env)
+	if err != nil {
+		return fmt.Errorf("event %s: %w", env.ID, err)
+	}
+
+	b.mu.RLock()
+	handlers := append(append([]EventHandler{}, b.handlers[msg.Type()]...), b.handlers["*"]...)
+	b.mu.RUnlock()
+
+	// Handlers run without the lock, so they can publish events themselves
+	ctx = WithTraceID(ctx, msg.TraceID)
+	var errs []error
+	for _, handler := range handlers {
+		if err := handler.Handle(ctx, msg); err != nil {
+			errs = append(errs, fmt.Errorf("event %s: %w", msg.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
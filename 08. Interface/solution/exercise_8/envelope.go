@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnknownType is returned for an event type without a codec
+	ErrUnknownType = errors.New("unknown event type")
+
+	// ErrUnsupportedVersion is returned for a payload newer than the latest
+	// version this program knows: its producer was deployed before it
+	ErrUnsupportedVersion = errors.New("unsupported event version")
+)
+
+// Envelope is an event as it is stored or sent: the metadata every event
+// has, and a payload whose shape depends on the type and the version. The
+// envelope itself never changes shape, so any consumer can read it, log it
+// or route it without knowing the payload.
+type Envelope struct {
+	ID         string          `json:"id"`
+	EventType  string          `json:"type"`
+	Version    int             `json:"version"`
+	TraceID    string          `json:"trace_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// Message is an event as handlers receive it: the envelope, with the payload
+// decoded into the latest version of its type
+type Message struct {
+	Envelope
+	// Upcasted is the version the payload was migrated from, or 0
+	Upcasted int
+	data     any
+}
+
+func (m Message) Type() string         { return m.EventType }
+func (m Message) Data() any            { return m.data }
+func (m Message) Timestamp() time.Time { return m.OccurredAt }
+
+// Codec converts the data of one event type to and from its payload, in the
+// latest version
+type Codec interface {
+	Encode(data any) (json.RawMessage, error)
+	Decode(payload json.RawMessage) (any, error)
+}
+
+// JSONCodec encodes a T as JSON. Decoding refuses unknown fields: after the
+// upcasters ran, a field the type doesn't have means one of them is wrong.
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Encode(data any) (json.RawMessage, error) {
+	v, ok := data.(T)
+	if !ok {
+		var want T
+		return nil, fmt.Errorf("data is %T, want %T", data, want)
+	}
+	return json.Marshal(v)
+}
+
+func (JSONCodec[T]) Decode(payload json.RawMessage) (any, error) {
+	var v T
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Upcaster migrates a payload from one version to the next. It works on the
+// decoded JSON, as the Go type of the old version no longer exists.
+type Upcaster interface {
+	Upcast(payload map[string]any) error
+}
+
+// UpcasterFunc makes a function an Upcaster
+type UpcasterFunc func(payload map[string]any) error
+
+func (f UpcasterFunc) Upcast(payload map[string]any) error {
+	return f(payload)
+}
+
+type schema struct {
+	version   int
+	codec     Codec
+	upcasters map[int]Upcaster // By the version they migrate from
+}
+
+// Registry knows the latest version of each event type, its codec, and the
+// upcasters bringing older payloads to it
+type Registry struct {
+	mu      sync.RWMutex
+	schemas map[string]*schema
+}
+
+func NewRegistry() *Registry {
+	return &Registry{schemas: make(map[string]*schema)}
+}
+
+// Register sets the codec of the latest version of an event type
+func (r *Registry) Register(eventType string, version int, codec Codec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.schemas[eventType] = &schema{version: version, codec: codec, upcasters: make(map[int]Upcaster)}
+}
+
+// RegisterUpcaster adds the migration of an event type from version from to
+// version from+1
+func (r *Registry) RegisterUpcaster(eventType string, from int, upcaster Upcaster) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.schemas[eventType]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownType, eventType)
+	}
+	if from < 1 || from >= s.version {
+		return fmt.Errorf("%s: no version %d before the latest, %d", eventType, from, s.version)
+	}
+	s.upcasters[from] = upcaster
+	return nil
+}
+
+// Check verifies that every older version of every type can be migrated,
+// so a missing upcaster is found at startup instead of with the first old
+// event
+func (r *Registry) Check() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var errs []error
+	for eventType, s := range r.schemas {
+		for v := 1; v < s.version; v++ {
+			if _, ok := s.upcasters[v]; !ok {
+				errs = append(errs, fmt.Errorf("%s: no upcaster from version %d", eventType, v))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Seal wraps an event in an envelope, with the latest version of its type
+func (r *Registry) Seal(event Event, traceID string) (Envelope, error) {
+	r.mu.RLock()
+	s, ok := r.schemas[event.Type()]
+	r.mu.RUnlock()
+	if !ok {
+		return Envelope{}, fmt.Errorf("%w: %s", ErrUnknownType, event.Type())
+	}
+
+	payload, err := s.codec.Encode(event.Data())
+	if err != nil {
+		return Envelope{}, fmt.Errorf("encode %s: %w", event.Type(), err)
+	}
+	return Envelope{
+		ID:         newID(8),
+		EventType:  event.Type(),
+		Version:    s.version,
+		TraceID:    traceID,
+		OccurredAt: event.Timestamp().UTC(),
+		Payload:    payload,
+	}, nil
+}
+
+// Open decodes the payload of an envelope, migrating it to the latest
+// version first
+func (r *Registry) Open(env Envelope) (Message, error) {
+	r.mu.RLock()
+	s, ok := r.schemas[env.EventType]
+	r.mu.RUnlock()
+	if !ok {
+		return Message{}, fmt.Errorf("%w: %s", ErrUnknownType, env.EventType)
+	}
+	if env.Version > s.version || env.Version < 1 {
+		return Message{}, fmt.Errorf("%w: %s version %d, latest is %d", ErrUnsupportedVersion, env.EventType, env.Version, s.version)
+	}
+
+	msg := Message{Envelope: env}
+	payload := env.Payload
+	if env.Version < s.version {
+		var err error
+		if payload, err = s.upcast(env.Payload, env.Version); err != nil {
+			return Message{}, fmt.Errorf("upcast %s: %w", env.EventType, err)
+		}
+		msg.Upcasted = env.Version
+		msg.Version = s.version
+		msg.Payload = payload
+	}
+
+	data, err := s.codec.Decode(payload)
+	if err != nil {
+		return Message{}, fmt.Errorf("decode %s version %d: %w", env.EventType, s.version, err)
+	}
+	msg.data = data
+	return msg, nil
+}
+
+// upcast runs the upcasters from version to the latest, one version at a
+// time, so each upcaster only knows two versions
+func (s *schema) upcast(payload json.RawMessage, version int) (json.RawMessage, error) {
+	var fields map[string]any
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber() // Keeps large integers exact
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+	for v := version; v < s.version; v++ {
+		upcaster, ok := s.upcasters[v]
+		if !ok {
+			return nil, fmt.Errorf("no upcaster from version %d", v)
+		}
+		if err := upcaster.Upcast(fields); err != nil {
+			return nil, fmt.Errorf("from version %d: %w", v, err)
+		}
+	}
+	return json.Marshal(fields)
+}
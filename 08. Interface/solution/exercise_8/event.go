@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Event is the interface of exercise 1: handlers see the type, the data and
+// the time of an event, whatever its version was on the wire
+type Event interface {
+	Type() string
+	Data() any
+	Timestamp() time.Time
+}
+
+// BaseEvent is the event a publisher creates
+type BaseEvent struct {
+	EventType string
+	EventData any
+	EventTime time.Time
+}
+
+func (e BaseEvent) Type() string         { return e.EventType }
+func (e BaseEvent) Data() any            { return e.EventData }
+func (e BaseEvent) Timestamp() time.Time { return e.EventTime }
+
+// EventHandler processes events. The context carries the trace ID of the
+// event, so the events a handler publishes belong to the same trace.
+type EventHandler interface {
+	Handle(ctx context.Context, event Event) error
+}
+
+// EventHandlerFunc makes a function an EventHandler
+type EventHandlerFunc func(ctx context.Context, event Event) error
+
+func (f EventHandlerFunc) Handle(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+type traceKey struct{}
+
+// WithTraceID returns a context whose published events carry traceID
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceKey{}, traceID)
+}
+
+// TraceID returns the trace ID of the context, or "" when it has none
+func TraceID(ctx context.Context) string {
+	id, _ := ctx.Value(traceKey{}).(string)
+	return id
+}
+
+// newID returns n random bytes in hex
+func newID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
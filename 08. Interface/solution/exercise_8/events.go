@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// The latest versions of the events. The older ones exist only as JSON, in
+// the upcasters and in the events already stored.
+
+// UserRegistered, version 3.
+//
+//	v1 {"name": "John Doe", "email": "John@Example.com"}
+//	v2 the name split in first_name and last_name
+//	v3 a locale, "en-US" for the users registered before it existed
+type UserRegistered struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	Locale    string `json:"locale"`
+}
+
+// PaymentReceived, version 2.
+//
+//	v1 {"order_id": "A1001", "amount": 125.5}, in dollars as a float
+//	v2 the amount in cents, and its currency
+type PaymentReceived struct {
+	OrderID     string `json:"order_id"`
+	AmountCents int64  `json:"amount_cents"`
+	Currency    string `json:"currency"`
+}
+
+// WelcomeEmailQueued is published by a handler of user.registered
+type WelcomeEmailQueued struct {
+	Email  string `json:"email"`
+	Locale string `json:"locale"`
+}
+
+// NewEventRegistry registers the event types with their upcasters
+func NewEventRegistry() (*Registry, error) {
+	r := NewRegistry()
+	r.Register("user.registered", 3, JSONCodec[UserRegistered]{})
+	r.Register("payment.received", 2, JSONCodec[PaymentReceived]{})
+	r.Register("email.welcome.queued", 1, JSONCodec[WelcomeEmailQueued]{})
+
+	err := r.RegisterUpcaster("user.registered", 1, UpcasterFunc(splitName))
+	if err == nil {
+		err = r.RegisterUpcaster("user.registered", 2, UpcasterFunc(func(p map[string]any) error {
+			p["locale"] = "en-US"
+			return nil
+		}))
+	}
+	if err == nil {
+		err = r.RegisterUpcaster("payment.received", 1, UpcasterFunc(amountToCents))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, r.Check()
+}
+
+// splitName migrates user.registered from version 1 to 2
+func splitName(p map[string]any) error {
+	name, ok := p["name"].(string)
+	if !ok {
+		return fmt.Errorf("name is %T, want a string", p["name"])
+	}
+	first, last, _ := strings.Cut(strings.TrimSpace(name), " ")
+	delete(p, "name")
+	p["first_name"] = first
+	p["last_name"] = strings.TrimSpace(last)
+	return nil
+}
+
+// amountToCents migrates payment.received from version 1 to 2. Version 1
+// only had dollar payments.
+func amountToCents(p map[string]any) error {
+	amount, ok := p["amount"].(json.Number)
+	if !ok {
+		return fmt.Errorf("amount is %T, want a number", p["amount"])
+	}
+	dollars, err := amount.Float64()
+	if err != nil {
+		return err
+	}
+	delete(p, "amount")
+	// 0.1 + 0.2 is 0.30000000000000004 as a float: round, don't truncate
+	p["amount_cents"] = int64(math.Round(dollars * 100))
+	p["currency"] = "USD"
+	return nil
+}
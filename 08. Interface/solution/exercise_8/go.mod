@@ -0,0 +1,3 @@
+module golang-training/module-08/exercise-8
+
+go 1.25
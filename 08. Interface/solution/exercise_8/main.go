@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// stored are envelopes written by older versions of the program, still in a
+// queue or an event store
+var stored = []string{
+	`{"id":"7a1c","type":"user.registered","version":1,"trace_id":"t-old-1","occurred_at":"2024-03-01T10:00:00Z","payload":{"name":"Ada Lovelace","email":"ada@example.com"}}`,
+	`{"id":"7a1d","type":"payment.received","version":1,"trace_id":"t-old-2","occurred_at":"2024-03-01T10:05:00Z","payload":{"order_id":"A0917","amount":19.99}}`,
+	`{"id":"9b20","type":"user.registered","version":2,"trace_id":"t-old-3","occurred_at":"2025-06-12T08:30:00Z","payload":{"first_name":"Grace","last_name":"Hopper","email":"grace@example.com"}}`,
+	// Written by a newer producer, deployed before this consumer
+	`{"id":"c4e1","type":"payment.received","version":3,"trace_id":"t-new-1","occurred_at":"2026-10-17T09:00:00Z","payload":{"order_id":"A1200","amount":{"cents":500,"currency":"EUR"}}}`,
+	// A payload an upcaster can't migrate
+	`{"id":"c4e2","type":"user.registered","version":1,"trace_id":"t-old-4","occurred_at":"2024-03-02T10:00:00Z","payload":{"name":null,"email":"nobody@example.com"}}`,
+}
+
+func main() {
+	registry, err := NewEventRegistry()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	bus := NewEventBus(registry)
+	bus.Sent = func(data []byte) { fmt.Printf("  sent: %s\n", data) }
+
+	// Handlers only know the latest version of each event
+	bus.SubscribeFunc("user.registered", func(ctx context.Context, event Event) error {
+		user := event.Data().(UserRegistered)
+		fmt.Printf("  welcome %s %s <%s> (%s)\n", user.FirstName, user.LastName, user.Email, user.Locale)
+		// Published with the context of the event: same trace
+		return bus.Publish(ctx, BaseEvent{
+			EventType: "email.welcome.queued",
+			EventData: WelcomeEmailQueued{Email: user.Email, Locale: user.Locale},
+			EventTime: time.Now(),
+		})
+	})
+	bus.SubscribeFunc("payment.received", func(ctx context.Context, event Event) error {
+		payment := event.Data().(PaymentReceived)
+		fmt.Printf("  order %s paid: %d.%02d %s\n", payment.OrderID, payment.AmountCents/100, payment.AmountCents%100, payment.Currency)
+		return nil
+	})
+	bus.SubscribeFunc("*", func(ctx context.Context, event Event) error {
+		msg := event.(Message)
+		from := ""
+		if msg.Upcasted > 0 {
+			from = fmt.Sprintf(", upcast from v%d", msg.Upcasted)
+		}
+		fmt.Printf("  [LOG] %s %s v%d%s, trace %s\n", msg.ID, msg.Type(), msg.Version, from, TraceID(ctx))
+		return nil
+	})
+
+	fmt.Println("=== Publishing the latest versions ===")
+	ctx := WithTraceID(context.Background(), "trace-signup-42")
+	err = bus.Publish(ctx, BaseEvent{
+		EventType: "user.registered",
+		EventData: UserRegistered{FirstName: "John", LastName: "Doe", Email: "john@example.com", Locale: "fr-FR"},
+		EventTime: time.Now(),
+	})
+	if err != nil {
+		fmt.Println("Error:", err)
+	}
+	err = bus.Publish(context.Background(), BaseEvent{
+		EventType: "payment.received",
+		EventData: PaymentReceived{OrderID: "A1001", AmountCents: 12550, Currency: "USD"},
+		EventTime: time.Now(),
+	})
+	if err != nil {
+		fmt.Println("Error:", err)
+	}
+
+	fmt.Println("\n=== Publishing mistakes ===")
+	err = bus.Publish(context.Background(), BaseEvent{EventType: "user.deleted", EventData: "123", EventTime: time.Now()})
+	fmt.Println("  unregistered type:", err, errors.Is(err, ErrUnknownType))
+	err = bus.Publish(context.Background(), BaseEvent{EventType: "payment.received", EventData: 125.50, EventTime: time.Now()})
+	fmt.Println("  data of an old version:", err)
+
+	fmt.Println("\n=== Replaying stored envelopes ===")
+	bus.Sent = nil
+	for _, data := range stored {
+		if err := bus.Deliver(context.Background(), []byte(data)); err != nil {
+			fmt.Println("  error:", err)
+		}
+	}
+}
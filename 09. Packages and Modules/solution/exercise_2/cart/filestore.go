@@ -0,0 +1,138 @@
+package cart
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileStore is a CartStore saving each cart as a JSON file of a directory.
+type FileStore struct {
+	Dir string
+	TTL time.Duration
+	Now func() time.Time
+
+	mu sync.Mutex
+}
+
+// NewFileStore creates the directory of the store if needed.
+func NewFileStore(dir string, ttl time.Duration) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating cart directory: %w", err)
+	}
+	return &FileStore{Dir: dir, TTL: ttl, Now: time.Now}, nil
+}
+
+// path returns the file of a cart. IDs are used as file names, so they can't
+// contain a path separator.
+func (s *FileStore) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return "", fmt.Errorf("invalid cart id %q", id)
+	}
+	return filepath.Join(s.Dir, id+".json"), nil
+}
+
+// Save writes the cart to a temporary file renamed over the previous one, so
+// a crash never leaves a half-written cart.
+func (s *FileStore) Save(c *Cart) error {
+	path, err := s.path(c.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("saving cart %s: %w", c.ID, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("saving cart %s: %w", c.ID, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("saving cart %s: %w", c.ID, err)
+	}
+	return nil
+}
+
+// Load reads a cart from its file.
+func (s *FileStore) Load(id string) (*Cart, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := readCart(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s: %w", id, ErrCartNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading cart %s: %w", id, err)
+	}
+	if expired(c.UpdatedAt, s.TTL, s.Now()) {
+		os.Remove(path)
+		return nil, fmt.Errorf("%s: %w", id, ErrCartExpired)
+	}
+	return c, nil
+}
+
+// Delete removes the file of a cart. Deleting a missing cart is not an error.
+func (s *FileStore) Delete(id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("deleting cart %s: %w", id, err)
+	}
+	return nil
+}
+
+// PurgeExpired deletes the files of the stale carts.
+func (s *FileStore) PurgeExpired() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	paths, err := filepath.Glob(filepath.Join(s.Dir, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, path := range paths {
+		c, err := readCart(path)
+		if err != nil {
+			return purged, fmt.Errorf("loading %s: %w", filepath.Base(path), err)
+		}
+		if expired(c.UpdatedAt, s.TTL, s.Now()) {
+			if err := os.Remove(path); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// readCart reads and decodes the file of a cart.
+func readCart(path string) (*Cart, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cart{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,67 @@
+package cart
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"golang-training/module-09/exercise-2/models"
+)
+
+// cartJSON is the saved form of a cart. The items are a list sorted by
+// product, so a saved cart doesn't change when the map order does.
+type cartJSON struct {
+	ID         string     `json:"id"`
+	CustomerID string     `json:"customer_id,omitempty"`
+	Items      []itemJSON `json:"items"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type itemJSON struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c *Cart) MarshalJSON() ([]byte, error) {
+	saved := cartJSON{
+		ID:         c.ID,
+		CustomerID: c.CustomerID,
+		Items:      make([]itemJSON, 0, len(c.Items)),
+		UpdatedAt:  c.UpdatedAt,
+	}
+	for _, item := range c.Items {
+		saved.Items = append(saved.Items, itemJSON{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	slices.SortFunc(saved.Items, func(a, b itemJSON) int {
+		return cmp.Compare(a.ProductID, b.ProductID)
+	})
+	return json.Marshal(saved)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Quantities of a product listed
+// twice are added up, as AddItem would.
+func (c *Cart) UnmarshalJSON(data []byte) error {
+	var saved cartJSON
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+	if saved.ID == "" {
+		return fmt.Errorf("cart without an id")
+	}
+
+	items := make(map[string]models.Item, len(saved.Items))
+	for _, item := range saved.Items {
+		if item.ProductID == "" || item.Quantity <= 0 {
+			return fmt.Errorf("cart %s: invalid item %+v", saved.ID, item)
+		}
+		merged := items[item.ProductID]
+		merged.ProductID = item.ProductID
+		merged.Quantity += item.Quantity
+		items[item.ProductID] = merged
+	}
+	*c = Cart{ID: saved.ID, CustomerID: saved.CustomerID, Items: items, UpdatedAt: saved.UpdatedAt}
+	return nil
+}
@@ -0,0 +1,105 @@
+package cart
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"golang-training/module-09/exercise-2/models"
+)
+
+// MergeStrategy decides the quantity of a product found in both carts.
+type MergeStrategy int
+
+const (
+	MergeSum MergeStrategy = iota // Add the quantities up
+	MergeMax                      // Keep the larger quantity, for guests who re-added what they already had
+)
+
+// Adjustment describes what a merge did to a product of the guest cart.
+type Adjustment struct {
+	ProductID string
+	Account   int // Quantity in the customer's cart before the merge
+	Guest     int
+	Result    int
+	Reason    string
+}
+
+// Merge moves the items of a guest cart into c. Quantities of a product
+// found in both carts are reconciled by the strategy, then capped to what is
+// available when available is not nil. The guest cart is left unchanged.
+func (c *Cart) Merge(guest *Cart, strategy MergeStrategy, available func(productID string) int) []Adjustment {
+	items := guest.GetItems()
+	slices.SortFunc(items, func(a, b models.Item) int { return cmp.Compare(a.ProductID, b.ProductID) })
+
+	var adjustments []Adjustment
+	for _, item := range items {
+		adj := Adjustment{
+			ProductID: item.ProductID,
+			Account:   c.Items[item.ProductID].Quantity,
+			Guest:     item.Quantity,
+		}
+		switch {
+		case adj.Account == 0:
+			adj.Result, adj.Reason = adj.Guest, "added from the guest cart"
+		case strategy == MergeMax:
+			adj.Result, adj.Reason = max(adj.Account, adj.Guest), "kept the larger quantity"
+		default:
+			adj.Result, adj.Reason = adj.Account+adj.Guest, "quantities added up"
+		}
+
+		if available != nil {
+			if stock := available(item.ProductID); adj.Result > stock {
+				adj.Result = max(stock, 0)
+				adj.Reason = fmt.Sprintf("limited to the %d in stock", adj.Result)
+			}
+		}
+
+		if adj.Result == 0 {
+			delete(c.Items, item.ProductID)
+		} else {
+			item.Quantity = adj.Result
+			c.Items[item.ProductID] = item
+		}
+		adjustments = append(adjustments, adj)
+	}
+	c.UpdatedAt = time.Now()
+	return adjustments
+}
+
+// MergeOnLogin merges the saved guest cart into the saved cart of the
+// customer who just logged in, saves the result and deletes the guest cart.
+// A missing or expired cart is treated as empty.
+func MergeOnLogin(store CartStore, guestID, customerID string, strategy MergeStrategy, available func(string) int) (*Cart, []Adjustment, error) {
+	account, err := store.Load(CustomerCartID(customerID))
+	if errors.Is(err, ErrCartNotFound) || errors.Is(err, ErrCartExpired) {
+		account, err = NewCustomerCart(customerID), nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	// Logging in again with the same session: merging the account cart into
+	// itself would double its quantities, then delete it
+	if guestID == CustomerCartID(customerID) {
+		return account, nil, nil
+	}
+
+	guest, err := store.Load(guestID)
+	if errors.Is(err, ErrCartNotFound) || errors.Is(err, ErrCartExpired) {
+		return account, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	adjustments := account.Merge(guest, strategy, available)
+	if err := store.Save(account); err != nil {
+		return nil, nil, err
+	}
+	if err := store.Delete(guestID); err != nil {
+		return nil, nil, err
+	}
+	return account, adjustments, nil
+}
@@ -1,21 +1,47 @@
 package cart
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
 	"golang-training/module-09/exercise-2/models"
 )
 
 // Cart represents a user's shopping cart.
+// A guest cart has no CustomerID; it is merged into the customer's cart when
+// the guest logs in.
 type Cart struct {
-	Items map[string]models.Item // Using map for easy item lookup/update by ProductID
+	ID         string
+	CustomerID string
+	Items      map[string]models.Item // Using map for easy item lookup/update by ProductID
+	UpdatedAt  time.Time              // Last change, used to expire stale carts
 }
 
-// NewCart creates and returns a new empty Cart.
+// NewCart creates and returns a new empty guest Cart.
 func NewCart() *Cart {
 	return &Cart{
-		Items: make(map[string]models.Item),
+		ID:        uuid.New().String(),
+		Items:     make(map[string]models.Item),
+		UpdatedAt: time.Now(),
 	}
 }
 
+// NewCustomerCart creates the cart of a logged-in customer. A customer has a
+// single cart, whose ID is derived from the customer ID.
+func NewCustomerCart(customerID string) *Cart {
+	c := NewCart()
+	c.ID = CustomerCartID(customerID)
+	c.CustomerID = customerID
+	return c
+}
+
+// CustomerCartID returns the ID of the cart of a customer.
+func CustomerCartID(customerID string) string {
+	return "customer-" + customerID
+}
+
 // AddItem adds a product to the cart or updates its quantity if already present.
 func (c *Cart) AddItem(productID string, quantity int) {
 	if quantity <= 0 {
@@ -32,11 +58,13 @@ func (c *Cart) AddItem(productID string, quantity int) {
 		}
 	}
 	c.Items[productID] = item
+	c.UpdatedAt = time.Now()
 }
 
 // RemoveItem removes a product from the cart.
 func (c *Cart) RemoveItem(productID string) {
 	delete(c.Items, productID)
+	c.UpdatedAt = time.Now()
 }
 
 // GetItems returns a slice of items currently in the cart.
@@ -49,13 +77,19 @@ func (c *Cart) GetItems() []models.Item {
 }
 
 // CalculateTotal calculates the total price of all items in the cart.
-// It requires a map of product prices to look up individual item prices.
-func (c *Cart) CalculateTotal(productPrices map[string]float64) float64 {
-	total := 0.0
+// It requires a map of product prices to look up individual item prices,
+// all in the same currency.
+func (c *Cart) CalculateTotal(productPrices map[string]models.Money) (models.Money, error) {
+	var total models.Money
 	for _, item := range c.Items {
-		if price, ok := productPrices[item.ProductID]; ok {
-			total += price * float64(item.Quantity)
+		price, ok := productPrices[item.ProductID]
+		if !ok {
+			continue
+		}
+		var err error
+		if total, err = total.Add(price.Mul(int64(item.Quantity))); err != nil {
+			return models.Money{}, fmt.Errorf("product %s: %w", item.ProductID, err)
 		}
 	}
-	return total
+	return total, nil
 }
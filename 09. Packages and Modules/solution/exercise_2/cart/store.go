@@ -0,0 +1,101 @@
+package cart
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Errors returned by the cart stores.
+var (
+	ErrCartNotFound = errors.New("cart not found")
+	ErrCartExpired  = errors.New("cart expired")
+)
+
+// CartStore saves carts between visits.
+// Carts not updated for longer than the TTL of the store are stale: Load
+// returns ErrCartExpired for them and PurgeExpired deletes them.
+type CartStore interface {
+	Save(c *Cart) error
+	Load(id string) (*Cart, error)
+	Delete(id string) error
+	PurgeExpired() (int, error)
+}
+
+// expired reports whether a cart last updated at updatedAt is stale at now.
+// A zero TTL never expires carts.
+func expired(updatedAt time.Time, ttl time.Duration, now time.Time) bool {
+	return ttl > 0 && now.Sub(updatedAt) > ttl
+}
+
+// MemoryStore is a CartStore keeping the carts in memory.
+type MemoryStore struct {
+	TTL time.Duration
+	Now func() time.Time // Clock, replaced in demos to age the carts
+
+	mu    sync.Mutex
+	carts map[string][]byte // Saved as JSON, so callers can't change a stored cart
+}
+
+// NewMemoryStore creates an empty store expiring carts after ttl.
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{TTL: ttl, Now: time.Now, carts: make(map[string][]byte)}
+}
+
+// Save stores a copy of the cart.
+func (s *MemoryStore) Save(c *Cart) error {
+	data, err := c.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("saving cart %s: %w", c.ID, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.carts[c.ID] = data
+	return nil
+}
+
+// Load returns a copy of a stored cart.
+func (s *MemoryStore) Load(id string) (*Cart, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.carts[id]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", id, ErrCartNotFound)
+	}
+	c := &Cart{}
+	if err := c.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("loading cart %s: %w", id, err)
+	}
+	if expired(c.UpdatedAt, s.TTL, s.Now()) {
+		delete(s.carts, id)
+		return nil, fmt.Errorf("%s: %w", id, ErrCartExpired)
+	}
+	return c, nil
+}
+
+// Delete removes a cart. Deleting a missing cart is not an error.
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.carts, id)
+	return nil
+}
+
+// PurgeExpired deletes the stale carts and returns how many were deleted.
+func (s *MemoryStore) PurgeExpired() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	purged := 0
+	for id, data := range s.carts {
+		c := &Cart{}
+		if err := c.UnmarshalJSON(data); err != nil {
+			return purged, fmt.Errorf("loading cart %s: %w", id, err)
+		}
+		if expired(c.UpdatedAt, s.TTL, s.Now()) {
+			delete(s.carts, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,128 @@
+package customers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"golang-training/module-09/exercise-2/models"
+)
+
+// welcomeBonus is the number of loyalty points given for the first paid order.
+const welcomeBonus = 50
+
+// OrderHistory summarizes the orders of a customer.
+type OrderHistory struct {
+	Customer      *models.Customer
+	Orders        []*models.Order // Newest first
+	Paid          int             // Orders paid and not refunded
+	TotalSpent    models.Money    // Total amount of the paid orders
+	TotalRefunded models.Money
+	LoyaltyPoints int
+}
+
+// RecordOrder adds an order to the history of its customer. Orders are kept
+// by pointer, so the history follows their later status changes.
+func (r *Registry) RecordOrder(order *models.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.customers[order.CustomerID]; !ok {
+		return fmt.Errorf("order %s: %s: %w", order.OrderID, order.CustomerID, ErrCustomerNotFound)
+	}
+	for _, recorded := range r.orders[order.CustomerID] {
+		if recorded.OrderID == order.OrderID {
+			return nil
+		}
+	}
+	r.orders[order.CustomerID] = append(r.orders[order.CustomerID], order)
+	return nil
+}
+
+// Order returns an order of a customer. An order placed by someone else is
+// reported as such, so a customer can't look at the orders of others.
+func (r *Registry) Order(customerID, orderID string) (*models.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for id, orders := range r.orders {
+		for _, order := range orders {
+			if order.OrderID != orderID {
+				continue
+			}
+			if id != customerID {
+				return nil, fmt.Errorf("order %s: %w", orderID, ErrWrongCustomer)
+			}
+			return order, nil
+		}
+	}
+	return nil, fmt.Errorf("order %s not found", orderID)
+}
+
+// History returns the orders of a customer with their totals and the loyalty
+// points earned.
+func (r *Registry) History(customerID string) (*OrderHistory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	customer, ok := r.customers[customerID]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", customerID, ErrCustomerNotFound)
+	}
+
+	history := &OrderHistory{
+		Customer: customer,
+		Orders:   append([]*models.Order(nil), r.orders[customerID]...),
+	}
+	if len(history.Orders) > 0 {
+		// Totals are in the currency of the orders, even when none was paid
+		zero := models.NewMoney(0, history.Orders[0].TotalAmount.Currency)
+		history.TotalSpent, history.TotalRefunded = zero, zero
+	}
+	for _, order := range history.Orders {
+		var err error
+		switch {
+		case isPaid(order.Status):
+			history.Paid++
+			history.TotalSpent, err = history.TotalSpent.Add(order.TotalAmount)
+			history.LoyaltyPoints += LoyaltyPoints(order)
+		case order.Status == models.StatusRefunded:
+			history.TotalRefunded, err = history.TotalRefunded.Add(order.TotalAmount)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("order %s: %w", order.OrderID, err)
+		}
+	}
+	if history.Paid > 0 {
+		history.LoyaltyPoints += welcomeBonus
+	}
+
+	sort.SliceStable(history.Orders, func(i, j int) bool {
+		return placedAt(history.Orders[i]).After(placedAt(history.Orders[j]))
+	})
+	return history, nil
+}
+
+// LoyaltyPoints returns the points earned by an order: one per whole dollar
+// of items, shipping excluded. Orders that were not paid, or were refunded,
+// earn nothing.
+func LoyaltyPoints(order *models.Order) int {
+	if !isPaid(order.Status) {
+		return 0
+	}
+	return int(order.Subtotal.Major())
+}
+
+// isPaid reports whether the money of an order with this status was kept.
+func isPaid(status models.OrderStatus) bool {
+	switch status {
+	case models.StatusPaid, models.StatusShipped, models.StatusDelivered:
+		return true
+	}
+	return false
+}
+
+// placedAt returns when an order was created, the first entry of its history.
+func placedAt(order *models.Order) time.Time {
+	if len(order.History) == 0 {
+		return time.Time{}
+	}
+	return order.History[0].At
+}
@@ -0,0 +1,106 @@
+package customers
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+
+	"golang-training/module-09/exercise-2/models"
+)
+
+// Errors returned by the registry.
+var (
+	ErrInvalidEmail     = errors.New("invalid email address")
+	ErrEmailTaken       = errors.New("email address already registered")
+	ErrCustomerNotFound = errors.New("customer not found")
+	ErrWrongCustomer    = errors.New("order belongs to another customer")
+)
+
+// Registry stores the customers and the orders they placed.
+type Registry struct {
+	mu        sync.RWMutex
+	customers map[string]*models.Customer // By ID
+	byEmail   map[string]string           // Customer ID by normalized email
+	orders    map[string][]*models.Order  // By customer ID, in placement order
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		customers: make(map[string]*models.Customer),
+		byEmail:   make(map[string]string),
+		orders:    make(map[string][]*models.Order),
+	}
+}
+
+// Register creates a customer. The email address is the login of the
+// customer, so it must be valid and not registered yet.
+func (r *Registry) Register(name, email string) (*models.Customer, error) {
+	parsed, err := mail.ParseAddress(email)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidEmail, email)
+	}
+	email = strings.ToLower(parsed.Address)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, taken := r.byEmail[email]; taken {
+		return nil, fmt.Errorf("%s: %w", email, ErrEmailTaken)
+	}
+
+	customer := &models.Customer{
+		ID:           fmt.Sprintf("C%03d", len(r.customers)+1),
+		Name:         strings.TrimSpace(name),
+		Email:        email,
+		Default:      -1,
+		RegisteredAt: time.Now(),
+	}
+	r.customers[customer.ID] = customer
+	r.byEmail[email] = customer.ID
+	return customer, nil
+}
+
+// AddAddress adds an address to a customer. The first address becomes the
+// default one, as does any address added with makeDefault.
+func (r *Registry) AddAddress(customerID string, addr models.Address, makeDefault bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	customer, ok := r.customers[customerID]
+	if !ok {
+		return fmt.Errorf("%s: %w", customerID, ErrCustomerNotFound)
+	}
+	if addr.Name == "" {
+		addr.Name = customer.Name
+	}
+	customer.Addresses = append(customer.Addresses, addr)
+	if makeDefault || customer.Default < 0 {
+		customer.Default = len(customer.Addresses) - 1
+	}
+	return nil
+}
+
+// Get returns a customer by ID.
+func (r *Registry) Get(customerID string) (*models.Customer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	customer, ok := r.customers[customerID]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", customerID, ErrCustomerNotFound)
+	}
+	return customer, nil
+}
+
+// FindByEmail returns the customer registered with an email address, whatever
+// its case.
+func (r *Registry) FindByEmail(email string) (*models.Customer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	id, ok := r.byEmail[strings.ToLower(strings.TrimSpace(email))]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", email, ErrCustomerNotFound)
+	}
+	return r.customers[id], nil
+}
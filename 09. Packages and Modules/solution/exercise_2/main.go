@@ -1,27 +1,52 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"os"
+	"time"
 
 	"golang-training/module-09/exercise-2/cart"
+	"golang-training/module-09/exercise-2/customers"
 	"golang-training/module-09/exercise-2/inventory"
 	"golang-training/module-09/exercise-2/models"
+	"golang-training/module-09/exercise-2/notifications"
+	"golang-training/module-09/exercise-2/notifications/mocksmtp"
 	processor "golang-training/module-09/exercise-2/order"
+	"golang-training/module-09/exercise-2/payments"
+	"golang-training/module-09/exercise-2/shipping"
 )
 
+// usd parses a constant dollar amount.
+func usd(amount string) models.Money {
+	return models.MustParseMoney(amount, "USD")
+}
+
+// printCartTotal prints the total price of a cart.
+func printCartTotal(name string, c *cart.Cart, productPrices map[string]models.Money) {
+	total, err := c.CalculateTotal(productPrices)
+	if err != nil {
+		fmt.Printf("%s Total: %v\n", name, err)
+		return
+	}
+	fmt.Printf("%s Total: %s\n", name, total)
+}
+
 func main() {
 	fmt.Println("--- Starting E-commerce Simulation ---")
 
 	// 1. Initialize Product Data (Simulated Database/Catalog)
 	products := map[string]models.Product{
-		"P001": {ID: "P001", Name: "Laptop Pro", Price: 1200.00},
-		"P002": {ID: "P002", Name: "Mechanical Keyboard", Price: 150.00},
-		"P003": {ID: "P003", Name: "Wireless Mouse", Price: 50.00},
-		"P004": {ID: "P004", Name: "USB-C Hub", Price: 75.00},
+		"P001": {ID: "P001", Name: "Laptop Pro", Price: usd("1200.00"), Weight: 2.1},
+		"P002": {ID: "P002", Name: "Mechanical Keyboard", Price: usd("150.00"), Weight: 1.2},
+		"P003": {ID: "P003", Name: "Wireless Mouse", Price: usd("50.00"), Weight: 0.1},
+		"P004": {ID: "P004", Name: "USB-C Hub", Price: usd("75.00"), Weight: 0.2},
 	}
 
 	// Extract product prices for easy lookup by other packages
-	productPrices := make(map[string]float64)
+	productPrices := make(map[string]models.Money)
 	for _, p := range products {
 		productPrices[p.ID] = p.Price
 	}
@@ -35,6 +60,71 @@ func main() {
 	}
 	inventory.InitializeProducts(initialStock)
 
+	// Shipping rates: each order picks its strategy
+	standard := shipping.WeightTiers{Tiers: []shipping.Tier{
+		{UpTo: 1, Price: usd("5.99")},
+		{UpTo: 5, Price: usd("12.99")},
+		{UpTo: 20, Price: usd("29.99")},
+	}}
+	freeAbove := shipping.FreeAbove{Threshold: usd("1000"), Otherwise: standard}
+	express := shipping.FlatRate{Amount: usd("39.99")}
+
+	home := models.Address{Name: "Alice", Street: "1 Main St", City: "Springfield", PostalCode: "62701", Country: "US"}
+	anchorage := models.Address{Name: "Bob", Street: "5 Harbor Rd", City: "Anchorage", PostalCode: "99501", Country: "US"}
+	toronto := models.Address{Name: "Chloe", Street: "20 King St", City: "Toronto", PostalCode: "M5H 2N2", Country: "CA"}
+	nowhere := models.Address{Name: "Dan", City: "Atlantis", PostalCode: "00000", Country: "XX"}
+
+	// Customer accounts
+	registry := customers.NewRegistry()
+	alice, err := registry.Register("Alice", "alice@example.com")
+	if err != nil {
+		fmt.Printf("Error registering Alice: %v\n", err)
+		return
+	}
+	registry.AddAddress(alice.ID, home, true)
+	chloe, err := registry.Register("Chloe", "Chloe@Example.com")
+	if err != nil {
+		fmt.Printf("Error registering Chloe: %v\n", err)
+		return
+	}
+	registry.AddAddress(chloe.ID, toronto, true)
+	if _, err := registry.Register("Alice again", "ALICE@example.com"); errors.Is(err, customers.ErrEmailTaken) {
+		fmt.Printf("Registering twice (expected): %v\n", err)
+	}
+	if _, err := registry.Register("Eve", "not-an-email"); errors.Is(err, customers.ErrInvalidEmail) {
+		fmt.Printf("Registering with a bad email (expected): %v\n", err)
+	}
+	if found, err := registry.FindByEmail("chloe@example.com"); err == nil {
+		fmt.Printf("Found %s (%s) by email\n", found.Name, found.ID)
+	}
+
+	// Publish every status change, as an event bus or an email sender would
+	models.OnTransition(func(order *models.Order, change models.StatusChange) {
+		fmt.Printf("[event] %s: order %s %s -> %s (%s)\n",
+			change.EventName(), order.OrderID[:8], change.From, change.To, change.Reason)
+	})
+
+	// Email the customers when their orders are paid or shipped. The emails
+	// go to a local mock SMTP server, through a batcher sending at most 5
+	// per second in the background.
+	smtpServer, err := mocksmtp.Start()
+	if err != nil {
+		fmt.Printf("Error starting the SMTP server: %v\n", err)
+		return
+	}
+	defer smtpServer.Close()
+	templates, err := notifications.LoadTemplates()
+	if err != nil {
+		fmt.Printf("Error loading the email templates: %v\n", err)
+		return
+	}
+	emails := notifications.NewBatcher(&notifications.SMTPSender{Addr: smtpServer.Addr()}, 5, time.Second, 100)
+	emails.OnError = func(msg notifications.Message, err error) {
+		log.Printf("email %q to %v failed: %v", msg.Subject, msg.To, err)
+	}
+	notifier := notifications.NewOrderNotifier(emails, templates, "Go Shop <orders@shop.example>", registry.Get, products)
+	models.OnTransition(notifier.Hook())
+
 	fmt.Println("\n--- First Customer Order ---")
 	// 3. Simulate a User's Shopping Journey (Cart 1)
 	customerCart1 := cart.NewCart()
@@ -44,7 +134,7 @@ func main() {
 	customerCart1.AddItem("P001", 1) // Add another Laptop (should update quantity)
 
 	fmt.Printf("Cart 1 Items: %v\n", customerCart1.GetItems())
-	fmt.Printf("Cart 1 Total: $%.2f\n", customerCart1.CalculateTotal(productPrices))
+	printCartTotal("Cart 1", customerCart1, productPrices)
 
 	fmt.Println("\n--- Processing Order 1 ---")
 	fmt.Println("Stock before Order 1:")
@@ -52,20 +142,33 @@ func main() {
 	fmt.Printf("P002 (Mechanical Keyboard) stock: %d\n", inventory.GetStock("P002"))
 	fmt.Printf("P003 (Wireless Mouse) stock: %d\n", inventory.GetStock("P003"))
 
-	order1, err := processor.ProcessOrder(customerCart1, productPrices)
+	// The gateway times out once after charging: the retry must not charge twice
+	flaky := payments.NewFlaky(1)
+	// Shipped to the default address of Alice
+	order1, err := processor.ProcessOrder(alice, customerCart1, products, processor.Delivery{Rate: freeAbove}, flaky)
 	if err != nil {
 		fmt.Printf("Error processing Order 1: %v\n", err)
 	} else {
+		registry.RecordOrder(order1)
 		fmt.Println("Order 1 Details:")
 		fmt.Printf("  Order ID: %s\n", order1.OrderID)
-		fmt.Printf("  Total Amount: $%.2f\n", order1.TotalAmount)
+		fmt.Printf("  Customer: %s\n", order1.CustomerID)
+		fmt.Printf("  Subtotal: %s\n", order1.Subtotal)
+		fmt.Printf("  Shipping: %s (%s, %s zone)\n", order1.Shipping.Cost, order1.Shipping.Method, order1.Shipping.Zone)
+		fmt.Printf("  Delivery: between %s and %s\n",
+			order1.Shipping.Earliest.Format("Mon Jan 2"), order1.Shipping.Latest.Format("Mon Jan 2"))
+		fmt.Printf("  Total Amount: %s\n", order1.TotalAmount)
 		fmt.Printf("  Status: %s\n", order1.Status)
+		fmt.Printf("  Payment: %s\n", order1.PaymentID)
 		fmt.Println("  Items:")
 		for _, item := range order1.Items {
 			fmt.Printf("    - Product ID: %s, Quantity: %d\n", item.ProductID, item.Quantity)
 		}
 	}
 
+	charges, attempts := flaky.Charges()
+	fmt.Printf("Gateway: %d charge for %d attempts\n", charges, attempts)
+
 	fmt.Println("\nStock after Order 1:")
 	fmt.Printf("P001 (Laptop Pro) stock: %d\n", inventory.GetStock("P001"))
 	fmt.Printf("P002 (Mechanical Keyboard) stock: %d\n", inventory.GetStock("P002"))
@@ -77,23 +180,236 @@ func main() {
 	customerCart2.AddItem("P004", 1) // 1 USB-C Hub
 
 	fmt.Printf("Cart 2 Items: %v\n", customerCart2.GetItems())
-	fmt.Printf("Cart 2 Total: $%.2f\n", customerCart2.CalculateTotal(productPrices))
+	printCartTotal("Cart 2", customerCart2, productPrices)
 
 	fmt.Println("\n--- Processing Order 2 ---")
 	fmt.Println("Stock before Order 2:")
 	fmt.Printf("P001 (Laptop Pro) stock: %d\n", inventory.GetStock("P001"))
 	fmt.Printf("P004 (USB-C Hub) stock: %d\n", inventory.GetStock("P004"))
 
-	order2, err := processor.ProcessOrder(customerCart2, productPrices)
+	order2, err := processor.ProcessOrder(alice, customerCart2, products, processor.Delivery{Address: home, Rate: express}, payments.NewAlwaysSucceed())
 	if err != nil {
 		fmt.Printf("Error processing Order 2 (expected): %v\n", err)
 	} else {
-		fmt.Printf("Order 2 processed unexpectedly: %+v\n", order2)
+		fmt.Printf("Order 2 processed unexpectedly: %v\n", order2)
 	}
 
 	fmt.Println("\nStock after attempted Order 2:")
 	fmt.Printf("P001 (Laptop Pro) stock: %d\n", inventory.GetStock("P001"))
 	fmt.Printf("P004 (USB-C Hub) stock: %d\n", inventory.GetStock("P004")) // Should remain unchanged for P004
 
+	fmt.Println("\n--- Third Customer Order (Declined Payment Scenario) ---")
+	customerCart3 := cart.NewCart()
+	customerCart3.AddItem("P004", 2) // 2 USB-C Hubs
+	printCartTotal("Cart 3", customerCart3, productPrices)
+	fmt.Printf("P004 (USB-C Hub) stock before: %d\n", inventory.GetStock("P004"))
+
+	// The card is declined above $100: the stock reserved for the order is released
+	order3, err := processor.ProcessOrder(chloe, customerCart3, products, processor.Delivery{Rate: standard}, payments.NewDeclining(usd("100")))
+	if order3 != nil {
+		registry.RecordOrder(order3) // Cancelled orders stay in the history
+	}
+	if errors.Is(err, payments.ErrDeclined) {
+		fmt.Printf("Error processing Order 3 (expected): %v\n", err)
+		fmt.Printf("Order 3: %v\n", order3)
+	}
+	fmt.Printf("P004 (USB-C Hub) stock after: %d\n", inventory.GetStock("P004"))
+
+	fmt.Println("\n--- Order Life Cycle ---")
+	if order1 != nil {
+		if err := processor.Ship(order1, "TRK-1001"); err != nil {
+			fmt.Printf("Error shipping Order 1: %v\n", err)
+		}
+		if err := processor.Deliver(order1); err != nil {
+			fmt.Printf("Error delivering Order 1: %v\n", err)
+		}
+		// Shipping twice is not a valid transition
+		if err := processor.Ship(order1, "TRK-1002"); err != nil {
+			fmt.Printf("Shipping Order 1 again (expected): %v\n", err)
+		}
+		if err := processor.Refund(order1, flaky, "returned by the customer"); err != nil {
+			fmt.Printf("Error refunding Order 1: %v\n", err)
+		}
+
+		fmt.Println("Order 1 History:")
+		for _, change := range order1.History {
+			fmt.Printf("  %s %-9s %s\n", change.At.Format("15:04:05.000"), change.To, change.Reason)
+		}
+	}
+
+	// A cancelled order is final: it can't be shipped nor refunded
+	if order3 != nil {
+		err := processor.Ship(order3, "TRK-1003")
+		var transitionErr *models.TransitionError
+		if errors.As(err, &transitionErr) {
+			fmt.Printf("Shipping Order 3 (expected): %s -> %s refused\n", transitionErr.From, transitionErr.To)
+		}
+		if err := processor.Refund(order3, flaky, "customer request"); errors.Is(err, models.ErrInvalidTransition) {
+			fmt.Printf("Refunding Order 3 (expected): %v\n", err)
+		}
+	}
+
+	fmt.Println("\n--- Saved Carts ---")
+	cartDir, err := os.MkdirTemp("", "carts")
+	if err != nil {
+		fmt.Printf("Error creating the cart directory: %v\n", err)
+		return
+	}
+	defer os.RemoveAll(cartDir)
+	store, err := cart.NewFileStore(cartDir, 7*24*time.Hour)
+	if err != nil {
+		fmt.Printf("Error creating the cart store: %v\n", err)
+		return
+	}
+
+	// Alice saved a cart last time she was logged in
+	accountCart := cart.NewCustomerCart(alice.ID)
+	accountCart.AddItem("P002", 1) // 1 Keyboard
+	store.Save(accountCart)
+
+	// Then she shops as a guest before logging in
+	guestCart := cart.NewCart()
+	guestCart.AddItem("P002", 1)  // The same keyboard, again
+	guestCart.AddItem("P003", 2)  // 2 Mouses
+	guestCart.AddItem("P004", 20) // More hubs than in stock
+	store.Save(guestCart)
+	if data, err := json.Marshal(guestCart); err == nil {
+		fmt.Printf("Guest cart: %s\n", data)
+	}
+
+	// A cart abandoned two weeks ago
+	staleCart := cart.NewCart()
+	staleCart.AddItem("P001", 1)
+	staleCart.UpdatedAt = time.Now().AddDate(0, 0, -14)
+	store.Save(staleCart)
+	if purged, err := store.PurgeExpired(); err == nil {
+		fmt.Printf("Purged %d stale cart\n", purged)
+	}
+
+	// Logging in merges the guest cart into the account cart
+	customerCart4, adjustments, err := cart.MergeOnLogin(store, guestCart.ID, alice.ID, cart.MergeMax, inventory.GetStock)
+	if err != nil {
+		fmt.Printf("Error merging the carts: %v\n", err)
+		return
+	}
+	for _, adj := range adjustments {
+		fmt.Printf("  %s: account %d, guest %d -> %d (%s)\n", adj.ProductID, adj.Account, adj.Guest, adj.Result, adj.Reason)
+	}
+	if _, err := store.Load(guestCart.ID); errors.Is(err, cart.ErrCartNotFound) {
+		fmt.Println("The guest cart was deleted after the merge")
+	}
+
+	// The memory store expires carts the same way
+	memory := cart.NewMemoryStore(time.Hour)
+	memory.Save(guestCart)
+	memory.Now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := memory.Load(guestCart.ID); errors.Is(err, cart.ErrCartExpired) {
+		fmt.Printf("Loading a cart two hours later (expected): %v\n", err)
+	}
+
+	fmt.Println("\n--- Fourth Customer Order ---")
+	order4, err := processor.ProcessOrder(alice, customerCart4, products, processor.Delivery{Address: home, Rate: express}, payments.NewAlwaysSucceed())
+	if err != nil {
+		fmt.Printf("Error processing Order 4: %v\n", err)
+	} else {
+		registry.RecordOrder(order4)
+		processor.Ship(order4, "TRK-1004")
+	}
+
+	fmt.Println("\n--- Customer Order History ---")
+	for _, customer := range []*models.Customer{alice, chloe} {
+		history, err := registry.History(customer.ID)
+		if err != nil {
+			fmt.Printf("Error reading the history of %s: %v\n", customer.ID, err)
+			continue
+		}
+		fmt.Printf("%s (%s, %s): %d orders, %d paid, spent %s, refunded %s, %d loyalty points\n",
+			customer.Name, customer.ID, customer.Email, len(history.Orders), history.Paid,
+			history.TotalSpent, history.TotalRefunded, history.LoyaltyPoints)
+		for _, order := range history.Orders {
+			fmt.Printf("  %s %-9s %9s %3d points\n",
+				order.OrderID[:8], order.Status, order.TotalAmount, customers.LoyaltyPoints(order))
+		}
+	}
+	if order1 != nil {
+		if _, err := registry.Order(chloe.ID, order1.OrderID); errors.Is(err, customers.ErrWrongCustomer) {
+			fmt.Printf("Chloe looking at Order 1 (expected): %v\n", err)
+		}
+	}
+
+	fmt.Println("\n--- Shipping Quotes for Cart 1 ---")
+	subtotal, _ := customerCart1.CalculateTotal(productPrices)
+	weight := 0.0
+	for _, item := range customerCart1.GetItems() {
+		weight += products[item.ProductID].Weight * float64(item.Quantity)
+	}
+	fmt.Printf("Subtotal %s, weight %.1f kg\n", subtotal, weight)
+	for _, addr := range []models.Address{home, anchorage, toronto, nowhere} {
+		for _, rate := range []shipping.Strategy{standard, freeAbove, express} {
+			quote, err := shipping.Quote(rate, addr, subtotal, weight, time.Now())
+			if err != nil {
+				fmt.Printf("  %-11s %-42s error: %v\n", addr.City, rate.Name(), err)
+				continue
+			}
+			fmt.Printf("  %-11s %-42s %7s (%s, by %s)\n",
+				addr.City, rate.Name(), quote.Cost, quote.Zone, quote.Latest.Format("Mon Jan 2"))
+		}
+	}
+	heavy := shipping.Shipment{Weight: 25, Zone: shipping.Domestic}
+	if _, err := standard.Cost(heavy); errors.Is(err, shipping.ErrTooHeavy) {
+		fmt.Printf("25 kg parcel (expected): %v\n", err)
+	}
+
+	fmt.Println("\n--- Money ---")
+	// Floats drift, cents don't
+	a, b := 0.1, 0.2
+	fmt.Printf("0.1 + 0.2 as float64: %v\n", a+b)
+	dime, fifth := usd("0.10"), usd("0.20")
+	sum, _ := dime.Add(fifth)
+	fmt.Printf("0.10 + 0.20 as Money: %s\n", sum)
+	if _, err := usd("10").Add(models.MustParseMoney("10", "EUR")); errors.Is(err, models.ErrCurrencyMismatch) {
+		fmt.Printf("Adding dollars and euros (expected): %v\n", err)
+	}
+	// A $100 gift card shared by three friends: no cent is lost
+	shares, _ := usd("100").Split(3)
+	fmt.Printf("$100 split in 3: %v\n", shares)
+	// The shipping of an order allocated to its items by price
+	allocated, _ := usd("29.99").Allocate(1200, 150, 50)
+	fmt.Printf("$29.99 allocated 1200:150:50: %v\n", allocated)
+	fmt.Printf("Formatting: %s, %s, %s\n", usd("1234567.8"), models.MustParseMoney("-500", "JPY"), models.NewMoney(1999, "EUR"))
+	if order4 != nil {
+		if data, err := json.Marshal(order4.TotalAmount); err == nil {
+			fmt.Printf("Order 4 total as JSON: %s\n", data)
+		}
+	}
+	if _, err := models.ParseMoney("19.999", "USD"); errors.Is(err, models.ErrInvalidAmount) {
+		fmt.Printf("Parsing 19.999 dollars (expected): %v\n", err)
+	}
+
+	fmt.Println("\n--- Idempotency Keys ---")
+	gateway := payments.NewAlwaysSucceed()
+	req := payments.ChargeRequest{OrderID: "manual-1", Amount: usd("50"), IdempotencyKey: "key-1"}
+	first, _ := gateway.Charge(req)
+	again, _ := gateway.Charge(req)
+	fmt.Printf("Same key twice: %s and %s\n", first.ID, again.ID)
+	req.Amount = usd("75")
+	if _, err := gateway.Charge(req); errors.Is(err, payments.ErrIdempotencyConflict) {
+		fmt.Printf("Same key, other amount: %v\n", err)
+	}
+
+	fmt.Println("\n--- Emails ---")
+	// Close waits for the queued emails
+	emails.Close()
+	sent, failed := emails.Stats()
+	fmt.Printf("%d sent, %d failed\n", sent, failed)
+	for _, received := range smtpServer.Messages() {
+		parsed, err := received.Parse()
+		if err != nil {
+			fmt.Printf("Error parsing an email: %v\n", err)
+			continue
+		}
+		fmt.Printf("  %s -> %v: %s\n", received.From, received.To, parsed.Header.Get("Subject"))
+	}
+
 	fmt.Println("\n--- End of Simulation ---")
 }
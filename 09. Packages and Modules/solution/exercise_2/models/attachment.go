@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Attachment is a file attached to an order, such as its invoice. The file
+// itself is stored elsewhere, on disk or in object storage; the order keeps
+// where it is and its checksum.
+type Attachment struct {
+	Name        string // Such as "INV-2026-0001.pdf", unique within the order
+	ContentType string
+	Size        int64
+	SHA256      string
+	Path        string
+	CreatedAt   time.Time
+}
+
+// Attach adds a file to the order, replacing the attachment of the same
+// name.
+func (o *Order) Attach(a Attachment) {
+	for i := range o.Attachments {
+		if o.Attachments[i].Name == a.Name {
+			o.Attachments[i] = a
+			return
+		}
+	}
+	o.Attachments = append(o.Attachments, a)
+}
+
+// Attachment returns the attachment of the order named name.
+func (o *Order) Attachment(name string) (Attachment, bool) {
+	for _, a := range o.Attachments {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Attachment{}, false
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Customer is a registered account. Customers are created by the customers
+// package, which keeps the email addresses unique.
+type Customer struct {
+	ID           string
+	Name         string
+	Email        string
+	Addresses    []Address
+	Default      int // Index of the default address, -1 when there is none
+	RegisteredAt time.Time
+}
+
+// DefaultAddress returns the address orders are shipped to unless another
+// one is chosen.
+func (c *Customer) DefaultAddress() (Address, bool) {
+	if c.Default < 0 || c.Default >= len(c.Addresses) {
+		return Address{}, false
+	}
+	return c.Addresses[c.Default], true
+}
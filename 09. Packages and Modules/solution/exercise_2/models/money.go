@@ -0,0 +1,312 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Errors returned by the Money operations.
+var (
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrInvalidAmount    = errors.New("invalid amount")
+)
+
+// currency describes how the amounts of a currency are written.
+type currency struct {
+	digits int // Digits of the minor unit: 2 for cents, 0 for yen
+	symbol string
+}
+
+var currencies = map[string]currency{
+	"USD": {digits: 2, symbol: "$"},
+	"EUR": {digits: 2, symbol: "€"},
+	"GBP": {digits: 2, symbol: "£"},
+	"CAD": {digits: 2, symbol: "CA$"},
+	"JPY": {digits: 0, symbol: "¥"},
+}
+
+// Money is an amount in the minor unit of its currency, such as cents.
+// Integers add up exactly where float64 prices drift: 0.1 + 0.2 is not 0.3
+// in floating point, but 10 + 20 cents is always 30 cents.
+//
+// The zero Money has no currency; it can be added to any amount, so totals
+// can start from it.
+//
+// In a database, Money takes two columns: the minor units in a BIGINT, which
+// sorts, sums and indexes as a number, and the currency code. With GORM, embed
+// it with a prefix per field, such as
+// `gorm:"embedded;embeddedPrefix:price_"` for price_amount and
+// price_currency.
+type Money struct {
+	Amount   int64  `gorm:"not null"` // In minor units
+	Currency string `gorm:"type:char(3);not null"`
+}
+
+// NewMoney creates an amount from minor units: NewMoney(1999, "USD") is $19.99.
+func NewMoney(minor int64, currencyCode string) Money {
+	return Money{Amount: minor, Currency: currencyCode}
+}
+
+// ParseMoney parses a decimal amount such as "19.99" or "-5".
+// It refuses more decimals than the currency has, rather than rounding.
+func ParseMoney(s, currencyCode string) (Money, error) {
+	cur, ok := currencies[currencyCode]
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, currencyCode)
+	}
+
+	text := strings.TrimSpace(s)
+	negative := strings.HasPrefix(text, "-")
+	text = strings.TrimPrefix(text, "-")
+	whole, fraction, _ := strings.Cut(text, ".")
+	if whole == "" || len(fraction) > cur.digits || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("%w: %q in %s", ErrInvalidAmount, s, currencyCode)
+	}
+
+	fraction += strings.Repeat("0", cur.digits-len(fraction))
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q: %v", ErrInvalidAmount, s, err)
+	}
+	if negative {
+		minor = -minor
+	}
+	return Money{Amount: minor, Currency: currencyCode}, nil
+}
+
+// MustParseMoney is like ParseMoney but panics on error. It is meant for
+// constant amounts, such as a price list.
+func MustParseMoney(s, currencyCode string) Money {
+	m, err := ParseMoney(s, currencyCode)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// isZeroDecimal reports whether s is a zero written in decimal, such as "0"
+// or "0.00".
+func isZeroDecimal(s string) bool {
+	whole, fraction, _ := strings.Cut(strings.TrimSpace(s), ".")
+	return whole != "" && strings.Trim(whole+fraction, "0") == ""
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// IsZero reports whether the amount is zero, whatever its currency.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsNegative reports whether the amount is below zero.
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// sameCurrency returns the currency of an operation on m and other. The
+// currency of the zero Money is taken from the other operand.
+func (m Money) sameCurrency(other Money) (string, error) {
+	switch {
+	case m.Currency == other.Currency:
+		return m.Currency, nil
+	case m.Currency == "" && m.Amount == 0:
+		return other.Currency, nil
+	case other.Currency == "" && other.Amount == 0:
+		return m.Currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+}
+
+// Add returns m + other. Both amounts must have the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	code, err := m.sameCurrency(other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: code}, nil
+}
+
+// Sub returns m - other. Both amounts must have the same currency.
+func (m Money) Sub(other Money) (Money, error) {
+	code, err := m.sameCurrency(other)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount - other.Amount, Currency: code}, nil
+}
+
+// Mul returns the amount multiplied by a quantity.
+func (m Money) Mul(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// Scale returns the amount multiplied by a rate, such as a tax or a
+// surcharge, rounded to the nearest minor unit, halves away from zero.
+func (m Money) Scale(rate float64) Money {
+	return Money{Amount: int64(math.Round(float64(m.Amount) * rate)), Currency: m.Currency}
+}
+
+// Compare returns -1, 0 or +1 as m is less than, equal to or greater than
+// other. Both amounts must have the same currency.
+func (m Money) Compare(other Money) (int, error) {
+	if _, err := m.sameCurrency(other); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < other.Amount:
+		return -1, nil
+	case m.Amount > other.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Major returns the whole units of the amount, such as the dollars of an
+// amount in cents, rounded toward zero.
+func (m Money) Major() int64 {
+	return m.Amount / pow10(currencies[m.Currency].digits)
+}
+
+// Allocate splits the amount in parts proportional to ratios, without losing
+// a minor unit: the remainder of the division is given one unit at a time to
+// the first parts. Allocating $100 with ratios 1, 1, 1 gives $33.34, $33.33
+// and $33.33.
+func (m Money) Allocate(ratios ...int) ([]Money, error) {
+	total := 0
+	for _, ratio := range ratios {
+		if ratio < 0 {
+			return nil, fmt.Errorf("allocate: negative ratio %d", ratio)
+		}
+		total += ratio
+	}
+	if total == 0 {
+		return nil, errors.New("allocate: the ratios add up to zero")
+	}
+
+	parts := make([]Money, len(ratios))
+	remainder := m.Amount
+	for i, ratio := range ratios {
+		parts[i] = Money{Amount: m.Amount * int64(ratio) / int64(total), Currency: m.Currency}
+		remainder -= parts[i].Amount
+	}
+
+	// Integer division truncates toward zero, so the remainder has the sign
+	// of the amount and is smaller than the number of parts
+	step := int64(1)
+	if remainder < 0 {
+		step = -1
+	}
+	for i := 0; remainder != 0; i = (i + 1) % len(parts) {
+		if ratios[i] == 0 {
+			continue
+		}
+		parts[i].Amount += step
+		remainder -= step
+	}
+	return parts, nil
+}
+
+// Split divides the amount in n parts as equal as possible.
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("split: invalid number of parts %d", n)
+	}
+	ratios := make([]int, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return m.Allocate(ratios...)
+}
+
+// Decimal returns the amount as a plain decimal number, such as "-1234.50".
+func (m Money) Decimal() string {
+	digits := currencies[m.Currency].digits
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	if digits == 0 {
+		return sign + strconv.FormatInt(amount, 10)
+	}
+	unit := pow10(digits)
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/unit, digits, amount%unit)
+}
+
+// String formats the amount for display, such as "$1,234.50" or "-¥500".
+// Currencies without a symbol are written "12.00 XYZ".
+func (m Money) String() string {
+	cur, ok := currencies[m.Currency]
+	if !ok {
+		return strings.TrimSpace(m.Decimal() + " " + m.Currency)
+	}
+
+	decimal := strings.TrimPrefix(m.Decimal(), "-")
+	whole, fraction, hasFraction := strings.Cut(decimal, ".")
+	var b strings.Builder
+	if m.Amount < 0 {
+		b.WriteString("-")
+	}
+	b.WriteString(cur.symbol)
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(",")
+		}
+		b.WriteRune(r)
+	}
+	if hasFraction {
+		b.WriteString("." + fraction)
+	}
+	return b.String()
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for range n {
+		p *= 10
+	}
+	return p
+}
+
+// moneyJSON is the JSON form of Money. The amount is a string, so clients
+// parsing numbers as floats don't round it.
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON implements json.Marshaler: {"amount":"19.99","currency":"USD"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. A zero amount without a
+// currency, as the zero Money is encoded, decodes to the zero Money.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Currency == "" && isZeroDecimal(v.Amount) {
+		*m = Money{}
+		return nil
+	}
+	parsed, err := ParseMoney(v.Amount, v.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestMoneyJSONRoundTrip(t *testing.T) {
+	for _, m := range []Money{
+		{},
+		NewMoney(1999, "USD"),
+		NewMoney(-450, "EUR"),
+		NewMoney(500, "JPY"),
+	} {
+		data, err := json.Marshal(m)
+		if err != nil {
+			t.Fatalf("Marshal(%v): %v", m, err)
+		}
+		var decoded Money
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", data, err)
+		}
+		if decoded != m {
+			t.Errorf("Unmarshal(%s) = %#v, want %#v", data, decoded, m)
+		}
+	}
+}
+
+func TestMoneyJSONRejectsAmountWithoutCurrency(t *testing.T) {
+	var m Money
+	err := json.Unmarshal([]byte(`{"amount":"12.00","currency":""}`), &m)
+	if !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("err = %v, want ErrUnknownCurrency", err)
+	}
+}
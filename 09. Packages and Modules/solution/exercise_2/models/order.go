@@ -1,8 +1,28 @@
 package models
 
+import "fmt"
+
+// Order is created by NewOrder. Its Status only changes through
+// TransitionTo, which keeps the History.
 type Order struct {
 	OrderID     string
+	CustomerID  string
 	Items       []Item
-	TotalAmount float64
-	Status      string // e.g., "Pending", "Completed", "Cancelled"
+	Subtotal    Money // Price of the items
+	Shipping    Shipping
+	TotalAmount Money // Subtotal plus shipping
+	Status      OrderStatus
+	PaymentID   string // ID of the charge, once paid
+	History     []StatusChange
+	Attachments []Attachment // Files such as the invoice
+}
+
+// String returns a short description of the order, such as
+// "order 1a2b3c4d (Paid, 3 items, $2,565.00)".
+func (o *Order) String() string {
+	id := o.OrderID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+	return fmt.Sprintf("order %s (%s, %d items, %s)", id, o.Status, len(o.Items), o.TotalAmount)
 }
@@ -2,9 +2,10 @@ package models
 
 // Product represents an item available for sale.
 type Product struct {
-	ID    string
-	Name  string
-	Price float64
+	ID     string
+	Name   string
+	Price  Money
+	Weight float64 // In kg, used for shipping
 }
 
 // Item represents a specific product with a quantity in a cart or order.
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Address is where an order is delivered.
+type Address struct {
+	Name       string
+	Street     string
+	City       string
+	PostalCode string
+	Country    string // ISO 3166 code, such as "US"
+}
+
+// Shipping describes how an order is delivered and what it costs.
+// It is computed by the shipping package when the order is placed.
+type Shipping struct {
+	Address  Address
+	Method   string // Name of the rate strategy
+	Zone     string
+	Cost     Money
+	Earliest time.Time // Estimated delivery window
+	Latest   time.Time
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OrderStatus is a state of the order life cycle.
+type OrderStatus string
+
+const (
+	StatusPending   OrderStatus = "Pending"
+	StatusPaid      OrderStatus = "Paid"
+	StatusShipped   OrderStatus = "Shipped"
+	StatusDelivered OrderStatus = "Delivered"
+	StatusCancelled OrderStatus = "Cancelled"
+	StatusRefunded  OrderStatus = "Refunded"
+)
+
+// transitions lists the statuses reachable from each status. Cancelled and
+// Refunded are final: no transition leaves them.
+var transitions = map[OrderStatus][]OrderStatus{
+	StatusPending:   {StatusPaid, StatusCancelled},
+	StatusPaid:      {StatusShipped, StatusRefunded},
+	StatusShipped:   {StatusDelivered},
+	StatusDelivered: {StatusRefunded},
+}
+
+// CanTransitionTo reports whether an order can go from s to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFinal reports whether no transition leaves the status.
+func (s OrderStatus) IsFinal() bool {
+	return len(transitions[s]) == 0
+}
+
+// ErrInvalidTransition is wrapped by every TransitionError.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// TransitionError is returned when a status change is not allowed.
+type TransitionError struct {
+	OrderID string
+	From    OrderStatus
+	To      OrderStatus
+}
+
+func (e *TransitionError) Error() string {
+	if e.From.IsFinal() {
+		return fmt.Sprintf("order %s: cannot go from %s to %s, %s is final", e.OrderID, e.From, e.To, e.From)
+	}
+	return fmt.Sprintf("order %s: cannot go from %s to %s, allowed: %v", e.OrderID, e.From, e.To, transitions[e.From])
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// StatusChange is an entry of the order history, also published as an event.
+type StatusChange struct {
+	OrderID string
+	From    OrderStatus // Empty for the creation of the order
+	To      OrderStatus
+	At      time.Time
+	Reason  string
+}
+
+// EventName returns the name of the event published for the change, such as
+// "order.paid".
+func (c StatusChange) EventName() string {
+	return "order." + strings.ToLower(string(c.To))
+}
+
+// TransitionHook is called after every status change.
+type TransitionHook func(order *Order, change StatusChange)
+
+// hooks are package-level, like the stock of the inventory package, so every
+// order publishes its changes to the same subscribers.
+var hooks []TransitionHook
+
+// OnTransition registers a hook called after every status change, for
+// example to publish events or send emails.
+func OnTransition(hook TransitionHook) {
+	hooks = append(hooks, hook)
+}
+
+// NewOrder creates a Pending order and records its creation in the history.
+// The total is the subtotal of the items plus the shipping cost, which must
+// be in the same currency.
+func NewOrder(orderID, customerID string, items []Item, subtotal Money, shipping Shipping) (*Order, error) {
+	total, err := subtotal.Add(shipping.Cost)
+	if err != nil {
+		return nil, fmt.Errorf("order %s: shipping cost: %w", orderID, err)
+	}
+	order := &Order{
+		OrderID:     orderID,
+		CustomerID:  customerID,
+		Items:       items,
+		Subtotal:    subtotal,
+		Shipping:    shipping,
+		TotalAmount: total,
+	}
+	order.record(StatusPending, "order created")
+	return order, nil
+}
+
+// TransitionTo changes the status of the order if the state machine allows
+// it, records the change and calls the hooks.
+func (o *Order) TransitionTo(next OrderStatus, reason string) error {
+	if !o.Status.CanTransitionTo(next) {
+		return &TransitionError{OrderID: o.OrderID, From: o.Status, To: next}
+	}
+	o.record(next, reason)
+	return nil
+}
+
+// record sets the status, appends it to the history and notifies the hooks.
+func (o *Order) record(next OrderStatus, reason string) {
+	change := StatusChange{
+		OrderID: o.OrderID,
+		From:    o.Status,
+		To:      next,
+		At:      time.Now(),
+		Reason:  reason,
+	}
+	o.Status = next
+	o.History = append(o.History, change)
+	for _, hook := range hooks {
+		hook(o, change)
+	}
+}
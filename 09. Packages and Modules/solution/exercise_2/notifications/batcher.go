@@ -0,0 +1,135 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrQueueFull is returned by Batcher.Send when the queue is full.
+	ErrQueueFull = errors.New("email queue full")
+	// ErrBatcherClosed is returned by Batcher.Send after Close.
+	ErrBatcherClosed = errors.New("email batcher closed")
+)
+
+// Batcher is an EmailSender that queues the messages and sends them in the
+// background, at most Limit per Interval. Email providers limit the rate
+// of sending, and the caller, such as an order, doesn't wait for SMTP.
+type Batcher struct {
+	sender   EmailSender
+	limit    int
+	interval time.Duration
+	queue    chan Message
+	done     chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+	sent   int
+	failed int
+
+	// OnError is called for each message the sender failed to send. The
+	// default logs it.
+	OnError func(msg Message, err error)
+}
+
+// NewBatcher starts a batcher sending through sender. capacity is the
+// number of messages that can wait.
+func NewBatcher(sender EmailSender, limit int, interval time.Duration, capacity int) *Batcher {
+	b := &Batcher{
+		sender:   sender,
+		limit:    limit,
+		interval: interval,
+		queue:    make(chan Message, capacity),
+		done:     make(chan struct{}),
+		OnError: func(msg Message, err error) {
+			log.Printf("email to %v failed: %v", msg.To, err)
+		},
+	}
+	go b.run()
+	return b
+}
+
+// Send queues the message. It is validated now, so the caller learns about
+// a bad address; the sending errors go to OnError.
+func (b *Batcher) Send(ctx context.Context, msg Message) error {
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrBatcherClosed
+	}
+	select {
+	case b.queue <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// run sends a batch of up to limit messages, then waits for the end of the
+// interval before the next batch.
+func (b *Batcher) run() {
+	defer close(b.done)
+	for {
+		// Wait for a message, so an idle batcher doesn't tick
+		msg, ok := <-b.queue
+		if !ok {
+			return
+		}
+		start := time.Now()
+		batch := []Message{msg}
+	fill:
+		for len(batch) < b.limit {
+			select {
+			case msg, ok := <-b.queue:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, msg)
+			default:
+				break fill
+			}
+		}
+
+		for _, msg := range batch {
+			ctx, cancel := context.WithTimeout(context.Background(), defaultSMTPTimeout)
+			err := b.sender.Send(ctx, msg)
+			cancel()
+			b.mu.Lock()
+			if err != nil {
+				b.failed++
+			} else {
+				b.sent++
+			}
+			b.mu.Unlock()
+			if err != nil {
+				b.OnError(msg, err)
+			}
+		}
+		time.Sleep(b.interval - time.Since(start))
+	}
+}
+
+// Close stops accepting messages, and returns once the queued ones are
+// sent, still at the limited rate.
+func (b *Batcher) Close() {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+	<-b.done
+}
+
+// Stats returns the number of messages sent and failed so far.
+func (b *Batcher) Stats() (sent, failed int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sent, b.failed
+}
@@ -0,0 +1,133 @@
+// Package notifications sends emails: messages rendered from templates,
+// senders for SMTP and for the log, and a batcher limiting the rate of
+// sending. OrderNotifier emails customers when their orders change.
+package notifications
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidMessage is returned for a message that can't be sent.
+var ErrInvalidMessage = errors.New("invalid message")
+
+// Message is an email with a text and an HTML version. Mail clients show
+// the HTML one, or the text one when they can't.
+type Message struct {
+	From    string   // Such as "Go Shop <orders@shop.example>"
+	To      []string // Addresses, with or without a name
+	Subject string
+	Text    string
+	HTML    string
+	Date    time.Time // Now when zero
+}
+
+// Validate checks the addresses, and that no header contains a line break:
+// a subject such as "Hi\r\nBcc: everyone@example.com" would add a header.
+func (m Message) Validate() error {
+	if _, err := mail.ParseAddress(m.From); err != nil {
+		return fmt.Errorf("%w: from %q: %v", ErrInvalidMessage, m.From, err)
+	}
+	if len(m.To) == 0 {
+		return fmt.Errorf("%w: no recipient", ErrInvalidMessage)
+	}
+	for _, to := range m.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("%w: to %q: %v", ErrInvalidMessage, to, err)
+		}
+	}
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		return fmt.Errorf("%w: line break in the subject", ErrInvalidMessage)
+	}
+	return nil
+}
+
+// envelope returns the bare addresses of the sender and the recipients,
+// for the MAIL FROM and RCPT TO commands of SMTP.
+func (m Message) envelope() (from string, to []string, err error) {
+	sender, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	for _, addr := range m.To {
+		recipient, err := mail.ParseAddress(addr)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+		}
+		to = append(to, recipient.Address)
+	}
+	return sender.Address, to, nil
+}
+
+// Bytes formats the message as MIME: the headers, then a
+// multipart/alternative body with the text part first, as the parts are
+// ordered from the simplest to the richest.
+func (m Message) Bytes() ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		if part.content == "" {
+			continue
+		}
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Quoted-printable keeps the lines short and the bytes 7-bit, as
+		// some SMTP servers require
+		qp := quotedprintable.NewWriter(w)
+		qp.Write([]byte(part.content))
+		qp.Close()
+	}
+	parts.Close()
+
+	var msg bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&msg, "%s: %s\r\n", name, value)
+	}
+	header("From", m.From)
+	header("To", strings.Join(m.To, ", "))
+	// Non-ASCII subjects are encoded as =?utf-8?q?...?=
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", date.Format(time.RFC1123Z))
+	header("Message-ID", "<"+uuid.NewString()+"@"+domain(m.From)+">")
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// domain returns the domain of an address, for the Message-ID.
+func domain(address string) string {
+	if addr, err := mail.ParseAddress(address); err == nil {
+		if _, d, ok := strings.Cut(addr.Address, "@"); ok {
+			return d
+		}
+	}
+	return "localhost"
+}
@@ -0,0 +1,161 @@
+// Package mocksmtp is an SMTP server keeping the messages it receives in
+// memory, for tests and demos. It speaks just enough SMTP for net/smtp:
+// no TLS, no authentication.
+package mocksmtp
+
+import (
+	"io"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Message is a message received by the server.
+type Message struct {
+	From string   // From MAIL FROM
+	To   []string // From RCPT TO
+	Data []byte   // The message, headers and body
+}
+
+// Parse reads the headers and the body of the message.
+func (m Message) Parse() (*mail.Message, error) {
+	return mail.ReadMessage(strings.NewReader(string(m.Data)))
+}
+
+// Server is a mock SMTP server listening on a local port.
+type Server struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	messages []Message
+	// Rejected recipients get a 550 answer, to test delivery errors
+	rejected map[string]bool
+}
+
+// Start starts a server on a random local port.
+func Start() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{listener: listener, rejected: make(map[string]bool)}
+	s.wg.Go(s.serve)
+	return s, nil
+}
+
+// Addr returns the host:port of the server.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Reject makes the server refuse an address as recipient.
+func (s *Server) Reject(address string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejected[strings.ToLower(address)] = true
+}
+
+// Messages returns the messages received so far.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Close stops the server and waits for the open connections.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return // Closed
+		}
+		s.wg.Go(func() { s.handle(conn) })
+	}
+}
+
+// handle runs an SMTP conversation. The commands are case-insensitive; each
+// answer is a code and a text.
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	reply := func(code int, text string) {
+		tp.PrintfLine("%d %s", code, text)
+	}
+
+	reply(220, "mocksmtp ready")
+	var current Message
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		command, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(command) {
+		case "EHLO":
+			// A multi-line answer: 250-text for every line but the last
+			tp.PrintfLine("250-mocksmtp greets %s", arg)
+			reply(250, "8BITMIME")
+		case "HELO":
+			reply(250, "mocksmtp")
+		case "MAIL":
+			current = Message{From: address(arg)}
+			reply(250, "OK")
+		case "RCPT":
+			to := address(arg)
+			s.mu.Lock()
+			rejected := s.rejected[strings.ToLower(to)]
+			s.mu.Unlock()
+			if rejected {
+				reply(550, "mailbox unavailable")
+				continue
+			}
+			current.To = append(current.To, to)
+			reply(250, "OK")
+		case "DATA":
+			if current.From == "" || len(current.To) == 0 {
+				reply(503, "need MAIL and RCPT first")
+				continue
+			}
+			reply(354, "end data with <CR><LF>.<CR><LF>")
+			// The dot reader removes the final dot and undoes the dot
+			// stuffing of the lines starting with a dot
+			data, err := io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			current.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, current)
+			s.mu.Unlock()
+			current = Message{}
+			reply(250, "OK queued")
+		case "RSET":
+			current = Message{}
+			reply(250, "OK")
+		case "NOOP":
+			reply(250, "OK")
+		case "QUIT":
+			reply(221, "bye")
+			return
+		default:
+			reply(502, "command not implemented")
+		}
+	}
+}
+
+// address extracts the address of "FROM:<alice@example.com>" or
+// "TO:<bob@example.com> SIZE=100".
+func address(arg string) string {
+	_, after, _ := strings.Cut(arg, "<")
+	addr, _, _ := strings.Cut(after, ">")
+	return addr
+}
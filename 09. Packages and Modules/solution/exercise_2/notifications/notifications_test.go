@@ -0,0 +1,312 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang-training/module-09/exercise-2/cart"
+	"golang-training/module-09/exercise-2/customers"
+	"golang-training/module-09/exercise-2/inventory"
+	"golang-training/module-09/exercise-2/models"
+	"golang-training/module-09/exercise-2/notifications/mocksmtp"
+	processor "golang-training/module-09/exercise-2/order"
+	"golang-training/module-09/exercise-2/payments"
+	"golang-training/module-09/exercise-2/shipping"
+)
+
+func usd(amount string) models.Money {
+	return models.MustParseMoney(amount, "USD")
+}
+
+func sampleEmail() OrderEmail {
+	return OrderEmail{
+		CustomerName: "Alice",
+		OrderNumber:  "3F2A9C1D",
+		Lines: []OrderLine{
+			{Name: "<script>alert(1)</script>", Quantity: 2, Price: usd("5"), Total: usd("10")},
+		},
+		Subtotal: usd("10"),
+		Shipping: usd("5.99"),
+		Total:    usd("15.99"),
+		Address:  models.Address{Name: "Alice", Street: "1 Main St", City: "Springfield", PostalCode: "62701", Country: "US"},
+		Earliest: time.Date(2026, time.March, 2, 0, 0, 0, 0, time.UTC),
+		Latest:   time.Date(2026, time.March, 4, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func startServer(t *testing.T) *mocksmtp.Server {
+	t.Helper()
+	server, err := mocksmtp.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return server
+}
+
+// parts returns the text and HTML parts of a received message.
+func parts(t *testing.T, received mocksmtp.Message) (subject, text, html string) {
+	t.Helper()
+	parsed, err := received.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err = new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, %v", parsed.Header.Get("Content-Type"), err)
+	}
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		// NextPart decodes the quoted-printable parts itself
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		switch contentType {
+		case "text/plain":
+			text = string(body)
+		case "text/html":
+			html = string(body)
+		}
+	}
+	return subject, text, html
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := templates.Render("order_confirmation", sampleEmail())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Subject != "Order 3F2A9C1D confirmed" {
+		t.Errorf("Subject = %q", msg.Subject)
+	}
+	if strings.Contains(msg.HTML, "<script>") || !strings.Contains(msg.HTML, "&lt;script&gt;") {
+		t.Errorf("the product name is not escaped in the HTML:\n%s", msg.HTML)
+	}
+	// The text part is not HTML: the name stays as is
+	if !strings.Contains(msg.Text, "<script>alert(1)</script>") || !strings.Contains(msg.Text, "$15.99") {
+		t.Errorf("unexpected text:\n%s", msg.Text)
+	}
+	if _, err := templates.Render("missing", sampleEmail()); err == nil {
+		t.Error("rendering a missing template: no error")
+	}
+}
+
+func TestMessageValidate(t *testing.T) {
+	valid := Message{From: "shop@example.com", To: []string{"alice@example.com"}, Subject: "Hi", Text: "Hello"}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid message: %v", err)
+	}
+	invalid := map[string]Message{
+		"no recipient":     {From: "shop@example.com", Subject: "Hi", Text: "Hello"},
+		"bad sender":       {From: "shop", To: []string{"alice@example.com"}, Subject: "Hi", Text: "Hello"},
+		"header injection": {From: "shop@example.com", To: []string{"alice@example.com"}, Subject: "Hi\r\nBcc: eve@example.com", Text: "Hello"},
+	}
+	for name, msg := range invalid {
+		if err := msg.Validate(); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestSMTPSender(t *testing.T) {
+	server := startServer(t)
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := templates.Render("order_confirmation", sampleEmail())
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.From = "Go Shop <orders@shop.example>"
+	msg.To = []string{"Alice Émile <alice@example.com>"}
+
+	sender := &SMTPSender{Addr: server.Addr()}
+	if err := sender.Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	received := server.Messages()
+	if len(received) != 1 {
+		t.Fatalf("received %d messages, want 1", len(received))
+	}
+	if received[0].From != "orders@shop.example" || len(received[0].To) != 1 || received[0].To[0] != "alice@example.com" {
+		t.Errorf("envelope = %s -> %v", received[0].From, received[0].To)
+	}
+	subject, text, html := parts(t, received[0])
+	if subject != msg.Subject {
+		t.Errorf("Subject = %q, want %q", subject, msg.Subject)
+	}
+	if text != msg.Text {
+		t.Errorf("text part = %q, want %q", text, msg.Text)
+	}
+	if html != msg.HTML {
+		t.Errorf("HTML part differs:\n%s", html)
+	}
+
+	// A recipient refused by the server fails the send
+	server.Reject("bob@example.com")
+	msg.To = []string{"bob@example.com"}
+	if err := sender.Send(context.Background(), msg); err == nil {
+		t.Error("sending to a rejected recipient: no error")
+	}
+}
+
+func TestLogSender(t *testing.T) {
+	var buf bytes.Buffer
+	sender := LogSender{Logger: log.New(&buf, "", 0)}
+	err := sender.Send(context.Background(), Message{From: "shop@example.com", To: []string{"alice@example.com"}, Subject: "Hi", Text: "Hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "Hi") || !strings.Contains(buf.String(), "Hello") {
+		t.Errorf("log = %q", buf.String())
+	}
+}
+
+// recordingSender records when each message is sent.
+type recordingSender struct {
+	mu    sync.Mutex
+	times []time.Time
+}
+
+func (s *recordingSender) Send(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.times = append(s.times, time.Now())
+	return nil
+}
+
+func TestBatcherRateLimit(t *testing.T) {
+	sender := &recordingSender{}
+	interval := 50 * time.Millisecond
+	batcher := NewBatcher(sender, 2, interval, 10)
+	msg := Message{From: "shop@example.com", To: []string{"alice@example.com"}, Subject: "Hi", Text: "Hello"}
+
+	start := time.Now()
+	for range 5 {
+		if err := batcher.Send(context.Background(), msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	batcher.Close()
+
+	// 2 messages per interval: the batches start at 0, 50 and 100 ms
+	if len(sender.times) != 5 {
+		t.Fatalf("sent %d messages, want 5", len(sender.times))
+	}
+	if last := sender.times[4].Sub(start); last < 2*interval {
+		t.Errorf("the fifth message was sent after %v, want at least %v", last, 2*interval)
+	}
+	if sent, failed := batcher.Stats(); sent != 5 || failed != 0 {
+		t.Errorf("Stats() = %d, %d", sent, failed)
+	}
+	if err := batcher.Send(context.Background(), msg); err != ErrBatcherClosed {
+		t.Errorf("Send after Close: %v, want ErrBatcherClosed", err)
+	}
+}
+
+func TestBatcherQueueFull(t *testing.T) {
+	sender := &recordingSender{}
+	batcher := NewBatcher(sender, 1, time.Hour, 1)
+	msg := Message{From: "shop@example.com", To: []string{"alice@example.com"}, Subject: "Hi", Text: "Hello"}
+
+	// The first message is taken by the batcher, the second waits in the
+	// queue, the third finds it full
+	var errs []error
+	for range 3 {
+		errs = append(errs, batcher.Send(context.Background(), msg))
+		time.Sleep(10 * time.Millisecond)
+	}
+	if errs[0] != nil || errs[1] != nil || errs[2] != ErrQueueFull {
+		t.Errorf("errors = %v, want nil, nil, ErrQueueFull", errs)
+	}
+}
+
+// TestOrderConfirmation processes an order with the notifier registered as
+// a transition hook: paying the order emails the customer.
+func TestOrderConfirmation(t *testing.T) {
+	server := startServer(t)
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	products := map[string]models.Product{
+		"P001": {ID: "P001", Name: "Laptop Pro", Price: usd("1200.00"), Weight: 2.1},
+		"P003": {ID: "P003", Name: "Wireless Mouse", Price: usd("50.00"), Weight: 0.1},
+	}
+	inventory.InitializeProducts(map[string]int{"P001": 5, "P003": 20})
+
+	registry := customers.NewRegistry()
+	alice, err := registry.Register("Alice", "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	home := models.Address{Name: "Alice", Street: "1 Main St", City: "Springfield", PostalCode: "62701", Country: "US"}
+
+	sender := &SMTPSender{Addr: server.Addr()}
+	notifier := NewOrderNotifier(sender, templates, "Go Shop <orders@shop.example>", registry.Get, products)
+	models.OnTransition(notifier.Hook())
+
+	c := cart.NewCart()
+	c.AddItem("P001", 1)
+	c.AddItem("P003", 2)
+	rate := shipping.FlatRate{Amount: usd("9.99")}
+	order, err := processor.ProcessOrder(alice, c, products, processor.Delivery{Address: home, Rate: rate}, payments.NewAlwaysSucceed())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := server.Messages()
+	if len(received) != 1 {
+		t.Fatalf("received %d messages, want 1", len(received))
+	}
+	if len(received[0].To) != 1 || received[0].To[0] != "alice@example.com" {
+		t.Errorf("recipients = %v", received[0].To)
+	}
+	subject, text, _ := parts(t, received[0])
+	number := strings.ToUpper(order.OrderID[:8])
+	if subject != "Order "+number+" confirmed" {
+		t.Errorf("Subject = %q", subject)
+	}
+	for _, want := range []string{"Laptop Pro", "Wireless Mouse", "$100.00", "$1,309.99", "Springfield"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("the text part lacks %q:\n%s", want, text)
+		}
+	}
+
+	if err := processor.Ship(order, "TRK-1001"); err != nil {
+		t.Fatal(err)
+	}
+	received = server.Messages()
+	if len(received) != 2 {
+		t.Fatalf("received %d messages after shipping, want 2", len(received))
+	}
+	if _, text, _ := parts(t, received[1]); !strings.Contains(text, "TRK-1001") {
+		t.Errorf("the shipping email lacks the tracking number:\n%s", text)
+	}
+}
@@ -0,0 +1,114 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"golang-training/module-09/exercise-2/models"
+)
+
+// OrderLine is a line of an order email.
+type OrderLine struct {
+	Name     string
+	Quantity int
+	Price    models.Money
+	Total    models.Money
+}
+
+// OrderEmail is the data of the order templates.
+type OrderEmail struct {
+	CustomerName   string
+	OrderNumber    string // The first characters of the order ID
+	Lines          []OrderLine
+	Subtotal       models.Money
+	Shipping       models.Money
+	Total          models.Money
+	Address        models.Address
+	Earliest       time.Time
+	Latest         time.Time
+	TrackingNumber string
+}
+
+// CustomerLookup returns a customer by ID, such as Registry.Get of the
+// customers package.
+type CustomerLookup func(customerID string) (*models.Customer, error)
+
+// OrderNotifier emails customers when their orders are paid or shipped.
+type OrderNotifier struct {
+	sender    EmailSender
+	templates *Templates
+	from      string
+	customers CustomerLookup
+	products  map[string]models.Product
+}
+
+func NewOrderNotifier(sender EmailSender, templates *Templates, from string, customers CustomerLookup, products map[string]models.Product) *OrderNotifier {
+	return &OrderNotifier{sender: sender, templates: templates, from: from, customers: customers, products: products}
+}
+
+// Hook returns the transition hook to register with models.OnTransition. A
+// hook can't fail the transition: a payment taken stays taken when the
+// email fails, so the error is logged.
+func (n *OrderNotifier) Hook() models.TransitionHook {
+	return func(order *models.Order, change models.StatusChange) {
+		if err := n.Notify(context.Background(), order, change); err != nil {
+			log.Printf("notifying order %s %s: %v", order.OrderID, change.EventName(), err)
+		}
+	}
+}
+
+// Notify sends the email of a status change: a confirmation when the order
+// is paid, the tracking number when it is shipped. Other changes send
+// nothing.
+func (n *OrderNotifier) Notify(ctx context.Context, order *models.Order, change models.StatusChange) error {
+	var name string
+	switch change.To {
+	case models.StatusPaid:
+		name = "order_confirmation"
+	case models.StatusShipped:
+		name = "order_shipped"
+	default:
+		return nil
+	}
+
+	customer, err := n.customers(order.CustomerID)
+	if err != nil {
+		return err
+	}
+	data := OrderEmail{
+		CustomerName: customer.Name,
+		OrderNumber:  strings.ToUpper(order.OrderID[:min(8, len(order.OrderID))]),
+		Subtotal:     order.Subtotal,
+		Shipping:     order.Shipping.Cost,
+		Total:        order.TotalAmount,
+		Address:      order.Shipping.Address,
+		Earliest:     order.Shipping.Earliest,
+		Latest:       order.Shipping.Latest,
+	}
+	// The shipping transition records "tracking TRK-1001"
+	data.TrackingNumber, _ = strings.CutPrefix(change.Reason, "tracking ")
+	for _, item := range order.Items {
+		product, ok := n.products[item.ProductID]
+		if !ok {
+			return fmt.Errorf("product %s not found", item.ProductID)
+		}
+		data.Lines = append(data.Lines, OrderLine{
+			Name:     product.Name,
+			Quantity: item.Quantity,
+			Price:    product.Price,
+			Total:    product.Price.Mul(int64(item.Quantity)),
+		})
+	}
+
+	msg, err := n.templates.Render(name, data)
+	if err != nil {
+		return err
+	}
+	msg.From = n.from
+	msg.To = []string{(&mail.Address{Name: customer.Name, Address: customer.Email}).String()}
+	return n.sender.Send(ctx, msg)
+}
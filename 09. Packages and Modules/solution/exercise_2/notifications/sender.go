@@ -0,0 +1,105 @@
+package notifications
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// EmailSender sends a message. The order code only knows this interface:
+// SMTP in production, the log in development, a fake in tests.
+type EmailSender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// defaultSMTPTimeout bounds a whole SMTP conversation when the context has
+// no deadline.
+const defaultSMTPTimeout = 10 * time.Second
+
+// SMTPSender sends messages to an SMTP server.
+type SMTPSender struct {
+	Addr      string      // host:port, such as smtp.example.com:587
+	Auth      smtp.Auth   // nil to send without authentication
+	TLSConfig *tls.Config // Used with STARTTLS when the server offers it; nil to stay in clear text
+}
+
+// Send delivers the message with one SMTP conversation. smtp.SendMail would
+// do the same, but it takes no context: here the connection is dialed with
+// the context, and its deadline bounds the conversation.
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	from, to, err := msg.envelope()
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", s.Addr, err)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultSMTPTimeout)
+	}
+	conn.SetDeadline(deadline)
+
+	host, _, _ := net.SplitHostPort(s.Addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("greeting %s: %w", s.Addr, err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && s.TLSConfig != nil {
+		if err := client.StartTLS(s.TLSConfig); err != nil {
+			return fmt.Errorf("starting TLS: %w", err)
+		}
+	}
+	if s.Auth != nil {
+		if err := client.Auth(s.Auth); err != nil {
+			return fmt.Errorf("authenticating: %w", err)
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("sender %s: %w", from, err)
+	}
+	for _, addr := range to {
+		if err := client.Rcpt(addr); err != nil {
+			return fmt.Errorf("recipient %s: %w", addr, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	// Closing the writer ends the data: the server accepts the message
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("sending the message: %w", err)
+	}
+	return client.Quit()
+}
+
+// LogSender prints the messages instead of sending them, for development.
+type LogSender struct {
+	Logger *log.Logger
+}
+
+func (s LogSender) Send(ctx context.Context, msg Message) error {
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+	s.Logger.Printf("email to %v: %s\n%s", msg.To, msg.Subject, msg.Text)
+	return nil
+}
@@ -0,0 +1,60 @@
+package notifications
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Templates renders the emails. An email named "order_confirmation" is
+// made of three templates:
+//
+//	order_confirmation.subject and order_confirmation.text, in order_confirmation.txt
+//	order_confirmation.html, in order_confirmation.html
+//
+// The text templates use text/template; the HTML one uses html/template,
+// which escapes the data: a product named <script> stays text.
+type Templates struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// LoadTemplates parses the templates embedded in the binary.
+func LoadTemplates() (*Templates, error) {
+	text, err := texttemplate.ParseFS(templateFS, "templates/*.txt")
+	if err != nil {
+		return nil, fmt.Errorf("parsing the text templates: %w", err)
+	}
+	html, err := htmltemplate.ParseFS(templateFS, "templates/*.html")
+	if err != nil {
+		return nil, fmt.Errorf("parsing the HTML templates: %w", err)
+	}
+	return &Templates{text: text, html: html}, nil
+}
+
+// Render returns the subject and the bodies of the email name, without
+// its sender and recipients.
+func (t *Templates) Render(name string, data any) (Message, error) {
+	var msg Message
+	var subject, text, html bytes.Buffer
+	if err := t.text.ExecuteTemplate(&subject, name+".subject", data); err != nil {
+		return msg, fmt.Errorf("rendering %s: %w", name, err)
+	}
+	if err := t.text.ExecuteTemplate(&text, name+".text", data); err != nil {
+		return msg, fmt.Errorf("rendering %s: %w", name, err)
+	}
+	if err := t.html.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return msg, fmt.Errorf("rendering %s: %w", name, err)
+	}
+	// The subject template may end with a line break; a header can't
+	msg.Subject = strings.Join(strings.Fields(subject.String()), " ")
+	msg.Text = strings.TrimSpace(text.String()) + "\n"
+	msg.HTML = html.String()
+	return msg, nil
+}
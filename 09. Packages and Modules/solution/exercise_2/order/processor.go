@@ -3,61 +3,167 @@ package orderprocessor
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 
 	"golang-training/module-09/exercise-2/cart"
 	"golang-training/module-09/exercise-2/inventory"
 	"golang-training/module-09/exercise-2/models"
+	"golang-training/module-09/exercise-2/payments"
+	"golang-training/module-09/exercise-2/shipping"
 )
 
+// maxPaymentAttempts is the number of times a charge is sent while the
+// payment gateway is unavailable.
+const maxPaymentAttempts = 3
+
+// Delivery is the shipping chosen by the customer for an order.
+type Delivery struct {
+	Address models.Address // Default address of the customer when empty
+	Rate    shipping.Strategy
+}
+
 // ProcessOrder handles the logic for converting a cart into a completed order.
-// It interacts with the inventory and models packages.
-func ProcessOrder(c *cart.Cart, productPrices map[string]float64) (*models.Order, error) {
+// It interacts with the inventory, payments, shipping and models packages.
+//
+// The order is placed by a registered customer. The total charged is the price of the items plus the shipping cost, given
+// by the rate strategy of the delivery.
+//
+// The order is created Pending and becomes Paid. When the payment fails, the
+// stock reserved for the order is put back (a compensating action) and the
+// Cancelled order is returned with the error.
+func ProcessOrder(customer *models.Customer, c *cart.Cart, products map[string]models.Product, delivery Delivery, gateway payments.PaymentGateway) (*models.Order, error) {
+	if customer == nil {
+		return nil, errors.New("an order needs a customer")
+	}
 	if c == nil || len(c.GetItems()) == 0 {
 		return nil, errors.New("cannot process an empty cart")
 	}
 
 	// Generate a unique order ID
 	orderID := uuid.New().String()
+	var err error
 
-	// Prepare for order creation
+	// Check the products and the shipping before touching the stock
 	orderItems := make([]models.Item, 0, len(c.GetItems()))
-	totalAmount := 0.0
-
-	// Temporary slice to track stock changes before committing, for rollback in case of error
-	// For this simple example, we're not doing a full transaction rollback.
-	// In a real system, you'd use database transactions.
-
+	var subtotal models.Money
+	weight := 0.0
 	for _, item := range c.GetItems() {
+		product, ok := products[item.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("product %s not found", item.ProductID)
+		}
+		orderItems = append(orderItems, item)
+		subtotal, err = subtotal.Add(product.Price.Mul(int64(item.Quantity)))
+		if err != nil {
+			return nil, fmt.Errorf("price of %s: %w", item.ProductID, err)
+		}
+		weight += product.Weight * float64(item.Quantity)
+	}
+	addr := delivery.Address
+	if addr == (models.Address{}) {
+		var ok bool
+		if addr, ok = customer.DefaultAddress(); !ok {
+			return nil, fmt.Errorf("customer %s has no address to ship to", customer.ID)
+		}
+	}
+	shipment, err := shipping.Quote(delivery.Rate, addr, subtotal, weight, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("cannot ship order %s: %w", orderID, err)
+	}
+
+	// Reserve the stock. Without database transactions, every removal is
+	// remembered so it can be undone.
+	var reserved []models.Item
+	release := func() {
+		for _, item := range reserved {
+			inventory.AddStock(item.ProductID, item.Quantity)
+		}
+	}
+	for _, item := range orderItems {
 		currentStock := inventory.GetStock(item.ProductID)
 		if currentStock < item.Quantity {
-			return nil, errors.New(fmt.Sprintf("insufficient stock for %s. Available: %d, Requested: %d", item.ProductID, currentStock, item.Quantity))
+			release()
+			return nil, fmt.Errorf("insufficient stock for %s. Available: %d, Requested: %d", item.ProductID, currentStock, item.Quantity)
 		}
-
-		// Simulate deducting stock. If this was a real database, this would be part of a transaction.
-		err := inventory.RemoveStock(item.ProductID, item.Quantity)
-		if err != nil {
-			// This error should ideally not happen if GetStock check passed, but good to handle.
+		if err := inventory.RemoveStock(item.ProductID, item.Quantity); err != nil {
+			release()
 			return nil, fmt.Errorf("failed to deduct stock for %s: %w", item.ProductID, err)
 		}
+		reserved = append(reserved, item)
+	}
 
-		// Add item to the order and calculate total
-		orderItems = append(orderItems, item)
-		if price, ok := productPrices[item.ProductID]; ok {
-			totalAmount += price * float64(item.Quantity)
-		} else {
-			return nil, fmt.Errorf("price not found for product %s", item.ProductID)
-		}
+	order, err := models.NewOrder(orderID, customer.ID, orderItems, subtotal, shipment)
+	if err != nil {
+		release()
+		return nil, err
 	}
 
-	order := &models.Order{
-		OrderID:     orderID,
-		Items:       orderItems,
-		TotalAmount: totalAmount,
-		Status:      "Completed",
+	// The idempotency key is derived from the order, so the retries of this
+	// payment can't charge twice
+	charge, err := chargeWithRetry(gateway, payments.ChargeRequest{
+		OrderID:        orderID,
+		Amount:         order.TotalAmount,
+		IdempotencyKey: "order-" + orderID,
+	})
+	if err != nil {
+		release()
+		order.TransitionTo(models.StatusCancelled, "payment failed: "+err.Error())
+		return order, fmt.Errorf("payment for order %s failed: %w", orderID, err)
+	}
+	order.PaymentID = charge.ID
+	if err := order.TransitionTo(models.StatusPaid, "payment "+charge.ID); err != nil {
+		return order, err
 	}
 
 	fmt.Printf("Order %s processed successfully!\n", orderID)
 	return order, nil
 }
+
+// Ship marks a paid order as shipped.
+func Ship(order *models.Order, trackingNumber string) error {
+	return order.TransitionTo(models.StatusShipped, "tracking "+trackingNumber)
+}
+
+// Deliver marks a shipped order as delivered.
+func Deliver(order *models.Order) error {
+	return order.TransitionTo(models.StatusDelivered, "signed for by the customer")
+}
+
+// Refund gives the payment of an order back. The transition is checked
+// first, so an order that can't be refunded is never refunded by the
+// gateway. The stock of an order that was not shipped goes back on sale.
+func Refund(order *models.Order, gateway payments.PaymentGateway, reason string) error {
+	if !order.Status.CanTransitionTo(models.StatusRefunded) {
+		return &models.TransitionError{OrderID: order.OrderID, From: order.Status, To: models.StatusRefunded}
+	}
+	if err := gateway.Refund(order.PaymentID); err != nil {
+		return fmt.Errorf("refund of order %s failed: %w", order.OrderID, err)
+	}
+	if order.Status == models.StatusPaid {
+		for _, item := range order.Items {
+			inventory.AddStock(item.ProductID, item.Quantity)
+		}
+	}
+	return order.TransitionTo(models.StatusRefunded, reason)
+}
+
+// chargeWithRetry sends a charge, retrying with a growing delay while the
+// gateway is unavailable. A declined payment is not retried.
+func chargeWithRetry(gateway payments.PaymentGateway, req payments.ChargeRequest) (*payments.Charge, error) {
+	var err error
+	for attempt := 1; attempt <= maxPaymentAttempts; attempt++ {
+		var charge *payments.Charge
+		charge, err = gateway.Charge(req)
+		if err == nil {
+			return charge, nil
+		}
+		if !errors.Is(err, payments.ErrUnavailable) {
+			return nil, err
+		}
+		fmt.Printf("Payment attempt %d failed: %v\n", attempt, err)
+		time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+	}
+	return nil, fmt.Errorf("giving up after %d attempts: %w", maxPaymentAttempts, err)
+}
@@ -0,0 +1,59 @@
+package payments
+
+import (
+	"errors"
+
+	"golang-training/module-09/exercise-2/models"
+)
+
+// Errors returned by the gateways. Callers check them with errors.Is to
+// decide whether to retry.
+var (
+	// ErrDeclined is final: retrying the same charge gives the same answer
+	ErrDeclined = errors.New("payment declined")
+
+	// ErrUnavailable is temporary: the charge can be retried with the same
+	// idempotency key
+	ErrUnavailable = errors.New("payment provider unavailable")
+
+	// ErrIdempotencyConflict means an idempotency key was reused for a
+	// different charge
+	ErrIdempotencyConflict = errors.New("idempotency key reused with different parameters")
+
+	// ErrChargeNotFound is returned when refunding an unknown charge
+	ErrChargeNotFound = errors.New("charge not found")
+)
+
+// ChargeRequest asks a gateway to take a payment.
+// IdempotencyKey identifies the payment: sending the same request again with
+// the same key returns the first result instead of charging twice, so a
+// request can safely be retried after a timeout.
+type ChargeRequest struct {
+	OrderID        string
+	Amount         models.Money
+	IdempotencyKey string
+}
+
+// ChargeStatus is the state of a charge.
+type ChargeStatus string
+
+const (
+	ChargeSucceeded ChargeStatus = "succeeded"
+	ChargeRefunded  ChargeStatus = "refunded"
+)
+
+// Charge is a payment taken by a gateway.
+type Charge struct {
+	ID      string
+	OrderID string
+	Amount  models.Money
+	Status  ChargeStatus
+}
+
+// PaymentGateway is implemented by every payment provider.
+// The order processor only depends on this interface, so providers can be
+// swapped, or replaced by mocks in tests and demos.
+type PaymentGateway interface {
+	Charge(req ChargeRequest) (*Charge, error)
+	Refund(chargeID string) error
+}
@@ -0,0 +1,133 @@
+package payments
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"golang-training/module-09/exercise-2/models"
+)
+
+// result is the stored answer to an idempotency key.
+type result struct {
+	req    ChargeRequest
+	charge *Charge
+	err    error
+}
+
+// MockGateway is an in-memory PaymentGateway. Its behaviour is chosen by the
+// constructor: NewAlwaysSucceed, NewFlaky or NewDeclining.
+type MockGateway struct {
+	Name string
+
+	mu       sync.Mutex
+	attempts int                // Charge calls, including retries
+	charges  map[string]*Charge // By charge ID
+	results  map[string]result  // By idempotency key
+
+	// decide returns whether the response of a charge attempt is lost after
+	// charging, and its error, nil to accept it
+	decide func(attempt int, req ChargeRequest) (lost bool, err error)
+}
+
+func newMock(name string, decide func(int, ChargeRequest) (bool, error)) *MockGateway {
+	return &MockGateway{
+		Name:    name,
+		charges: make(map[string]*Charge),
+		results: make(map[string]result),
+		decide:  decide,
+	}
+}
+
+// NewAlwaysSucceed creates a gateway accepting every charge.
+func NewAlwaysSucceed() *MockGateway {
+	return newMock("always-succeed", func(int, ChargeRequest) (bool, error) {
+		return false, nil
+	})
+}
+
+// NewFlaky creates a gateway whose first failures attempts time out after
+// the charge was made: the money is taken but the response is lost. Only
+// the idempotency key prevents a retry from charging twice.
+func NewFlaky(failures int) *MockGateway {
+	return newMock("flaky", func(attempt int, _ ChargeRequest) (bool, error) {
+		return attempt <= failures, nil
+	})
+}
+
+// NewDeclining creates a gateway declining every charge above limit, and
+// every charge when limit is zero or in another currency.
+func NewDeclining(limit models.Money) *MockGateway {
+	return newMock("declining", func(_ int, req ChargeRequest) (bool, error) {
+		if cmp, err := req.Amount.Compare(limit); err != nil || limit.IsZero() || cmp > 0 {
+			return false, fmt.Errorf("%w: insufficient funds for %s", ErrDeclined, req.Amount)
+		}
+		return false, nil
+	})
+}
+
+// Charge takes a payment, or returns the stored result of its idempotency key.
+func (g *MockGateway) Charge(req ChargeRequest) (*Charge, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.attempts++
+
+	if req.Amount.IsZero() || req.Amount.IsNegative() {
+		return nil, fmt.Errorf("%s: invalid amount %s", g.Name, req.Amount)
+	}
+	if req.IdempotencyKey != "" {
+		if previous, ok := g.results[req.IdempotencyKey]; ok {
+			if previous.req != req {
+				return nil, fmt.Errorf("%s: key %s: %w", g.Name, req.IdempotencyKey, ErrIdempotencyConflict)
+			}
+			fmt.Printf("[%s] Replaying the result of key %s\n", g.Name, req.IdempotencyKey)
+			return previous.charge, previous.err
+		}
+	}
+
+	lost, err := g.decide(g.attempts, req)
+	var charge *Charge
+	if err == nil {
+		charge = &Charge{
+			ID:      "ch_" + uuid.New().String()[:8],
+			OrderID: req.OrderID,
+			Amount:  req.Amount,
+			Status:  ChargeSucceeded,
+		}
+		g.charges[charge.ID] = charge
+		fmt.Printf("[%s] Charged %s for order %s (%s)\n", g.Name, req.Amount, req.OrderID, charge.ID)
+	}
+
+	// Declines are final and stored like successes; a temporary error is
+	// not, the retry must be able to succeed
+	if req.IdempotencyKey != "" && !errors.Is(err, ErrUnavailable) {
+		g.results[req.IdempotencyKey] = result{req: req, charge: charge, err: err}
+	}
+	if lost {
+		return nil, fmt.Errorf("%s: timeout waiting for the response: %w", g.Name, ErrUnavailable)
+	}
+	return charge, err
+}
+
+// Refund gives the money of a charge back.
+func (g *MockGateway) Refund(chargeID string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	charge, ok := g.charges[chargeID]
+	if !ok {
+		return fmt.Errorf("%s: %s: %w", g.Name, chargeID, ErrChargeNotFound)
+	}
+	charge.Status = ChargeRefunded
+	fmt.Printf("[%s] Refunded %s (%s)\n", g.Name, charge.Amount, charge.ID)
+	return nil
+}
+
+// Charges returns the number of charges made and the number of Charge calls.
+func (g *MockGateway) Charges() (charges, attempts int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.charges), g.attempts
+}
@@ -0,0 +1,60 @@
+package shipping
+
+import (
+	"fmt"
+	"time"
+
+	"golang-training/module-09/exercise-2/models"
+)
+
+// cutoffHour is the hour after which orders leave the warehouse the next
+// business day.
+const cutoffHour = 14
+
+// Quote computes the shipping of an order placed at now: the zone of the
+// address, the cost given by the strategy and the estimated delivery dates.
+func Quote(strategy Strategy, addr models.Address, subtotal models.Money, weight float64, now time.Time) (models.Shipping, error) {
+	zone, err := LookupZone(addr)
+	if err != nil {
+		return models.Shipping{}, err
+	}
+	cost, err := strategy.Cost(Shipment{Subtotal: subtotal, Weight: weight, Zone: zone})
+	if err != nil {
+		return models.Shipping{}, fmt.Errorf("%s: %w", strategy.Name(), err)
+	}
+	earliest, latest := EstimateDelivery(zone, now)
+	return models.Shipping{
+		Address:  addr,
+		Method:   strategy.Name(),
+		Zone:     zone.Name,
+		Cost:     cost,
+		Earliest: earliest,
+		Latest:   latest,
+	}, nil
+}
+
+// EstimateDelivery returns the delivery window of an order placed at now.
+// Parcels leave the warehouse on the next business day after the cutoff, and
+// only travel on business days.
+func EstimateDelivery(zone Zone, now time.Time) (earliest, latest time.Time) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if now.Hour() >= cutoffHour || isWeekend(day) {
+		day = addBusinessDays(day, 1)
+	}
+	return addBusinessDays(day, zone.MinDays), addBusinessDays(day, zone.MaxDays)
+}
+
+// addBusinessDays adds n days to t, skipping Saturdays and Sundays.
+func addBusinessDays(t time.Time, n int) time.Time {
+	for n > 0 {
+		t = t.AddDate(0, 0, 1)
+		if !isWeekend(t) {
+			n--
+		}
+	}
+	return t
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
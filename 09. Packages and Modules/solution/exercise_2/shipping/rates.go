@@ -0,0 +1,89 @@
+package shipping
+
+import (
+	"errors"
+	"fmt"
+
+	"golang-training/module-09/exercise-2/models"
+)
+
+// ErrTooHeavy is returned by a rate that can't carry the weight of a shipment.
+var ErrTooHeavy = errors.New("shipment too heavy")
+
+// Shipment is what a rate strategy prices.
+type Shipment struct {
+	Subtotal models.Money // Price of the items
+	Weight   float64      // In kg
+	Zone     Zone
+}
+
+// Strategy computes the shipping cost of a shipment.
+// The order processor only depends on this interface: the strategy is chosen
+// per order, and new rates are added without touching the processor.
+type Strategy interface {
+	Name() string
+	Cost(s Shipment) (models.Money, error)
+}
+
+// FlatRate charges the same amount for every shipment, whatever its weight or
+// destination.
+type FlatRate struct {
+	Amount models.Money
+}
+
+func (r FlatRate) Name() string {
+	return fmt.Sprintf("flat rate %s", r.Amount)
+}
+
+func (r FlatRate) Cost(Shipment) (models.Money, error) {
+	return r.Amount, nil
+}
+
+// Tier is the price of shipments up to a weight.
+type Tier struct {
+	UpTo  float64 // In kg
+	Price models.Money
+}
+
+// WeightTiers charges the price of the first tier carrying the weight of the
+// shipment, multiplied by the multiplier of its zone. Tiers must be sorted
+// by weight.
+type WeightTiers struct {
+	Tiers []Tier
+}
+
+func (r WeightTiers) Name() string {
+	return "weight-based"
+}
+
+func (r WeightTiers) Cost(s Shipment) (models.Money, error) {
+	for _, tier := range r.Tiers {
+		if s.Weight <= tier.UpTo {
+			return tier.Price.Scale(s.Zone.Multiplier), nil
+		}
+	}
+	return models.Money{}, fmt.Errorf("%.1f kg: %w", s.Weight, ErrTooHeavy)
+}
+
+// FreeAbove makes shipping free when the subtotal reaches a threshold, and
+// uses another strategy otherwise. It wraps a strategy instead of
+// duplicating one.
+type FreeAbove struct {
+	Threshold models.Money
+	Otherwise Strategy
+}
+
+func (r FreeAbove) Name() string {
+	return fmt.Sprintf("free above %s, else %s", r.Threshold, r.Otherwise.Name())
+}
+
+func (r FreeAbove) Cost(s Shipment) (models.Money, error) {
+	cmp, err := s.Subtotal.Compare(r.Threshold)
+	if err != nil {
+		return models.Money{}, err
+	}
+	if cmp >= 0 {
+		return models.NewMoney(0, s.Subtotal.Currency), nil
+	}
+	return r.Otherwise.Cost(s)
+}
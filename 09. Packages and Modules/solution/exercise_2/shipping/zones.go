@@ -0,0 +1,67 @@
+package shipping
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang-training/module-09/exercise-2/models"
+)
+
+// Errors returned by the zone lookup.
+var (
+	ErrInvalidAddress = errors.New("invalid address")
+	ErrNoZone         = errors.New("no shipping to this destination")
+)
+
+// Zone groups destinations sharing a price multiplier and a delivery time.
+type Zone struct {
+	Name       string
+	Multiplier float64 // Applied by the weight-based rate
+	MinDays    int     // Business days in transit
+	MaxDays    int
+}
+
+var (
+	Domestic      = Zone{Name: "domestic", Multiplier: 1, MinDays: 2, MaxDays: 4}
+	Remote        = Zone{Name: "remote", Multiplier: 1.8, MinDays: 4, MaxDays: 8}
+	NorthAmerica  = Zone{Name: "north-america", Multiplier: 2, MinDays: 4, MaxDays: 7}
+	International = Zone{Name: "international", Multiplier: 3.5, MinDays: 6, MaxDays: 14}
+)
+
+// countries maps the countries shipped to, other than the domestic one, to
+// their zone.
+var countries = map[string]Zone{
+	"CA": NorthAmerica,
+	"MX": NorthAmerica,
+	"GB": International,
+	"FR": International,
+	"DE": International,
+	"JP": International,
+	"AU": International,
+}
+
+// remotePrefixes are the US postal codes of Alaska and Hawaii.
+var remotePrefixes = []string{"967", "968", "995", "996", "997", "998", "999"}
+
+// LookupZone returns the zone an address belongs to.
+func LookupZone(addr models.Address) (Zone, error) {
+	country := strings.ToUpper(strings.TrimSpace(addr.Country))
+	postalCode := strings.TrimSpace(addr.PostalCode)
+	if country == "" || postalCode == "" {
+		return Zone{}, fmt.Errorf("%w: country and postal code are required", ErrInvalidAddress)
+	}
+
+	if country == "US" {
+		for _, prefix := range remotePrefixes {
+			if strings.HasPrefix(postalCode, prefix) {
+				return Remote, nil
+			}
+		}
+		return Domestic, nil
+	}
+	if zone, ok := countries[country]; ok {
+		return zone, nil
+	}
+	return Zone{}, fmt.Errorf("%s: %w", country, ErrNoZone)
+}
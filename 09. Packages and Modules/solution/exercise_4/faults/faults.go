@@ -0,0 +1,71 @@
+// Package faults makes in-process services fail like remote ones, at a rate
+// or on demand.
+package faults
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+)
+
+// ErrInjected is the cause of every injected failure.
+var ErrInjected = errors.New("injected failure")
+
+// Injector decides whether an operation fails. The zero value never fails.
+type Injector struct {
+	mu     sync.Mutex
+	rate   map[string]float64 // By operation, "*" for all of them
+	forced map[string]int     // Failures to inject before the rate applies
+	rnd    *rand.Rand
+}
+
+// New creates an injector failing every operation with the given rate,
+// between 0 and 1. The seed makes the failures reproducible.
+func New(rate float64, seed int64) *Injector {
+	i := &Injector{rnd: rand.New(rand.NewSource(seed))}
+	i.SetRate("*", rate)
+	return i
+}
+
+// SetRate sets the failure rate of an operation, or of all of them with "*".
+func (i *Injector) SetRate(op string, rate float64) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.rate == nil {
+		i.rate = make(map[string]float64)
+	}
+	i.rate[op] = rate
+}
+
+// FailNext makes the next n calls of an operation fail.
+func (i *Injector) FailNext(op string, n int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.forced == nil {
+		i.forced = make(map[string]int)
+	}
+	i.forced[op] += n
+}
+
+// Check returns an error wrapping ErrInjected when the operation must fail.
+func (i *Injector) Check(service, op string) error {
+	if i == nil {
+		return nil
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.forced[op] > 0 {
+		i.forced[op]--
+		return fmt.Errorf("%s.%s: %w", service, op, ErrInjected)
+	}
+	rate, ok := i.rate[op]
+	if !ok {
+		rate = i.rate["*"]
+	}
+	if rate > 0 && i.rnd != nil && i.rnd.Float64() < rate {
+		return fmt.Errorf("%s.%s: %w", service, op, ErrInjected)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Package inventory is the stock service: it reserves the items of an order,
+// and releases them when the order is abandoned.
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+
+	"golang-training/module-09/exercise-2/models"
+	"golang-training/module-09/exercise-4/faults"
+)
+
+// ErrInsufficientStock is a business failure: retrying doesn't help.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// Service holds the stock of each product and the reservations of the
+// orders.
+type Service struct {
+	Faults *faults.Injector
+
+	mu           sync.Mutex
+	stock        map[string]int
+	reservations map[string][]models.Item // By order ID
+}
+
+// New creates the service with the initial stock of each product.
+func New(stock map[string]int) *Service {
+	return &Service{stock: maps.Clone(stock), reservations: make(map[string][]models.Item)}
+}
+
+// Reserve takes the items of an order out of the stock, all or none.
+// Reserving the same order again returns the first reservation: the saga can
+// retry a step whose result it didn't record.
+func (s *Service) Reserve(orderID string, items []models.Item) (string, error) {
+	if err := s.Faults.Check("inventory", "reserve"); err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reservationID := "res-" + orderID
+	if _, ok := s.reservations[orderID]; ok {
+		return reservationID, nil
+	}
+	for _, item := range items {
+		if s.stock[item.ProductID] < item.Quantity {
+			return "", fmt.Errorf("%s: %d available, %d requested: %w", item.ProductID, s.stock[item.ProductID], item.Quantity, ErrInsufficientStock)
+		}
+	}
+	for _, item := range items {
+		s.stock[item.ProductID] -= item.Quantity
+	}
+	s.reservations[orderID] = items
+	return reservationID, nil
+}
+
+// Release puts the items of an order back in stock. Releasing an order
+// without a reservation does nothing, so a compensation can run twice.
+func (s *Service) Release(orderID string) error {
+	if err := s.Faults.Check("inventory", "release"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, item := range s.reservations[orderID] {
+		s.stock[item.ProductID] += item.Quantity
+	}
+	delete(s.reservations, orderID)
+	return nil
+}
+
+// Stock returns the stock of each product, and the quantities reserved.
+func (s *Service) Stock() (stock, reserved map[string]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reserved = make(map[string]int)
+	for _, items := range s.reservations {
+		for _, item := range items {
+			reserved[item.ProductID] += item.Quantity
+		}
+	}
+	return maps.Clone(s.stock), reserved
+}
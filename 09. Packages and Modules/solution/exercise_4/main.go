@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang-training/module-09/exercise-2/models"
+	"golang-training/module-09/exercise-4/faults"
+	"golang-training/module-09/exercise-4/inventory"
+	"golang-training/module-09/exercise-4/payment"
+	"golang-training/module-09/exercise-4/saga"
+	"golang-training/module-09/exercise-4/shipping"
+)
+
+var initialStock = map[string]int{"P001": 5, "P002": 10, "P003": 20}
+
+var prices = map[string]models.Money{
+	"P001": models.MustParseMoney("1200.00", "USD"),
+	"P002": models.MustParseMoney("150.00", "USD"),
+	"P003": models.MustParseMoney("50.00", "USD"),
+}
+
+var home = models.Address{Name: "Alice", Street: "1 Main St", City: "Springfield", PostalCode: "62701", Country: "US"}
+
+// errCrash stops the coordinator as if the process died.
+var errCrash = errors.New("process crashed")
+
+// crashingLog fails every append after the first n, like a process killed
+// between two steps.
+type crashingLog struct {
+	saga.Log
+	n int
+}
+
+func (l *crashingLog) Append(e saga.Entry) error {
+	if l.n == 0 {
+		return errCrash
+	}
+	l.n--
+	return l.Log.Append(e)
+}
+
+func newServices(stock map[string]int) Services {
+	return Services{
+		Inventory: inventory.New(stock),
+		Payment:   payment.New(models.MustParseMoney("5000.00", "USD")),
+		Shipping:  shipping.New("US", "CA"),
+	}
+}
+
+func newOrder(id string, to models.Address, items ...models.Item) *PlaceOrder {
+	o := &PlaceOrder{OrderID: id, Items: items, Address: to}
+	for _, item := range items {
+		o.Total, _ = o.Total.Add(prices[item.ProductID].Mul(int64(item.Quantity)))
+	}
+	return o
+}
+
+func trace(e saga.Entry) {
+	line := fmt.Sprintf("    %-19s %s", e.Type, e.Step)
+	if e.Error != "" {
+		line += ": " + e.Error
+	}
+	fmt.Println(line)
+}
+
+func report(s Services) {
+	stock, reserved := s.Inventory.Stock()
+	captured, charges, refunds := s.Payment.Captured()
+	fmt.Printf("  stock %v, reserved %v\n  captured %s (%d charges, %d refunded), %d shipments\n",
+		stock, reserved, captured, charges, refunds, s.Shipping.Shipments())
+}
+
+func main() {
+	dir, err := os.MkdirTemp("", "saga")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+
+	fmt.Println("=== Placing orders ===")
+	s := newServices(initialStock)
+	stateLog, err := saga.OpenFileLog(filepath.Join(dir, "orders.log"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer stateLog.Close()
+	coordinator := NewOrderSaga(s, stateLog)
+	coordinator.Trace = trace
+	coordinator.Backoff = 10 * time.Millisecond
+
+	fmt.Println("  A1: everything succeeds")
+	err = coordinator.Run(ctx, "A1", newOrder("A1", home, models.Item{ProductID: "P001", Quantity: 1}, models.Item{ProductID: "P003", Quantity: 2}))
+	fmt.Println("  result:", err)
+
+	fmt.Println("  A2: no carrier ships to the address, after the payment")
+	atlantis := models.Address{Name: "Dan", City: "Atlantis", Country: "XX"}
+	err = coordinator.Run(ctx, "A2", newOrder("A2", atlantis, models.Item{ProductID: "P002", Quantity: 2}))
+	fmt.Println("  result:", err)
+
+	fmt.Println("  A3: the card is declined, only the stock is released")
+	err = coordinator.Run(ctx, "A3", newOrder("A3", home, models.Item{ProductID: "P001", Quantity: 4}, models.Item{ProductID: "P002", Quantity: 2}))
+	fmt.Println("  result:", err)
+
+	fmt.Println("  A4: shipping is down, and the first two refunds fail")
+	s.Shipping.Faults = &faults.Injector{}
+	s.Shipping.Faults.FailNext("book", 1)
+	s.Payment.Faults = &faults.Injector{}
+	s.Payment.Faults.FailNext("refund", 2)
+	err = coordinator.Run(ctx, "A4", newOrder("A4", home, models.Item{ProductID: "P003", Quantity: 3}))
+	fmt.Println("  result:", err)
+
+	fmt.Println("  A5: the inventory keeps failing to release the stock")
+	s.Shipping.Faults.FailNext("book", 1)
+	s.Inventory.Faults = &faults.Injector{}
+	s.Inventory.Faults.FailNext("release", 3)
+	err = coordinator.Run(ctx, "A5", newOrder("A5", home, models.Item{ProductID: "P002", Quantity: 1}))
+	fmt.Println("  result:", err, "| stuck:", errors.Is(err, saga.ErrStuck))
+	report(s)
+
+	fmt.Println("\n=== Recovering after the inventory is back ===")
+	results, err := coordinator.Recover(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("  results:", results)
+	report(s)
+
+	fmt.Println("\n=== Crashing in the middle of sagas ===")
+	// B1 crashes after the payment, going forward. B2 crashes after the
+	// refund, compensating. A new coordinator, as after a restart, resumes
+	// both from the log.
+	crashing := NewOrderSaga(s, &crashingLog{Log: stateLog, n: 5})
+	crashing.Trace = trace
+	err = crashing.Run(ctx, "B1", newOrder("B1", home, models.Item{ProductID: "P003", Quantity: 1}))
+	fmt.Println("  B1:", err)
+	crashing = NewOrderSaga(s, &crashingLog{Log: stateLog, n: 7})
+	crashing.Trace = trace
+	err = crashing.Run(ctx, "B2", newOrder("B2", atlantis, models.Item{ProductID: "P003", Quantity: 1}))
+	fmt.Println("  B2:", err)
+	report(s)
+
+	restarted := NewOrderSaga(s, stateLog)
+	restarted.Trace = trace
+	results, err = restarted.Recover(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("  recovered:", results)
+	report(s)
+
+	fmt.Println("\n=== 50 orders with 15% of failures everywhere ===")
+	s = newServices(map[string]int{"P003": 100})
+	s.Inventory.Faults = faults.New(0.15, 1)
+	s.Payment.Faults = faults.New(0.15, 2)
+	s.Shipping.Faults = faults.New(0.15, 3)
+	memLog := &saga.MemoryLog{}
+	coordinator = NewOrderSaga(s, memLog)
+	coordinator.Backoff = time.Millisecond
+	completed, compensated, stuck := 0, 0, 0
+	var expected models.Money
+	for i := range 50 {
+		id := fmt.Sprintf("C%02d", i)
+		order := newOrder(id, home, models.Item{ProductID: "P003", Quantity: 1})
+		switch err := coordinator.Run(ctx, id, order); {
+		case err == nil:
+			completed++
+			expected, _ = expected.Add(order.Total)
+		case errors.Is(err, saga.ErrStuck):
+			stuck++
+		default:
+			compensated++
+		}
+	}
+	fmt.Printf("  %d completed, %d compensated, %d stuck\n", completed, compensated, stuck)
+	report(s)
+	captured, _, _ := s.Payment.Captured()
+	stock, reserved := s.Inventory.Stock()
+	fmt.Printf("  consistent: captured %s = %d orders placed x $50.00: %v, stock %d + reserved %d = 100\n",
+		captured, completed, captured == expected, stock["P003"], reserved["P003"])
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+
+	"golang-training/module-09/exercise-2/models"
+	"golang-training/module-09/exercise-4/inventory"
+	"golang-training/module-09/exercise-4/payment"
+	"golang-training/module-09/exercise-4/saga"
+	"golang-training/module-09/exercise-4/shipping"
+)
+
+// PlaceOrder is the state of the order saga: the order, and what each
+// service returned. It is written to the log after every step.
+type PlaceOrder struct {
+	OrderID     string         `json:"order_id"`
+	Items       []models.Item  `json:"items"`
+	Total       models.Money   `json:"total"`
+	Address     models.Address `json:"address"`
+	Reservation string         `json:"reservation,omitempty"`
+	ChargeID    string         `json:"charge_id,omitempty"`
+	Tracking    string         `json:"tracking,omitempty"`
+}
+
+// Services are the services an order goes through.
+type Services struct {
+	Inventory *inventory.Service
+	Payment   *payment.Service
+	Shipping  *shipping.Service
+}
+
+// NewOrderSaga reserves the stock, charges the customer and books the
+// shipment. Booking is last and has no Undo: once it succeeds, the order is
+// placed. Steps that can fail for business reasons, such as a declined card,
+// come before the ones that are hard to undo.
+func NewOrderSaga(s Services, log saga.Log) *saga.Coordinator[PlaceOrder] {
+	return &saga.Coordinator[PlaceOrder]{
+		Name: "place-order",
+		Log:  log,
+		Steps: []saga.Step[PlaceOrder]{
+			{
+				Name: "reserve-stock",
+				Do: func(_ context.Context, o *PlaceOrder) (err error) {
+					o.Reservation, err = s.Inventory.Reserve(o.OrderID, o.Items)
+					return err
+				},
+				Undo: func(_ context.Context, o *PlaceOrder) error {
+					return s.Inventory.Release(o.OrderID)
+				},
+			},
+			{
+				Name: "charge-payment",
+				Do: func(_ context.Context, o *PlaceOrder) (err error) {
+					o.ChargeID, err = s.Payment.Charge(o.OrderID, o.Total)
+					return err
+				},
+				Undo: func(_ context.Context, o *PlaceOrder) error {
+					return s.Payment.Refund(o.OrderID)
+				},
+			},
+			{
+				Name: "book-shipment",
+				Do: func(_ context.Context, o *PlaceOrder) (err error) {
+					o.Tracking, err = s.Shipping.Book(o.OrderID, o.Address)
+					return err
+				},
+			},
+		},
+	}
+}
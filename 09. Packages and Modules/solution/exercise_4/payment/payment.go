@@ -0,0 +1,86 @@
+// Package payment is the payment service: it charges orders, and refunds
+// them.
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang-training/module-09/exercise-2/models"
+	"golang-training/module-09/exercise-4/faults"
+)
+
+// ErrDeclined is a business failure: retrying doesn't help.
+var ErrDeclined = errors.New("payment declined")
+
+// Charge is a payment of an order.
+type Charge struct {
+	ID       string
+	OrderID  string
+	Amount   models.Money
+	Refunded bool
+}
+
+// Service keeps the charges in memory. Charges above Limit are declined.
+type Service struct {
+	Faults *faults.Injector
+	Limit  models.Money
+
+	mu      sync.Mutex
+	charges map[string]*Charge // By order ID: one charge per order
+}
+
+func New(limit models.Money) *Service {
+	return &Service{Limit: limit, charges: make(map[string]*Charge)}
+}
+
+// Charge takes the payment of an order. The order ID is the idempotency
+// key: charging the same order again returns the first charge.
+func (s *Service) Charge(orderID string, amount models.Money) (string, error) {
+	if err := s.Faults.Check("payment", "charge"); err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if charge, ok := s.charges[orderID]; ok {
+		return charge.ID, nil
+	}
+	if cmp, err := amount.Compare(s.Limit); err != nil || cmp > 0 {
+		return "", fmt.Errorf("%s above the limit of %s: %w", amount, s.Limit, ErrDeclined)
+	}
+	charge := &Charge{ID: "ch-" + orderID, OrderID: orderID, Amount: amount}
+	s.charges[orderID] = charge
+	return charge.ID, nil
+}
+
+// Refund gives the payment of an order back. Refunding twice does nothing.
+func (s *Service) Refund(orderID string) error {
+	if err := s.Faults.Check("payment", "refund"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if charge, ok := s.charges[orderID]; ok {
+		charge.Refunded = true
+	}
+	return nil
+}
+
+// Captured returns the money kept: the charges not refunded.
+func (s *Service) Captured() (total models.Money, charges, refunds int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, charge := range s.charges {
+		charges++
+		if charge.Refunded {
+			refunds++
+			continue
+		}
+		total, _ = total.Add(charge.Amount)
+	}
+	return total, charges, refunds
+}
@@ -0,0 +1,128 @@
+package saga
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// EntryType is what happened to a saga.
+type EntryType string
+
+const (
+	SagaStarted        EntryType = "saga_started"
+	StepStarted        EntryType = "step_started"
+	StepCompleted      EntryType = "step_completed"
+	StepFailed         EntryType = "step_failed"
+	StepCompensated    EntryType = "step_compensated"
+	CompensationFailed EntryType = "compensation_failed"
+	SagaCompleted      EntryType = "saga_completed"
+	SagaCompensated    EntryType = "saga_compensated"
+)
+
+// Entry is one line of the state log. State is the saga state after the
+// entry, so the last entry of a saga is enough to resume it.
+type Entry struct {
+	SagaID string          `json:"saga_id"`
+	Saga   string          `json:"saga"`
+	Type   EntryType       `json:"type"`
+	Step   string          `json:"step,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	State  json.RawMessage `json:"state,omitempty"`
+	At     time.Time       `json:"at"`
+}
+
+// Log stores the entries of every saga. Append must not return before the
+// entry is durable: the coordinator acts only after its intent is logged.
+type Log interface {
+	Append(e Entry) error
+	Entries() ([]Entry, error)
+}
+
+// MemoryLog is a Log for tests and demos. Nothing survives the process.
+type MemoryLog struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func (l *MemoryLog) Append(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, e)
+	return nil
+}
+
+func (l *MemoryLog) Entries() ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Entry(nil), l.entries...), nil
+}
+
+// FileLog appends the entries to a file as JSON lines, and syncs the file
+// after each one.
+type FileLog struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// OpenFileLog opens or creates the log at path.
+func OpenFileLog(path string) (*FileLog, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileLog{path: path, file: f}, nil
+}
+
+func (l *FileLog) Append(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return l.file.Sync()
+}
+
+// Entries reads the log from the start. A last line cut by a crash is
+// ignored: its action was never started.
+func (l *FileLog) Entries() ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	r := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		data, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return entries, nil // Without a newline, the line is incomplete
+		}
+		if err != nil {
+			return nil, err
+		}
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", l.path, line, err)
+		}
+		entries = append(entries, e)
+	}
+}
+
+func (l *FileLog) Close() error {
+	return l.file.Close()
+}
@@ -0,0 +1,220 @@
+// Package saga runs a sequence of steps on several services, without a
+// transaction spanning them. When a step fails, the steps already done are
+// undone by compensating actions, in reverse order. Every change of state is
+// written to a log first, so a saga interrupted by a crash is resumed by
+// Recover.
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrStuck means a compensation kept failing: the saga is left for Recover,
+// or for a person, to finish.
+var ErrStuck = errors.New("saga stuck: compensation failed")
+
+// Step is an action on a service and the action undoing it. Both may run
+// more than once, after a crash or a retry, so both must be idempotent.
+// Undo is nil for a step with nothing to undo, such as the last one.
+type Step[T any] struct {
+	Name string
+	Do   func(ctx context.Context, state *T) error
+	Undo func(ctx context.Context, state *T) error
+}
+
+// Error is returned by Run when a step failed. The saga was compensated
+// unless Cause wraps ErrStuck.
+type Error struct {
+	SagaID string
+	Step   string
+	Cause  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("saga %s: step %s: %v", e.SagaID, e.Step, e.Cause)
+}
+
+func (e *Error) Unwrap() error { return e.Cause }
+
+// Coordinator runs the sagas of one kind. T is the state the steps share,
+// such as the order and the IDs returned by the services; it is stored in
+// the log as JSON.
+type Coordinator[T any] struct {
+	Name  string
+	Steps []Step[T]
+	Log   Log
+
+	// CompensationAttempts is the number of times an Undo is tried before
+	// the saga is stuck, 3 when zero. Backoff is the wait after the first
+	// failure, doubled after each one.
+	CompensationAttempts int
+	Backoff              time.Duration
+
+	// Trace, when set, is called with every entry logged
+	Trace func(Entry)
+}
+
+// Run starts a saga and runs it to the end: completed, compensated, or
+// stuck. An error of the log stops it at once, as a crash would.
+func (c *Coordinator[T]) Run(ctx context.Context, sagaID string, state *T) error {
+	if err := c.append(sagaID, SagaStarted, "", nil, state); err != nil {
+		return err
+	}
+	return c.forward(ctx, sagaID, state, 0)
+}
+
+// forward runs the steps from the index from, then compensates them if one
+// fails.
+func (c *Coordinator[T]) forward(ctx context.Context, sagaID string, state *T, from int) error {
+	for i := from; i < len(c.Steps); i++ {
+		step := c.Steps[i]
+		if err := c.append(sagaID, StepStarted, step.Name, nil, state); err != nil {
+			return err
+		}
+		if err := step.Do(ctx, state); err != nil {
+			if logErr := c.append(sagaID, StepFailed, step.Name, err, state); logErr != nil {
+				return logErr
+			}
+			return c.compensate(ctx, sagaID, state, i-1, nil, &Error{SagaID: sagaID, Step: step.Name, Cause: err})
+		}
+		if err := c.append(sagaID, StepCompleted, step.Name, nil, state); err != nil {
+			return err
+		}
+	}
+	return c.append(sagaID, SagaCompleted, "", nil, state)
+}
+
+// compensate undoes the steps from the index last down to the first,
+// skipping the ones already compensated, and returns failure.
+func (c *Coordinator[T]) compensate(ctx context.Context, sagaID string, state *T, last int, done map[string]bool, failure *Error) error {
+	attempts := c.CompensationAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	for i := last; i >= 0; i-- {
+		step := c.Steps[i]
+		if step.Undo == nil || done[step.Name] {
+			continue
+		}
+		var err error
+		delay := c.Backoff
+		for attempt := 1; attempt <= attempts; attempt++ {
+			if err = step.Undo(ctx, state); err == nil {
+				break
+			}
+			if attempt < attempts {
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				delay *= 2
+			}
+		}
+		if err != nil {
+			if logErr := c.append(sagaID, CompensationFailed, step.Name, err, state); logErr != nil {
+				return logErr
+			}
+			failure.Cause = fmt.Errorf("%w: undo %s: %v (after: %v)", ErrStuck, step.Name, err, failure.Cause)
+			return failure
+		}
+		if err := c.append(sagaID, StepCompensated, step.Name, nil, state); err != nil {
+			return err
+		}
+	}
+	if err := c.append(sagaID, SagaCompensated, "", nil, state); err != nil {
+		return err
+	}
+	return failure
+}
+
+func (c *Coordinator[T]) append(sagaID string, typ EntryType, step string, cause error, state *T) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("saga %s: encode state: %w", sagaID, err)
+	}
+	e := Entry{SagaID: sagaID, Saga: c.Name, Type: typ, Step: step, State: data, At: time.Now().UTC()}
+	if cause != nil {
+		e.Error = cause.Error()
+	}
+	if err := c.Log.Append(e); err != nil {
+		return fmt.Errorf("saga %s: log %s: %w", sagaID, typ, err)
+	}
+	if c.Trace != nil {
+		c.Trace(e)
+	}
+	return nil
+}
+
+// progress is a saga rebuilt from its entries.
+type progress struct {
+	state       json.RawMessage
+	completed   int // Steps completed, which run in order
+	failed      string
+	cause       string
+	compensated map[string]bool
+	finished    bool
+}
+
+// Recover resumes the sagas of this coordinator left unfinished in the log,
+// in the order they started. A saga going forward runs its next step again,
+// since the crash may have come before or after the service did it. A saga
+// compensating, or stuck, compensates the steps left.
+func (c *Coordinator[T]) Recover(ctx context.Context) (map[string]error, error) {
+	entries, err := c.Log.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	sagas := make(map[string]*progress)
+	var order []string
+	for _, e := range entries {
+		if e.Saga != c.Name {
+			continue
+		}
+		p, ok := sagas[e.SagaID]
+		if !ok {
+			p = &progress{compensated: make(map[string]bool)}
+			sagas[e.SagaID] = p
+			order = append(order, e.SagaID)
+		}
+		p.state = e.State
+		switch e.Type {
+		case StepCompleted:
+			p.completed++
+		case StepFailed:
+			p.failed, p.cause = e.Step, e.Error
+		case CompensationFailed:
+			if p.failed == "" {
+				p.failed, p.cause = e.Step, e.Error
+			}
+		case StepCompensated:
+			p.compensated[e.Step] = true
+		case SagaCompleted, SagaCompensated:
+			p.finished = true
+		}
+	}
+
+	results := make(map[string]error)
+	for _, id := range order {
+		p := sagas[id]
+		if p.finished {
+			continue
+		}
+		state := new(T)
+		if err := json.Unmarshal(p.state, state); err != nil {
+			return results, fmt.Errorf("saga %s: decode state: %w", id, err)
+		}
+		if p.failed == "" {
+			results[id] = c.forward(ctx, id, state, p.completed)
+			continue
+		}
+		failure := &Error{SagaID: id, Step: p.failed, Cause: errors.New(p.cause)}
+		results[id] = c.compensate(ctx, id, state, p.completed-1, p.compensated, failure)
+	}
+	return results, nil
+}
@@ -0,0 +1,59 @@
+// Package shipping is the shipping service: it books a carrier for an
+// order.
+package shipping
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang-training/module-09/exercise-2/models"
+	"golang-training/module-09/exercise-4/faults"
+)
+
+// ErrUnserviceable is a business failure: the carrier doesn't ship there.
+var ErrUnserviceable = errors.New("no carrier for the address")
+
+// Service books shipments. Countries lists where carriers ship.
+type Service struct {
+	Faults    *faults.Injector
+	Countries map[string]bool
+
+	mu        sync.Mutex
+	shipments map[string]string // Tracking number by order ID
+}
+
+func New(countries ...string) *Service {
+	s := &Service{Countries: make(map[string]bool), shipments: make(map[string]string)}
+	for _, c := range countries {
+		s.Countries[c] = true
+	}
+	return s
+}
+
+// Book creates the shipment of an order and returns its tracking number.
+// Booking the same order again returns the first shipment.
+func (s *Service) Book(orderID string, to models.Address) (string, error) {
+	if err := s.Faults.Check("shipping", "book"); err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tracking, ok := s.shipments[orderID]; ok {
+		return tracking, nil
+	}
+	if !s.Countries[to.Country] {
+		return "", fmt.Errorf("country %q: %w", to.Country, ErrUnserviceable)
+	}
+	tracking := fmt.Sprintf("TRK-%s-%s", to.Country, orderID)
+	s.shipments[orderID] = tracking
+	return tracking, nil
+}
+
+// Shipments returns the number of shipments booked.
+func (s *Service) Shipments() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.shipments)
+}
@@ -0,0 +1,55 @@
+package events
+
+import "sync"
+
+// Bus delivers the events to the subscribers asynchronously, through a
+// buffered channel each. Publish never waits: when a buffer is full, the
+// event is dropped for that subscriber, which finds the gap in the sequence
+// numbers and reads the missing events from the log.
+type Bus struct {
+	mu      sync.RWMutex
+	subs    []chan Event
+	dropped int
+}
+
+// Subscribe returns a channel receiving the events published from now on.
+func (b *Bus) Subscribe(buffer int) <-chan Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, buffer)
+	b.subs = append(b.subs, ch)
+	return ch
+}
+
+// Publish sends an event to every subscriber with room for it.
+func (b *Bus) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			b.dropped++
+		}
+	}
+}
+
+// Dropped returns the number of events dropped because of full buffers.
+func (b *Bus) Dropped() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.dropped
+}
+
+// Close closes the channels of the subscribers.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, ch := range b.subs {
+		close(ch)
+	}
+	b.subs = nil
+}
@@ -0,0 +1,42 @@
+// Package events carries the changes of the orders from the write side to
+// the read models: an append-only log, the source of truth, and a bus
+// delivering the new events as they are written.
+package events
+
+import (
+	"time"
+
+	"golang-training/module-09/exercise-2/models"
+)
+
+// Event is a status change of an order. It carries the customer and the
+// total, so a read model never has to ask the write side.
+type Event struct {
+	Seq        uint64             `json:"seq"` // Position in the log, from 1
+	Type       string             `json:"type"`
+	OrderID    string             `json:"order_id"`
+	CustomerID string             `json:"customer_id"`
+	From       models.OrderStatus `json:"from,omitempty"`
+	To         models.OrderStatus `json:"to"`
+	Total      models.Money       `json:"total"`
+	Items      int                `json:"items"`
+	At         time.Time          `json:"at"`
+}
+
+// FromChange builds the event of the last change of an order.
+func FromChange(order *models.Order, change models.StatusChange) Event {
+	items := 0
+	for _, item := range order.Items {
+		items += item.Quantity
+	}
+	return Event{
+		Type:       change.EventName(),
+		OrderID:    order.OrderID,
+		CustomerID: order.CustomerID,
+		From:       change.From,
+		To:         change.To,
+		Total:      order.TotalAmount,
+		Items:      items,
+		At:         change.At.UTC(),
+	}
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Log appends the events to a file as JSON lines and numbers them.
+type Log struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	last uint64
+}
+
+// OpenLog opens or creates the log at path, and finds its last event.
+func OpenLog(path string) (*Log, error) {
+	l := &Log{path: path}
+	if err := l.ReadFrom(1, func(e Event) error {
+		l.last = e.Seq
+		return nil
+	}); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	l.file = f
+	return l, nil
+}
+
+// Append numbers the event and writes it. It returns the event with its
+// sequence number.
+func (l *Log) Append(e Event) (Event, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e.Seq = l.last + 1
+	data, err := json.Marshal(e)
+	if err != nil {
+		return e, err
+	}
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return e, err
+	}
+	if err := l.file.Sync(); err != nil {
+		return e, err
+	}
+	l.last = e.Seq
+	return e, nil
+}
+
+// Last returns the sequence number of the last event written.
+func (l *Log) Last() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.last
+}
+
+// ReadFrom calls fn with every event from the sequence number from, in
+// order, and stops at the first error.
+func (l *Log) ReadFrom(from uint64, fn func(Event) error) error {
+	f, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("%s:%d: %w", l.path, line, err)
+		}
+		if e.Seq < from {
+			continue
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (l *Log) Close() error {
+	return l.file.Close()
+}
@@ -0,0 +1,7 @@
+module golang-training/module-09/exercise-5
+
+go 1.25
+
+require golang-training/module-09/exercise-2 v0.0.0
+
+replace golang-training/module-09/exercise-2 => ../exercise_2
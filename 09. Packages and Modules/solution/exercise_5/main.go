@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"golang-training/module-09/exercise-2/models"
+	"golang-training/module-09/exercise-5/events"
+	"golang-training/module-09/exercise-5/orders"
+	"golang-training/module-09/exercise-5/readmodel"
+)
+
+func usd(amount string) models.Money {
+	return models.MustParseMoney(amount, "USD")
+}
+
+func printSummary(s readmodel.CustomerSummary, position uint64) {
+	fmt.Printf("  %s at event %d: %d orders, %d open, spent %s, refunded %s\n",
+		s.CustomerID, position, s.Orders, s.Open, s.Spent, s.Refunded)
+	for _, line := range s.Recent {
+		fmt.Printf("    %s %-9s %3d items %10s\n", line.OrderID, line.Status, line.Items, line.Total)
+	}
+}
+
+// rebuild is the command recomputing the read model from the log, such as
+// after changing the summaries: go run . rebuild -log orders.events
+func rebuild(args []string) error {
+	fs := flag.NewFlagSet("rebuild", flag.ExitOnError)
+	path := fs.String("log", filepath.Join(os.TempDir(), "orders.events"), "event log to read")
+	fs.Parse(args)
+
+	if _, err := os.Stat(*path); err != nil {
+		return err
+	}
+	eventLog, err := events.OpenLog(*path)
+	if err != nil {
+		return err
+	}
+	defer eventLog.Close()
+
+	start := time.Now()
+	p, err := readmodel.Rebuild(eventLog)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Rebuilt %d summaries from %d events in %v\n", len(p.Customers()), p.Position(), time.Since(start).Round(time.Microsecond))
+	for _, id := range p.Customers() {
+		summary, position, _ := p.Get(id)
+		printSummary(summary, position)
+	}
+	return nil
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "rebuild" {
+		if err := rebuild(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	path := flag.String("log", filepath.Join(os.TempDir(), "orders.events"), "event log, emptied at start")
+	flag.Parse()
+	os.Remove(*path)
+	eventLog, err := events.OpenLog(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer eventLog.Close()
+
+	bus := &events.Bus{}
+	service := orders.NewService(eventLog, bus)
+	projection := readmodel.NewProjection()
+	projection.Delay = 20 * time.Millisecond
+	projection.Poll = 100 * time.Millisecond
+	// Subscribed before the first write, so it receives every event
+	subscription := bus.Subscribe(4)
+	done := make(chan error)
+	go func() {
+		done <- projection.Run(subscription, eventLog, func(from, to uint64) {
+			fmt.Printf("  [read model] read events %d to %d from the log\n", from, to)
+		})
+	}()
+	ctx := context.Background()
+
+	fmt.Println("=== Reading right after writing ===")
+	id, seq, err := service.Place("alice", []models.Item{{ProductID: "P001", Quantity: 1}}, usd("1200.00"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	summary, position, found := projection.Get("alice")
+	fmt.Printf("  placed %s as event %d, read at once: found %v, at event %d\n", id, seq, found, position)
+
+	// Reading its own write: wait for the event of the write, with a limit
+	waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+	err = projection.WaitFor(waitCtx, seq)
+	cancel()
+	if err != nil {
+		log.Fatal(err)
+	}
+	summary, position, _ = projection.Get("alice")
+	printSummary(summary, position)
+
+	// A read that can't wait long gets the summary it can, and knows it is
+	// behind
+	seq, _ = service.Transition(id, models.StatusPaid, "payment ch_1")
+	waitCtx, cancel = context.WithTimeout(ctx, 5*time.Millisecond)
+	err = projection.WaitFor(waitCtx, seq)
+	cancel()
+	summary, position, _ = projection.Get("alice")
+	fmt.Printf("  paid as event %d, short wait: %v\n", seq, err)
+	fmt.Printf("  stale read: spent %s at event %d, %d behind\n", summary.Spent, position, seq-position)
+
+	fmt.Println("\n=== A burst of writes, faster than the read model ===")
+	placed := map[string][]string{}
+	for i := range 12 {
+		customer := []string{"alice", "bob", "chloe"}[i%3]
+		id, _, err := service.Place(customer, []models.Item{{ProductID: "P003", Quantity: i%4 + 1}}, usd("50.00").Mul(int64(i%4+1)))
+		if err != nil {
+			log.Fatal(err)
+		}
+		placed[customer] = append(placed[customer], id)
+	}
+	for _, id := range placed["bob"][:2] {
+		service.Transition(id, models.StatusPaid, "payment")
+	}
+	service.Transition(placed["bob"][0], models.StatusRefunded, "damaged")
+	service.Transition(placed["chloe"][0], models.StatusCancelled, "changed my mind")
+	_, err = service.Transition(placed["chloe"][0], models.StatusPaid, "too late")
+	fmt.Println("  rejected by the write side:", err)
+
+	last := eventLog.Last()
+	fmt.Printf("  %d events written, %d dropped by the bus, read model at event %d\n", last, bus.Dropped(), projection.Position())
+	if err := projection.WaitFor(ctx, last); err != nil {
+		log.Fatal(err)
+	}
+	for _, customer := range projection.Customers() {
+		summary, position, _ := projection.Get(customer)
+		printSummary(summary, position)
+	}
+
+	fmt.Println("\n=== The same event delivered twice ===")
+	eventLog.ReadFrom(last, func(e events.Event) error {
+		before, _, _ := projection.Get(e.CustomerID)
+		projection.Apply(e)
+		after, _, _ := projection.Get(e.CustomerID)
+		fmt.Printf("  event %d applied again: summary unchanged %v\n", e.Seq, reflect.DeepEqual(before, after))
+		return nil
+	})
+
+	fmt.Println("\n=== Rebuilding from the log ===")
+	bus.Close()
+	if err := <-done; err != nil {
+		log.Fatal(err)
+	}
+	rebuilt, err := readmodel.Rebuild(eventLog)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("  rebuilt at event %d, same summaries as the live read model: %v\n",
+		rebuilt.Position(), reflect.DeepEqual(rebuilt.All(), projection.All()))
+	fmt.Printf("  the log is kept at %s: go run . rebuild -log %s\n", *path, *path)
+}
@@ -0,0 +1,83 @@
+// Package orders is the write side: it validates the commands on the
+// orders, keeps the orders, and records their changes as events.
+package orders
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"golang-training/module-09/exercise-2/models"
+	"golang-training/module-09/exercise-5/events"
+)
+
+// Service changes the orders. It answers commands only: the questions of
+// the customers are answered by the read models.
+type Service struct {
+	mu     sync.Mutex
+	orders map[string]*models.Order
+	log    *events.Log
+	bus    *events.Bus
+	next   int
+}
+
+func NewService(log *events.Log, bus *events.Bus) *Service {
+	return &Service{orders: make(map[string]*models.Order), log: log, bus: bus}
+}
+
+// Place creates a Pending order. It returns the sequence number of its
+// event, which a client can wait for before reading.
+func (s *Service) Place(customerID string, items []models.Item, subtotal models.Money) (string, uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.next++
+	orderID := fmt.Sprintf("ORD-%04d", s.next)
+	order, err := models.NewOrder(orderID, customerID, items, subtotal, models.Shipping{Cost: models.NewMoney(0, subtotal.Currency)})
+	if err != nil {
+		return "", 0, err
+	}
+	seq, err := s.emit(order)
+	if err != nil {
+		return "", 0, err
+	}
+	s.orders[orderID] = order
+	return orderID, seq, nil
+}
+
+// Transition changes the status of an order, such as paying or refunding
+// it.
+func (s *Service) Transition(orderID string, next models.OrderStatus, reason string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.orders[orderID]
+	if !ok {
+		return 0, fmt.Errorf("order %s not found", orderID)
+	}
+	// The change is undone when its event can't be written: an order must
+	// not differ from its events
+	before := *order
+	before.History = slices.Clone(order.History)
+	if err := order.TransitionTo(next, reason); err != nil {
+		return 0, err
+	}
+	seq, err := s.emit(order)
+	if err != nil {
+		*order = before
+	}
+	return seq, err
+}
+
+// emit writes the last change of the order to the log, then publishes it.
+// The log is written first: an event on the bus is always in the log, so a
+// read model rebuilt from the log sees it too.
+func (s *Service) emit(order *models.Order) (uint64, error) {
+	change := order.History[len(order.History)-1]
+	e, err := s.log.Append(events.FromChange(order, change))
+	if err != nil {
+		return 0, fmt.Errorf("order %s: %w", order.OrderID, err)
+	}
+	s.bus.Publish(e)
+	return e.Seq, nil
+}
@@ -0,0 +1,276 @@
+// Package readmodel answers the questions about the orders of a customer
+// from summaries computed in advance, instead of from the orders
+// themselves. The summaries are updated from the events, after the writes:
+// they can be behind, and say how far they are.
+package readmodel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"golang-training/module-09/exercise-2/models"
+	"golang-training/module-09/exercise-5/events"
+)
+
+// recentOrders is the number of orders kept in a summary.
+const recentOrders = 5
+
+// OrderLine is an order as the summary of its customer shows it.
+type OrderLine struct {
+	OrderID  string
+	Status   models.OrderStatus
+	Total    models.Money
+	Items    int
+	PlacedAt time.Time
+}
+
+// CustomerSummary is everything the order page of a customer shows, in one
+// read.
+type CustomerSummary struct {
+	CustomerID  string
+	Orders      int
+	Open        int          // Not delivered, cancelled or refunded yet
+	Spent       models.Money // Paid and not refunded
+	Refunded    models.Money
+	LastOrderAt time.Time
+	Recent      []OrderLine // Newest first
+}
+
+// Projection keeps the summaries of every customer up to date with the
+// events. Position is the sequence number of the last event applied.
+type Projection struct {
+	mu        sync.RWMutex
+	summaries map[string]*CustomerSummary
+	orders    map[string]OrderLine // By order ID, to find the line to update
+	position  uint64
+	changed   chan struct{} // Closed and replaced when the position moves
+
+	// Delay is added before applying each event, to see the read model lag
+	Delay time.Duration
+
+	// Poll is how often Run checks the log while no event arrives, 1s when
+	// zero
+	Poll time.Duration
+}
+
+func NewProjection() *Projection {
+	return &Projection{
+		summaries: make(map[string]*CustomerSummary),
+		orders:    make(map[string]OrderLine),
+		changed:   make(chan struct{}),
+	}
+}
+
+// Apply updates the summaries with an event. Events at or before the
+// position were already applied and are ignored, so an event delivered
+// twice is counted once.
+func (p *Projection) Apply(e events.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if e.Seq <= p.position {
+		return nil
+	}
+	if e.Seq != p.position+1 {
+		return &GapError{Position: p.position, Seq: e.Seq}
+	}
+
+	s, ok := p.summaries[e.CustomerID]
+	if !ok {
+		zero := models.NewMoney(0, e.Total.Currency)
+		s = &CustomerSummary{CustomerID: e.CustomerID, Spent: zero, Refunded: zero}
+		p.summaries[e.CustomerID] = s
+	}
+	line := p.orders[e.OrderID]
+	switch e.To {
+	case models.StatusPending:
+		line = OrderLine{OrderID: e.OrderID, Total: e.Total, Items: e.Items, PlacedAt: e.At}
+		s.Orders++
+		s.Open++
+		s.LastOrderAt = e.At
+	case models.StatusPaid:
+		s.Spent = add(s.Spent, e.Total)
+	case models.StatusRefunded:
+		s.Spent = add(s.Spent, e.Total.Mul(-1))
+		s.Refunded = add(s.Refunded, e.Total)
+	}
+	if e.From != "" && isOpen(e.From) && !isOpen(e.To) {
+		s.Open--
+	}
+	line.Status = e.To
+	p.orders[e.OrderID] = line
+	s.Recent = recent(s.Recent, line)
+
+	p.position = e.Seq
+	close(p.changed)
+	p.changed = make(chan struct{})
+	return nil
+}
+
+// isOpen reports whether an order in the status can still change for the
+// customer.
+func isOpen(s models.OrderStatus) bool {
+	return s == models.StatusPending || s == models.StatusPaid || s == models.StatusShipped
+}
+
+// add adds two amounts of the same currency. The events of a customer are
+// all in one currency; another one is a bug of the write side.
+func add(a, b models.Money) models.Money {
+	sum, err := a.Add(b)
+	if err != nil {
+		panic(err)
+	}
+	return sum
+}
+
+// recent puts the line first in the list, replacing its older version.
+func recent(lines []OrderLine, line OrderLine) []OrderLine {
+	lines = slices.DeleteFunc(lines, func(l OrderLine) bool { return l.OrderID == line.OrderID })
+	lines = append(lines, line)
+	slices.SortStableFunc(lines, func(a, b OrderLine) int { return b.PlacedAt.Compare(a.PlacedAt) })
+	return lines[:min(len(lines), recentOrders)]
+}
+
+// GapError means events were missed: they must be read from the log
+// before the next ones are applied.
+type GapError struct {
+	Position, Seq uint64
+}
+
+func (e *GapError) Error() string {
+	return fmt.Sprintf("missed events %d to %d", e.Position+1, e.Seq-1)
+}
+
+// Get returns the summary of a customer, and the position it reflects.
+func (p *Projection) Get(customerID string) (CustomerSummary, uint64, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	s, ok := p.summaries[customerID]
+	if !ok {
+		return CustomerSummary{CustomerID: customerID}, p.position, false
+	}
+	summary := *s
+	summary.Recent = slices.Clone(s.Recent)
+	return summary, p.position, true
+}
+
+// All returns every summary, by customer ID.
+func (p *Projection) All() map[string]CustomerSummary {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	all := make(map[string]CustomerSummary, len(p.summaries))
+	for id, s := range p.summaries {
+		summary := *s
+		summary.Recent = slices.Clone(s.Recent)
+		all[id] = summary
+	}
+	return all
+}
+
+// Customers returns the IDs of the customers, sorted.
+func (p *Projection) Customers() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return slices.Sorted(maps.Keys(p.summaries))
+}
+
+// Position returns the sequence number of the last event applied.
+func (p *Projection) Position() uint64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.position
+}
+
+// WaitFor blocks until the events up to seq are applied. A client that just
+// wrote waits for the sequence number of its write, and reads its own
+// change. It returns the context error when the read model is still behind.
+func (p *Projection) WaitFor(ctx context.Context, seq uint64) error {
+	for {
+		p.mu.RLock()
+		position, changed := p.position, p.changed
+		p.mu.RUnlock()
+		if position >= seq {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return fmt.Errorf("read model at %d, waiting for %d: %w", position, seq, ctx.Err())
+		}
+	}
+}
+
+// Run applies the events of the channel until it is closed. A gap in the
+// sequence numbers, left by events the bus dropped, is filled from the log.
+// The last events of a burst may be dropped too, with no event after them
+// to show the gap: the log is also checked when the bus is quiet.
+// onCatchUp, when not nil, is called with the events read from the log.
+func (p *Projection) Run(ch <-chan events.Event, log *events.Log, onCatchUp func(from, to uint64)) error {
+	poll := p.Poll
+	if poll <= 0 {
+		poll = time.Second
+	}
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		var err error
+		caughtUp := false
+		from := p.Position() + 1
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if p.Delay > 0 {
+				time.Sleep(p.Delay)
+			}
+			var gap *GapError
+			if err = p.Apply(e); errors.As(err, &gap) {
+				err, caughtUp = p.CatchUp(log, e.Seq), true
+			}
+		case <-ticker.C:
+			if log.Last() > p.Position() {
+				err, caughtUp = p.CatchUp(log, log.Last()), true
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if caughtUp && onCatchUp != nil {
+			onCatchUp(from, p.Position())
+		}
+	}
+}
+
+// CatchUp applies the events of the log after the position, up to seq.
+func (p *Projection) CatchUp(log *events.Log, seq uint64) error {
+	errDone := errors.New("done")
+	err := log.ReadFrom(p.Position()+1, func(e events.Event) error {
+		if e.Seq > seq {
+			return errDone
+		}
+		return p.Apply(e)
+	})
+	if errors.Is(err, errDone) {
+		return nil
+	}
+	return err
+}
+
+// Rebuild computes a new projection from every event of the log, such as
+// after changing what the summaries hold, or losing them.
+func Rebuild(log *events.Log) (*Projection, error) {
+	p := NewProjection()
+	if err := log.ReadFrom(1, p.Apply); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
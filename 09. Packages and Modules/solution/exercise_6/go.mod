@@ -0,0 +1,9 @@
+module golang-training/module-09/exercise-6
+
+go 1.25
+
+require golang-training/module-09/exercise-2 v0.0.0
+
+require github.com/google/uuid v1.6.0 // indirect
+
+replace golang-training/module-09/exercise-2 => ../exercise_2
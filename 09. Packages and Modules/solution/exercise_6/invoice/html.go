@@ -0,0 +1,19 @@
+package invoice
+
+import (
+	"embed"
+	"html/template"
+	"io"
+)
+
+//go:embed templates/invoice.html
+var templateFS embed.FS
+
+// htmlTemplate escapes the data: a product named <script> stays text.
+var htmlTemplate = template.Must(template.ParseFS(templateFS, "templates/invoice.html"))
+
+// RenderHTML writes the invoice as a standalone HTML page, styled to be
+// printed on A4 paper.
+func RenderHTML(w io.Writer, inv *Invoice) error {
+	return htmlTemplate.Execute(w, inv)
+}
@@ -0,0 +1,115 @@
+// Package invoice issues the invoices of paid orders, and renders them as
+// PDF and as HTML.
+package invoice
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang-training/module-09/exercise-2/models"
+)
+
+// Errors returned by New.
+var (
+	ErrNotPaid        = errors.New("order not paid")
+	ErrTotalsMismatch = errors.New("invoice lines don't add up to the order subtotal")
+	ErrUnknownProduct = errors.New("unknown product")
+)
+
+// Company is the seller, printed in the header of every invoice.
+type Company struct {
+	Name    string
+	Address []string // Lines of the postal address
+	Email   string
+	TaxID   string
+}
+
+// Line is a line of an invoice.
+type Line struct {
+	Description string
+	Quantity    int
+	UnitPrice   models.Money
+	Amount      models.Money
+}
+
+// Invoice is the data of an issued invoice. Once issued, an invoice never
+// changes: a mistake is corrected by a credit note, not by editing it.
+type Invoice struct {
+	Number         string // Such as INV-2026-0001, without gaps
+	IssuedAt       time.Time
+	Company        Company
+	OrderID        string
+	OrderNumber    string // The first characters of the order ID, as in the emails
+	CustomerName   string
+	CustomerEmail  string
+	ShipTo         models.Address
+	Lines          []Line
+	Subtotal       models.Money
+	ShippingMethod string
+	Shipping       models.Money
+	Total          models.Money
+	PaidAt         time.Time
+	PaymentID      string
+}
+
+// New builds the invoice of an order. Only an order that was paid has an
+// invoice. The items of an order have no price: they are priced from the
+// products, and the invoice is refused if the lines don't add up to the
+// subtotal the customer paid, such as after a change of price.
+func New(number string, issuedAt time.Time, company Company, order *models.Order, customer *models.Customer, products map[string]models.Product) (*Invoice, error) {
+	var paidAt time.Time
+	for _, change := range order.History {
+		if change.To == models.StatusPaid {
+			paidAt = change.At
+		}
+	}
+	if paidAt.IsZero() {
+		return nil, fmt.Errorf("order %s is %s: %w", order.OrderID, order.Status, ErrNotPaid)
+	}
+
+	inv := &Invoice{
+		Number:         number,
+		IssuedAt:       issuedAt,
+		Company:        company,
+		OrderID:        order.OrderID,
+		OrderNumber:    strings.ToUpper(order.OrderID[:min(8, len(order.OrderID))]),
+		CustomerName:   customer.Name,
+		CustomerEmail:  customer.Email,
+		ShipTo:         order.Shipping.Address,
+		ShippingMethod: order.Shipping.Method,
+		Shipping:       order.Shipping.Cost,
+		Total:          order.TotalAmount,
+		PaidAt:         paidAt,
+		PaymentID:      order.PaymentID,
+	}
+	for _, item := range order.Items {
+		product, ok := products[item.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownProduct, item.ProductID)
+		}
+		line := Line{
+			Description: product.Name,
+			Quantity:    item.Quantity,
+			UnitPrice:   product.Price,
+			Amount:      product.Price.Mul(int64(item.Quantity)),
+		}
+		subtotal, err := inv.Subtotal.Add(line.Amount)
+		if err != nil {
+			return nil, err
+		}
+		inv.Subtotal = subtotal
+		inv.Lines = append(inv.Lines, line)
+	}
+	if inv.Subtotal != order.Subtotal {
+		return nil, fmt.Errorf("%w: %s, order %s", ErrTotalsMismatch, inv.Subtotal, order.Subtotal)
+	}
+	return inv, nil
+}
+
+// FileName returns the name of the invoice file with the extension ext,
+// such as INV-2026-0001.pdf.
+func (inv *Invoice) FileName(ext string) string {
+	return inv.Number + ext
+}
@@ -0,0 +1,127 @@
+package invoice
+
+import (
+	"fmt"
+	"io"
+)
+
+// Layout of the invoice, in points.
+const (
+	margin     = 50.0
+	rowHeight  = 18.0
+	tableTop   = 600.0 // Top of the table on the first page
+	tableFloor = 200.0 // Lowest row before the next page, leaving room for the totals
+)
+
+// Right edges of the table columns.
+var (
+	colQty    = 360.0
+	colPrice  = 460.0
+	colAmount = pageWidth - margin
+)
+
+// RenderPDF writes the invoice as an A4 PDF. Lines that don't fit on a
+// page continue on the next one, under the table header again.
+func RenderPDF(w io.Writer, inv *Invoice) error {
+	doc := &document{title: "Invoice " + inv.Number}
+	p := doc.newPage()
+	header(p, inv)
+	y := tableHeader(p, tableTop)
+
+	for _, line := range inv.Lines {
+		if y < tableFloor {
+			p = doc.newPage()
+			p.text(margin, pageHeight-margin-10, bold, 10, inv.Company.Name+" - invoice "+inv.Number+" (continued)")
+			y = tableHeader(p, pageHeight-margin-40)
+		}
+		p.text(margin+5, y, regular, 10, truncate(line.Description, colQty-60-margin))
+		p.textRight(colQty, y, regular, 10, fmt.Sprint(line.Quantity))
+		p.textRight(colPrice, y, regular, 10, line.UnitPrice.String())
+		p.textRight(colAmount, y, regular, 10, line.Amount.String())
+		p.line(margin, y-6, colAmount, y-6, 0.3)
+		y -= rowHeight
+	}
+	totals(p, inv, y-6)
+
+	for i, p := range doc.pages {
+		p.textRight(colAmount, 30, regular, 8, fmt.Sprintf("Page %d of %d", i+1, len(doc.pages)))
+	}
+	return doc.write(w, inv.IssuedAt)
+}
+
+// header writes the company, the invoice number and dates, and the
+// customer.
+func header(p *page, inv *Invoice) {
+	top := pageHeight - margin - 18
+	p.text(margin, top, bold, 18, inv.Company.Name)
+	y := top - 16
+	lines := append(append([]string{}, inv.Company.Address...), inv.Company.Email)
+	if inv.Company.TaxID != "" {
+		lines = append(lines, "Tax ID "+inv.Company.TaxID)
+	}
+	for _, line := range lines {
+		p.text(margin, y, regular, 9, line)
+		y -= 12
+	}
+
+	p.textRight(colAmount, top, bold, 24, "INVOICE")
+	p.textRight(colAmount, top-22, regular, 10, inv.Number)
+	p.textRight(colAmount, top-36, regular, 10, "Issued "+inv.IssuedAt.Format("January 2, 2006"))
+	p.textRight(colAmount, top-50, regular, 10, "Order "+inv.OrderNumber)
+	p.textRight(colAmount, top-66, bold, 12, "PAID")
+
+	y = 685
+	p.text(margin, y, bold, 8, "BILL TO")
+	p.text(margin, y-14, regular, 10, inv.CustomerName)
+	p.text(margin, y-28, regular, 10, inv.CustomerEmail)
+	p.text(300, y, bold, 8, "SHIP TO")
+	p.text(300, y-14, regular, 10, inv.ShipTo.Name)
+	p.text(300, y-28, regular, 10, inv.ShipTo.Street)
+	p.text(300, y-42, regular, 10, fmt.Sprintf("%s %s, %s", inv.ShipTo.PostalCode, inv.ShipTo.City, inv.ShipTo.Country))
+}
+
+// tableHeader draws the header row with its top at y, and returns the
+// baseline of the first row.
+func tableHeader(p *page, y float64) float64 {
+	p.fill(margin, y-rowHeight, colAmount-margin, rowHeight, 0.92)
+	base := y - 13
+	p.text(margin+5, base, bold, 10, "Description")
+	p.textRight(colQty, base, bold, 10, "Qty")
+	p.textRight(colPrice, base, bold, 10, "Unit price")
+	p.textRight(colAmount, base, bold, 10, "Amount")
+	return base - rowHeight
+}
+
+// totals writes the subtotal, the shipping and the total under the last
+// row, whose bottom line is at y, and the payment under them.
+func totals(p *page, inv *Invoice, y float64) {
+	y -= 16
+	p.textRight(colPrice, y, regular, 10, "Subtotal")
+	p.textRight(colAmount, y, regular, 10, inv.Subtotal.String())
+	y -= 16
+	p.textRight(colPrice, y, regular, 10, "Shipping ("+inv.ShippingMethod+")")
+	p.textRight(colAmount, y, regular, 10, inv.Shipping.String())
+	y -= 10
+	p.line(colQty, y, colAmount, y, 1)
+	y -= 16
+	p.textRight(colPrice, y, bold, 11, "Total")
+	p.textRight(colAmount, y, bold, 11, inv.Total.String())
+
+	paid := "Paid on " + inv.PaidAt.Format("January 2, 2006")
+	if inv.PaymentID != "" {
+		paid += ", payment " + inv.PaymentID
+	}
+	p.text(margin, y-40, regular, 9, paid+". Thank you for your order.")
+}
+
+// truncate shortens s with an ellipsis to fit in width points.
+func truncate(s string, width float64) string {
+	if textWidth(regular, 10, s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && textWidth(regular, 10, string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
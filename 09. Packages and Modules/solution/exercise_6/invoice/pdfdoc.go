@@ -0,0 +1,182 @@
+package invoice
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// document is a minimal PDF writer: pages of text and lines in the standard
+// Helvetica fonts, which every PDF reader has, so no font is embedded.
+//
+// A PDF file is a list of numbered objects, a cross-reference table giving
+// the byte offset of each object, and a trailer pointing to the root object
+// and to the table.
+type document struct {
+	title string
+	pages []*page
+}
+
+// page is the content stream of a page: drawing operators in the PDF
+// language, in points (1/72 inch) from the bottom left corner
+type page struct {
+	content bytes.Buffer
+}
+
+// A4 in points
+const (
+	pageWidth  = 595.0
+	pageHeight = 842.0
+)
+
+// Font resource names of the pages.
+const (
+	regular = "F1"
+	bold    = "F2"
+)
+
+func (d *document) newPage() *page {
+	p := &page{}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// text writes s with its baseline starting at x, y.
+func (p *page) text(x, y float64, font string, size float64, s string) {
+	fmt.Fprintf(&p.content, "BT /%s %s Tf %s %s Td (%s) Tj ET\n", font, num(size), num(x), num(y), escape(s))
+}
+
+// textRight writes s ending at x.
+func (p *page) textRight(x, y float64, font string, size float64, s string) {
+	p.text(x-textWidth(font, size, s), y, font, size, s)
+}
+
+// line draws a line of width w.
+func (p *page) line(x1, y1, x2, y2, w float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n", num(w), num(x1), num(y1), num(x2), num(y2))
+}
+
+// fill paints a gray rectangle, 0 being black and 1 white.
+func (p *page) fill(x, y, w, h, gray float64) {
+	fmt.Fprintf(&p.content, "q %s g %s %s %s %s re f Q\n", num(gray), num(x), num(y), num(w), num(h))
+}
+
+// write writes the document. Object 1 is the catalog, 2 the page tree, 3
+// and 4 the fonts, 5 the document information, then each page and its
+// content stream.
+func (d *document) write(w io.Writer, created time.Time) error {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// The comment of high bytes tells tools the file is binary
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (golang-training) /CreationDate (D:%s) >>",
+		escape(d.title), created.UTC().Format("20060102150405Z")))
+
+	for i, p := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] "+
+			"/Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			num(pageWidth), num(pageHeight), regular, bold, 7+2*i))
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		zw.Write(p.content.Bytes())
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.Bytes()))
+	}
+
+	// Each entry of the table is exactly 20 bytes, end of line included
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// num formats a coordinate without needless decimals.
+func num(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// escape encodes s for a PDF string literal in WinAnsiEncoding: the
+// Latin-1 letters keep their byte, the euro sign is 0x80, other characters
+// outside the encoding become "?", and the delimiters are escaped.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 32 && r <= 126:
+			b.WriteRune(r)
+		case r == '€':
+			b.WriteByte(0x80)
+		case r >= 0xA0 && r <= 0xFF:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// textWidth measures s in points. The widths of the standard fonts are
+// published by Adobe, in thousandths of the font size.
+func textWidth(font string, size float64, s string) float64 {
+	widths := &helveticaWidths
+	if font == bold {
+		widths = &helveticaBoldWidths
+	}
+	total := 0
+	for _, r := range s {
+		if r >= 32 && r <= 126 {
+			total += widths[r-32]
+		} else {
+			total += 556 // Most Latin-1 letters, €, £ and ¥
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// Widths of the characters 32 (space) to 126 (~).
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
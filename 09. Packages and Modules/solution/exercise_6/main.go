@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang-training/module-09/exercise-2/cart"
+	"golang-training/module-09/exercise-2/customers"
+	"golang-training/module-09/exercise-2/inventory"
+	"golang-training/module-09/exercise-2/models"
+	processor "golang-training/module-09/exercise-2/order"
+	"golang-training/module-09/exercise-2/payments"
+	"golang-training/module-09/exercise-2/shipping"
+	"golang-training/module-09/exercise-6/invoice"
+)
+
+func usd(amount string) models.Money {
+	return models.MustParseMoney(amount, "USD")
+}
+
+var company = invoice.Company{
+	Name:    "Go Shop Inc.",
+	Address: []string{"100 Gopher Way", "San Francisco, CA 94107", "United States"},
+	Email:   "billing@shop.example",
+	TaxID:   "US-12-3456789",
+}
+
+// catalog returns the products, with a range of cables for an order long
+// enough to take two pages.
+func catalog() map[string]models.Product {
+	products := map[string]models.Product{
+		"P001": {ID: "P001", Name: "Laptop Pro", Price: usd("1200.00"), Weight: 2.1},
+		"P002": {ID: "P002", Name: "Mechanical Keyboard", Price: usd("150.00"), Weight: 1.2},
+		"P003": {ID: "P003", Name: "Wireless Mouse", Price: usd("50.00"), Weight: 0.1},
+		"P004": {ID: "P004", Name: "USB-C Hub", Price: usd("75.00"), Weight: 0.2},
+		"P005": {ID: "P005", Name: "Screen Cleaning Kit (Microfiber Cloth & 200 ml Spray)", Price: usd("9.90"), Weight: 0.3},
+	}
+	for i := 1; i <= 30; i++ {
+		id := fmt.Sprintf("C%03d", i)
+		products[id] = models.Product{
+			ID:     id,
+			Name:   fmt.Sprintf("USB-C Cable, %d.%d m, braided", i/2, 5*(i%2)),
+			Price:  models.NewMoney(int64(599+50*i), "USD"),
+			Weight: 0.05,
+		}
+	}
+	return products
+}
+
+// seed places the orders of the demo: two paid orders, and one still
+// pending, which has no invoice yet.
+func seed(s *shop, registry *customers.Registry) (paid []*models.Order, pending *models.Order, err error) {
+	stock := map[string]int{"P001": 5, "P002": 10, "P003": 30, "P004": 8, "P005": 50}
+	cables := make(map[string]int)
+	for id := range s.products {
+		if strings.HasPrefix(id, "C") {
+			stock[id] = 100
+			cables[id] = 2
+		}
+	}
+	inventory.InitializeProducts(stock)
+	rate := shipping.WeightTiers{Tiers: []shipping.Tier{
+		{UpTo: 1, Price: usd("5.99")},
+		{UpTo: 5, Price: usd("12.99")},
+		{UpTo: 20, Price: usd("29.99")},
+	}}
+
+	alice, err := registry.Register("Alice Martin", "alice@example.com")
+	if err != nil {
+		return nil, nil, err
+	}
+	registry.AddAddress(alice.ID, models.Address{Name: "Alice Martin", Street: "1 Main St", City: "Springfield", PostalCode: "62701", Country: "US"}, true)
+	chloe, err := registry.Register("Chloé Lévesque", "chloe@example.com")
+	if err != nil {
+		return nil, nil, err
+	}
+	registry.AddAddress(chloe.ID, models.Address{Name: "Chloé Lévesque", Street: "20 King St", City: "Toronto", PostalCode: "M5H 2N2", Country: "CA"}, true)
+
+	gateway := payments.NewAlwaysSucceed()
+	carts := []struct {
+		customer *models.Customer
+		items    map[string]int
+	}{
+		{alice, map[string]int{"P001": 1, "P002": 2, "P003": 3}},
+		{chloe, map[string]int{"P003": 20, "P004": 1, "P005": 24}},
+		// Enough lines for a second page
+		{chloe, cables},
+	}
+	for _, c := range carts {
+		shopping := cart.NewCart()
+		for id, quantity := range c.items {
+			shopping.AddItem(id, quantity)
+		}
+		order, err := processor.ProcessOrder(c.customer, shopping, s.products, processor.Delivery{Rate: rate}, gateway)
+		if err != nil {
+			return nil, nil, err
+		}
+		s.add(order)
+		paid = append(paid, order)
+	}
+
+	// Placed but not paid yet
+	items := []models.Item{{ProductID: "P004", Quantity: 2}}
+	address, _ := alice.DefaultAddress()
+	quote, err := shipping.Quote(rate, address, usd("150.00"), 0.4, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	if pending, err = models.NewOrder("pending-0001", alice.ID, items, usd("150.00"), quote); err != nil {
+		return nil, nil, err
+	}
+	s.add(pending)
+	return paid, pending, nil
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	dir := flag.String("dir", "invoices", "directory of the invoice files")
+	demo := flag.Bool("demo", false, "request the invoices from a test server, then exit")
+	flag.Parse()
+
+	if *demo {
+		// The files of the demo don't outlive it
+		tmp, err := os.MkdirTemp("", "invoices")
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer os.RemoveAll(tmp)
+		*dir = tmp
+	}
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		log.Fatal(err)
+	}
+
+	registry := customers.NewRegistry()
+	s := &shop{
+		company:   company,
+		products:  catalog(),
+		customers: registry.Get,
+		dir:       *dir,
+		now:       time.Now,
+		orders:    make(map[string]*models.Order),
+		invoices:  make(map[string]*invoice.Invoice),
+	}
+	paid, pending, err := seed(s, registry)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *demo {
+		if err := runDemo(s, paid, pending); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	for _, order := range paid {
+		fmt.Printf("Paid:    http://localhost%s/orders/%s/invoice\n", *addr, order.OrderID)
+	}
+	fmt.Printf("Pending: http://localhost%s/orders/%s/invoice\n", *addr, pending.OrderID)
+	log.Fatal(http.ListenAndServe(*addr, s.routes()))
+}
+
+// runDemo fetches the invoices of the orders and checks what comes back.
+func runDemo(s *shop, paid []*models.Order, pending *models.Order) error {
+	srv := httptest.NewServer(s.routes())
+	defer srv.Close()
+	get := func(path string) (*http.Response, []byte, error) {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return resp, body, err
+	}
+
+	fmt.Println("\n--- Invoices of the paid orders ---")
+	for _, order := range paid {
+		for _, format := range []string{"pdf", "html"} {
+			resp, body, err := get("/orders/" + order.OrderID + "/invoice?format=" + format)
+			if err != nil {
+				return err
+			}
+			if resp.StatusCode != http.StatusOK {
+				return fmt.Errorf("invoice of %s: %s: %s", order.OrderID, resp.Status, body)
+			}
+			fmt.Printf("%s %-4s %d bytes, %s\n", order.OrderID[:8], format, len(body), resp.Header.Get("Content-Disposition"))
+			if format == "pdf" {
+				// A reader starts from the end: startxref gives the table of
+				// the objects
+				if !bytes.HasPrefix(body, []byte("%PDF-1.4")) || !bytes.HasSuffix(body, []byte("%%EOF\n")) || !bytes.Contains(body, []byte("startxref")) {
+					return fmt.Errorf("invoice of %s is not a PDF file", order.OrderID)
+				}
+				fmt.Printf("         %d page(s)\n", bytes.Count(body, []byte("/Type /Page ")))
+			}
+		}
+	}
+
+	fmt.Println("\n--- Asking again serves the same invoice ---")
+	first := paid[0]
+	resp, _, err := get("/orders/" + first.OrderID + "/invoice")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: %s, ETag %s\n", resp.Status, resp.Header.Get("Content-Disposition"), resp.Header.Get("ETag")[:13]+`…"`)
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/orders/"+first.OrderID+"/invoice", nil)
+	req.Header.Set("If-None-Match", resp.Header.Get("ETag"))
+	if resp, err = http.DefaultClient.Do(req); err != nil {
+		return err
+	}
+	resp.Body.Close()
+	fmt.Printf("With If-None-Match: %s\n", resp.Status)
+
+	fmt.Println("\n--- Orders without an invoice ---")
+	for _, path := range []string{"/orders/" + pending.OrderID + "/invoice", "/orders/no-such-order/invoice", "/orders/" + first.OrderID + "/invoice?format=docx"} {
+		resp, body, err := get(path)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("GET %s: %s: %s", path, resp.Status, body)
+	}
+
+	fmt.Println("\n--- The order keeps its attachments ---")
+	_, body, err := get("/orders/" + first.OrderID)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(body))
+
+	// Keep a copy of the files to look at
+	for _, order := range paid {
+		for _, a := range order.Attachments {
+			data, err := os.ReadFile(a.Path)
+			if err != nil {
+				return err
+			}
+			out := filepath.Join(os.TempDir(), a.Name)
+			if err := os.WriteFile(out, data, 0o644); err != nil {
+				return err
+			}
+			fmt.Printf("Copied %s to %s\n", a.Name, out)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang-training/module-09/exercise-2/models"
+	"golang-training/module-09/exercise-6/invoice"
+)
+
+// Formats of the invoice files, by the format query parameter.
+var formats = map[string]struct {
+	ext         string
+	contentType string
+	render      func(io.Writer, *invoice.Invoice) error
+}{
+	"pdf":  {".pdf", "application/pdf", invoice.RenderPDF},
+	"html": {".html", "text/html; charset=utf-8", invoice.RenderHTML},
+}
+
+// shop holds the orders and issues their invoices. An invoice is issued
+// once, the first time it is asked for: it is rendered in every format,
+// the files are written to dir and attached to the order, and the next
+// requests serve the same files.
+type shop struct {
+	company   invoice.Company
+	products  map[string]models.Product
+	customers func(id string) (*models.Customer, error)
+	dir       string
+	now       func() time.Time
+
+	mu       sync.Mutex
+	orders   map[string]*models.Order
+	invoices map[string]*invoice.Invoice // By order ID
+	issued   int                         // Invoices issued this year
+	year     int
+}
+
+func (s *shop) add(order *models.Order) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders[order.OrderID] = order
+}
+
+// invoice returns the invoice of an order, issuing it the first time.
+// The lock is held while the files are written, so two requests can't
+// issue two invoices for the same order, and a failure leaves no gap in
+// the numbers.
+func (s *shop) invoice(orderID string) (*invoice.Invoice, *models.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	order, ok := s.orders[orderID]
+	if !ok {
+		return nil, nil, errNotFound
+	}
+	if inv, ok := s.invoices[orderID]; ok {
+		return inv, order, nil
+	}
+
+	customer, err := s.customers(order.CustomerID)
+	if err != nil {
+		return nil, order, err
+	}
+	now := s.now()
+	issued := s.issued
+	if now.Year() != s.year {
+		issued = 0 // The numbers start over every year
+	}
+	number := fmt.Sprintf("INV-%d-%04d", now.Year(), issued+1)
+	inv, err := invoice.New(number, now, s.company, order, customer, s.products)
+	if err != nil {
+		return nil, order, err
+	}
+
+	// Write every file before attaching any, so the order never points to
+	// a partial invoice
+	var attachments []models.Attachment
+	for _, format := range formats {
+		var buf bytes.Buffer
+		if err := format.render(&buf, inv); err != nil {
+			return nil, order, err
+		}
+		attachment, err := s.save(inv.FileName(format.ext), format.contentType, buf.Bytes(), now)
+		if err != nil {
+			return nil, order, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	for _, a := range attachments {
+		order.Attach(a)
+	}
+	s.invoices[orderID] = inv
+	s.issued, s.year = issued+1, now.Year()
+	return inv, order, nil
+}
+
+// save writes a file atomically: a crash leaves the old file or the new
+// one, never half of it.
+func (s *shop) save(name, contentType string, data []byte, now time.Time) (models.Attachment, error) {
+	path := filepath.Join(s.dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return models.Attachment{}, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return models.Attachment{}, err
+	}
+	sum := sha256.Sum256(data)
+	return models.Attachment{
+		Name:        name,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		SHA256:      hex.EncodeToString(sum[:]),
+		Path:        path,
+		CreatedAt:   now,
+	}, nil
+}
+
+var errNotFound = errors.New("order not found")
+
+func (s *shop) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orders/{id}", s.getOrder)
+	mux.HandleFunc("GET /orders/{id}/invoice", s.getInvoice)
+	return mux
+}
+
+// getOrder returns the order, with its attachments.
+func (s *shop) getOrder(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	order, ok := s.orders[r.PathValue("id")]
+	var body []byte
+	if ok {
+		body, _ = json.MarshalIndent(order, "", "  ")
+	}
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, errNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// getInvoice serves the invoice of an order, as PDF or, with
+// ?format=html, as HTML.
+func (s *shop) getInvoice(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		name = "pdf"
+	}
+	format, ok := formats[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown format %q: pdf or html", name), http.StatusBadRequest)
+		return
+	}
+
+	inv, order, err := s.invoice(r.PathValue("id"))
+	switch {
+	case errors.Is(err, errNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, invoice.ErrNotPaid):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		log.Printf("invoice of order %s: %v", r.PathValue("id"), err)
+		http.Error(w, "cannot issue the invoice", http.StatusInternalServerError)
+		return
+	}
+
+	s.mu.Lock()
+	attachment, ok := order.Attachment(inv.FileName(format.ext))
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "invoice file missing", http.StatusInternalServerError)
+		return
+	}
+	f, err := os.Open(attachment.Path)
+	if err != nil {
+		log.Printf("invoice of order %s: %v", order.OrderID, err)
+		http.Error(w, "invoice file missing", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", attachment.Name))
+	w.Header().Set("ETag", `"`+attachment.SHA256+`"`)
+	// ServeContent answers the conditional and range requests
+	http.ServeContent(w, r, attachment.Name, attachment.CreatedAt, f)
+}
@@ -0,0 +1,182 @@
+// Package broadcast sends every message published to many subscribers, each
+// through a buffered channel of its own, so a slow subscriber never blocks
+// the publisher or the others.
+package broadcast
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrSlow ends a subscription with the Disconnect policy whose buffer
+	// was full
+	ErrSlow = errors.New("subscriber too slow")
+
+	// ErrClosed ends the subscriptions of a closed broadcaster
+	ErrClosed = errors.New("broadcaster closed")
+)
+
+// Policy is what happens to a subscriber whose buffer is full
+type Policy int
+
+const (
+	// DropNewest skips the new message for this subscriber
+	DropNewest Policy = iota
+	// DropOldest removes the oldest message of the buffer to make room,
+	// for subscribers only interested in the latest state, such as prices
+	DropOldest
+	// Disconnect ends the subscription: the subscriber knows it missed
+	// messages, and can subscribe again and resynchronize
+	Disconnect
+)
+
+func (p Policy) String() string {
+	switch p {
+	case DropNewest:
+		return "drop-newest"
+	case DropOldest:
+		return "drop-oldest"
+	case Disconnect:
+		return "disconnect"
+	}
+	return "unknown"
+}
+
+// Subscription receives the messages on C, which is closed when the
+// subscription ends. Err then tells why.
+type Subscription[T any] struct {
+	C <-chan T
+
+	ch      chan T
+	b       *Broadcaster[T]
+	policy  Policy
+	stop    func() bool // Stops the context.AfterFunc
+	dropped atomic.Int64
+	err     error // Set under b.mu, before ch is closed
+}
+
+// Close ends the subscription. It may be called more than once.
+func (s *Subscription[T]) Close() {
+	s.b.remove(s, nil)
+}
+
+// Err returns why the subscription ended: nil after Close, the error of its
+// context, ErrSlow or ErrClosed. It is nil while the subscription is active.
+func (s *Subscription[T]) Err() error {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	return s.err
+}
+
+// Dropped returns the number of messages this subscriber missed.
+func (s *Subscription[T]) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Broadcaster publishes messages of type T. The zero value is not usable:
+// create one with New.
+type Broadcaster[T any] struct {
+	mu     sync.Mutex
+	subs   map[*Subscription[T]]struct{}
+	closed bool
+}
+
+func New[T any]() *Broadcaster[T] {
+	return &Broadcaster[T]{subs: make(map[*Subscription[T]]struct{})}
+}
+
+// Subscribe adds a subscriber with a buffer of buffer messages. The
+// subscription ends when ctx is done: a request handler passes the context
+// of its request, and the subscription ends with the connection. No
+// goroutine waits for the context.
+func (b *Broadcaster[T]) Subscribe(ctx context.Context, buffer int, policy Policy) (*Subscription[T], error) {
+	if buffer < 1 {
+		buffer = 1
+	}
+	ch := make(chan T, buffer)
+	s := &Subscription[T]{C: ch, ch: ch, b: b, policy: policy}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil, ErrClosed
+	}
+	b.subs[s] = struct{}{}
+	// Under the lock, as removeLocked reads it. A context already done runs
+	// the function in a goroutine, which waits for the lock.
+	s.stop = context.AfterFunc(ctx, func() { b.remove(s, context.Cause(ctx)) })
+	b.mu.Unlock()
+	return s, nil
+}
+
+// Publish sends msg to every subscriber without waiting, and returns the
+// number of subscribers it was delivered to.
+func (b *Broadcaster[T]) Publish(msg T) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delivered := 0
+	for s := range b.subs {
+		select {
+		case s.ch <- msg:
+			delivered++
+			continue
+		default:
+		}
+		s.dropped.Add(1)
+		switch s.policy {
+		case DropOldest:
+			// Only Publish sends, under the lock: once a message is
+			// removed, there is room. The subscriber may have taken it
+			// first, which makes room too.
+			select {
+			case <-s.ch:
+			default:
+			}
+			s.ch <- msg
+			delivered++
+		case Disconnect:
+			b.removeLocked(s, ErrSlow)
+		}
+	}
+	return delivered
+}
+
+// Len returns the number of subscribers.
+func (b *Broadcaster[T]) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// Close ends every subscription with ErrClosed. Subscribe fails after it.
+func (b *Broadcaster[T]) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for s := range b.subs {
+		b.removeLocked(s, ErrClosed)
+	}
+}
+
+func (b *Broadcaster[T]) remove(s *Subscription[T], err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.removeLocked(s, err)
+}
+
+// removeLocked ends a subscription. The channel is closed under the lock,
+// so Publish never sends on a closed channel.
+func (b *Broadcaster[T]) removeLocked(s *Subscription[T], err error) {
+	if _, ok := b.subs[s]; !ok {
+		return
+	}
+	delete(b.subs, s)
+	s.stop()
+	s.err = err
+	close(s.ch)
+}
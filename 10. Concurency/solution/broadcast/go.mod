@@ -0,0 +1,3 @@
+module golang-training/module-10/broadcast
+
+go 1.25
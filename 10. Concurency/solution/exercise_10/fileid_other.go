@@ -0,0 +1,23 @@
+//go:build !unix
+
+package main
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// fileID identifies a directory by its path with every symbolic link
+// resolved, where there are no inode numbers
+type fileID struct {
+	path string
+}
+
+func idOf(path string, info fs.FileInfo) (fileID, bool) {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fileID{}, false
+	}
+	abs, err := filepath.Abs(real)
+	return fileID{path: abs}, err == nil
+}
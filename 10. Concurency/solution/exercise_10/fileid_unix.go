@@ -0,0 +1,22 @@
+//go:build unix
+
+package main
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileID identifies a directory whatever the path leading to it: two
+// symbolic links to the same directory give the same device and inode
+type fileID struct {
+	dev, ino uint64
+}
+
+func idOf(path string, info fs.FileInfo) (fileID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(stat.Dev), ino: uint64(stat.Ino)}, true
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"time"
+)
+
+func main() {
+	var filter Filter
+	flag.StringVar(&filter.Name, "name", "", "count only the files whose name matches this glob, such as '*.go'")
+	flag.StringVar(&filter.Exclude, "exclude", "", "skip the directories whose name matches this glob, such as .git")
+	minSize := flag.String("min-size", "", "count only the files of at least this size, such as 10K or 1.5M")
+	maxSize := flag.String("max-size", "", "count only the files of at most this size")
+	newer := flag.Duration("newer", 0, "count only the files modified in this duration, such as 24h")
+	older := flag.Duration("older", 0, "count only the files not modified in this duration")
+	parallel := flag.Int("j", runtime.GOMAXPROCS(0), "number of directories walked at once; 1 walks with a single filepath.WalkDir")
+	follow := flag.Bool("L", false, "follow symbolic links")
+	list := flag.Bool("list", false, "list the matching files as they are found, as find does")
+	asJSON := flag.Bool("json", false, "print the tree as JSON")
+	depth := flag.Int("depth", 2, "levels of directories printed, -1 for all")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("dirtool: ")
+
+	var err error
+	if filter.MinSize, err = parseSize(*minSize); err != nil {
+		log.Fatal(err)
+	}
+	if filter.MaxSize, err = parseSize(*maxSize); err != nil {
+		log.Fatal(err)
+	}
+	now := time.Now()
+	if *newer > 0 {
+		filter.Newer = now.Add(-*newer)
+	}
+	if *older > 0 {
+		filter.Older = now.Add(-*older)
+	}
+	root := "."
+	if flag.NArg() > 0 {
+		root = flag.Arg(0)
+	}
+
+	w := &Walker{Filter: filter, Parallel: max(*parallel, 1), Follow: *follow}
+	done := make(chan struct{})
+	if *list {
+		// The walkers send the files as they find them; one goroutine
+		// prints them, so lines never mix
+		found := make(chan File, 64)
+		w.Found = found
+		go func() {
+			defer close(done)
+			for f := range found {
+				fmt.Printf("%10s  %s  %s\n", bytesize(f.Size), f.ModTime.Format(time.DateTime), f.Path)
+			}
+		}()
+	} else {
+		close(done)
+	}
+
+	start := time.Now()
+	tree, walkErr := w.Walk(root)
+	<-done
+	if tree == nil {
+		log.Fatal(walkErr)
+	}
+
+	switch {
+	case *asJSON:
+		if err := printJSON(os.Stdout, tree, *depth); err != nil {
+			log.Fatal(err)
+		}
+	case *list:
+		fmt.Printf("%10s  %d files\n", bytesize(tree.Size), tree.Files)
+	default:
+		printTree(os.Stdout, tree, *depth)
+	}
+	fmt.Fprintf(os.Stderr, "walked in %v with %d goroutines at most\n", time.Since(start).Round(time.Millisecond), w.Parallel)
+
+	// Like du and find, report every error at the end and exit with 1
+	if walkErr != nil {
+		for _, e := range unwrapJoined(walkErr) {
+			log.Print(e)
+		}
+		os.Exit(1)
+	}
+}
+
+func unwrapJoined(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
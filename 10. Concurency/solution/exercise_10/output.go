@@ -0,0 +1,88 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// printTree prints the directories down to depth, the largest first, with
+// the lines of a tree
+func printTree(w io.Writer, n *Node, depth int) {
+	fmt.Fprintf(w, "%10s  %6d files  %s\n", bytesize(n.Size), n.Files, n.Path)
+	printChildren(w, n, depth, "")
+}
+
+func printChildren(w io.Writer, n *Node, depth int, prefix string) {
+	if depth == 0 {
+		return
+	}
+	children := sorted(n.Children)
+	for i, c := range children {
+		branch, next := "├── ", "│   "
+		if i == len(children)-1 {
+			branch, next = "└── ", "    "
+		}
+		fmt.Fprintf(w, "%10s  %6d files  %s%s%s\n", bytesize(c.Size), c.Files, prefix, branch, c.Name)
+		printChildren(w, c, depth-1, prefix+next)
+	}
+}
+
+// printJSON prints the tree down to depth; the sizes still count the
+// directories below
+func printJSON(w io.Writer, n *Node, depth int) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(prune(n, depth))
+}
+
+func prune(n *Node, depth int) *Node {
+	p := *n
+	p.Children = nil
+	if depth != 0 {
+		for _, c := range sorted(n.Children) {
+			p.Children = append(p.Children, prune(c, depth-1))
+		}
+	}
+	return &p
+}
+
+func sorted(nodes []*Node) []*Node {
+	return slices.SortedFunc(slices.Values(nodes), func(a, b *Node) int {
+		return cmp.Or(cmp.Compare(b.Size, a.Size), strings.Compare(a.Name, b.Name))
+	})
+}
+
+// bytesize formats a number of bytes in binary units
+func bytesize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// parseSize reads a size such as 512, 10K, 1.5M or 2G, in binary units
+func parseSize(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	mult := int64(1)
+	if i := strings.IndexAny(strings.ToUpper(s), "KMGT"); i >= 0 && i == len(s)-1 {
+		mult = 1 << (10 * (strings.IndexByte("KMGT", strings.ToUpper(s)[i]) + 1))
+		s = s[:i]
+	}
+	var f float64
+	if _, err := fmt.Sscanf(s, "%g", &f); err != nil || f < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(f * float64(mult)), nil
+}
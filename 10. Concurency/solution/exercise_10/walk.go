@@ -0,0 +1,268 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCycle is reported for a symbolic link leading back to a directory
+// being walked
+var ErrCycle = errors.New("file system loop")
+
+// Filter selects the files counted and listed; directories are always
+// walked
+type Filter struct {
+	Name    string // Glob matched against the base name, such as *.go
+	Exclude string // Glob of directory names not walked, such as .git
+	MinSize int64
+	MaxSize int64 // 0 for no limit
+	Newer   time.Time
+	Older   time.Time
+}
+
+func (f Filter) match(name string, info fs.FileInfo) bool {
+	if f.Name != "" {
+		if ok, _ := filepath.Match(f.Name, name); !ok {
+			return false
+		}
+	}
+	return info.Size() >= f.MinSize &&
+		(f.MaxSize == 0 || info.Size() <= f.MaxSize) &&
+		(f.Newer.IsZero() || info.ModTime().After(f.Newer)) &&
+		(f.Older.IsZero() || info.ModTime().Before(f.Older))
+}
+
+// Node is a directory with the files it holds that match the filter
+type Node struct {
+	Name     string  `json:"name"`
+	Path     string  `json:"path"`
+	Size     int64   `json:"size"`  // Of the matching files below, computed by total
+	Files    int     `json:"files"` // Matching files below
+	Children []*Node `json:"children,omitempty"`
+
+	self  int64 // Of the matching files directly in the directory
+	files int
+}
+
+// File is a matching file, sent to Walker.Found as soon as it is seen
+type File struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// Walker walks a tree with up to Parallel goroutines. Each goroutine walks a
+// subtree with filepath.WalkDir, and hands the subdirectories it meets to
+// new goroutines while fewer than Parallel run; otherwise it walks them
+// itself, so no goroutine ever waits for a free slot.
+type Walker struct {
+	Filter   Filter
+	Parallel int
+	Follow   bool        // Walk the directories symbolic links point to
+	Found    chan<- File // Receives the matching files, if not nil
+
+	slots   chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	errs    []error
+	visited map[fileID]bool
+}
+
+// Walk returns the tree under root, and every error met on the way joined:
+// an unreadable directory is reported and skipped, as du does
+func (w *Walker) Walk(root string) (*Node, error) {
+	root = filepath.Clean(root)
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s: not a directory", root)
+	}
+	w.slots = make(chan struct{}, max(w.Parallel-1, 0))
+	w.visited = make(map[fileID]bool)
+
+	node := &Node{Name: filepath.Base(root), Path: root}
+	if w.enter(root, root) {
+		w.walk(root, root, node)
+	}
+	w.wg.Wait()
+	if w.Found != nil {
+		close(w.Found)
+	}
+	total(node)
+	// The goroutines meet the errors in any order
+	slices.SortFunc(w.errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return node, errors.Join(w.errs...)
+}
+
+// walk walks the directory real into node. display is the path shown for
+// real: they differ below a followed link, walked at its target because
+// WalkDir does not follow a link given as its root.
+func (w *Walker) walk(display, real string, node *Node) {
+	nodes := map[string]*Node{real: node}
+	filepath.WalkDir(real, func(path string, d fs.DirEntry, err error) error {
+		shown := display
+		if rel, _ := filepath.Rel(real, path); rel != "." {
+			shown = filepath.Join(display, rel)
+		}
+		if err != nil {
+			w.fail(fmt.Errorf("%s: %w", shown, unwrapPath(err)))
+			return nil // WalkDir skips the directory it could not read
+		}
+		parent := nodes[filepath.Dir(path)]
+
+		switch {
+		case path == real:
+			return nil // Entered by the caller
+		case d.IsDir():
+			if w.Filter.Exclude != "" {
+				if ok, _ := filepath.Match(w.Filter.Exclude, d.Name()); ok {
+					return filepath.SkipDir
+				}
+			}
+			if !w.enter(shown, path) {
+				return filepath.SkipDir
+			}
+			child := &Node{Name: d.Name(), Path: shown}
+			parent.Children = append(parent.Children, child)
+			if w.handOff(shown, path, child) {
+				return filepath.SkipDir
+			}
+			nodes[path] = child
+			return nil
+		case d.Type()&fs.ModeSymlink != 0:
+			w.link(shown, path, d.Name(), parent)
+			return nil
+		case d.Type().IsRegular():
+			info, err := d.Info()
+			if err != nil {
+				w.fail(fmt.Errorf("%s: %w", shown, unwrapPath(err)))
+				return nil
+			}
+			w.file(shown, d.Name(), info, parent)
+		}
+		return nil
+	})
+}
+
+// link counts a symbolic link when following links, as the file or the
+// directory it points to
+func (w *Walker) link(shown, path, name string, parent *Node) {
+	if !w.Follow {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		w.fail(fmt.Errorf("%s: %w", shown, unwrapPath(err)))
+		return
+	}
+	if !info.IsDir() {
+		if info.Mode().IsRegular() {
+			w.file(shown, name, info, parent)
+		}
+		return
+	}
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		w.fail(fmt.Errorf("%s: %w", shown, unwrapPath(err)))
+		return
+	}
+	// A link to a directory holding it would be walked forever
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(path)); err == nil &&
+		(dir == target || strings.HasPrefix(dir, target+string(filepath.Separator))) {
+		w.fail(fmt.Errorf("%s: %w", shown, ErrCycle))
+		return
+	}
+	if !w.enter(shown, target) {
+		return
+	}
+	child := &Node{Name: name, Path: shown}
+	parent.Children = append(parent.Children, child)
+	if !w.handOff(shown, target, child) {
+		w.walk(shown, target, child)
+	}
+}
+
+// handOff walks a directory in a new goroutine if a slot is free
+func (w *Walker) handOff(shown, real string, node *Node) bool {
+	select {
+	case w.slots <- struct{}{}:
+	default:
+		return false
+	}
+	w.wg.Go(func() {
+		defer func() { <-w.slots }()
+		w.walk(shown, real, node)
+	})
+	return true
+}
+
+// enter records a directory as visited, and reports whether to walk it.
+// Only a followed link can lead twice to a directory: it is counted once,
+// under the first path walked, as du does.
+func (w *Walker) enter(shown, path string) bool {
+	if !w.Follow {
+		return true
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		w.fail(fmt.Errorf("%s: %w", shown, unwrapPath(err)))
+		return false
+	}
+	id, ok := idOf(path, info)
+	if !ok {
+		return true
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.visited[id] {
+		return false
+	}
+	w.visited[id] = true
+	return true
+}
+
+func (w *Walker) file(shown, name string, info fs.FileInfo, parent *Node) {
+	if !w.Filter.match(name, info) {
+		return
+	}
+	parent.self += info.Size()
+	parent.files++
+	if w.Found != nil {
+		w.Found <- File{Path: shown, Size: info.Size(), ModTime: info.ModTime()}
+	}
+}
+
+func (w *Walker) fail(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.errs = append(w.errs, err)
+}
+
+// total adds the sizes of the children to their parents, once every
+// goroutine is done
+func total(n *Node) {
+	n.Size, n.Files = n.self, n.files
+	for _, c := range n.Children {
+		total(c)
+		n.Size += c.Size
+		n.Files += c.Files
+	}
+}
+
+// unwrapPath drops the path of a *fs.PathError, as the shown path replaces it
+func unwrapPath(err error) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return fmt.Errorf("%s: %w", pathErr.Op, pathErr.Err)
+	}
+	return err
+}
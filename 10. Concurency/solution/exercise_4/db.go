@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Product is the table of the GORM exercises of module 14
+type Product struct {
+	ID       uint   `gorm:"primaryKey"`
+	Name     string `gorm:"size:100;not null"`
+	Category string `gorm:"size:50;index"`
+	Stock    int
+}
+
+// openDB creates a database with some products. SQLite in a file, not in
+// memory: each connection to ":memory:" would get a database of its own.
+func openDB(dir string) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "shop.db")+"?_journal_mode=WAL&_busy_timeout=5000"),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.AutoMigrate(&Product{}); err != nil {
+		return nil, err
+	}
+	products := make([]Product, 200)
+	for i := range products {
+		products[i] = Product{Name: fmt.Sprintf("Product %d", i+1), Category: []string{"Office", "Outdoor", "Home"}[i%3], Stock: i % 50}
+	}
+	return db, db.CreateInBatches(products, 100).Error
+}
+
+// report is a slow read: it holds its connection while the products are
+// counted, as a report or a long transaction would
+func report(ctx context.Context, db *gorm.DB, hold time.Duration) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&Product{}).Where("stock < ?", 10).Count(&count).Error; err != nil {
+			return err
+		}
+		time.Sleep(hold)
+		return nil
+	}, &sql.TxOptions{ReadOnly: true})
+}
+
+// connections follows the number of connections open, at most
+type connections struct {
+	open, peak atomic.Int64
+}
+
+func (c *connections) track(db *gorm.DB) func() {
+	sqlDB, _ := db.DB()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				open := int64(sqlDB.Stats().OpenConnections)
+				c.open.Store(open)
+				if open > c.peak.Load() {
+					c.peak.Store(open)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return func() { close(stop); <-done }
+}
+
+// runReports runs n reports at the same time, each through acquire and
+// release when they are not nil, and returns the failures and the time taken
+func runReports(db *gorm.DB, n int, hold time.Duration, timeout time.Duration, sem Semaphore) (failed int, elapsed time.Duration) {
+	var wg sync.WaitGroup
+	var failures atomic.Int64
+	start := time.Now()
+	for range n {
+		wg.Go(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if sem != nil {
+				if err := sem.Acquire(ctx); err != nil {
+					failures.Add(1)
+					return
+				}
+				defer sem.Release()
+			}
+			if err := report(ctx, db, hold); err != nil {
+				failures.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+	return int(failures.Load()), time.Since(start)
+}
+
+// limitDB compares three ways of running 40 reports on a database that
+// should not get more than 4 connections
+func limitDB(dir string) error {
+	db, err := openDB(dir)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	const reports, limit, hold = 40, 4, 20 * time.Millisecond
+
+	fmt.Println("--- No limit ---")
+	var conns connections
+	stop := conns.track(db)
+	failed, elapsed := runReports(db, reports, hold, 5*time.Second, nil)
+	stop()
+	fmt.Printf("  %d reports in %v, %d failed, up to %d connections open\n", reports, elapsed.Round(time.Millisecond), failed, conns.peak.Load())
+	sqlDB.SetMaxIdleConns(0) // Closes the connections left
+	sqlDB.SetMaxIdleConns(2)
+
+	// database/sql has a semaphore of its own: with MaxOpenConns, queries
+	// wait for a free connection, and the pool counts the waits
+	fmt.Println("--- Pool limit: SetMaxOpenConns ---")
+	sqlDB.SetMaxOpenConns(limit)
+	conns = connections{}
+	stop = conns.track(db)
+	before := sqlDB.Stats()
+	failed, elapsed = runReports(db, reports, hold, 5*time.Second, nil)
+	stop()
+	after := sqlDB.Stats()
+	waits := after.WaitCount - before.WaitCount
+	waited := after.WaitDuration - before.WaitDuration
+	fmt.Printf("  %d reports in %v, %d failed, up to %d connections open\n", reports, elapsed.Round(time.Millisecond), failed, conns.peak.Load())
+	fmt.Printf("  pool: %d waits for a connection, %v in total, %v on average\n", waits, waited.Round(time.Millisecond), (waited / time.Duration(max(waits, 1))).Round(time.Millisecond))
+	sqlDB.SetMaxOpenConns(0)
+
+	// A semaphore in the application limits the work, not the connections:
+	// one report may use several, and the limit can differ by kind of work
+	fmt.Println("--- Semaphore around the reports ---")
+	for _, sem := range []struct {
+		name string
+		sem  Semaphore
+	}{
+		{"channel", NewChanSemaphore(limit)},
+		{"x/sync", NewWeightedSemaphore(limit)},
+	} {
+		instrumented := Instrument(sem.sem)
+		failed, elapsed = runReports(db, reports, hold, 5*time.Second, instrumented)
+		fmt.Printf("  %-7s %d reports in %v, %d failed\n          %v\n", sem.name, reports, elapsed.Round(time.Millisecond), failed, instrumented.Stats())
+	}
+
+	// Waiting is not always better: a caller with a deadline gives up, and
+	// the database is not overloaded with work nobody waits for anymore
+	fmt.Println("--- Semaphore with a 100ms deadline per report ---")
+	instrumented := Instrument(NewChanSemaphore(limit))
+	failed, elapsed = runReports(db, reports, hold, 100*time.Millisecond, instrumented)
+	st := instrumented.Stats()
+	fmt.Printf("  %d reports in %v, %d gave up: %d waiting for a permit, %d during the report\n  %v\n",
+		reports, elapsed.Round(time.Millisecond), failed, st.Timeouts, failed-st.Timeouts, st)
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// fileServer serves files of the size in the path, slowly, and counts the
+// downloads in progress, to check the limits from the server side
+type fileServer struct {
+	*httptest.Server
+	active, peak atomic.Int64
+}
+
+func newFileServer() *fileServer {
+	s := &fileServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		active := s.active.Add(1)
+		defer s.active.Add(-1)
+		for peak := s.peak.Load(); active > peak && !s.peak.CompareAndSwap(peak, active); peak = s.peak.Load() {
+		}
+
+		size, err := strconv.Atoi(r.URL.Path[len("/files/"):])
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(size))
+		chunk := make([]byte, 64<<10)
+		for sent := 0; sent < size; sent += len(chunk) {
+			time.Sleep(2 * time.Millisecond)
+			if _, err := w.Write(chunk[:min(len(chunk), size-sent)]); err != nil {
+				return
+			}
+		}
+	}))
+	return s
+}
+
+func download(ctx context.Context, url string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.Copy(io.Discard, resp.Body)
+}
+
+// limitDownloads downloads 24 files, with at most 3 downloads at once, then
+// with at most 4 MB of files in progress
+func limitDownloads() error {
+	server := newFileServer()
+	defer server.Close()
+	sizes := make([]int64, 24)
+	for i := range sizes {
+		sizes[i] = int64(i%4+1) << 19 // 512 KB to 2 MB
+	}
+
+	fmt.Println("--- At most 3 downloads ---")
+	sem := Instrument(NewChanSemaphore(3))
+	var wg sync.WaitGroup
+	var total atomic.Int64
+	start := time.Now()
+	for _, size := range sizes {
+		wg.Go(func() {
+			if err := sem.Acquire(context.Background()); err != nil {
+				return
+			}
+			defer sem.Release()
+			n, err := download(context.Background(), fmt.Sprintf("%s/files/%d", server.URL, size))
+			if err != nil {
+				fmt.Println("  error:", err)
+			}
+			total.Add(n)
+		})
+	}
+	wg.Wait()
+	fmt.Printf("  %d files, %d MB in %v, at most %d downloads seen by the server\n  %v\n",
+		len(sizes), total.Load()>>20, time.Since(start).Round(time.Millisecond), server.peak.Load(), sem.Stats())
+
+	// Weighted: a file takes one permit per 512 KB, so four small files or
+	// two large ones run at once. The memory of the downloads stays bounded
+	// whatever the mix of sizes.
+	fmt.Println("--- At most 4 MB in progress, x/sync weighted ---")
+	const unit = 512 << 10
+	weighted := NewWeightedSemaphore(4 << 20 / unit)
+	var inFlight, peakBytes atomic.Int64
+	server.peak.Store(0)
+	total.Store(0)
+	start = time.Now()
+	for _, size := range sizes {
+		wg.Go(func() {
+			permits := (size + unit - 1) / unit
+			if err := weighted.AcquireN(context.Background(), permits); err != nil {
+				return
+			}
+			defer weighted.ReleaseN(permits)
+			now := inFlight.Add(size)
+			defer inFlight.Add(-size)
+			for peak := peakBytes.Load(); now > peak && !peakBytes.CompareAndSwap(peak, now); peak = peakBytes.Load() {
+			}
+			n, err := download(context.Background(), fmt.Sprintf("%s/files/%d", server.URL, size))
+			if err != nil {
+				fmt.Println("  error:", err)
+			}
+			total.Add(n)
+		})
+	}
+	wg.Wait()
+	fmt.Printf("  %d files, %d MB in %v, at most %.1f MB and %d downloads in progress\n",
+		len(sizes), total.Load()>>20, time.Since(start).Round(time.Millisecond), float64(peakBytes.Load())/(1<<20), server.peak.Load())
+
+	// TryAcquire never waits: a server answers 503 at once instead of
+	// queueing requests it can't serve in time
+	fmt.Println("--- Rejecting when busy ---")
+	busy := Instrument(NewChanSemaphore(2))
+	for range 5 {
+		wg.Go(func() {
+			if !busy.TryAcquire() {
+				return
+			}
+			defer busy.Release()
+			download(context.Background(), server.URL+"/files/524288")
+		})
+	}
+	wg.Wait()
+	fmt.Printf("  %v\n", busy.Stats())
+	return nil
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+func main() {
+	dir, err := os.MkdirTemp("", "semaphore")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fmt.Println("=== Database connections ===")
+	if err := limitDB(dir); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("\n=== Downloads ===")
+	if err := limitDownloads(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// Semaphore limits how many goroutines hold a resource at the same time.
+// Acquire waits for a permit, or returns the error of the context.
+type Semaphore interface {
+	Acquire(ctx context.Context) error
+	TryAcquire() bool
+	Release()
+}
+
+// ChanSemaphore is a semaphore made of a buffered channel: each permit
+// taken is a value in the buffer, so sending blocks when all are taken
+type ChanSemaphore chan struct{}
+
+func NewChanSemaphore(n int) ChanSemaphore {
+	return make(ChanSemaphore, n)
+}
+
+func (s ChanSemaphore) Acquire(ctx context.Context) error {
+	// Checked first: select picks at random when both cases are ready
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s ChanSemaphore) TryAcquire() bool {
+	select {
+	case s <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s ChanSemaphore) Release() {
+	select {
+	case <-s:
+	default:
+		panic("semaphore: release without acquire")
+	}
+}
+
+// WeightedSemaphore adapts golang.org/x/sync/semaphore, taking one permit at
+// a time. Its waiters are served in order, and AcquireN takes several
+// permits at once, such as one per megabyte.
+type WeightedSemaphore struct {
+	w *semaphore.Weighted
+}
+
+func NewWeightedSemaphore(n int64) *WeightedSemaphore {
+	return &WeightedSemaphore{w: semaphore.NewWeighted(n)}
+}
+
+func (s *WeightedSemaphore) Acquire(ctx context.Context) error { return s.w.Acquire(ctx, 1) }
+func (s *WeightedSemaphore) TryAcquire() bool                  { return s.w.TryAcquire(1) }
+func (s *WeightedSemaphore) Release()                          { s.w.Release(1) }
+
+// AcquireN takes n permits, all at once
+func (s *WeightedSemaphore) AcquireN(ctx context.Context, n int64) error { return s.w.Acquire(ctx, n) }
+
+// ReleaseN gives back n permits
+func (s *WeightedSemaphore) ReleaseN(n int64) { s.w.Release(n) }
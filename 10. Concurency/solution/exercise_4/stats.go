@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Instrumented measures a semaphore: how long goroutines wait for a permit,
+// how many wait, and how many give up
+type Instrumented struct {
+	Semaphore
+
+	mu         sync.Mutex
+	waits      []time.Duration
+	timeouts   int
+	rejected   int
+	inUse      int
+	maxInUse   int
+	waiting    int
+	maxWaiting int
+}
+
+func Instrument(s Semaphore) *Instrumented {
+	return &Instrumented{Semaphore: s}
+}
+
+func (s *Instrumented) Acquire(ctx context.Context) error {
+	s.mu.Lock()
+	s.waiting++
+	s.maxWaiting = max(s.maxWaiting, s.waiting)
+	s.mu.Unlock()
+
+	start := time.Now()
+	err := s.Semaphore.Acquire(ctx)
+	wait := time.Since(start)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.waiting--
+	if err != nil {
+		s.timeouts++
+		return err
+	}
+	s.waits = append(s.waits, wait)
+	s.acquired()
+	return nil
+}
+
+func (s *Instrumented) TryAcquire() bool {
+	ok := s.Semaphore.TryAcquire()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !ok {
+		s.rejected++
+		return false
+	}
+	s.waits = append(s.waits, 0)
+	s.acquired()
+	return true
+}
+
+func (s *Instrumented) acquired() {
+	s.inUse++
+	s.maxInUse = max(s.maxInUse, s.inUse)
+}
+
+func (s *Instrumented) Release() {
+	s.mu.Lock()
+	s.inUse--
+	s.mu.Unlock()
+	s.Semaphore.Release()
+}
+
+// Stats summarizes the use of a semaphore
+type Stats struct {
+	Acquired   int
+	Timeouts   int // Acquire returned the error of its context
+	Rejected   int // TryAcquire found no permit
+	MaxInUse   int
+	MaxWaiting int
+	WaitP50    time.Duration
+	WaitP95    time.Duration
+	WaitMax    time.Duration
+	WaitTotal  time.Duration
+}
+
+func (s *Instrumented) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := Stats{Acquired: len(s.waits), Timeouts: s.timeouts, Rejected: s.rejected, MaxInUse: s.maxInUse, MaxWaiting: s.maxWaiting}
+	if len(s.waits) == 0 {
+		return st
+	}
+	sorted := slices.Clone(s.waits)
+	slices.Sort(sorted)
+	for _, w := range sorted {
+		st.WaitTotal += w
+	}
+	st.WaitP50 = sorted[(len(sorted)-1)*50/100]
+	st.WaitP95 = sorted[(len(sorted)-1)*95/100]
+	st.WaitMax = sorted[len(sorted)-1]
+	return st
+}
+
+func (st Stats) String() string {
+	return fmt.Sprintf("%d acquired, %d timed out, %d rejected, at most %d in use and %d waiting; wait p50 %v, p95 %v, max %v",
+		st.Acquired, st.Timeouts, st.Rejected, st.MaxInUse, st.MaxWaiting,
+		st.WaitP50.Round(time.Millisecond), st.WaitP95.Round(time.Millisecond), st.WaitMax.Round(time.Millisecond))
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// DiskQueue is a FIFO of records in a file, one per line. Records are
+// appended at the end and read from an offset, saved next to the file by
+// Sync; the file is emptied once every record was read.
+type DiskQueue struct {
+	path   string
+	w      *os.File
+	r      *os.File
+	br     *bufio.Reader
+	offset int64 // Of the next record to read
+	size   int64
+	count  int
+}
+
+// OpenDiskQueue opens the queue at path, with the records a previous
+// process left unread.
+func OpenDiskQueue(path string) (*DiskQueue, error) {
+	w, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	r, err := os.Open(path)
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+	q := &DiskQueue{path: path, w: w, r: r}
+
+	if data, err := os.ReadFile(path + ".offset"); err == nil {
+		if q.offset, err = strconv.ParseInt(string(bytes.TrimSpace(data)), 10, 64); err != nil {
+			return nil, fmt.Errorf("%s.offset: %w", path, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if _, err := r.Seek(q.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	// Count the records left, and find the end
+	q.size = q.offset
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		q.count++
+		q.size += int64(len(scanner.Bytes())) + 1
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(q.offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	q.br = bufio.NewReader(r)
+	return q, nil
+}
+
+// Push appends a record. It must not contain a newline.
+func (q *DiskQueue) Push(record []byte) error {
+	if _, err := q.w.Write(append(record, '\n')); err != nil {
+		return err
+	}
+	q.size += int64(len(record)) + 1
+	q.count++
+	return nil
+}
+
+// Pop reads the oldest record. ok is false when the queue is empty.
+func (q *DiskQueue) Pop() (record []byte, ok bool, err error) {
+	if q.count == 0 {
+		return nil, false, nil
+	}
+	line, err := q.br.ReadBytes('\n')
+	if err != nil {
+		return nil, false, err
+	}
+	q.offset += int64(len(line))
+	q.count--
+	if q.count == 0 {
+		// Every record was read: start the file again, instead of growing
+		// it forever
+		if err := q.reset(); err != nil {
+			return nil, false, err
+		}
+	}
+	return line[:len(line)-1], true, nil
+}
+
+func (q *DiskQueue) reset() error {
+	if err := q.w.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := q.r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	q.br.Reset(q.r)
+	q.offset, q.size = 0, 0
+	return nil
+}
+
+// Len returns the number of records left.
+func (q *DiskQueue) Len() int { return q.count }
+
+// Sync writes the records and the read offset to the disk, so a new process
+// resumes from there.
+func (q *DiskQueue) Sync() error {
+	if err := q.w.Sync(); err != nil {
+		return err
+	}
+	tmp := q.path + ".offset.tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatInt(q.offset, 10)), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, q.path+".offset")
+}
+
+// Close syncs and closes the queue.
+func (q *DiskQueue) Close() error {
+	err := q.Sync()
+	return errors.Join(err, q.w.Close(), q.r.Close())
+}
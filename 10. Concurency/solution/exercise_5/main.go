@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Job is an item of the queue, such as an event to store or an email to
+// send.
+type Job struct {
+	ID      int       `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+// consumers start n goroutines calling handle with the jobs until the
+// queue is drained or ctx is cancelled.
+func consumers(ctx context.Context, q *Queue[Job], n int, work time.Duration, handle func(Job)) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for range n {
+		wg.Go(func() {
+			for {
+				// Checked before taking a job: once cancelled, a consumer
+				// leaves the jobs to the flush
+				if ctx.Err() != nil {
+					return
+				}
+				select {
+				case job, ok := <-q.Out():
+					if !ok {
+						return
+					}
+					time.Sleep(work)
+					handle(job)
+				case <-ctx.Done():
+					return
+				}
+			}
+		})
+	}
+	return &wg
+}
+
+// produce puts count jobs from the ID first, one every interval.
+func produce(q *Queue[Job], first, count int, interval time.Duration) {
+	for id := first; id < first+count; id++ {
+		if err := q.Put(Job{ID: id, Created: time.Now()}); err != nil {
+			log.Fatal(err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// report prints the depth of the queue every interval until stop is closed.
+func report(q *Queue[Job], processed *atomic.Int64, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	start := time.Now()
+	for {
+		select {
+		case <-ticker.C:
+			d := q.Depth()
+			fmt.Printf("  %5v  memory %3d  disk %4d  processed %4d  spilled %4d  restored %4d\n",
+				time.Since(start).Round(100*time.Millisecond), d.Memory, d.Disk, processed.Load(), d.Spilled, d.Restored)
+		case <-stop:
+			return
+		}
+	}
+}
+
+func main() {
+	capacity := flag.Int("capacity", 100, "jobs kept in memory")
+	drain := flag.Duration("drain", 300*time.Millisecond, "time given to the consumers to drain the queue at shutdown")
+	flag.Parse()
+
+	dir, err := os.MkdirTemp("", "spill")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "jobs.queue")
+	const workers, work = 3, 4 * time.Millisecond // About 700 jobs/s
+
+	// Every job handled is recorded, to check that none is lost or handled
+	// twice
+	var mu sync.Mutex
+	processed := make(map[int]int)
+	var count atomic.Int64
+	handle := func(job Job) {
+		mu.Lock()
+		processed[job.ID]++
+		mu.Unlock()
+		count.Add(1)
+	}
+
+	fmt.Println("=== A burst faster than the consumers, then a trickle ===")
+	q, err := OpenQueue[Job](path, *capacity)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wg := consumers(ctx, q, workers, work, handle)
+	stop := make(chan struct{})
+	go report(q, &count, 250*time.Millisecond, stop)
+
+	produce(q, 0, 1500, 200*time.Microsecond) // About 4000 jobs/s
+	produce(q, 1500, 100, 5*time.Millisecond)
+	for d := q.Depth(); d.Memory+d.Disk > 0; d = q.Depth() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	fmt.Printf("  caught up: %d jobs processed\n", count.Load())
+
+	fmt.Println("\n=== Shutting down during another burst ===")
+	produce(q, 1600, 1000, 0)
+	fmt.Printf("  shutdown with %+v\n", q.Depth())
+	// No more jobs; the consumers drain the queue for a while, then stop,
+	// and what is left is flushed to the disk
+	q.Close()
+	drained := make(chan struct{})
+	go func(wg *sync.WaitGroup) { wg.Wait(); close(drained) }(wg)
+	select {
+	case <-drained:
+	case <-time.After(*drain):
+		cancel()
+		wg.Wait()
+	}
+	flushed, err := q.Flush()
+	if err != nil {
+		log.Fatal(err)
+	}
+	close(stop)
+	fmt.Printf("  %d jobs processed, %d flushed to %s\n", count.Load(), flushed, filepath.Base(path))
+
+	fmt.Println("\n=== Restarting: the flushed jobs come first ===")
+	q, err = OpenQueue[Job](path, *capacity)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("  reopened with %+v\n", q.Depth())
+	before := count.Load()
+	var once sync.Once
+	wg = consumers(context.Background(), q, workers, work, func(job Job) {
+		once.Do(func() { fmt.Printf("  first job after the restart: %d\n", job.ID) })
+		handle(job)
+	})
+	produce(q, 2600, 50, time.Millisecond)
+	q.Close()
+	wg.Wait()
+	if _, err := q.Flush(); err != nil {
+		log.Fatal(err)
+	}
+
+	twice := 0
+	for _, n := range processed {
+		if n > 1 {
+			twice++
+		}
+	}
+	fmt.Printf("  %d jobs processed after the restart; %d of the 2650 jobs processed overall, %d more than once\n",
+		count.Load()-before, len(processed), twice)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrClosed is returned by Put after Close.
+var ErrClosed = errors.New("queue closed")
+
+// Queue is a bounded channel that never blocks its producer: when the
+// channel is full, items go to a DiskQueue, and a mover goroutine brings
+// them back as the consumers make room. Items keep their order: once one
+// item is on disk, the next ones follow it there until the disk is empty.
+type Queue[T any] struct {
+	out  chan T
+	disk *DiskQueue
+
+	mu        sync.Mutex
+	inTransit bool // The mover holds an item read from the disk
+	closed    bool
+	wake      chan struct{}
+	stop      chan struct{}
+	moverDone chan struct{}
+	held      []T // Item the mover held when stopped
+
+	spilled  atomic.Int64
+	restored atomic.Int64
+	moveErr  error
+}
+
+// OpenQueue opens a queue keeping capacity items in memory, and the others
+// in the file at path, including the ones a previous process flushed.
+func OpenQueue[T any](path string, capacity int) (*Queue[T], error) {
+	disk, err := OpenDiskQueue(path)
+	if err != nil {
+		return nil, err
+	}
+	q := &Queue[T]{
+		out:       make(chan T, capacity),
+		disk:      disk,
+		wake:      make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		moverDone: make(chan struct{}),
+	}
+	go q.move()
+	return q, nil
+}
+
+// Put adds an item without waiting for the consumers.
+func (q *Queue[T]) Put(item T) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+	if q.disk.Len() == 0 && !q.inTransit {
+		select {
+		case q.out <- item:
+			return nil
+		default:
+		}
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	if err := q.disk.Push(data); err != nil {
+		return err
+	}
+	q.spilled.Add(1)
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Out is the channel of the consumers. It is closed once the queue is
+// closed and every item was delivered.
+func (q *Queue[T]) Out() <-chan T {
+	return q.out
+}
+
+// move brings the items of the disk back to the channel, oldest first.
+func (q *Queue[T]) move() {
+	defer close(q.moverDone)
+	for {
+		q.mu.Lock()
+		if q.disk.Len() == 0 {
+			closed := q.closed
+			q.mu.Unlock()
+			if closed {
+				close(q.out)
+				return
+			}
+			select {
+			case <-q.wake:
+				continue
+			case <-q.stop:
+				return
+			}
+		}
+		data, _, err := q.disk.Pop()
+		var item T
+		if err == nil {
+			err = json.Unmarshal(data, &item)
+		}
+		if err != nil {
+			q.moveErr = err
+			q.mu.Unlock()
+			return
+		}
+		q.inTransit = true
+		q.mu.Unlock()
+
+		select {
+		case q.out <- item:
+			q.restored.Add(1)
+		case <-q.stop:
+			q.mu.Lock()
+			q.held = []T{item}
+			q.inTransit = false
+			q.mu.Unlock()
+			return
+		}
+		q.mu.Lock()
+		q.inTransit = false
+		q.mu.Unlock()
+	}
+}
+
+// Depth is the number of items waiting in the queue.
+type Depth struct {
+	Memory   int
+	Disk     int
+	Spilled  int64 // Items written to the disk since the start
+	Restored int64 // Items brought back from the disk since the start
+}
+
+func (q *Queue[T]) Depth() Depth {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	disk := q.disk.Len()
+	if q.inTransit {
+		disk++
+	}
+	return Depth{Memory: len(q.out), Disk: disk, Spilled: q.spilled.Load(), Restored: q.restored.Load()}
+}
+
+// Close stops accepting items. The mover goes on bringing the items of the
+// disk back, and Out is closed once every item was delivered: consumers
+// ranging over it drain the queue, then return.
+func (q *Queue[T]) Close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Flush stops the mover, writes every item left to the disk, in order, and
+// closes the queue. Items flushed are delivered by the next OpenQueue of
+// the same path. The consumers must be stopped first: an item taken during
+// the flush could be delivered twice. After a complete drain, there is
+// nothing to write.
+func (q *Queue[T]) Flush() (int, error) {
+	select {
+	case <-q.stop:
+	default:
+		close(q.stop)
+	}
+	<-q.moverDone
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+
+	// In order: the channel, the item held by the mover, then the disk
+	var items [][]byte
+drain:
+	for {
+		select {
+		case item, ok := <-q.out:
+			if !ok {
+				break drain
+			}
+			data, err := json.Marshal(item)
+			if err != nil {
+				return 0, err
+			}
+			items = append(items, data)
+		default:
+			break drain
+		}
+	}
+	for _, item := range q.held {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return 0, err
+		}
+		items = append(items, data)
+	}
+	for {
+		data, ok, err := q.disk.Pop()
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			break
+		}
+		items = append(items, append([]byte(nil), data...))
+	}
+	for _, data := range items {
+		if err := q.disk.Push(data); err != nil {
+			return 0, err
+		}
+	}
+	return len(items), errors.Join(q.moveErr, q.disk.Close())
+}
@@ -0,0 +1,7 @@
+module golang-training/module-10/exercise-6
+
+go 1.25
+
+require golang-training/module-10/broadcast v0.0.0
+
+replace golang-training/module-10/broadcast => ../broadcast
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"golang-training/module-10/broadcast"
+)
+
+// Price is a message of the demo: the latest price of a product
+type Price struct {
+	Seq     int
+	Product string
+	Cents   int
+}
+
+// policies runs one fast subscriber and a slow one per policy, while 100
+// prices are published
+func policies() {
+	b := broadcast.New[Price]()
+	ctx := context.Background()
+
+	type subscriber struct {
+		name  string
+		sub   *broadcast.Subscription[Price]
+		delay time.Duration
+	}
+	var subscribers []subscriber
+	for _, s := range []struct {
+		name   string
+		policy broadcast.Policy
+		delay  time.Duration
+	}{
+		{"fast", broadcast.DropNewest, 0},
+		{"slow, drop-newest", broadcast.DropNewest, 2 * time.Millisecond},
+		{"slow, drop-oldest", broadcast.DropOldest, 2 * time.Millisecond},
+		{"slow, disconnect", broadcast.Disconnect, 2 * time.Millisecond},
+	} {
+		sub, err := b.Subscribe(ctx, 8, s.policy)
+		if err != nil {
+			log.Fatal(err)
+		}
+		subscribers = append(subscribers, subscriber{s.name, sub, s.delay})
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, len(subscribers))
+	for i, s := range subscribers {
+		wg.Go(func() {
+			var seqs []int
+			for price := range s.sub.C {
+				seqs = append(seqs, price.Seq)
+				time.Sleep(s.delay)
+			}
+			results[i] = fmt.Sprintf("  %-18s received %3d, dropped %2d, ended: %v\n    %s",
+				s.name, len(seqs), s.sub.Dropped(), s.sub.Err(), ranges(seqs))
+		})
+	}
+
+	for seq := 1; seq <= 100; seq++ {
+		b.Publish(Price{Seq: seq, Product: "P001", Cents: 120000 + seq})
+		time.Sleep(200 * time.Microsecond)
+	}
+	// Gives the subscribers time to read their buffers, then ends them all
+	time.Sleep(50 * time.Millisecond)
+	b.Close()
+	wg.Wait()
+	for _, r := range results {
+		fmt.Println(r)
+	}
+}
+
+// ranges writes increasing numbers as ranges, such as "#1-20 #45-60"
+func ranges(seqs []int) string {
+	var parts []string
+	for i := 0; i < len(seqs); {
+		j := i
+		for j+1 < len(seqs) && seqs[j+1] == seqs[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, fmt.Sprintf("#%d", seqs[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("#%d-%d", seqs[i], seqs[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, " ")
+}
+
+// cleanup subscribes 1000 goroutines with contexts ending at different
+// times, and checks that nothing is left once they are done
+func cleanup() {
+	b := broadcast.New[Price]()
+	before := runtime.NumGoroutine()
+
+	var wg sync.WaitGroup
+	for i := range 1000 {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(10+i%50)*time.Millisecond)
+		sub, err := b.Subscribe(ctx, 4, broadcast.DropOldest)
+		if err != nil {
+			log.Fatal(err)
+		}
+		wg.Go(func() {
+			defer cancel()
+			for i := 0; ; i++ {
+				// Some leave by themselves, the others when their context ends
+				if i == 3 && sub.Dropped()%2 == 0 && len(sub.C) == 0 {
+					sub.Close()
+				}
+				if _, ok := <-sub.C; !ok {
+					return
+				}
+			}
+		})
+	}
+	during := runtime.NumGoroutine()
+	stop := make(chan struct{})
+	go func() {
+		for seq := 0; ; seq++ {
+			select {
+			case <-stop:
+				return
+			default:
+				b.Publish(Price{Seq: seq})
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+	wg.Wait()
+	close(stop)
+	time.Sleep(10 * time.Millisecond)
+	fmt.Printf("  goroutines: %d before, %d with 1000 subscribers, %d after; %d subscribers left\n",
+		before, during, runtime.NumGoroutine(), b.Len())
+}
+
+func main() {
+	fmt.Println("=== Slow subscribers ===")
+	policies()
+	fmt.Println("\n=== Unsubscribing and cancellation ===")
+	cleanup()
+	fmt.Println("\n=== Server-Sent Events ===")
+	if err := serveEvents(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"golang-training/module-10/broadcast"
+)
+
+// TodoEvent is a change of a todo, streamed to the browsers
+type TodoEvent struct {
+	ID    int    `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+}
+
+// eventsHandler streams the events as Server-Sent Events. The subscription
+// takes the context of the request: it ends when the client goes away, and
+// the handler returns. A client too slow to follow is disconnected, and
+// reconnects, as EventSource does by itself.
+func eventsHandler(b *broadcast.Broadcaster[TodoEvent]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		sub, err := b.Subscribe(r.Context(), 16, broadcast.Disconnect)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		defer sub.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		// Sent at once, so the client knows the stream is open
+		fmt.Fprint(w, ": connected\n\n")
+		flusher.Flush()
+
+		for event := range sub.C {
+			data, _ := json.Marshal(event)
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+			flusher.Flush()
+		}
+		if errors.Is(sub.Err(), broadcast.ErrSlow) {
+			// Tells EventSource when to reconnect, in milliseconds
+			fmt.Fprint(w, "retry: 1000\n\n")
+		}
+	}
+}
+
+// readEvents reads the stream of a client until it ends or max events were
+// read, and returns the IDs and names of the events.
+func readEvents(ctx context.Context, url string, max int) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// An event is its fields, one per line, ended by an empty line
+	var events []string
+	var id, name string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() && len(events) < max {
+		field, value, _ := strings.Cut(scanner.Text(), ": ")
+		switch field {
+		case "id":
+			id = value
+		case "event":
+			name = value
+		case "":
+			if id != "" {
+				events = append(events, "#"+id+" "+name)
+			}
+			id, name = "", ""
+		}
+	}
+	return events, scanner.Err()
+}
+
+func serveEvents() error {
+	b := broadcast.New[TodoEvent]()
+	server := httptest.NewServer(eventsHandler(b))
+	defer server.Close()
+
+	type result struct {
+		name   string
+		events []string
+		err    error
+	}
+	results := make(chan result)
+	// One client reads everything, the other leaves after 3 events
+	for _, c := range []struct {
+		name string
+		max  int
+	}{{"browser", 100}, {"tab closed", 3}} {
+		go func() {
+			events, err := readEvents(context.Background(), server.URL, c.max)
+			results <- result{c.name, events, err}
+		}()
+	}
+	for b.Len() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	fmt.Printf("  %d clients connected\n", b.Len())
+
+	for id := 1; id <= 6; id++ {
+		b.Publish(TodoEvent{ID: id, Type: []string{"todo.created", "todo.updated", "todo.completed"}[id%3], Title: fmt.Sprintf("Todo %d", id)})
+		time.Sleep(20 * time.Millisecond)
+	}
+	// The closed tab ended its request: its subscription is gone
+	fmt.Printf("  %d client left after the tab closed\n", b.Len())
+
+	// Closing the broadcaster ends the streams, as at shutdown
+	b.Close()
+	for range 2 {
+		r := <-results
+		if r.err != nil {
+			return r.err
+		}
+		fmt.Printf("  %-10s %s\n", r.name, strings.Join(r.events, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+//go:build broken
+
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"golang-training/module-10/exercise-7/closedsend"
+	"golang-training/module-10/exercise-7/doublelock"
+	"golang-training/module-10/exercise-7/todostore"
+	"golang-training/module-10/exercise-7/waitgroup"
+)
+
+func init() {
+	// Only main runs: the runtime sees every goroutine blocked and stops
+	// with "fatal error: all goroutines are asleep - deadlock!"
+	scenarios["doublelock-broken"] = func() {
+		s := doublelock.NewBrokenStock()
+		s.Add("P001", 3)
+		fmt.Println("take 2:", s.Take("P001", 2))
+	}
+	scenarios["closedsend-broken"] = func() {
+		prices := map[string]int{"P001": 1999, "P002": 0, "P003": 450, "P004": 12500}
+		fmt.Println(closedsend.Summary(closedsend.BrokenCheckPrices(prices, 3)))
+	}
+	scenarios["waitgroup-broken"] = func() {
+		for _, m := range waitgroup.BrokenSendReminders([]string{"Buy milk", "Call Ana", "Pay rent"}) {
+			fmt.Printf("%q\n", m)
+		}
+	}
+	scenarios["todostore-broken"] = func() {
+		s := todostore.NewBrokenTodoStore()
+		var wg sync.WaitGroup
+		for i := range 1000 {
+			wg.Go(func() { s.Create(fmt.Sprintf("todo %d", i)) })
+		}
+		wg.Wait()
+		fmt.Println("todos created:", len(s.List()))
+	}
+}
@@ -0,0 +1,35 @@
+//go:build broken
+
+package closedsend
+
+import "sync"
+
+// BrokenCheckPrices lets each worker close results when it runs out of
+// jobs. The first worker to finish closes it while the others still send:
+// "panic: send on closed channel", or "close of closed channel".
+func BrokenCheckPrices(prices map[string]int, workers int) []Check {
+	jobs := make(chan string)
+	results := make(chan Check)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			defer close(results)
+			for product := range jobs {
+				results <- Check{Product: product, Valid: prices[product] > 0}
+			}
+		})
+	}
+	go func() {
+		for product := range prices {
+			jobs <- product
+		}
+		close(jobs)
+	}()
+
+	var checks []Check
+	for c := range results {
+		checks = append(checks, c)
+	}
+	return checks
+}
@@ -0,0 +1,13 @@
+//go:build broken
+
+package closedsend
+
+import "testing"
+
+// The panic happens in a worker goroutine: no recover in the test can catch
+// it, and the whole test binary stops with the stack of the send
+func TestBrokenCheckPricesPanics(t *testing.T) {
+	for range 50 {
+		BrokenCheckPrices(prices(200), 8)
+	}
+}
@@ -0,0 +1,59 @@
+// Package closedsend checks prices with several workers sending to one
+// results channel. Sending on a closed channel panics, so only the last
+// sender may close it, once every sender is done.
+package closedsend
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Check is the result of checking a price
+type Check struct {
+	Product string
+	Valid   bool
+}
+
+// CheckPrices checks the prices with workers goroutines, and returns the
+// results in no particular order
+func CheckPrices(prices map[string]int, workers int) []Check {
+	jobs := make(chan string)
+	results := make(chan Check)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for product := range jobs {
+				results <- Check{Product: product, Valid: prices[product] > 0}
+			}
+		})
+	}
+	go func() {
+		for product := range prices {
+			jobs <- product
+		}
+		close(jobs)
+	}()
+	// The fix: one goroutine closes results after every worker returned
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var checks []Check
+	for c := range results {
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// Summary counts the valid prices
+func Summary(checks []Check) string {
+	valid := 0
+	for _, c := range checks {
+		if c.Valid {
+			valid++
+		}
+	}
+	return fmt.Sprintf("%d checked, %d valid", len(checks), valid)
+}
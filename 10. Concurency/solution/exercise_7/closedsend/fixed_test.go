@@ -0,0 +1,23 @@
+package closedsend
+
+import (
+	"fmt"
+	"testing"
+)
+
+func prices(n int) map[string]int {
+	p := make(map[string]int, n)
+	for i := range n {
+		p[fmt.Sprintf("P%03d", i)] = i % 10
+	}
+	return p
+}
+
+func TestCheckPrices(t *testing.T) {
+	for range 50 {
+		checks := CheckPrices(prices(200), 8)
+		if got := Summary(checks); got != "200 checked, 180 valid" {
+			t.Fatalf("got %q", got)
+		}
+	}
+}
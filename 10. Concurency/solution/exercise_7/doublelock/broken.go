@@ -0,0 +1,24 @@
+//go:build broken
+
+package doublelock
+
+// BrokenStock is Stock with the bug
+type BrokenStock struct {
+	Stock
+}
+
+func NewBrokenStock() *BrokenStock {
+	return &BrokenStock{Stock: *NewStock()}
+}
+
+// Take checks the units with the exported Units, which locks the mutex
+// Take already holds: the goroutine waits for itself forever.
+func (s *BrokenStock) Take(product string, n int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Units(product) < n {
+		return false
+	}
+	s.units[product] -= n
+	return true
+}
@@ -0,0 +1,24 @@
+//go:build broken
+
+package doublelock
+
+import (
+	"testing"
+	"time"
+)
+
+// The runtime reports "all goroutines are asleep" only when every goroutine
+// is blocked. In a test, or a server, other goroutines are alive: a
+// deadlock is a call that never returns, found with a timeout.
+func TestBrokenTakeDeadlocks(t *testing.T) {
+	s := NewBrokenStock()
+	s.Add("P001", 10)
+
+	done := make(chan bool)
+	go func() { done <- s.Take("P001", 1) }()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Take did not return within 1s: deadlock")
+	}
+}
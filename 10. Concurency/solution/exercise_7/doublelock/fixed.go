@@ -0,0 +1,48 @@
+// Package doublelock is a counter of stock locked twice by the same
+// goroutine. sync.Mutex is not reentrant: the second Lock waits for the
+// first to be unlocked, which never happens.
+package doublelock
+
+import "sync"
+
+// Stock counts the units of each product
+type Stock struct {
+	mu    sync.Mutex
+	units map[string]int
+}
+
+func NewStock() *Stock {
+	return &Stock{units: make(map[string]int)}
+}
+
+// Units returns the units of a product
+func (s *Stock) Units(product string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.units[product]
+}
+
+// Add adds units of a product
+func (s *Stock) Add(product string, n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.units[product] += n
+}
+
+// Take removes n units if there are enough, and reports whether it did. The
+// fix: methods holding the lock call unexported helpers that expect it held,
+// never the exported methods taking it.
+func (s *Stock) Take(product string, n int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.unitsLocked(product) < n {
+		return false
+	}
+	s.units[product] -= n
+	return true
+}
+
+// unitsLocked must be called with s.mu held
+func (s *Stock) unitsLocked(product string) int {
+	return s.units[product]
+}
@@ -0,0 +1,28 @@
+package doublelock
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestTakeConcurrently(t *testing.T) {
+	s := NewStock()
+	s.Add("P001", 100)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	taken := 0
+	for range 150 {
+		wg.Go(func() {
+			if s.Take("P001", 1) {
+				mu.Lock()
+				taken++
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	if taken != 100 || s.Units("P001") != 0 {
+		t.Errorf("took %d units, %d left; want 100 and 0", taken, s.Units("P001"))
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang-training/module-10/exercise-7/closedsend"
+	"golang-training/module-10/exercise-7/doublelock"
+	"golang-training/module-10/exercise-7/todostore"
+	"golang-training/module-10/exercise-7/waitgroup"
+)
+
+// scenarios maps a bug name to a program running the fixed version; the
+// broken versions are added by broken.go when built with -tags broken
+var scenarios = map[string]func(){
+	"doublelock": func() {
+		s := doublelock.NewStock()
+		s.Add("P001", 3)
+		fmt.Println("take 2:", s.Take("P001", 2))
+		fmt.Println("take 2:", s.Take("P001", 2))
+		fmt.Println("left:", s.Units("P001"))
+	},
+	"closedsend": func() {
+		prices := map[string]int{"P001": 1999, "P002": 0, "P003": 450, "P004": 12500}
+		fmt.Println(closedsend.Summary(closedsend.CheckPrices(prices, 3)))
+	},
+	"waitgroup": func() {
+		for _, m := range waitgroup.SendReminders([]string{"Buy milk", "Call Ana", "Pay rent"}) {
+			fmt.Println(m)
+		}
+	},
+	"todostore": func() {
+		s := todostore.NewTodoStore()
+		var wg sync.WaitGroup
+		for i := range 1000 {
+			wg.Go(func() { s.Create(fmt.Sprintf("todo %d", i)) })
+		}
+		wg.Wait()
+		fmt.Println("todos created:", len(s.List()))
+	},
+}
+
+func main() {
+	bug := flag.String("bug", "", "scenario to run: "+strings.Join(slices.Sorted(maps.Keys(scenarios)), ", "))
+	flag.Parse()
+
+	if *bug == "" {
+		for _, name := range slices.Sorted(maps.Keys(scenarios)) {
+			fmt.Printf("== %s\n", name)
+			scenarios[name]()
+		}
+		return
+	}
+	run, ok := scenarios[*bug]
+	if !ok {
+		log.Fatalf("unknown scenario %q", *bug)
+	}
+	run()
+}
@@ -0,0 +1,28 @@
+//go:build broken
+
+package todostore
+
+import "time"
+
+// BrokenTodoStore is TodoStore as the first server exercise has it: no lock.
+// Concurrent Creates hand out the same ID and lose todos when two appends
+// write the same slot; go test -race reports "DATA RACE" on nextID.
+type BrokenTodoStore struct {
+	todos  []Todo
+	nextID int
+}
+
+func NewBrokenTodoStore() *BrokenTodoStore {
+	return &BrokenTodoStore{nextID: 1}
+}
+
+func (s *BrokenTodoStore) Create(title string) Todo {
+	todo := Todo{ID: s.nextID, Title: title, CreatedAt: time.Now()}
+	s.nextID++
+	s.todos = append(s.todos, todo)
+	return todo
+}
+
+func (s *BrokenTodoStore) List() []Todo {
+	return s.todos
+}
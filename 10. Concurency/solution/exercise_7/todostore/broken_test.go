@@ -0,0 +1,32 @@
+//go:build broken
+
+package todostore
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// Without -race this test usually passes: a race does not have to corrupt
+// anything on a given run. With -race it fails every time.
+func TestBrokenConcurrentCreate(t *testing.T) {
+	s := NewBrokenTodoStore()
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Go(func() { s.Create(fmt.Sprintf("todo %d", i)) })
+	}
+	wg.Wait()
+
+	todos := s.List()
+	seen := make(map[int]bool)
+	for _, todo := range todos {
+		if seen[todo.ID] {
+			t.Errorf("ID %d handed out twice", todo.ID)
+		}
+		seen[todo.ID] = true
+	}
+	if len(todos) != 100 {
+		t.Errorf("got %d todos, want 100", len(todos))
+	}
+}
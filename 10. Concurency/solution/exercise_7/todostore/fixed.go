@@ -0,0 +1,60 @@
+// Package todostore is the TodoStore of the server exercises (module 12,
+// exercise 1): a slice and a counter shared by every request handler. The
+// HTTP server runs each request in its own goroutine, so without a lock two
+// POST requests write nextID and the slice at the same time.
+package todostore
+
+import (
+	"sync"
+	"time"
+)
+
+// Todo represents a todo item
+type Todo struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Completed bool      `json:"completed"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TodoStore manages the todo items; safe for concurrent use
+type TodoStore struct {
+	mu     sync.RWMutex
+	todos  []Todo
+	nextID int
+}
+
+func NewTodoStore() *TodoStore {
+	return &TodoStore{nextID: 1}
+}
+
+// Create adds a todo and returns it with its ID
+func (s *TodoStore) Create(title string) Todo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	todo := Todo{ID: s.nextID, Title: title, CreatedAt: time.Now()}
+	s.nextID++
+	s.todos = append(s.todos, todo)
+	return todo
+}
+
+// Complete marks a todo completed, and reports whether it exists
+func (s *TodoStore) Complete(id int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.todos {
+		if s.todos[i].ID == id {
+			s.todos[i].Completed = true
+			return true
+		}
+	}
+	return false
+}
+
+// List returns a copy of the todos: returning s.todos itself would let the
+// caller read the slice after the lock is released
+func (s *TodoStore) List() []Todo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Todo(nil), s.todos...)
+}
@@ -0,0 +1,37 @@
+package todostore
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// Run with go test -race: the detector only sees races that happen, so the
+// test has readers and writers at the same time
+func TestConcurrentCreate(t *testing.T) {
+	s := NewTodoStore()
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Go(func() {
+			todo := s.Create(fmt.Sprintf("todo %d", i))
+			s.Complete(todo.ID)
+		})
+		wg.Go(func() { _ = len(s.List()) })
+	}
+	wg.Wait()
+
+	todos := s.List()
+	if len(todos) != 100 {
+		t.Fatalf("got %d todos, want 100", len(todos))
+	}
+	seen := make(map[int]bool)
+	for _, todo := range todos {
+		if seen[todo.ID] {
+			t.Errorf("ID %d handed out twice", todo.ID)
+		}
+		if !todo.Completed {
+			t.Errorf("todo %d not completed", todo.ID)
+		}
+		seen[todo.ID] = true
+	}
+}
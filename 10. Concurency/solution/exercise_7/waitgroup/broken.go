@@ -0,0 +1,26 @@
+//go:build broken
+
+package waitgroup
+
+import (
+	"fmt"
+	"sync"
+)
+
+// BrokenSendReminders calls Add in the goroutine. Wait often returns before
+// any goroutine ran, with empty messages; and the goroutines write the
+// slice while the caller reads it, a data race. go vet reports it:
+// "WaitGroup.Add called from inside new goroutine".
+func BrokenSendReminders(todos []string) []string {
+	messages := make([]string, len(todos))
+	var wg sync.WaitGroup
+	for i, todo := range todos {
+		go func() {
+			wg.Add(1)
+			defer wg.Done()
+			messages[i] = fmt.Sprintf("Reminder: %s", todo)
+		}()
+	}
+	wg.Wait()
+	return messages
+}
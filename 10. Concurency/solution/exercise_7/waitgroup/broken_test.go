@@ -0,0 +1,15 @@
+//go:build broken
+
+package waitgroup
+
+import "testing"
+
+func TestBrokenSendReminders(t *testing.T) {
+	for range 100 {
+		for i, m := range BrokenSendReminders(todos(50)) {
+			if m == "" {
+				t.Fatalf("message %d missing: Wait returned too early", i)
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// Package waitgroup sends reminders concurrently and waits for them with a
+// sync.WaitGroup. Add must happen before the goroutine starts: called inside
+// it, Wait may run first, see a zero counter and return at once.
+package waitgroup
+
+import (
+	"fmt"
+	"sync"
+)
+
+// SendReminders sends a reminder for each todo and returns the messages
+func SendReminders(todos []string) []string {
+	messages := make([]string, len(todos))
+	var wg sync.WaitGroup
+	for i, todo := range todos {
+		wg.Add(1) // The fix: counted before the goroutine exists
+		go func() {
+			defer wg.Done()
+			messages[i] = fmt.Sprintf("Reminder: %s", todo)
+		}()
+	}
+	wg.Wait()
+	return messages
+}
+
+// SendRemindersGo is SendReminders with WaitGroup.Go (Go 1.25), which does
+// the Add and the Done itself
+func SendRemindersGo(todos []string) []string {
+	messages := make([]string, len(todos))
+	var wg sync.WaitGroup
+	for i, todo := range todos {
+		wg.Go(func() {
+			messages[i] = fmt.Sprintf("Reminder: %s", todo)
+		})
+	}
+	wg.Wait()
+	return messages
+}
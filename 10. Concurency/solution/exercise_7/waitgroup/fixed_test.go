@@ -0,0 +1,31 @@
+package waitgroup
+
+import (
+	"fmt"
+	"testing"
+)
+
+func todos(n int) []string {
+	t := make([]string, n)
+	for i := range t {
+		t[i] = fmt.Sprintf("todo %d", i)
+	}
+	return t
+}
+
+func TestSendReminders(t *testing.T) {
+	for name, send := range map[string]func([]string) []string{
+		"Add": SendReminders,
+		"Go":  SendRemindersGo,
+	} {
+		t.Run(name, func(t *testing.T) {
+			for range 100 {
+				for i, m := range send(todos(50)) {
+					if m == "" {
+						t.Fatalf("message %d missing: Wait returned too early", i)
+					}
+				}
+			}
+		})
+	}
+}
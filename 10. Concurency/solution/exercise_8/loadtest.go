@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Config describes a load test
+type Config struct {
+	URL         string
+	Method      string
+	Body        []byte
+	Concurrency int
+	Duration    time.Duration
+	Rate        int // Requests per second for all workers together; 0 means as fast as possible
+	Timeout     time.Duration
+}
+
+// sample is the outcome of one request
+type sample struct {
+	latency time.Duration
+	status  int
+	err     error
+}
+
+// Run sends requests with a pool of Concurrency workers for Duration. The
+// producer hands out one job per request: as fast as workers take them, or
+// one per tick of the rate limiter. A tick finding every worker busy is
+// counted as missed instead of queued, so the report shows when the target
+// cannot keep up with the rate.
+func Run(ctx context.Context, cfg Config) *Report {
+	client := &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			// The default keeps 2 idle connections per host: the other
+			// workers would open a new connection for every request
+			MaxIdleConnsPerHost: cfg.Concurrency,
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	jobs := make(chan struct{})
+	results := make(chan []sample, cfg.Concurrency)
+	var wg sync.WaitGroup
+	for range cfg.Concurrency {
+		wg.Go(func() {
+			// Each worker keeps its samples and hands them over once, so
+			// workers never wait for each other while measuring
+			var samples []sample
+			for range jobs {
+				samples = append(samples, do(client, cfg))
+			}
+			results <- samples
+		})
+	}
+
+	start := time.Now()
+	missed := produce(ctx, jobs, cfg.Rate)
+	close(jobs)
+	wg.Wait()
+	elapsed := time.Since(start)
+	close(results)
+
+	report := newReport(cfg, elapsed, missed)
+	for samples := range results {
+		for _, s := range samples {
+			report.add(s)
+		}
+	}
+	return report
+}
+
+// produce sends jobs until ctx is done and returns the ticks missed
+func produce(ctx context.Context, jobs chan<- struct{}, rate int) int {
+	if rate <= 0 {
+		for {
+			select {
+			case jobs <- struct{}{}:
+			case <-ctx.Done():
+				return 0
+			}
+		}
+	}
+
+	ticker := time.NewTicker(time.Second / time.Duration(rate))
+	defer ticker.Stop()
+	missed := 0
+	for {
+		select {
+		case <-ticker.C:
+			select {
+			case jobs <- struct{}{}:
+			default:
+				missed++
+			}
+		case <-ctx.Done():
+			return missed
+		}
+	}
+}
+
+// do sends one request. Requests started before the end of the test are not
+// cancelled with it: they finish, or fail after the client timeout.
+func do(client *http.Client, cfg Config) sample {
+	req, err := http.NewRequest(cfg.Method, cfg.URL, bytes.NewReader(cfg.Body))
+	if err != nil {
+		return sample{err: err}
+	}
+	if len(cfg.Body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return sample{latency: time.Since(start), err: err}
+	}
+	// Read the body to the end, or the connection cannot be reused
+	_, err = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return sample{latency: time.Since(start), status: resp.StatusCode, err: err}
+}
+
+// classify names the kind of a request error for the breakdown
+func classify(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection refused"
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return "connection reset"
+	default:
+		return "other"
+	}
+}
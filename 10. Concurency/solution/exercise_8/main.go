@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+func main() {
+	var cfg Config
+	var body string
+	flag.StringVar(&cfg.URL, "url", "http://localhost:8080/api/v1/todos", "URL to load")
+	flag.StringVar(&cfg.Method, "method", "GET", "HTTP method")
+	flag.StringVar(&body, "body", "", "request body, sent as JSON")
+	flag.IntVar(&cfg.Concurrency, "c", 10, "number of workers")
+	flag.DurationVar(&cfg.Duration, "d", 10*time.Second, "duration of the test")
+	flag.IntVar(&cfg.Rate, "rate", 0, "requests per second for all workers, 0 for no limit")
+	flag.DurationVar(&cfg.Timeout, "timeout", 5*time.Second, "timeout of each request")
+	flag.Parse()
+
+	if u, err := url.Parse(cfg.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		log.Fatalf("invalid URL %q", cfg.URL)
+	}
+	if cfg.Concurrency < 1 || cfg.Duration <= 0 || cfg.Rate < 0 || cfg.Timeout <= 0 {
+		log.Fatal("-c must be at least 1, -d and -timeout positive, and -rate not negative")
+	}
+	cfg.Method = strings.ToUpper(cfg.Method)
+	cfg.Body = []byte(body)
+
+	// Ctrl+C ends the test early, with the report of what was sent
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Print(Run(ctx, cfg))
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Report summarizes the samples of a load test
+type Report struct {
+	cfg       Config
+	elapsed   time.Duration
+	missed    int
+	latencies []time.Duration // Of the requests that got a response
+	statuses  map[int]int
+	errors    map[string]int
+	examples  map[string]string // The first error of each kind
+}
+
+func newReport(cfg Config, elapsed time.Duration, missed int) *Report {
+	return &Report{
+		cfg:      cfg,
+		elapsed:  elapsed,
+		missed:   missed,
+		statuses: make(map[int]int),
+		errors:   make(map[string]int),
+		examples: make(map[string]string),
+	}
+}
+
+func (r *Report) add(s sample) {
+	if s.status == 0 {
+		kind := classify(s.err)
+		r.errors[kind]++
+		if _, ok := r.examples[kind]; !ok {
+			r.examples[kind] = s.err.Error()
+		}
+		return
+	}
+	r.latencies = append(r.latencies, s.latency)
+	r.statuses[s.status]++
+}
+
+// Requests counts the requests sent
+func (r *Report) Requests() int {
+	n := len(r.latencies)
+	for _, count := range r.errors {
+		n += count
+	}
+	return n
+}
+
+// Failed counts the requests without a response or with an error status
+func (r *Report) Failed() int {
+	n := 0
+	for _, count := range r.errors {
+		n += count
+	}
+	for status, count := range r.statuses {
+		if status >= 400 {
+			n += count
+		}
+	}
+	return n
+}
+
+// Percentile returns the latency under which p percent of the responses came
+func (r *Report) Percentile(p int) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	return r.latencies[(len(r.latencies)-1)*p/100]
+}
+
+func (r *Report) String() string {
+	slices.Sort(r.latencies)
+
+	var b strings.Builder
+	rate := "unlimited"
+	if r.cfg.Rate > 0 {
+		rate = fmt.Sprintf("%d/s", r.cfg.Rate)
+	}
+	fmt.Fprintf(&b, "Target     %s %s\n", r.cfg.Method, r.cfg.URL)
+	fmt.Fprintf(&b, "Load       %d workers for %v, rate %s\n", r.cfg.Concurrency, r.cfg.Duration, rate)
+
+	requests := r.Requests()
+	fmt.Fprintf(&b, "Requests   %d in %v, %.1f/s; %d failed", requests, r.elapsed.Round(time.Millisecond),
+		float64(requests)/r.elapsed.Seconds(), r.Failed())
+	if r.cfg.Rate > 0 {
+		fmt.Fprintf(&b, "; %d ticks missed with every worker busy", r.missed)
+	}
+	b.WriteString("\n")
+
+	if len(r.latencies) > 0 {
+		var total time.Duration
+		for _, l := range r.latencies {
+			total += l
+		}
+		fmt.Fprintf(&b, "Latency    min %v, p50 %v, p90 %v, p99 %v, max %v, mean %v\n",
+			round(r.latencies[0]), round(r.Percentile(50)), round(r.Percentile(90)), round(r.Percentile(99)),
+			round(r.latencies[len(r.latencies)-1]), round(total/time.Duration(len(r.latencies))))
+	}
+
+	for _, status := range slices.Sorted(maps.Keys(r.statuses)) {
+		fmt.Fprintf(&b, "Status     %d %s: %d\n", status, http.StatusText(status), r.statuses[status])
+	}
+	for _, kind := range slices.Sorted(maps.Keys(r.errors)) {
+		fmt.Fprintf(&b, "Error      %s: %d (%s)\n", kind, r.errors[kind], r.examples[kind])
+	}
+	return b.String()
+}
+
+// round keeps 3 significant digits or so: microseconds matter for a local
+// server, not for a remote one
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrChanged is returned when the remote file changed during the download
+var ErrChanged = errors.New("remote file changed")
+
+// StatusError is an unexpected response status
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d %s", e.Code, http.StatusText(e.Code))
+}
+
+// Downloader downloads a file in parallel ranged segments
+type Downloader struct {
+	Client   *http.Client
+	Segments int
+	Retries  int           // Attempts per segment after the first
+	Progress io.Writer     // Live progress on a terminal; nil for none
+	Interval time.Duration // Between two saves of the state and redraws of the progress
+	Log      func(format string, args ...any)
+}
+
+// Result describes a download
+type Result struct {
+	Size     int64
+	Resumed  int64 // Bytes already on disk from an earlier run
+	Fetched  int64 // Bytes fetched by this run
+	Segments int
+	Retries  int64
+	Elapsed  time.Duration
+	SHA256   string
+}
+
+// remote is what a probe learns of the file
+type remote struct {
+	size      int64
+	validator string
+	ranges    bool
+}
+
+// Download downloads url to path. The progress is saved to path+".state"
+// while it runs, and a later call resumes from it when the remote file is
+// the same; the state file is removed once the file is complete.
+func (d *Downloader) Download(ctx context.Context, url, path string) (*Result, error) {
+	start := time.Now()
+	r, err := d.probe(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	statePath := path + ".state"
+	st, resumed := d.resume(url, path, statePath, r)
+	if !resumed {
+		segments := d.Segments
+		if !r.ranges {
+			d.logf("the server does not accept ranges: one segment, no resume")
+			segments = 1
+		}
+		st = &state{URL: url, Size: r.size, Validator: r.validator, Segments: split(r.size, segments)}
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if !resumed {
+		// Preallocate the file: every segment writes at its own offset
+		if err := f.Truncate(r.size); err != nil {
+			return nil, err
+		}
+	}
+
+	res := &Result{Size: r.size, Segments: len(st.Segments)}
+	for _, s := range st.Segments {
+		res.Resumed += s.done.Load()
+	}
+	err = d.run(ctx, url, r, st, f, statePath)
+	for _, s := range st.Segments {
+		res.Fetched += s.done.Load()
+		res.Retries += s.retries.Load()
+	}
+	res.Fetched -= res.Resumed
+	res.Elapsed = time.Since(start)
+	if err != nil {
+		return res, err
+	}
+
+	if res.SHA256, err = checksum(f); err != nil {
+		return res, err
+	}
+	os.Remove(statePath)
+	return res, nil
+}
+
+// resume loads the state of an earlier run, if it matches the remote file
+func (d *Downloader) resume(url, path, statePath string, r remote) (*state, bool) {
+	st, err := loadState(statePath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			d.logf("ignoring the state: %v", err)
+		}
+		return nil, false
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != r.size || !r.ranges || !st.matches(url, r) {
+		d.logf("the state does not match the remote file: starting over")
+		return nil, false
+	}
+	return st, true
+}
+
+// run downloads the segments and saves the state every Interval. The first
+// segment to fail for good cancels the others.
+func (d *Downloader) run(ctx context.Context, url string, r remote, st *state, f *os.File, statePath string) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var wg sync.WaitGroup
+	for _, s := range st.Segments {
+		wg.Go(func() {
+			if err := d.fetch(ctx, url, r, s, f); err != nil {
+				cancel(err)
+			}
+		})
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	p := &progress{w: d.Progress, st: st, start: time.Now()}
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := d.checkpoint(st, f, statePath); err != nil {
+				cancel(err)
+			}
+			p.render()
+		case <-done:
+			p.render()
+			if ctx.Err() != nil && !st.complete() {
+				err := context.Cause(ctx)
+				// Keep what was written for the next run
+				if cerr := d.checkpoint(st, f, statePath); cerr != nil {
+					return errors.Join(err, cerr)
+				}
+				return err
+			}
+			return f.Sync()
+		}
+	}
+}
+
+// checkpoint saves the progress. The counters are read before the sync:
+// every byte they count was written before, so it is on disk once Sync
+// returns, and the state never claims bytes a crash could lose.
+func (d *Downloader) checkpoint(st *state, f *os.File, statePath string) error {
+	done := st.snapshot()
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return st.save(statePath, done)
+}
+
+// fetch downloads what is left of a segment, retrying with a backoff
+func (d *Downloader) fetch(ctx context.Context, url string, r remote, s *segment, f *os.File) error {
+	backoff := 100 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		err := d.fetchOnce(ctx, url, r, s, f)
+		if err == nil || ctx.Err() != nil || attempt == d.Retries || !retryable(err) {
+			return err
+		}
+		s.retries.Add(1)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff = min(2*backoff, 2*time.Second)
+	}
+}
+
+func (d *Downloader) fetchOnce(ctx context.Context, url string, r remote, s *segment, f *os.File) error {
+	done := s.done.Load()
+	if done == s.length() {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if r.ranges {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", s.Start+done, s.End))
+		// The server sends the range only if the file is still the same,
+		// and the whole file otherwise
+		if r.validator != "" {
+			req.Header.Set("If-Range", r.validator)
+		}
+	} else if done > 0 {
+		// Without ranges a retry starts over
+		s.done.Store(0)
+	}
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case r.ranges && resp.StatusCode == http.StatusOK:
+		return ErrChanged
+	case r.ranges && resp.StatusCode != http.StatusPartialContent, !r.ranges && resp.StatusCode != http.StatusOK:
+		return &StatusError{Code: resp.StatusCode}
+	}
+
+	if _, err := io.Copy(&segmentWriter{f: f, s: s}, io.LimitReader(resp.Body, s.length()-s.done.Load())); err != nil {
+		return err
+	}
+	if s.done.Load() < s.length() {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// segmentWriter writes at the offset of the next byte of its segment
+type segmentWriter struct {
+	f *os.File
+	s *segment
+}
+
+func (w *segmentWriter) Write(p []byte) (int, error) {
+	n, err := w.f.WriteAt(p, w.s.Start+w.s.done.Load())
+	w.s.done.Add(int64(n))
+	return n, err
+}
+
+// probe asks for the first byte: a 206 response tells that the server
+// accepts ranges, and its Content-Range gives the size of the file
+func (d *Downloader) probe(ctx context.Context, url string) (remote, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return remote{}, err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return remote{}, err
+	}
+	defer resp.Body.Close()
+
+	var r remote
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		_, total, ok := strings.Cut(resp.Header.Get("Content-Range"), "/")
+		if r.size, err = strconv.ParseInt(total, 10, 64); !ok || err != nil {
+			return remote{}, fmt.Errorf("invalid Content-Range %q", resp.Header.Get("Content-Range"))
+		}
+		r.ranges = true
+	case http.StatusOK:
+		if resp.ContentLength < 0 {
+			return remote{}, errors.New("the server sends no size")
+		}
+		r.size = resp.ContentLength
+	default:
+		return remote{}, &StatusError{Code: resp.StatusCode}
+	}
+
+	// If-Range accepts a strong ETag or a date, not a weak ETag
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		r.validator = etag
+	} else {
+		r.validator = resp.Header.Get("Last-Modified")
+	}
+	return r, nil
+}
+
+func retryable(err error) bool {
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.Code >= 500 || status.Code == http.StatusTooManyRequests
+	}
+	return !errors.Is(err, ErrChanged)
+}
+
+func checksum(f *os.File) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(f, 0, 1<<62)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (d *Downloader) logf(format string, args ...any) {
+	if d.Log != nil {
+		d.Log(format, args...)
+	}
+}
@@ -0,0 +1,3 @@
+module golang-training/module-10/exercise-9
+
+go 1.25
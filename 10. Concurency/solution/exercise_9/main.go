@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+)
+
+func main() {
+	url := flag.String("url", "", "URL of the file to download; empty runs the demo")
+	out := flag.String("o", "", "path of the downloaded file, by default the last element of the URL")
+	segments := flag.Int("n", 4, "number of parallel segments")
+	retries := flag.Int("retries", 5, "attempts per segment after the first")
+	expected := flag.String("sha256", "", "expected SHA-256 of the file, in hex")
+	flag.Parse()
+
+	d := &Downloader{
+		Client:   &http.Client{},
+		Segments: *segments,
+		Retries:  *retries,
+		Interval: 200 * time.Millisecond,
+		Log:      log.Printf,
+	}
+	if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		d.Progress = os.Stdout
+	}
+
+	if *url == "" {
+		demo(d)
+		return
+	}
+	if *out == "" {
+		*out = filepath.Base(*url)
+	}
+
+	// Ctrl+C stops the download; the next run resumes it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	res, err := d.Download(ctx, *url, *out)
+	if res != nil {
+		fmt.Println(res)
+	}
+	if err != nil {
+		log.Fatalf("download: %v (run again to resume)", err)
+	}
+	if *expected != "" && res.SHA256 != *expected {
+		log.Fatalf("checksum mismatch: got %s, want %s", res.SHA256, *expected)
+	}
+}
+
+// demo downloads a file of a local server, interrupts the download, resumes
+// it, and starts over when the file changed in between
+func demo(d *Downloader) {
+	srv := newDemoServer(32<<20, 1)
+	defer srv.Close()
+	dir, err := os.MkdirTemp("", "segments")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "file.bin")
+	url := srv.URL + "/file.bin"
+
+	fmt.Println("== Download interrupted after 1s")
+	interrupted(d, url, path)
+
+	fmt.Println("== Resume")
+	srv.served.Store(0)
+	res, err := d.Download(context.Background(), url, path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(res)
+	verify(res, srv.SHA256())
+	fmt.Printf("the server sent %s of the file this time\n", bytesize(srv.served.Load()))
+
+	fmt.Println("== File changed on the server between two runs")
+	interrupted(d, url, path)
+	srv.replace(32<<20, 2)
+	res, err = d.Download(context.Background(), url, path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(res)
+	verify(res, srv.SHA256())
+}
+
+func interrupted(d *Downloader, url, path string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	res, err := d.Download(ctx, url, path)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		log.Fatalf("expected an interrupted download, got %v", err)
+	}
+	fmt.Println(res)
+	st, err := loadState(path + ".state")
+	if err != nil {
+		log.Fatal(err)
+	}
+	for i, s := range st.Segments {
+		fmt.Printf("  state: segment %d has %s of %s\n", i+1, bytesize(s.Done), bytesize(s.length()))
+	}
+}
+
+func verify(res *Result, expected string) {
+	if res.SHA256 != expected {
+		log.Fatalf("checksum mismatch: got %s, want %s", res.SHA256, expected)
+	}
+	fmt.Println("checksum OK:", res.SHA256)
+}
+
+func (r *Result) String() string {
+	s := fmt.Sprintf("%s in %d segments, %s fetched in %v", bytesize(r.Size), r.Segments, bytesize(r.Fetched),
+		r.Elapsed.Round(time.Millisecond))
+	if r.Resumed > 0 {
+		s += fmt.Sprintf(", %s resumed from disk", bytesize(r.Resumed))
+	}
+	if r.Retries > 0 {
+		s += fmt.Sprintf(", %d retries", r.Retries)
+	}
+	return s
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// progress redraws one bar per segment and a total line in place, moving the
+// cursor up over the previous drawing with an ANSI escape sequence
+type progress struct {
+	w     io.Writer
+	st    *state
+	start time.Time
+	first int64 // Bytes done when the run started, to measure its speed
+	lines int
+}
+
+func (p *progress) render() {
+	if p.w == nil {
+		return
+	}
+	var b strings.Builder
+	if p.lines > 0 {
+		fmt.Fprintf(&b, "\033[%dA", p.lines)
+	} else {
+		p.first = sum(p.st.snapshot())
+	}
+
+	done := p.st.snapshot()
+	for i, s := range p.st.Segments {
+		fmt.Fprintf(&b, "\r\033[K  segment %-2d %s %5.1f%%", i+1, bar(done[i], s.length(), 30), percent(done[i], s.length()))
+		if r := s.retries.Load(); r > 0 {
+			fmt.Fprintf(&b, "  %d retries", r)
+		}
+		b.WriteString("\n")
+	}
+	total := sum(done)
+	speed := float64(total-p.first) / time.Since(p.start).Seconds()
+	fmt.Fprintf(&b, "\r\033[K  total      %s %5.1f%%  %s/s\n", bar(total, p.st.Size, 30), percent(total, p.st.Size), bytesize(int64(speed)))
+	p.lines = len(p.st.Segments) + 1
+	io.WriteString(p.w, b.String())
+}
+
+func bar(done, length int64, width int) string {
+	filled := width
+	if length > 0 {
+		filled = int(done * int64(width) / length)
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", width-filled) + "]"
+}
+
+func percent(done, length int64) float64 {
+	if length == 0 {
+		return 100
+	}
+	return float64(done) * 100 / float64(length)
+}
+
+func sum(done []int64) int64 {
+	var total int64
+	for _, d := range done {
+		total += d
+	}
+	return total
+}
+
+// bytesize formats a number of bytes in binary units
+func bytesize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// demoServer serves a file slowly, with ranges, and aborts some responses
+// halfway, to watch the segments, their retries and a resume
+type demoServer struct {
+	*httptest.Server
+	served   atomic.Int64 // Bytes of the file written to clients
+	requests atomic.Int64
+
+	mu      sync.Mutex
+	data    []byte
+	etag    string
+	modtime time.Time
+}
+
+func newDemoServer(size int, seed uint64) *demoServer {
+	s := &demoServer{}
+	s.replace(size, seed)
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// replace changes the content of the file, and so its ETag
+func (s *demoServer) replace(size int, seed uint64) {
+	data := make([]byte, size)
+	rand.NewChaCha8([32]byte{byte(seed)}).Read(data)
+	sum := sha256.Sum256(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = data
+	s.etag = `"` + hex.EncodeToString(sum[:8]) + `"`
+	s.modtime = time.Now()
+}
+
+// SHA256 returns the checksum of the file, which a real server publishes
+// next to it
+func (s *demoServer) SHA256() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sum := sha256.Sum256(s.data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *demoServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	data, etag, modtime := s.data, s.etag, s.modtime
+	s.mu.Unlock()
+
+	// One response in 4 breaks after 1 MiB
+	abortAfter := int64(-1)
+	if s.requests.Add(1)%4 == 0 {
+		abortAfter = 1 << 20
+	}
+	// http.ServeContent answers Range and If-Range requests from the ETag
+	w.Header().Set("ETag", etag)
+	tw := &throttledWriter{ResponseWriter: w, server: s, abortAfter: abortAfter}
+	http.ServeContent(tw, r, "file.bin", modtime, bytes.NewReader(data))
+}
+
+// throttledWriter sends about 8 MiB/s per response
+type throttledWriter struct {
+	http.ResponseWriter
+	server     *demoServer
+	written    int64
+	abortAfter int64
+}
+
+func (w *throttledWriter) Write(p []byte) (int, error) {
+	if w.abortAfter >= 0 && w.written >= w.abortAfter {
+		// The server closes the connection without ending the response
+		panic(http.ErrAbortHandler)
+	}
+	time.Sleep(time.Duration(len(p)) * time.Second / (8 << 20))
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	w.server.served.Add(int64(n))
+	return n, err
+}
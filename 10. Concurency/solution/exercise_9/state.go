@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sync/atomic"
+)
+
+// segment is a byte range of the file, End included, downloaded by one
+// goroutine. Done counts the bytes written from Start, so a resumed
+// download asks for Start+Done to End.
+type segment struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	Done  int64 `json:"done"`
+
+	done    atomic.Int64 // Done while the download runs
+	retries atomic.Int64
+}
+
+func (s *segment) length() int64 { return s.End - s.Start + 1 }
+
+// state is saved next to the file being downloaded, to resume it
+type state struct {
+	URL       string     `json:"url"`
+	Size      int64      `json:"size"`
+	Validator string     `json:"validator"` // ETag or Last-Modified of the remote file
+	Segments  []*segment `json:"segments"`
+}
+
+// split cuts size bytes into n segments of nearly equal length
+func split(size int64, n int) []*segment {
+	n = int(min(int64(n), max(size, 1)))
+	segments := make([]*segment, n)
+	for i := range n {
+		start := size * int64(i) / int64(n)
+		end := size*int64(i+1)/int64(n) - 1
+		segments[i] = &segment{Start: start, End: end}
+	}
+	return segments
+}
+
+func loadState(path string) (*state, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, err
+	}
+	for _, s := range st.Segments {
+		s.done.Store(min(s.Done, s.length()))
+	}
+	return &st, nil
+}
+
+// save writes the state to a temporary file renamed over the old one, so a
+// crash leaves either state whole. The caller must have synced the bytes
+// counted in done before.
+func (st *state) save(path string, done []int64) error {
+	for i, s := range st.Segments {
+		s.Done = done[i]
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// snapshot reads the bytes done of each segment
+func (st *state) snapshot() []int64 {
+	done := make([]int64, len(st.Segments))
+	for i, s := range st.Segments {
+		done[i] = s.done.Load()
+	}
+	return done
+}
+
+// complete reports whether every segment is downloaded
+func (st *state) complete() bool {
+	for _, s := range st.Segments {
+		if s.done.Load() < s.length() {
+			return false
+		}
+	}
+	return true
+}
+
+// matches reports whether a saved state can resume the download of remote
+func (st *state) matches(url string, r remote) bool {
+	return st.URL == url && st.Size == r.size && st.Validator == r.validator && len(st.Segments) > 0
+}
@@ -0,0 +1,160 @@
+// Package archive writes and extracts zip and tar.gz archives as streams:
+// an archive is written straight to an io.Writer, such as an HTTP response,
+// and a tar.gz is extracted as it is read, without temporary files.
+//
+// Extraction never trusts the archive: names leaving the destination
+// directory are rejected, every write goes through an os.Root so a symbolic
+// link can't lead outside either, and the number of files and the bytes
+// written are limited whatever sizes the headers claim.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Format is an archive format
+type Format int
+
+const (
+	Zip Format = iota
+	TarGz
+)
+
+// ParseFormat reads a format name: zip, tar.gz or tgz
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "zip":
+		return Zip, nil
+	case "tar.gz", "tgz":
+		return TarGz, nil
+	}
+	return 0, fmt.Errorf("unknown archive format %q", name)
+}
+
+// FormatOf guesses the format from the extension of a file name
+func FormatOf(name string) (Format, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return Zip, nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return TarGz, nil
+	}
+	return 0, fmt.Errorf("%s: unknown archive extension", name)
+}
+
+func (f Format) String() string {
+	if f == TarGz {
+		return "tar.gz"
+	}
+	return "zip"
+}
+
+// Ext returns the file extension of the format, with its dot
+func (f Format) Ext() string {
+	return "." + f.String()
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	if f == TarGz {
+		return "application/gzip"
+	}
+	return "application/zip"
+}
+
+// Writer writes an archive to an io.Writer as files are added
+type Writer struct {
+	zw *zip.Writer
+	tw *tar.Writer
+	gw *gzip.Writer
+}
+
+// NewWriter starts an archive on w. A zip written to a stream puts the size
+// and CRC of each file after its content, as the writer can't seek back.
+func NewWriter(w io.Writer, format Format) *Writer {
+	if format == TarGz {
+		gw := gzip.NewWriter(w)
+		return &Writer{gw: gw, tw: tar.NewWriter(gw)}
+	}
+	return &Writer{zw: zip.NewWriter(w)}
+}
+
+// AddFile adds a regular file named name, with the size, mode and
+// modification time of info, copying its content from r
+func (w *Writer) AddFile(name string, info fs.FileInfo, r io.Reader) error {
+	name = path.Clean(filepath.ToSlash(name))
+	if !fs.ValidPath(name) {
+		return fmt.Errorf("%s: %w", name, ErrUnsafePath)
+	}
+
+	var dst io.Writer
+	if w.zw != nil {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		header.Method = zip.Deflate
+		if dst, err = w.zw.CreateHeader(header); err != nil {
+			return err
+		}
+	} else {
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		// Tar stores the owner by default: it means nothing on another machine
+		header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+		if err := w.tw.WriteHeader(header); err != nil {
+			return err
+		}
+		dst = w.tw
+	}
+
+	// A tar header announces the size: a file that grew since is cut, one
+	// that shrank fails the tar writer
+	_, err := io.Copy(dst, io.LimitReader(r, info.Size()))
+	return err
+}
+
+// AddFS adds every regular file of fsys, with its path in fsys as name
+func (w *Writer) AddFS(fsys fs.FS) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return w.AddFile(name, info, f)
+	})
+}
+
+// Close writes the end of the archive: the central directory of a zip, the
+// trailer of a tar and of its gzip stream. Without it the archive is
+// corrupt, which a reader can detect.
+func (w *Writer) Close() error {
+	if w.zw != nil {
+		return w.zw.Close()
+	}
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	return w.gw.Close()
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang-training/module-11/archive"
+)
+
+// demo archives a directory in both formats, extracts the archives, then
+// extracts three hostile archives: a path leaving the directory, a symbolic
+// link, and a zip bomb
+func demo() error {
+	tmp, err := os.MkdirTemp("", "arc-demo")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	src := filepath.Join(tmp, "src")
+	for name, content := range map[string]string{
+		"notes.txt":        "Buy milk\n",
+		"docs/readme.md":   "# Readme\n" + strings.Repeat("Lorem ipsum dolor sit amet. ", 200),
+		"docs/img/dot.svg": `<svg xmlns="http://www.w3.org/2000/svg"><circle r="1"/></svg>`,
+	} {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return err
+		}
+	}
+
+	for _, format := range []archive.Format{archive.Zip, archive.TarGz} {
+		// The archive is written to a buffer here, to any io.Writer in
+		// general: a file, a pipe, an HTTP response
+		var buf bytes.Buffer
+		w := archive.NewWriter(&buf, format)
+		if err := w.AddFS(os.DirFS(src)); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+
+		dir := filepath.Join(tmp, "out-"+format.String())
+		stats, err := extractBytes(buf.Bytes(), format, dir, archive.DefaultLimits)
+		if err != nil {
+			return err
+		}
+		same, err := sameFiles(src, dir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%-6s %5d bytes: %d files, %d bytes extracted, identical: %v\n",
+			format, buf.Len(), stats.Files, stats.Bytes, same)
+	}
+
+	fmt.Println("hostile archives:")
+	slip := evilTarGz([][2]string{{"ok.txt", "fine"}, {"../../outside.txt", "escaped"}})
+	_, err = archive.ExtractTarGz(bytes.NewReader(slip), filepath.Join(tmp, "slip"), archive.DefaultLimits)
+	_, statErr := os.Stat(filepath.Join(tmp, "..", "outside.txt"))
+	fmt.Printf("  zip slip:     %v; written outside: %v\n", err, statErr == nil)
+
+	link := evilTarGzLink()
+	stats, err := archive.ExtractTarGz(bytes.NewReader(link), filepath.Join(tmp, "link"), archive.DefaultLimits)
+	fmt.Printf("  symlink:      err %v, skipped %v\n", err, stats.Skipped)
+
+	bomb := zipBomb(1 << 30)
+	limits := archive.Limits{MaxFiles: 10, MaxTotalSize: 10 << 20}
+	start := time.Now()
+	stats, err = archive.ExtractZip(bytes.NewReader(bomb), int64(len(bomb)), filepath.Join(tmp, "bomb"), limits)
+	fmt.Printf("  zip bomb:     %d KiB claiming 1 GiB: %v after %d MiB in %v\n",
+		len(bomb)>>10, err, stats.Bytes>>20, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func extractBytes(data []byte, format archive.Format, dir string, limits archive.Limits) (*archive.Stats, error) {
+	if format == archive.TarGz {
+		return archive.ExtractTarGz(bytes.NewReader(data), dir, limits)
+	}
+	return archive.ExtractZip(bytes.NewReader(data), int64(len(data)), dir, limits)
+}
+
+// sameFiles compares the regular files of two directories
+func sameFiles(a, b string) (bool, error) {
+	same := true
+	err := filepath.WalkDir(a, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(a, path)
+		want, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		got, err := os.ReadFile(filepath.Join(b, rel))
+		if err != nil || !bytes.Equal(got, want) {
+			same = false
+		}
+		return nil
+	})
+	return same, err
+}
+
+// evilTarGz writes entries with any name, which archive.Writer refuses
+func evilTarGz(files [][2]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		name, content := f[0], f[1]
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		io.WriteString(tw, content)
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+// evilTarGzLink holds a link to /etc and a file written through it
+func evilTarGzLink() []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "etc", Linkname: "/etc", Typeflag: tar.TypeSymlink})
+	tw.WriteHeader(&tar.Header{Name: "etc/evil.conf", Mode: 0o644, Size: 4, Typeflag: tar.TypeReg})
+	io.WriteString(tw, "evil")
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+// zipBomb compresses size zero bytes: deflate shrinks them about 1000 times
+func zipBomb(size int64) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("zeros.bin")
+	io.CopyN(w, zeros{}, size)
+	zw.Close()
+	return buf.Bytes()
+}
+
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
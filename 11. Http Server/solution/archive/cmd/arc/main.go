@@ -0,0 +1,186 @@
+// Command arc creates, lists and extracts zip and tar.gz archives with the
+// archive package:
+//
+//	go run ./cmd/arc create -o notes.tar.gz notes/
+//	go run ./cmd/arc create -format zip notes/ > notes.zip
+//	go run ./cmd/arc list notes.zip
+//	go run ./cmd/arc extract -C out notes.tar.gz
+//	curl -s localhost:8080/api/files/archive | go run ./cmd/arc extract -C out -format tar.gz -
+//	go run ./cmd/arc demo
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"golang-training/module-11/archive"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("arc: ")
+	if len(os.Args) < 2 {
+		log.Fatal("usage: arc create|list|extract|demo [flags] ...")
+	}
+
+	var err error
+	switch args := os.Args[2:]; os.Args[1] {
+	case "create":
+		err = create(args)
+	case "list":
+		err = list(args)
+	case "extract":
+		err = extract(args)
+	case "demo":
+		err = demo()
+	default:
+		err = fmt.Errorf("unknown command %q", os.Args[1])
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// create archives a directory to a file or to the standard output
+func create(args []string) error {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	out := fs.String("o", "-", "archive to write, - for the standard output")
+	formatName := fs.String("format", "", "zip or tar.gz; by default the extension of -o")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: arc create [-o archive] [-format zip|tar.gz] dir")
+	}
+
+	format, err := formatFor(*formatName, *out)
+	if err != nil {
+		return err
+	}
+	w := io.Writer(os.Stdout)
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	aw := archive.NewWriter(w, format)
+	if err := aw.AddFS(os.DirFS(fs.Arg(0))); err != nil {
+		return err
+	}
+	return aw.Close()
+}
+
+// list prints the entries of an archive with their sizes, without
+// extracting it
+func list(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: arc list archive")
+	}
+	format, err := archive.FormatOf(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if format == archive.Zip {
+		zr, err := zip.OpenReader(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		for _, f := range zr.File {
+			fmt.Printf("%10d  %10d  %s  %s\n", f.UncompressedSize64, f.CompressedSize64, f.Mode(), f.Name)
+		}
+		return nil
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%10d  %s  %s\n", header.Size, header.FileInfo().Mode(), header.Name)
+	}
+}
+
+// extract extracts an archive file, or a tar.gz read from the standard input
+func extract(args []string) error {
+	fs := flag.NewFlagSet("extract", flag.ExitOnError)
+	dir := fs.String("C", ".", "directory to extract to")
+	formatName := fs.String("format", "", "zip or tar.gz; by default the extension of the archive")
+	maxSize := fs.Int64("max-size", archive.DefaultLimits.MaxTotalSize, "most bytes extracted")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: arc extract [-C dir] [-format zip|tar.gz] archive|-")
+	}
+	format, err := formatFor(*formatName, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	limits := archive.DefaultLimits
+	limits.MaxTotalSize = *maxSize
+
+	var stats *archive.Stats
+	switch {
+	case format == archive.TarGz && fs.Arg(0) == "-":
+		stats, err = archive.ExtractTarGz(os.Stdin, *dir, limits)
+	case format == archive.Zip && fs.Arg(0) == "-":
+		return errors.New("a zip can't be extracted from a stream: save it to a file first")
+	default:
+		f, ferr := os.Open(fs.Arg(0))
+		if ferr != nil {
+			return ferr
+		}
+		defer f.Close()
+		if format == archive.TarGz {
+			stats, err = archive.ExtractTarGz(f, *dir, limits)
+		} else {
+			info, serr := f.Stat()
+			if serr != nil {
+				return serr
+			}
+			stats, err = archive.ExtractZip(f, info.Size(), *dir, limits)
+		}
+	}
+	if stats != nil {
+		printStats(stats)
+	}
+	return err
+}
+
+func formatFor(name, file string) (archive.Format, error) {
+	if name != "" {
+		return archive.ParseFormat(name)
+	}
+	return archive.FormatOf(file)
+}
+
+func printStats(stats *archive.Stats) {
+	fmt.Printf("extracted %d files and %d directories, %d bytes\n", stats.Files, stats.Dirs, stats.Bytes)
+	for _, name := range stats.Skipped {
+		fmt.Printf("  skipped %s: not a regular file\n", name)
+	}
+}
@@ -0,0 +1,204 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// ErrUnsafePath is returned for an entry whose name would leave the
+	// destination directory, such as ../../etc/passwd or /etc/passwd
+	ErrUnsafePath = errors.New("unsafe path in archive")
+	// ErrTooLarge is returned when the content exceeds Limits
+	ErrTooLarge = errors.New("archive too large")
+	// ErrTooManyFiles is returned when the entries exceed Limits.MaxFiles
+	ErrTooManyFiles = errors.New("too many files in archive")
+)
+
+// Limits bound an extraction; zero means no limit
+type Limits struct {
+	MaxFiles     int
+	MaxFileSize  int64 // Uncompressed bytes of one file
+	MaxTotalSize int64 // Uncompressed bytes of every file
+}
+
+// DefaultLimits suit archives uploaded by users
+var DefaultLimits = Limits{MaxFiles: 10_000, MaxFileSize: 100 << 20, MaxTotalSize: 1 << 30}
+
+// Stats describes an extraction
+type Stats struct {
+	Files   int
+	Dirs    int
+	Bytes   int64
+	Skipped []string // Links and special files, never extracted
+}
+
+// ExtractTarGz extracts a tar.gz read from r into dir, as it is read. On
+// error, the files extracted so far are left in dir.
+func ExtractTarGz(r io.Reader, dir string, limits Limits) (*Stats, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	x, err := newExtractor(dir, limits)
+	if err != nil {
+		return nil, err
+	}
+	defer x.root.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return &x.stats, nil
+		}
+		if err != nil {
+			return &x.stats, err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = x.dir(header.Name)
+		case tar.TypeReg:
+			err = x.file(header.Name, header.FileInfo().Mode(), tr)
+		default:
+			x.stats.Skipped = append(x.stats.Skipped, header.Name)
+		}
+		if err != nil {
+			return &x.stats, err
+		}
+	}
+}
+
+// ExtractZip extracts a zip into dir. A zip lists its files at its end, so
+// it is read from an io.ReaderAt, such as an *os.File, and not from a
+// stream.
+func ExtractZip(r io.ReaderAt, size int64, dir string, limits Limits) (*Stats, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	x, err := newExtractor(dir, limits)
+	if err != nil {
+		return nil, err
+	}
+	defer x.root.Close()
+
+	for _, f := range zr.File {
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			err = x.dir(f.Name)
+		case mode.IsRegular():
+			err = x.zipFile(f)
+		default:
+			x.stats.Skipped = append(x.stats.Skipped, f.Name)
+		}
+		if err != nil {
+			return &x.stats, err
+		}
+	}
+	return &x.stats, nil
+}
+
+// extractor writes the entries under an os.Root: no name, symbolic link or
+// ".." can reach a file outside of it
+type extractor struct {
+	root   *os.Root
+	limits Limits
+	stats  Stats
+}
+
+func newExtractor(dir string, limits Limits) (*extractor, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &extractor{root: root, limits: limits}, nil
+}
+
+// local checks an entry name before anything is written: os.Root would
+// refuse it too, but the archive is rejected with a clear error
+func local(name string) (string, error) {
+	clean := filepath.FromSlash(name)
+	if !filepath.IsLocal(clean) {
+		return "", fmt.Errorf("%s: %w", name, ErrUnsafePath)
+	}
+	return clean, nil
+}
+
+func (x *extractor) dir(name string) error {
+	clean, err := local(name)
+	if err != nil {
+		return err
+	}
+	x.stats.Dirs++
+	return x.root.MkdirAll(clean, 0o755)
+}
+
+func (x *extractor) zipFile(f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return x.file(f.Name, f.Mode(), rc)
+}
+
+// file writes a regular file. The sizes are counted as the bytes are
+// written: the header of a zip bomb claims a small size.
+func (x *extractor) file(name string, mode fs.FileMode, r io.Reader) error {
+	clean, err := local(name)
+	if err != nil {
+		return err
+	}
+	if x.limits.MaxFiles > 0 && x.stats.Files >= x.limits.MaxFiles {
+		return fmt.Errorf("%s: %w", name, ErrTooManyFiles)
+	}
+	if dir := filepath.Dir(clean); dir != "." {
+		if err := x.root.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	f, err := x.root.OpenFile(clean, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm()|0o600)
+	if err != nil {
+		return err
+	}
+	x.stats.Files++
+
+	// Read one byte more than allowed, to tell a file of exactly the limit
+	// from a larger one
+	limit := int64(-1)
+	if x.limits.MaxFileSize > 0 {
+		limit = x.limits.MaxFileSize
+	}
+	if x.limits.MaxTotalSize > 0 && (limit < 0 || x.limits.MaxTotalSize-x.stats.Bytes < limit) {
+		limit = x.limits.MaxTotalSize - x.stats.Bytes
+	}
+	src := r
+	if limit >= 0 {
+		src = io.LimitReader(r, limit+1)
+	}
+	n, err := io.Copy(f, src)
+	x.stats.Bytes += n
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && limit >= 0 && n > limit {
+		x.root.Remove(clean)
+		err = fmt.Errorf("%s: %w", name, ErrTooLarge)
+	}
+	return err
+}
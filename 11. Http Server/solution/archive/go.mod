@@ -0,0 +1,3 @@
+module golang-training/module-11/archive
+
+go 1.25
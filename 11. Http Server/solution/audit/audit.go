@@ -0,0 +1,260 @@
+// Package audit records who did what in the server exercises: the actor,
+// the action, the resource and the fields it changed, in a table of the
+// database of the application.
+//
+// An audit log answers questions the access log can't: who completed this
+// todo, what was the title before, which files did this user delete last
+// week. Entries are only added, never changed, and pruned after a
+// retention period.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Actor is whoever made the request
+type Actor struct {
+	ID     string // User ID, API key name or "anonymous"
+	Tenant string // Set for multi-tenant servers: queries only see the entries of the tenant
+	IP     string
+}
+
+// actorKey is the context key of the actor
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the actor. The authentication
+// middleware of the server sets it.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor of ctx, or an anonymous one
+func ActorFrom(ctx context.Context) Actor {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	if !ok || actor.ID == "" {
+		actor.ID = "anonymous"
+	}
+	return actor
+}
+
+// Entry is a row of the audit_entries table
+type Entry struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	At         time.Time `gorm:"not null;index" json:"at"`
+	Actor      string    `gorm:"size:100;not null;index:idx_audit_actor,priority:1" json:"actor"`
+	Tenant     string    `gorm:"size:100;index" json:"tenant,omitempty"`
+	IP         string    `gorm:"size:45" json:"ip,omitempty"`
+	Action     string    `gorm:"size:50;not null" json:"action"` // Such as todo.update
+	Resource   string    `gorm:"size:50;not null;index:idx_audit_resource,priority:1" json:"resource"`
+	ResourceID string    `gorm:"size:100;index:idx_audit_resource,priority:2" json:"resource_id"`
+	Changes    []Change  `gorm:"serializer:json;type:text" json:"changes,omitempty"`
+}
+
+func (Entry) TableName() string {
+	return "audit_entries"
+}
+
+// AutoMigrate creates the audit table in the database of the application
+func AutoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(&Entry{})
+}
+
+// Logger writes the audit entries
+type Logger struct {
+	db *gorm.DB
+	// Ignored fields are left out of the changes, such as updated_at,
+	// which changes every time
+	ignored map[string]bool
+	now     func() time.Time
+}
+
+// New returns a logger writing to db, whose table AutoMigrate created
+func New(db *gorm.DB, ignoredFields ...string) *Logger {
+	l := &Logger{db: db, ignored: map[string]bool{"updated_at": true}, now: func() time.Time { return time.Now().UTC() }}
+	for _, field := range ignoredFields {
+		l.ignored[field] = true
+	}
+	return l
+}
+
+// Record adds an entry for an action of the actor of ctx. before is nil
+// for a creation and after is nil for a deletion; the entry keeps the
+// fields that differ between them, as JSON sees them: fields tagged
+// json:"-", such as password hashes, are never recorded.
+func (l *Logger) Record(ctx context.Context, action, resource, resourceID string, before, after any) error {
+	changes, err := Diff(before, after)
+	if err != nil {
+		return err
+	}
+	kept := changes[:0]
+	for _, change := range changes {
+		if !l.ignored[change.Field] {
+			kept = append(kept, change)
+		}
+	}
+	actor := ActorFrom(ctx)
+	return l.db.WithContext(ctx).Create(&Entry{
+		At:         l.now(),
+		Actor:      actor.ID,
+		Tenant:     actor.Tenant,
+		IP:         actor.IP,
+		Action:     action,
+		Resource:   resource,
+		ResourceID: resourceID,
+		Changes:    kept,
+	}).Error
+}
+
+// Filter selects entries. Zero fields don't filter.
+type Filter struct {
+	Actor      string
+	Tenant     string
+	Action     string
+	Resource   string
+	ResourceID string
+	From, To   time.Time // From is included, To is not
+	Limit      int
+}
+
+// Query returns the entries of the filter, the latest first
+func (l *Logger) Query(ctx context.Context, f Filter) ([]Entry, error) {
+	query := l.db.WithContext(ctx).Order("at DESC, id DESC")
+	for column, value := range map[string]string{
+		"actor": f.Actor, "tenant": f.Tenant, "action": f.Action, "resource": f.Resource, "resource_id": f.ResourceID,
+	} {
+		if value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+	if !f.From.IsZero() {
+		query = query.Where("at >= ?", f.From.UTC())
+	}
+	if !f.To.IsZero() {
+		query = query.Where("at < ?", f.To.UTC())
+	}
+	if f.Limit > 0 {
+		query = query.Limit(f.Limit)
+	}
+	entries := []Entry{}
+	err := query.Find(&entries).Error
+	return entries, err
+}
+
+// Prune deletes the entries older than retention, and returns their number
+func (l *Logger) Prune(ctx context.Context, retention time.Duration) (int64, error) {
+	result := l.db.WithContext(ctx).Where("at < ?", l.now().Add(-retention)).Delete(&Entry{})
+	return result.RowsAffected, result.Error
+}
+
+// StartPruning prunes the entries older than retention every interval, in
+// the background, until stop is called
+func (l *Logger) StartPruning(retention, interval time.Duration, onError func(error)) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := l.Prune(ctx, retention); err != nil && ctx.Err() == nil && onError != nil {
+				onError(err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Change is a field whose value changed. Nested fields are joined with a
+// dot, such as address.city.
+type Change struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// Diff returns the fields that differ between two values, compared as JSON
+// objects. nil stands for a value that doesn't exist, so every field of the
+// other one is a change.
+func Diff(before, after any) ([]Change, error) {
+	b, err := flatten(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := flatten(after)
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	for field, value := range b {
+		if other, ok := a[field]; !ok || !equal(value, other) {
+			changes = append(changes, Change{Field: field, Before: value, After: a[field]})
+		}
+	}
+	for field, value := range a {
+		if _, ok := b[field]; !ok {
+			changes = append(changes, Change{Field: field, After: value})
+		}
+	}
+	sortChanges(changes)
+	return changes, nil
+}
+
+// sortChanges orders the changes by field, so entries read the same way
+// every time
+func sortChanges(changes []Change) {
+	slices.SortFunc(changes, func(a, b Change) int { return strings.Compare(a.Field, b.Field) })
+}
+
+// flatten encodes v as JSON, then maps the path of each leaf to its value
+func flatten(v any) (map[string]any, error) {
+	fields := make(map[string]any)
+	if v == nil {
+		return fields, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	var walk func(prefix string, v any)
+	walk = func(prefix string, v any) {
+		object, ok := v.(map[string]any)
+		if !ok || len(object) == 0 {
+			fields[prefix] = v
+			return
+		}
+		for key, value := range object {
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			walk(key, value)
+		}
+	}
+	walk("", decoded)
+	return fields, nil
+}
+
+// equal compares two decoded JSON values: arrays are compared as their
+// encoding
+func equal(a, b any) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return string(x) == string(y)
+}
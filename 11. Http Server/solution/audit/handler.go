@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// QueryHandler serves the entries as JSON, filtered by the query string:
+//
+//	GET /audit?actor=alice&resource=todo&resource_id=3&action=todo.update
+//	           &from=2026-10-01T00:00:00Z&to=2026-10-18T00:00:00Z&limit=50
+//
+// When the actor of the request has a tenant, only the entries of that
+// tenant are returned. Mount it behind the authorization of the
+// administrators: the audit log shows what everyone did.
+func (l *Logger) QueryHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		f := Filter{
+			Actor:      q.Get("actor"),
+			Action:     q.Get("action"),
+			Resource:   q.Get("resource"),
+			ResourceID: q.Get("resource_id"),
+			Tenant:     ActorFrom(r.Context()).Tenant,
+			Limit:      100,
+		}
+		var err error
+		if f.From, err = parseTime(q.Get("from")); err != nil {
+			writeError(w, "from: "+err.Error())
+			return
+		}
+		if f.To, err = parseTime(q.Get("to")); err != nil {
+			writeError(w, "to: "+err.Error())
+			return
+		}
+		if v := q.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 1000 {
+				writeError(w, "limit must be between 1 and 1000")
+				return
+			}
+			f.Limit = n
+		}
+
+		entries, err := l.Query(r.Context(), f)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
+	})
+}
+
+// parseTime reads an RFC 3339 time, or a date such as 2026-10-17
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func writeError(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
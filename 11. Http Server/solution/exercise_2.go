@@ -1,10 +1,24 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"html/template"
+	"io/fs"
 	"log"
+	"maps"
+	"mime"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -40,45 +54,262 @@ func NotFoundHandler(w http.ResponseWriter, r *http.Request) {
 	`))
 }
 
-func main() {
-	// Create the directory if it doesn't exist
-	os.MkdirAll("./static", 0755)
+// ===== In-memory assets with content hashes =====
 
-	// Create a sample index.html file
-	indexContent := `
-		<!DOCTYPE html>
-		<html>
-		<head>
-			<title>Go File Server</title>
-			<style>
-				body { font-family: Arial, sans-serif; margin: 40px; line-height: 1.6; }
-				h1 { color: #333; }
-			</style>
-		</head>
-		<body>
-			<h1>Welcome to the Go File Server</h1>
-			<p>This is a simple file server built with Go's standard library.</p>
-		</body>
-		</html>
-	`
-	os.WriteFile("./static/index.html", []byte(indexContent), 0644)
+// Cache-Control values. A hashed URL never changes content: a new version of
+// the file gets a new URL, so browsers can keep it for a year without asking
+// again. Everything else must be revalidated on each use.
+const (
+	cacheForever    = "public, max-age=31536000, immutable"
+	cacheRevalidate = "no-cache"
+)
 
-	// Create a file server handler
-	fileServer := http.FileServer(http.Dir("./static"))
+// Asset is a file loaded in memory
+type Asset struct {
+	Name        string // Logical name, such as css/style.css
+	HashedName  string // Name with the content hash, such as css/style.3f2a9c1d.css
+	Content     []byte
+	ContentType string
+	ETag        string
+	ModTime     time.Time
+}
+
+// AssetCache holds the assets, by logical and by hashed name
+type AssetCache struct {
+	byName   map[string]*Asset
+	byHashed map[string]*Asset
+}
+
+// hashedName inserts the first 8 hex digits of the hash before the extension
+func hashedName(name string, sum []byte) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hex.EncodeToString(sum[:4]) + ext
+}
+
+// LoadAssets reads every file under dir into memory. The files are read
+// once, at startup: requests never touch the disk.
+func LoadAssets(dir string) (*AssetCache, error) {
+	cache := &AssetCache{byName: make(map[string]*Asset), byHashed: make(map[string]*Asset)}
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(rel)
+		sum := sha256.Sum256(content)
+		contentType := mime.TypeByExtension(path.Ext(name))
+		if contentType == "" {
+			contentType = http.DetectContentType(content)
+		}
+		asset := &Asset{
+			Name:        name,
+			HashedName:  hashedName(name, sum[:]),
+			Content:     content,
+			ContentType: contentType,
+			// A strong ETag: the same bytes always give the same tag
+			ETag:    `"` + hex.EncodeToString(sum[:16]) + `"`,
+			ModTime: info.ModTime(),
+		}
+		cache.byName[asset.Name] = asset
+		cache.byHashed[asset.HashedName] = asset
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading assets from %s: %w", dir, err)
+	}
+	return cache, nil
+}
+
+// URL returns the hashed URL of an asset, for templates. An unknown name is
+// returned as is, so a typo shows up as a 404 in the browser.
+func (c *AssetCache) URL(name string) string {
+	if asset, ok := c.byName[name]; ok {
+		return "/assets/" + asset.HashedName
+	}
+	return "/assets/" + name
+}
+
+// ServeHTTP serves /assets/{name...}. A hashed name is cached forever; a
+// logical name still works, for links that can't know the hash, but has to
+// be revalidated.
+func (c *AssetCache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	asset, ok := c.byHashed[name]
+	cacheControl := cacheForever
+	if !ok {
+		asset, ok = c.byName[name]
+		cacheControl = cacheRevalidate
+	}
+	if !ok {
+		NotFoundHandler(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("ETag", asset.ETag)
+	w.Header().Set("Content-Type", asset.ContentType)
+	// ServeContent answers conditional requests: 304 Not Modified when
+	// If-None-Match matches the ETag, or If-Modified-Since the ModTime. It
+	// also handles HEAD and Range requests.
+	http.ServeContent(w, r, asset.Name, asset.ModTime, bytes.NewReader(asset.Content))
+}
 
-	// Register handlers
+// Manifest maps the logical names to the hashed URLs, for clients that build
+// pages themselves, such as a JavaScript application
+func (c *AssetCache) Manifest(w http.ResponseWriter, r *http.Request) {
+	manifest := make(map[string]string, len(c.byName))
+	for name := range c.byName {
+		manifest[name] = c.URL(name)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	// The manifest changes with the assets: never cache it without asking
+	w.Header().Set("Cache-Control", cacheRevalidate)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(manifest)
+}
+
+// pageHTML links the assets through the asset function, which adds the
+// hash to their URL
+const pageHTML = `<!DOCTYPE html>
+<html>
+<head>
+	<title>Go File Server</title>
+	<link rel="stylesheet" href="{{asset "css/style.css"}}">
+</head>
+<body>
+	<h1>Welcome to the Go File Server</h1>
+	<p>The stylesheet and the script of this page are cached by the browser for a year.</p>
+	<script src="{{asset "js/app.js"}}"></script>
+</body>
+</html>
+`
+
+// IndexHandler renders the page. The page itself is revalidated on every
+// visit, so a deployment with new assets is picked up right away.
+func (c *AssetCache) IndexHandler() http.HandlerFunc {
+	page := template.Must(template.New("index").Funcs(template.FuncMap{"asset": c.URL}).Parse(pageHTML))
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", cacheRevalidate)
+		if err := page.Execute(w, nil); err != nil {
+			log.Println("rendering the page:", err)
+		}
+	}
+}
+
+// writeSampleFiles creates the files served by the exercise
+func writeSampleFiles(css string) error {
+	files := map[string]string{
+		"./static/index.html":           "<h1>Served by http.FileServer</h1>\n",
+		"./static/assets/css/style.css": css,
+		"./static/assets/js/app.js":     "console.log('app loaded');\n",
+	}
+	for file, content := range files {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newMux(assets *AssetCache) *http.ServeMux {
 	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", assets.IndexHandler())
+	mux.Handle("GET /assets/{name...}", assets)
+	mux.HandleFunc("GET /manifest.json", assets.Manifest)
 
-	// Add the file server with the logging middleware
-	mux.Handle("/", LoggingMiddleware(fileServer))
+	// The plain file server, still available for the other files
+	fileServer := http.FileServer(http.Dir("./static"))
+	mux.Handle("/static/", http.StripPrefix("/static/", fileServer))
 
 	// Custom not found handler
 	mux.HandleFunc("/notfound", NotFoundHandler)
+	return mux
+}
+
+// runDemo shows the caching headers of a few requests, then loads a new
+// version of the stylesheet to show the URL changing with the content
+func runDemo(assets *AssetCache) {
+	server := httptest.NewServer(newMux(assets))
+	defer server.Close()
+
+	get := func(url string, header http.Header) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+url, nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.Fatal(err)
+		}
+		resp.Body.Close()
+		fmt.Printf("GET %-34s %d %-16s Cache-Control: %-36s ETag: %s\n",
+			url, resp.StatusCode, http.StatusText(resp.StatusCode), resp.Header.Get("Cache-Control"), resp.Header.Get("ETag"))
+		return resp
+	}
+
+	styleURL := assets.URL("css/style.css")
+	get("/", nil)
+	first := get(styleURL, nil)
+	// The browser revalidates with the ETag it has: nothing is sent again
+	get(styleURL, http.Header{"If-None-Match": {first.Header.Get("ETag")}})
+	get("/assets/css/style.css", nil)
+	get("/assets/css/missing.css", nil)
+
+	fmt.Println("\nManifest:")
+	for _, name := range slices.Sorted(maps.Keys(assets.byName)) {
+		fmt.Printf("  %-14s -> %s\n", name, assets.URL(name))
+	}
+
+	// A deployment changes the stylesheet: the page links a new URL, which
+	// no browser has in its cache
+	if err := writeSampleFiles("body { font-family: Arial, sans-serif; color: #222; }\n"); err != nil {
+		log.Fatal(err)
+	}
+	updated, err := LoadAssets("./static/assets")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("\nAfter changing style.css: %s -> %s\n", styleURL, updated.URL("css/style.css"))
+	fmt.Printf("app.js is unchanged:      %s -> %s\n", assets.URL("js/app.js"), updated.URL("js/app.js"))
+}
+
+func main() {
+	demo := flag.Bool("demo", false, "print the caching headers of sample requests instead of serving")
+	flag.Parse()
+
+	if err := writeSampleFiles("body { font-family: Arial, sans-serif; margin: 40px; line-height: 1.6; }\nh1 { color: #333; }\n"); err != nil {
+		log.Fatal(err)
+	}
+	assets, err := LoadAssets("./static/assets")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *demo {
+		runDemo(assets)
+		return
+	}
 
 	// Start the server
 	fmt.Println("Starting file server on :8080...")
-	fmt.Println("Files are served from the ./static directory")
+	fmt.Printf("Loaded %d assets from ./static/assets, see /manifest.json\n", len(assets.byName))
 	fmt.Println("Use Ctrl+C to stop the server")
 
-	log.Fatal(http.ListenAndServe(":8080", mux))
+	log.Fatal(http.ListenAndServe(":8080", LoggingMiddleware(newMux(assets))))
 }
@@ -1,14 +1,36 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"log"
+	"maps"
+	"math"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"text/tabwriter"
 	"time"
 )
 
+// StatusError is returned when an API answers with a status other than 200
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API returned status code %d", e.Code)
+}
+
 // ApiResponse represents a generic API response
 type ApiResponse struct {
 	Source  string
@@ -54,7 +76,7 @@ func FetchAPI(url string, source string) ApiResponse {
 	} else {
 		return ApiResponse{
 			Source:  source,
-			Error:   fmt.Errorf("API returned status code %d", resp.StatusCode),
+			Error:   &StatusError{Code: resp.StatusCode},
 			Latency: latency,
 		}
 	}
@@ -66,63 +88,293 @@ func FetchAPI(url string, source string) ApiResponse {
 	}
 }
 
+// API is a source to fetch
+type API struct {
+	URL    string `json:"url"`
+	Source string `json:"source"`
+}
+
+// FetchAll fetches every API concurrently and returns the responses in the
+// order of apis. At most concurrency requests run at once, all of them when
+// it is zero or less.
+func FetchAll(apis []API, concurrency int) []ApiResponse {
+	if concurrency <= 0 {
+		concurrency = len(apis)
+	}
+	// A buffered channel as a semaphore: a goroutine sends to take a slot
+	// and receives to free it, so the sends block when all slots are taken
+	slots := make(chan struct{}, concurrency)
+	responses := make([]ApiResponse, len(apis))
+	var wg sync.WaitGroup
+	for i, api := range apis {
+		// Each goroutine writes its own element: no lock is needed
+		wg.Go(func() {
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			// The latency is measured by FetchAPI: the wait for a slot is
+			// not part of it
+			responses[i] = FetchAPI(api.URL, api.Source)
+		})
+	}
+	wg.Wait()
+	return responses
+}
+
+// Error categories, to count failures by cause rather than by message
+const (
+	CategoryTimeout     = "timeout"
+	CategoryConnection  = "connection"
+	CategoryClientError = "http_4xx"
+	CategoryServerError = "http_5xx"
+	CategoryInvalidBody = "invalid_body"
+	CategoryOther       = "other"
+)
+
+// Categorize returns the category of an error returned by FetchAPI
+func Categorize(err error) string {
+	var statusErr *StatusError
+	var netErr net.Error
+	var opErr *net.OpError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &statusErr) && statusErr.Code >= 500:
+		return CategoryServerError
+	case errors.As(err, &statusErr):
+		return CategoryClientError
+	// Check timeouts first: a timeout while dialing is a net.OpError too
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return CategoryTimeout
+	case errors.As(err, &opErr):
+		return CategoryConnection
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return CategoryInvalidBody
+	}
+	return CategoryOther
+}
+
+// ===== Report =====
+
+// Source statuses
+const (
+	StatusOK      = "ok"      // Every request succeeded
+	StatusPartial = "partial" // Some requests failed
+	StatusFailed  = "failed"  // Every request failed
+)
+
+// LatencyStats summarizes the latencies of a source, in milliseconds
+type LatencyStats struct {
+	Min  float64 `json:"min_ms"`
+	Mean float64 `json:"mean_ms"`
+	P50  float64 `json:"p50_ms"`
+	P90  float64 `json:"p90_ms"`
+	P99  float64 `json:"p99_ms"`
+	Max  float64 `json:"max_ms"`
+}
+
+// SourceReport aggregates the requests made to one source over every run
+type SourceReport struct {
+	Source    string         `json:"source"`
+	URL       string         `json:"url"`
+	Status    string         `json:"status"`
+	Requests  int            `json:"requests"`
+	Successes int            `json:"successes"`
+	Latency   LatencyStats   `json:"latency"`
+	Errors    map[string]int `json:"errors,omitempty"` // Count by category
+	LastError string         `json:"last_error,omitempty"`
+}
+
+// Report is the result of every run, one entry per source
+type Report struct {
+	Runs     int            `json:"runs"`
+	Started  time.Time      `json:"started"`
+	Duration float64        `json:"duration_ms"`
+	Sources  []SourceReport `json:"sources"`
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// percentile returns the nearest-rank percentile p of sorted latencies: the
+// smallest value greater than or equal to p percent of the values
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(float64(len(sorted))*p/100)) - 1
+	return sorted[max(rank, 0)]
+}
+
+func latencyStats(latencies []time.Duration) LatencyStats {
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+	return LatencyStats{
+		Min:  milliseconds(sorted[0]),
+		Mean: milliseconds(total / time.Duration(len(sorted))),
+		P50:  milliseconds(percentile(sorted, 50)),
+		P90:  milliseconds(percentile(sorted, 90)),
+		P99:  milliseconds(percentile(sorted, 99)),
+		Max:  milliseconds(sorted[len(sorted)-1]),
+	}
+}
+
+// NewReport aggregates the responses of every run. runs[i] holds the
+// responses of run i, in the order of apis.
+func NewReport(apis []API, runs [][]ApiResponse, started time.Time) Report {
+	report := Report{Runs: len(runs), Started: started.UTC(), Duration: milliseconds(time.Since(started))}
+	for i, api := range apis {
+		source := SourceReport{Source: api.Source, URL: api.URL, Errors: make(map[string]int)}
+		var latencies []time.Duration
+		for _, run := range runs {
+			resp := run[i]
+			source.Requests++
+			// Failed requests count in the latencies too: a timeout is the
+			// slowest answer a caller gets
+			latencies = append(latencies, resp.Latency)
+			if resp.Error != nil {
+				source.Errors[Categorize(resp.Error)]++
+				source.LastError = resp.Error.Error()
+				continue
+			}
+			source.Successes++
+		}
+		source.Latency = latencyStats(latencies)
+		switch source.Successes {
+		case source.Requests:
+			source.Status = StatusOK
+		case 0:
+			source.Status = StatusFailed
+		default:
+			source.Status = StatusPartial
+		}
+		report.Sources = append(report.Sources, source)
+	}
+	return report
+}
+
+// WriteJSON writes the report as indented JSON
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteTable writes the report as a text table
+func (r Report) WriteTable(w io.Writer) error {
+	fmt.Fprintf(w, "%d run(s) in %.0fms\n\n", r.Runs, r.Duration)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tSTATUS\tOK\tP50 ms\tP90 ms\tP99 ms\tMAX ms\tERRORS")
+	for _, s := range r.Sources {
+		var errs []string
+		for _, category := range slices.Sorted(maps.Keys(s.Errors)) {
+			errs = append(errs, fmt.Sprintf("%s=%d", category, s.Errors[category]))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d/%d\t%.1f\t%.1f\t%.1f\t%.1f\t%s\n",
+			s.Source, s.Status, s.Successes, s.Requests,
+			s.Latency.P50, s.Latency.P90, s.Latency.P99, s.Latency.Max, strings.Join(errs, " "))
+	}
+	return tw.Flush()
+}
+
+// ===== Demo APIs =====
+
+// startDemoAPIs starts local APIs failing in different ways, to show every
+// error category without network access
+func startDemoAPIs() (*httptest.Server, []API) {
+	var flakyCalls atomic.Int64
+	mux := http.NewServeMux()
+	mux.HandleFunc("/get", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Duration(5+time.Now().UnixNano()%20) * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"origin": "127.0.0.1"}`)
+	})
+	mux.HandleFunc("/flaky", func(w http.ResponseWriter, r *http.Request) {
+		// Every third call fails
+		if flakyCalls.Add(1)%3 == 0 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"ok": true}`)
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"truncated": `)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	server := httptest.NewServer(mux)
+
+	// Nothing listens on the address of a closed listener
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatal(err)
+	}
+	closed := "http://" + listener.Addr().String()
+	listener.Close()
+
+	return server, []API{
+		{server.URL + "/get", "Get"},
+		{server.URL + "/flaky", "Flaky"},
+		{server.URL + "/missing", "Missing"},
+		{server.URL + "/broken", "Broken JSON"},
+		{server.URL + "/slow", "Slow"},
+		{closed, "Closed Port"},
+	}
+}
+
 func main() {
+	repeat := flag.Int("repeat", 1, "number of runs, to collect latency distributions")
+	format := flag.String("format", "text", "report format: text or json")
+	timeout := flag.Duration("timeout", 5*time.Second, "timeout of each request")
+	demo := flag.Bool("demo", false, "fetch local demo APIs instead of httpbin.org")
+	concurrency := flag.Int("concurrency", 0, "maximum number of requests at once, 0 for all of them")
+	flag.Parse()
+	if *repeat < 1 || (*format != "text" && *format != "json") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
 	// List of APIs to fetch (using httpbin for demonstration)
-	apis := []struct {
-		URL    string
-		Source string
-	}{
+	apis := []API{
 		{"https://httpbin.org/get", "HTTPBin Get"},
 		{"https://httpbin.org/ip", "IP Info"},
 		{"https://httpbin.org/user-agent", "User Agent"},
 		{"https://httpbin.org/headers", "Headers"},
 		{"https://httpbin.org/delay/2", "Delayed Response"}, // This one will take longer
 	}
+	if *demo {
+		server, demoAPIs := startDemoAPIs()
+		defer server.Close()
+		apis = demoAPIs
+		// The slow API answers after 2s: time out before
+		*timeout = 500 * time.Millisecond
+	}
 
-	// Channel to collect responses
-	responses := make(chan ApiResponse, len(apis))
+	// Override the default HTTP client, used by FetchAPI
+	http.DefaultClient = &http.Client{Timeout: *timeout}
 
-	// WaitGroup to wait for all goroutines
-	var wg sync.WaitGroup
-	wg.Add(len(apis))
-
-	// Set a custom timeout for the HTTP client
-	client := &http.Client{
-		Timeout: 5 * time.Second,
-	}
-
-	// Override the default HTTP client
-	http.DefaultClient = client
-
-	fmt.Println("Making concurrent API requests...")
-
-	// Make requests concurrently
-	for _, api := range apis {
-		go func(url, source string) {
-			defer wg.Done()
-			responses <- FetchAPI(url, source)
-		}(api.URL, api.Source)
-	}
-
-	// Close the channel when all goroutines are done
-	go func() {
-		wg.Wait()
-		close(responses)
-	}()
-
-	// Process the results
-	for resp := range responses {
-		if resp.Error != nil {
-			fmt.Printf("[%s] Error: %v (took %v)\n", resp.Source, resp.Error, resp.Latency)
-		} else {
-			fmt.Printf("[%s] Success (took %v)\n", resp.Source, resp.Latency)
-			// Print a sample of the data
-			for k, v := range resp.Data {
-				fmt.Printf("  - %s: %v\n", k, v)
-				break // Just show one item for brevity
-			}
-		}
+	// Progress goes to stderr, so the JSON report can be redirected alone
+	started := time.Now()
+	runs := make([][]ApiResponse, 0, *repeat)
+	for run := range *repeat {
+		fmt.Fprintf(os.Stderr, "Run %d/%d: making %d concurrent API requests...\n", run+1, *repeat, len(apis))
+		runs = append(runs, FetchAll(apis, *concurrency))
 	}
 
-	fmt.Println("All requests completed!")
+	report := NewReport(apis, runs, started)
+	var err error
+	if *format == "json" {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteTable(os.Stdout)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+)
+
+// RequestIDHeader carries the request ID between services and back to the client
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey is the context key of the request ID. An unexported type
+// can't collide with the keys of other packages.
+type requestIDKey struct{}
+
+// NewRequestID returns a random UUID (version 4)
+func NewRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 9562 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// validRequestID accepts the IDs received from other services: short, and
+// without characters that could forge log lines or headers
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom returns the request ID of ctx, or "" when there is none
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestIDMiddleware gives every request an ID: the one sent by the caller
+// when it is valid, so a request keeps its ID across services, or a new
+// one. The ID is stored in the context of the request and sent back in the
+// response headers.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = NewRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), id)))
+	})
+}
+
+// responseRecorder remembers the status and the size of a response
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += n
+	return n, err
+}
+
+// AccessLogMiddleware logs one line per request, with its ID. It must be
+// inside RequestIDMiddleware, which puts the ID in the context.
+func AccessLogMiddleware(logger *log.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			logger.Printf("request_id=%s method=%s path=%s status=%d bytes=%d duration=%v",
+				RequestIDFrom(r.Context()), r.Method, r.URL.Path, rec.status, rec.bytes, time.Since(start).Round(time.Microsecond))
+		})
+	}
+}
+
+// Chain applies the middlewares, the first one being the outermost
+func Chain(h http.Handler, middlewares ...func(http.Handler) http.Handler) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// ===== Downstream calls =====
+
+// requestIDTransport copies the request ID of the context of each outgoing
+// request into its headers, so the services called log the same ID
+type requestIDTransport struct {
+	next http.RoundTripper
+}
+
+func (t requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := RequestIDFrom(req.Context())
+	if id == "" || req.Header.Get(RequestIDHeader) != "" {
+		return t.next.RoundTrip(req)
+	}
+	// A RoundTripper must not modify the request it was given
+	req = req.Clone(req.Context())
+	req.Header.Set(RequestIDHeader, id)
+	return t.next.RoundTrip(req)
+}
+
+// APIClient calls the user service, as the client of module 7. Its
+// transport forwards the request ID of the context of each call.
+type APIClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func NewAPIClient(baseURL string) *APIClient {
+	return &APIClient{
+		BaseURL: baseURL,
+		HTTPClient: &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: requestIDTransport{next: http.DefaultTransport},
+		},
+	}
+}
+
+// User is returned by the user service
+type User struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// GetUser fetches a user. The context carries the request ID of the
+// incoming request that needs the user.
+func (c *APIClient) GetUser(ctx context.Context, userID string) (*User, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/users/"+userID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user service returned status %d for user %s", resp.StatusCode, userID)
+	}
+	var user User
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &user, nil
+}
+
+// ===== Services =====
+
+// newUserService serves the users. It runs the same middlewares, so its
+// access log shows the ID of the request that called it.
+func newUserService(logger *log.Logger) http.Handler {
+	users := map[string]User{
+		"u1": {ID: "u1", Name: "Alice"},
+		"u2": {ID: "u2", Name: "Bob"},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		user, ok := users[r.PathValue("id")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(user)
+	})
+	return Chain(mux, RequestIDMiddleware, AccessLogMiddleware(logger))
+}
+
+// newOrderAPI serves orders, fetching their customer from the user service
+func newOrderAPI(users *APIClient, logger *log.Logger) http.Handler {
+	orders := map[string]string{"A1": "u1", "A2": "u2", "A3": "u9"} // Order ID to customer ID
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		orderID := r.PathValue("id")
+		customerID, ok := orders[orderID]
+		if !ok {
+			writeError(w, r, http.StatusNotFound, "order not found")
+			return
+		}
+		customer, err := users.GetUser(r.Context(), customerID)
+		if err != nil {
+			// The log line and the response share the request ID: the
+			// client reports the ID, and the ID finds the cause in the logs
+			logger.Printf("request_id=%s error=%q", RequestIDFrom(r.Context()), err)
+			writeError(w, r, http.StatusBadGateway, "customer unavailable")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"order_id": orderID, "customer": customer})
+	})
+	return Chain(mux, RequestIDMiddleware, AccessLogMiddleware(logger))
+}
+
+// writeError sends a JSON error including the request ID
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":      message,
+		"request_id": RequestIDFrom(r.Context()),
+	})
+}
+
+func main() {
+	userService := httptest.NewServer(newUserService(log.New(os.Stdout, "[users] ", 0)))
+	defer userService.Close()
+	orderAPI := httptest.NewServer(newOrderAPI(NewAPIClient(userService.URL), log.New(os.Stdout, "[orders] ", 0)))
+	defer orderAPI.Close()
+
+	requests := []struct {
+		path      string
+		requestID string // Sent by the client, empty to let the server choose
+	}{
+		{"/orders/A1", ""},
+		{"/orders/A2", "checkout-7f3a"},
+		{"/orders/A3", ""},                          // The customer is missing: 502
+		{"/orders/A2", "<script>alert(1)</script>"}, // Rejected, replaced by a new ID
+		{"/orders/A9", ""},
+	}
+	for _, r := range requests {
+		fmt.Printf("\n=== GET %s ===\n", r.path)
+		req, err := http.NewRequest(http.MethodGet, orderAPI.URL+r.path, nil)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		if r.requestID != "" {
+			req.Header.Set(RequestIDHeader, r.requestID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Printf("%s, %s: %s\n", resp.Status, RequestIDHeader, resp.Header.Get(RequestIDHeader))
+		fmt.Print(string(body))
+	}
+}
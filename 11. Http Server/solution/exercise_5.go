@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Backends run the todo APIs of module 12 (Gin) and module 13 (Echo), for
+// example:
+//
+//	cd "12. Server (Gin Gonic)/solution/exercise_1" && go run . -addr :8081
+//	cd "12. Server (Gin Gonic)/solution/exercise_1" && go run . -addr :8082
+//	cd "13. Server (Echo)/solution/exercise_1" && go run . -addr :8091
+//
+// then the proxy forwards /gin/api/v1/todos to one of the Gin servers and
+// /echo/api/v1/todos to the Echo server.
+
+// maxRetryBody is the largest request body kept in memory to be sent again
+// to another backend. Larger bodies, such as uploads, are streamed to a
+// single backend.
+const maxRetryBody = 1 << 20
+
+// Pool is a group of backends serving the same application. Requests go to
+// the backends in turn (round-robin); when a backend can't be reached, the
+// request is sent to the next one.
+type Pool struct {
+	name     string
+	backends []*url.URL
+	next     atomic.Uint64
+}
+
+// NewPool parses a comma-separated list of backend URLs
+func NewPool(name, backends string) (*Pool, error) {
+	p := &Pool{name: name}
+	for _, raw := range strings.Split(backends, ",") {
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("pool %s: invalid backend URL %q", name, raw)
+		}
+		p.backends = append(p.backends, u)
+	}
+	return p, nil
+}
+
+// order returns the backends to try for a request: the next one in turn
+// first, then the others as fallbacks
+func (p *Pool) order() []*url.URL {
+	start := int(p.next.Add(1)-1) % len(p.backends)
+	return append(p.backends[start:len(p.backends):len(p.backends)], p.backends[:start]...)
+}
+
+// isConnectionError reports whether the request failed before reaching the
+// backend, so it can safely be sent to another one, even a POST
+func isConnectionError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// readCloser reads from a reader and closes another closer, the original
+// body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bufferBody keeps a copy of a body of up to maxRetryBody bytes, to send it
+// again after a failure: the transport closes the body it was given. A
+// larger body, announced by its Content-Length or found larger while read,
+// is left in req.Body to be streamed, and retry is false.
+func bufferBody(req *http.Request) (body []byte, retry bool, err error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true, nil
+	}
+	if req.ContentLength > maxRetryBody {
+		return nil, false, nil
+	}
+	body, err = io.ReadAll(io.LimitReader(req.Body, maxRetryBody+1))
+	if err != nil {
+		req.Body.Close()
+		return nil, false, err
+	}
+	if len(body) <= maxRetryBody {
+		req.Body.Close()
+		return body, true, nil
+	}
+	// Unknown length and too large: put the bytes read back in front
+	req.Body = readCloser{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+	return nil, false, nil
+}
+
+// RoundTrip makes Pool the http.RoundTripper of the reverse proxy: it
+// chooses the backend of each request, and retries on connection failures
+// when the body could be kept
+func (p *Pool) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, retry, err := bufferBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, backend := range p.order() {
+		out := req.Clone(req.Context())
+		out.URL.Scheme, out.URL.Host = backend.Scheme, backend.Host
+		out.Host = backend.Host
+		if body != nil {
+			out.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		resp, err := http.DefaultTransport.RoundTrip(out)
+		if err == nil {
+			resp.Header.Set("X-Upstream", p.name+" "+backend.Host)
+			return resp, nil
+		}
+		if !isConnectionError(err) || !retry {
+			return nil, err
+		}
+		log.Printf("[%s] %s unreachable, trying the next backend: %v", p.name, backend.Host, err)
+		errs = append(errs, err)
+	}
+	return nil, fmt.Errorf("all %s backends failed: %w", p.name, errors.Join(errs...))
+}
+
+// NewProxy creates the reverse proxy of a pool, mounted under prefix
+func NewProxy(prefix string, pool *Pool) http.Handler {
+	proxy := &httputil.ReverseProxy{
+		// Rewrite builds the outgoing request from the incoming one. Headers
+		// such as Connection are already removed; Pool.RoundTrip sets the
+		// backend.
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(pool.backends[0])
+			r.SetXForwarded() // X-Forwarded-For, -Host and -Proto
+			r.Out.Header.Set("X-Forwarded-Prefix", prefix)
+			// The backends don't need the cookies of the proxy domain
+			r.Out.Header.Del("Cookie")
+		},
+		// ModifyResponse rewrites the response headers of the backend
+		ModifyResponse: func(resp *http.Response) error {
+			// Links created by the backend don't know about the prefix
+			if location := resp.Header.Get("Location"); strings.HasPrefix(location, "/") {
+				resp.Header.Set("Location", prefix+location)
+			}
+			// Don't tell clients which software runs behind the proxy
+			resp.Header.Del("Server")
+			resp.Header.Del("X-Powered-By")
+			resp.Header.Add("Via", "1.1 training-proxy")
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.Printf("[%s] %s %s: %v", pool.name, r.Method, r.URL.Path, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			json.NewEncoder(w).Encode(map[string]string{"error": pool.name + " backends unavailable"})
+		},
+		Transport: pool,
+	}
+	// The backend sees /api/v1/todos for /gin/api/v1/todos
+	return http.StripPrefix(prefix, proxy)
+}
+
+// ===== Demo backends =====
+
+// startDemoBackend starts a backend answering with its name and the
+// headers set by the proxy
+func startDemoBackend(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ := io.Copy(io.Discard, r.Body)
+		w.Header().Set("Server", name+"/1.0")
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			w.Header().Set("Location", "/api/v1/todos/42")
+			w.WriteHeader(http.StatusCreated)
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"backend":         name,
+			"path":            r.URL.Path,
+			"x_forwarded_for": r.Header.Get("X-Forwarded-For"),
+			"prefix":          r.Header.Get("X-Forwarded-Prefix"),
+			"cookie":          r.Header.Get("Cookie"),
+			"body_bytes":      strconv.FormatInt(received, 10),
+		})
+	}))
+}
+
+// deadAddress returns an address where nothing listens
+func deadAddress() string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	return "http://" + addr
+}
+
+func runDemo() {
+	gin1, gin2 := startDemoBackend("gin-1"), startDemoBackend("gin-2")
+	echo1 := startDemoBackend("echo-1")
+	defer gin1.Close()
+	defer gin2.Close()
+	defer echo1.Close()
+
+	// The first Echo backend is down: its requests fail over to echo-1
+	ginPool, _ := NewPool("gin", gin1.URL+","+gin2.URL)
+	echoPool, _ := NewPool("echo", deadAddress()+","+echo1.URL)
+	mux := http.NewServeMux()
+	mux.Handle("/gin/", NewProxy("/gin", ginPool))
+	mux.Handle("/echo/", NewProxy("/echo", echoPool))
+	proxy := httptest.NewServer(mux)
+	defer proxy.Close()
+
+	todo := `{"title": "Try the proxy"}`
+	requests := []struct {
+		method, path string
+		body         io.Reader
+	}{
+		{"GET", "/gin/api/v1/todos", nil},
+		{"GET", "/gin/api/v1/todos", nil},
+		{"GET", "/gin/api/v1/todos", nil},
+		{"POST", "/gin/api/v1/todos", strings.NewReader(todo)},
+		{"POST", "/echo/api/v1/todos", strings.NewReader(todo)}, // The body is sent again to echo-1
+		{"GET", "/echo/api/v1/todos", nil},
+		// An upload of 5 MiB, streamed to one backend without failover
+		{"POST", "/gin/api/v1/files", bytes.NewReader(make([]byte, 5<<20))},
+		// The same without a Content-Length: the proxy finds it too large
+		// to keep while reading it
+		{"POST", "/gin/api/v1/files", io.MultiReader(bytes.NewReader(make([]byte, 5<<20)))},
+	}
+	for _, r := range requests {
+		req, _ := http.NewRequest(r.method, proxy.URL+r.path, r.body)
+		req.Header.Set("Cookie", "session=secret")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Printf("%s %s -> %s, upstream %q, server %q, location %q\n  %s",
+			r.method, r.path, resp.Status, resp.Header.Get("X-Upstream"),
+			resp.Header.Get("Server"), resp.Header.Get("Location"), body)
+	}
+
+	// Every backend of a pool down: the error handler answers
+	echo1.Close()
+	resp, err := http.Get(proxy.URL + "/echo/api/v1/todos")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	fmt.Printf("All Echo backends down -> %s\n  %s", resp.Status, body)
+}
+
+func main() {
+	addr := flag.String("addr", ":8000", "address of the proxy")
+	ginBackends := flag.String("gin", "http://localhost:8081,http://localhost:8082", "Gin backends, comma-separated")
+	echoBackends := flag.String("echo", "http://localhost:8091,http://localhost:8092", "Echo backends, comma-separated")
+	demo := flag.Bool("demo", false, "run against demo backends started by the program")
+	flag.Parse()
+
+	if *demo {
+		runDemo()
+		return
+	}
+
+	ginPool, err := NewPool("gin", *ginBackends)
+	if err != nil {
+		log.Fatal(err)
+	}
+	echoPool, err := NewPool("echo", *echoBackends)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/gin/", NewProxy("/gin", ginPool))
+	mux.Handle("/echo/", NewProxy("/echo", echoPool))
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	log.Printf("Proxy listening on %s: /gin/ -> %s, /echo/ -> %s", *addr, *ginBackends, *echoBackends)
+	log.Fatal(server.ListenAndServe())
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// APIKeyHeader carries the API key, unless the client sends it as a bearer
+// token
+const APIKeyHeader = "X-API-Key"
+
+// clientKey is the context key of the authenticated client
+type clientKey struct{}
+
+// ClientFrom returns the client authenticated by Keys.Middleware, or nil
+func ClientFrom(ctx context.Context) *Client {
+	client, _ := ctx.Value(clientKey{}).(*Client)
+	return client
+}
+
+// Keys finds the client of an API key
+type Keys struct {
+	byHash map[string]*Client
+}
+
+func NewKeys(clients []Client) *Keys {
+	k := &Keys{byHash: make(map[string]*Client)}
+	for i := range clients {
+		k.byHash[clients[i].KeySHA256] = &clients[i]
+	}
+	return k
+}
+
+// Lookup returns the client of the key, or nil. The key is hashed first:
+// looking the hash up in a map doesn't tell an attacker, through the time
+// taken, how much of a key was right.
+func (k *Keys) Lookup(key string) *Client {
+	if key == "" {
+		return nil
+	}
+	return k.byHash[HashKey(key)]
+}
+
+// apiKey reads the key from X-API-Key or from Authorization: Bearer
+func apiKey(r *http.Request) string {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// Middleware rejects the requests without a valid key with 401, and the
+// clients not allowed on the route with 403. The client is stored in the
+// context of the request for the rate limiter and the proxy.
+func (k *Keys) Middleware(route string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := k.Lookup(apiKey(r))
+			if client == nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="gateway"`)
+				writeError(w, http.StatusUnauthorized, "missing or invalid API key")
+				return
+			}
+			if info := infoFrom(r.Context()); info != nil {
+				info.client = client.Name
+			}
+			if !client.Allowed(route) {
+				writeError(w, http.StatusForbidden, "API key not allowed on "+route)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientKey{}, client)))
+		})
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
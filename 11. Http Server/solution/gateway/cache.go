@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cachedResponse is a response kept by the cache
+type cachedResponse struct {
+	status  int
+	header  http.Header
+	body    []byte
+	stored  time.Time
+	expires time.Time
+}
+
+// Cache keeps the GET responses of the backends for the TTL of their route.
+// The backends don't know the API keys, so a response is the same for
+// every client and is shared between them.
+type Cache struct {
+	mu         sync.Mutex
+	entries    map[string]*cachedResponse
+	maxEntries int
+	maxBody    int
+	now        func() time.Time
+}
+
+// NewCache returns a cache of at most maxEntries responses of at most
+// maxBody bytes each
+func NewCache(maxEntries, maxBody int) *Cache {
+	return &Cache{entries: make(map[string]*cachedResponse), maxEntries: maxEntries, maxBody: maxBody, now: time.Now}
+}
+
+func (c *Cache) get(key string) *cachedResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expires) {
+		return nil
+	}
+	return entry
+}
+
+func (c *Cache) put(key string, entry *cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		c.evict()
+	}
+	c.entries[key] = entry
+}
+
+// evict removes the expired entries, or the one expiring first when none
+// has expired. The caller holds the lock.
+func (c *Cache) evict() {
+	now := c.now()
+	var first string
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		} else if first == "" || entry.expires.Before(c.entries[first].expires) {
+			first = key
+		}
+	}
+	if len(c.entries) >= c.maxEntries {
+		delete(c.entries, first)
+	}
+}
+
+// Purge removes the responses of a route, after a change through it
+func (c *Cache) Purge(route string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if strings.HasPrefix(key, route+" ") {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// cacheable reports whether the backend allows a shared cache to keep its
+// response
+func cacheable(status int, header http.Header) bool {
+	if status != http.StatusOK || header.Get("Set-Cookie") != "" {
+		return false
+	}
+	control := strings.ToLower(header.Get("Cache-Control"))
+	return !strings.Contains(control, "no-store") && !strings.Contains(control, "private")
+}
+
+// captureWriter writes the response through, and keeps a copy of it up to
+// a limit
+type captureWriter struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	limit    int
+	overflow bool
+}
+
+func (c *captureWriter) WriteHeader(status int) {
+	c.status = status
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *captureWriter) Write(p []byte) (int, error) {
+	if !c.overflow {
+		if c.body.Len()+len(p) > c.limit {
+			c.overflow = true
+			c.body.Reset()
+		} else {
+			c.body.Write(p)
+		}
+	}
+	return c.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController, used by the reverse proxy to flush,
+// reach the original writer
+func (c *captureWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// Middleware serves the GET requests of the route from the cache, and
+// keeps the successful responses. Any other successful request through the
+// route purges its responses, so a client reads its own changes. It must
+// be inside Keys.Middleware: only authorized clients reach the cache.
+func (c *Cache) Middleware(route Route) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if route.CacheTTL.Duration == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				rec := &captureWriter{ResponseWriter: w, status: http.StatusOK, overflow: true}
+				next.ServeHTTP(rec, r)
+				if rec.status < 400 {
+					c.Purge(route.Name)
+				}
+				return
+			}
+
+			key := route.Name + " " + r.URL.RequestURI()
+			info := infoFrom(r.Context())
+			if entry := c.get(key); entry != nil && !strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+				for name, values := range entry.header {
+					w.Header()[name] = values
+				}
+				w.Header().Set("X-Cache", "HIT")
+				w.Header().Set("Age", strconv.Itoa(int(c.now().Sub(entry.stored).Seconds())))
+				w.WriteHeader(entry.status)
+				w.Write(entry.body)
+				if info != nil {
+					info.cache = "hit"
+				}
+				return
+			}
+			if info != nil {
+				info.cache = "miss"
+			}
+
+			// Headers set by the outer middlewares, such as the rate limit,
+			// belong to this request, not to the response kept
+			outer := make(map[string]bool)
+			for name := range w.Header() {
+				outer[name] = true
+			}
+			w.Header().Set("X-Cache", "MISS")
+			rec := &captureWriter{ResponseWriter: w, status: http.StatusOK, limit: c.maxBody}
+			next.ServeHTTP(rec, r)
+
+			if rec.overflow || !cacheable(rec.status, w.Header()) {
+				return
+			}
+			header := w.Header().Clone()
+			for name := range outer {
+				delete(header, name)
+			}
+			header.Del("X-Cache")
+			now := c.now()
+			c.put(key, &cachedResponse{
+				status:  rec.status,
+				header:  header,
+				body:    bytes.Clone(rec.body.Bytes()),
+				stored:  now,
+				expires: now.Add(route.CacheTTL.Duration),
+			})
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Route sends the requests under Prefix to Backend, replacing Prefix with
+// Target in the path: with the prefix /api/books and the target /books,
+// /api/books/1 reaches the backend as /books/1
+type Route struct {
+	Name     string   `json:"name"`
+	Prefix   string   `json:"prefix"`
+	Backend  string   `json:"backend"`
+	Target   string   `json:"target"`
+	CacheTTL Duration `json:"cache_ttl"` // 0 disables the cache of the route
+	Health   string   `json:"health"`    // Path of the backend checked by /readyz, optional
+}
+
+// Client is an application calling the gateway with an API key
+type Client struct {
+	Name      string   `json:"name"`
+	KeySHA256 string   `json:"key_sha256"` // The configuration holds the hash of the key, never the key
+	Rate      float64  `json:"rate"`       // Requests per second, on average
+	Burst     int      `json:"burst"`      // Requests allowed at once
+	Routes    []string `json:"routes"`     // Names of the routes it may call, or "*"
+}
+
+// Allowed reports whether the client may call the route
+func (c *Client) Allowed(route string) bool {
+	for _, name := range c.Routes {
+		if name == "*" || name == route {
+			return true
+		}
+	}
+	return false
+}
+
+// Config is the content of gateway.json
+type Config struct {
+	Routes  []Route  `json:"routes"`
+	Clients []Client `json:"clients"`
+}
+
+// Duration reads durations such as "30s" from JSON
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// HashKey returns the hex SHA-256 of an API key, as written in the
+// configuration
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadConfig reads and validates the configuration file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var cfg Config
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Validate reports every mistake of the configuration at once
+func (c *Config) Validate() error {
+	var errs []error
+	routes := make(map[string]bool)
+	for _, r := range c.Routes {
+		if r.Name == "" || routes[r.Name] {
+			errs = append(errs, fmt.Errorf("route %q: missing or duplicate name", r.Name))
+		}
+		routes[r.Name] = true
+		if !strings.HasPrefix(r.Prefix, "/") || strings.HasSuffix(r.Prefix, "/") {
+			errs = append(errs, fmt.Errorf("route %s: prefix %q must start and not end with /", r.Name, r.Prefix))
+		}
+		if r.Target != "" && (!strings.HasPrefix(r.Target, "/") || strings.HasSuffix(r.Target, "/")) {
+			errs = append(errs, fmt.Errorf("route %s: target %q must start and not end with /", r.Name, r.Target))
+		}
+		if u, err := url.Parse(r.Backend); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("route %s: invalid backend URL %q", r.Name, r.Backend))
+		}
+		if r.CacheTTL.Duration < 0 {
+			errs = append(errs, fmt.Errorf("route %s: negative cache TTL", r.Name))
+		}
+	}
+
+	keys := make(map[string]bool)
+	for _, cl := range c.Clients {
+		if cl.Name == "" {
+			errs = append(errs, errors.New("client without a name"))
+		}
+		if len(cl.KeySHA256) != 64 || keys[cl.KeySHA256] {
+			errs = append(errs, fmt.Errorf("client %s: key_sha256 must be a unique hex SHA-256", cl.Name))
+		}
+		keys[cl.KeySHA256] = true
+		if cl.Rate <= 0 || cl.Burst < 1 {
+			errs = append(errs, fmt.Errorf("client %s: rate and burst must be positive", cl.Name))
+		}
+		for _, name := range cl.Routes {
+			if name != "*" && !routes[name] {
+				errs = append(errs, fmt.Errorf("client %s: unknown route %q", cl.Name, name))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
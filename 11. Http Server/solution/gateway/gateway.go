@@ -0,0 +1,118 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang-training/module-11/healthcheck"
+)
+
+// Chain applies the middlewares, the first one being the outermost
+func Chain(h http.Handler, middlewares ...func(http.Handler) http.Handler) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// Gateway is the entry point of the book and todo services. Each route is
+// a chain of middlewares in front of a reverse proxy:
+//
+//	metrics -> API key -> rate limit -> cache -> path rewrite and proxy
+//
+// The backends never see the API keys: they trust the X-Gateway-Client
+// header set by the gateway, and must only be reachable through it.
+type Gateway struct {
+	Metrics *Metrics
+	Health  *healthcheck.Health
+	mux     *http.ServeMux
+}
+
+// New builds the gateway of a validated configuration
+func New(cfg *Config) (*Gateway, error) {
+	g := &Gateway{
+		Metrics: NewMetrics(),
+		Health:  healthcheck.New(2*time.Second, 5*time.Second),
+		mux:     http.NewServeMux(),
+	}
+	keys := NewKeys(cfg.Clients)
+	limiter := NewRateLimiter()
+	cache := NewCache(1000, 1<<20)
+	client := &http.Client{Timeout: 2 * time.Second}
+
+	for _, route := range cfg.Routes {
+		backend, err := url.Parse(route.Backend)
+		if err != nil {
+			return nil, err
+		}
+		handler := Chain(newProxy(route, backend),
+			g.Metrics.Middleware(route.Name),
+			keys.Middleware(route.Name),
+			limiter.Middleware,
+			cache.Middleware(route),
+		)
+		g.mux.Handle(route.Prefix, handler)
+		g.mux.Handle(route.Prefix+"/", handler)
+
+		// One backend down leaves the other routes working: the gateway is
+		// degraded, not down
+		if route.Health != "" {
+			g.Health.Add(route.Name, healthcheck.Readiness,
+				healthcheck.HTTPGet(client, strings.TrimSuffix(route.Backend, "/")+route.Health), healthcheck.Optional())
+		}
+	}
+
+	// The endpoints of the gateway itself. The metrics name the clients:
+	// expose them on an internal network only.
+	g.Health.Register(g.mux)
+	g.mux.Handle("GET /_gateway/metrics", g.Metrics)
+	g.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "no route for "+r.URL.Path)
+	})
+	return g, nil
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+// newProxy forwards the requests of a route to its backend, rewriting the
+// path from the prefix of the route to its target
+func newProxy(route Route, backend *url.URL) http.Handler {
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(backend)
+			rest := strings.TrimPrefix(r.In.URL.Path, route.Prefix)
+			r.Out.URL.Path = strings.TrimSuffix(backend.Path, "/") + route.Target + rest
+			r.Out.URL.RawPath = ""
+			r.SetXForwarded()
+
+			// The key stops at the gateway: the backend learns the client
+			// from a header it can trust, as the clients can't reach it
+			r.Out.Header.Del(APIKeyHeader)
+			r.Out.Header.Del("Authorization")
+			r.Out.Header.Del("Cookie")
+			if client := ClientFrom(r.In.Context()); client != nil {
+				r.Out.Header.Set("X-Gateway-Client", client.Name)
+			}
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			// Links created by the backend point to its paths: give them the
+			// prefix of the route
+			if location := resp.Header.Get("Location"); strings.HasPrefix(location, route.Target+"/") {
+				resp.Header.Set("Location", route.Prefix+strings.TrimPrefix(location, route.Target))
+			}
+			resp.Header.Del("Server")
+			resp.Header.Del("X-Powered-By")
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.Printf("[%s] %s %s: %v", route.Name, r.Method, r.URL.Path, err)
+			writeError(w, http.StatusBadGateway, route.Name+" backend unavailable")
+		},
+	}
+}
@@ -0,0 +1,7 @@
+module golang-training/module-11/gateway
+
+go 1.25
+
+require golang-training/module-11/healthcheck v0.0.0
+
+replace golang-training/module-11/healthcheck => ../healthcheck
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The gateway runs in front of the book API of exercise 1 and the todo API
+// of module 12, for example:
+//
+//	go run exercise_1.go                                    # books on :8080
+//	cd "12. Server (Gin Gonic)/solution/exercise_1" && go run . -addr :8081
+//	go run . -config gateway.json                           # gateway on :8000
+//	curl -H 'X-API-Key: demo-reader-key' localhost:8000/api/books
+
+// ===== Demo backends =====
+
+// demoBackend counts the requests reaching it, to show the cache at work
+type demoBackend struct {
+	*httptest.Server
+	hits atomic.Int64
+}
+
+// startBooks starts a small book API, like the one of exercise 1
+func startBooks() *demoBackend {
+	var mu sync.Mutex
+	books := []map[string]any{{"id": 1, "title": "The Go Programming Language"}, {"id": 2, "title": "Go in Action"}}
+	b := &demoBackend{}
+	b.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b.hits.Add(1)
+		w.Header().Set("Server", "books/1.0")
+		w.Header().Set("X-Backend-Path", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/health":
+		case r.URL.Path == "/books" && r.Method == http.MethodGet:
+			json.NewEncoder(w).Encode(books)
+		case strings.HasPrefix(r.URL.Path, "/books/") && r.Method == http.MethodGet:
+			for _, book := range books {
+				if fmt.Sprint(book["id"]) == strings.TrimPrefix(r.URL.Path, "/books/") {
+					json.NewEncoder(w).Encode(book)
+					return
+				}
+			}
+			http.Error(w, `{"error": "not found"}`, http.StatusNotFound)
+		case r.URL.Path == "/books" && r.Method == http.MethodPost:
+			var book map[string]any
+			json.NewDecoder(r.Body).Decode(&book)
+			book["id"] = len(books) + 1
+			book["added_by"] = r.Header.Get("X-Gateway-Client")
+			books = append(books, book)
+			w.Header().Set("Location", fmt.Sprintf("/books/%d", len(books)))
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(book)
+		default:
+			http.Error(w, `{"error": "not found"}`, http.StatusNotFound)
+		}
+	}))
+	return b
+}
+
+// startTodos starts a backend answering like the todo API of module 12
+func startTodos() *demoBackend {
+	b := &demoBackend{}
+	b.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b.hits.Add(1)
+		w.Header().Set("X-Backend-Path", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		// Per-user data: a shared cache must not keep it
+		w.Header().Set("Cache-Control", "private")
+		json.NewEncoder(w).Encode([]map[string]any{{"id": 1, "title": "Try the gateway", "completed": false}})
+	}))
+	return b
+}
+
+func runDemo() {
+	books, todos := startBooks(), startTodos()
+	defer books.Close()
+	defer todos.Close()
+
+	cfg := &Config{
+		Routes: []Route{
+			{Name: "books", Prefix: "/api/books", Backend: books.URL, Target: "/books", CacheTTL: Duration{30 * time.Second}, Health: "/health"},
+			{Name: "todos", Prefix: "/api/todos", Backend: todos.URL, Target: "/api/v1/todos", CacheTTL: Duration{30 * time.Second}},
+		},
+		Clients: []Client{
+			{Name: "catalog-site", KeySHA256: HashKey("demo-reader-key"), Rate: 1, Burst: 4, Routes: []string{"books"}},
+			{Name: "mobile-app", KeySHA256: HashKey("demo-app-key"), Rate: 10, Burst: 20, Routes: []string{"*"}},
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	gateway, err := New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	send := func(method, path, key, body string) {
+		var payload io.Reader
+		if body != "" {
+			payload = strings.NewReader(body)
+		}
+		req, _ := http.NewRequest(method, server.URL+path, payload)
+		if key != "" {
+			req.Header.Set(APIKeyHeader, key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Printf("%-6s %-16s key=%-15q -> %s", method, path, key, resp.Status)
+		for _, header := range []string{"X-Cache", "X-Backend-Path", "X-RateLimit-Remaining", "Retry-After", "Location"} {
+			if value := resp.Header.Get(header); value != "" {
+				fmt.Printf(", %s: %s", header, value)
+			}
+		}
+		fmt.Printf("\n       %s", data)
+	}
+
+	fmt.Println("--- Authentication ---")
+	send("GET", "/api/books", "", "")
+	send("GET", "/api/books", "wrong-key", "")
+	send("GET", "/api/todos", "demo-reader-key", "") // Only the books route
+
+	fmt.Println("--- Routing and caching ---")
+	send("GET", "/api/books", "demo-reader-key", "")
+	send("GET", "/api/books", "demo-app-key", "") // Cached response, shared
+	send("POST", "/api/books", "demo-app-key", `{"title": "Learning Go"}`)
+	send("GET", "/api/books", "demo-reader-key", "") // The POST purged the cache
+	send("GET", "/api/todos", "demo-app-key", "")
+	send("GET", "/api/todos", "demo-app-key", "") // Cache-Control: private
+	fmt.Printf("Requests reaching the backends: books %d, todos %d\n", books.hits.Load(), todos.hits.Load())
+
+	fmt.Println("--- Rate limit: 4 at once, then 1 per second ---")
+	for range 3 {
+		send("GET", "/api/books/1", "demo-reader-key", "")
+	}
+
+	fmt.Println("--- Gateway endpoints ---")
+	send("GET", "/unknown", "demo-app-key", "")
+	send("GET", "/healthz", "", "")
+	send("GET", "/_gateway/metrics", "", "")
+}
+
+func main() {
+	addr := flag.String("addr", ":8000", "address of the gateway")
+	configPath := flag.String("config", "gateway.json", "routes and API keys")
+	hashKey := flag.String("hash-key", "", "print the key_sha256 of an API key for the configuration, and exit")
+	demo := flag.Bool("demo", false, "run against demo backends started by the program")
+	flag.Parse()
+
+	switch {
+	case *hashKey != "":
+		fmt.Println(HashKey(*hashKey))
+		return
+	case *demo:
+		runDemo()
+		return
+	}
+
+	cfg, err := LoadConfig(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	gateway, err := New(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           gateway,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+	for _, route := range cfg.Routes {
+		log.Printf("%s%s -> %s%s", *addr, route.Prefix, route.Backend, route.Target)
+	}
+	if err := server.ListenAndServe(); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+}
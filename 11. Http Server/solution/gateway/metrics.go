@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the latency histogram
+var latencyBuckets = []time.Duration{5 * time.Millisecond, 25 * time.Millisecond, 100 * time.Millisecond, 500 * time.Millisecond, 2 * time.Second}
+
+// requestInfo is filled by the middlewares inside Metrics.Middleware, which
+// only see the request once it is done
+type requestInfo struct {
+	client string
+	cache  string // "hit", "miss", or "" when the cache wasn't used
+}
+
+type infoKey struct{}
+
+func infoFrom(ctx context.Context) *requestInfo {
+	info, _ := ctx.Value(infoKey{}).(*requestInfo)
+	return info
+}
+
+// RouteMetrics aggregates the requests of a route
+type RouteMetrics struct {
+	Requests    int64            `json:"requests"`
+	Statuses    map[string]int64 `json:"statuses"` // Such as "2xx": 10
+	Clients     map[string]int64 `json:"clients"`  // "anonymous" for the requests without a valid key
+	CacheHits   int64            `json:"cache_hits"`
+	CacheMisses int64            `json:"cache_misses"`
+	// Latency counts the requests by the bucket of their duration, such as
+	// "<=25ms"
+	Latency   map[string]int64 `json:"latency"`
+	MeanMs    float64          `json:"mean_ms"`
+	MaxMs     float64          `json:"max_ms"`
+	totalTime time.Duration
+}
+
+// Metrics aggregates the requests of the gateway by route
+type Metrics struct {
+	mu     sync.Mutex
+	routes map[string]*RouteMetrics
+	start  time.Time
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{routes: make(map[string]*RouteMetrics), start: time.Now()}
+}
+
+// Record adds a request to the metrics of its route
+func (m *Metrics) Record(route string, info *requestInfo, status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rm, ok := m.routes[route]
+	if !ok {
+		rm = &RouteMetrics{Statuses: map[string]int64{}, Clients: map[string]int64{}, Latency: map[string]int64{}}
+		m.routes[route] = rm
+	}
+	rm.Requests++
+	rm.Statuses[fmt.Sprintf("%dxx", status/100)]++
+	client := info.client
+	if client == "" {
+		client = "anonymous"
+	}
+	rm.Clients[client]++
+	switch info.cache {
+	case "hit":
+		rm.CacheHits++
+	case "miss":
+		rm.CacheMisses++
+	}
+
+	bucket := "+Inf"
+	for _, bound := range latencyBuckets {
+		if duration <= bound {
+			bucket = "<=" + bound.String()
+			break
+		}
+	}
+	rm.Latency[bucket]++
+	rm.totalTime += duration
+	rm.MeanMs = milliseconds(rm.totalTime / time.Duration(rm.Requests))
+	rm.MaxMs = max(rm.MaxMs, milliseconds(duration))
+}
+
+// Snapshot returns a copy of the metrics of every route
+func (m *Metrics) Snapshot() map[string]RouteMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := make(map[string]RouteMetrics, len(m.routes))
+	for name, rm := range m.routes {
+		c := *rm
+		c.Statuses = cloneMap(rm.Statuses)
+		c.Clients = cloneMap(rm.Clients)
+		c.Latency = cloneMap(rm.Latency)
+		snapshot[name] = c
+	}
+	return snapshot
+}
+
+// milliseconds returns d in milliseconds, rounded to the microsecond
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func cloneMap(m map[string]int64) map[string]int64 {
+	c := make(map[string]int64, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// statusRecorder remembers the status of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Middleware records every request of the route, including the ones
+// rejected by the other middlewares, so it must be the outermost one
+func (m *Metrics) Middleware(route string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			info := &requestInfo{}
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), infoKey{}, info)))
+			m.Record(route, info, rec.status, time.Since(start))
+		})
+	}
+}
+
+// ServeHTTP serves the metrics as JSON
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(map[string]any{
+		"uptime": time.Since(m.start).Round(time.Second).String(),
+		"routes": m.Snapshot(),
+	})
+}
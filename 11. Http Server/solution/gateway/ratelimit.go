@@ -0,0 +1,72 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// bucket holds the tokens of a client: each request takes one, and they
+// come back at the rate of the client, up to its burst
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter limits the requests of each API key with a token bucket,
+// across every route
+type RateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// Allow takes a token from the bucket of the client. It returns the tokens
+// left, or how long until the next one when there is none.
+func (l *RateLimiter) Allow(client *Client) (remaining int, retryAfter time.Duration, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, found := l.buckets[client.Name]
+	if !found {
+		b = &bucket{tokens: float64(client.Burst), last: now}
+		l.buckets[client.Name] = b
+	}
+	b.tokens = math.Min(float64(client.Burst), b.tokens+now.Sub(b.last).Seconds()*client.Rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / client.Rate * float64(time.Second))
+		return 0, wait, false
+	}
+	b.tokens--
+	return int(b.tokens), 0, true
+}
+
+// Middleware answers 429 Too Many Requests, with Retry-After, to the
+// clients over their rate. It must be inside Keys.Middleware.
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := ClientFrom(r.Context())
+		if client == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		remaining, retryAfter, ok := l.Allow(client)
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(client.Burst))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,55 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Ping checks a connection, such as the PingContext method of a *sql.DB:
+//
+//	sqlDB, _ := gormDB.DB()
+//	health.Add("database", healthcheck.Readiness, healthcheck.Ping(sqlDB.PingContext))
+func Ping(ping func(ctx context.Context) error) CheckFunc {
+	return func(ctx context.Context) error {
+		if err := ping(ctx); err != nil {
+			return fmt.Errorf("%w: ping: %v", ErrUnhealthy, err)
+		}
+		return nil
+	}
+}
+
+// HTTPGet checks an upstream API: the URL must answer with a status below
+// 400
+func HTTPGet(client *http.Client, url string) CheckFunc {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrUnhealthy, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("%w: %s answered %s", ErrUnhealthy, url, resp.Status)
+		}
+		return nil
+	}
+}
+
+// DiskSpace checks that the file system holding path has at least minFree
+// bytes available, such as the directory receiving uploads
+func DiskSpace(path string, minFree uint64) CheckFunc {
+	return func(ctx context.Context) error {
+		free, err := freeSpace(path)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrUnhealthy, err)
+		}
+		if free < minFree {
+			return fmt.Errorf("%w: %s has %d MB free, below %d MB", ErrUnhealthy, path, free>>20, minFree>>20)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,3 @@
+module golang-training/module-11/healthcheck
+
+go 1.25
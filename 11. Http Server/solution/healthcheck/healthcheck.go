@@ -0,0 +1,279 @@
+// Package healthcheck serves the health endpoints of the server exercises.
+//
+// Orchestrators such as Kubernetes ask two different questions:
+//
+//   - Liveness, /livez: is the process working at all? When it fails, the
+//     process is restarted. Only checks that a restart can fix belong here,
+//     such as a deadlocked worker; a database outage does not, restarting
+//     every instance would not bring the database back.
+//   - Readiness, /readyz: can the instance serve requests right now? When
+//     it fails, the instance stops receiving traffic until it passes
+//     again. Dependencies such as the database belong here, and a server
+//     shutting down reports itself not ready.
+//
+// /healthz runs every check and reports the details, for humans and
+// monitoring.
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrUnhealthy is wrapped by the errors of the checkers of this package
+var ErrUnhealthy = errors.New("unhealthy")
+
+// Kind tells which endpoints run a check
+type Kind int
+
+const (
+	// Liveness checks run on /livez, /readyz and /healthz
+	Liveness Kind = iota
+	// Readiness checks run on /readyz and /healthz
+	Readiness
+)
+
+func (k Kind) String() string {
+	if k == Liveness {
+		return "liveness"
+	}
+	return "readiness"
+}
+
+// Status of a check or of a whole report
+type Status string
+
+const (
+	StatusUp       Status = "up"
+	StatusDegraded Status = "degraded" // Only optional checks failed
+	StatusDown     Status = "down"
+)
+
+// CheckFunc returns nil when the dependency is healthy. It must return when
+// ctx is done.
+type CheckFunc func(ctx context.Context) error
+
+// CheckOption configures a check added with Add
+type CheckOption func(*check)
+
+// Optional makes a failing check report the degraded status without failing
+// the endpoint, for a dependency the server can work without, such as a
+// recommendation API
+func Optional() CheckOption {
+	return func(c *check) { c.optional = true }
+}
+
+// WithTimeout sets how long the check may run, instead of the timeout of the
+// Health
+func WithTimeout(d time.Duration) CheckOption {
+	return func(c *check) { c.timeout = d }
+}
+
+// WithTTL sets how long the result of the check is reused, instead of the TTL
+// of the Health
+func WithTTL(d time.Duration) CheckOption {
+	return func(c *check) { c.ttl = d }
+}
+
+// Result is the outcome of a check
+type Result struct {
+	Status    Status    `json:"status"`
+	Kind      string    `json:"kind"`
+	Optional  bool      `json:"optional,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMS float64   `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+	Cached    bool      `json:"cached"`
+}
+
+// Report is the JSON body of the endpoints
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type check struct {
+	name     string
+	kind     Kind
+	fn       CheckFunc
+	optional bool
+	timeout  time.Duration
+	ttl      time.Duration
+
+	// mu is held while the check runs: concurrent requests wait for the
+	// running check instead of starting it again
+	mu     sync.Mutex
+	last   Result
+	expiry time.Time
+}
+
+// run returns the cached result while it is fresh, or runs the check
+func (c *check) run(ctx context.Context) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Now().Before(c.expiry) {
+		cached := c.last
+		cached.Cached = true
+		return cached
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := time.Now()
+	err := c.safeCall(ctx)
+	result := Result{
+		Status:    StatusUp,
+		Kind:      c.kind.String(),
+		Optional:  c.optional,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		CheckedAt: start.UTC(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	c.last, c.expiry = result, start.Add(c.ttl)
+	return result
+}
+
+// safeCall runs the check, turning a panic into an error and giving up when
+// the check ignores its context
+func (c *check) safeCall(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		done <- c.fn(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out after %v", c.timeout)
+	}
+}
+
+// Health holds the registered checks
+type Health struct {
+	timeout  time.Duration
+	ttl      time.Duration
+	mu       sync.RWMutex
+	checks   []*check
+	draining atomic.Bool
+}
+
+// New creates a Health. Each check runs at most timeout, and its result is
+// reused for ttl: health endpoints are polled often, by several systems, and
+// must not overload the dependencies they check.
+func New(timeout, ttl time.Duration) *Health {
+	return &Health{timeout: timeout, ttl: ttl}
+}
+
+// Add registers a check. It panics when the name is taken, a programming
+// error found at startup.
+func (h *Health) Add(name string, kind Kind, fn CheckFunc, opts ...CheckOption) {
+	c := &check{name: name, kind: kind, fn: fn, timeout: h.timeout, ttl: h.ttl}
+	for _, opt := range opts {
+		opt(c)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, existing := range h.checks {
+		if existing.name == name {
+			panic("healthcheck: duplicate check " + name)
+		}
+	}
+	h.checks = append(h.checks, c)
+}
+
+// Drain makes the server report itself not ready, while it keeps serving the
+// requests in progress. Call it when the shutdown starts, so the load
+// balancer stops sending traffic before the server stops.
+func (h *Health) Drain() {
+	h.draining.Store(true)
+}
+
+// Run runs the checks of the kind in parallel and aggregates their results.
+// Readiness runs the liveness checks too: an instance that is not alive
+// can't be ready.
+func (h *Health) Run(ctx context.Context, kind Kind) Report {
+	h.mu.RLock()
+	var selected []*check
+	for _, c := range h.checks {
+		if c.kind <= kind {
+			selected = append(selected, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(selected)+1)}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range selected {
+		wg.Go(func() {
+			result := c.run(ctx)
+			mu.Lock()
+			report.Checks[c.name] = result
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+
+	if kind == Readiness && h.draining.Load() {
+		report.Checks["draining"] = Result{Status: StatusDown, Kind: Readiness.String(), Error: "shutting down", CheckedAt: time.Now().UTC()}
+	}
+	for _, result := range report.Checks {
+		switch {
+		case result.Status == StatusUp:
+		case result.Optional:
+			if report.Status == StatusUp {
+				report.Status = StatusDegraded
+			}
+		default:
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+func (h *Health) handler(kind Kind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := h.Run(r.Context(), kind)
+		w.Header().Set("Content-Type", "application/json")
+		// The endpoints are polled: a cache in between would hide failures
+		w.Header().Set("Cache-Control", "no-store")
+		if report.Status == StatusDown {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	}
+}
+
+// LivenessHandler serves /livez
+func (h *Health) LivenessHandler() http.HandlerFunc {
+	return h.handler(Liveness)
+}
+
+// ReadinessHandler serves /readyz and /healthz: every check runs, and the
+// status code answers the readiness question
+func (h *Health) ReadinessHandler() http.HandlerFunc {
+	return h.handler(Readiness)
+}
+
+// Register adds /livez, /readyz and /healthz to a ServeMux
+func (h *Health) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /livez", h.LivenessHandler())
+	mux.HandleFunc("GET /readyz", h.ReadinessHandler())
+	mux.HandleFunc("GET /healthz", h.ReadinessHandler())
+}
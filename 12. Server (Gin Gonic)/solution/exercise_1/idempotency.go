@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader is sent by clients that may retry a POST, such as
+// after a timeout: the server creates the todo once, whatever the number of
+// attempts
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotentBody is the largest request body accepted with a key, as the
+// body is read to compare it with the first request
+const maxIdempotentBody = 1 << 20
+
+// maxProcessing is how long a key stays reserved by a request being
+// processed. A reservation left behind, such as by a server restarting
+// mid-request, expires and the next retry takes the key over.
+const maxProcessing = time.Minute
+
+// storedResponse is the response of the first request with a key
+type storedResponse struct {
+	fingerprint [32]byte // SHA-256 of the method, path and body of the request
+	done        bool     // False while the first request is processed
+	status      int
+	header      http.Header
+	body        []byte
+	expires     time.Time // Of the reservation while not done, then of the response
+}
+
+// IdempotencyStore keeps the responses by key for ttl
+type IdempotencyStore struct {
+	mu        sync.Mutex
+	responses map[string]*storedResponse
+	ttl       time.Duration
+}
+
+func NewIdempotencyStore(ttl time.Duration) *IdempotencyStore {
+	return &IdempotencyStore{responses: make(map[string]*storedResponse), ttl: ttl}
+}
+
+// Cleanup removes the expired keys and reservations: a retry after the TTL
+// is processed as a new request
+func (s *IdempotencyStore) Cleanup(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := 0
+	for key, response := range s.responses {
+		if now.After(response.expires) {
+			delete(s.responses, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// StartCleanup runs Cleanup every interval until stop is called
+func (s *IdempotencyStore) StartCleanup(interval time.Duration) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case now := <-ticker.C:
+				s.Cleanup(now)
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// recordingWriter copies the response body for the store
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency processes the first request with a given Idempotency-Key and
+// stores its response. A retry with the same key and the same request gets
+// the stored response without running the handler again; the same key with
+// another request is an error of the client, answered with 422. Requests
+// without a key are processed as usual.
+func Idempotency(store *IdempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentBody+1))
+		if err != nil || len(body) > maxIdempotentBody {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := sha256.Sum256([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n" + string(body)))
+
+		reservation := &storedResponse{fingerprint: fingerprint, expires: time.Now().Add(maxProcessing)}
+		store.mu.Lock()
+		stored, found := store.responses[key]
+		if found && time.Now().After(stored.expires) {
+			found = false
+		}
+		if !found {
+			// Reserve the key before processing, so a concurrent retry
+			// doesn't create a second todo
+			store.responses[key] = reservation
+		}
+		store.mu.Unlock()
+
+		if found {
+			replay(c, stored, fingerprint)
+			return
+		}
+
+		// Release the key unless a response is recorded: after a server
+		// error, or a panic that gin.Recovery catches above this middleware,
+		// the client must be able to retry
+		recorded := false
+		defer func() {
+			if recorded {
+				return
+			}
+			store.mu.Lock()
+			defer store.mu.Unlock()
+			// Unless the reservation expired and a retry took the key over
+			if store.responses[key] == reservation {
+				delete(store.responses, key)
+			}
+		}()
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		status := writer.Status()
+		if status >= 500 {
+			// A server error may not happen again: let the client retry
+			return
+		}
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		recorded = true
+		store.responses[key] = &storedResponse{
+			fingerprint: fingerprint,
+			done:        true,
+			status:      status,
+			header:      writer.Header().Clone(),
+			body:        writer.body.Bytes(),
+			expires:     time.Now().Add(store.ttl),
+		}
+	}
+}
+
+// replay answers a request whose key was seen before. It reads the fields
+// of stored without the lock: they don't change once done is set, and a
+// new storedResponse replaces the reservation.
+func replay(c *gin.Context, stored *storedResponse, fingerprint [32]byte) {
+	switch {
+	case stored.fingerprint != fingerprint:
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+			"error": "Idempotency-Key already used with a different request",
+		})
+	case !stored.done:
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{
+			"error": "A request with this Idempotency-Key is in progress, retry later",
+		})
+	default:
+		for name, values := range stored.header {
+			c.Writer.Header()[name] = values
+		}
+		c.Header("Idempotent-Replayed", "true")
+		c.Writer.WriteHeader(stored.status)
+		c.Writer.Write(stored.body)
+		c.Abort()
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
 	"strconv"
@@ -48,8 +49,16 @@ func NewTodoStore() *TodoStore {
 }
 
 func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	flag.Parse()
+
 	store := NewTodoStore()
 
+	// Responses of POST requests with an Idempotency-Key are kept for a day
+	idempotency := NewIdempotencyStore(24 * time.Hour)
+	stopCleanup := idempotency.StartCleanup(time.Hour)
+	defer stopCleanup()
+
 	// Create a default gin router
 	r := gin.Default()
 
@@ -81,8 +90,8 @@ func main() {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
 		})
 
-		// POST /api/v1/todos - Create a new todo
-		v1.POST("/todos", func(c *gin.Context) {
+		// POST /api/v1/todos - Create a new todo, once per Idempotency-Key
+		v1.POST("/todos", Idempotency(idempotency), func(c *gin.Context) {
 			var newTodo Todo
 
 			// Bind JSON body to the newTodo struct
@@ -157,8 +166,9 @@ func main() {
 		})
 	}
 
-	// Start the server
-	err := r.Run(":8080")
+	// Start the server. Run several instances on different ports behind the
+	// reverse proxy of module 11 with -addr.
+	err := r.Run(*addr)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LogLevel is the severity of a log entry, as in the Logger of module 07
+type LogLevel int
+
+const (
+	DEBUG LogLevel = iota
+	INFO
+	WARNING
+	ERROR
+)
+
+func (l LogLevel) String() string {
+	return [...]string{"DEBUG", "INFO", "WARNING", "ERROR"}[l]
+}
+
+// MarshalText writes the level name in JSON
+func (l LogLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// ParseLogLevel converts a level name, in any case, to a LogLevel
+func ParseLogLevel(name string) (LogLevel, error) {
+	for level := DEBUG; level <= ERROR; level++ {
+		if strings.EqualFold(level.String(), name) {
+			return level, nil
+		}
+	}
+	return DEBUG, fmt.Errorf("unknown log level %q", name)
+}
+
+// LogEntry is a single log message
+type LogEntry struct {
+	Time    time.Time `json:"time"`
+	Level   LogLevel  `json:"level"`
+	Message string    `json:"message"`
+}
+
+// RingBuffer keeps the last log entries in memory, overwriting the oldest
+type RingBuffer struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	next    int // Index of the next write
+	total   int // Entries written since the start
+}
+
+// NewRingBuffer creates a buffer keeping the last size entries
+func NewRingBuffer(size int) *RingBuffer {
+	return &RingBuffer{entries: make([]LogEntry, size)}
+}
+
+// Add stores an entry
+func (b *RingBuffer) Add(level LogLevel, format string, args ...any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[b.next] = LogEntry{Time: time.Now(), Level: level, Message: fmt.Sprintf(format, args...)}
+	b.next = (b.next + 1) % len(b.entries)
+	b.total++
+}
+
+// Recent returns up to n entries of at least minLevel, oldest first, and
+// the number of entries overwritten since the start
+func (b *RingBuffer) Recent(n int, minLevel LogLevel) ([]LogEntry, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stored := min(b.total, len(b.entries))
+	result := make([]LogEntry, 0, min(n, stored))
+	for i := 1; i <= stored && len(result) < n; i++ {
+		entry := b.entries[(b.next-i+len(b.entries))%len(b.entries)]
+		if entry.Level >= minLevel {
+			result = append(result, entry)
+		}
+	}
+	slices.Reverse(result)
+	return result, b.total - stored
+}
+
+// RecordRequests logs every request in the buffer, with a level depending
+// on the response status
+func RecordRequests(buffer *RingBuffer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		level := INFO
+		switch status := c.Writer.Status(); {
+		case status >= 500:
+			level = ERROR
+		case status >= 400:
+			level = WARNING
+		case c.Request.URL.Path == "/debug/logs":
+			level = DEBUG // Don't fill the buffer with its own requests
+		}
+		buffer.Add(level, "%s %s | %d | %s | %s",
+			c.Request.Method, c.Request.URL.Path, c.Writer.Status(), time.Since(start), c.ClientIP())
+		for _, err := range c.Errors {
+			buffer.Add(ERROR, "%s %s: %v", c.Request.Method, c.Request.URL.Path, err.Err)
+		}
+	}
+}
+
+// RecentLogs exposes the buffer as JSON: GET /debug/logs?n=20&level=warning
+func RecentLogs(buffer *RingBuffer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		n, err := strconv.Atoi(c.DefaultQuery("n", "50"))
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "n must be a positive number"})
+			return
+		}
+		minLevel, err := ParseLogLevel(c.DefaultQuery("level", "debug"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		entries, dropped := buffer.Recent(n, minLevel)
+		c.JSON(http.StatusOK, gin.H{
+			"entries": entries,
+			"dropped": dropped,
+		})
+	}
+}
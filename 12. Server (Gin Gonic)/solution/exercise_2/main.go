@@ -86,14 +86,31 @@ func GetUserFromContext(c *gin.Context) string {
 	return user.(string)
 }
 
+// RequireAdmin rejects the users other than the administrator
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if GetUserFromContext(c) != "Administrator" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Administrator access required",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
 func main() {
 	config := NewConfig()
 
 	// Create a Gin router with default middleware
 	r := gin.New()
 
+	// Keep the last requests in memory for live debugging
+	recent := NewRingBuffer(200)
+
 	// Add custom middlewares
 	r.Use(CustomLogger())
+	r.Use(RecordRequests(recent))
 	r.Use(gin.Recovery())
 
 	// Public endpoints
@@ -132,6 +149,9 @@ func main() {
 		})
 	}
 
+	// Recent logs, only for administrators
+	r.GET("/debug/logs", APIKeyAuth(config), RequireAdmin(), RecentLogs(recent))
+
 	// Start the server
 	log.Println("Starting secure API server on :8080...")
 	err := r.Run(":8080")
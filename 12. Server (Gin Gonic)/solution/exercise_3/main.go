@@ -1,8 +1,13 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"log"
 	"net/http"
@@ -12,18 +17,97 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"golang-training/module-11/archive"
+	"golang-training/module-11/healthcheck"
+	"golang-training/module-30/imaging"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
-// UploadStats represent the metadata of uploading file
-type UploadStats struct {
-	Filename   string    `json:"filename"`
-	Size       int64     `json:"size"`
-	MimeType   string    `json:"mime_type"`
-	UploadedAt time.Time `json:"uploaded_at"`
+// writeUpload streams the content to a temporary file and returns its path and SHA-256
+func writeUpload(src io.Reader) (string, string, error) {
+	tmp, err := os.CreateTemp("uploads", ".upload-*")
+	if err != nil {
+		return "", "", err
+	}
+	defer tmp.Close()
+
+	// Hash the content while it is copied, so the file is read only once
+	checksum := newChecksumReader(src)
+	if _, err := io.Copy(tmp, checksum); err != nil {
+		os.Remove(tmp.Name())
+		return "", "", err
+	}
+
+	return tmp.Name(), checksum.Sum(), nil
+}
+
+// checksumReader computes the SHA-256 of the content read through it: an
+// io.TeeReader writes everything read into the hash
+type checksumReader struct {
+	io.Reader
+	hash hash.Hash
+}
+
+func newChecksumReader(r io.Reader) *checksumReader {
+	h := sha256.New()
+	return &checksumReader{Reader: io.TeeReader(r, h), hash: h}
 }
 
-// In-memory store for upload stats
-var uploads []UploadStats
+// Sum returns the hex checksum of the content read so far
+func (c *checksumReader) Sum() string {
+	return hex.EncodeToString(c.hash.Sum(nil))
+}
+
+// writeArchive writes the files of the uploads to w, one after the other,
+// without a temporary file. A file deleted since the listing is skipped.
+func writeArchive(w io.Writer, format archive.Format, uploads *UploadService, list []UploadStats) error {
+	aw := archive.NewWriter(w, format)
+	for _, stats := range list {
+		err := func() error {
+			f, err := os.Open(uploads.Path(&stats))
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			info, err := f.Stat()
+			if err != nil {
+				return err
+			}
+			return aw.AddFile(stats.Filename, info, f)
+		}()
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return aw.Close()
+}
+
+// writeThumbnail writes the image at path scaled down to fit in
+// width×height, keeping PNG for its transparency and JPEG for the rest
+func writeThumbnail(w io.Writer, path string, width, height int) (imaging.Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	img, format, err := imaging.Decode(f, maxImageWidth*maxImageHeight)
+	if err != nil {
+		return "", err
+	}
+
+	size := img.Bounds().Size()
+	if w, h := imaging.Fit(size.X, size.Y, width, height); w != size.X || h != size.Y {
+		img = imaging.Resize(img, w, h, imaging.Bilinear)
+	}
+	if format != imaging.PNG {
+		format = imaging.JPEG
+	}
+	return format, imaging.Encode(w, img, format, 85)
+}
 
 //go:embed upload.html
 var htmlUploadForm string
@@ -31,6 +115,24 @@ var htmlUploadForm string
 // Maximum file size (10 MB)
 const maxFileSize = 10 * 1024 * 1024
 
+// Maximum dimensions of uploaded images
+const (
+	maxImageWidth  = 4096
+	maxImageHeight = 4096
+)
+
+// downloadOnly serves files as downloads of a fixed type. The browser then
+// never renders them on this origin: an upload named page.html or image.svg
+// could otherwise run its script with the session of the user opening it.
+func downloadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", "attachment")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		next.ServeHTTP(w, r)
+	})
+}
+
 func main() {
 	// Create uploads directory if it doesn't exist
 	err := os.MkdirAll("./uploads", 0755)
@@ -38,14 +140,72 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// Upload metadata is kept in SQLite so it survives restarts
+	db, err := gorm.Open(sqlite.Open("uploads.db"), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&UploadStats{}); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	uploadService := NewUploadService(db, "uploads")
+
+	// The server is ready when the database answers and the uploads fit on
+	// the disk
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	health := healthcheck.New(2*time.Second, 5*time.Second)
+	health.Add("database", healthcheck.Readiness, healthcheck.Ping(sqlDB.PingContext))
+	health.Add("disk", healthcheck.Readiness, healthcheck.DiskSpace("./uploads", 10*maxFileSize))
+
+	// Use clamd when configured, otherwise fall back to the stub scanner
+	var scanner Scanner = StubScanner{}
+	if addr := os.Getenv("CLAMD_ADDR"); addr != "" {
+		clamd := &ClamdScanner{Addr: addr, Timeout: 30 * time.Second}
+		scanner = clamd
+		// Only uploads need clamd: the server is degraded, not down, without it
+		health.Add("clamd", healthcheck.Readiness, healthcheck.Ping(clamd.Ping), healthcheck.Optional())
+	}
+	policy := NewDefaultUploadPolicy(scanner)
+
 	// Create a Gin router with default middleware
 	r := gin.Default()
 
 	// Set a lower memory limit for multipart forms (default is 32 MiB)
 	r.MaxMultipartMemory = 8 << 20 // 8 MiB
 
-	// Serve static files from the uploads directory
-	r.Static("/files", "./uploads")
+	// Gin does not allow a catch-all static route next to /files/by-hash/:sha,
+	// so a single catch-all route serves both the files and the hash lookup
+	fileServer := downloadOnly(http.StripPrefix("/files", http.FileServer(gin.Dir("./uploads", false))))
+	serveFiles := func(c *gin.Context) {
+		sum, byHash := strings.CutPrefix(c.Param("filepath"), "/by-hash/")
+		if !byHash {
+			fileServer.ServeHTTP(c.Writer, c.Request)
+			return
+		}
+
+		stats, err := uploadService.FindByHash(strings.ToLower(sum))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No file with this hash"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, stats)
+	}
+	r.GET("/files/*filepath", serveFiles)
+	r.HEAD("/files/*filepath", serveFiles)
+
+	// Health endpoints, shared with the other server exercises
+	r.GET("/livez", gin.WrapH(health.LivenessHandler()))
+	r.GET("/readyz", gin.WrapH(health.ReadinessHandler()))
+	r.GET("/healthz", gin.WrapH(health.ReadinessHandler()))
 
 	// Serve the HTML upload form
 	r.GET("/", func(c *gin.Context) {
@@ -71,6 +231,23 @@ func main() {
 			return
 		}
 
+		// Run the upload policy before anything is written to disk
+		candidate, err := NewUploadCandidate(filepath.Base(header.Filename), header.Size, file)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := policy.Evaluate(c.Request.Context(), candidate); err != nil {
+			var violation *PolicyViolation
+			if errors.As(err, &violation) {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": violation.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
 		// Create a safe filename
 		filename := header.Filename
 		// Remove any path from the filename
@@ -80,41 +257,159 @@ func main() {
 		basename := strings.TrimSuffix(filename, ext)
 		filename = fmt.Sprintf("%s_%d%s", basename, time.Now().Unix(), ext)
 
-		// Create destination file
-		dst, err := os.Create(filepath.Join("uploads", filename))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		defer dst.Close()
-
-		// Copy the file
-		_, err = io.Copy(dst, file)
+		// Copy the file to a temporary location, hashing it on the way
+		tmpPath, sum, err := writeUpload(file)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		// Detect mime type
-		mimeType := header.Header.Get("Content-Type")
-
-		// Store upload stats
-		stats := UploadStats{
+		// Store upload stats, using the sniffed rather than the client provided type.
+		// Identical content uploaded before returns the existing record instead.
+		stats, err := uploadService.Store(tmpPath, &UploadStats{
 			Filename:   filename,
 			Size:       header.Size,
-			MimeType:   mimeType,
+			MimeType:   candidate.MimeType,
+			SHA256:     sum,
 			UploadedAt: time.Now(),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
-		uploads = append(uploads, stats)
 
 		c.JSON(http.StatusOK, stats)
 	})
 
 	// Get list of uploaded files
 	r.GET("/files-list", func(c *gin.Context) {
+		uploads, err := uploadService.FindAll()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusOK, uploads)
 	})
 
+	// REST API for the upload metadata
+	api := r.Group("/api/files")
+	{
+		// GET /api/files?page=1&page_size=20 - List uploads page by page
+		api.GET("", func(c *gin.Context) {
+			page, pageSize := parsePagination(c.Query("page"), c.Query("page_size"))
+
+			result, err := uploadService.List(page, pageSize)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, result)
+		})
+
+		// GET /api/files/archive?format=zip - Download every upload in one
+		// zip or tar.gz archive, streamed as it is written
+		api.GET("/archive", func(c *gin.Context) {
+			format, err := archive.ParseFormat(c.DefaultQuery("format", "zip"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			uploads, err := uploadService.FindAll()
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+
+			name := "uploads-" + time.Now().Format("20060102-150405") + format.Ext()
+			c.Header("Content-Type", format.ContentType())
+			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+			c.Status(http.StatusOK)
+
+			// The status is sent with the first bytes: an error after them
+			// can only stop the archive before its end, which the client
+			// sees as a corrupt archive
+			if err := writeArchive(c.Writer, format, uploadService, uploads); err != nil {
+				log.Printf("archive %s: %v", name, err)
+			}
+		})
+
+		// GET /api/files/:id - Get a single upload
+		api.GET("/:id", func(c *gin.Context) {
+			id, err := parseID(c.Param("id"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			stats, err := uploadService.FindByID(id)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, stats)
+		})
+
+		// GET /api/files/:id/thumbnail?width=200&height=200 - A smaller copy
+		// of an image upload, PNG for PNG uploads and JPEG otherwise
+		api.GET("/:id/thumbnail", func(c *gin.Context) {
+			id, err := parseID(c.Param("id"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			width, height := parseThumbnailSize(c.Query("width"), c.Query("height"))
+
+			stats, err := uploadService.FindByID(id)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if !strings.HasPrefix(stats.MimeType, "image/") {
+				c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Not an image"})
+				return
+			}
+
+			var buf bytes.Buffer
+			format, err := writeThumbnail(&buf, uploadService.Path(stats), width, height)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			// An upload never changes: its thumbnails can be cached for good
+			c.Header("Cache-Control", "public, max-age=31536000, immutable")
+			c.Data(http.StatusOK, format.ContentType(), buf.Bytes())
+		})
+
+		// DELETE /api/files/:id - Delete the upload and its file on disk
+		api.DELETE("/:id", func(c *gin.Context) {
+			id, err := parseID(c.Param("id"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			err = uploadService.Delete(id)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			c.Status(http.StatusNoContent)
+		})
+	}
+
 	// Start the server
 	log.Println("Starting file upload server on :8080...")
 	err = r.Run(":8080")
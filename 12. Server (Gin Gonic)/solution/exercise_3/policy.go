@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrInfected is returned by scanners when the content contains malware
+var ErrInfected = errors.New("file is infected")
+
+// PolicyViolation is returned when an upload is rejected by a rule
+type PolicyViolation struct {
+	Rule   string
+	Reason string
+	Err    error
+}
+
+func (v *PolicyViolation) Error() string {
+	return fmt.Sprintf("upload rejected by %s policy: %s", v.Rule, v.Reason)
+}
+
+func (v *PolicyViolation) Unwrap() error {
+	return v.Err
+}
+
+// UploadCandidate describes an uploaded file before it is persisted
+type UploadCandidate struct {
+	Filename string
+	Size     int64
+	MimeType string // Detected from the content, not the client provided header
+	content  io.ReaderAt
+}
+
+// NewUploadCandidate sniffs the MIME type from the first 512 bytes of the content
+func NewUploadCandidate(filename string, size int64, content io.ReaderAt) (*UploadCandidate, error) {
+	header := make([]byte, 512)
+	n, err := content.ReadAt(header, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read file header: %w", err)
+	}
+
+	return &UploadCandidate{
+		Filename: filename,
+		Size:     size,
+		MimeType: http.DetectContentType(header[:n]),
+		content:  content,
+	}, nil
+}
+
+// Open returns a fresh reader over the whole content, so every rule starts at the beginning
+func (c *UploadCandidate) Open() io.Reader {
+	return io.NewSectionReader(c.content, 0, c.Size)
+}
+
+// UploadRule checks a candidate and returns an error to veto the upload
+type UploadRule interface {
+	Check(ctx context.Context, c *UploadCandidate) error
+}
+
+// UploadRuleFunc allows plain functions to be used as rules
+type UploadRuleFunc func(ctx context.Context, c *UploadCandidate) error
+
+func (f UploadRuleFunc) Check(ctx context.Context, c *UploadCandidate) error {
+	return f(ctx, c)
+}
+
+// UploadPolicy runs a chain of rules, stopping at the first rejection
+type UploadPolicy struct {
+	rules []UploadRule
+}
+
+// NewUploadPolicy creates a policy from the given rules
+func NewUploadPolicy(rules ...UploadRule) *UploadPolicy {
+	return &UploadPolicy{rules: rules}
+}
+
+// Use appends a rule to the policy
+func (p *UploadPolicy) Use(rule UploadRule) {
+	p.rules = append(p.rules, rule)
+}
+
+// Evaluate checks the candidate against every rule in order
+func (p *UploadPolicy) Evaluate(ctx context.Context, c *UploadCandidate) error {
+	for _, rule := range p.rules {
+		if err := rule.Check(ctx, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExtensionBlacklist rejects files by their extension
+type ExtensionBlacklist []string
+
+func (b ExtensionBlacklist) Check(_ context.Context, c *UploadCandidate) error {
+	ext := strings.ToLower(filepath.Ext(c.Filename))
+	for _, blocked := range b {
+		if ext == strings.ToLower(blocked) {
+			return &PolicyViolation{
+				Rule:   "extension",
+				Reason: fmt.Sprintf("files with extension %s are not allowed", ext),
+			}
+		}
+	}
+	return nil
+}
+
+// MIMETypePolicy allows or denies files by their sniffed MIME type.
+// Entries ending with "/" match a whole family, e.g. "image/".
+type MIMETypePolicy struct {
+	Allow []string
+	Deny  []string
+}
+
+func (m MIMETypePolicy) Check(_ context.Context, c *UploadCandidate) error {
+	// Strip parameters such as "; charset=utf-8"
+	mimeType, _, _ := strings.Cut(c.MimeType, ";")
+
+	if matchMIMEType(m.Deny, mimeType) {
+		return &PolicyViolation{
+			Rule:   "mime",
+			Reason: fmt.Sprintf("content type %s is denied", mimeType),
+		}
+	}
+
+	if len(m.Allow) > 0 && !matchMIMEType(m.Allow, mimeType) {
+		return &PolicyViolation{
+			Rule:   "mime",
+			Reason: fmt.Sprintf("content type %s is not allowed", mimeType),
+		}
+	}
+	return nil
+}
+
+// matchMIMEType checks a type against exact entries and family prefixes
+func matchMIMEType(patterns []string, mimeType string) bool {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "/") && strings.HasPrefix(mimeType, pattern) {
+			return true
+		}
+		if pattern == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// ImageDimensions limits the width and height of uploaded images
+type ImageDimensions struct {
+	MaxWidth  int
+	MaxHeight int
+}
+
+// decodedImageTypes are the image types with a decoder registered by the
+// imports above: the others, such as WebP or SVG, can't be measured
+var decodedImageTypes = []string{"image/gif", "image/jpeg", "image/png"}
+
+func (d ImageDimensions) Check(_ context.Context, c *UploadCandidate) error {
+	if !matchMIMEType(decodedImageTypes, c.MimeType) {
+		return nil
+	}
+
+	// DecodeConfig only reads the image header, not the whole image
+	config, format, err := image.DecodeConfig(c.Open())
+	if err != nil {
+		return &PolicyViolation{
+			Rule:   "image",
+			Reason: "unable to decode image header",
+			Err:    err,
+		}
+	}
+
+	if config.Width > d.MaxWidth || config.Height > d.MaxHeight {
+		return &PolicyViolation{
+			Rule: "image",
+			Reason: fmt.Sprintf("%s image is %dx%d, maximum is %dx%d",
+				format, config.Width, config.Height, d.MaxWidth, d.MaxHeight),
+		}
+	}
+	return nil
+}
+
+// Scanner is the hook a content scanner implements to veto uploads
+type Scanner interface {
+	Name() string
+	Scan(ctx context.Context, r io.Reader) error
+}
+
+// ScanWith adapts a scanner into an upload rule
+func ScanWith(scanner Scanner) UploadRule {
+	return UploadRuleFunc(func(ctx context.Context, c *UploadCandidate) error {
+		err := scanner.Scan(ctx, c.Open())
+		if errors.Is(err, ErrInfected) {
+			return &PolicyViolation{
+				Rule:   scanner.Name(),
+				Reason: err.Error(),
+				Err:    err,
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%s scan failed: %w", scanner.Name(), err)
+		}
+		return nil
+	})
+}
+
+// eicarSignature is the standard antivirus test string
+const eicarSignature = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// StubScanner flags files containing the EICAR test signature, useful without a real scanner
+type StubScanner struct{}
+
+func (s StubScanner) Name() string {
+	return "stub-scanner"
+}
+
+// Scan reads the content in chunks rather than whole, so a large upload
+// isn't loaded in memory. The end of each chunk is kept before the next
+// one, so a signature spanning two chunks is found too.
+func (s StubScanner) Scan(ctx context.Context, r io.Reader) error {
+	signature := []byte(eicarSignature)
+	overlap := len(signature) - 1
+	buf := make([]byte, overlap+32*1024)
+	kept := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, readErr := r.Read(buf[kept:])
+		window := buf[:kept+n]
+		if bytes.Contains(window, signature) {
+			return fmt.Errorf("%w: Eicar-Test-Signature", ErrInfected)
+		}
+		kept = copy(buf, window[max(0, len(window)-overlap):])
+
+		if errors.Is(readErr, io.EOF) {
+			return nil
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read content: %w", readErr)
+		}
+	}
+}
+
+// ClamdScanner streams the content to a clamd daemon using the INSTREAM command
+type ClamdScanner struct {
+	Addr    string // e.g. "localhost:3310"
+	Timeout time.Duration
+}
+
+func (s *ClamdScanner) Name() string {
+	return "clamd"
+}
+
+// Ping sends the PING command, answered by PONG when clamd is up
+func (s *ClamdScanner) Ping(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: s.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write([]byte("zPING\x00")); err != nil {
+		return fmt.Errorf("failed to send command: %w", err)
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\x00')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read reply: %w", err)
+	}
+	if reply = strings.TrimRight(reply, "\x00\n"); reply != "PONG" {
+		return fmt.Errorf("unexpected clamd reply: %q", reply)
+	}
+	return nil
+}
+
+func (s *ClamdScanner) Scan(ctx context.Context, r io.Reader) error {
+	dialer := net.Dialer{Timeout: s.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(s.Timeout))
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return fmt.Errorf("failed to send command: %w", err)
+	}
+
+	// Each chunk is prefixed with its length as a 4 byte big-endian integer
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			if err := binary.Write(conn, binary.BigEndian, uint32(n)); err != nil {
+				return fmt.Errorf("failed to send chunk size: %w", err)
+			}
+			if _, err := conn.Write(buf[:n]); err != nil {
+				return fmt.Errorf("failed to send chunk: %w", err)
+			}
+		}
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read content: %w", readErr)
+		}
+	}
+
+	// A zero length chunk terminates the stream
+	if err := binary.Write(conn, binary.BigEndian, uint32(0)); err != nil {
+		return fmt.Errorf("failed to terminate stream: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\x00')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read reply: %w", err)
+	}
+	reply = strings.TrimRight(reply, "\x00\n")
+
+	// Replies look like "stream: OK" or "stream: Eicar-Test-Signature FOUND"
+	switch {
+	case strings.HasSuffix(reply, "OK"):
+		return nil
+	case strings.HasSuffix(reply, "FOUND"):
+		signature := strings.TrimSuffix(strings.TrimPrefix(reply, "stream: "), " FOUND")
+		return fmt.Errorf("%w: %s", ErrInfected, signature)
+	default:
+		return fmt.Errorf("unexpected clamd reply: %q", reply)
+	}
+}
+
+// NewDefaultUploadPolicy creates the policy used by the upload endpoint
+func NewDefaultUploadPolicy(scanner Scanner) *UploadPolicy {
+	return NewUploadPolicy(
+		ExtensionBlacklist{".exe", ".bat", ".cmd", ".sh", ".msi", ".dll", ".js"},
+		MIMETypePolicy{
+			Allow: []string{"image/gif", "image/jpeg", "image/png", "text/", "application/pdf", "application/zip"},
+			Deny:  []string{"text/html", "text/xml"},
+		},
+		ImageDimensions{MaxWidth: maxImageWidth, MaxHeight: maxImageHeight},
+		ScanWith(scanner),
+	)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UploadStats represent the metadata of uploading file
+type UploadStats struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Filename   string    `json:"filename" gorm:"size:255;not null;uniqueIndex"`
+	Size       int64     `json:"size" gorm:"not null"`
+	MimeType   string    `json:"mime_type" gorm:"size:100"`
+	SHA256     string    `json:"sha256" gorm:"size:64;not null;uniqueIndex"`
+	UploadedAt time.Time `json:"uploaded_at" gorm:"index"`
+}
+
+// Pagination defaults for the files API
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// UploadPage is one page of the uploads list
+type UploadPage struct {
+	Files    []UploadStats `json:"files"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+	Total    int64         `json:"total"`
+}
+
+// UploadService handles database and disk operations for uploads
+type UploadService struct {
+	db  *gorm.DB
+	dir string
+	mu  sync.Mutex // Serializes the duplicate check with the insert
+}
+
+// NewUploadService creates a new upload service storing files in dir
+func NewUploadService(db *gorm.DB, dir string) *UploadService {
+	return &UploadService{db: db, dir: dir}
+}
+
+// Store moves a temporary file to its final name and records it. When a file
+// with the same hash already exists, the temporary file is discarded and the
+// existing record is returned instead.
+func (s *UploadService) Store(tmpPath string, stats *UploadStats) (*UploadStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.FindByHash(stats.SHA256)
+	if err == nil {
+		os.Remove(tmpPath)
+		return existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+
+	// A different file with the same name uploaded in the same second gets a hash suffix
+	path := filepath.Join(s.dir, stats.Filename)
+	if _, err := os.Stat(path); err == nil {
+		ext := filepath.Ext(stats.Filename)
+		stats.Filename = fmt.Sprintf("%s_%s%s", strings.TrimSuffix(stats.Filename, ext), stats.SHA256[:8], ext)
+		path = filepath.Join(s.dir, stats.Filename)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to move upload: %w", err)
+	}
+
+	if err := s.db.Create(stats).Error; err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return stats, nil
+}
+
+// FindByID retrieves an upload by its ID
+func (s *UploadService) FindByID(id uint) (*UploadStats, error) {
+	var stats UploadStats
+	if err := s.db.First(&stats, id).Error; err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// FindByHash retrieves an upload by the SHA-256 of its content
+func (s *UploadService) FindByHash(sum string) (*UploadStats, error) {
+	var stats UploadStats
+	if err := s.db.Where("sha256 = ?", sum).First(&stats).Error; err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// FindAll retrieves all uploads, newest first
+func (s *UploadService) FindAll() ([]UploadStats, error) {
+	var uploads []UploadStats
+	err := s.db.Order("uploaded_at DESC, id DESC").Find(&uploads).Error
+	return uploads, err
+}
+
+// Path returns the path of the file of an upload on disk
+func (s *UploadService) Path(stats *UploadStats) string {
+	return filepath.Join(s.dir, stats.Filename)
+}
+
+// List retrieves one page of uploads, newest first
+func (s *UploadService) List(page, pageSize int) (*UploadPage, error) {
+	result := &UploadPage{Files: []UploadStats{}, Page: page, PageSize: pageSize}
+
+	if err := s.db.Model(&UploadStats{}).Count(&result.Total).Error; err != nil {
+		return nil, err
+	}
+
+	err := s.db.Order("uploaded_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&result.Files).Error
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Delete removes the upload record and its file on disk
+func (s *UploadService) Delete(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var stats UploadStats
+		if err := tx.First(&stats, id).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&stats).Error; err != nil {
+			return err
+		}
+
+		// Removing the file last rolls the record back if the file can't be deleted
+		err := os.Remove(filepath.Join(s.dir, stats.Filename))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove file: %w", err)
+		}
+		return nil
+	})
+}
+
+// parsePagination reads the page and page_size query values, falling back to defaults
+func parsePagination(pageValue, pageSizeValue string) (int, int) {
+	page, err := strconv.Atoi(pageValue)
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(pageSizeValue)
+	if err != nil || pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return page, pageSize
+}
+
+// parseThumbnailSize reads the width and height of a thumbnail, 200×200 by
+// default and at most 1024×1024
+func parseThumbnailSize(widthValue, heightValue string) (int, int) {
+	size := func(value string) int {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return 200
+		}
+		return min(n, 1024)
+	}
+	return size(widthValue), size(heightValue)
+}
+
+// parseID converts a path parameter into a record ID
+func parseID(value string) (uint, error) {
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file ID: %s", value)
+	}
+	return uint(id), nil
+}
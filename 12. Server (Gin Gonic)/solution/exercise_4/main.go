@@ -0,0 +1,296 @@
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig holds the cross-origin policy of the API
+type CORSConfig struct {
+	AllowOrigins     []string      // Origins allowed to call the API, "*" allows any origin
+	AllowMethods     []string      // Methods allowed in cross-origin requests
+	AllowHeaders     []string      // Request headers the client is allowed to send
+	ExposeHeaders    []string      // Response headers readable from JavaScript
+	AllowCredentials bool          // Allow cookies and authorization headers
+	MaxAge           time.Duration // How long the browser may cache a preflight response
+}
+
+// NewCORSConfig creates the default policy, allowing overrides from environment variables.
+// Credentials are off unless CORS_ALLOW_CREDENTIALS is "true".
+func NewCORSConfig() (*CORSConfig, error) {
+	cfg := &CORSConfig{
+		AllowOrigins:     getEnvList("CORS_ALLOW_ORIGINS", []string{"http://localhost:3000"}),
+		AllowMethods:     getEnvList("CORS_ALLOW_METHODS", []string{"GET", "POST", "PUT"}),
+		AllowHeaders:     getEnvList("CORS_ALLOW_HEADERS", []string{"Content-Type", "Authorization"}),
+		ExposeHeaders:    getEnvList("CORS_EXPOSE_HEADERS", []string{"X-Total-Count"}),
+		AllowCredentials: os.Getenv("CORS_ALLOW_CREDENTIALS") == "true",
+		MaxAge:           10 * time.Minute,
+	}
+
+	// With credentials, any website could send the cookies of its visitors
+	// and read the answers: only the listed origins may do that
+	if cfg.AllowCredentials && slices.Contains(cfg.AllowOrigins, "*") {
+		return nil, errors.New(`CORS: credentials can't be allowed with the "*" origin, list the origins instead`)
+	}
+	return cfg, nil
+}
+
+// getEnvList reads a comma separated list from the environment
+func getEnvList(key string, fallback []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// isOriginAllowed checks the request origin against the policy
+func (cfg *CORSConfig) isOriginAllowed(origin string) bool {
+	return slices.Contains(cfg.AllowOrigins, "*") || slices.Contains(cfg.AllowOrigins, origin)
+}
+
+// isMethodAllowed checks the requested method against the policy
+func (cfg *CORSConfig) isMethodAllowed(method string) bool {
+	return slices.Contains(cfg.AllowMethods, strings.ToUpper(method))
+}
+
+// areHeadersAllowed checks every requested header against the policy
+func (cfg *CORSConfig) areHeadersAllowed(requested string) bool {
+	if requested == "" {
+		return true
+	}
+
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		allowed := slices.ContainsFunc(cfg.AllowHeaders, func(h string) bool {
+			return strings.EqualFold(h, header)
+		})
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// CORS implements the cross-origin resource sharing middleware by hand
+func CORS(cfg *CORSConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+
+		// Same-origin and non-browser requests don't carry an Origin header
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		// The response differs per origin, so caches must key on it
+		c.Writer.Header().Add("Vary", "Origin")
+
+		preflight := c.Request.Method == http.MethodOptions &&
+			c.GetHeader("Access-Control-Request-Method") != ""
+
+		if !cfg.isOriginAllowed(origin) {
+			log.Printf("CORS: origin %s is not allowed", origin)
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			// Without the CORS headers the browser hides the response from the page
+			c.Next()
+			return
+		}
+
+		// Only a listed origin is echoed back, the others are allowed by the
+		// "*" wildcard, which NewCORSConfig never combines with credentials
+		if slices.Contains(cfg.AllowOrigins, origin) {
+			c.Header("Access-Control-Allow-Origin", origin)
+		} else {
+			c.Header("Access-Control-Allow-Origin", "*")
+		}
+
+		if cfg.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+			c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+
+			requestedMethod := c.GetHeader("Access-Control-Request-Method")
+			requestedHeaders := c.GetHeader("Access-Control-Request-Headers")
+
+			if !cfg.isMethodAllowed(requestedMethod) || !cfg.areHeadersAllowed(requestedHeaders) {
+				log.Printf("CORS: preflight from %s rejected (method: %s, headers: %s)",
+					origin, requestedMethod, requestedHeaders)
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+
+			c.Header("Access-Control-Allow-Methods", strings.Join(cfg.AllowMethods, ", "))
+			c.Header("Access-Control-Allow-Headers", strings.Join(cfg.AllowHeaders, ", "))
+			c.Header("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+
+			// Preflight requests never reach the route handlers
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		if len(cfg.ExposeHeaders) > 0 {
+			c.Header("Access-Control-Expose-Headers", strings.Join(cfg.ExposeHeaders, ", "))
+		}
+
+		c.Next()
+	}
+}
+
+// Message represents a message posted through the API
+type Message struct {
+	ID        int       `json:"id"`
+	Text      string    `json:"text" binding:"required"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MessageStore manages the messages
+type MessageStore struct {
+	messages []Message
+	nextID   int
+	mu       sync.Mutex
+}
+
+// NewMessageStore creates a new store with initial data
+func NewMessageStore() *MessageStore {
+	return &MessageStore{
+		messages: []Message{
+			{ID: 1, Text: "Welcome to the CORS playground", CreatedAt: time.Now()},
+		},
+		nextID: 2,
+	}
+}
+
+//go:embed cors.html
+var htmlCORSPage string
+
+// servePage serves the test page on a separate origin
+func servePage(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(htmlCORSPage))
+	})
+
+	log.Printf("Serving CORS test page on http://localhost%s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func main() {
+	cfg, err := NewCORSConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	store := NewMessageStore()
+
+	// The same page served from an allowed and a blocked origin
+	go servePage(":3000")
+	go servePage(":4000")
+
+	// Create a default gin router
+	r := gin.Default()
+
+	// Register the CORS middleware before any route
+	r.Use(CORS(cfg))
+
+	api := r.Group("/api")
+	{
+		// GET /api/messages - Get all messages
+		api.GET("/messages", func(c *gin.Context) {
+			store.mu.Lock()
+			defer store.mu.Unlock()
+
+			c.Header("X-Total-Count", strconv.Itoa(len(store.messages)))
+			c.JSON(http.StatusOK, store.messages)
+		})
+
+		// POST /api/messages - Create a new message
+		api.POST("/messages", func(c *gin.Context) {
+			var message Message
+			if err := c.ShouldBindJSON(&message); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			store.mu.Lock()
+			defer store.mu.Unlock()
+
+			message.ID = store.nextID
+			store.nextID++
+			message.CreatedAt = time.Now()
+			store.messages = append(store.messages, message)
+
+			c.JSON(http.StatusCreated, message)
+		})
+
+		// DELETE /api/messages/:id - Blocked for browsers by the default policy
+		api.DELETE("/messages/:id", func(c *gin.Context) {
+			id, err := strconv.Atoi(c.Param("id"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+				return
+			}
+
+			store.mu.Lock()
+			defer store.mu.Unlock()
+
+			for i, message := range store.messages {
+				if message.ID == id {
+					store.messages = append(store.messages[:i], store.messages[i+1:]...)
+					c.Status(http.StatusNoContent)
+					return
+				}
+			}
+
+			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		})
+
+		// POST /api/login - Set a cookie that is only sent back with credentials
+		api.POST("/login", func(c *gin.Context) {
+			c.SetSameSite(http.SameSiteLaxMode)
+			c.SetCookie("demo_session", "gopher", 3600, "/", "", false, true)
+			c.JSON(http.StatusOK, gin.H{"message": "Logged in as gopher"})
+		})
+
+		// GET /api/whoami - Read the cookie set by /api/login
+		api.GET("/whoami", func(c *gin.Context) {
+			user, err := c.Cookie("demo_session")
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "No session cookie received"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"user": user})
+		})
+	}
+
+	// Start the server
+	log.Printf("Starting API server on :8080 (allowed origins: %v, credentials: %t)", cfg.AllowOrigins, cfg.AllowCredentials)
+	if err := r.Run(":8080"); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// redacted replaces the values of sensitive fields
+const redacted = "[REDACTED]"
+
+// BodyLogConfig configures BodyLogger
+type BodyLogConfig struct {
+	MaxBodySize  int      // Bytes kept of each body, the rest is dropped
+	RedactFields []string // Field names whose values are never logged, in any case
+	SkipPrefixes []string // Paths not recorded, such as /debug/
+}
+
+// Exchange is a request and its response, as logged
+type Exchange struct {
+	Time              time.Time `json:"time"`
+	RequestID         string    `json:"request_id"`
+	User              string    `json:"user,omitempty"`
+	Method            string    `json:"method"`
+	URL               string    `json:"url"`
+	Status            int       `json:"status"`
+	LatencyMS         float64   `json:"latency_ms"`
+	RequestBody       string    `json:"request_body,omitempty"`
+	RequestTruncated  bool      `json:"request_truncated,omitempty"`
+	ResponseBody      string    `json:"response_body,omitempty"`
+	ResponseTruncated bool      `json:"response_truncated,omitempty"`
+}
+
+// cappedBuffer keeps the first max bytes written to it and counts the rest
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); len(p) > room {
+		b.truncated = true
+		p = p[:max(room, 0)]
+	}
+	b.buf.Write(p)
+	return len(p), nil
+}
+
+// bodyWriter wraps the gin.ResponseWriter to copy the response body as the
+// handler writes it. Embedding the interface keeps the other methods, such
+// as Status and Flush; only the writes are intercepted.
+type bodyWriter struct {
+	gin.ResponseWriter
+	body *cappedBuffer
+}
+
+func (w *bodyWriter) Write(p []byte) (int, error) {
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// WriteString is used by c.String: without it, the embedded WriteString
+// would write to the client without going through Write
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	w.body.Write([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// readCloser joins the reader of the new body with the closer of the
+// original one
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// captureRequestBody reads the first max bytes of the request body, and
+// puts them back in front of the rest: the handler reads the whole body as
+// if nothing happened, and a large upload is not loaded in memory
+func captureRequestBody(r *http.Request, max int) (*cappedBuffer, error) {
+	captured := &cappedBuffer{max: max}
+	if r.Body == nil || r.Body == http.NoBody {
+		return captured, nil
+	}
+	head, err := io.ReadAll(io.LimitReader(r.Body, int64(max)+1))
+	if err != nil {
+		return nil, err
+	}
+	captured.Write(head)
+	r.Body = readCloser{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+	return captured, nil
+}
+
+// Redactor hides the values of sensitive fields in bodies and URLs
+type Redactor struct {
+	fields map[string]bool
+	// For bodies that can't be parsed, such as truncated JSON:
+	// "password": "secret" and password=secret
+	jsonField *regexp.Regexp
+	formField *regexp.Regexp
+}
+
+func NewRedactor(fields []string) *Redactor {
+	r := &Redactor{fields: make(map[string]bool)}
+	quoted := make([]string, len(fields))
+	for i, field := range fields {
+		r.fields[strings.ToLower(field)] = true
+		quoted[i] = regexp.QuoteMeta(field)
+	}
+	names := strings.Join(quoted, "|")
+	r.jsonField = regexp.MustCompile(`(?i)("(?:` + names + `)"\s*:\s*)"(?:[^"\\]|\\.)*"?`)
+	r.formField = regexp.MustCompile(`(?i)\b((?:` + names + `)=)[^&\s]*`)
+	return r
+}
+
+// redactValue walks decoded JSON and replaces the values of the sensitive
+// keys, at any depth
+func (r *Redactor) redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if r.fields[strings.ToLower(key)] {
+				v[key] = redacted
+			} else {
+				v[key] = r.redactValue(value)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = r.redactValue(value)
+		}
+	}
+	return v
+}
+
+// Body returns the body with the sensitive values replaced. A complete JSON
+// or form body is parsed; anything else goes through the pattern, which
+// also covers a JSON body cut by the size cap.
+func (r *Redactor) Body(body []byte, contentType string, truncated bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if !truncated {
+		switch {
+		case strings.HasPrefix(contentType, "application/json"):
+			var decoded any
+			if json.Unmarshal(body, &decoded) == nil {
+				if out, err := json.Marshal(r.redactValue(decoded)); err == nil {
+					return string(out)
+				}
+			}
+		case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+			if values, err := url.ParseQuery(string(body)); err == nil {
+				return r.values(values).Encode()
+			}
+		}
+	}
+	text := r.jsonField.ReplaceAllString(string(body), "${1}"+strconv.Quote(redacted))
+	return r.formField.ReplaceAllString(text, "${1}"+redacted)
+}
+
+func (r *Redactor) values(values url.Values) url.Values {
+	for key := range values {
+		if r.fields[strings.ToLower(key)] {
+			values[key] = []string{redacted}
+		}
+	}
+	return values
+}
+
+// URL returns the path and the query of u, with the sensitive query
+// parameters replaced, such as ?api_key=
+func (r *Redactor) URL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Path
+	}
+	return u.Path + "?" + r.values(u.Query()).Encode()
+}
+
+// ExchangeLog keeps the last exchanges in a ring buffer, like RingBuffer
+// for log entries. It is an http.Handler exposing them as JSON.
+type ExchangeLog struct {
+	mu        sync.Mutex
+	exchanges []Exchange
+	next      int
+	total     int
+}
+
+func NewExchangeLog(size int) *ExchangeLog {
+	return &ExchangeLog{exchanges: make([]Exchange, size)}
+}
+
+func (l *ExchangeLog) Add(exchange Exchange) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.exchanges[l.next] = exchange
+	l.next = (l.next + 1) % len(l.exchanges)
+	l.total++
+}
+
+// Recent returns the stored exchanges, newest first
+func (l *ExchangeLog) Recent() []Exchange {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stored := min(l.total, len(l.exchanges))
+	result := make([]Exchange, 0, stored)
+	for i := 1; i <= stored; i++ {
+		result = append(result, l.exchanges[(l.next-i+len(l.exchanges))%len(l.exchanges)])
+	}
+	return result
+}
+
+func (l *ExchangeLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"exchanges": l.Recent()})
+}
+
+// BodyLogger records the bodies of each request and its response, redacted,
+// in the request log at DEBUG level and in exchanges. It must come after
+// RequestLogger, which sets the request ID.
+func BodyLogger(config BodyLogConfig, exchanges *ExchangeLog) gin.HandlerFunc {
+	redactor := NewRedactor(config.RedactFields)
+	return func(c *gin.Context) {
+		for _, prefix := range config.SkipPrefixes {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+
+		start := time.Now()
+		requestBody, err := captureRequestBody(c.Request, config.MaxBodySize)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read the request body"})
+			return
+		}
+		writer := &bodyWriter{ResponseWriter: c.Writer, body: &cappedBuffer{max: config.MaxBodySize}}
+		c.Writer = writer
+
+		c.Next()
+
+		user, _ := c.Get("user")
+		username, _ := user.(string)
+		exchange := Exchange{
+			Time:              start.UTC(),
+			RequestID:         c.Writer.Header().Get("X-Request-ID"),
+			User:              username,
+			Method:            c.Request.Method,
+			URL:               redactor.URL(c.Request.URL),
+			Status:            c.Writer.Status(),
+			LatencyMS:         float64(time.Since(start).Microseconds()) / 1000,
+			RequestBody:       redactor.Body(requestBody.buf.Bytes(), c.ContentType(), requestBody.truncated),
+			RequestTruncated:  requestBody.truncated,
+			ResponseBody:      redactor.Body(writer.body.buf.Bytes(), c.Writer.Header().Get("Content-Type"), writer.body.truncated),
+			ResponseTruncated: writer.body.truncated,
+		}
+		exchanges.Add(exchange)
+		LoggerFrom(c).Debug("Exchange",
+			slog.String("request_body", exchange.RequestBody),
+			slog.String("response_body", exchange.ResponseBody))
+	}
+}
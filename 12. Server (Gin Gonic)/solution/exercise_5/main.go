@@ -0,0 +1,276 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Config holds the application configuration
+type Config struct {
+	APIKeys map[string]string // Map of API key to username
+}
+
+// NewConfig creates a default configuration
+func NewConfig() *Config {
+	return &Config{
+		APIKeys: map[string]string{
+			"development-key": "Developer",
+			"test-key":        "Tester",
+			"admin-key":       "Administrator",
+		},
+	}
+}
+
+// loggerKey is the gin.Context key of the request logger
+const loggerKey = "logger"
+
+// RequestLogger replaces the text logging middleware: it gives every request
+// a logger carrying its ID, then logs the request with structured attributes
+func RequestLogger(base *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		id := c.GetHeader("X-Request-ID")
+		if id == "" {
+			buf := make([]byte, 8)
+			rand.Read(buf)
+			id = hex.EncodeToString(buf)
+		}
+		c.Header("X-Request-ID", id)
+		c.Set(loggerKey, base.With(slog.String("request_id", id)))
+
+		c.Next()
+
+		// The logger is read again: APIKeyAuth adds the user to it
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		case c.FullPath() == "/debug/logs":
+			level = slog.LevelDebug // Don't fill the buffer with its own requests
+		}
+
+		attrs := []slog.Attr{
+			slog.Group("http",
+				slog.String("method", c.Request.Method),
+				slog.String("path", c.Request.URL.Path),
+				slog.Int("status", status),
+				slog.Int("bytes", c.Writer.Size()),
+			),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+		LoggerFrom(c).LogAttrs(c.Request.Context(), level, "Request", attrs...)
+	}
+}
+
+// LoggerFrom returns the request logger, or the default logger outside of
+// RequestLogger
+func LoggerFrom(c *gin.Context) *slog.Logger {
+	if logger, ok := c.Get(loggerKey); ok {
+		return logger.(*slog.Logger)
+	}
+	return slog.Default()
+}
+
+// Recovery logs a panic with the request logger and answers 500
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, err any) {
+		LoggerFrom(c).Error("Panic recovered", slog.Any("panic", err))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+			"error": "Internal server error",
+		})
+	})
+}
+
+// APIKeyAuth implements authentication using API keys
+func APIKeyAuth(config *Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get API key from header
+		apiKey := c.GetHeader("X-API-Key")
+		if apiKey == "" {
+			// Check if it's in query string
+			apiKey = c.Query("api_key")
+		}
+
+		// Validate API key
+		if apiKey == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "API key is required",
+			})
+			return
+		}
+
+		username, valid := config.APIKeys[apiKey]
+		if !valid {
+			// Never log the key itself, only a prefix to recognise it
+			LoggerFrom(c).Warn("Invalid API key", slog.String("key_prefix", apiKey[:min(4, len(apiKey))]))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid API key",
+			})
+			return
+		}
+
+		// Store user information in the context, and in the request logger
+		c.Set("user", username)
+		c.Set(loggerKey, LoggerFrom(c).With(slog.String("user", username)))
+		c.Next()
+	}
+}
+
+// RequireAdmin rejects the users other than the administrator
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if GetUserFromContext(c) != "Administrator" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "Administrator access required",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetUserFromContext retrieves the user from the Gin context
+func GetUserFromContext(c *gin.Context) string {
+	user, exists := c.Get("user")
+	if !exists {
+		return "Unknown"
+	}
+	return user.(string)
+}
+
+func main() {
+	config := NewConfig()
+
+	// The console shows INFO and above, the file keeps everything, and the
+	// ring buffer keeps the last entries for /debug/logs
+	logger, err := NewLogger(INFO, "")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer logger.Close()
+	if err := logger.AddFile("gin.log", DEBUG); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	recent := NewRingBuffer(200)
+	logger.AddSink(recent, DEBUG)
+	exchanges := NewExchangeLog(50)
+
+	// From here on, every log goes through slog, including the log package
+	// and the debug messages of Gin
+	slog.SetDefault(slog.New(NewSlogHandler(logger)))
+	gin.DebugPrintFunc = func(format string, values ...any) {
+		slog.Debug(strings.TrimSpace(fmt.Sprintf(format, values...)), slog.String("component", "gin"))
+	}
+	gin.DebugPrintRouteFunc = func(method, path, handler string, handlers int) {
+		slog.Debug("Route registered", slog.String("component", "gin"),
+			slog.String("method", method), slog.String("path", path), slog.Int("handlers", handlers))
+	}
+
+	r := gin.New()
+	r.Use(RequestLogger(slog.Default()))
+	r.Use(BodyLogger(BodyLogConfig{
+		MaxBodySize:  4 << 10, // 4 KiB
+		RedactFields: []string{"password", "api_key", "token", "secret"},
+		SkipPrefixes: []string{"/debug/"},
+	}, exchanges))
+	r.Use(Recovery())
+
+	// Public endpoints
+	r.GET("/", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Welcome to the Secure API",
+			"status":  "online",
+			"time":    time.Now().Format(time.RFC3339),
+		})
+	})
+
+	// Secured API group
+	api := r.Group("/api")
+	api.Use(APIKeyAuth(config))
+	{
+		api.GET("/protected", func(c *gin.Context) {
+			username := GetUserFromContext(c)
+			LoggerFrom(c).Debug("Serving protected data")
+			c.JSON(http.StatusOK, gin.H{
+				"message": fmt.Sprintf("Hello, %s! This is protected data.", username),
+				"time":    time.Now().Format(time.RFC3339),
+			})
+		})
+
+		api.GET("/profile", func(c *gin.Context) {
+			username := GetUserFromContext(c)
+			role := "user"
+			if username == "Administrator" {
+				role = "admin"
+			}
+
+			LoggerFrom(c).Info("Profile viewed", slog.String("role", role))
+			c.JSON(http.StatusOK, gin.H{
+				"username": username,
+				"role":     role,
+				"access":   "granted",
+			})
+		})
+
+		// Creates an API key after checking the password: both must stay out
+		// of the logs
+		api.POST("/keys", func(c *gin.Context) {
+			var req struct {
+				Password string `json:"password" binding:"required"`
+				Label    string `json:"label" binding:"required"`
+			}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if req.Password != "training" {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Wrong password"})
+				return
+			}
+			key := make([]byte, 16)
+			rand.Read(key)
+			c.JSON(http.StatusCreated, gin.H{
+				"label":   req.Label,
+				"api_key": hex.EncodeToString(key),
+				"owner":   GetUserFromContext(c),
+			})
+		})
+
+		// A handler failing on purpose, to see the panic in the logs
+		api.GET("/crash", func(c *gin.Context) {
+			var profile map[string]string
+			profile["name"] = GetUserFromContext(c) // Assignment to a nil map
+		})
+	}
+
+	// Recent logs as JSON, only for administrators: the ring buffer is an
+	// http.Handler, wrapped for Gin
+	r.GET("/debug/logs", APIKeyAuth(config), RequireAdmin(), gin.WrapH(recent))
+	// The last requests and responses with their bodies, redacted
+	r.GET("/debug/requests", APIKeyAuth(config), RequireAdmin(), gin.WrapH(exchanges))
+
+	// Start the server
+	slog.Info("Starting secure API server", slog.String("addr", ":8080"))
+	if err := r.Run(":8080"); err != nil {
+		slog.Error("Server stopped", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// demoClients is the number of clients sending requests during the demo
+const demoClients = 8
+
+// runDemo starts the server as a separate process, sends it requests
+// without pause, restarts it with SIGHUP in the middle and counts the
+// requests that failed
+func runDemo() int {
+	// A free port: listen on port 0, then release it for the server
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	addr := probe.Addr().String()
+	probe.Close()
+	baseURL := "http://" + addr
+
+	executable, err := os.Executable()
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	server := exec.Command(executable, "-addr", addr)
+	server.Stdout, server.Stderr = os.Stdout, os.Stderr
+	if err := server.Start(); err != nil {
+		log.Print(err)
+		return 1
+	}
+	if err := waitUntilUp(baseURL); err != nil {
+		server.Process.Kill()
+		log.Print(err)
+		return 1
+	}
+
+	var (
+		mu       sync.Mutex
+		byPID    = make(map[string]int)
+		failures []string
+		total    atomic.Int64
+		lastPID  atomic.Value
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for range demoClients {
+		wg.Go(func() {
+			for ctx.Err() == nil {
+				total.Add(1)
+				resp, err := http.Get(baseURL + "/work?ms=50")
+				if err != nil {
+					mu.Lock()
+					failures = append(failures, err.Error())
+					mu.Unlock()
+					continue
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				pid := resp.Header.Get("X-Server-PID")
+				lastPID.Store(pid)
+				mu.Lock()
+				byPID[pid]++
+				mu.Unlock()
+			}
+		})
+	}
+
+	time.Sleep(time.Second)
+	fmt.Printf("\n>>> kill -HUP %d, under load\n\n", server.Process.Pid)
+	server.Process.Signal(syscall.SIGHUP)
+	// The old process exits once it handed the socket over and drained
+	server.Wait()
+	time.Sleep(time.Second)
+	cancel()
+	wg.Wait()
+
+	fmt.Printf("\n%d requests, %d failed\n", total.Load(), len(failures))
+	for pid, n := range byPID {
+		fmt.Printf("  served by pid %s: %d\n", pid, n)
+	}
+	for _, failure := range failures {
+		fmt.Println("  failure:", failure)
+	}
+
+	// Stop the new process, which is not a child of the demo
+	pid, _ := strconv.Atoi(lastPID.Load().(string))
+	if process, err := os.FindProcess(pid); err == nil {
+		fmt.Printf("\n>>> kill %d\n\n", pid)
+		process.Signal(syscall.SIGTERM)
+		for range 50 {
+			if _, err := http.Get(baseURL); err != nil {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	if len(failures) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// waitUntilUp polls the server until it answers
+func waitUntilUp(baseURL string) error {
+	for range 50 {
+		resp, err := http.Get(baseURL)
+		if err == nil {
+			resp.Body.Close()
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("server at %s not up after 5s", baseURL)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// drainTimeout is how long the requests in progress may take to finish when
+// a process stops
+const drainTimeout = 30 * time.Second
+
+func setupRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery())
+
+	// Every response tells which process served it
+	pid := strconv.Itoa(os.Getpid())
+	generation := strconv.Itoa(Generation())
+	r.Use(func(c *gin.Context) {
+		c.Header("X-Server-PID", pid)
+		c.Header("X-Server-Generation", generation)
+		c.Next()
+	})
+
+	r.GET("/", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"pid": pid, "generation": generation})
+	})
+
+	// A slow request, to see the old process finish it after a restart
+	r.GET("/work", func(c *gin.Context) {
+		ms, err := strconv.Atoi(c.DefaultQuery("ms", "100"))
+		if err != nil || ms < 0 || ms > 60000 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ms must be between 0 and 60000"})
+			return
+		}
+		time.Sleep(time.Duration(ms) * time.Millisecond)
+		c.JSON(http.StatusOK, gin.H{"pid": pid, "slept_ms": ms})
+	})
+	return r
+}
+
+// run serves until SIGINT or SIGTERM, or until a restart handed the socket
+// to a new process. It returns the exit code.
+func run(addr string) int {
+	listener, err := Listen(addr)
+	if err != nil {
+		log.Printf("Failed to listen: %v", err)
+		return 1
+	}
+
+	server := &http.Server{
+		Handler:           setupRouter(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+	if err := NotifyReady(); err != nil {
+		log.Printf("Failed to notify the parent: %v", err)
+		return 1
+	}
+	pid := os.Getpid()
+	log.Printf("Generation %d serving on %s. Restart: kill -HUP %d, stop: kill %d", Generation(), listener.Addr(), pid, pid)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+loop:
+	for {
+		select {
+		case <-hup:
+			log.Println("Received SIGHUP, starting a new process")
+			child, err := StartChild(listener)
+			if err != nil {
+				// The old process keeps the socket: nothing changed for the
+				// clients
+				log.Printf("Restart failed, still serving: %v", err)
+				continue
+			}
+			log.Printf("Process %d is ready, draining", child.Pid)
+			break loop
+		case <-ctx.Done():
+			log.Printf("%v, shutting down", context.Cause(ctx))
+			break loop
+		case err := <-serverErr:
+			log.Printf("Server failed: %v", err)
+			return 1
+		}
+	}
+
+	// Shutdown closes the listener of this process only: the socket stays
+	// open in the child. It then waits for the requests in progress.
+	start := time.Now()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Drain incomplete: %v", err)
+		return 1
+	}
+	log.Printf("Drained in %v, exiting", time.Since(start).Round(time.Millisecond))
+	return 0
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on, unused after a restart")
+	demo := flag.Bool("demo", false, "start the server, restart it under load and report dropped requests")
+	flag.Parse()
+
+	gin.SetMode(gin.ReleaseMode)
+	log.SetPrefix(fmt.Sprintf("[pid %d] ", os.Getpid()))
+
+	if *demo {
+		os.Exit(runDemo())
+	}
+	os.Exit(run(*addr))
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// A restart hands the listening socket from the running process, the parent,
+// to a new process, the child, through file descriptor inheritance:
+//
+//  1. The parent starts the child with the socket as an extra file, and an
+//     environment variable telling the child to use it instead of listening.
+//  2. The child serves on the inherited socket, then reports itself ready by
+//     closing a pipe, also inherited.
+//  3. The parent stops accepting connections and finishes the requests in
+//     progress (draining), then exits.
+//
+// The socket is never closed: while both processes accept from it, the
+// kernel gives each new connection to one of them, and connections waiting
+// in the backlog are accepted by the child once the parent stops. No request
+// is refused. When the child fails to start, the parent keeps serving.
+//
+// Passing sockets to a child works on Unix systems only: on Windows,
+// (*net.TCPListener).File and exec.Cmd.ExtraFiles are not supported.
+
+// Environment variables set for the child
+const (
+	envListenerFD = "GIN_LISTENER_FD"
+	envReadyFD    = "GIN_READY_FD"
+	envGeneration = "GIN_GENERATION"
+)
+
+// Files passed in ExtraFiles get the descriptors 3, 4, ... in the child:
+// 0, 1 and 2 are the standard input, output and error
+const firstExtraFD = 3
+
+// readyTimeout is how long the parent waits for the child to be ready
+const readyTimeout = 10 * time.Second
+
+// Listen returns the socket inherited from the parent, or a new one for the
+// first process
+func Listen(addr string) (net.Listener, error) {
+	fd := os.Getenv(envListenerFD)
+	if fd == "" {
+		return net.Listen("tcp", addr)
+	}
+	n, err := strconv.Atoi(fd)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", envListenerFD, fd, err)
+	}
+	file := os.NewFile(uintptr(n), "listener")
+	defer file.Close()
+	// FileListener duplicates the descriptor: the file can be closed
+	return net.FileListener(file)
+}
+
+// Generation returns the number of restarts since the first process
+func Generation() int {
+	n, _ := strconv.Atoi(os.Getenv(envGeneration))
+	return n
+}
+
+// NotifyReady tells the parent that the child serves requests. It does
+// nothing in the first process.
+func NotifyReady() error {
+	fd := os.Getenv(envReadyFD)
+	if fd == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(fd)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", envReadyFD, fd, err)
+	}
+	// Closing the pipe is the signal: the parent reads EOF
+	return os.NewFile(uintptr(n), "ready").Close()
+}
+
+// StartChild starts a new process of the same executable, with the same
+// arguments, serving on the socket of listener. It returns when the child is
+// ready, or with an error when it exited or didn't become ready in time.
+func StartChild(listener net.Listener) (*os.Process, error) {
+	tcpListener, ok := listener.(*net.TCPListener)
+	if !ok {
+		return nil, fmt.Errorf("cannot pass a %T to a child process", listener)
+	}
+	// File returns a duplicate of the descriptor, without the close-on-exec
+	// flag once passed in ExtraFiles
+	listenerFile, err := tcpListener.File()
+	if err != nil {
+		return nil, fmt.Errorf("getting the listener file: %w", err)
+	}
+	defer listenerFile.Close()
+
+	readyRead, readyWrite, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer readyRead.Close()
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	cmd.ExtraFiles = []*os.File{listenerFile, readyWrite}
+	cmd.Env = append(os.Environ(),
+		envListenerFD+"="+strconv.Itoa(firstExtraFD),
+		envReadyFD+"="+strconv.Itoa(firstExtraFD+1),
+		envGeneration+"="+strconv.Itoa(Generation()+1),
+	)
+	err = cmd.Start()
+	// The child has its own copy: closing ours lets the read below return
+	// EOF when the child closes its copy, or exits
+	readyWrite.Close()
+	if err != nil {
+		return nil, fmt.Errorf("starting the child: %w", err)
+	}
+
+	// The child closes the pipe when ready, and exiting closes it too: wait
+	// for EOF, then check that the child is still running
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	readyRead.SetReadDeadline(time.Now().Add(readyTimeout))
+	_, err = io.Copy(io.Discard, readyRead)
+	switch {
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		cmd.Process.Kill()
+		return nil, fmt.Errorf("child %d not ready after %v", cmd.Process.Pid, readyTimeout)
+	case err != nil:
+		cmd.Process.Kill()
+		return nil, err
+	}
+	select {
+	case err := <-exited:
+		return nil, fmt.Errorf("child %d exited before being ready: %v", cmd.Process.Pid, err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	return cmd.Process, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// request sends a request to the router as tenant: a host such as
+// acme.localhost, or a header value after a comma, such as "localhost,acme"
+func request(router http.Handler, method, path, tenant, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	host, header, _ := strings.Cut(tenant, ",")
+	req.Host = host
+	if header != "" {
+		req.Header.Set(TenantHeader, header)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAPIIsolatesTenants(t *testing.T) {
+	router := setupRouter(newTestDB(t), "localhost")
+
+	rec := request(router, "POST", "/api/v1/todos", "acme.localhost", `{"title": "Order anvils"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST status = %d, want 201: %s", rec.Code, rec.Body)
+	}
+	var created Todo
+	json.Unmarshal(rec.Body.Bytes(), &created)
+	path := "/api/v1/todos/" + strconv.FormatUint(uint64(created.ID), 10)
+
+	// Globex, through the header or its subdomain, can't reach the todo
+	for _, globex := range []string{"localhost,globex", "globex.localhost"} {
+		for _, r := range []struct{ method, body string }{
+			{"GET", ""},
+			{"PUT", `{"title": "Hacked", "completed": true}`},
+			{"DELETE", ""},
+		} {
+			if rec := request(router, r.method, path, globex, r.body); rec.Code != http.StatusNotFound {
+				t.Errorf("%s %s as %s status = %d, want 404", r.method, path, globex, rec.Code)
+			}
+		}
+		rec := request(router, "GET", "/api/v1/todos", globex, "")
+		if body := strings.TrimSpace(rec.Body.String()); body != "[]" {
+			t.Errorf("GET /api/v1/todos as %s = %s, want []", globex, body)
+		}
+	}
+
+	rec = request(router, "GET", path, "localhost,acme", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Order anvils") {
+		t.Errorf("GET %s as acme = %d %s", path, rec.Code, rec.Body)
+	}
+	if strings.Contains(rec.Body.String(), "tenant") {
+		t.Errorf("the tenant ID is sent to the client: %s", rec.Body)
+	}
+}
+
+func TestTenantResolution(t *testing.T) {
+	router := setupRouter(newTestDB(t), "localhost")
+	tests := []struct {
+		name   string
+		tenant string
+		want   int
+	}{
+		{"subdomain", "acme.localhost", http.StatusOK},
+		{"subdomain with port", "acme.localhost:8080", http.StatusOK},
+		{"header", "localhost,globex", http.StatusOK},
+		{"same tenant in both", "acme.localhost,acme", http.StatusOK},
+		{"different tenants", "acme.localhost,globex", http.StatusBadRequest},
+		{"no tenant", "localhost", http.StatusBadRequest},
+		{"nested subdomain", "www.acme.localhost", http.StatusBadRequest},
+		{"other domain", "acme.example.com", http.StatusBadRequest},
+		{"unknown tenant", "initech.localhost", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := request(router, "GET", "/api/v1/tenant", tt.tenant, "")
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang-training/module-11/audit"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// OpenDatabase opens the database, creates the tables and the sample
+// tenants
+func OpenDatabase(dsn string) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.AutoMigrate(&Tenant{}, &Todo{}); err != nil {
+		return nil, err
+	}
+	if err := audit.AutoMigrate(db); err != nil {
+		return nil, err
+	}
+	tenants := []Tenant{{ID: "acme", Name: "Acme Corporation"}, {ID: "globex", Name: "Globex"}}
+	if err := db.Save(&tenants).Error; err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// fail answers a store error: a todo of another tenant is not found, like a
+// todo that doesn't exist
+func fail(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// UserHeader names the user making the request. A real API would take it
+// from a verified token: the header only stands in for the authentication.
+const UserHeader = "X-User-ID"
+
+// ActorMiddleware puts the actor of the request, for the audit log, in the
+// context of the request. It runs after TenantMiddleware, so each tenant
+// only sees its own entries.
+func ActorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := audit.Actor{
+			ID:     c.GetHeader(UserHeader),
+			Tenant: TenantFrom(c.Request.Context()),
+			IP:     c.ClientIP(),
+		}
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
+
+// record adds an entry to the audit log. The change is already made, so a
+// failure is logged rather than answered to the client.
+func record(c *gin.Context, auditLog *audit.Logger, action string, id uint, before, after any) {
+	err := auditLog.Record(c.Request.Context(), action, "todo", strconv.FormatUint(uint64(id), 10), before, after)
+	if err != nil {
+		log.Printf("audit: %s of todo %d: %v", action, id, err)
+	}
+}
+
+// setupRouter creates the API. The handlers don't know about tenants: the
+// middleware puts the tenant in the request context, and the store scopes
+// every query with it. Every change is recorded in the audit log.
+func setupRouter(db *gorm.DB, baseDomain string) *gin.Engine {
+	store := NewTodoStore(db)
+	auditLog := audit.New(db)
+
+	r := gin.Default()
+	v1 := r.Group("/api/v1", TenantMiddleware(db, baseDomain), ActorMiddleware())
+	{
+		v1.GET("/tenant", func(c *gin.Context) {
+			tenant, _ := c.Get("tenant")
+			c.JSON(http.StatusOK, tenant)
+		})
+
+		// GET /api/v1/audit?actor=alice&from=2026-10-01&to=2026-10-18
+		// returns the changes made in the tenant. Restrict it to the
+		// administrators of the tenant in a real API.
+		v1.GET("/audit", gin.WrapH(auditLog.QueryHandler()))
+
+		v1.GET("/todos", func(c *gin.Context) {
+			todos, err := store.List(c.Request.Context())
+			if err != nil {
+				fail(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, todos)
+		})
+
+		v1.GET("/todos/:id", func(c *gin.Context) {
+			id, ok := parseID(c)
+			if !ok {
+				return
+			}
+			todo, err := store.Get(c.Request.Context(), id)
+			if err != nil {
+				fail(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, todo)
+		})
+
+		v1.POST("/todos", func(c *gin.Context) {
+			var todo Todo
+			if err := c.ShouldBindJSON(&todo); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if err := store.Create(c.Request.Context(), &todo); err != nil {
+				fail(c, err)
+				return
+			}
+			record(c, auditLog, "todo.create", todo.ID, nil, todo)
+			c.JSON(http.StatusCreated, todo)
+		})
+
+		v1.PUT("/todos/:id", func(c *gin.Context) {
+			id, ok := parseID(c)
+			if !ok {
+				return
+			}
+			var update Todo
+			if err := c.ShouldBindJSON(&update); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			before, err := store.Get(c.Request.Context(), id)
+			if err != nil {
+				fail(c, err)
+				return
+			}
+			todo, err := store.Update(c.Request.Context(), id, update.Title, update.Completed)
+			if err != nil {
+				fail(c, err)
+				return
+			}
+			record(c, auditLog, "todo.update", id, before, todo)
+			c.JSON(http.StatusOK, todo)
+		})
+
+		v1.DELETE("/todos/:id", func(c *gin.Context) {
+			id, ok := parseID(c)
+			if !ok {
+				return
+			}
+			before, err := store.Get(c.Request.Context(), id)
+			if err != nil {
+				fail(c, err)
+				return
+			}
+			if err := store.Delete(c.Request.Context(), id); err != nil {
+				fail(c, err)
+				return
+			}
+			record(c, auditLog, "todo.delete", id, before, nil)
+			c.Status(http.StatusNoContent)
+		})
+	}
+	return r
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	dsn := flag.String("db", "todos.db", "SQLite database")
+	// Names under localhost resolve to the loopback address, so
+	// http://acme.localhost:8080 works without editing /etc/hosts
+	baseDomain := flag.String("domain", "localhost", "base domain: tenants are its subdomains")
+	retention := flag.Duration("audit-retention", 90*24*time.Hour, "how long the audit log keeps its entries")
+	flag.Parse()
+
+	db, err := OpenDatabase(*dsn)
+	if err != nil {
+		log.Fatalf("Failed to open the database: %v", err)
+	}
+	stopPruning := audit.New(db).StartPruning(*retention, time.Hour, func(err error) {
+		log.Printf("audit: pruning: %v", err)
+	})
+	defer stopPruning()
+
+	log.Printf("Try: curl http://acme.localhost%s/api/v1/todos or curl -H '%s: globex' http://localhost%s/api/v1/todos",
+		*addr, TenantHeader, *addr)
+	if err := setupRouter(db, *baseDomain).Run(*addr); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrNoTenant is returned by the queries run without a tenant in their
+// context: a forgotten middleware fails instead of returning every tenant's
+// todos
+var ErrNoTenant = errors.New("no tenant in context")
+
+// Todo belongs to one tenant. The tenant ID is never read from, nor sent to,
+// the client.
+type Todo struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TenantID  string    `gorm:"index;not null" json:"-"`
+	Title     string    `json:"title" binding:"required"`
+	Completed bool      `json:"completed"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ForTenant is a GORM scope restricting a query to the tenant of ctx
+func ForTenant(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		tenantID := TenantFrom(ctx)
+		if tenantID == "" {
+			db.AddError(ErrNoTenant)
+			return db
+		}
+		return db.Where("tenant_id = ?", tenantID)
+	}
+}
+
+// TodoStore stores the todos of every tenant in one table. Every method
+// goes through scoped, so no query can forget the tenant.
+type TodoStore struct {
+	db *gorm.DB
+}
+
+func NewTodoStore(db *gorm.DB) *TodoStore {
+	return &TodoStore{db: db}
+}
+
+// scoped returns the database restricted to the tenant of ctx
+func (s *TodoStore) scoped(ctx context.Context) *gorm.DB {
+	return s.db.WithContext(ctx).Scopes(ForTenant(ctx))
+}
+
+func (s *TodoStore) List(ctx context.Context) ([]Todo, error) {
+	var todos []Todo
+	err := s.scoped(ctx).Order("id").Find(&todos).Error
+	return todos, err
+}
+
+// Get returns gorm.ErrRecordNotFound for the todos of other tenants too: a
+// tenant can't even learn that an ID exists
+func (s *TodoStore) Get(ctx context.Context, id uint) (*Todo, error) {
+	var todo Todo
+	if err := s.scoped(ctx).First(&todo, id).Error; err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// Create stores the todo for the tenant of ctx, whatever its TenantID
+func (s *TodoStore) Create(ctx context.Context, todo *Todo) error {
+	tenantID := TenantFrom(ctx)
+	if tenantID == "" {
+		return ErrNoTenant
+	}
+	todo.ID = 0
+	todo.TenantID = tenantID
+	return s.db.WithContext(ctx).Create(todo).Error
+}
+
+// Update changes the title and the completion of a todo of the tenant
+func (s *TodoStore) Update(ctx context.Context, id uint, title string, completed bool) (*Todo, error) {
+	result := s.scoped(ctx).Model(&Todo{}).Where("id = ?", id).
+		Updates(map[string]any{"title": title, "completed": completed, "updated_at": time.Now()})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return s.Get(ctx, id)
+}
+
+func (s *TodoStore) Delete(ctx context.Context, id uint) error {
+	result := s.scoped(ctx).Delete(&Todo{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
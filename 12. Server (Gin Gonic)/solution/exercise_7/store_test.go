@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+var (
+	acme   = WithTenant(context.Background(), "acme")
+	globex = WithTenant(context.Background(), "globex")
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "todos.db"))
+	if err != nil {
+		t.Fatalf("OpenDatabase() error = %v", err)
+	}
+	return db
+}
+
+func mustCreate(t *testing.T, store *TodoStore, ctx context.Context, title string) *Todo {
+	t.Helper()
+	todo := &Todo{Title: title}
+	if err := store.Create(ctx, todo); err != nil {
+		t.Fatalf("Create(%q) error = %v", title, err)
+	}
+	return todo
+}
+
+func TestTenantsOnlyListTheirTodos(t *testing.T) {
+	store := NewTodoStore(newTestDB(t))
+	mustCreate(t, store, acme, "Order anvils")
+	mustCreate(t, store, acme, "Test rocket skates")
+	mustCreate(t, store, globex, "Hire a new CEO")
+
+	for ctx, want := range map[context.Context][]string{
+		acme:   {"Order anvils", "Test rocket skates"},
+		globex: {"Hire a new CEO"},
+	} {
+		todos, err := store.List(ctx)
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		var titles []string
+		for _, todo := range todos {
+			titles = append(titles, todo.Title)
+			if todo.TenantID != TenantFrom(ctx) {
+				t.Errorf("List() for %s returned a todo of %s", TenantFrom(ctx), todo.TenantID)
+			}
+		}
+		if len(titles) != len(want) {
+			t.Errorf("List() for %s = %v, want %v", TenantFrom(ctx), titles, want)
+		}
+	}
+}
+
+func TestTenantsCannotReadOrChangeOtherTodos(t *testing.T) {
+	store := NewTodoStore(newTestDB(t))
+	todo := mustCreate(t, store, acme, "Order anvils")
+
+	if _, err := store.Get(globex, todo.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Get() of another tenant's todo error = %v, want ErrRecordNotFound", err)
+	}
+	if _, err := store.Update(globex, todo.ID, "Hacked", true); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Update() of another tenant's todo error = %v, want ErrRecordNotFound", err)
+	}
+	if err := store.Delete(globex, todo.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Delete() of another tenant's todo error = %v, want ErrRecordNotFound", err)
+	}
+
+	// The todo is intact for its tenant
+	got, err := store.Get(acme, todo.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Title != "Order anvils" || got.Completed {
+		t.Errorf("todo changed by another tenant: %+v", got)
+	}
+}
+
+func TestCreateUsesTheTenantOfTheContext(t *testing.T) {
+	store := NewTodoStore(newTestDB(t))
+	todo := &Todo{TenantID: "globex", Title: "Sneak into globex"}
+	if err := store.Create(acme, todo); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if todo.TenantID != "acme" {
+		t.Errorf("TenantID = %q, want acme", todo.TenantID)
+	}
+	if todos, _ := store.List(globex); len(todos) != 0 {
+		t.Errorf("globex sees %d todos created by acme", len(todos))
+	}
+}
+
+func TestQueriesWithoutTenantFail(t *testing.T) {
+	store := NewTodoStore(newTestDB(t))
+	mustCreate(t, store, acme, "Order anvils")
+	ctx := context.Background()
+
+	if _, err := store.List(ctx); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("List() error = %v, want ErrNoTenant", err)
+	}
+	if _, err := store.Get(ctx, 1); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Get() error = %v, want ErrNoTenant", err)
+	}
+	if err := store.Create(ctx, &Todo{Title: "Orphan"}); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Create() error = %v, want ErrNoTenant", err)
+	}
+	if _, err := store.Update(ctx, 1, "Orphan", false); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Update() error = %v, want ErrNoTenant", err)
+	}
+	if err := store.Delete(ctx, 1); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Delete() error = %v, want ErrNoTenant", err)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TenantHeader names the tenant of a request when the host doesn't, such as
+// for calls to localhost
+const TenantHeader = "X-Tenant-ID"
+
+// Tenant is an organization using the service. Its todos are invisible to
+// the other tenants.
+type Tenant struct {
+	ID   string `gorm:"primaryKey" json:"id"` // Also the subdomain: acme.todos.example
+	Name string `json:"name"`
+}
+
+// tenantKey is the context key of the tenant
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant ID
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFrom returns the tenant ID of ctx, or "" when there is none
+func TenantFrom(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantKey{}).(string)
+	return tenantID
+}
+
+// subdomain returns the first label of host when host is a subdomain of
+// baseDomain: "acme" for acme.todos.example:8080
+func subdomain(host, baseDomain string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	label, ok := strings.CutSuffix(strings.ToLower(host), "."+baseDomain)
+	if !ok || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+// TenantMiddleware finds the tenant of the request, from the subdomain of
+// the host or from the X-Tenant-ID header, and stores it in the context of
+// the request, where TodoStore reads it. A request without a known tenant
+// never reaches the handlers.
+func TenantMiddleware(db *gorm.DB, baseDomain string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fromHost := subdomain(c.Request.Host, baseDomain)
+		fromHeader := c.GetHeader(TenantHeader)
+		tenantID := fromHost
+		switch {
+		case fromHost != "" && fromHeader != "" && fromHost != fromHeader:
+			// acme.todos.example with X-Tenant-ID: globex is an attempt to
+			// read another tenant's data, or a broken client
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Tenant of the host and of the header differ"})
+			return
+		case fromHost == "":
+			tenantID = fromHeader
+		}
+		if tenantID == "" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Tenant required: use a subdomain or the " + TenantHeader + " header"})
+			return
+		}
+
+		var tenant Tenant
+		err := db.WithContext(c.Request.Context()).First(&tenant, "id = ?", tenantID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Unknown tenant"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set("tenant", tenant)
+		c.Request = c.Request.WithContext(WithTenant(c.Request.Context(), tenant.ID))
+		c.Next()
+	}
+}
@@ -0,0 +1,89 @@
+// Package flags turns features on and off at runtime: for everyone, for a
+// percentage of the users, or for chosen users, without deploying again.
+package flags
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sync/atomic"
+)
+
+// Flag is the rule of a feature
+type Flag struct {
+	// Enabled is the switch of the feature: false turns it off for
+	// everyone, targeted users included
+	Enabled bool `json:"enabled"`
+	// Percentage of the users getting the feature, from 0 to 100. nil makes
+	// a boolean flag, on for everyone when Enabled.
+	Percentage *int `json:"percentage,omitempty"`
+	// Users always get the feature when it is enabled, such as the team
+	// testing it
+	Users []string `json:"users,omitempty"`
+}
+
+// Validate checks the percentage
+func (f Flag) Validate() error {
+	if f.Percentage != nil && (*f.Percentage < 0 || *f.Percentage > 100) {
+		return fmt.Errorf("percentage %d not between 0 and 100", *f.Percentage)
+	}
+	return nil
+}
+
+// Evaluate reports whether the user gets the feature key
+func (f Flag) Evaluate(key, userID string) bool {
+	switch {
+	case !f.Enabled:
+		return false
+	case userID != "" && slices.Contains(f.Users, userID):
+		return true
+	case f.Percentage == nil:
+		return true
+	case userID == "":
+		// An anonymous request has no stable bucket: it would get the
+		// feature on one request and not on the next
+		return false
+	default:
+		return Bucket(key, userID) < *f.Percentage
+	}
+}
+
+// Bucket places a user in one of 100 buckets for a flag. The same user
+// always lands in the same bucket, so the feature doesn't come and go
+// between requests, and raising the percentage from 10 to 20 keeps the
+// first 10%. The key is part of the hash: the users of a 10% rollout are
+// not the same for every flag.
+func Bucket(key, userID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key + ":" + userID))
+	return int(h.Sum32() % 100)
+}
+
+// Store holds the current flags. Reads don't lock: Replace swaps the whole
+// set, and a request keeps the set it started with.
+type Store struct {
+	flags atomic.Pointer[map[string]Flag]
+}
+
+func NewStore(flags map[string]Flag) *Store {
+	s := &Store{}
+	s.Replace(flags)
+	return s
+}
+
+// Replace installs a new set of flags
+func (s *Store) Replace(flags map[string]Flag) {
+	s.flags.Store(&flags)
+}
+
+// Snapshot returns the current set. It must not be modified.
+func (s *Store) Snapshot() map[string]Flag {
+	return *s.flags.Load()
+}
+
+// Enabled reports whether the user gets the feature key. An unknown flag
+// is off: a typo never turns a feature on.
+func (s *Store) Enabled(key, userID string) bool {
+	flag, ok := s.Snapshot()[key]
+	return ok && flag.Evaluate(key, userID)
+}
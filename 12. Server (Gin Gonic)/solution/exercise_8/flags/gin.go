@@ -0,0 +1,68 @@
+package flags
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// contextKey is the key of the evaluator in the Gin context
+const contextKey = "flags"
+
+// Evaluator answers the flags of one request: the flags of the store when
+// the request started, for its user
+type Evaluator struct {
+	flags  map[string]Flag
+	userID string
+}
+
+// Enabled reports whether the user of the request gets the feature key
+func (e Evaluator) Enabled(key string) bool {
+	flag, ok := e.flags[key]
+	return ok && flag.Evaluate(key, e.userID)
+}
+
+// All returns every flag for the user, for clients that adapt their
+// interface, such as a single-page app
+func (e Evaluator) All() map[string]bool {
+	all := make(map[string]bool, len(e.flags))
+	for key := range e.flags {
+		all[key] = e.Enabled(key)
+	}
+	return all
+}
+
+// Middleware stores the evaluator of the request in the Gin context. user
+// returns the ID of the user, set by the authentication middleware. A
+// reload in the middle of the request doesn't change its flags.
+func Middleware(store *Store, user func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(contextKey, Evaluator{flags: store.Snapshot(), userID: user(c)})
+		c.Next()
+	}
+}
+
+// FromContext returns the evaluator of the request. Without the middleware,
+// every flag is off.
+func FromContext(c *gin.Context) Evaluator {
+	e, _ := c.Get(contextKey)
+	evaluator, _ := e.(Evaluator)
+	return evaluator
+}
+
+// Enabled reports whether the user of the request gets the feature key
+func Enabled(c *gin.Context, key string) bool {
+	return FromContext(c).Enabled(key)
+}
+
+// Require answers 404 Not Found when the feature is off for the user: the
+// routes don't exist for them yet
+func Require(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !Enabled(c, key) {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not found"})
+			return
+		}
+		c.Next()
+	}
+}
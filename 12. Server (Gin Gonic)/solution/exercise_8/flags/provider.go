@@ -0,0 +1,126 @@
+package flags
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix starts the environment variables overriding a flag:
+// FLAG_API_V2=off, FLAG_API_V2=on or FLAG_API_V2=25% for the flag api_v2.
+// An operator can switch a feature off with a restart, without editing the
+// file.
+const EnvPrefix = "FLAG_"
+
+// Parse reads flags from JSON, such as:
+//
+//	{"api_v2": {"enabled": true, "percentage": 25, "users": ["alice"]}}
+func Parse(data []byte) (map[string]Flag, error) {
+	var flags map[string]Flag
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&flags); err != nil {
+		return nil, err
+	}
+	for key, flag := range flags {
+		if err := flag.Validate(); err != nil {
+			return nil, fmt.Errorf("flag %s: %w", key, err)
+		}
+	}
+	return flags, nil
+}
+
+// ApplyEnv applies the overrides of environ, in the form of os.Environ, to
+// flags
+func ApplyEnv(flags map[string]Flag, environ []string) error {
+	for _, entry := range environ {
+		name, value, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(name, EnvPrefix) {
+			continue
+		}
+		key := strings.ToLower(strings.TrimPrefix(name, EnvPrefix))
+		flag := flags[key]
+		switch value = strings.TrimSpace(value); {
+		case value == "on" || value == "true":
+			flag.Enabled, flag.Percentage = true, nil
+		case value == "off" || value == "false":
+			flag.Enabled = false
+		case strings.HasSuffix(value, "%"):
+			percentage, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+			if err != nil {
+				return fmt.Errorf("%s: invalid percentage %q", name, value)
+			}
+			flag.Enabled, flag.Percentage = true, &percentage
+		default:
+			return fmt.Errorf("%s: want on, off or a percentage, got %q", name, value)
+		}
+		if err := flag.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		flags[key] = flag
+	}
+	return nil
+}
+
+// Load reads the flags of a file, with the overrides of the environment
+func Load(path string) (map[string]Flag, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	flags, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := ApplyEnv(flags, os.Environ()); err != nil {
+		return nil, err
+	}
+	return flags, nil
+}
+
+// Watch reloads the file into the store when it changes, checking every
+// interval, until ctx is cancelled. An invalid file is reported to onError
+// and the store keeps the last valid flags: a typo in the file must not
+// turn every feature off.
+//
+// Polling the modification time works everywhere, including the volumes
+// mounted in containers where file system events don't arrive.
+func Watch(ctx context.Context, path string, interval time.Duration, store *Store, onReload func(map[string]Flag), onError func(error)) {
+	var lastMod time.Time
+	var lastSize int64
+	if info, err := os.Stat(path); err == nil {
+		lastMod, lastSize = info.ModTime(), info.Size()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			onError(err)
+			continue
+		}
+		if info.ModTime().Equal(lastMod) && info.Size() == lastSize {
+			continue
+		}
+		lastMod, lastSize = info.ModTime(), info.Size()
+		flags, err := Load(path)
+		if err != nil {
+			onError(err)
+			continue
+		}
+		store.Replace(flags)
+		if onReload != nil {
+			onReload(flags)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang-training/module-12/exercise-8/flags"
+)
+
+// Todo represents a todo item
+type Todo struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title" binding:"required"`
+	Completed bool      `json:"completed"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TodoV2 is the todo of the v2 API: a status replaces the completed
+// boolean, to add more states later
+type TodoV2 struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Status    string    `json:"status"` // open or done
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func toV2(todo Todo) TodoV2 {
+	status := "open"
+	if todo.Completed {
+		status = "done"
+	}
+	return TodoV2{ID: todo.ID, Title: todo.Title, Status: status, CreatedAt: todo.CreatedAt}
+}
+
+// TodoStore manages the todo items
+type TodoStore struct {
+	mu     sync.Mutex
+	todos  []Todo
+	nextID int
+}
+
+func NewTodoStore() *TodoStore {
+	s := &TodoStore{nextID: 1}
+	for i, title := range []string{"Learn Gin Framework", "Build a RESTful API", "Roll out the v2 API", "Write the release notes"} {
+		todo := s.Create(title)
+		s.todos[i].Completed = todo.ID%2 == 1
+	}
+	return s
+}
+
+func (s *TodoStore) Create(title string) Todo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	todo := Todo{ID: s.nextID, Title: title, CreatedAt: time.Now()}
+	s.nextID++
+	s.todos = append(s.todos, todo)
+	return todo
+}
+
+// List returns the todos whose title contains query, all of them when it
+// is empty
+func (s *TodoStore) List(query string) []Todo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	todos := []Todo{}
+	for _, todo := range s.todos {
+		if strings.Contains(strings.ToLower(todo.Title), strings.ToLower(query)) {
+			todos = append(todos, todo)
+		}
+	}
+	return todos
+}
+
+// userID identifies the user. The header stands in for the authentication
+// of Exercise 2, which would set the user from the API key.
+func userID(c *gin.Context) string {
+	return c.GetHeader("X-User-ID")
+}
+
+func newRouter(store *TodoStore, flagStore *flags.Store) *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery(), flags.Middleware(flagStore, userID))
+
+	// GET /api/flags - The flags of the current user, for the front end
+	r.GET("/api/flags", func(c *gin.Context) {
+		c.JSON(http.StatusOK, flags.FromContext(c).All())
+	})
+
+	v1 := r.Group("/api/v1")
+	{
+		// GET /api/v1/todos - Get all todos, filtered by ?q= when the
+		// todo_search flag is on
+		v1.GET("/todos", func(c *gin.Context) {
+			query := ""
+			if flags.Enabled(c, "todo_search") {
+				query = c.Query("q")
+			}
+			c.JSON(http.StatusOK, store.List(query))
+		})
+
+		// POST /api/v1/todos - Create a new todo
+		v1.POST("/todos", func(c *gin.Context) {
+			var input Todo
+			if err := c.ShouldBindJSON(&input); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusCreated, store.Create(input.Title))
+		})
+	}
+
+	// The v2 API only exists for the users of the api_v2 rollout; the
+	// others get 404, as before v2 was deployed
+	v2 := r.Group("/api/v2", flags.Require("api_v2"))
+	{
+		// GET /api/v2/todos - The todos in an envelope, with their status
+		v2.GET("/todos", func(c *gin.Context) {
+			todos := store.List(c.Query("q"))
+			items := make([]TodoV2, 0, len(todos))
+			for _, todo := range todos {
+				items = append(items, toV2(todo))
+			}
+			c.JSON(http.StatusOK, gin.H{"items": items, "total": len(items)})
+		})
+	}
+	return r
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	flagsPath := flag.String("flags", "flags.json", "file of the feature flags, reloaded when it changes")
+	demo := flag.Bool("demo", false, "roll out the v2 API step by step and print who gets it")
+	flag.Parse()
+
+	if *demo {
+		runDemo()
+		return
+	}
+
+	initial, err := flags.Load(*flagsPath)
+	if err != nil {
+		log.Fatalf("Failed to load the flags: %v", err)
+	}
+	flagStore := flags.NewStore(initial)
+	go flags.Watch(context.Background(), *flagsPath, 2*time.Second, flagStore,
+		func(f map[string]flags.Flag) { log.Printf("Flags reloaded from %s", *flagsPath) },
+		func(err error) { log.Printf("Keeping the current flags: %v", err) })
+
+	log.Printf("Listening on %s, flags from %s", *addr, *flagsPath)
+	log.Printf("Try: curl -H 'X-User-ID: alice' http://localhost%s/api/v2/todos", *addr)
+	log.Fatal(newRouter(NewTodoStore(), flagStore).Run(*addr))
+}
+
+// ===== Demo =====
+
+func runDemo() {
+	gin.SetMode(gin.ReleaseMode)
+	dir, err := os.MkdirTemp("", "flags")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "flags.json")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	write(`{"api_v2": {"enabled": true, "percentage": 0, "users": ["alice"]}, "todo_search": {"enabled": false}}`)
+	initial, err := flags.Load(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	flagStore := flags.NewStore(initial)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloaded := make(chan struct{}, 1)
+	go flags.Watch(ctx, path, 20*time.Millisecond, flagStore,
+		func(map[string]flags.Flag) { reloaded <- struct{}{} },
+		func(err error) { fmt.Printf("  reload refused: %v\n", err) })
+	// update writes the file and waits for the watcher to load it
+	update := func(content string) {
+		write(content)
+		select {
+		case <-reloaded:
+		case <-time.After(time.Second):
+			fmt.Println("  (flags not reloaded)")
+		}
+	}
+
+	server := httptest.NewServer(newRouter(NewTodoStore(), flagStore))
+	defer server.Close()
+	get := func(user, path string) (int, string) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		if user != "" {
+			req.Header.Set("X-User-ID", user)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, strings.TrimSpace(string(body))
+	}
+	// rollout counts the users of a sample getting the v2 API
+	users := make([]string, 1000)
+	for i := range users {
+		users[i] = "user-" + strconv.Itoa(i)
+	}
+	rollout := func() int {
+		n := 0
+		for _, user := range users {
+			if status, _ := get(user, "/api/v2/todos"); status == http.StatusOK {
+				n++
+			}
+		}
+		return n
+	}
+	show := func(user, path string) {
+		status, body := get(user, path)
+		if len(body) > 90 {
+			body = body[:90] + "..."
+		}
+		fmt.Printf("  %-8s GET %-22s %d %s\n", user, path, status, body)
+	}
+
+	fmt.Println("--- Targeted users only ---")
+	show("alice", "/api/v2/todos")
+	show("bob", "/api/v2/todos")
+	show("", "/api/v2/todos")
+	fmt.Printf("  v2 for %d of %d users\n", rollout(), len(users))
+
+	fmt.Println("\n--- Progressive rollout ---")
+	var before []string
+	for _, percentage := range []int{10, 25, 50, 100} {
+		update(fmt.Sprintf(`{"api_v2": {"enabled": true, "percentage": %d, "users": ["alice"]}, "todo_search": {"enabled": false}}`, percentage))
+		// The users who had v2 keep it when the percentage grows
+		kept := 0
+		for _, user := range before {
+			if status, _ := get(user, "/api/v2/todos"); status == http.StatusOK {
+				kept++
+			}
+		}
+		fmt.Printf("  %3d%%: v2 for %4d of %d users, %d of the %d before kept it\n", percentage, rollout(), len(users), kept, len(before))
+		before = before[:0]
+		for _, user := range users {
+			if flags.Bucket("api_v2", user) < percentage {
+				before = append(before, user)
+			}
+		}
+	}
+
+	fmt.Println("\n--- Boolean flag in a handler ---")
+	show("bob", "/api/v1/todos?q=v2")
+	update(`{"api_v2": {"enabled": true, "percentage": 100}, "todo_search": {"enabled": true}}`)
+	show("bob", "/api/v1/todos?q=v2")
+	show("bob", "/api/flags")
+
+	fmt.Println("\n--- Kill switch ---")
+	update(`{"api_v2": {"enabled": false, "percentage": 100, "users": ["alice"]}, "todo_search": {"enabled": true}}`)
+	show("alice", "/api/v2/todos")
+	fmt.Printf("  v2 for %d of %d users\n", rollout(), len(users))
+
+	fmt.Println("\n--- Invalid file ---")
+	write(`{"api_v2": {"enabled": true, "percentage": 150}}`)
+	time.Sleep(100 * time.Millisecond)
+	show("alice", "/api/v2/todos")
+
+	fmt.Println("\n--- Environment override ---")
+	os.Setenv("FLAG_API_V2", "on")
+	defer os.Unsetenv("FLAG_API_V2")
+	update(`{"api_v2": {"enabled": false}, "todo_search": {"enabled": true}}`)
+	show("bob", "/api/v2/todos")
+	snapshot, _ := json.Marshal(flagStore.Snapshot())
+	fmt.Printf("  flags: %s\n", snapshot)
+}
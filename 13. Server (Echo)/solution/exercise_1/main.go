@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
 	"strconv"
@@ -48,6 +49,9 @@ func NewTodoStore() *TodoStore {
 }
 
 func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	flag.Parse()
+
 	store := NewTodoStore()
 
 	// Create Echo instance
@@ -142,8 +146,9 @@ func main() {
 		return echo.NewHTTPError(http.StatusNotFound, "Todo not found")
 	})
 
-	// Start server
-	if err := e.Start(":8080"); err != nil {
+	// Start server. Run several instances on different ports behind the
+	// reverse proxy of module 11 with -addr.
+	if err := e.Start(*addr); err != nil {
 		log.Fatal(err)
 	}
 }
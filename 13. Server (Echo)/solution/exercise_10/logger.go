@@ -0,0 +1,275 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The Logger of module 07 (exercise 3) with its sinks, as in exercise 5 of
+// the Gin module. A main package can't be imported, so it is copied here;
+// the server only uses it through slog.
+
+// LogLevel defines different logging severity levels
+type LogLevel int
+
+const (
+	DEBUG LogLevel = iota
+	INFO
+	WARNING
+	ERROR
+	FATAL
+)
+
+func (l LogLevel) String() string {
+	return [...]string{"DEBUG", "INFO", "WARNING", "ERROR", "FATAL"}[l]
+}
+
+// MarshalText writes the level name in JSON
+func (l LogLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// ParseLogLevel converts a level name, in any case, to a LogLevel
+func ParseLogLevel(name string) (LogLevel, error) {
+	for level := DEBUG; level <= FATAL; level++ {
+		if strings.EqualFold(level.String(), name) {
+			return level, nil
+		}
+	}
+	return DEBUG, fmt.Errorf("unknown log level %q", name)
+}
+
+// Attr is a key-value pair attached to a log entry by structured logging
+type Attr struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+// LogEntry is a single log message
+type LogEntry struct {
+	Time    time.Time `json:"time"`
+	Level   LogLevel  `json:"level"`
+	Message string    `json:"message"`
+	Attrs   []Attr    `json:"attrs,omitempty"`
+}
+
+func (e LogEntry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] [%s] %s", e.Time.Format("2006-01-02 15:04:05.000"), e.Level, e.Message)
+	for _, attr := range e.Attrs {
+		value := fmt.Sprint(attr.Value)
+		if strings.ContainsAny(value, " =\"") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&b, " %s=%s", attr.Key, value)
+	}
+	return b.String()
+}
+
+// Sink is a destination for log entries
+type Sink interface {
+	WriteEntry(entry LogEntry) error
+}
+
+// WriterSink writes entries as text lines, to the console or a file
+type WriterSink struct {
+	w io.Writer
+}
+
+// NewWriterSink creates a sink writing to w, closing it with the logger if
+// it is an io.Closer
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func (s *WriterSink) WriteEntry(entry LogEntry) error {
+	_, err := fmt.Fprintln(s.w, entry)
+	return err
+}
+
+func (s *WriterSink) Close() error {
+	if closer, ok := s.w.(io.Closer); ok && s.w != os.Stdout && s.w != os.Stderr {
+		return closer.Close()
+	}
+	return nil
+}
+
+// RingBuffer keeps the last entries in memory, the oldest is overwritten
+// when it is full. It is an http.Handler exposing them as JSON.
+type RingBuffer struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	next    int // Index of the next write
+	total   int // Entries written since the start
+}
+
+// NewRingBuffer creates a buffer keeping the last size entries
+func NewRingBuffer(size int) *RingBuffer {
+	return &RingBuffer{entries: make([]LogEntry, size)}
+}
+
+func (b *RingBuffer) WriteEntry(entry LogEntry) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	b.total++
+	return nil
+}
+
+// Recent returns up to n entries of at least minLevel, oldest first
+func (b *RingBuffer) Recent(n int, minLevel LogLevel) []LogEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stored := min(b.total, len(b.entries))
+	result := make([]LogEntry, 0, min(n, stored))
+	// Walk backwards from the newest entry, then reverse
+	for i := 1; i <= stored && len(result) < n; i++ {
+		entry := b.entries[(b.next-i+len(b.entries))%len(b.entries)]
+		if entry.Level >= minLevel {
+			result = append(result, entry)
+		}
+	}
+	slices.Reverse(result)
+	return result
+}
+
+// ServeHTTP answers GET requests with the recent entries as JSON. The query
+// parameters n (default 50) and level (default DEBUG) filter them.
+func (b *RingBuffer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := 50
+	if value := r.URL.Query().Get("n"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			http.Error(w, "n must be a positive number", http.StatusBadRequest)
+			return
+		}
+		n = parsed
+	}
+	minLevel := DEBUG
+	if value := r.URL.Query().Get("level"); value != "" {
+		level, err := ParseLogLevel(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		minLevel = level
+	}
+
+	entries := b.Recent(n, minLevel)
+	b.mu.Lock()
+	dropped := max(b.total-len(b.entries), 0)
+	b.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"entries": entries,
+		"dropped": dropped, // Entries overwritten since the start
+	})
+}
+
+// levelSink is a sink with its own minimum level
+type levelSink struct {
+	sink     Sink
+	minLevel LogLevel
+}
+
+// Logger provides structured logging functionality. Each sink has its own
+// minimum level, so the console can show warnings while a file keeps
+// everything.
+type Logger struct {
+	mu    sync.Mutex
+	sinks []levelSink
+}
+
+// NewLogger creates a logger writing entries of at least level to the
+// console, and to a file if logPath is not empty
+func NewLogger(level LogLevel, logPath string) (*Logger, error) {
+	logger := &Logger{}
+	logger.AddSink(NewWriterSink(os.Stdout), level)
+
+	if logPath != "" {
+		if err := logger.AddFile(logPath, level); err != nil {
+			return nil, err
+		}
+	}
+	return logger, nil
+}
+
+// AddSink sends the entries of at least minLevel to a sink
+func (l *Logger) AddSink(sink Sink, minLevel LogLevel) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sinks = append(l.sinks, levelSink{sink: sink, minLevel: minLevel})
+}
+
+// AddFile appends the entries of at least minLevel to a file
+func (l *Logger) AddFile(path string, minLevel LogLevel) error {
+	logFile, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	l.AddSink(NewWriterSink(logFile), minLevel)
+	return nil
+}
+
+// Log writes a log entry with the given level and message to every sink
+// accepting its level
+func (l *Logger) Log(level LogLevel, format string, args ...interface{}) {
+	l.Write(LogEntry{
+		Time:    time.Now(),
+		Level:   level,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// Enabled reports whether a sink accepts entries of a level
+func (l *Logger) Enabled(level LogLevel) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range l.sinks {
+		if level >= s.minLevel {
+			return true
+		}
+	}
+	return false
+}
+
+// Write sends an entry to every sink accepting its level
+func (l *Logger) Write(entry LogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range l.sinks {
+		if entry.Level < s.minLevel {
+			continue
+		}
+		if err := s.sink.WriteEntry(entry); err != nil {
+			// A broken sink must not stop the others, report it on stderr
+			fmt.Fprintf(os.Stderr, "log sink %T failed: %v\n", s.sink, err)
+		}
+	}
+}
+
+// Close closes the sinks that need it, such as files
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var errs []error
+	for _, s := range l.sinks {
+		if closer, ok := s.sink.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
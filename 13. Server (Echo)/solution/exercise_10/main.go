@@ -0,0 +1,293 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// Config holds the application configuration
+type Config struct {
+	APIKeys map[string]string // Map of API key to username
+}
+
+// NewConfig creates a default configuration
+func NewConfig() *Config {
+	return &Config{
+		APIKeys: map[string]string{
+			"development-key": "Developer",
+			"test-key":        "Tester",
+			"admin-key":       "Administrator",
+		},
+	}
+}
+
+// loggerKey is the echo.Context key of the request logger
+const loggerKey = "logger"
+
+// WithRequestLogger gives every request a logger carrying its ID, set by
+// middleware.RequestID in the X-Request-ID response header
+func WithRequestLogger(base *slog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			id := c.Response().Header().Get(echo.HeaderXRequestID)
+			c.Set(loggerKey, base.With(slog.String("request_id", id)))
+			return next(c)
+		}
+	}
+}
+
+// LoggerFrom returns the request logger, or the default logger outside of
+// WithRequestLogger
+func LoggerFrom(c echo.Context) *slog.Logger {
+	if logger, ok := c.Get(loggerKey).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// RequestLogger replaces the text logging middleware with the request logger
+// of Echo, which collects the values of the request and leaves the writing
+// to LogValuesFunc. The errors of the handlers go to the error handler
+// first, so the status logged is the one sent.
+func RequestLogger() echo.MiddlewareFunc {
+	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+		LogMethod:       true,
+		LogURIPath:      true,
+		LogStatus:       true,
+		LogResponseSize: true,
+		LogLatency:      true,
+		LogRemoteIP:     true,
+		LogError:        true,
+		HandleError:     true,
+		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
+			level := slog.LevelInfo
+			switch {
+			case v.Status >= 500:
+				level = slog.LevelError
+			case v.Status >= 400:
+				level = slog.LevelWarn
+			case c.Path() == "/debug/logs":
+				level = slog.LevelDebug // Don't fill the buffer with its own requests
+			}
+
+			attrs := []slog.Attr{
+				slog.Group("http",
+					slog.String("method", v.Method),
+					slog.String("path", v.URIPath),
+					slog.Int("status", v.Status),
+					slog.Int64("bytes", v.ResponseSize),
+				),
+				slog.Duration("latency", v.Latency),
+				slog.String("client_ip", v.RemoteIP),
+			}
+			if v.Error != nil {
+				attrs = append(attrs, slog.Any("error", v.Error))
+			}
+			// The logger is read now: APIKeyAuth adds the user to it
+			LoggerFrom(c).LogAttrs(c.Request().Context(), level, "Request", attrs...)
+			return nil
+		},
+	})
+}
+
+// Recover logs a panic with the request logger, then lets the error handler
+// answer 500
+func Recover() echo.MiddlewareFunc {
+	return middleware.RecoverWithConfig(middleware.RecoverConfig{
+		LogErrorFunc: func(c echo.Context, err error, _ []byte) error {
+			LoggerFrom(c).Error("Panic recovered", slog.Any("panic", err))
+			return err
+		},
+	})
+}
+
+// APIKeyAuth implements authentication using API keys
+func APIKeyAuth(config *Config) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// Get API key from header
+			apiKey := c.Request().Header.Get("X-API-Key")
+			if apiKey == "" {
+				// Fallback to query parameter
+				apiKey = c.QueryParam("api_key")
+			}
+
+			if apiKey == "" {
+				return echo.NewHTTPError(http.StatusUnauthorized, "API key is required")
+			}
+
+			username, valid := config.APIKeys[apiKey]
+			if !valid {
+				// Never log the key itself, only a prefix to recognise it
+				LoggerFrom(c).Warn("Invalid API key", slog.String("key_prefix", apiKey[:min(4, len(apiKey))]))
+				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid API key")
+			}
+
+			// Store user information in the context, and in the request logger
+			c.Set("user", username)
+			c.Set(loggerKey, LoggerFrom(c).With(slog.String("user", username)))
+			return next(c)
+		}
+	}
+}
+
+// RequireAdmin rejects the users other than the administrator
+func RequireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if GetUserFromContext(c) != "Administrator" {
+			return echo.NewHTTPError(http.StatusForbidden, "Administrator access required")
+		}
+		return next(c)
+	}
+}
+
+// GetUserFromContext retrieves the user from the Echo context
+func GetUserFromContext(c echo.Context) string {
+	user := c.Get("user")
+	if user == nil {
+		return "Unknown"
+	}
+	return user.(string)
+}
+
+func newServer(config *Config, recent *RingBuffer) *echo.Echo {
+	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	// The messages of Echo itself, such as a failing listener, go through
+	// slog too
+	e.Logger.SetOutput(slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn).Writer())
+
+	// RequestID comes first so every later middleware has the ID
+	e.Use(middleware.RequestID())
+	e.Use(WithRequestLogger(slog.Default()))
+	e.Use(RequestLogger())
+	e.Use(Recover())
+
+	// Public endpoint
+	e.GET("/", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{
+			"message": "Welcome to the Secure API",
+			"status":  "online",
+			"time":    time.Now().Format(time.RFC3339),
+		})
+	})
+
+	// Secured API group
+	api := e.Group("/api")
+	api.Use(APIKeyAuth(config))
+
+	api.GET("/protected", func(c echo.Context) error {
+		username := GetUserFromContext(c)
+		LoggerFrom(c).Debug("Serving protected data")
+		return c.JSON(http.StatusOK, map[string]string{
+			"message": fmt.Sprintf("Hello, %s! This is protected data.", username),
+			"time":    time.Now().Format(time.RFC3339),
+		})
+	})
+
+	api.GET("/profile", func(c echo.Context) error {
+		username := GetUserFromContext(c)
+		role := "user"
+		if username == "Administrator" {
+			role = "admin"
+		}
+
+		LoggerFrom(c).Info("Profile viewed", slog.String("role", role))
+		return c.JSON(http.StatusOK, map[string]string{
+			"username": username,
+			"role":     role,
+			"access":   "granted",
+		})
+	})
+
+	// A handler failing on purpose, to see the panic in the logs
+	api.GET("/crash", func(c echo.Context) error {
+		var profile map[string]string
+		profile["name"] = GetUserFromContext(c) // Assignment to a nil map
+		return nil
+	})
+
+	// Recent logs as JSON, only for administrators: the ring buffer is an
+	// http.Handler, wrapped for Echo
+	e.GET("/debug/logs", echo.WrapHandler(recent), APIKeyAuth(config), RequireAdmin)
+
+	return e
+}
+
+func main() {
+	demo := flag.Bool("demo", false, "send a few requests to the server and print the logs")
+	flag.Parse()
+
+	// The console shows INFO and above, the file keeps everything, and the
+	// ring buffer keeps the last entries for /debug/logs
+	logger, err := NewLogger(INFO, "")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer logger.Close()
+	if !*demo {
+		if err := logger.AddFile("echo.log", DEBUG); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	recent := NewRingBuffer(200)
+	logger.AddSink(recent, DEBUG)
+
+	// From here on, every log goes through slog, including the log package
+	slog.SetDefault(slog.New(NewSlogHandler(logger)))
+
+	e := newServer(NewConfig(), recent)
+	if *demo {
+		runDemo(e)
+		return
+	}
+
+	slog.Info("Starting secure API server", slog.String("addr", ":8080"))
+	if err := e.Start(":8080"); err != nil {
+		slog.Error("Server stopped", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+// runDemo sends requests logged at every level, then asks for the entries
+// of the ring buffer, debug ones included
+func runDemo(e *echo.Echo) {
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	requests := []struct{ path, key string }{
+		{"/", ""},
+		{"/api/profile", "test-key"},
+		{"/api/protected", "wrong-key"},
+		{"/api/crash", "development-key"},
+		{"/missing", ""},
+		{"/debug/logs?n=3&level=debug", "admin-key"},
+	}
+	for _, r := range requests {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+r.path, nil)
+		if r.key != "" {
+			req.Header.Set("X-API-Key", r.key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Printf("GET %s -> %s\n  %s\n", r.path, resp.Status, strings.TrimSpace(string(body)))
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// The slog.Handler of exercise 5 of the Gin module: it knows nothing of the
+// framework, so the Echo server logs through the same code.
+
+// SlogHandler is a slog.Handler writing to a Logger, so code using the
+// standard log/slog API keeps the sinks and levels of the Logger. Groups
+// become key prefixes: "request.method".
+type SlogHandler struct {
+	logger *Logger
+	attrs  []Attr // Added with WithAttrs, already prefixed
+	prefix string // Groups opened with WithGroup, "request." for example
+}
+
+// NewSlogHandler creates a handler for slog.New
+func NewSlogHandler(logger *Logger) *SlogHandler {
+	return &SlogHandler{logger: logger}
+}
+
+// levelFromSlog maps the slog levels to the Logger levels. slog levels are
+// numbers with gaps, such as slog.LevelInfo+2, so ranges are compared.
+func levelFromSlog(level slog.Level) LogLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return DEBUG
+	case level < slog.LevelWarn:
+		return INFO
+	case level < slog.LevelError:
+		return WARNING
+	default:
+		return ERROR
+	}
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Enabled(levelFromSlog(level))
+}
+
+func (h *SlogHandler) Handle(_ context.Context, record slog.Record) error {
+	entry := LogEntry{
+		Time:    record.Time,
+		Level:   levelFromSlog(record.Level),
+		Message: record.Message,
+		Attrs:   slices.Clone(h.attrs),
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		entry.Attrs = appendAttr(entry.Attrs, h.prefix, attr)
+		return true
+	})
+	h.logger.Write(entry)
+	return nil
+}
+
+// WithAttrs returns a handler adding attrs to every entry. The handler is
+// copied: the loggers created from the same parent don't share attributes.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = slices.Clip(h.attrs) // Appending must not write in the parent's array
+	for _, attr := range attrs {
+		clone.attrs = appendAttr(clone.attrs, h.prefix, attr)
+	}
+	return &clone
+}
+
+// WithGroup returns a handler putting the next attributes in a group
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// appendAttr flattens an attribute, following the rules of slog.Handler:
+// empty attributes are ignored, groups are expanded with their key as a
+// prefix and groups without a key are inlined
+func appendAttr(attrs []Attr, prefix string, attr slog.Attr) []Attr {
+	attr.Value = attr.Value.Resolve() // Call LogValuer implementations
+	if attr.Equal(slog.Attr{}) {
+		return attrs
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindGroup:
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, member := range attr.Value.Group() {
+			attrs = appendAttr(attrs, prefix, member)
+		}
+		return attrs
+	case slog.KindDuration:
+		return append(attrs, Attr{Key: prefix + attr.Key, Value: attr.Value.Duration().String()})
+	case slog.KindTime:
+		return append(attrs, Attr{Key: prefix + attr.Key, Value: attr.Value.Time().Format(time.RFC3339)})
+	}
+
+	value := attr.Value.Any()
+	if err, ok := value.(error); ok {
+		value = err.Error() // An error would be encoded as {} in JSON
+	}
+	return append(attrs, Attr{Key: prefix + attr.Key, Value: value})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Timeout gives the handler a deadline: the context of the request is
+// cancelled after d. The handler must pass the context to what it calls, a
+// database query or an HTTP request, and stop when it is done; Go can't
+// interrupt a goroutine from the outside. Applied to a group and to a route,
+// the shorter timeout wins, as a context keeps the earliest deadline.
+func Timeout(d time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx, cancel := context.WithTimeout(c.Request().Context(), d)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			err := next(c)
+			// The deadline of this middleware passed, not the one of an
+			// outer middleware, nor the client going away
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Response().Committed {
+				return echo.NewHTTPError(http.StatusServiceUnavailable,
+					fmt.Sprintf("Request timed out after %v", d)).SetInternal(err)
+			}
+			return err
+		}
+	}
+}
+
+func bodyTooLarge(limit int64) *echo.HTTPError {
+	return echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body larger than %d bytes", limit))
+}
+
+// BodyLimit rejects request bodies larger than limit bytes with 413. A
+// Content-Length above the limit is rejected before the handler runs; a body
+// without a length, sent in chunks, is cut by http.MaxBytesReader while the
+// handler reads it.
+func BodyLimit(limit int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			if req.ContentLength > limit {
+				return bodyTooLarge(limit)
+			}
+			req.Body = http.MaxBytesReader(c.Response(), req.Body, limit)
+
+			err := next(c)
+			// c.Bind wraps the read error in a 400 HTTPError, which unwraps
+			// to the MaxBytesError. Its limit is the one of the reader that
+			// failed, the smallest when a group and a route both set one.
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				return bodyTooLarge(maxErr.Limit).SetInternal(maxErr)
+			}
+			return err
+		}
+	}
+}
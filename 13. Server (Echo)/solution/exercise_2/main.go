@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -102,11 +107,26 @@ func GetUserFromContext(c echo.Context) string {
 	return user.(string)
 }
 
-func main() {
-	config := NewConfig()
+// buildReport simulates a slow report. It stops as soon as ctx is done, and
+// returns the error of the context then.
+func buildReport(ctx context.Context, duration time.Duration) error {
+	select {
+	case <-time.After(duration):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
+// Note is posted to /api/notes
+type Note struct {
+	Text string `json:"text"`
+}
+
+func newServer(config *Config) *echo.Echo {
 	// Create Echo instance
 	e := echo.New()
+	e.HideBanner = true
 
 	// Register custom middlewares
 	e.Use(CustomLogger())
@@ -121,9 +141,10 @@ func main() {
 		})
 	})
 
-	// Secured API group
+	// Secured API group. Every route has a timeout and a body limit; the
+	// routes below can lower them.
 	api := e.Group("/api")
-	api.Use(APIKeyAuth(config))
+	api.Use(APIKeyAuth(config), Timeout(10*time.Second), BodyLimit(64<<10))
 
 	api.GET("/protected", func(c echo.Context) error {
 		username := GetUserFromContext(c)
@@ -147,9 +168,83 @@ func main() {
 		})
 	})
 
+	// A slow endpoint: ?seconds=3 takes longer than its timeout
+	api.GET("/report", func(c echo.Context) error {
+		seconds, err := strconv.ParseFloat(c.QueryParam("seconds"), 64)
+		if err != nil || seconds < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "seconds must be a positive number")
+		}
+		if err := buildReport(c.Request().Context(), time.Duration(seconds*float64(time.Second))); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, map[string]string{"report": "ready", "user": GetUserFromContext(c)})
+	}, Timeout(2*time.Second))
+
+	// Notes are short: 1 KiB at most
+	api.POST("/notes", func(c echo.Context) error {
+		var note Note
+		if err := c.Bind(&note); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusCreated, map[string]int{"length": len(note.Text)})
+	}, BodyLimit(1<<10))
+
+	return e
+}
+
+func main() {
+	demo := flag.Bool("demo", false, "send slow and oversized requests to the server and print the answers")
+	flag.Parse()
+
+	e := newServer(NewConfig())
+	if *demo {
+		runDemo(e)
+		return
+	}
+
 	// Start server
 	log.Println("Starting secure API server on :8080...")
 	if err := e.Start(":8080"); err != nil {
 		log.Fatal(err)
 	}
 }
+
+// runDemo shows the timeouts and the body limits at work
+func runDemo(e *echo.Echo) {
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	note := func(size int) string {
+		return `{"text": "` + strings.Repeat("a", size) + `"}`
+	}
+	requests := []struct {
+		method, path string
+		body         io.Reader
+	}{
+		{"GET", "/api/report?seconds=0.5", nil},
+		{"GET", "/api/report?seconds=3", nil}, // Cancelled after 2s
+		{"POST", "/api/notes", strings.NewReader(note(100))},
+		{"POST", "/api/notes", strings.NewReader(note(5000))}, // Rejected from its Content-Length
+		// No Content-Length: the body is cut while it is read
+		{"POST", "/api/notes", io.MultiReader(strings.NewReader(note(5000)))},
+	}
+	for _, r := range requests {
+		req, _ := http.NewRequest(r.method, server.URL+r.path, r.body)
+		req.Header.Set("X-API-Key", "test-key")
+		req.Header.Set("Content-Type", "application/json")
+		length := strconv.FormatInt(req.ContentLength, 10) + " bytes"
+		if req.ContentLength == 0 && r.body != nil {
+			length = "chunked"
+		}
+		start := time.Now()
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Printf("%s %s (%s) -> %s in %v\n  %s\n",
+			r.method, r.path, length, resp.Status, time.Since(start).Round(10*time.Millisecond), strings.TrimSpace(string(body)))
+	}
+}
@@ -2,12 +2,21 @@ module golang-training/module-13/exercise-3
 
 go 1.25
 
-require github.com/labstack/echo/v4 v4.15.0
+require (
+	github.com/labstack/echo/v4 v4.15.0
+	golang-training/module-11/audit v0.0.0
+	golang-training/module-11/healthcheck v0.0.0
+	gorm.io/driver/sqlite v1.6.0
+	gorm.io/gorm v1.31.1
+)
 
 require (
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
 	github.com/labstack/gommon v0.4.2 // indirect
 	github.com/mattn/go-colorable v0.1.14 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/mattn/go-sqlite3 v1.14.22 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/valyala/fasttemplate v1.2.2 // indirect
 	golang.org/x/crypto v0.46.0 // indirect
@@ -15,3 +24,7 @@ require (
 	golang.org/x/sys v0.39.0 // indirect
 	golang.org/x/text v0.32.0 // indirect
 )
+
+replace golang-training/module-11/audit => "../../../11. Http Server/solution/audit"
+
+replace golang-training/module-11/healthcheck => "../../../11. Http Server/solution/healthcheck"
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"log"
 	"net/http"
@@ -12,18 +16,65 @@ import (
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"golang-training/module-11/audit"
+	"golang-training/module-11/healthcheck"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
-// UploadStats represent the metadata of uploading file
-type UploadStats struct {
-	Filename   string    `json:"filename"`
-	Size       int64     `json:"size"`
-	MimeType   string    `json:"mime_type"`
-	UploadedAt time.Time `json:"uploaded_at"`
+// writeUpload streams the content to a temporary file and returns its path and SHA-256
+func writeUpload(src io.Reader) (string, string, error) {
+	tmp, err := os.CreateTemp("uploads", ".upload-*")
+	if err != nil {
+		return "", "", err
+	}
+	defer tmp.Close()
+
+	// Hash the content while it is copied, so the file is read only once
+	checksum := newChecksumReader(src)
+	if _, err := io.Copy(tmp, checksum); err != nil {
+		os.Remove(tmp.Name())
+		return "", "", err
+	}
+
+	return tmp.Name(), checksum.Sum(), nil
+}
+
+// checksumReader computes the SHA-256 of the content read through it: an
+// io.TeeReader writes everything read into the hash
+type checksumReader struct {
+	io.Reader
+	hash hash.Hash
+}
+
+func newChecksumReader(r io.Reader) *checksumReader {
+	h := sha256.New()
+	return &checksumReader{Reader: io.TeeReader(r, h), hash: h}
+}
+
+// Sum returns the hex checksum of the content read so far
+func (c *checksumReader) Sum() string {
+	return hex.EncodeToString(c.hash.Sum(nil))
+}
+
+// auditActor puts the actor of the request, for the audit log, in the
+// context of the request. X-User-ID stands in for a real authentication.
+func auditActor(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		actor := audit.Actor{ID: c.Request().Header.Get("X-User-ID"), IP: c.RealIP()}
+		c.SetRequest(c.Request().WithContext(audit.WithActor(c.Request().Context(), actor)))
+		return next(c)
+	}
 }
 
-// In-memory store for upload stats
-var uploads []UploadStats
+// record adds an entry to the audit log. The change is already made, so a
+// failure is logged rather than answered to the client.
+func record(c echo.Context, auditLog *audit.Logger, action string, id uint, before, after any) {
+	err := auditLog.Record(c.Request().Context(), action, "file", fmt.Sprint(id), before, after)
+	if err != nil {
+		c.Logger().Errorf("audit: %s of file %d: %v", action, id, err)
+	}
+}
 
 //go:embed upload.html
 var htmlUploadForm string
@@ -31,17 +82,91 @@ var htmlUploadForm string
 // Maximum file size (10 MB)
 const maxFileSize = 10 * 1024 * 1024
 
+// downloadOnly serves files as downloads of a fixed type. The browser then
+// never renders them on this origin: an upload named page.html or image.svg
+// could otherwise run its script with the session of the user opening it.
+func downloadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", "attachment")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		next.ServeHTTP(w, r)
+	})
+}
+
 func main() {
 	// Create uploads directory if it doesn't exist
 	if err := os.MkdirAll("./uploads", 0755); err != nil {
 		log.Fatal(err)
 	}
 
+	// Upload metadata is kept in SQLite so it survives restarts
+	db, err := gorm.Open(sqlite.Open("uploads.db"), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&UploadStats{}); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+	if err := audit.AutoMigrate(db); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	uploadService := NewUploadService(db, "uploads")
+
+	// Who uploaded and deleted which file, kept for 90 days
+	auditLog := audit.New(db)
+	stopPruning := auditLog.StartPruning(90*24*time.Hour, time.Hour, func(err error) {
+		log.Printf("audit: pruning: %v", err)
+	})
+	defer stopPruning()
+
+	// The server is ready when the database answers and the uploads fit on
+	// the disk
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	health := healthcheck.New(2*time.Second, 5*time.Second)
+	health.Add("database", healthcheck.Readiness, healthcheck.Ping(sqlDB.PingContext))
+	health.Add("disk", healthcheck.Readiness, healthcheck.DiskSpace("./uploads", 10*maxFileSize))
+
+	// Use clamd when configured, otherwise fall back to the stub scanner
+	var scanner Scanner = StubScanner{}
+	if addr := os.Getenv("CLAMD_ADDR"); addr != "" {
+		clamd := &ClamdScanner{Addr: addr, Timeout: 30 * time.Second}
+		scanner = clamd
+		// Only uploads need clamd: the server is degraded, not down, without it
+		health.Add("clamd", healthcheck.Readiness, healthcheck.Ping(clamd.Ping), healthcheck.Optional())
+	}
+	policy := NewDefaultUploadPolicy(scanner)
+
 	// Create Echo instance
 	e := echo.New()
+	e.Use(auditActor)
 
-	// Serve static files from the uploads directory
-	e.Static("/files", "./uploads")
+	// Health endpoints, shared with the other server exercises
+	e.GET("/livez", echo.WrapHandler(health.LivenessHandler()))
+	e.GET("/readyz", echo.WrapHandler(health.ReadinessHandler()))
+	e.GET("/healthz", echo.WrapHandler(health.ReadinessHandler()))
+
+	// Serve the uploads from their directory, as downloads
+	fileServer := echo.WrapHandler(downloadOnly(http.StripPrefix("/files", http.FileServer(http.Dir("./uploads")))))
+	e.GET("/files/*", fileServer)
+	e.HEAD("/files/*", fileServer)
+
+	// Look up an uploaded file by the SHA-256 of its content
+	e.GET("/files/by-hash/:sha", func(c echo.Context) error {
+		stats, err := uploadService.FindByHash(strings.ToLower(c.Param("sha")))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "No file with this hash")
+		}
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, stats)
+	})
 
 	// Serve the HTML upload form
 	e.GET("/", func(c echo.Context) error {
@@ -72,44 +197,114 @@ func main() {
 		}
 		defer src.Close()
 
+		// Run the upload policy before anything is written to disk
+		candidate, err := NewUploadCandidate(filepath.Base(file.Filename), file.Size, src)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		if err := policy.Evaluate(c.Request().Context(), candidate); err != nil {
+			var violation *PolicyViolation
+			if errors.As(err, &violation) {
+				return echo.NewHTTPError(http.StatusUnprocessableEntity, violation.Error())
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
 		// Create a safe filename
 		filename := filepath.Base(file.Filename)
 		ext := filepath.Ext(filename)
 		basename := strings.TrimSuffix(filename, ext)
 		filename = fmt.Sprintf("%s_%d%s", basename, time.Now().Unix(), ext)
 
-		// Create destination file
-		dst, err := os.Create(filepath.Join("uploads", filename))
+		// Copy the file to a temporary location, hashing it on the way
+		tmpPath, sum, err := writeUpload(src)
 		if err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
-		defer dst.Close()
-
-		// Copy file contents
-		if _, err := io.Copy(dst, src); err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
-		}
 
-		// Detect MIME type
-		mimeType := file.Header.Get("Content-Type")
-
-		// Store upload stats
-		stats := UploadStats{
+		// Store upload stats, using the sniffed rather than the client provided type.
+		// Identical content uploaded before returns the existing record instead.
+		stats, err := uploadService.Store(tmpPath, &UploadStats{
 			Filename:   filename,
 			Size:       file.Size,
-			MimeType:   mimeType,
+			MimeType:   candidate.MimeType,
+			SHA256:     sum,
 			UploadedAt: time.Now(),
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
-		uploads = append(uploads, stats)
+		record(c, auditLog, "file.upload", stats.ID, nil, stats)
 
 		return c.JSON(http.StatusOK, stats)
 	})
 
 	// Get list of uploaded files
 	e.GET("/files-list", func(c echo.Context) error {
+		uploads, err := uploadService.FindAll()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
 		return c.JSON(http.StatusOK, uploads)
 	})
 
+	// REST API for the upload metadata
+	api := e.Group("/api/files")
+
+	// GET /api/files?page=1&page_size=20 - List uploads page by page
+	api.GET("", func(c echo.Context) error {
+		page, pageSize := parsePagination(c.QueryParam("page"), c.QueryParam("page_size"))
+
+		result, err := uploadService.List(page, pageSize)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, result)
+	})
+
+	// GET /api/files/:id - Get a single upload
+	api.GET("/:id", func(c echo.Context) error {
+		id, err := parseID(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		stats, err := uploadService.FindByID(id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "File not found")
+		}
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, stats)
+	})
+
+	// DELETE /api/files/:id - Delete the upload and its file on disk
+	api.DELETE("/:id", func(c echo.Context) error {
+		id, err := parseID(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		before, err := uploadService.FindByID(id)
+		if err == nil {
+			err = uploadService.Delete(id)
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "File not found")
+		}
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		record(c, auditLog, "file.delete", id, before, nil)
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	// GET /api/audit?actor=alice&action=file.delete&from=2026-10-01 - Who
+	// changed what. Restrict it to administrators in a real server.
+	e.GET("/api/audit", echo.WrapHandler(auditLog.QueryHandler()))
+
 	// Start server
 	log.Println("Starting file upload server on :8080...")
 	if err := e.Start(":8080"); err != nil {
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// Name of the cookie carrying the signed session ID
+const sessionCookieName = "session_id"
+
+// Sentinel errors returned by the session store
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionExpired  = errors.New("session expired")
+	ErrInvalidCookie   = errors.New("invalid session cookie")
+)
+
+// Config holds the application configuration
+type Config struct {
+	Users         map[string]string // Map of username to password
+	CookieSecret  []byte            // Key used to sign session cookies
+	SessionTTL    time.Duration     // Idle time before a session expires
+	RenewalWindow time.Duration     // Remaining lifetime that triggers a renewal
+	SecureCookie  bool              // Only send the cookie over HTTPS
+}
+
+// NewConfig creates a default configuration
+func NewConfig() *Config {
+	return &Config{
+		Users: map[string]string{
+			"developer": "dev-password",
+			"tester":    "test-password",
+			"admin":     "admin-password",
+		},
+		CookieSecret:  []byte("change-me-to-a-long-random-secret"),
+		SessionTTL:    15 * time.Minute,
+		RenewalWindow: 5 * time.Minute,
+		SecureCookie:  false, // Set to true when serving over HTTPS
+	}
+}
+
+// Session represents an authenticated user session
+type Session struct {
+	ID        string    `json:"-"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SessionStore keeps sessions in memory and expires them after a TTL
+type SessionStore struct {
+	sessions map[string]*Session
+	ttl      time.Duration
+	mu       sync.Mutex
+}
+
+// NewSessionStore creates a new store with the given session lifetime
+func NewSessionStore(ttl time.Duration) *SessionStore {
+	return &SessionStore{
+		sessions: make(map[string]*Session),
+		ttl:      ttl,
+	}
+}
+
+// Create starts a new session for the given user
+func (s *SessionStore) Create(username string) (*Session, error) {
+	id, err := generateSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &Session{
+		ID:        id,
+		Username:  username,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[id] = session
+	return session, nil
+}
+
+// Get returns a copy of a live session, removing it if it has expired
+func (s *SessionStore) Get(id string) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, exists := s.sessions[id]
+	if !exists {
+		return Session{}, ErrSessionNotFound
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		delete(s.sessions, id)
+		return Session{}, ErrSessionExpired
+	}
+
+	return *session, nil
+}
+
+// Renew extends the lifetime of a session by another TTL
+func (s *SessionStore) Renew(id string) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, exists := s.sessions[id]
+	if !exists {
+		return Session{}, ErrSessionNotFound
+	}
+
+	session.ExpiresAt = time.Now().Add(s.ttl)
+	return *session, nil
+}
+
+// Revoke deletes a session so it can no longer be used
+func (s *SessionStore) Revoke(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+}
+
+// Cleanup removes all expired sessions from the store
+func (s *SessionStore) Cleanup() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	now := time.Now()
+	for id, session := range s.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(s.sessions, id)
+			removed++
+		}
+	}
+	return removed
+}
+
+// generateSessionID returns a random, URL-safe session identifier
+func generateSessionID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CookieCodec signs session IDs so the client cannot forge them
+type CookieCodec struct {
+	secret []byte
+}
+
+// Encode appends an HMAC signature to the session ID
+func (c CookieCodec) Encode(id string) string {
+	return id + "." + c.sign(id)
+}
+
+// Decode verifies the signature and returns the session ID
+func (c CookieCodec) Decode(value string) (string, error) {
+	id, signature, found := strings.Cut(value, ".")
+	if !found {
+		return "", ErrInvalidCookie
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(c.sign(id))) {
+		return "", ErrInvalidCookie
+	}
+	return id, nil
+}
+
+// sign computes the HMAC-SHA256 of the value
+func (c CookieCodec) sign(value string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// newSessionCookie builds the HttpOnly, SameSite cookie for a session
+func newSessionCookie(config *Config, value string, expires time.Time) *http.Cookie {
+	return &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    value,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true, // Not readable from JavaScript
+		Secure:   config.SecureCookie,
+		SameSite: http.SameSiteLaxMode, // Not sent on cross-site POST requests
+	}
+}
+
+// SessionAuth loads the session user into the context and renews sessions close to expiry
+func SessionAuth(config *Config, store *SessionStore, codec CookieCodec) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			cookie, err := c.Cookie(sessionCookieName)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Login required")
+			}
+
+			id, err := codec.Decode(cookie.Value)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid session")
+			}
+
+			session, err := store.Get(id)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Session expired or revoked")
+			}
+
+			// Sliding expiration: renew the session when it is about to expire
+			if time.Until(session.ExpiresAt) < config.RenewalWindow {
+				session, err = store.Renew(id)
+				if err != nil {
+					return echo.NewHTTPError(http.StatusUnauthorized, "Session expired or revoked")
+				}
+				c.SetCookie(newSessionCookie(config, cookie.Value, session.ExpiresAt))
+			}
+
+			// Store session information in the context
+			c.Set("user", session.Username)
+			c.Set("session", session)
+
+			return next(c)
+		}
+	}
+}
+
+// GetUserFromContext retrieves the user from the Echo context
+func GetUserFromContext(c echo.Context) string {
+	user := c.Get("user")
+	if user == nil {
+		return "Unknown"
+	}
+	return user.(string)
+}
+
+// LoginRequest contains the login form data
+type LoginRequest struct {
+	Username string `json:"username" form:"username"`
+	Password string `json:"password" form:"password"`
+}
+
+// checkCredentials compares the password in constant time
+func checkCredentials(config *Config, username, password string) bool {
+	expected, exists := config.Users[username]
+	if !exists {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(password)) == 1
+}
+
+func main() {
+	config := NewConfig()
+	store := NewSessionStore(config.SessionTTL)
+	codec := CookieCodec{secret: config.CookieSecret}
+
+	// Periodically remove expired sessions
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if removed := store.Cleanup(); removed > 0 {
+				log.Printf("Removed %d expired sessions", removed)
+			}
+		}
+	}()
+
+	sessionAuth := SessionAuth(config, store, codec)
+
+	// Create Echo instance
+	e := echo.New()
+
+	e.Use(middleware.Logger())
+	e.Use(middleware.Recover())
+
+	// Public endpoint
+	e.GET("/", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{
+			"message": "Welcome to the Session API",
+			"status":  "online",
+			"time":    time.Now().Format(time.RFC3339),
+		})
+	})
+
+	// POST /login - Validate credentials and start a session
+	e.POST("/login", func(c echo.Context) error {
+		var req LoginRequest
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		if !checkCredentials(config, req.Username, req.Password) {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Invalid username or password")
+		}
+
+		session, err := store.Create(req.Username)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		c.SetCookie(newSessionCookie(config, codec.Encode(session.ID), session.ExpiresAt))
+
+		return c.JSON(http.StatusOK, session)
+	})
+
+	// POST /logout - Revoke the current session
+	e.POST("/logout", func(c echo.Context) error {
+		session := c.Get("session").(Session)
+		store.Revoke(session.ID)
+
+		// Expire the cookie in the browser
+		cookie := newSessionCookie(config, "", time.Unix(0, 0))
+		cookie.MaxAge = -1
+		c.SetCookie(cookie)
+
+		return c.JSON(http.StatusOK, map[string]string{
+			"message": "Logged out",
+		})
+	}, sessionAuth)
+
+	// Session protected group
+	api := e.Group("/api")
+	api.Use(sessionAuth)
+
+	api.GET("/profile", func(c echo.Context) error {
+		session := c.Get("session").(Session)
+		return c.JSON(http.StatusOK, map[string]string{
+			"username":   session.Username,
+			"logged_in":  session.CreatedAt.Format(time.RFC3339),
+			"expires_at": session.ExpiresAt.Format(time.RFC3339),
+		})
+	})
+
+	api.GET("/protected", func(c echo.Context) error {
+		username := GetUserFromContext(c)
+		return c.JSON(http.StatusOK, map[string]string{
+			"message": fmt.Sprintf("Hello, %s! This is protected data.", username),
+			"time":    time.Now().Format(time.RFC3339),
+		})
+	})
+
+	// Start server
+	log.Println("Starting session API server on :8080...")
+	if err := e.Start(":8080"); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	_ "embed"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// CORSConfig holds the cross-origin policy of the API
+type CORSConfig struct {
+	AllowOrigins     []string      // Origins allowed to call the API, "*" allows any origin
+	AllowMethods     []string      // Methods allowed in cross-origin requests
+	AllowHeaders     []string      // Request headers the client is allowed to send
+	ExposeHeaders    []string      // Response headers readable from JavaScript
+	AllowCredentials bool          // Allow cookies and authorization headers
+	MaxAge           time.Duration // How long the browser may cache a preflight response
+}
+
+// NewCORSConfig creates the default policy, allowing overrides from environment variables.
+// Credentials are off unless CORS_ALLOW_CREDENTIALS is "true".
+func NewCORSConfig() (*CORSConfig, error) {
+	cfg := &CORSConfig{
+		AllowOrigins:     getEnvList("CORS_ALLOW_ORIGINS", []string{"http://localhost:3000"}),
+		AllowMethods:     getEnvList("CORS_ALLOW_METHODS", []string{"GET", "POST", "PUT"}),
+		AllowHeaders:     getEnvList("CORS_ALLOW_HEADERS", []string{"Content-Type", "Authorization"}),
+		ExposeHeaders:    getEnvList("CORS_EXPOSE_HEADERS", []string{"X-Total-Count"}),
+		AllowCredentials: os.Getenv("CORS_ALLOW_CREDENTIALS") == "true",
+		MaxAge:           10 * time.Minute,
+	}
+
+	// With credentials, any website could send the cookies of its visitors
+	// and read the answers: only the listed origins may do that
+	if cfg.AllowCredentials && slices.Contains(cfg.AllowOrigins, "*") {
+		return nil, errors.New(`CORS: credentials can't be allowed with the "*" origin, list the origins instead`)
+	}
+	return cfg, nil
+}
+
+// getEnvList reads a comma separated list from the environment
+func getEnvList(key string, fallback []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// isOriginAllowed checks the request origin against the policy
+func (cfg *CORSConfig) isOriginAllowed(origin string) bool {
+	return slices.Contains(cfg.AllowOrigins, "*") || slices.Contains(cfg.AllowOrigins, origin)
+}
+
+// isMethodAllowed checks the requested method against the policy
+func (cfg *CORSConfig) isMethodAllowed(method string) bool {
+	return slices.Contains(cfg.AllowMethods, strings.ToUpper(method))
+}
+
+// areHeadersAllowed checks every requested header against the policy
+func (cfg *CORSConfig) areHeadersAllowed(requested string) bool {
+	if requested == "" {
+		return true
+	}
+
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		allowed := slices.ContainsFunc(cfg.AllowHeaders, func(h string) bool {
+			return strings.EqualFold(h, header)
+		})
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// CORS implements the cross-origin resource sharing middleware by hand
+func CORS(cfg *CORSConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			header := c.Response().Header()
+			origin := req.Header.Get(echo.HeaderOrigin)
+
+			// Same-origin and non-browser requests don't carry an Origin header
+			if origin == "" {
+				return next(c)
+			}
+
+			// The response differs per origin, so caches must key on it
+			header.Add(echo.HeaderVary, echo.HeaderOrigin)
+
+			preflight := req.Method == http.MethodOptions &&
+				req.Header.Get(echo.HeaderAccessControlRequestMethod) != ""
+
+			if !cfg.isOriginAllowed(origin) {
+				log.Printf("CORS: origin %s is not allowed", origin)
+				if preflight {
+					return c.NoContent(http.StatusForbidden)
+				}
+				// Without the CORS headers the browser hides the response from the page
+				return next(c)
+			}
+
+			// Only a listed origin is echoed back, the others are allowed by the
+			// "*" wildcard, which NewCORSConfig never combines with credentials
+			if slices.Contains(cfg.AllowOrigins, origin) {
+				header.Set(echo.HeaderAccessControlAllowOrigin, origin)
+			} else {
+				header.Set(echo.HeaderAccessControlAllowOrigin, "*")
+			}
+
+			if cfg.AllowCredentials {
+				header.Set(echo.HeaderAccessControlAllowCredentials, "true")
+			}
+
+			if preflight {
+				header.Add(echo.HeaderVary, echo.HeaderAccessControlRequestMethod)
+				header.Add(echo.HeaderVary, echo.HeaderAccessControlRequestHeaders)
+
+				requestedMethod := req.Header.Get(echo.HeaderAccessControlRequestMethod)
+				requestedHeaders := req.Header.Get(echo.HeaderAccessControlRequestHeaders)
+
+				if !cfg.isMethodAllowed(requestedMethod) || !cfg.areHeadersAllowed(requestedHeaders) {
+					log.Printf("CORS: preflight from %s rejected (method: %s, headers: %s)",
+						origin, requestedMethod, requestedHeaders)
+					return c.NoContent(http.StatusForbidden)
+				}
+
+				header.Set(echo.HeaderAccessControlAllowMethods, strings.Join(cfg.AllowMethods, ", "))
+				header.Set(echo.HeaderAccessControlAllowHeaders, strings.Join(cfg.AllowHeaders, ", "))
+				header.Set(echo.HeaderAccessControlMaxAge, strconv.Itoa(int(cfg.MaxAge.Seconds())))
+
+				// Preflight requests never reach the route handlers
+				return c.NoContent(http.StatusNoContent)
+			}
+
+			if len(cfg.ExposeHeaders) > 0 {
+				header.Set(echo.HeaderAccessControlExposeHeaders, strings.Join(cfg.ExposeHeaders, ", "))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// Message represents a message posted through the API
+type Message struct {
+	ID        int       `json:"id"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MessageStore manages the messages
+type MessageStore struct {
+	messages []Message
+	nextID   int
+	mu       sync.Mutex
+}
+
+// NewMessageStore creates a new store with initial data
+func NewMessageStore() *MessageStore {
+	return &MessageStore{
+		messages: []Message{
+			{ID: 1, Text: "Welcome to the CORS playground", CreatedAt: time.Now()},
+		},
+		nextID: 2,
+	}
+}
+
+//go:embed cors.html
+var htmlCORSPage string
+
+// servePage serves the test page on a separate origin
+func servePage(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(echo.HeaderContentType, echo.MIMETextHTML)
+		w.Write([]byte(htmlCORSPage))
+	})
+
+	log.Printf("Serving CORS test page on http://localhost%s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func main() {
+	cfg, err := NewCORSConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	store := NewMessageStore()
+
+	// The same page served from an allowed and a blocked origin
+	go servePage(":3000")
+	go servePage(":4000")
+
+	// Create Echo instance
+	e := echo.New()
+
+	e.Use(middleware.Logger())
+
+	// Register the CORS middleware with Pre so it also runs for unmatched OPTIONS routes
+	e.Pre(CORS(cfg))
+
+	api := e.Group("/api")
+
+	// GET /api/messages - Get all messages
+	api.GET("/messages", func(c echo.Context) error {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+
+		c.Response().Header().Set("X-Total-Count", strconv.Itoa(len(store.messages)))
+		return c.JSON(http.StatusOK, store.messages)
+	})
+
+	// POST /api/messages - Create a new message
+	api.POST("/messages", func(c echo.Context) error {
+		var message Message
+		if err := c.Bind(&message); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if message.Text == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "text is required")
+		}
+
+		store.mu.Lock()
+		defer store.mu.Unlock()
+
+		message.ID = store.nextID
+		store.nextID++
+		message.CreatedAt = time.Now()
+		store.messages = append(store.messages, message)
+
+		return c.JSON(http.StatusCreated, message)
+	})
+
+	// DELETE /api/messages/:id - Blocked for browsers by the default policy
+	api.DELETE("/messages/:id", func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid message ID")
+		}
+
+		store.mu.Lock()
+		defer store.mu.Unlock()
+
+		for i, message := range store.messages {
+			if message.ID == id {
+				store.messages = append(store.messages[:i], store.messages[i+1:]...)
+				return c.NoContent(http.StatusNoContent)
+			}
+		}
+
+		return echo.NewHTTPError(http.StatusNotFound, "Message not found")
+	})
+
+	// POST /api/login - Set a cookie that is only sent back with credentials
+	api.POST("/login", func(c echo.Context) error {
+		c.SetCookie(&http.Cookie{
+			Name:     "demo_session",
+			Value:    "gopher",
+			Path:     "/",
+			MaxAge:   3600,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		return c.JSON(http.StatusOK, map[string]string{"message": "Logged in as gopher"})
+	})
+
+	// GET /api/whoami - Read the cookie set by /api/login
+	api.GET("/whoami", func(c echo.Context) error {
+		cookie, err := c.Cookie("demo_session")
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, "No session cookie received")
+		}
+		return c.JSON(http.StatusOK, map[string]string{"user": cookie.Value})
+	})
+
+	// Start server
+	log.Printf("Starting API server on :8080 (allowed origins: %v, credentials: %t)", cfg.AllowOrigins, cfg.AllowCredentials)
+	if err := e.Start(":8080"); err != nil {
+		log.Fatal(err)
+	}
+}
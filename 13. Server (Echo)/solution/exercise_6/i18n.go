@@ -0,0 +1,180 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// The translations are compiled into the binary: one JSON file per locale,
+// named after its BCP 47 tag, such as fr.json or pt-BR.json
+//
+//go:embed locales/*.json
+var locales embed.FS
+
+// fallback is the locale used when none of the Accept-Language header is
+// available, and for the messages missing from a locale
+var fallback = language.English
+
+// pluralMessage is a message whose text depends on a number. The keys of
+// cases are the plural categories of CLDR (zero, one, two, few, many,
+// other) or exact values such as "=0".
+type pluralMessage struct {
+	Arg   int               `json:"arg"` // Position of the number in the arguments, from 1
+	Cases map[string]string `json:"cases"`
+}
+
+// Translator holds the catalog and chooses a locale for each request
+type Translator struct {
+	catalog *catalog.Builder
+	tags    []language.Tag // The available locales, the fallback first
+	matcher language.Matcher
+}
+
+// NewTranslator loads every locale of fsys. A message is either a string, a
+// printf format, or a pluralMessage object.
+func NewTranslator(fsys fs.FS) (*Translator, error) {
+	builder := catalog.NewBuilder(catalog.Fallback(fallback))
+	files, err := fs.Glob(fsys, "locales/*.json")
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[language.Tag]map[string]json.RawMessage)
+	for _, file := range files {
+		tag, err := language.Parse(strings.TrimSuffix(path.Base(file), ".json"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		var messages map[string]json.RawMessage
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		for key, raw := range messages {
+			if err := setMessage(builder, tag, key, raw); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", file, key, err)
+			}
+		}
+		all[tag] = messages
+	}
+
+	// A message missing from a locale would be printed as its key: use the
+	// text of the fallback instead, until a translator adds it
+	for tag, messages := range all {
+		for key, raw := range all[fallback] {
+			if _, ok := messages[key]; !ok {
+				if err := setMessage(builder, tag, key, raw); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	// The matcher prefers the first tag of the list when nothing matches:
+	// put the fallback first
+	tags := builder.Languages()
+	slices.SortFunc(tags, func(a, b language.Tag) int {
+		switch {
+		case a == fallback:
+			return -1
+		case b == fallback:
+			return 1
+		}
+		return strings.Compare(a.String(), b.String())
+	})
+	if len(tags) == 0 || tags[0] != fallback {
+		return nil, fmt.Errorf("no messages for the fallback locale %s", fallback)
+	}
+	return &Translator{catalog: builder, tags: tags, matcher: language.NewMatcher(tags)}, nil
+}
+
+func setMessage(builder *catalog.Builder, tag language.Tag, key string, raw json.RawMessage) error {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return builder.SetString(tag, key, text)
+	}
+	var msg pluralMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return err
+	}
+	if msg.Arg < 1 || len(msg.Cases) == 0 {
+		return fmt.Errorf("a plural message needs arg and cases")
+	}
+	// Selectf takes the cases as selector, message pairs. Exact values
+	// must come before the categories, as the first matching case wins.
+	var cases []any
+	for _, selector := range slices.Sorted(maps.Keys(msg.Cases)) {
+		cases = append(cases, selector, msg.Cases[selector])
+	}
+	return builder.Set(tag, key, plural.Selectf(msg.Arg, "%d", cases...))
+}
+
+// Locales returns the available locales, the fallback first
+func (t *Translator) Locales() []language.Tag {
+	return slices.Clone(t.tags)
+}
+
+// Printer returns the printer of the best locale for an Accept-Language
+// header, such as "fr-CH, fr;q=0.9, en;q=0.8"
+func (t *Translator) Printer(acceptLanguage string) (*message.Printer, language.Tag) {
+	// An invalid header gives no preference: the fallback is chosen
+	preferred, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	// Match returns the tag of the request with the matched locale added as
+	// an extension, such as fr-u-rg-chzzzz for fr-CH: the index gives the
+	// locale of the catalog itself
+	_, index, _ := t.matcher.Match(preferred...)
+	tag := t.tags[index]
+	return message.NewPrinter(tag, message.Catalog(t.catalog)), tag
+}
+
+// printerKey is the echo.Context key of the printer
+const printerKey = "printer"
+
+// Localize chooses the locale of each request from the lang query parameter,
+// for links and tests, or from the Accept-Language header. Handlers get the
+// printer with T.
+func Localize(t *Translator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			accept := c.Request().Header.Get("Accept-Language")
+			if lang := c.QueryParam("lang"); lang != "" {
+				accept = lang
+			}
+			printer, tag := t.Printer(accept)
+			c.Set(printerKey, printer)
+			c.Response().Header().Set("Content-Language", tag.String())
+			// The response depends on the header: caches must keep one copy
+			// per language
+			c.Response().Header().Add("Vary", "Accept-Language")
+			return next(c)
+		}
+	}
+}
+
+// T translates a message for the locale of the request
+func T(c echo.Context, key string, args ...any) string {
+	printer, ok := c.Get(printerKey).(*message.Printer)
+	if !ok {
+		printer = message.NewPrinter(fallback)
+	}
+	return printer.Sprintf(key, args...)
+}
+
+// LocalizedError returns an HTTP error with a translated message
+func LocalizedError(c echo.Context, status int, key string, args ...any) error {
+	return echo.NewHTTPError(status, T(c, key, args...))
+}
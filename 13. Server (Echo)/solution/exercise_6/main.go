@@ -0,0 +1,226 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxTitleLength is the longest title accepted, in characters
+const maxTitleLength = 80
+
+// Todo represents a todo item
+type Todo struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Completed bool      `json:"completed"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TodoStore manages the todo items
+type TodoStore struct {
+	mu     sync.Mutex
+	todos  []Todo
+	nextID int
+}
+
+func NewTodoStore() *TodoStore {
+	return &TodoStore{
+		todos: []Todo{
+			{ID: 1, Title: "Learn Echo Framework", Completed: true, CreatedAt: time.Now()},
+			{ID: 2, Title: "Translate the API", CreatedAt: time.Now()},
+		},
+		nextID: 3,
+	}
+}
+
+// parseID reads the id parameter, or returns a translated 400 error
+func parseID(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, LocalizedError(c, http.StatusBadRequest, "todo.invalid_id", c.Param("id"))
+	}
+	return id, nil
+}
+
+func newServer(translator *Translator, store *TodoStore) *echo.Echo {
+	e := echo.New()
+	e.HideBanner = true
+	e.Use(Localize(translator))
+
+	e.GET("/", func(c echo.Context) error {
+		locales := make([]string, 0)
+		for _, tag := range translator.Locales() {
+			locales = append(locales, tag.String())
+		}
+		return c.JSON(http.StatusOK, map[string]any{
+			"message": T(c, "welcome"),
+			"locale":  c.Response().Header().Get("Content-Language"),
+			"locales": locales,
+		})
+	})
+
+	api := e.Group("/api/v1")
+
+	api.GET("/todos", func(c echo.Context) error {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		remaining := 0
+		for _, todo := range store.todos {
+			if !todo.Completed {
+				remaining++
+			}
+		}
+		return c.JSON(http.StatusOK, map[string]any{
+			"summary":   T(c, "todos.count", len(store.todos)),
+			"remaining": T(c, "todos.remaining", remaining),
+			"todos":     store.todos,
+		})
+	})
+
+	api.GET("/todos/:id", func(c echo.Context) error {
+		id, err := parseID(c)
+		if err != nil {
+			return err
+		}
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		for _, todo := range store.todos {
+			if todo.ID == id {
+				return c.JSON(http.StatusOK, todo)
+			}
+		}
+		return LocalizedError(c, http.StatusNotFound, "todo.not_found", id)
+	})
+
+	api.POST("/todos", func(c echo.Context) error {
+		var todo Todo
+		if err := c.Bind(&todo); err != nil {
+			return err
+		}
+		todo.Title = strings.TrimSpace(todo.Title)
+		switch {
+		case todo.Title == "":
+			return LocalizedError(c, http.StatusBadRequest, "todo.title_required")
+		case utf8.RuneCountInString(todo.Title) > maxTitleLength:
+			return LocalizedError(c, http.StatusBadRequest, "todo.title_too_long", maxTitleLength)
+		}
+
+		store.mu.Lock()
+		todo.ID = store.nextID
+		store.nextID++
+		todo.CreatedAt = time.Now()
+		store.todos = append(store.todos, todo)
+		store.mu.Unlock()
+
+		return c.JSON(http.StatusCreated, map[string]any{
+			"message": T(c, "todo.created", todo.Title),
+			"todo":    todo,
+		})
+	})
+
+	api.DELETE("/todos/:id", func(c echo.Context) error {
+		id, err := parseID(c)
+		if err != nil {
+			return err
+		}
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		for i, todo := range store.todos {
+			if todo.ID == id {
+				store.todos = append(store.todos[:i], store.todos[i+1:]...)
+				return c.JSON(http.StatusOK, map[string]string{"message": T(c, "todo.deleted", id)})
+			}
+		}
+		return LocalizedError(c, http.StatusNotFound, "todo.not_found", id)
+	})
+
+	// The printer also formats the numbers for the locale: 1,234.5 in
+	// English, 1 234,5 in French
+	api.GET("/stats", func(c echo.Context) error {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		completed := 0
+		for _, todo := range store.todos {
+			if todo.Completed {
+				completed++
+			}
+		}
+		percent := 0.0
+		if len(store.todos) > 0 {
+			percent = 100 * float64(completed) / float64(len(store.todos))
+		}
+		return c.JSON(http.StatusOK, map[string]string{
+			"completion": T(c, "stats.completion", percent, len(store.todos)),
+		})
+	})
+
+	return e
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	demo := flag.Bool("demo", false, "send requests in several languages and print the answers")
+	flag.Parse()
+
+	translator, err := NewTranslator(locales)
+	if err != nil {
+		log.Fatalf("Failed to load the translations: %v", err)
+	}
+	e := newServer(translator, NewTodoStore())
+	if *demo {
+		runDemo(e)
+		return
+	}
+
+	log.Printf("Starting server on %s, try: curl -H 'Accept-Language: fr' http://localhost%s/api/v1/todos", *addr, *addr)
+	if err := e.Start(*addr); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runDemo(e *echo.Echo) {
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	requests := []struct {
+		method, path, language, body string
+	}{
+		{"GET", "/", "en-US,en;q=0.9", ""},
+		{"GET", "/", "fr-CH, fr;q=0.9, en;q=0.8", ""},
+		{"GET", "/", "ja", ""}, // Not available: English
+		{"GET", "/api/v1/todos", "fr", ""},
+		{"POST", "/api/v1/todos", "fr", `{"title": "Écrire la documentation"}`},
+		{"POST", "/api/v1/todos", "de", `{"title": "  "}`},
+		{"GET", "/api/v1/todos", "de", ""}, // todos.remaining is missing in German: English
+		{"GET", "/api/v1/todos/42", "de-AT", ""},
+		{"GET", "/api/v1/todos/abc", "fr", ""},
+		{"DELETE", "/api/v1/todos/1", "en", ""},
+		{"GET", "/api/v1/todos?lang=en", "fr", ""}, // The query parameter wins
+		{"GET", "/api/v1/stats", "fr", ""},
+	}
+	for _, r := range requests {
+		req, _ := http.NewRequest(r.method, server.URL+r.path, strings.NewReader(r.body))
+		req.Header.Set("Accept-Language", r.language)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Printf("%s %s [Accept-Language: %s] -> %s, Content-Language: %s\n  %s\n",
+			r.method, r.path, r.language, resp.Status, resp.Header.Get("Content-Language"), strings.TrimSpace(string(body)))
+	}
+}
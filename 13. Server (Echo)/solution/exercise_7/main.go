@@ -0,0 +1,203 @@
+package main
+
+import (
+	"embed"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// The app is compiled into the binary: the server is a single file to
+// deploy, and the API and the app can't be out of sync
+//
+//go:embed web
+var web embed.FS
+
+// Todo represents a todo item
+type Todo struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Completed bool      `json:"completed"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TodoStore manages the todo items
+type TodoStore struct {
+	mu     sync.Mutex
+	todos  []Todo
+	nextID int
+}
+
+func NewTodoStore() *TodoStore {
+	return &TodoStore{
+		todos: []Todo{
+			{ID: 1, Title: "Learn Echo Framework", Completed: true, CreatedAt: time.Now()},
+			{ID: 2, Title: "Embed a single-page app", CreatedAt: time.Now()},
+		},
+		nextID: 3,
+	}
+}
+
+func (s *TodoStore) find(id int) int {
+	for i, todo := range s.todos {
+		if todo.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func registerAPI(api *echo.Group, store *TodoStore) {
+	api.GET("/todos", func(c echo.Context) error {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		return c.JSON(http.StatusOK, store.todos)
+	})
+
+	api.GET("/todos/:id", func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid todo ID")
+		}
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		i := store.find(id)
+		if i < 0 {
+			return echo.NewHTTPError(http.StatusNotFound, "Todo not found")
+		}
+		return c.JSON(http.StatusOK, store.todos[i])
+	})
+
+	api.POST("/todos", func(c echo.Context) error {
+		var todo Todo
+		if err := c.Bind(&todo); err != nil {
+			return err
+		}
+		if strings.TrimSpace(todo.Title) == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Title is required")
+		}
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		todo.ID = store.nextID
+		store.nextID++
+		todo.CreatedAt = time.Now()
+		store.todos = append(store.todos, todo)
+		return c.JSON(http.StatusCreated, todo)
+	})
+
+	api.PUT("/todos/:id", func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid todo ID")
+		}
+		var update Todo
+		if err := c.Bind(&update); err != nil {
+			return err
+		}
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		i := store.find(id)
+		if i < 0 {
+			return echo.NewHTTPError(http.StatusNotFound, "Todo not found")
+		}
+		store.todos[i].Title = update.Title
+		store.todos[i].Completed = update.Completed
+		return c.JSON(http.StatusOK, store.todos[i])
+	})
+}
+
+func newServer(files fs.FS) (*echo.Echo, error) {
+	spa, err := NewSPA(files)
+	if err != nil {
+		return nil, err
+	}
+
+	e := echo.New()
+	e.HideBanner = true
+	e.Use(middleware.Recover())
+
+	// The API and the app share the origin: no CORS needed
+	registerAPI(e.Group("/api/v1"), NewTodoStore())
+	// Unknown API routes answer JSON, never the index.html of the app
+	e.Any("/api/*", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound, "No such API route")
+	})
+
+	// Everything else is the app. Echo tries the static routes, then the
+	// parameters, then the wildcards: /api/v1/todos wins over /*.
+	e.GET("/*", spa.Handler())
+	e.HEAD("/*", spa.Handler())
+	return e, nil
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	demo := flag.Bool("demo", false, "print the answers and cache headers of sample requests")
+	flag.Parse()
+
+	files, err := fs.Sub(web, "web")
+	if err != nil {
+		log.Fatal(err)
+	}
+	e, err := newServer(files)
+	if err != nil {
+		log.Fatalf("Failed to load the app: %v", err)
+	}
+	if *demo {
+		runDemo(e)
+		return
+	}
+
+	log.Printf("Open http://localhost%s", *addr)
+	if err := e.Start(*addr); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runDemo(e *echo.Echo) {
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	get := func(path string, header http.Header) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		firstLine, _, _ := strings.Cut(strings.TrimSpace(string(body)), "\n")
+		fmt.Printf("GET %-26s %d  %-32s %-36s %.40s\n", path, resp.StatusCode,
+			resp.Header.Get("Content-Type"), resp.Header.Get("Cache-Control"), firstLine)
+		return resp
+	}
+
+	index := get("/", nil)
+	get("/", http.Header{"If-None-Match": {index.Header.Get("ETag")}})
+	get("/todos/2", nil)
+	get("/about", nil)
+	assets, _ := fs.ReadDir(web, "web/assets")
+	for _, asset := range assets {
+		get("/assets/"+asset.Name(), nil)
+	}
+	get("/assets/app.00000000.js", nil)
+	get("/robots.txt", nil)
+	get("/logo.png", nil)
+	get("/api/v1/todos", nil)
+	get("/api/v1/todos/9", nil)
+	get("/api/v2/todos", nil)
+}
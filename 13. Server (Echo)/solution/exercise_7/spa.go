@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Cache-Control values. The bundler puts the hash of their content in the
+// names of the assets, app.bd462e48.js: a new version gets a new name, so
+// the browser keeps them for a year. index.html keeps its name and links the
+// assets of the current version: it is revalidated on every visit.
+const (
+	cacheImmutable  = "public, max-age=31536000, immutable"
+	cacheRevalidate = "no-cache"
+	cacheShort      = "public, max-age=3600" // robots.txt, favicon.ico
+)
+
+// SPA serves a single-page application from a file system, such as the
+// files embedded in the binary
+type SPA struct {
+	files fs.FS
+	index []byte
+	etag  string
+}
+
+func NewSPA(files fs.FS) (*SPA, error) {
+	index, err := fs.ReadFile(files, "index.html")
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(index)
+	return &SPA{files: files, index: index, etag: `"` + hex.EncodeToString(sum[:8]) + `"`}, nil
+}
+
+// serveFile serves a file with a strong ETag, as embedded files have no
+// modification time for If-Modified-Since
+func serveFile(c echo.Context, name string, content []byte, cacheControl string) error {
+	sum := sha256.Sum256(content)
+	header := c.Response().Header()
+	header.Set("Cache-Control", cacheControl)
+	header.Set("ETag", `"`+hex.EncodeToString(sum[:8])+`"`)
+	// ServeContent answers If-None-Match with 304 and sets the Content-Type
+	// from the extension
+	http.ServeContent(c.Response(), c.Request(), name, time.Time{}, bytes.NewReader(content))
+	return nil
+}
+
+// Handler serves the files, and index.html for the routes of the app:
+//
+//	/assets/app.bd462e48.js   the file, cached for a year
+//	/assets/missing.js        404: a missing script must not get HTML
+//	/robots.txt               the file, cached for an hour
+//	/todos/2, /about          index.html: the app reads the URL and shows
+//	                          the view, after a reload or from a shared link
+//	/missing.png              404, a path with an extension is a file
+func (s *SPA) Handler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		urlPath := c.Request().URL.Path
+		name := strings.TrimPrefix(path.Clean(urlPath), "/")
+		if name == "" || name == "index.html" {
+			return serveFile(c, "index.html", s.index, cacheRevalidate)
+		}
+
+		content, err := fs.ReadFile(s.files, name)
+		switch {
+		case err == nil && strings.HasPrefix(name, "assets/"):
+			return serveFile(c, name, content, cacheImmutable)
+		case err == nil:
+			return serveFile(c, name, content, cacheShort)
+		case !errors.Is(err, fs.ErrNotExist):
+			return err
+		case strings.HasPrefix(name, "assets/") || path.Ext(name) != "":
+			return echo.ErrNotFound
+		}
+
+		// History API fallback: the route belongs to the app
+		return serveFile(c, "index.html", s.index, cacheRevalidate)
+	}
+}
@@ -0,0 +1,96 @@
+// A single-page app without framework: the History API changes the URL
+// without loading a page, and render draws the view of the current URL.
+const app = document.getElementById("app");
+
+async function api(path, options) {
+  const resp = await fetch("/api/v1" + path, options);
+  if (!resp.ok) {
+    throw new Error((await resp.json()).message || resp.statusText);
+  }
+  return resp.status === 204 ? null : resp.json();
+}
+
+function escape(text) {
+  const div = document.createElement("div");
+  div.textContent = text;
+  return div.innerHTML;
+}
+
+// Links with data-link navigate inside the app
+function navigate(path) {
+  history.pushState(null, "", path);
+  render();
+}
+
+document.addEventListener("click", (event) => {
+  const link = event.target.closest("a[data-link]");
+  if (link) {
+    event.preventDefault();
+    navigate(link.getAttribute("href"));
+  }
+});
+
+// The back and forward buttons
+window.addEventListener("popstate", render);
+
+async function listView() {
+  const todos = await api("/todos");
+  app.innerHTML = `
+    <h1>Todos</h1>
+    <form id="new-todo"><input name="title" placeholder="What needs to be done?" required> <button>Add</button></form>
+    <ul>${todos.map((t) => `<li class="${t.completed ? "done" : ""}"><a href="/todos/${t.id}" data-link>${escape(t.title)}</a></li>`).join("")}</ul>`;
+  document.getElementById("new-todo").addEventListener("submit", async (event) => {
+    event.preventDefault();
+    const title = new FormData(event.target).get("title");
+    await api("/todos", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ title }),
+    });
+    render();
+  });
+}
+
+async function detailView(id) {
+  const todo = await api("/todos/" + id);
+  app.innerHTML = `
+    <h1>${escape(todo.title)}</h1>
+    <p>${todo.completed ? "Done" : "To do"}, created ${new Date(todo.created_at).toLocaleString()}</p>
+    <button id="toggle">${todo.completed ? "Reopen" : "Complete"}</button>
+    <p><a href="/" data-link>Back to the list</a></p>`;
+  document.getElementById("toggle").addEventListener("click", async () => {
+    await api("/todos/" + id, {
+      method: "PUT",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ title: todo.title, completed: !todo.completed }),
+    });
+    render();
+  });
+}
+
+function aboutView() {
+  app.innerHTML = `
+    <h1>About</h1>
+    <p>Reload this page: the server answers /about with index.html, and the app shows this view again.</p>
+    <p><a href="/" data-link>Back to the list</a></p>`;
+}
+
+async function render() {
+  const path = location.pathname;
+  const detail = path.match(/^\/todos\/(\d+)$/);
+  try {
+    if (path === "/") {
+      await listView();
+    } else if (detail) {
+      await detailView(detail[1]);
+    } else if (path === "/about") {
+      aboutView();
+    } else {
+      app.innerHTML = `<h1>Page not found</h1><p><a href="/" data-link>Back to the list</a></p>`;
+    }
+  } catch (err) {
+    app.innerHTML = `<h1>Error</h1><p>${escape(err.message)}</p><p><a href="/" data-link>Back to the list</a></p>`;
+  }
+}
+
+render();
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// script is a session of requests covering the success and error cases of
+// every route
+var script = []struct {
+	method, path, body string
+}{
+	{"GET", "/api/v1/todos", ""},
+	{"GET", "/api/v1/todos/2", ""},
+	{"GET", "/api/v1/todos/42", ""},
+	{"GET", "/api/v1/todos/abc", ""},
+	{"POST", "/api/v1/todos", `{"title": "  Compare the frameworks  "}`},
+	{"POST", "/api/v1/todos", `{"title": ""}`},
+	{"POST", "/api/v1/todos", `{"title": `},
+	{"PUT", "/api/v1/todos/4", `{"title": "Compare the frameworks", "completed": true}`},
+	{"PUT", "/api/v1/todos/42", `{"title": "Missing"}`},
+	{"PUT", "/api/v1/todos/4", `not json`},
+	{"DELETE", "/api/v1/todos/1", ""},
+	{"DELETE", "/api/v1/todos/1", ""},
+	{"GET", "/api/v1/todos", ""},
+}
+
+type answer struct {
+	Status int
+	Body   any
+}
+
+// play runs the script against a handler. The timestamps differ from a
+// run to the next: they are removed from the bodies.
+func play(t *testing.T, handler http.Handler) []answer {
+	t.Helper()
+	answers := make([]answer, len(script))
+	for i, step := range script {
+		req := httptest.NewRequest(step.method, step.path, strings.NewReader(step.body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		answers[i].Status = rec.Code
+		if rec.Body.Len() > 0 {
+			if err := json.Unmarshal(rec.Body.Bytes(), &answers[i].Body); err != nil {
+				t.Fatalf("%s %s: invalid JSON %q: %v", step.method, step.path, rec.Body, err)
+			}
+			removeTimes(answers[i].Body)
+		}
+	}
+	return answers
+}
+
+func removeTimes(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		delete(v, "created_at")
+		delete(v, "updated_at")
+	case []any:
+		for _, item := range v {
+			removeTimes(item)
+		}
+	}
+}
+
+func TestAdaptersAnswerAlike(t *testing.T) {
+	want := play(t, adapters[0].new(seed()))
+	for _, a := range adapters[1:] {
+		t.Run(a.name, func(t *testing.T) {
+			got := play(t, a.new(seed()))
+			for i, step := range script {
+				if !reflect.DeepEqual(got[i], want[i]) {
+					t.Errorf("%s %s %s\n %s: %+v\n %s: %+v", step.method, step.path, step.body,
+						a.name, got[i], adapters[0].name, want[i])
+				}
+			}
+		})
+	}
+}
+
+// The benchmarks call the handlers directly, without network: they show
+// the cost of each framework alone. go run . -load compares them over
+// TCP connections.
+//
+//	go test -bench . -benchmem
+func BenchmarkGetTodo(b *testing.B) {
+	for _, a := range adapters {
+		b.Run(a.name, func(b *testing.B) {
+			handler := a.new(seed())
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/todos/2", nil)
+			for b.Loop() {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				if rec.Code != http.StatusOK {
+					b.Fatalf("status %d", rec.Code)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCreateTodo(b *testing.B) {
+	for _, a := range adapters {
+		b.Run(a.name, func(b *testing.B) {
+			handler := a.new(seed())
+			for b.Loop() {
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/todos", strings.NewReader(`{"title": "Benchmark"}`))
+				req.Header.Set("Content-Type", "application/json")
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				if rec.Code != http.StatusCreated {
+					b.Fatalf("status %d", rec.Code)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+// Package api is the HTTP contract shared by the adapters: the routes, the
+// request and error bodies, and the status code of each error of the todo
+// service. Every framework must answer a request with the same status and
+// the same JSON.
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"golang-training/module-13/exercise-8/todo"
+)
+
+// Routes served by every adapter, in the syntax of Gin and Echo. The
+// standard library writes {id} instead of :id.
+const (
+	TodosPath = "/api/v1/todos"
+	TodoPath  = "/api/v1/todos/:id"
+)
+
+// Messages of the errors found by the adapters, before the service is called
+var (
+	MsgInvalidID   = "invalid todo ID"
+	MsgInvalidJSON = "invalid JSON body"
+)
+
+// CreateRequest is the body of POST /api/v1/todos
+type CreateRequest struct {
+	Title string `json:"title"`
+}
+
+// UpdateRequest is the body of PUT /api/v1/todos/:id
+type UpdateRequest struct {
+	Title     string `json:"title"`
+	Completed bool   `json:"completed"`
+}
+
+// Error is the body of every error response
+type Error struct {
+	Error string `json:"error"`
+}
+
+// ErrorStatus returns the status code and the body answering an error of
+// the service
+func ErrorStatus(err error) (int, Error) {
+	switch {
+	case errors.Is(err, todo.ErrNotFound):
+		return http.StatusNotFound, Error{err.Error()}
+	case errors.Is(err, todo.ErrInvalid):
+		return http.StatusBadRequest, Error{err.Error()}
+	}
+	// The details of an unexpected error stay in the server
+	return http.StatusInternalServerError, Error{"internal error"}
+}
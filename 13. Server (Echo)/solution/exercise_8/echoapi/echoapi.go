@@ -0,0 +1,100 @@
+// Package echoapi serves the todo service with Echo
+package echoapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"golang-training/module-13/exercise-8/api"
+	"golang-training/module-13/exercise-8/todo"
+)
+
+// New returns an Echo server serving the service, without middleware
+func New(service *todo.Service) http.Handler {
+	e := echo.New()
+	e.HideBanner = true
+	h := handler{service}
+	e.GET(api.TodosPath, h.list)
+	e.POST(api.TodosPath, h.create)
+	e.GET(api.TodoPath, h.get)
+	e.PUT(api.TodoPath, h.update)
+	e.DELETE(api.TodoPath, h.delete)
+	return e
+}
+
+type handler struct {
+	service *todo.Service
+}
+
+func fail(c echo.Context, err error) error {
+	status, body := api.ErrorStatus(err)
+	return c.JSON(status, body)
+}
+
+func badRequest(c echo.Context, message string) error {
+	return c.JSON(http.StatusBadRequest, api.Error{Error: message})
+}
+
+// bindJSON decodes the body only. c.Bind would also read the path
+// parameters and the query string into the request.
+func bindJSON(c echo.Context, v any) bool {
+	return json.NewDecoder(c.Request().Body).Decode(v) == nil
+}
+
+func (h handler) list(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.service.List(c.Request().Context()))
+}
+
+func (h handler) get(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return badRequest(c, api.MsgInvalidID)
+	}
+	t, err := h.service.Get(c.Request().Context(), id)
+	if err != nil {
+		return fail(c, err)
+	}
+	return c.JSON(http.StatusOK, t)
+}
+
+func (h handler) create(c echo.Context) error {
+	var req api.CreateRequest
+	if !bindJSON(c, &req) {
+		return badRequest(c, api.MsgInvalidJSON)
+	}
+	t, err := h.service.Create(c.Request().Context(), req.Title)
+	if err != nil {
+		return fail(c, err)
+	}
+	return c.JSON(http.StatusCreated, t)
+}
+
+func (h handler) update(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return badRequest(c, api.MsgInvalidID)
+	}
+	var req api.UpdateRequest
+	if !bindJSON(c, &req) {
+		return badRequest(c, api.MsgInvalidJSON)
+	}
+	t, err := h.service.Update(c.Request().Context(), id, req.Title, req.Completed)
+	if err != nil {
+		return fail(c, err)
+	}
+	return c.JSON(http.StatusOK, t)
+}
+
+func (h handler) delete(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return badRequest(c, api.MsgInvalidID)
+	}
+	if err := h.service.Delete(c.Request().Context(), id); err != nil {
+		return fail(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,106 @@
+// Package ginapi serves the todo service with Gin
+package ginapi
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"golang-training/module-13/exercise-8/api"
+	"golang-training/module-13/exercise-8/todo"
+)
+
+// New returns a Gin engine serving the service. It has no logger nor
+// recovery middleware, as the other adapters: the comparison measures the
+// routing, binding and rendering of each framework only.
+func New(service *todo.Service) http.Handler {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	h := handler{service}
+	r.GET(api.TodosPath, h.list)
+	r.POST(api.TodosPath, h.create)
+	r.GET(api.TodoPath, h.get)
+	r.PUT(api.TodoPath, h.update)
+	r.DELETE(api.TodoPath, h.delete)
+	return r
+}
+
+type handler struct {
+	service *todo.Service
+}
+
+func fail(c *gin.Context, err error) {
+	status, body := api.ErrorStatus(err)
+	c.JSON(status, body)
+}
+
+func todoID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, api.Error{Error: api.MsgInvalidID})
+		return 0, false
+	}
+	return id, true
+}
+
+func (h handler) list(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.List(c.Request.Context()))
+}
+
+func (h handler) get(c *gin.Context) {
+	id, ok := todoID(c)
+	if !ok {
+		return
+	}
+	t, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+func (h handler) create(c *gin.Context) {
+	var req api.CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, api.Error{Error: api.MsgInvalidJSON})
+		return
+	}
+	t, err := h.service.Create(c.Request.Context(), req.Title)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, t)
+}
+
+func (h handler) update(c *gin.Context) {
+	id, ok := todoID(c)
+	if !ok {
+		return
+	}
+	var req api.UpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, api.Error{Error: api.MsgInvalidJSON})
+		return
+	}
+	t, err := h.service.Update(c.Request.Context(), id, req.Title, req.Completed)
+	if err != nil {
+		fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+func (h handler) delete(c *gin.Context) {
+	id, ok := todoID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		fail(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
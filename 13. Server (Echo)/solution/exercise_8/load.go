@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"golang-training/module-13/exercise-8/api"
+)
+
+// LoadResult is the outcome of the load test of one framework
+type LoadResult struct {
+	Name     string
+	Requests int
+	Errors   int
+	Duration time.Duration
+	P50, P99 time.Duration
+}
+
+func (r LoadResult) Throughput() float64 {
+	return float64(r.Requests) / r.Duration.Seconds()
+}
+
+// RunLoad sends requests to the handler from concurrency clients for the
+// given duration. Nine requests out of ten read a todo, the tenth creates
+// one: the servers route, parse the body and render JSON.
+func RunLoad(name string, handler http.Handler, duration time.Duration, concurrency int) LoadResult {
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	// Keep a connection per client, as a browser or a proxy would, instead
+	// of measuring the TCP handshakes
+	client := &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: concurrency}}
+	defer client.CloseIdleConnections()
+
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		errors    atomic.Int64
+		wg        sync.WaitGroup
+	)
+	deadline := time.Now().Add(duration)
+	start := time.Now()
+	for worker := range concurrency {
+		wg.Go(func() {
+			var mine []time.Duration
+			for i := 0; time.Now().Before(deadline); i++ {
+				req, want := nextRequest(server.URL, worker, i)
+				sent := time.Now()
+				resp, err := client.Do(req)
+				if err != nil {
+					errors.Add(1)
+					continue
+				}
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+				mine = append(mine, time.Since(sent))
+				if resp.StatusCode != want {
+					errors.Add(1)
+				}
+			}
+			mu.Lock()
+			latencies = append(latencies, mine...)
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+
+	result := LoadResult{Name: name, Requests: len(latencies), Errors: int(errors.Load()), Duration: time.Since(start)}
+	if len(latencies) > 0 {
+		slices.Sort(latencies)
+		result.P50 = latencies[len(latencies)*50/100]
+		result.P99 = latencies[len(latencies)*99/100]
+	}
+	return result
+}
+
+// nextRequest returns the i-th request of a client and its expected status
+func nextRequest(baseURL string, worker, i int) (*http.Request, int) {
+	if i%10 == 9 {
+		body := fmt.Sprintf(`{"title": "Load test %d-%d"}`, worker, i)
+		req, _ := http.NewRequest(http.MethodPost, baseURL+api.TodosPath, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		return req, http.StatusCreated
+	}
+	// The seed has three todos
+	req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("%s%s/%d", baseURL, api.TodosPath, i%3+1), nil)
+	return req, http.StatusOK
+}
+
+// PrintResults prints the results as a table, relative to the first one
+func PrintResults(w io.Writer, results []LoadResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "framework\trequests\terrors\treq/s\tp50\tp99\tvs "+results[0].Name+"\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.0f\t%v\t%v\t%.2fx\t\n", r.Name, r.Requests, r.Errors, r.Throughput(),
+			r.P50.Round(time.Microsecond), r.P99.Round(time.Microsecond), r.Throughput()/results[0].Throughput())
+	}
+	tw.Flush()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"golang-training/module-13/exercise-8/echoapi"
+	"golang-training/module-13/exercise-8/ginapi"
+	"golang-training/module-13/exercise-8/stdapi"
+	"golang-training/module-13/exercise-8/todo"
+)
+
+// adapter builds the HTTP server of a framework around the service. The
+// service, its rules and its errors are the same for all of them.
+type adapter struct {
+	name string
+	new  func(*todo.Service) http.Handler
+}
+
+var adapters = []adapter{
+	{"stdlib", stdapi.New},
+	{"gin", ginapi.New},
+	{"echo", echoapi.New},
+}
+
+func findAdapter(name string) (adapter, bool) {
+	for _, a := range adapters {
+		if a.name == name {
+			return a, true
+		}
+	}
+	return adapter{}, false
+}
+
+func adapterNames() string {
+	names := make([]string, len(adapters))
+	for i, a := range adapters {
+		names[i] = a.name
+	}
+	return strings.Join(names, ", ")
+}
+
+func main() {
+	framework := flag.String("framework", "echo", "framework serving the API: "+adapterNames())
+	addr := flag.String("addr", ":8080", "address to listen on")
+	load := flag.Bool("load", false, "load test every framework and compare their throughput")
+	duration := flag.Duration("duration", 3*time.Second, "duration of the load test of each framework")
+	concurrency := flag.Int("concurrency", 32, "concurrent clients of the load test")
+	flag.Parse()
+
+	if *load {
+		results := make([]LoadResult, 0, len(adapters))
+		for _, a := range adapters {
+			fmt.Fprintf(os.Stderr, "Load testing %s for %v...\n", a.name, *duration)
+			results = append(results, RunLoad(a.name, a.new(seed()), *duration, *concurrency))
+		}
+		PrintResults(os.Stdout, results)
+		return
+	}
+
+	a, ok := findAdapter(*framework)
+	if !ok {
+		log.Fatalf("Unknown framework %q, choose one of %s", *framework, adapterNames())
+	}
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           a.new(seed()),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	log.Printf("Serving the todo API with %s on %s", a.name, *addr)
+	log.Fatal(server.ListenAndServe())
+}
+
+// seed creates a service with a few todos
+func seed() *todo.Service {
+	return todo.NewService("Learn Gin Framework", "Learn Echo Framework", "Keep the business logic out of the handlers")
+}
@@ -0,0 +1,118 @@
+// Package stdapi serves the todo service with the ServeMux of the standard
+// library, the reference the frameworks are compared to
+package stdapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang-training/module-13/exercise-8/api"
+	"golang-training/module-13/exercise-8/todo"
+)
+
+// New returns a ServeMux serving the service
+func New(service *todo.Service) http.Handler {
+	h := handler{service}
+	// The patterns of ServeMux write the parameter {id}
+	todoPath := strings.Replace(api.TodoPath, ":id", "{id}", 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+api.TodosPath, h.list)
+	mux.HandleFunc("POST "+api.TodosPath, h.create)
+	mux.HandleFunc("GET "+todoPath, h.get)
+	mux.HandleFunc("PUT "+todoPath, h.update)
+	mux.HandleFunc("DELETE "+todoPath, h.delete)
+	return mux
+}
+
+type handler struct {
+	service *todo.Service
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func fail(w http.ResponseWriter, err error) {
+	status, body := api.ErrorStatus(err)
+	writeJSON(w, status, body)
+}
+
+func todoID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, api.Error{Error: api.MsgInvalidID})
+		return 0, false
+	}
+	return id, true
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeJSON(w, http.StatusBadRequest, api.Error{Error: api.MsgInvalidJSON})
+		return false
+	}
+	return true
+}
+
+func (h handler) list(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.service.List(r.Context()))
+}
+
+func (h handler) get(w http.ResponseWriter, r *http.Request) {
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	t, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (h handler) create(w http.ResponseWriter, r *http.Request) {
+	var req api.CreateRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	t, err := h.service.Create(r.Context(), req.Title)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, t)
+}
+
+func (h handler) update(w http.ResponseWriter, r *http.Request) {
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	var req api.UpdateRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	t, err := h.service.Update(r.Context(), id, req.Title, req.Completed)
+	if err != nil {
+		fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (h handler) delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := todoID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.service.Delete(r.Context(), id); err != nil {
+		fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
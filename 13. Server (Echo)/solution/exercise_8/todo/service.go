@@ -0,0 +1,123 @@
+// Package todo holds the business logic of the todo API. It knows nothing
+// about HTTP: the adapters of the ginapi, echoapi and stdapi packages turn
+// requests into calls to the Service, and its errors into status codes.
+package todo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxTitleLength is the longest title accepted, in characters
+const MaxTitleLength = 200
+
+var (
+	ErrNotFound = errors.New("todo not found")
+	ErrInvalid  = errors.New("invalid todo")
+)
+
+// Todo represents a todo item
+type Todo struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Completed bool      `json:"completed"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Service manages the todo items. It is safe for concurrent use: the
+// servers of every framework call it from many goroutines.
+type Service struct {
+	mu     sync.RWMutex
+	todos  map[int]Todo
+	nextID int
+	now    func() time.Time
+}
+
+// NewService creates a service holding the given titles as first todos
+func NewService(titles ...string) *Service {
+	s := &Service{todos: make(map[int]Todo), nextID: 1, now: time.Now}
+	for _, title := range titles {
+		s.Create(context.Background(), title)
+	}
+	return s
+}
+
+func validTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	switch {
+	case title == "":
+		return "", fmt.Errorf("%w: title is required", ErrInvalid)
+	case len([]rune(title)) > MaxTitleLength:
+		return "", fmt.Errorf("%w: title is longer than %d characters", ErrInvalid, MaxTitleLength)
+	}
+	return title, nil
+}
+
+// List returns the todos ordered by ID
+func (s *Service) List(ctx context.Context) []Todo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	todos := make([]Todo, 0, len(s.todos))
+	for _, todo := range s.todos {
+		todos = append(todos, todo)
+	}
+	slices.SortFunc(todos, func(a, b Todo) int { return a.ID - b.ID })
+	return todos
+}
+
+func (s *Service) Get(ctx context.Context, id int) (Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	todo, ok := s.todos[id]
+	if !ok {
+		return Todo{}, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	return todo, nil
+}
+
+func (s *Service) Create(ctx context.Context, title string) (Todo, error) {
+	title, err := validTitle(title)
+	if err != nil {
+		return Todo{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	todo := Todo{ID: s.nextID, Title: title, CreatedAt: now, UpdatedAt: now}
+	s.todos[todo.ID] = todo
+	s.nextID++
+	return todo, nil
+}
+
+// Update replaces the title and the completion of a todo
+func (s *Service) Update(ctx context.Context, id int, title string, completed bool) (Todo, error) {
+	title, err := validTitle(title)
+	if err != nil {
+		return Todo{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	todo, ok := s.todos[id]
+	if !ok {
+		return Todo{}, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	todo.Title, todo.Completed, todo.UpdatedAt = title, completed, s.now()
+	s.todos[id] = todo
+	return todo, nil
+}
+
+func (s *Service) Delete(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.todos[id]; !ok {
+		return fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	delete(s.todos, id)
+	return nil
+}
@@ -0,0 +1,36 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestServiceRules(t *testing.T) {
+	ctx := context.Background()
+	s := NewService("First")
+
+	if _, err := s.Create(ctx, "   "); !errors.Is(err, ErrInvalid) {
+		t.Errorf("blank title: got %v, want ErrInvalid", err)
+	}
+	if _, err := s.Create(ctx, strings.Repeat("é", MaxTitleLength+1)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("long title: got %v, want ErrInvalid", err)
+	}
+	created, err := s.Create(ctx, "  Second ")
+	if err != nil || created.ID != 2 || created.Title != "Second" {
+		t.Fatalf("Create = %+v, %v", created, err)
+	}
+	if _, err := s.Update(ctx, 9, "Missing", false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update of a missing todo: got %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: got %v, want ErrNotFound", err)
+	}
+	if todos := s.List(ctx); len(todos) != 1 || todos[0].ID != 2 {
+		t.Errorf("List = %+v", todos)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCursorExpired is returned for a cursor older than the changes kept:
+// the client missed changes and must load the whole list again
+var ErrCursorExpired = errors.New("cursor expired")
+
+// Change is a change of a todo. Seq is the cursor of the change: clients
+// ask for the changes after the last Seq they saw.
+type Change struct {
+	Seq  uint64    `json:"seq"`
+	Op   string    `json:"op"` // created, updated or deleted
+	Todo Todo      `json:"todo"`
+	At   time.Time `json:"at"`
+}
+
+// ChangeLog keeps the latest changes and wakes the requests waiting for
+// them.
+//
+// The waiters share a channel that is closed, then replaced, on each
+// change: closing a channel wakes every goroutine receiving from it, which
+// a send can't do. Unlike sync.Cond, a channel can be selected on together
+// with the context of the request, so a waiter also wakes up when its
+// client disconnects.
+type ChangeLog struct {
+	mu      sync.Mutex
+	changes []Change // Ordered by Seq, at most max of them
+	max     int
+	lastSeq uint64
+	notify  chan struct{} // Closed on the next change
+	closed  bool
+	waiting atomic.Int64
+}
+
+func NewChangeLog(max int) *ChangeLog {
+	return &ChangeLog{max: max, notify: make(chan struct{})}
+}
+
+// Append records a change and wakes the waiters
+func (l *ChangeLog) Append(op string, todo Todo) Change {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lastSeq++
+	change := Change{Seq: l.lastSeq, Op: op, Todo: todo, At: time.Now()}
+	l.changes = append(l.changes, change)
+	if len(l.changes) > l.max {
+		l.changes = l.changes[len(l.changes)-l.max:]
+	}
+	close(l.notify)
+	l.notify = make(chan struct{})
+	return change
+}
+
+// Cursor returns the cursor of the latest change, to start following the
+// changes from now
+func (l *ChangeLog) Cursor() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastSeq
+}
+
+// since returns up to limit changes after cursor, and the channel closed
+// on the next change. The caller holds the lock.
+func (l *ChangeLog) since(cursor uint64, limit int) ([]Change, <-chan struct{}, error) {
+	if cursor > l.lastSeq {
+		return nil, nil, ErrCursorExpired // A cursor of a previous run of the server
+	}
+	if len(l.changes) > 0 && cursor+1 < l.changes[0].Seq {
+		return nil, nil, ErrCursorExpired
+	}
+	// Seqs have no gaps: the change after cursor is at a known index
+	start := len(l.changes) - int(l.lastSeq-cursor)
+	changes := l.changes[start:]
+	if len(changes) > limit {
+		changes = changes[:limit]
+	}
+	return append([]Change{}, changes...), l.notify, nil
+}
+
+// Wait returns the changes after cursor as soon as there are some, or an
+// empty list after timeout. It returns early with ctx.Err() when the
+// context is done, such as when the client disconnects, and with an empty
+// list when the log is closed for the shutdown of the server.
+func (l *ChangeLog) Wait(ctx context.Context, cursor uint64, limit int, timeout time.Duration) ([]Change, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	l.waiting.Add(1)
+	defer l.waiting.Add(-1)
+
+	for {
+		l.mu.Lock()
+		changes, notify, err := l.since(cursor, limit)
+		closed := l.closed
+		l.mu.Unlock()
+		if err != nil || len(changes) > 0 || closed {
+			return changes, err
+		}
+
+		select {
+		case <-notify:
+			// Check again: the change may be one the client already has
+		case <-timer.C:
+			return []Change{}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Waiting returns the number of requests waiting for changes
+func (l *ChangeLog) Waiting() int64 {
+	return l.waiting.Load()
+}
+
+// Close answers the waiting requests at once, so a shutdown of the server
+// doesn't wait for their timeout
+func (l *ChangeLog) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.closed {
+		l.closed = true
+		close(l.notify)
+		l.notify = make(chan struct{})
+	}
+}
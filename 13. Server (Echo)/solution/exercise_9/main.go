@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Long polling sends changes to clients with plain HTTP requests: the
+// client asks for the changes after its cursor, the server holds the
+// request until there is one, then the client asks again. It works through
+// every proxy and needs nothing but fetch, at the cost of one request per
+// batch of changes.
+//
+// Server-Sent Events keep one response open and write each change to it:
+// less overhead, and the browser reconnects by itself with Last-Event-ID,
+// but some proxies buffer the stream. WebSockets add messages from the
+// client on the same connection, for chats and games, with their own
+// protocol to secure and scale. The cursor and the change log below are
+// needed by all three to resume after a disconnect.
+
+const (
+	// maxWait is the longest a request waits for changes: below the idle
+	// timeout of the proxies and load balancers in front of the server
+	maxWait = 30 * time.Second
+	// maxChanges is the largest number of changes of a response
+	maxChanges = 100
+)
+
+// ChangesResponse is the body of GET /api/v1/todos/changes. The client
+// sends Cursor as since in its next request.
+type ChangesResponse struct {
+	Changes []Change `json:"changes"`
+	Cursor  uint64   `json:"cursor"`
+}
+
+func parseID(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "Invalid todo ID")
+	}
+	return id, nil
+}
+
+func newServer(store *TodoStore, changes *ChangeLog) *echo.Echo {
+	e := echo.New()
+	e.HideBanner = true
+	v1 := e.Group("/api/v1")
+
+	// GET /api/v1/todos - The todos, and the cursor to follow their changes
+	v1.GET("/todos", func(c echo.Context) error {
+		todos, cursor := store.List()
+		return c.JSON(http.StatusOK, map[string]any{"todos": todos, "cursor": cursor})
+	})
+
+	// GET /api/v1/todos/changes?since=12&timeout=30s - The changes after
+	// the cursor, as soon as there are some
+	v1.GET("/todos/changes", func(c echo.Context) error {
+		since, err := strconv.ParseUint(c.QueryParam("since"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "since must be the cursor of GET /api/v1/todos or of the previous changes")
+		}
+		timeout := maxWait
+		if value := c.QueryParam("timeout"); value != "" {
+			if timeout, err = time.ParseDuration(value); err != nil || timeout <= 0 {
+				return echo.NewHTTPError(http.StatusBadRequest, "Invalid timeout")
+			}
+			timeout = min(timeout, maxWait)
+		}
+
+		start := time.Now()
+		found, err := changes.Wait(c.Request().Context(), since, maxChanges, timeout)
+		switch {
+		case errors.Is(err, ErrCursorExpired):
+			return echo.NewHTTPError(http.StatusGone, "Changes since this cursor are gone: load the todos again")
+		case errors.Is(err, context.Canceled):
+			// The client left: nobody reads the answer
+			log.Printf("Client left after waiting %v", time.Since(start).Round(time.Millisecond))
+			return nil
+		case err != nil:
+			return err
+		}
+
+		cursor := since
+		if len(found) > 0 {
+			cursor = found[len(found)-1].Seq
+		}
+		c.Response().Header().Set("Cache-Control", "no-store")
+		return c.JSON(http.StatusOK, ChangesResponse{Changes: found, Cursor: cursor})
+	})
+
+	// POST /api/v1/todos - Create a todo
+	v1.POST("/todos", func(c echo.Context) error {
+		var body struct {
+			Title string `json:"title"`
+		}
+		if err := c.Bind(&body); err != nil || strings.TrimSpace(body.Title) == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Title is required")
+		}
+		return c.JSON(http.StatusCreated, store.Create(body.Title))
+	})
+
+	// PUT /api/v1/todos/:id - Update a todo
+	v1.PUT("/todos/:id", func(c echo.Context) error {
+		id, err := parseID(c)
+		if err != nil {
+			return err
+		}
+		var body Todo
+		if err := c.Bind(&body); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		todo, err := store.Update(id, body.Title, body.Completed)
+		if errors.Is(err, ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Todo not found")
+		}
+		return c.JSON(http.StatusOK, todo)
+	})
+
+	// DELETE /api/v1/todos/:id - Delete a todo
+	v1.DELETE("/todos/:id", func(c echo.Context) error {
+		id, err := parseID(c)
+		if err != nil {
+			return err
+		}
+		if errors.Is(store.Delete(id), ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Todo not found")
+		}
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	return e
+}
+
+// ===== Demo =====
+
+// poll sends a long-polling request and returns the status, the body and
+// the time it waited
+func poll(ctx context.Context, url string) (string, string, time.Duration, error) {
+	start := time.Now()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", "", time.Since(start), err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.Status, strings.TrimSpace(string(body)), time.Since(start).Round(10 * time.Millisecond), nil
+}
+
+// send sends a request changing the todos
+func send(method, url, body string) {
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
+func runDemo() {
+	changes := NewChangeLog(1000)
+	store := NewTodoStore(changes)
+	server := httptest.NewServer(newServer(store, changes))
+	defer server.Close()
+	api := server.URL + "/api/v1/todos"
+
+	resp, err := http.Get(api)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var list struct {
+		Cursor uint64 `json:"cursor"`
+	}
+	json.NewDecoder(resp.Body).Decode(&list)
+	resp.Body.Close()
+	fmt.Printf("GET /api/v1/todos: cursor %d\n", list.Cursor)
+
+	// The poll waits until the todo is created, 300ms later
+	type result struct {
+		status, body string
+		waited       time.Duration
+	}
+	results := make(chan result)
+	go func() {
+		status, body, waited, _ := poll(context.Background(), fmt.Sprintf("%s/changes?since=%d", api, list.Cursor))
+		results <- result{status, body, waited}
+	}()
+	time.Sleep(300 * time.Millisecond)
+	send(http.MethodPost, api, `{"title": "Try long polling"}`)
+	r := <-results
+	fmt.Printf("Waiting poll -> %s after %v\n  %s\n", r.status, r.waited, r.body)
+
+	// Changes made while no request waits are returned at once
+	send(http.MethodPost, api, `{"title": "Compare with SSE"}`)
+	send(http.MethodDelete, api+"/1", "")
+	status, body, waited, _ := poll(context.Background(), fmt.Sprintf("%s/changes?since=%d", api, list.Cursor+1))
+	fmt.Printf("Poll with changes ready -> %s after %v\n  %s\n", status, waited, body)
+
+	// Nothing happens: an empty answer after the timeout, with the same cursor
+	status, body, waited, _ = poll(context.Background(), api+"/changes?since=3&timeout=500ms")
+	fmt.Printf("Poll without changes -> %s after %v\n  %s\n", status, waited, body)
+
+	// The client gives up: the handler stops waiting at once
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	go poll(ctx, api+"/changes?since=3")
+	time.Sleep(100 * time.Millisecond)
+	fmt.Printf("Requests waiting: %d\n", changes.Waiting())
+	<-ctx.Done()
+	time.Sleep(50 * time.Millisecond)
+	fmt.Printf("Requests waiting after the client left: %d\n", changes.Waiting())
+
+	// A cursor the server doesn't know: the client must reload the list
+	status, body, _, _ = poll(context.Background(), api+"/changes?since=99")
+	fmt.Printf("Poll with an unknown cursor -> %s\n  %s\n", status, body)
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	demo := flag.Bool("demo", false, "follow the changes of the todos with long polling")
+	flag.Parse()
+
+	if *demo {
+		runDemo()
+		return
+	}
+
+	changes := NewChangeLog(1000)
+	e := newServer(NewTodoStore(changes), changes)
+	// A response can take maxWait to start: the write timeout must be
+	// longer, or every idle poll would be cut
+	e.Server.ReadHeaderTimeout = 5 * time.Second
+	e.Server.WriteTimeout = maxWait + 10*time.Second
+	// Answer the waiting polls when the server stops, instead of waiting
+	// for their timeout
+	e.Server.RegisterOnShutdown(changes.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		log.Printf("Try: curl 'localhost%s/api/v1/todos/changes?since=0' and create a todo", *addr)
+		if err := e.Start(*addr); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrNotFound = errors.New("todo not found")
+
+// Todo represents a todo item
+type Todo struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Completed bool      `json:"completed"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TodoStore keeps the todos in memory, and records every change in its
+// change log
+type TodoStore struct {
+	mu      sync.Mutex
+	todos   []Todo
+	nextID  int
+	changes *ChangeLog
+}
+
+func NewTodoStore(changes *ChangeLog) *TodoStore {
+	now := time.Now()
+	return &TodoStore{
+		todos: []Todo{
+			{ID: 1, Title: "Learn Echo Framework", CreatedAt: now, UpdatedAt: now},
+			{ID: 2, Title: "Build a RESTful API", CreatedAt: now, UpdatedAt: now},
+		},
+		nextID:  3,
+		changes: changes,
+	}
+}
+
+// List returns the todos and the cursor of the latest change they
+// include. The change is appended under the lock of the store, so the
+// list and the cursor always match: following the changes from the cursor
+// misses nothing and repeats nothing.
+func (s *TodoStore) List() ([]Todo, uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.todos), s.changes.Cursor()
+}
+
+func (s *TodoStore) Create(title string) Todo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	todo := Todo{ID: s.nextID, Title: title, CreatedAt: now, UpdatedAt: now}
+	s.nextID++
+	s.todos = append(s.todos, todo)
+	s.changes.Append("created", todo)
+	return todo
+}
+
+func (s *TodoStore) Update(id int, title string, completed bool) (Todo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.todos {
+		if s.todos[i].ID == id {
+			s.todos[i].Title = title
+			s.todos[i].Completed = completed
+			s.todos[i].UpdatedAt = time.Now()
+			s.changes.Append("updated", s.todos[i])
+			return s.todos[i], nil
+		}
+	}
+	return Todo{}, ErrNotFound
+}
+
+func (s *TodoStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, todo := range s.todos {
+		if todo.ID == id {
+			s.todos = slices.Delete(s.todos, i, i+1)
+			s.changes.Append("deleted", todo)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrMigrationInterrupted is returned when a data migration stops before the last batch
+var ErrMigrationInterrupted = errors.New("data migration interrupted")
+
+// DataMigrationState records the progress of a data migration so it can resume
+type DataMigrationState struct {
+	Name      string `gorm:"primaryKey;size:100"`
+	LastID    uint   // Highest ID already migrated
+	Completed bool
+	UpdatedAt time.Time
+}
+
+// BatchFunc migrates the rows with an ID in (fromID, toID] and returns how many changed
+type BatchFunc func(tx *gorm.DB, fromID, toID uint) (int64, error)
+
+// DataMigration changes existing rows in batches. Unlike a schema migration it
+// can take a long time, so it runs in small transactions and records its progress.
+type DataMigration struct {
+	Name      string
+	Table     string
+	BatchSize uint
+	Apply     BatchFunc
+}
+
+// RunDataMigration runs a data migration, resuming after the last migrated ID.
+// maxBatches stops it early to simulate an interrupted deploy, zero means no limit.
+func (m *MigrationManager) RunDataMigration(dm DataMigration, maxBatches int) error {
+	if err := m.db.AutoMigrate(&DataMigrationState{}); err != nil {
+		return err
+	}
+
+	var state DataMigrationState
+	if err := m.db.FirstOrCreate(&state, DataMigrationState{Name: dm.Name}).Error; err != nil {
+		return err
+	}
+
+	if state.Completed {
+		fmt.Printf("[%s] already completed\n", dm.Name)
+		return nil
+	}
+	if state.LastID > 0 {
+		fmt.Printf("[%s] resuming after ID %d\n", dm.Name, state.LastID)
+	}
+
+	// Rows created after this point are written by the new code and need no backfill
+	var maxID uint
+	if err := m.db.Table(dm.Table).Select("COALESCE(MAX(id), 0)").Scan(&maxID).Error; err != nil {
+		return err
+	}
+
+	for batch := 1; state.LastID < maxID; batch++ {
+		if maxBatches > 0 && batch > maxBatches {
+			return fmt.Errorf("%w after ID %d", ErrMigrationInterrupted, state.LastID)
+		}
+
+		next := state
+		next.LastID = min(state.LastID+dm.BatchSize, maxID)
+
+		var changed int64
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			if changed, err = dm.Apply(tx, state.LastID, next.LastID); err != nil {
+				return err
+			}
+			// The progress is saved in the same transaction as the batch itself
+			return tx.Save(&next).Error
+		})
+		if err != nil {
+			return fmt.Errorf("batch after ID %d failed: %w", state.LastID, err)
+		}
+		state = next
+
+		fmt.Printf("[%s] IDs up to %d: %d rows updated (%.0f%%)\n",
+			dm.Name, state.LastID, changed, float64(state.LastID)/float64(maxID)*100)
+	}
+
+	state.Completed = true
+	return m.db.Save(&state).Error
+}
+
+// backfillIsActive marks users inactive when they haven't been updated since the cutoff
+func backfillIsActive(cutoff time.Time) BatchFunc {
+	return func(tx *gorm.DB, fromID, toID uint) (int64, error) {
+		result := tx.Exec("UPDATE users SET is_active = (updated_at >= ?) WHERE id > ? AND id <= ?",
+			cutoff, fromID, toID)
+		return result.RowsAffected, result.Error
+	}
+}
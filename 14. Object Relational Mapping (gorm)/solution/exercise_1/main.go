@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"math/rand/v2"
 	"time"
 
 	"gorm.io/driver/sqlite"
@@ -18,6 +20,11 @@ type UserV1 struct {
 	UpdatedAt time.Time
 }
 
+// TableName keeps every version of the model in the same table
+func (UserV1) TableName() string {
+	return "users"
+}
+
 // Second version adds Age field
 type UserV2 struct {
 	ID        uint   `gorm:"primaryKey"`
@@ -28,6 +35,10 @@ type UserV2 struct {
 	UpdatedAt time.Time
 }
 
+func (UserV2) TableName() string {
+	return "users"
+}
+
 // Third version adds IsActive field and change Age to nullable
 type UserV3 struct {
 	ID        uint   `gorm:"primaryKey"`
@@ -40,6 +51,13 @@ type UserV3 struct {
 	DeletedAt *time.Time `gorm:"index"` // Add soft delete
 }
 
+func (UserV3) TableName() string {
+	return "users"
+}
+
+// Number of existing users the is_active backfill has to process
+const legacyUserCount = 5000
+
 // MigrationManager handles database migrations
 type MigrationManager struct {
 	db *gorm.DB
@@ -75,9 +93,14 @@ func (m *MigrationManager) RunMigrations() {
 		}
 	}
 
+	// Users from the old system, some of them not seen for a long time
+	if err := m.seedLegacyUsers(); err != nil {
+		log.Fatalf("Failed to seed legacy users: %v", err)
+	}
+
 	// Display users after initial migration
 	var usersV1 []UserV1
-	m.db.Find(&usersV1)
+	m.db.Scopes(withoutLegacyUsers).Find(&usersV1)
 	fmt.Println("Users after initial migration:")
 	for _, u := range usersV1 {
 		fmt.Printf("ID: %d, Name: %s, Email: %s\n", u.ID, u.Name, u.Email)
@@ -104,7 +127,7 @@ func (m *MigrationManager) RunMigrations() {
 
 	// Display users after second migration
 	var usersV2 []UserV2
-	m.db.Find(&usersV2)
+	m.db.Scopes(withoutLegacyUsers).Find(&usersV2)
 	fmt.Println("Users after adding Age field:")
 	for _, u := range usersV2 {
 		fmt.Printf("ID: %d, Name: %s, Email: %s, Age: %d\n", u.ID, u.Name, u.Email, u.Age)
@@ -117,9 +140,30 @@ func (m *MigrationManager) RunMigrations() {
 		log.Fatalf("Failed to migrate to UserV3: %v", err)
 	}
 
-	// Set IsActive for existing users
-	m.db.Exec("UPDATE users SET is_active = ? WHERE name IN (?, ?)", true, "Alice", "Bob")
-	m.db.Exec("UPDATE users SET is_active = ? WHERE name = ?", false, "Charlie")
+	// Step 4: Fill is_active for the existing users with a data migration
+	fmt.Println("Step 4: Backfilling is_active (data migration)")
+	backfill := DataMigration{
+		Name:      "backfill_users_is_active",
+		Table:     "users",
+		BatchSize: 500,
+		Apply:     backfillIsActive(time.Now().AddDate(-1, 0, 0)),
+	}
+
+	// Simulate a deploy that is interrupted after a few batches, then run it again
+	err := m.RunDataMigration(backfill, 4)
+	if errors.Is(err, ErrMigrationInterrupted) {
+		fmt.Printf("%v, running it again\n", err)
+	} else if err != nil {
+		log.Fatalf("Data migration failed: %v", err)
+	}
+	if err := m.RunDataMigration(backfill, 0); err != nil {
+		log.Fatalf("Data migration failed: %v", err)
+	}
+
+	var activeCount, inactiveCount int64
+	m.db.Model(&UserV3{}).Where("is_active = ?", true).Count(&activeCount)
+	m.db.Model(&UserV3{}).Where("is_active = ?", false).Count(&inactiveCount)
+	fmt.Printf("Active users: %d, inactive users: %d\n\n", activeCount, inactiveCount)
 
 	// Remove age for one user to demonstrate NULL value
 	m.db.Exec("UPDATE users SET age = NULL WHERE name = ?", "Bob")
@@ -136,7 +180,7 @@ func (m *MigrationManager) RunMigrations() {
 
 	// Display users after third migration
 	var usersV3 []UserV3
-	m.db.Find(&usersV3)
+	m.db.Scopes(withoutLegacyUsers).Find(&usersV3)
 	fmt.Println("Users after final migration:")
 	for _, u := range usersV3 {
 		ageStr := "NULL"
@@ -154,7 +198,7 @@ func (m *MigrationManager) RunMigrations() {
 
 	// Show all users including soft deleted
 	var allUsers []UserV3
-	m.db.Unscoped().Find(&allUsers)
+	m.db.Unscoped().Scopes(withoutLegacyUsers).Find(&allUsers)
 	fmt.Println("All users (including soft deleted):")
 	for _, u := range allUsers {
 		ageStr := "NULL"
@@ -170,6 +214,35 @@ func (m *MigrationManager) RunMigrations() {
 	}
 }
 
+// seedLegacyUsers creates users with an update time spread over the last two years
+func (m *MigrationManager) seedLegacyUsers() error {
+	var count int64
+	if err := m.db.Model(&UserV1{}).Where("email LIKE ?", "legacy%").Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	users := make([]UserV1, legacyUserCount)
+	for i := range users {
+		lastSeen := time.Now().AddDate(0, 0, -rng.IntN(730))
+		users[i] = UserV1{
+			Name:      fmt.Sprintf("Legacy User %d", i+1),
+			Email:     fmt.Sprintf("legacy%d@example.com", i+1),
+			CreatedAt: lastSeen,
+			UpdatedAt: lastSeen,
+		}
+	}
+	return m.db.CreateInBatches(users, 500).Error
+}
+
+// withoutLegacyUsers keeps the listings short by hiding the generated users
+func withoutLegacyUsers(db *gorm.DB) *gorm.DB {
+	return db.Where("email NOT LIKE ?", "legacy%")
+}
+
 func main() {
 	// Initialize migration manager
 	mgr, err := NewMigrationManager()
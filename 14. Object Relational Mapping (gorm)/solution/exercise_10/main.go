@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang-training/module-14/seed"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Author writes posts
+type Author struct {
+	ID    uint   `gorm:"primaryKey"`
+	Name  string `gorm:"size:100;not null"`
+	Posts []Post
+}
+
+// Post belongs to an author. SQLite doesn't index foreign keys: without
+// idx_posts_author_status_created, finding the posts of an author reads
+// the whole table.
+type Post struct {
+	ID        uint      `gorm:"primaryKey"`
+	AuthorID  uint      `gorm:"not null;index:idx_posts_author_status_created,priority:1"`
+	Title     string    `gorm:"size:200;not null"`
+	Status    string    `gorm:"size:20;not null;index:idx_posts_author_status_created,priority:2"`
+	Views     int       `gorm:"not null"`
+	CreatedAt time.Time `gorm:"index:idx_posts_author_status_created,priority:3"`
+}
+
+const (
+	postsIndex = "idx_posts_author_status_created"
+	// defaultProfile seeds 500k posts: enough for the missing index to show
+	defaultProfile = "large"
+)
+
+var statuses = []string{"published", "published", "published", "draft"}
+
+// seedBlog creates an author per customer of the profile and a post per
+// order. seed.Insert does nothing when the same profile was already
+// seeded: 500k rows take a while.
+func seedBlog(ctx context.Context, db *gorm.DB, p seed.Profile) error {
+	authors, err := seed.Insert(ctx, db, "authors", p, p.Customers, func(i int, f *seed.Faker) Author {
+		return Author{Name: seed.NewCustomer(i, f).Name}
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(authors)
+	posts, err := seed.Insert(ctx, db, "posts", p, p.Orders, func(i int, f *seed.Faker) Post {
+		return Post{
+			AuthorID:  uint(f.IntN(p.Customers) + 1), // Authors have the IDs 1 to p.Customers
+			Title:     f.Sentence(f.Between(3, 8)),
+			Status:    f.Pick(statuses),
+			Views:     f.IntN(10_000),
+			CreatedAt: f.Time(365),
+		}
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(posts)
+	return nil
+}
+
+// AuthorSummary is an author with the number of published posts and the
+// title of the latest one
+type AuthorSummary struct {
+	ID        uint
+	Name      string
+	Published int
+	Latest    string
+}
+
+// summariesNPlusOne loads the authors, then runs a query per author: 1 + N
+// statements
+func summariesNPlusOne(db *gorm.DB, limit int) ([]AuthorSummary, error) {
+	var authors []Author
+	if err := db.Order("id").Limit(limit).Find(&authors).Error; err != nil {
+		return nil, err
+	}
+	summaries := make([]AuthorSummary, len(authors))
+	for i, a := range authors {
+		var posts []Post
+		err := db.Where("author_id = ? AND status = ?", a.ID, "published").Order("created_at DESC").Find(&posts).Error
+		if err != nil {
+			return nil, err
+		}
+		summaries[i] = summarize(a, posts)
+	}
+	return summaries, nil
+}
+
+// summariesPreload loads the posts of every author with a single IN query:
+// 2 statements, whatever the number of authors
+func summariesPreload(db *gorm.DB, limit int) ([]AuthorSummary, error) {
+	var authors []Author
+	err := db.Preload("Posts", func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ?", "published").Order("created_at DESC")
+	}).Order("id").Limit(limit).Find(&authors).Error
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]AuthorSummary, len(authors))
+	for i, a := range authors {
+		summaries[i] = summarize(a, a.Posts)
+	}
+	return summaries, nil
+}
+
+// summariesJoin lets the database compute the summaries: 1 statement, and
+// only the summaries cross the connection, not every post
+func summariesJoin(db *gorm.DB, limit int) ([]AuthorSummary, error) {
+	var summaries []AuthorSummary
+	err := db.Model(&Author{}).
+		Select(`authors.id, authors.name, COUNT(posts.id) AS published,
+			(SELECT title FROM posts latest WHERE latest.author_id = authors.id AND latest.status = 'published'
+				ORDER BY latest.created_at DESC LIMIT 1) AS latest`).
+		Joins("LEFT JOIN posts ON posts.author_id = authors.id AND posts.status = ?", "published").
+		Group("authors.id").
+		Order("authors.id").
+		Limit(limit).
+		Scan(&summaries).Error
+	return summaries, err
+}
+
+func summarize(a Author, published []Post) AuthorSummary {
+	summary := AuthorSummary{ID: a.ID, Name: a.Name, Published: len(published)}
+	if len(published) > 0 {
+		summary.Latest = published[0].Title
+	}
+	return summary
+}
+
+// explain prints the plan SQLite chose for a query
+func explain(db *gorm.DB, query string, args ...any) {
+	var plan []struct{ Detail string }
+	if err := db.Raw("EXPLAIN QUERY PLAN "+query, args...).Scan(&plan).Error; err != nil {
+		log.Fatalf("Failed to explain: %v", err)
+	}
+	for _, step := range plan {
+		fmt.Println("  plan:", step.Detail)
+	}
+}
+
+// timeQuery runs a query several times and returns the mean duration
+func timeQuery(db *gorm.DB, runs int, query string, args ...any) time.Duration {
+	start := time.Now()
+	for range runs {
+		var posts []Post
+		if err := db.Raw(query, args...).Scan(&posts).Error; err != nil {
+			log.Fatalf("Query failed: %v", err)
+		}
+	}
+	return time.Since(start) / time.Duration(runs)
+}
+
+func newRouter(db *gorm.DB) *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery(), QueryCountLogger(log.New(os.Stdout, "[gin] ", 0), 10))
+
+	strategies := map[string]func(*gorm.DB, int) ([]AuthorSummary, error){
+		"n-plus-one": summariesNPlusOne,
+		"preload":    summariesPreload,
+		"join":       summariesJoin,
+	}
+	// GET /authors?strategy=preload&limit=50
+	r.GET("/authors", func(c *gin.Context) {
+		load, ok := strategies[c.DefaultQuery("strategy", "join")]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "strategy must be n-plus-one, preload or join"})
+			return
+		}
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if err != nil || limit < 1 || limit > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+			return
+		}
+		// The context of the request carries the counter of the middleware
+		summaries, err := load(db.WithContext(c.Request.Context()), limit)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, summaries)
+	})
+	return r
+}
+
+// openDB opens perf.db with the query counter and the tables
+func openDB() (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open("perf.db"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, fmt.Errorf("connecting to the database: %w", err)
+	}
+	if err := db.Use(QueryCounter{}); err != nil {
+		return nil, fmt.Errorf("registering the query counter: %w", err)
+	}
+	if err := db.AutoMigrate(&Author{}, &Post{}); err != nil {
+		return nil, fmt.Errorf("migrating the database: %w", err)
+	}
+	return db, nil
+}
+
+func main() {
+	// go run . seed -profile small seeds less data for the measures below
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		profile, err := seed.Command(os.Args[2:], defaultProfile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		db, err := openDB()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := seedBlog(context.Background(), db, profile); err != nil {
+			log.Fatalf("Failed to seed: %v", err)
+		}
+		return
+	}
+
+	serve := flag.String("serve", "", "serve the API on this address, such as :8080, after the demo")
+	flag.Parse()
+
+	db, err := openDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	// The index is created again below, after the measures without it
+	if err := db.Migrator().DropIndex(&Post{}, postsIndex); err != nil {
+		log.Fatalf("Failed to drop index: %v", err)
+	}
+	// Keep the data of a previous run or of the seed subcommand
+	var posts int64
+	if err := db.Model(&Post{}).Count(&posts).Error; err != nil {
+		log.Fatalf("Failed to count posts: %v", err)
+	}
+	if posts == 0 {
+		profile, _ := seed.Lookup(defaultProfile)
+		if err := seedBlog(context.Background(), db, profile); err != nil {
+			log.Fatalf("Failed to seed: %v", err)
+		}
+	} else {
+		fmt.Printf("Using the %d posts already seeded\n", posts)
+	}
+
+	fmt.Println("\n--- EXPLAIN QUERY PLAN ---")
+	query := `SELECT * FROM posts WHERE author_id = ? AND status = ? ORDER BY created_at DESC LIMIT 10`
+	args := []any{42, "published"}
+	fmt.Println("Without index:")
+	explain(db, query, args...)
+	fmt.Printf("  mean time: %v\n", timeQuery(db, 20, query, args...).Round(time.Microsecond))
+
+	start := time.Now()
+	if err := db.Migrator().CreateIndex(&Post{}, postsIndex); err != nil {
+		log.Fatalf("Failed to create index: %v", err)
+	}
+	fmt.Printf("With %s (built in %v):\n", postsIndex, time.Since(start).Round(time.Millisecond))
+	// The index matches the filter and the order: no sort step is left
+	explain(db, query, args...)
+	fmt.Printf("  mean time: %v\n", timeQuery(db, 20, query, args...).Round(time.Microsecond))
+
+	// With the index, what is left is the cost of each round trip
+	fmt.Println("\n--- N+1 Queries ---")
+	for _, s := range []struct {
+		name string
+		load func(*gorm.DB, int) ([]AuthorSummary, error)
+	}{
+		{"N+1", summariesNPlusOne},
+		{"Preload", summariesPreload},
+		{"Join", summariesJoin},
+	} {
+		ctx, stats := WithQueryStats(db.Statement.Context)
+		start := time.Now()
+		summaries, err := s.load(db.WithContext(ctx), 50)
+		if err != nil {
+			log.Fatalf("%s failed: %v", s.name, err)
+		}
+		count, _ := stats.Snapshot()
+		fmt.Printf("%-8s %3d statements %10v  first: %+v\n", s.name, count, time.Since(start).Round(time.Microsecond), summaries[0])
+	}
+
+	fmt.Println("\n--- Query Counts per Request ---")
+	gin.SetMode(gin.ReleaseMode)
+	router := newRouter(db)
+	for _, strategy := range []string{"n-plus-one", "preload", "join"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/authors?limit=50&strategy="+strategy, nil))
+		if rec.Code != http.StatusOK {
+			log.Fatalf("GET /authors?strategy=%s: %d %s", strategy, rec.Code, strings.TrimSpace(rec.Body.String()))
+		}
+	}
+
+	if *serve != "" {
+		log.Printf("Serving on %s: try /authors?strategy=n-plus-one", *serve)
+		log.Fatal(router.Run(*serve))
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// QueryStats counts the statements run for a request and their total time.
+// It is shared by the goroutines of the request.
+type QueryStats struct {
+	mu       sync.Mutex
+	count    int
+	duration time.Duration
+}
+
+func (s *QueryStats) add(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.count++
+	s.duration += d
+}
+
+// Snapshot returns the number of statements and their total time
+func (s *QueryStats) Snapshot() (int, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count, s.duration
+}
+
+type queryStatsKey struct{}
+
+// WithQueryStats returns a context collecting the statements run with it
+func WithQueryStats(ctx context.Context) (context.Context, *QueryStats) {
+	stats := &QueryStats{}
+	return context.WithValue(ctx, queryStatsKey{}, stats), stats
+}
+
+func queryStatsFrom(ctx context.Context) *QueryStats {
+	stats, _ := ctx.Value(queryStatsKey{}).(*QueryStats)
+	return stats
+}
+
+// QueryCounter is a GORM plugin: db.Use(QueryCounter{}) registers callbacks
+// around every kind of statement. Statements run with a context from
+// WithQueryStats are counted; the others are ignored.
+type QueryCounter struct{}
+
+const startKey = "querycount:start"
+
+func (QueryCounter) Name() string {
+	return "querycount"
+}
+
+func (QueryCounter) Initialize(db *gorm.DB) error {
+	before := func(tx *gorm.DB) {
+		tx.InstanceSet(startKey, time.Now())
+	}
+	after := func(tx *gorm.DB) {
+		stats := queryStatsFrom(tx.Statement.Context)
+		start, ok := tx.InstanceGet(startKey)
+		if stats == nil || !ok {
+			return
+		}
+		stats.add(time.Since(start.(time.Time)))
+	}
+
+	// Each processor runs the callbacks of one kind of statement: Find and
+	// First are queries, Exec is raw, Scan and Pluck are rows. The timer
+	// starts and stops around the callback sending the SQL.
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("querycount:before_create", before),
+		cb.Create().After("gorm:create").Register("querycount:after_create", after),
+		cb.Query().Before("gorm:query").Register("querycount:before_query", before),
+		// Before the preloads, which run their own statements
+		cb.Query().After("gorm:query").Before("gorm:preload").Register("querycount:after_query", after),
+		cb.Update().Before("gorm:update").Register("querycount:before_update", before),
+		cb.Update().After("gorm:update").Register("querycount:after_update", after),
+		cb.Delete().Before("gorm:delete").Register("querycount:before_delete", before),
+		cb.Delete().After("gorm:delete").Register("querycount:after_delete", after),
+		cb.Row().Before("gorm:row").Register("querycount:before_row", before),
+		cb.Row().After("gorm:row").Register("querycount:after_row", after),
+		cb.Raw().Before("gorm:raw").Register("querycount:before_raw", before),
+		cb.Raw().After("gorm:raw").Register("querycount:after_raw", after),
+	)
+}
+
+// QueryCountLogger is a Gin middleware logging the number of statements of
+// each request. A request above warnAt statements is likely to run one
+// query per item of a list: an N+1.
+func QueryCountLogger(logger *log.Logger, warnAt int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, stats := WithQueryStats(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+		start := time.Now()
+
+		c.Next()
+
+		count, dbTime := stats.Snapshot()
+		warning := ""
+		if count > warnAt {
+			warning = " N+1?"
+		}
+		logger.Printf("%s %s status=%d queries=%d db=%v total=%v%s", c.Request.Method, c.Request.URL.Path,
+			c.Writer.Status(), count, dbTime.Round(time.Microsecond), time.Since(start).Round(time.Microsecond), warning)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A small SELECT builder, in the spirit of squirrel. Values always travel
+// as arguments, never inside the SQL text: only identifiers, which come
+// from the code, are written into the query.
+
+// ErrInvalidQuery is returned by ToSQL for a query that can't be built
+var ErrInvalidQuery = errors.New("invalid query")
+
+// identifier matches column and table names, such as products.price_cents
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Cond is a WHERE condition with its arguments. The zero Cond is no
+// condition: And and Where skip it, so optional filters need no if around
+// them.
+type Cond struct {
+	sql  string
+	args []any
+}
+
+// Expr is a condition written by hand, with one ? per argument
+func Expr(sql string, args ...any) Cond {
+	return Cond{sql: sql, args: args}
+}
+
+func Eq(column string, value any) Cond  { return Expr(column+" = ?", value) }
+func Gte(column string, value any) Cond { return Expr(column+" >= ?", value) }
+func Lte(column string, value any) Cond { return Expr(column+" <= ?", value) }
+
+// Like matches column against a pattern of LIKE, where \ escapes % and _
+func Like(column, pattern string) Cond {
+	return Expr(column+` LIKE ? ESCAPE '\'`, pattern)
+}
+
+// In matches one of the values. No values matches no row: "IN ()" is a
+// syntax error in most databases.
+func In[T any](column string, values []T) Cond {
+	if len(values) == 0 {
+		return Expr("1 = 0")
+	}
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return Expr(column+" IN (?"+strings.Repeat(", ?", len(values)-1)+")", args...)
+}
+
+// And joins the conditions which are not zero
+func And(conds ...Cond) Cond { return join(" AND ", conds) }
+
+// Or joins the conditions which are not zero
+func Or(conds ...Cond) Cond { return join(" OR ", conds) }
+
+func join(op string, conds []Cond) Cond {
+	conds = slices.DeleteFunc(slices.Clone(conds), Cond.IsZero)
+	if len(conds) == 1 {
+		return conds[0]
+	}
+	var joined Cond
+	parts := make([]string, len(conds))
+	for i, c := range conds {
+		// Parentheses keep a OR b AND c from meaning a OR (b AND c)
+		parts[i] = "(" + c.sql + ")"
+		joined.args = append(joined.args, c.args...)
+	}
+	joined.sql = strings.Join(parts, op)
+	return joined
+}
+
+// IsZero reports whether c is no condition
+func (c Cond) IsZero() bool {
+	return c.sql == ""
+}
+
+// SelectBuilder builds a SELECT. Its methods return a modified copy, so a
+// builder can be shared: the page and the count of a search start from the
+// same one.
+type SelectBuilder struct {
+	columns []string
+	from    string
+	where   []Cond
+	orderBy []string
+	limit   int
+	offset  int
+	err     error
+}
+
+// Select starts a query returning the columns
+func Select(columns ...string) SelectBuilder {
+	return SelectBuilder{columns: columns}
+}
+
+func (b SelectBuilder) Columns(columns ...string) SelectBuilder {
+	b.columns = columns
+	return b
+}
+
+func (b SelectBuilder) From(table string) SelectBuilder {
+	b.from = table
+	return b
+}
+
+// Where adds a condition, joined to the others with AND
+func (b SelectBuilder) Where(c Cond) SelectBuilder {
+	if !c.IsZero() {
+		b.where = append(slices.Clip(b.where), c)
+	}
+	return b
+}
+
+// OrderBy adds a sort key. The column is checked, as it is written into the
+// query: it must still come from a list of allowed columns, not from the
+// request.
+func (b SelectBuilder) OrderBy(column string, desc bool) SelectBuilder {
+	if !identifier.MatchString(column) {
+		b.err = fmt.Errorf("%w: order by %q", ErrInvalidQuery, column)
+		return b
+	}
+	if desc {
+		column += " DESC"
+	}
+	b.orderBy = append(slices.Clip(b.orderBy), column)
+	return b
+}
+
+// Limit sets the maximum number of rows, 0 for no limit
+func (b SelectBuilder) Limit(n int) SelectBuilder {
+	b.limit = n
+	return b
+}
+
+func (b SelectBuilder) Offset(n int) SelectBuilder {
+	b.offset = n
+	return b
+}
+
+// Count returns the query counting the rows of b, without its order and
+// page
+func (b SelectBuilder) Count() SelectBuilder {
+	b.columns = []string{"COUNT(*)"}
+	b.orderBy, b.limit, b.offset = nil, 0, 0
+	return b
+}
+
+// ToSQL returns the query and its arguments, for database/sql
+func (b SelectBuilder) ToSQL() (string, []any, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	if len(b.columns) == 0 || b.from == "" {
+		return "", nil, fmt.Errorf("%w: a SELECT needs columns and a table", ErrInvalidQuery)
+	}
+	if b.offset > 0 && b.limit == 0 {
+		return "", nil, fmt.Errorf("%w: OFFSET without LIMIT", ErrInvalidQuery)
+	}
+	if !identifier.MatchString(b.from) {
+		return "", nil, fmt.Errorf("%w: table %q", ErrInvalidQuery, b.from)
+	}
+
+	var sql strings.Builder
+	sql.WriteString("SELECT " + strings.Join(b.columns, ", ") + " FROM " + b.from)
+	where := And(b.where...)
+	if !where.IsZero() {
+		if strings.Count(where.sql, "?") != len(where.args) {
+			return "", nil, fmt.Errorf("%w: %d placeholders for %d arguments in %q",
+				ErrInvalidQuery, strings.Count(where.sql, "?"), len(where.args), where.sql)
+		}
+		sql.WriteString(" WHERE " + where.sql)
+	}
+	if len(b.orderBy) > 0 {
+		sql.WriteString(" ORDER BY " + strings.Join(b.orderBy, ", "))
+	}
+	// The page is made of numbers from the code: writing them is safe
+	if b.limit > 0 {
+		sql.WriteString(" LIMIT " + strconv.Itoa(b.limit))
+	}
+	if b.offset > 0 {
+		sql.WriteString(" OFFSET " + strconv.Itoa(b.offset))
+	}
+	return sql.String(), where.args, nil
+}
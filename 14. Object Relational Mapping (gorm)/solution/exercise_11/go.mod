@@ -0,0 +1,18 @@
+module golang-training/module-14/exercise-11
+
+go 1.25
+
+require (
+	github.com/mattn/go-sqlite3 v1.14.22
+	golang-training/module-14/seed v0.0.0
+	gorm.io/driver/sqlite v1.6.0
+	gorm.io/gorm v1.31.1
+)
+
+require (
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	golang.org/x/text v0.20.0 // indirect
+)
+
+replace golang-training/module-14/seed => ../seed
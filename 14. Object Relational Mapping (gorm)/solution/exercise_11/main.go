@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+
+	_ "github.com/mattn/go-sqlite3"
+	"golang-training/module-14/seed"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// seedCatalog creates the products table with GORM and fills it with the
+// small seed profile
+func seedCatalog(ctx context.Context, db *gorm.DB) error {
+	if err := db.AutoMigrate(&Product{}); err != nil {
+		return err
+	}
+	profile, _ := seed.Lookup("small")
+	result, err := seed.Insert(ctx, db, "products", profile, profile.Products, func(i int, f *seed.Faker) Product {
+		p := seed.NewProduct(i, f)
+		return Product{
+			SKU:         p.SKU,
+			Name:        p.Name,
+			Description: p.Description,
+			Category:    p.Category,
+			Brand:       p.Brand,
+			PriceCents:  p.Price,
+			Stock:       p.Stock,
+			CreatedAt:   p.CreatedAt,
+		}
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(result)
+	return nil
+}
+
+func productIDs(products []Product) []uint {
+	ids := make([]uint, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+func main() {
+	ctx := context.Background()
+	gdb, err := gorm.Open(sqlite.Open("catalog.db"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	if err := seedCatalog(ctx, gdb); err != nil {
+		log.Fatalf("Failed to seed: %v", err)
+	}
+
+	// The same file through database/sql, without GORM
+	db, err := sql.Open("sqlite3", "catalog.db")
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	fmt.Println("\n--- Building Queries ---")
+	cond := And(
+		Or(Eq("category", "Office"), Eq("brand", "Acme")),
+		Cond{}, // An unset filter: skipped
+		In("category", []string{}),
+	)
+	query, args, _ := Select("id").From("products").Where(cond).ToSQL()
+	fmt.Printf("%s\n  args: %v\n", query, args)
+	query, args, _ = Select("id").From("products").Where(Gte("stock", 1)).OrderBy("name", false).Limit(5).Offset(10).Count().ToSQL()
+	fmt.Printf("%s\n  args: %v\n", query, args)
+
+	fmt.Println("\n--- Product Search ---")
+	searches := []string{
+		"category=Office&category=Outdoor&min_price=1000&max_price=5000&sort=-price&per_page=5",
+		"q=lamp&in_stock=true&sort=-created&per_page=3",
+		"q=lamp&in_stock=true&sort=-created&per_page=3&page=2",
+		"brand=Acme&sort=name&per_page=3",
+		// Text from the request is an argument: the quote is part of the pattern
+		"q=' OR 1=1 --",
+		// % is escaped: it matches a literal percent sign, which no product has
+		"q=100%25",
+		"sort=price%3BDROP+TABLE+products",
+		"min_price=cheap",
+	}
+	for _, raw := range searches {
+		fmt.Printf("\n?%s\n", raw)
+		params, _ := url.ParseQuery(raw)
+		filter, err := ParseFilter(params)
+		if err != nil {
+			fmt.Println("  rejected:", err)
+			continue
+		}
+		query, args, err := filter.Query().ToSQL()
+		if err != nil {
+			fmt.Println("  invalid query:", err)
+			continue
+		}
+		fmt.Printf("  %s\n  args: %v\n", query, args)
+
+		result, err := SearchProducts(ctx, db, filter)
+		if err != nil {
+			log.Fatalf("Search failed: %v", err)
+		}
+		fmt.Printf("  %d matches, page %d:\n", result.Total, filter.Page)
+		for _, p := range result.Products {
+			fmt.Printf("    #%-4d %-28s %-15s %-9s $%d.%02d stock %d\n",
+				p.ID, p.Name, p.Category, p.Brand, p.PriceCents/100, p.PriceCents%100, p.Stock)
+		}
+
+		withGORM, err := SearchGORM(ctx, gdb, filter)
+		if err != nil {
+			log.Fatalf("GORM search failed: %v", err)
+		}
+		fmt.Println("  same results with GORM:",
+			withGORM.Total == result.Total && slices.Equal(productIDs(withGORM.Products), productIDs(result.Products)))
+	}
+
+	// A column name can't be an argument: the builder refuses one it can't
+	// write safely, even when the caller forgot the allowed list
+	_, _, err = Select("id").From("products").OrderBy("price_cents; DROP TABLE products", false).ToSQL()
+	fmt.Println("\nOrderBy with an unchecked column:", err, errors.Is(err, ErrInvalidQuery))
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Product model, created with GORM and read with database/sql
+type Product struct {
+	ID          uint   `gorm:"primaryKey"`
+	SKU         string `gorm:"size:20;not null;uniqueIndex"`
+	Name        string `gorm:"size:100;not null"`
+	Description string `gorm:"type:text"`
+	Category    string `gorm:"size:50;not null;index"`
+	Brand       string `gorm:"size:50;not null"`
+	PriceCents  int64  `gorm:"not null;index"`
+	Stock       int    `gorm:"not null"`
+	CreatedAt   time.Time
+}
+
+// productColumns are read by SearchProducts, in the order of scanProduct
+var productColumns = []string{"id", "sku", "name", "description", "category", "brand", "price_cents", "stock", "created_at"}
+
+// ErrInvalidFilter is returned for query parameters that can't be used
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// sortColumns maps the sort names of the API to columns. The column of an
+// ORDER BY can't be an argument: only names from this list reach the SQL.
+var sortColumns = map[string]string{
+	"price":   "price_cents",
+	"name":    "name",
+	"created": "created_at",
+	"stock":   "stock",
+}
+
+const (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// ProductFilter is a product search. Zero fields don't filter.
+type ProductFilter struct {
+	Text       string   // In the name or the description
+	Categories []string // Any of them
+	Brand      string
+	MinPrice   int64 // In cents
+	MaxPrice   int64
+	InStock    bool
+	Sort       string // A key of sortColumns, "" for the ID
+	Desc       bool
+	Page       int // From 1
+	PerPage    int
+}
+
+// ParseFilter reads a filter from query parameters such as
+// ?q=lamp&category=Home&category=Garden&min_price=1000&sort=-price&page=2
+func ParseFilter(q url.Values) (ProductFilter, error) {
+	f := ProductFilter{
+		Text:       strings.TrimSpace(q.Get("q")),
+		Categories: q["category"],
+		Brand:      q.Get("brand"),
+		InStock:    q.Get("in_stock") == "true",
+		Page:       1,
+		PerPage:    defaultPerPage,
+	}
+	var err error
+	number := func(name string, dst *int64) {
+		if v := q.Get(name); v != "" && err == nil {
+			if *dst, err = strconv.ParseInt(v, 10, 64); err != nil || *dst < 0 {
+				err = fmt.Errorf("%w: %s must be a positive number", ErrInvalidFilter, name)
+			}
+		}
+	}
+	var page, perPage int64
+	number("min_price", &f.MinPrice)
+	number("max_price", &f.MaxPrice)
+	number("page", &page)
+	number("per_page", &perPage)
+	if err != nil {
+		return f, err
+	}
+	if page > 0 {
+		f.Page = int(page)
+	}
+	if perPage > 0 {
+		f.PerPage = int(min(perPage, maxPerPage))
+	}
+
+	// sort=price sorts up, sort=-price down
+	sort := q.Get("sort")
+	f.Sort, f.Desc = strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")
+	if _, ok := sortColumns[f.Sort]; f.Sort != "" && !ok {
+		return f, fmt.Errorf("%w: unknown sort %q", ErrInvalidFilter, f.Sort)
+	}
+	return f, nil
+}
+
+// escapeLike makes % and _ match themselves in a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// where returns the conditions of the filter. Each field adds its condition
+// only when set: this is the part raw SQL makes painful, with a string and
+// an argument list grown by hand.
+func (f ProductFilter) where() Cond {
+	var text Cond
+	if f.Text != "" {
+		pattern := "%" + escapeLike(f.Text) + "%"
+		text = Or(Like("name", pattern), Like("description", pattern))
+	}
+	var categories, brand, minPrice, maxPrice, inStock Cond
+	if len(f.Categories) > 0 {
+		categories = In("category", f.Categories)
+	}
+	if f.Brand != "" {
+		brand = Eq("brand", f.Brand)
+	}
+	if f.MinPrice > 0 {
+		minPrice = Gte("price_cents", f.MinPrice)
+	}
+	if f.MaxPrice > 0 {
+		maxPrice = Lte("price_cents", f.MaxPrice)
+	}
+	if f.InStock {
+		inStock = Expr("stock > 0")
+	}
+	return And(text, categories, brand, minPrice, maxPrice, inStock)
+}
+
+// Query returns the query of a page of results
+func (f ProductFilter) Query() SelectBuilder {
+	q := Select(productColumns...).From("products").Where(f.where())
+	if f.Sort != "" {
+		q = q.OrderBy(sortColumns[f.Sort], f.Desc)
+	}
+	// The ID makes the order total, so pages don't overlap
+	return q.OrderBy("id", f.Desc).Limit(f.PerPage).Offset((f.Page - 1) * f.PerPage)
+}
+
+// SearchResult is a page of products and the number of matches
+type SearchResult struct {
+	Products []Product
+	Total    int
+}
+
+// SearchProducts runs the filter with database/sql: a count, then the page
+func SearchProducts(ctx context.Context, db *sql.DB, f ProductFilter) (SearchResult, error) {
+	var result SearchResult
+	query := f.Query()
+
+	countSQL, args, err := query.Count().ToSQL()
+	if err != nil {
+		return result, err
+	}
+	if err := db.QueryRowContext(ctx, countSQL, args...).Scan(&result.Total); err != nil {
+		return result, fmt.Errorf("counting products: %w", err)
+	}
+
+	pageSQL, args, err := query.ToSQL()
+	if err != nil {
+		return result, err
+	}
+	rows, err := db.QueryContext(ctx, pageSQL, args...)
+	if err != nil {
+		return result, fmt.Errorf("searching products: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return result, err
+		}
+		result.Products = append(result.Products, p)
+	}
+	return result, rows.Err()
+}
+
+// scanProduct reads a row of productColumns. This is the mapping GORM
+// writes for us.
+func scanProduct(rows *sql.Rows) (Product, error) {
+	var p Product
+	err := rows.Scan(&p.ID, &p.SKU, &p.Name, &p.Description, &p.Category, &p.Brand, &p.PriceCents, &p.Stock, &p.CreatedAt)
+	return p, err
+}
+
+// SearchGORM runs the same search with GORM, for comparison: the chain of
+// Where calls plays the part of the builder, and Find scans the rows
+func SearchGORM(ctx context.Context, db *gorm.DB, f ProductFilter) (SearchResult, error) {
+	var result SearchResult
+	query := db.WithContext(ctx).Model(&Product{})
+	if f.Text != "" {
+		pattern := "%" + escapeLike(f.Text) + "%"
+		query = query.Where(`name LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\'`, pattern, pattern)
+	}
+	if len(f.Categories) > 0 {
+		query = query.Where("category IN ?", f.Categories)
+	}
+	if f.Brand != "" {
+		query = query.Where("brand = ?", f.Brand)
+	}
+	if f.MinPrice > 0 {
+		query = query.Where("price_cents >= ?", f.MinPrice)
+	}
+	if f.MaxPrice > 0 {
+		query = query.Where("price_cents <= ?", f.MaxPrice)
+	}
+	if f.InStock {
+		query = query.Where("stock > 0")
+	}
+
+	// A new session, so Count and Find each start from the conditions
+	// instead of sharing one statement
+	query = query.Session(&gorm.Session{})
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return result, err
+	}
+	result.Total = int(total)
+
+	direction := ""
+	if f.Desc {
+		direction = " DESC"
+	}
+	if f.Sort != "" {
+		query = query.Order(sortColumns[f.Sort] + direction)
+	}
+	err := query.Order("id" + direction).
+		Limit(f.PerPage).
+		Offset((f.Page - 1) * f.PerPage).
+		Find(&result.Products).Error
+	return result, err
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// StatusClientClosedRequest is the status nginx logs when the client went
+// away before the answer. Nobody receives it: it is for the logs.
+const StatusClientClosedRequest = 499
+
+// NewRouter exposes the products over HTTP. The handlers pass the context
+// of the request to the service: when the client disconnects, net/http
+// cancels it and the running query is interrupted.
+func NewRouter(service *ProductService) *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Logger(), gin.Recovery())
+
+	r.GET("/products", func(c *gin.Context) {
+		var products []Product
+		var err error
+		if category := c.Query("category"); category != "" {
+			products, err = service.FindByCategory(c.Request.Context(), category)
+		} else {
+			products, err = service.FindAll(c.Request.Context())
+		}
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, products)
+	})
+
+	r.GET("/products/search", func(c *gin.Context) {
+		products, err := service.SearchProducts(c.Request.Context(), c.Query("q"))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, products)
+	})
+
+	r.GET("/products/:id", func(c *gin.Context) {
+		id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+			return
+		}
+		product, err := service.FindByID(c.Request.Context(), uint(id))
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, product)
+	})
+	return r
+}
+
+// respondError maps the errors of the service to a status
+func respondError(c *gin.Context, err error) {
+	c.Error(err)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+	case errors.Is(c.Request.Context().Err(), context.Canceled):
+		c.AbortWithStatus(StatusClientClosedRequest)
+	case errors.Is(err, context.DeadlineExceeded):
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "The database took too long to answer"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal error"})
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// slowSQL counts to a billion, which takes minutes unless interrupted
+const slowSQL = `WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n WHERE x < 1000000000)
+SELECT count(*) FROM n`
+
+func newTestService(t *testing.T) (*ProductService, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "products.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&Product{}); err != nil {
+		t.Fatal(err)
+	}
+	service := NewProductService(db)
+	if err := service.Create(context.Background(), &Product{Name: "Laptop", Price: 1299.99}); err != nil {
+		t.Fatal(err)
+	}
+	return service, db
+}
+
+// slowQueries makes every query of db run slowSQL first, with the context
+// of the query, and sends the error each query ends with to the channel
+func slowQueries(t *testing.T, db *gorm.DB) <-chan error {
+	t.Helper()
+	errs := make(chan error, 1)
+	err := errors.Join(
+		db.Callback().Query().Before("gorm:query").Register("test:slow", func(tx *gorm.DB) {
+			if _, err := tx.Statement.ConnPool.ExecContext(tx.Statement.Context, slowSQL); err != nil {
+				tx.AddError(err)
+			}
+		}),
+		db.Callback().Query().After("gorm:query").Register("test:observe", func(tx *gorm.DB) {
+			errs <- tx.Error
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return errs
+}
+
+func TestClientDisconnectCancelsQuery(t *testing.T) {
+	service, db := newTestService(t)
+	errs := slowQueries(t, db)
+	// Closing the server waits for the running handlers: it is only closed
+	// when the query was interrupted, a failing test doesn't hang
+	server := httptest.NewServer(NewRouter(service))
+
+	// The client gives up after 100ms
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/products/search?q=laptop", nil)
+	start := time.Now()
+	if _, err := http.DefaultClient.Do(req); err == nil {
+		t.Fatal("the request succeeded, want the client to give up")
+	}
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("query error = %v, want context.Canceled", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("the query stopped %v after the request, want it interrupted", elapsed)
+		}
+		server.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("the query still runs 5s after the client disconnected")
+	}
+}
+
+func TestQueryTimeout(t *testing.T) {
+	service, db := newTestService(t)
+	errs := slowQueries(t, db)
+	service.QueryTimeout = 50 * time.Millisecond
+
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	NewRouter(service).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/products/1", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusGatewayTimeout)
+	}
+	if err := <-errs; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("query error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the request took %v, want the query stopped after 50ms", elapsed)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"gorm.io/gorm"
+)
+
+// Attributes are the properties specific to a kind of product, such as the
+// RAM of a laptop or the capacity of a coffee maker. They are stored in a
+// single JSON column instead of a column per property.
+type Attributes map[string]any
+
+// The accessors return false when the attribute is missing or has another
+// type. Numbers are float64 once read back from JSON, whatever they were
+// when saved.
+
+func (a Attributes) String(key string) (string, bool) {
+	v, ok := a[key].(string)
+	return v, ok
+}
+
+func (a Attributes) Number(key string) (float64, bool) {
+	switch v := a[key].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func (a Attributes) Bool(key string) (bool, bool) {
+	v, ok := a[key].(bool)
+	return v, ok
+}
+
+func (a Attributes) Strings(key string) ([]string, bool) {
+	switch v := a[key].(type) {
+	case []string:
+		return v, true
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, s)
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+// ErrInvalidAttribute is returned for an attribute name that can't be used
+// in a JSON path
+var ErrInvalidAttribute = errors.New("invalid attribute name")
+
+var attributeName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// attributePath returns the JSON path of an attribute, such as $.ram_gb.
+// The path is passed as a parameter, but a name like "a[0]" or "a.b" would
+// still read another value.
+func attributePath(key string) (string, error) {
+	if !attributeName.MatchString(key) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidAttribute, key)
+	}
+	return "$." + key, nil
+}
+
+// attributeOperators are the comparisons accepted by WhereAttribute
+var attributeOperators = map[string]bool{"=": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+// WhereAttribute is a scope keeping the products whose attribute compares
+// to value. json_extract returns the JSON value as an SQL value: strings as
+// text, numbers as numbers, and booleans as 1 or 0.
+func WhereAttribute(key, op string, value any) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		path, err := attributePath(key)
+		if err != nil {
+			db.AddError(err)
+			return db
+		}
+		if !attributeOperators[op] {
+			db.AddError(fmt.Errorf("invalid attribute operator %q", op))
+			return db
+		}
+		return db.Where("json_extract(attributes, ?) "+op+" ?", path, value)
+	}
+}
+
+// HasAttribute is a scope keeping the products having the attribute, even
+// when its value is null
+func HasAttribute(key string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		path, err := attributePath(key)
+		if err != nil {
+			db.AddError(err)
+			return db
+		}
+		return db.Where("json_type(attributes, ?) IS NOT NULL", path)
+	}
+}
+
+// AttributeContains is a scope keeping the products whose array attribute
+// contains the value. json_each turns the array into rows.
+func AttributeContains(key string, value any) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		path, err := attributePath(key)
+		if err != nil {
+			db.AddError(err)
+			return db
+		}
+		return db.Where("EXISTS (SELECT 1 FROM json_each(products.attributes, ?) WHERE json_each.value = ?)", path, value)
+	}
+}
+
+// FindByAttributes retrieves the products matching every scope
+func (s *ProductService) FindByAttributes(ctx context.Context, scopes ...func(*gorm.DB) *gorm.DB) ([]Product, error) {
+	db, cancel := s.conn(ctx)
+	defer cancel()
+	var products []Product
+	err := db.Scopes(scopes...).Order("id").Find(&products).Error
+	return products, err
+}
+
+// MergeAttributes applies a JSON merge patch (RFC 7396) to the attributes
+// of a product, in the database: the keys of the patch are set, a nil value
+// removes its key, and the other attributes are kept. Unlike Update, two
+// merges of different keys don't overwrite each other.
+func (s *ProductService) MergeAttributes(ctx context.Context, id uint, patch Attributes) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	db, cancel := s.conn(ctx)
+	defer cancel()
+	result := db.Model(&Product{}).Where("id = ?", id).Updates(map[string]any{
+		"attributes": gorm.Expr("json_patch(coalesce(attributes, '{}'), ?)", string(data)),
+		// The copies loaded before the merge are now stale
+		"version": gorm.Expr("version + 1"),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// IndexAttribute creates an index on the values of an attribute. A query
+// uses it when it filters on the same json_extract expression.
+func IndexAttribute(db *gorm.DB, key string) error {
+	path, err := attributePath(key)
+	if err != nil {
+		return err
+	}
+	// An index expression can't have parameters: the path is validated above
+	statement := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_products_attr_%s ON products (json_extract(attributes, '%s'))", key, path)
+	return db.Exec(statement).Error
+}
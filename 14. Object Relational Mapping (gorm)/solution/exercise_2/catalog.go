@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"golang-training/module-14/seed"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// runSeedCommand fills catalog.db with a generated catalog, then compares
+// OFFSET and keyset pagination deep into it:
+//
+//	go run . seed -profile large
+func runSeedCommand(args []string) error {
+	profile, err := seed.Command(args, "medium")
+	if err != nil {
+		return err
+	}
+	// A separate database: the demo of main prints every product
+	db, err := gorm.Open(sqlite.Open("catalog.db"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&Product{}); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	result, err := seed.Insert(ctx, db, "products", profile, profile.Products, func(i int, f *seed.Faker) Product {
+		p := seed.NewProduct(i, f)
+		return Product{
+			Name:        p.Name,
+			Description: p.Description,
+			Price:       p.PriceFloat(),
+			Stock:       p.Stock,
+			Category:    p.Category,
+			IsActive:    true,
+			Attributes:  Attributes{"brand": p.Brand, "sku": p.SKU},
+			CreatedAt:   p.CreatedAt,
+			UpdatedAt:   p.CreatedAt,
+		}
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(result)
+
+	// The page at 90% of the catalog, reached both ways
+	const limit = 20
+	service := NewProductService(db)
+	// From page 2, so the row before the page exists for the cursor
+	page := max(2, profile.Products*9/10/limit)
+	var previous Product
+	if err := db.Order("price ASC, id ASC").Offset((page-1)*limit - 1).Limit(1).Find(&previous).Error; err != nil {
+		return err
+	}
+	cursor := encodeCursor(previous)
+
+	var byOffset, byKeyset []Product
+	var offsetErr, keysetErr error
+	offsetTime := timeIt(func() error {
+		byOffset, offsetErr = service.ListPage(ctx, page, limit)
+		return offsetErr
+	})
+	keysetTime := timeIt(func() error {
+		byKeyset, _, keysetErr = service.ListAfter(ctx, cursor, limit)
+		return keysetErr
+	})
+	if err := errors.Join(offsetErr, keysetErr); err != nil {
+		return err
+	}
+	sameIDs := slices.EqualFunc(byOffset, byKeyset, func(a, b Product) bool { return a.ID == b.ID })
+	fmt.Printf("Page %d of %d products, same rows both ways: %t\n", page, profile.Products, sameIDs)
+	fmt.Printf("  OFFSET %-8d %v\n", (page-1)*limit, offsetTime.Round(time.Microsecond))
+	fmt.Printf("  keyset cursor   %v\n", keysetTime.Round(time.Microsecond))
+	return nil
+}
+
+// timeIt returns the mean duration of fn over 10 runs
+func timeIt(fn func() error) time.Duration {
+	const runs = 10
+	start := time.Now()
+	for range runs {
+		if err := fn(); err != nil {
+			return 0
+		}
+	}
+	return time.Since(start) / runs
+}
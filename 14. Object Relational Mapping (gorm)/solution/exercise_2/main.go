@@ -1,8 +1,16 @@
 package main
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"slices"
+	"sync"
 	"time"
 
 	"gorm.io/driver/sqlite"
@@ -12,36 +20,105 @@ import (
 
 // Product model
 type Product struct {
-	ID          uint    `gorm:"primaryKey"`
-	Name        string  `gorm:"size:100;not null"`
-	Description string  `gorm:"type:text"`
-	Price       float64 `gorm:"type:decimal(10,2);not null"`
-	Stock       int     `gorm:"default:0"`
-	Category    string  `gorm:"size:50;index"`
-	IsActive    bool    `gorm:"default:true"`
+	ID          uint       `gorm:"primaryKey;index:idx_products_price_id,priority:2"`
+	Name        string     `gorm:"size:100;not null"`
+	Description string     `gorm:"type:text"`
+	Price       float64    `gorm:"type:decimal(10,2);not null;index:idx_products_price_id,priority:1"` // The order of ListAfter
+	Stock       int        `gorm:"default:0"`
+	Category    string     `gorm:"size:50;index"`
+	IsActive    bool       `gorm:"default:true"`
+	Version     uint       `gorm:"not null;default:0"` // Incremented on every update
+	Attributes  Attributes `gorm:"serializer:json"`    // Saved as a JSON document
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
 
-// ProductService handles database operations for products
+// ErrStaleObject is returned when a row was modified after it was loaded
+var ErrStaleObject = errors.New("stale object")
+
+// StaleObjectError describes which product version could not be updated
+type StaleObjectError struct {
+	ID      uint
+	Version uint
+}
+
+func (e *StaleObjectError) Error() string {
+	return fmt.Sprintf("product %d was modified concurrently (version %d is outdated)", e.ID, e.Version)
+}
+
+func (e *StaleObjectError) Unwrap() error {
+	return ErrStaleObject
+}
+
+// ErrInvalidCursor is returned when a pagination cursor can't be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrInvalidLimit is returned for a page size below 1
+var ErrInvalidLimit = errors.New("invalid limit")
+
+// productCursor is the sort key of the last product on a page
+type productCursor struct {
+	Price float64 `json:"price"`
+	ID    uint    `json:"id"`
+}
+
+// encodeCursor turns the position after the product into an opaque token
+func encodeCursor(product Product) string {
+	data, _ := json.Marshal(productCursor{Price: product.Price, ID: product.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads the position back from the token
+func decodeCursor(cursor string) (productCursor, error) {
+	var c productCursor
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return c, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return c, nil
+}
+
+// DefaultQueryTimeout bounds every query of a ProductService
+const DefaultQueryTimeout = 5 * time.Second
+
+// ProductService handles database operations for products. Every method
+// takes the context of the caller, such as the one of an HTTP request: when
+// it is cancelled, the query running for it is interrupted.
 type ProductService struct {
 	db *gorm.DB
+	// QueryTimeout interrupts a query running longer, even when the context
+	// of the caller has no deadline
+	QueryTimeout time.Duration
 }
 
 // NewProductService creates a new product service with the provided database connection
 func NewProductService(db *gorm.DB) *ProductService {
-	return &ProductService{db: db}
+	return &ProductService{db: db, QueryTimeout: DefaultQueryTimeout}
+}
+
+// conn returns the connection for one query, bound to ctx and to the query
+// timeout. The caller must call cancel once the query is done.
+func (s *ProductService) conn(ctx context.Context) (db *gorm.DB, cancel context.CancelFunc) {
+	ctx, cancel = context.WithTimeout(ctx, s.QueryTimeout)
+	return s.db.WithContext(ctx), cancel
 }
 
 // Create adds a new product to the database
-func (s *ProductService) Create(product *Product) error {
-	return s.db.Create(product).Error
+func (s *ProductService) Create(ctx context.Context, product *Product) error {
+	db, cancel := s.conn(ctx)
+	defer cancel()
+	return db.Create(product).Error
 }
 
 // FindByID retrieves a product by its ID
-func (s *ProductService) FindByID(id uint) (*Product, error) {
+func (s *ProductService) FindByID(ctx context.Context, id uint) (*Product, error) {
+	db, cancel := s.conn(ctx)
+	defer cancel()
 	var product Product
-	err := s.db.First(&product, id).Error
+	err := db.First(&product, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -49,37 +126,211 @@ func (s *ProductService) FindByID(id uint) (*Product, error) {
 }
 
 // FindAll retrieves all products
-func (s *ProductService) FindAll() ([]Product, error) {
+func (s *ProductService) FindAll(ctx context.Context) ([]Product, error) {
+	db, cancel := s.conn(ctx)
+	defer cancel()
 	var products []Product
-	err := s.db.Find(&products).Error
+	err := db.Find(&products).Error
 	return products, err
 }
 
 // FindByCategory retrieves products by category
-func (s *ProductService) FindByCategory(category string) ([]Product, error) {
+func (s *ProductService) FindByCategory(ctx context.Context, category string) ([]Product, error) {
+	db, cancel := s.conn(ctx)
+	defer cancel()
 	var products []Product
-	err := s.db.Where("category = ?", category).Find(&products).Error
+	err := db.Where("category = ?", category).Find(&products).Error
 	return products, err
 }
 
-// Update modifies an existing product
-func (s *ProductService) Update(product *Product) error {
-	return s.db.Save(product).Error
+// Update modifies an existing product, failing with a StaleObjectError when the
+// row was updated by someone else since the product was loaded
+func (s *ProductService) Update(ctx context.Context, product *Product) error {
+	db, cancel := s.conn(ctx)
+	defer cancel()
+	loadedVersion := product.Version
+	product.Version++
+
+	// Only matches the row if it still has the version we loaded
+	result := db.Model(product).
+		Where("version = ?", loadedVersion).
+		Select("*").
+		Omit("id", "created_at").
+		Updates(product)
+	if result.Error != nil {
+		product.Version = loadedVersion
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		product.Version = loadedVersion
+		return &StaleObjectError{ID: product.ID, Version: loadedVersion}
+	}
+	return nil
+}
+
+// UpdateWithRetry loads the product, applies the change and saves it, starting
+// over with a fresh copy whenever another update got there first
+func (s *ProductService) UpdateWithRetry(ctx context.Context, id uint, maxAttempts int, change func(*Product) error) error {
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		// Each attempt has its own query timeouts: stop retrying when the
+		// caller is gone
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		product, err := s.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := change(product); err != nil {
+			return err
+		}
+
+		err = s.Update(ctx, product)
+		if !errors.Is(err, ErrStaleObject) {
+			return err
+		}
+	}
+	return fmt.Errorf("update failed after %d attempts: %w", maxAttempts, ErrStaleObject)
 }
 
 // Delete removes a product by ID
-func (s *ProductService) Delete(id uint) error {
-	return s.db.Delete(&Product{}, id).Error
+func (s *ProductService) Delete(ctx context.Context, id uint) error {
+	db, cancel := s.conn(ctx)
+	defer cancel()
+	return db.Delete(&Product{}, id).Error
+}
+
+// ListAfter returns up to limit products ordered by price, continuing after the
+// cursor. An empty cursor starts at the first page, an empty next cursor means
+// there are no more pages.
+func (s *ProductService) ListAfter(ctx context.Context, cursor string, limit int) ([]Product, string, error) {
+	if limit < 1 {
+		return nil, "", fmt.Errorf("%w: %d", ErrInvalidLimit, limit)
+	}
+	db, cancel := s.conn(ctx)
+	defer cancel()
+	// The ID breaks ties between products with the same price, so the order is stable
+	query := db.Order("price ASC, id ASC").Limit(limit + 1)
+
+	if cursor != "" {
+		after, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		// Seek past the last row instead of counting rows to skip like OFFSET.
+		// The row value comparison, unlike the equivalent OR, lets SQLite
+		// start at the cursor in idx_products_price_id.
+		query = query.Where("(price, id) > (?, ?)", after.Price, after.ID)
+	}
+
+	var products []Product
+	if err := query.Find(&products).Error; err != nil {
+		return nil, "", err
+	}
+
+	// The extra row only tells whether there is another page
+	if len(products) <= limit {
+		return products, "", nil
+	}
+	products = products[:limit]
+	return products, encodeCursor(products[limit-1]), nil
+}
+
+// ListPage returns a page of products using OFFSET, in the same order as ListAfter
+func (s *ProductService) ListPage(ctx context.Context, page, limit int) ([]Product, error) {
+	db, cancel := s.conn(ctx)
+	defer cancel()
+	var products []Product
+	err := db.Order("price ASC, id ASC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&products).Error
+	return products, err
 }
 
 // SearchProducts searches for products by name or description
-func (s *ProductService) SearchProducts(query string) ([]Product, error) {
+func (s *ProductService) SearchProducts(ctx context.Context, query string) ([]Product, error) {
+	db, cancel := s.conn(ctx)
+	defer cancel()
 	var products []Product
-	err := s.db.Where("name LIKE ? OR description LIKE ?", "%"+query+"%", "%"+query+"%").Find(&products).Error
+	err := db.Where("name LIKE ? OR description LIKE ?", "%"+query+"%", "%"+query+"%").Find(&products).Error
 	return products, err
 }
 
+// collectWithCursor walks all pages with ListAfter and returns the product IDs in order.
+// afterFirstPage, when set, runs between the first and the second page.
+func collectWithCursor(ctx context.Context, service *ProductService, limit int, afterFirstPage func() error) ([]uint, error) {
+	var ids []uint
+	cursor := ""
+	for page := 1; ; page++ {
+		products, next, err := service.ListAfter(ctx, cursor, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range products {
+			ids = append(ids, p.ID)
+		}
+
+		if next == "" {
+			return ids, nil
+		}
+		if page == 1 && afterFirstPage != nil {
+			if err := afterFirstPage(); err != nil {
+				return nil, err
+			}
+		}
+		cursor = next
+	}
+}
+
+// collectWithOffset walks all pages with ListPage and returns the product IDs in order
+func collectWithOffset(ctx context.Context, service *ProductService, limit int, afterFirstPage func() error) ([]uint, error) {
+	var ids []uint
+	for page := 1; ; page++ {
+		products, err := service.ListPage(ctx, page, limit)
+		if err != nil {
+			return nil, err
+		}
+		if len(products) == 0 {
+			return ids, nil
+		}
+		for _, p := range products {
+			ids = append(ids, p.ID)
+		}
+
+		if page == 1 && afterFirstPage != nil {
+			if err := afterFirstPage(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// countDuplicates returns how many IDs were seen more than once
+func countDuplicates(ids []uint) int {
+	seen := make(map[uint]bool)
+	duplicates := 0
+	for _, id := range ids {
+		if seen[id] {
+			duplicates++
+		}
+		seen[id] = true
+	}
+	return duplicates
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		if err := runSeedCommand(os.Args[2:]); err != nil {
+			log.Fatalf("Seeding failed: %v", err)
+		}
+		return
+	}
+	serve := flag.String("serve", "", "serve the products over HTTP on this address, such as :8080, after the demo")
+	flag.Parse()
+
 	// Set up the logger for GORM
 	newLogger := logger.New(
 		log.New(log.Writer(), "\r\n", log.LstdFlags),
@@ -92,7 +343,8 @@ func main() {
 	)
 
 	// Connect to a SQLite database
-	db, err := gorm.Open(sqlite.Open("products.db"), &gorm.Config{
+	// Wait for locks instead of failing, the concurrent updates below write at the same time
+	db, err := gorm.Open(sqlite.Open("products.db?_busy_timeout=5000"), &gorm.Config{
 		Logger: newLogger,
 	})
 	if err != nil {
@@ -104,9 +356,13 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
+	if err := IndexAttribute(db, "brand"); err != nil {
+		log.Fatalf("Failed to index attribute: %v", err)
+	}
 
 	// Create a product service
 	productService := NewProductService(db)
+	ctx := context.Background()
 
 	// Create products
 	products := []Product{
@@ -117,6 +373,9 @@ func main() {
 			Stock:       10,
 			Category:    "Electronics",
 			IsActive:    true,
+			Attributes: Attributes{
+				"brand": "Acme", "ram_gb": 16, "ports": []string{"usb-c", "hdmi"}, "touchscreen": false,
+			},
 		},
 		{
 			Name:        "Smartphone",
@@ -124,6 +383,7 @@ func main() {
 			Price:       799.99,
 			Stock:       15,
 			Category:    "Electronics",
+			Attributes:  Attributes{"brand": "Nova", "storage_gb": 256, "ports": []string{"usb-c"}, "has_5g": true},
 		},
 		{
 			Name:        "Coffee Maker",
@@ -131,13 +391,14 @@ func main() {
 			Price:       89.99,
 			Stock:       5,
 			Category:    "Home Appliances",
+			Attributes:  Attributes{"brand": "Brewster", "capacity_cups": 12, "programmable": true},
 		},
 	}
 
 	// Demonstrate CRUD operations
 	fmt.Println("--- Create Products ---")
 	for _, product := range products {
-		if err := productService.Create(&product); err != nil {
+		if err := productService.Create(ctx, &product); err != nil {
 			log.Printf("Failed to create product: %v", err)
 		} else {
 			fmt.Printf("Product created: %s (ID: %d)\n", product.Name, product.ID)
@@ -145,7 +406,7 @@ func main() {
 	}
 
 	fmt.Println("\n--- Find All Products ---")
-	allProducts, err := productService.FindAll()
+	allProducts, err := productService.FindAll(ctx)
 	if err != nil {
 		log.Printf("Failed to retrieve products: %v", err)
 	} else {
@@ -156,7 +417,7 @@ func main() {
 	}
 
 	fmt.Println("\n--- Find Products by Category ---")
-	electronicsProducts, err := productService.FindByCategory("Electronics")
+	electronicsProducts, err := productService.FindByCategory(ctx, "Electronics")
 	if err != nil {
 		log.Printf("Failed to retrieve electronics products: %v", err)
 	} else {
@@ -171,7 +432,7 @@ func main() {
 		productToUpdate := allProducts[0]
 		// Increase price by 10%
 		productToUpdate.Price = productToUpdate.Price * 1.1
-		if err := productService.Update(&productToUpdate); err != nil {
+		if err := productService.Update(ctx, &productToUpdate); err != nil {
 			log.Printf("Failed to update product: %v", err)
 		} else {
 			fmt.Printf("Product updated: %s (New price: $%.2f)\n",
@@ -179,8 +440,72 @@ func main() {
 		}
 	}
 
+	fmt.Println("\n--- Optimistic Locking ---")
+	if len(allProducts) > 0 {
+		// Two users load the same product
+		first, _ := productService.FindByID(ctx, allProducts[0].ID)
+		second, _ := productService.FindByID(ctx, allProducts[0].ID)
+
+		first.Stock += 5
+		if err := productService.Update(ctx, first); err != nil {
+			log.Printf("Failed to update product: %v", err)
+		} else {
+			fmt.Printf("First update succeeded (stock: %d, version: %d)\n", first.Stock, first.Version)
+		}
+
+		// The second copy still has the old version, so it would overwrite the first update
+		second.Price = 999.99
+		err := productService.Update(ctx, second)
+		var staleErr *StaleObjectError
+		if errors.As(err, &staleErr) {
+			fmt.Printf("Second update rejected: %v\n", staleErr)
+		}
+	}
+
+	fmt.Println("\n--- Concurrent Updates with Retry ---")
+	if len(allProducts) > 0 {
+		id := allProducts[0].ID
+		before, _ := productService.FindByID(ctx, id)
+
+		const updatesPerWorker = 5
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		workers := []string{"worker-1", "worker-2"}
+		attempts := make(map[string]int)
+
+		for _, worker := range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range updatesPerWorker {
+					err := productService.UpdateWithRetry(ctx, id, 10, func(p *Product) error {
+						mu.Lock()
+						attempts[worker]++
+						mu.Unlock()
+
+						p.Stock--
+						// Widen the window between read and write so the workers collide
+						time.Sleep(10 * time.Millisecond)
+						return nil
+					})
+					if err != nil {
+						log.Printf("%s failed to update product: %v", worker, err)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		after, _ := productService.FindByID(ctx, id)
+		for _, worker := range workers {
+			fmt.Printf("%s: %d updates in %d attempts\n", worker, updatesPerWorker, attempts[worker])
+		}
+		fmt.Printf("Stock went from %d to %d (version %d to %d)\n",
+			before.Stock, after.Stock, before.Version, after.Version)
+	}
+
 	fmt.Println("\n--- Search Products ---")
-	searchResults, err := productService.SearchProducts("coffee")
+	searchResults, err := productService.SearchProducts(ctx, "coffee")
 	if err != nil {
 		log.Printf("Search failed: %v", err)
 	} else {
@@ -191,19 +516,161 @@ func main() {
 		}
 	}
 
+	fmt.Println("\n--- JSON Attributes ---")
+	if len(allProducts) > 0 {
+		laptop, _ := productService.FindByID(ctx, allProducts[0].ID)
+		ram, _ := laptop.Attributes.Number("ram_gb")
+		ports, _ := laptop.Attributes.Strings("ports")
+		_, hasColor := laptop.Attributes.String("color")
+		fmt.Printf("%s: %.0f GB RAM, ports %v, has a color: %t\n", laptop.Name, ram, ports, hasColor)
+
+		queries := []struct {
+			label  string
+			scopes []func(*gorm.DB) *gorm.DB
+		}{
+			{"ram_gb >= 16", []func(*gorm.DB) *gorm.DB{WhereAttribute("ram_gb", ">=", 16)}},
+			{"ports contains usb-c", []func(*gorm.DB) *gorm.DB{AttributeContains("ports", "usb-c")}},
+			{"has capacity_cups", []func(*gorm.DB) *gorm.DB{HasAttribute("capacity_cups")}},
+			{"programmable and brand Brewster", []func(*gorm.DB) *gorm.DB{
+				WhereAttribute("programmable", "=", true), WhereAttribute("brand", "=", "Brewster"),
+			}},
+		}
+		for _, q := range queries {
+			found, err := productService.FindByAttributes(ctx, q.scopes...)
+			if err != nil {
+				log.Printf("Attribute query failed: %v", err)
+				continue
+			}
+			names := make([]string, len(found))
+			for i, p := range found {
+				names[i] = p.Name
+			}
+			fmt.Printf("%-32s %v\n", q.label+":", names)
+		}
+		if _, err := productService.FindByAttributes(ctx, WhereAttribute("ram'); --", "=", 1)); errors.Is(err, ErrInvalidAttribute) {
+			fmt.Printf("Rejected: %v\n", err)
+		}
+
+		// The merge only touches the keys of the patch, in a single UPDATE
+		patch := Attributes{"ram_gb": 32, "color": "silver", "touchscreen": nil}
+		if err := productService.MergeAttributes(ctx, laptop.ID, patch); err != nil {
+			log.Printf("Failed to merge attributes: %v", err)
+		}
+		merged, _ := productService.FindByID(ctx, laptop.ID)
+		data, _ := json.Marshal(merged.Attributes)
+		fmt.Printf("After merging %v: %s (version %d to %d)\n", patch, data, laptop.Version, merged.Version)
+
+		// The loaded copy is stale now: saving it would lose the merge
+		laptop.Stock++
+		var staleErr *StaleObjectError
+		if errors.As(productService.Update(ctx, laptop), &staleErr) {
+			fmt.Printf("Update of the copy loaded before the merge rejected: %v\n", staleErr)
+		}
+
+		var plan []struct{ Detail string }
+		db.Raw("EXPLAIN QUERY PLAN SELECT id FROM products WHERE json_extract(attributes, '$.brand') = ?", "Acme").Scan(&plan)
+		for _, step := range plan {
+			fmt.Println("Query plan:", step.Detail)
+		}
+	}
+
 	fmt.Println("\n--- Delete Product ---")
 	if len(allProducts) > 0 {
 		idToDelete := allProducts[len(allProducts)-1].ID
-		if err := productService.Delete(idToDelete); err != nil {
+		if err := productService.Delete(ctx, idToDelete); err != nil {
 			log.Printf("Failed to delete product: %v", err)
 		} else {
 			fmt.Printf("Product with ID %d deleted\n", idToDelete)
 		}
 	}
 
+	fmt.Println("\n--- Keyset Pagination ---")
+	// The demo products are created in a transaction that is rolled back at the end
+	errRollback := errors.New("rollback demo data")
+	err = db.Transaction(func(tx *gorm.DB) error {
+		service := NewProductService(tx)
+
+		// Several products share a price, only the ID tie-breaker keeps their order stable
+		for i := 1; i <= 7; i++ {
+			cable := Product{Name: fmt.Sprintf("USB Cable %d", i), Price: 9.99, Stock: 50, Category: "Accessories"}
+			if err := service.Create(ctx, &cable); err != nil {
+				return err
+			}
+		}
+
+		cursor := ""
+		for page := 1; page <= 2; page++ {
+			products, next, err := service.ListAfter(ctx, cursor, 3)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Page %d:", page)
+			for _, p := range products {
+				fmt.Printf(" %s ($%.2f)", p.Name, p.Price)
+			}
+			fmt.Printf("\n  next cursor: %s\n", next)
+			cursor = next
+		}
+
+		if _, _, err := service.ListAfter(ctx, "not-a-cursor", 3); errors.Is(err, ErrInvalidCursor) {
+			fmt.Printf("Tampered cursor rejected: %v\n", err)
+		}
+
+		keyset, err := collectWithCursor(ctx, service, 3, nil)
+		if err != nil {
+			return err
+		}
+		offset, err := collectWithOffset(ctx, service, 3, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Keyset and OFFSET return the same %d products in the same order: %t\n",
+			len(keyset), slices.Equal(keyset, offset))
+
+		// A cheaper product added while paging shifts every OFFSET page by one row
+		insertCheapest := func() error {
+			return service.Create(ctx, &Product{Name: "Cable Tie", Price: 0.99, Category: "Accessories"})
+		}
+		keyset, err = collectWithCursor(ctx, service, 3, insertCheapest)
+		if err != nil {
+			return err
+		}
+		offset, err = collectWithOffset(ctx, service, 3, insertCheapest)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("With an insert between pages: keyset saw %d duplicates, OFFSET saw %d\n",
+			countDuplicates(keyset), countDuplicates(offset))
+
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		log.Printf("Pagination demo failed: %v", err)
+	}
+
+	fmt.Println("\n--- Cancelled Queries ---")
+	// A request whose client is gone: the query is not even started
+	gone, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := productService.FindAll(gone); errors.Is(err, context.Canceled) {
+		fmt.Println("FindAll with a cancelled context:", err)
+	}
+	// A deadline shorter than the query timeout wins
+	expired, cancel := context.WithTimeout(ctx, time.Nanosecond)
+	defer cancel()
+	time.Sleep(time.Millisecond)
+	if _, err := productService.SearchProducts(expired, "coffee"); errors.Is(err, context.DeadlineExceeded) {
+		fmt.Println("SearchProducts past its deadline:", err)
+	}
+
 	fmt.Println("\n--- Final Product List ---")
-	finalProducts, _ := productService.FindAll()
+	finalProducts, _ := productService.FindAll(ctx)
 	for _, p := range finalProducts {
 		fmt.Printf("ID: %d, Name: %s, Price: $%.2f\n", p.ID, p.Name, p.Price)
 	}
+
+	if *serve != "" {
+		log.Printf("Serving the products on %s", *serve)
+		log.Fatal(NewRouter(productService).Run(*serve))
+	}
 }
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// seedPrices adds products with repeated prices, so the pages end between
+// products of the same price and the ID has to break the ties
+func seedPrices(t *testing.T, service *ProductService, n int) {
+	t.Helper()
+	for i := range n {
+		product := &Product{Name: fmt.Sprintf("Product %d", i), Price: float64(10 + i%7)}
+		if err := service.Create(context.Background(), product); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// allIDs returns the IDs of every product in the order of the pages, in a
+// single query
+func allIDs(t *testing.T, service *ProductService) []uint {
+	t.Helper()
+	var ids []uint
+	if err := service.db.Model(&Product{}).Order("price ASC, id ASC").Pluck("id", &ids).Error; err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+func TestKeysetMatchesOffset(t *testing.T) {
+	service, _ := newTestService(t)
+	seedPrices(t, service, 40)
+	want := allIDs(t, service)
+
+	for _, limit := range []int{1, 3, 7, 41, 100} {
+		t.Run(fmt.Sprintf("limit %d", limit), func(t *testing.T) {
+			keyset, err := collectWithCursor(context.Background(), service, limit, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			offset, err := collectWithOffset(context.Background(), service, limit, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(keyset, offset) {
+				t.Errorf("keyset pages %v\nOFFSET pages %v", keyset, offset)
+			}
+			if !slices.Equal(keyset, want) {
+				t.Errorf("keyset pages %v\nwant %v", keyset, want)
+			}
+		})
+	}
+}
+
+func TestKeysetInsertBetweenPages(t *testing.T) {
+	service, _ := newTestService(t)
+	seedPrices(t, service, 20)
+	before := allIDs(t, service)
+
+	// A product sorted before the first page is added once it was read
+	var inserted uint
+	insert := func() error {
+		product := &Product{Name: "Cheapest", Price: 1}
+		err := service.Create(context.Background(), product)
+		inserted = product.ID
+		return err
+	}
+
+	keyset, err := collectWithCursor(context.Background(), service, 5, insert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := countDuplicates(keyset); n > 0 {
+		t.Errorf("keyset pages returned %d rows twice: %v", n, keyset)
+	}
+	if slices.Contains(keyset, inserted) {
+		t.Errorf("keyset pages returned the row inserted before the cursor")
+	}
+	if !slices.Equal(keyset, before) {
+		t.Errorf("keyset pages %v\nwant the rows present at the start %v", keyset, before)
+	}
+
+	// OFFSET counts the new row on the next page: the last row of the first
+	// page comes again
+	offset, err := collectWithOffset(context.Background(), service, 5, insert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if countDuplicates(offset) == 0 {
+		t.Errorf("OFFSET pages returned no duplicate, the insert didn't shift them: %v", offset)
+	}
+}
+
+func TestKeysetInsertAfterCursor(t *testing.T) {
+	service, _ := newTestService(t)
+	seedPrices(t, service, 20)
+
+	// A product sorted after the cursor is found on a later page
+	var inserted uint
+	keyset, err := collectWithCursor(context.Background(), service, 5, func() error {
+		product := &Product{Name: "Priciest", Price: 10_000}
+		err := service.Create(context.Background(), product)
+		inserted = product.ID
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := allIDs(t, service); !slices.Equal(keyset, want) {
+		t.Errorf("keyset pages %v\nwant %v", keyset, want)
+	}
+	if keyset[len(keyset)-1] != inserted {
+		t.Errorf("last row = %d, want the inserted row %d", keyset[len(keyset)-1], inserted)
+	}
+}
+
+func TestKeysetInvalidLimit(t *testing.T) {
+	service, _ := newTestService(t)
+	seedPrices(t, service, 3)
+
+	for _, limit := range []int{0, -1} {
+		products, next, err := service.ListAfter(context.Background(), "", limit)
+		if !errors.Is(err, ErrInvalidLimit) {
+			t.Errorf("limit %d: err = %v, want ErrInvalidLimit", limit, err)
+		}
+		if products != nil || next != "" {
+			t.Errorf("limit %d: got %d products and cursor %q", limit, len(products), next)
+		}
+	}
+}
@@ -3,6 +3,7 @@ module golang-training/module-14/exercise-3
 go 1.25
 
 require (
+	golang-training/module-09/exercise-2 v0.0.0
 	gorm.io/driver/sqlite v1.6.0
 	gorm.io/gorm v1.31.1
 )
@@ -13,3 +14,5 @@ require (
 	github.com/mattn/go-sqlite3 v1.14.22 // indirect
 	golang.org/x/text v0.33.0 // indirect
 )
+
+replace golang-training/module-09/exercise-2 => "../../../09. Packages and Modules/solution/exercise_2"
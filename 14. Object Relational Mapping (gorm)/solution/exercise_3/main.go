@@ -7,6 +7,8 @@ import (
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+
+	"golang-training/module-09/exercise-2/models"
 )
 
 // User model
@@ -34,7 +36,7 @@ type Product struct {
 	ID           uint          `gorm:"primaryKey"`
 	Name         string        `gorm:"size:100;not null"`
 	Description  string        `gorm:"type:text"`
-	Price        float64       `gorm:"type:decimal(10,2);not null"`
+	Price        models.Money  `gorm:"embedded;embeddedPrefix:price_"` // price_amount in cents, price_currency
 	Categories   []Category    `gorm:"many2many:product_categories;"`
 	OrderDetails []OrderDetail // Has Many relationship
 	CreatedAt    time.Time
@@ -58,7 +60,7 @@ type Order struct {
 	User         User          // Belongs To relationship
 	OrderDetails []OrderDetail // Has Many relationship
 	Status       string        `gorm:"size:20;default:'pending'"`
-	TotalAmount  float64       `gorm:"type:decimal(10,2);not null"`
+	TotalAmount  models.Money  `gorm:"embedded;embeddedPrefix:total_"`
 	OrderDate    time.Time     `gorm:"not null;default:CURRENT_TIMESTAMP"`
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
@@ -66,13 +68,13 @@ type Order struct {
 
 // OrderDetail model - belongs to Order and Product
 type OrderDetail struct {
-	ID        uint    `gorm:"primaryKey"`
-	OrderID   uint    `gorm:"index;not null"`
-	Order     Order   // Belongs To relationship
-	ProductID uint    `gorm:"index;not null"`
-	Product   Product // Belongs To relationship
-	Quantity  int     `gorm:"not null"`
-	Price     float64 `gorm:"type:decimal(10,2);not null"`
+	ID        uint         `gorm:"primaryKey"`
+	OrderID   uint         `gorm:"index;not null"`
+	Order     Order        // Belongs To relationship
+	ProductID uint         `gorm:"index;not null"`
+	Product   Product      // Belongs To relationship
+	Quantity  int          `gorm:"not null"`
+	Price     models.Money `gorm:"embedded;embeddedPrefix:price_"` // Unit price when ordered
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -118,22 +120,22 @@ func (d *Database) SeedDatabase() error {
 		{
 			Name:        "Smartphone",
 			Description: "Latest smartphone model",
-			Price:       799.99,
+			Price:       models.MustParseMoney("799.99", "USD"),
 		},
 		{
 			Name:        "Laptop",
 			Description: "Professional laptop",
-			Price:       1299.99,
+			Price:       models.MustParseMoney("1299.99", "USD"),
 		},
 		{
 			Name:        "T-Shirt",
 			Description: "Cotton T-shirt",
-			Price:       19.99,
+			Price:       models.MustParseMoney("19.99", "USD"),
 		},
 		{
 			Name:        "Programming Guide",
 			Description: "Comprehensive programming book",
-			Price:       49.99,
+			Price:       models.MustParseMoney("49.99", "USD"),
 		},
 	}
 
@@ -172,11 +174,18 @@ func (d *Database) SeedDatabase() error {
 		return err
 	}
 
-	// Create an order for the user
+	// Create an order for the user, its total is the sum of the items
+	total := models.NewMoney(0, "USD")
+	for _, product := range products[:3] {
+		var err error
+		if total, err = total.Add(product.Price); err != nil {
+			return err
+		}
+	}
 	order := Order{
 		UserID:      user.ID,
 		Status:      "completed",
-		TotalAmount: 2119.97, // Sum of all order items
+		TotalAmount: total,
 		OrderDate:   time.Now(),
 	}
 
@@ -233,10 +242,10 @@ func (d *Database) QueryExamples() {
 	}
 	fmt.Printf("User: %s has %d orders\n", user.Name, len(user.Orders))
 	for i, order := range user.Orders {
-		fmt.Printf("Order #%d - Total: $%.2f, Status: %s, Items: %d\n",
+		fmt.Printf("Order #%d - Total: %s, Status: %s, Items: %d\n",
 			i+1, order.TotalAmount, order.Status, len(order.OrderDetails))
 		for j, detail := range order.OrderDetails {
-			fmt.Printf("  Item %d: %s, Qty: %d, Price: $%.2f\n",
+			fmt.Printf("  Item %d: %s, Qty: %d, Price: %s\n",
 				j+1, detail.Product.Name, detail.Quantity, detail.Price)
 		}
 	}
@@ -249,7 +258,7 @@ func (d *Database) QueryExamples() {
 		log.Fatalf("Failed to fetch products: %v", err)
 	}
 	for _, product := range products {
-		fmt.Printf("Product: %s, Price: $%.2f, Categories: ", product.Name, product.Price)
+		fmt.Printf("Product: %s, Price: %s, Categories: ", product.Name, product.Price)
 		for i, category := range product.Categories {
 			if i > 0 {
 				fmt.Print(", ")
@@ -265,21 +274,21 @@ func (d *Database) QueryExamples() {
 	type OrderWithUser struct {
 		OrderID     uint
 		Status      string
-		TotalAmount float64
+		TotalAmount models.Money `gorm:"embedded;embeddedPrefix:total_"`
 		UserName    string
 		UserEmail   string
 	}
 
 	var ordersWithUsers []OrderWithUser
 	if err := d.db.Table("orders").
-		Select("orders.id as order_id, orders.status, orders.total_amount, users.name as user_name, users.email as user_email").
+		Select("orders.id as order_id, orders.status, orders.total_amount, orders.total_currency, users.name as user_name, users.email as user_email").
 		Joins("left join users on users.id = orders.user_id").
 		Scan(&ordersWithUsers).Error; err != nil {
 		log.Fatalf("Failed to execute join query: %v", err)
 	}
 
 	for _, o := range ordersWithUsers {
-		fmt.Printf("Order #%d - Status: %s, Total: $%.2f, Customer: %s (%s)\n",
+		fmt.Printf("Order #%d - Status: %s, Total: %s, Customer: %s (%s)\n",
 			o.OrderID, o.Status, o.TotalAmount, o.UserName, o.UserEmail)
 	}
 	fmt.Println()
@@ -295,8 +304,26 @@ func (d *Database) QueryExamples() {
 	}
 
 	for _, p := range electronicsProducts {
-		fmt.Printf("Product: %s, Price: $%.2f\n", p.Name, p.Price)
+		fmt.Printf("Product: %s, Price: %s\n", p.Name, p.Price)
+	}
+
+	// The amounts are integer columns: they sort, sum and filter as numbers,
+	// where text such as "19.99 USD" would sort after "1299.99 USD"
+	fmt.Println("\nProducts from $20, most expensive first:")
+	var byPrice []Product
+	if err := d.db.Where("price_currency = ? AND price_amount >= ?", "USD", 2000).
+		Order("price_amount DESC").Find(&byPrice).Error; err != nil {
+		log.Fatalf("Failed to sort products by price: %v", err)
+	}
+	for _, p := range byPrice {
+		fmt.Printf("Product: %s, Price: %s\n", p.Name, p.Price)
+	}
+	var revenue struct{ Cents int64 }
+	if err := d.db.Model(&Order{}).Select("COALESCE(SUM(total_amount), 0) AS cents").
+		Where("total_currency = ?", "USD").Scan(&revenue).Error; err != nil {
+		log.Fatalf("Failed to sum the orders: %v", err)
 	}
+	fmt.Printf("Revenue: %s\n", models.NewMoney(revenue.Cents, "USD"))
 
 	// 6. Transaction example - Create order with rollback on error
 	fmt.Println("\n6. Transaction Example (Commit / Rollback):")
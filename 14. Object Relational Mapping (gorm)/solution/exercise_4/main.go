@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"golang-training/module-14/seed"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Product model
+type Product struct {
+	ID          uint    `gorm:"primaryKey"`
+	Name        string  `gorm:"size:100;not null"`
+	Description string  `gorm:"type:text"`
+	Price       float64 `gorm:"type:decimal(10,2);not null"`
+	Stock       int     `gorm:"default:0"`
+	Category    string  `gorm:"size:50;index"`
+	IsActive    bool    `gorm:"default:true"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Number of runs per benchmark query
+const benchmarkRuns = 20
+
+// defaultProfile seeds 100k products, unless the seed subcommand already
+// filled the database
+const defaultProfile = "large"
+
+// seedProducts fills the products table with the generated catalog of the
+// profile. The catalog is the same on every run, so every run benchmarks
+// the same rows.
+func seedProducts(db *gorm.DB, profile seed.Profile) error {
+	result, err := seed.Insert(context.Background(), db, "products", profile, profile.Products,
+		func(i int, f *seed.Faker) Product {
+			p := seed.NewProduct(i, f)
+			return Product{
+				Name:        p.Name,
+				Description: p.Description,
+				Price:       p.PriceFloat(),
+				Stock:       p.Stock,
+				Category:    p.Category,
+				IsActive:    true,
+			}
+		})
+	if err != nil {
+		return err
+	}
+	fmt.Println(result)
+	return nil
+}
+
+// openDatabase connects to Postgres when POSTGRES_DSN is set and to SQLite otherwise
+func openDatabase() (*gorm.DB, SearchRepository, error) {
+	config := &gorm.Config{
+		// Silent, seeding 100k rows would otherwise log every batch
+		Logger: logger.Default.LogMode(logger.Silent),
+	}
+
+	if dsn := os.Getenv("POSTGRES_DSN"); dsn != "" {
+		db, err := gorm.Open(postgres.Open(dsn), config)
+		if err != nil {
+			return nil, nil, err
+		}
+		return db, NewPostgresSearchRepository(db), nil
+	}
+
+	db, err := gorm.Open(sqlite.Open("search.db"), config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, NewFTS5SearchRepository(db), nil
+}
+
+// printResults shows the ranked results with their highlighted excerpt
+func printResults(repo SearchRepository, query string) {
+	results, err := repo.Search(query, 5)
+	if err != nil {
+		log.Printf("%s search failed: %v", repo.Name(), err)
+		return
+	}
+
+	fmt.Printf("\n%s results for %q:\n", repo.Name(), query)
+	for i, result := range results {
+		fmt.Printf("%d. %s (rank: %.3f)\n   %s\n", i+1, result.Name, result.Rank, result.Highlight)
+	}
+}
+
+// benchmark returns the average duration of a search
+func benchmark(repo SearchRepository, query string) (time.Duration, int, error) {
+	var matches int
+	start := time.Now()
+	for range benchmarkRuns {
+		results, err := repo.Search(query, 20)
+		if err != nil {
+			return 0, 0, err
+		}
+		matches = len(results)
+	}
+	return time.Since(start) / benchmarkRuns, matches, nil
+}
+
+func main() {
+	// go run . seed -profile small replaces the products, then exits
+	seedOnly := len(os.Args) > 1 && os.Args[1] == "seed"
+	profile, err := seed.Lookup(defaultProfile)
+	if seedOnly {
+		profile, err = seed.Command(os.Args[2:], defaultProfile)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, fullText, err := openDatabase()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	// Auto Migrate the schema
+	if err := db.AutoMigrate(&Product{}); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	repositories := []SearchRepository{NewLikeSearchRepository(db), fullText}
+	for _, repo := range repositories {
+		if err := repo.Setup(); err != nil {
+			log.Fatalf("Failed to set up %s: %v", repo.Name(), err)
+		}
+	}
+
+	var count int64
+	if err := db.Model(&Product{}).Count(&count).Error; err != nil {
+		log.Fatalf("Failed to count products: %v", err)
+	}
+	if seedOnly || count == 0 {
+		if err := seedProducts(db, profile); err != nil {
+			log.Fatalf("Failed to seed products: %v", err)
+		}
+	}
+	if seedOnly {
+		return
+	}
+
+	// Compare the results: LIKE needs the exact phrase and has no notion of relevance
+	fmt.Println("\n--- Search Results ---")
+	for _, repo := range repositories {
+		printResults(repo, "wireless headphones")
+	}
+	printResults(fullText, "charging speaker")
+
+	// Compare the speed on queries matching many, few and no rows. LIKE stops at
+	// the first 20 matches in table order, so common terms look cheap, while a
+	// rare term forces a full scan. The full-text index ranks every match instead.
+	fmt.Println("\n--- Benchmark ---")
+	queries := []string{"keyboard", "noise cancelling", "waterproof camera", "titanium"}
+
+	fmt.Printf("%-20s %-20s %8s %12s\n", "Repository", "Query", "Results", "Avg time")
+	for _, query := range queries {
+		for _, repo := range repositories {
+			elapsed, matches, err := benchmark(repo, query)
+			if err != nil {
+				log.Printf("%s benchmark failed: %v", repo.Name(), err)
+				continue
+			}
+			fmt.Printf("%-20s %-20s %8d %12v\n", repo.Name(), query, matches, elapsed.Round(time.Microsecond))
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// PostgresSearchRepository uses a generated tsvector column for full-text search
+// and falls back to trigram similarity on the name to tolerate typos
+type PostgresSearchRepository struct {
+	db *gorm.DB
+}
+
+// NewPostgresSearchRepository creates a tsvector based search repository
+func NewPostgresSearchRepository(db *gorm.DB) *PostgresSearchRepository {
+	return &PostgresSearchRepository{db: db}
+}
+
+func (r *PostgresSearchRepository) Name() string {
+	return "Postgres tsvector"
+}
+
+// Setup adds the weighted search column and the GIN indexes
+func (r *PostgresSearchRepository) Setup() error {
+	statements := []string{
+		// The name weighs more than the description when ranking
+		`ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (
+				setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+				setweight(to_tsvector('english', coalesce(description, '')), 'B')
+			) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector)`,
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (name gin_trgm_ops)`,
+	}
+
+	for _, statement := range statements {
+		if err := r.db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to set up search: %w", err)
+		}
+	}
+	return nil
+}
+
+// Search runs a ranked full-text query, and a trigram query when nothing matches
+func (r *PostgresSearchRepository) Search(query string, limit int) ([]SearchResult, error) {
+	var results []SearchResult
+	err := r.db.Raw(`
+		SELECT p.*,
+			ts_rank(p.search_vector, q) AS rank,
+			ts_headline('english', p.description, q,
+				'StartSel=' || ? || ', StopSel=' || ? || ', MaxWords=15, MinWords=5') AS highlight
+		FROM products p, websearch_to_tsquery('english', ?) q
+		WHERE p.search_vector @@ q
+		ORDER BY rank DESC
+		LIMIT ?`,
+		highlightStart, highlightEnd, query, limit,
+	).Scan(&results).Error
+	if err != nil || len(results) > 0 {
+		return results, err
+	}
+
+	// No full-text match, the query might contain a typo
+	err = r.db.Raw(`
+		SELECT p.*, similarity(p.name, ?) AS rank, p.description AS highlight
+		FROM products p
+		WHERE p.name % ?
+		ORDER BY rank DESC
+		LIMIT ?`,
+		query, query, limit,
+	).Scan(&results).Error
+	return results, err
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Markers wrapped around matched terms in highlighted text
+const (
+	highlightStart = "["
+	highlightEnd   = "]"
+)
+
+// SearchResult is a product matched by a search, with its relevance
+type SearchResult struct {
+	Product
+	Rank      float64 // Higher is more relevant
+	Highlight string  // Description excerpt with the matched terms marked
+}
+
+// SearchRepository searches products using a specific database feature
+type SearchRepository interface {
+	Name() string
+	Setup() error
+	Search(query string, limit int) ([]SearchResult, error)
+}
+
+// LikeSearchRepository is the original LIKE based implementation
+type LikeSearchRepository struct {
+	db *gorm.DB
+}
+
+// NewLikeSearchRepository creates a LIKE based search repository
+func NewLikeSearchRepository(db *gorm.DB) *LikeSearchRepository {
+	return &LikeSearchRepository{db: db}
+}
+
+func (r *LikeSearchRepository) Name() string {
+	return "LIKE"
+}
+
+// Setup does nothing, LIKE with a leading wildcard can't use an index
+func (r *LikeSearchRepository) Setup() error {
+	return nil
+}
+
+// Search matches the query as a substring of the name or description, without ranking
+func (r *LikeSearchRepository) Search(query string, limit int) ([]SearchResult, error) {
+	var products []Product
+	pattern := "%" + query + "%"
+	err := r.db.Where("name LIKE ? OR description LIKE ?", pattern, pattern).
+		Limit(limit).
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, len(products))
+	for i, product := range products {
+		results[i] = SearchResult{
+			Product:   product,
+			Highlight: highlightTerms(product.Description, strings.Fields(query)),
+		}
+	}
+	return results, nil
+}
+
+// highlightTerms marks every case-insensitive occurrence of the terms in the text
+func highlightTerms(text string, terms []string) string {
+	if len(terms) == 0 {
+		return text
+	}
+
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	re := regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
+	return re.ReplaceAllString(text, highlightStart+"$0"+highlightEnd)
+}
+
+// FTS5SearchRepository uses an SQLite FTS5 virtual table kept in sync with triggers.
+// The SQLite driver only includes FTS5 when built with -tags sqlite_fts5.
+type FTS5SearchRepository struct {
+	db *gorm.DB
+}
+
+// NewFTS5SearchRepository creates an FTS5 based search repository
+func NewFTS5SearchRepository(db *gorm.DB) *FTS5SearchRepository {
+	return &FTS5SearchRepository{db: db}
+}
+
+func (r *FTS5SearchRepository) Name() string {
+	return "SQLite FTS5"
+}
+
+// Setup creates the index table, the sync triggers and rebuilds the index from the products
+func (r *FTS5SearchRepository) Setup() error {
+	statements := []string{
+		// External content table: the text is stored once, in products
+		`CREATE VIRTUAL TABLE IF NOT EXISTS products_fts USING fts5(
+			name, description,
+			content='products', content_rowid='id',
+			tokenize='porter unicode61'
+		)`,
+		`CREATE TRIGGER IF NOT EXISTS products_fts_insert AFTER INSERT ON products BEGIN
+			INSERT INTO products_fts(rowid, name, description) VALUES (new.id, new.name, new.description);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS products_fts_delete AFTER DELETE ON products BEGIN
+			INSERT INTO products_fts(products_fts, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS products_fts_update AFTER UPDATE ON products BEGIN
+			INSERT INTO products_fts(products_fts, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+			INSERT INTO products_fts(rowid, name, description) VALUES (new.id, new.name, new.description);
+		END`,
+		// Index rows that existed before the triggers were created
+		`INSERT INTO products_fts(products_fts) VALUES ('rebuild')`,
+	}
+
+	for _, statement := range statements {
+		if err := r.db.Exec(statement).Error; err != nil {
+			if strings.Contains(err.Error(), "no such module: fts5") {
+				return fmt.Errorf("FTS5 is not available, run with -tags sqlite_fts5: %w", err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Search matches all terms (prefix match on each) and orders by BM25 relevance
+func (r *FTS5SearchRepository) Search(query string, limit int) ([]SearchResult, error) {
+	match := toFTS5Query(query)
+	if match == "" {
+		return nil, nil
+	}
+
+	var results []SearchResult
+	err := r.db.Raw(`
+		SELECT p.*,
+			-bm25(products_fts, 10.0, 1.0) AS rank,
+			snippet(products_fts, 1, ?, ?, '...', 12) AS highlight
+		FROM products_fts
+		JOIN products p ON p.id = products_fts.rowid
+		WHERE products_fts MATCH ?
+		ORDER BY bm25(products_fts, 10.0, 1.0)
+		LIMIT ?`,
+		highlightStart, highlightEnd, match, limit,
+	).Scan(&results).Error
+	return results, err
+}
+
+// toFTS5Query quotes every term so user input can't break the MATCH syntax
+func toFTS5Query(query string) string {
+	var terms []string
+	for _, term := range strings.Fields(query) {
+		term = strings.ReplaceAll(term, `"`, `""`)
+		terms = append(terms, `"`+term+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
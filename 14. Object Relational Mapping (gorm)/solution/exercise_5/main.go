@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// Product model, identified by its SKU for imports
+type Product struct {
+	ID        uint    `gorm:"primaryKey"`
+	SKU       string  `gorm:"size:20;not null;uniqueIndex"`
+	Name      string  `gorm:"size:100;not null"`
+	Price     float64 `gorm:"type:decimal(10,2);not null"`
+	Stock     int     `gorm:"default:0"`
+	Category  string  `gorm:"size:50;index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Number of products used by every operation, and rows per INSERT statement
+const (
+	productCount = 10_000
+	batchSize    = 500
+)
+
+var categories = []string{"Electronics", "Home Appliances", "Outdoor", "Office", "Fashion"}
+
+// Timing holds the duration of the same operation done both ways
+type Timing struct {
+	Operation string
+	RowByRow  time.Duration
+	Batch     time.Duration
+}
+
+// generateProducts creates products with SKUs starting at the given number
+func generateProducts(rng *rand.Rand, firstSKU, n int) []Product {
+	products := make([]Product, n)
+	for i := range products {
+		products[i] = Product{
+			SKU:      fmt.Sprintf("SKU-%06d", firstSKU+i),
+			Name:     fmt.Sprintf("Product %d", firstSKU+i),
+			Price:    float64(rng.IntN(50000)) / 100,
+			Stock:    rng.IntN(200),
+			Category: categories[rng.IntN(len(categories))],
+		}
+	}
+	return products
+}
+
+// generateFeed simulates a supplier price list: half the SKUs exist, half are new
+func generateFeed(rng *rand.Rand) []Product {
+	return generateProducts(rng, productCount/2, productCount)
+}
+
+// measure runs the function and returns how long it took
+func measure(name string, fn func() error) time.Duration {
+	start := time.Now()
+	if err := fn(); err != nil {
+		log.Fatalf("%s failed: %v", name, err)
+	}
+	elapsed := time.Since(start)
+	fmt.Printf("%-35s %v\n", name, elapsed.Round(time.Millisecond))
+	return elapsed
+}
+
+// clearProducts deletes every product
+func clearProducts(db *gorm.DB) error {
+	return db.Exec("DELETE FROM products").Error
+}
+
+// resetProducts empties the table and loads the initial catalog
+func resetProducts(db *gorm.DB, products []Product) error {
+	if err := clearProducts(db); err != nil {
+		return err
+	}
+	return db.CreateInBatches(cloneProducts(products), batchSize).Error
+}
+
+// cloneProducts copies the products, since Create fills in the IDs of the slice it receives
+func cloneProducts(products []Product) []Product {
+	return append([]Product(nil), products...)
+}
+
+// insertRowByRow creates every product with its own INSERT
+func insertRowByRow(db *gorm.DB, products []Product) error {
+	for _, product := range cloneProducts(products) {
+		if err := db.Create(&product).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upsertRowByRow looks every SKU up, then updates or creates it
+func upsertRowByRow(db *gorm.DB, feed []Product) error {
+	for _, incoming := range feed {
+		var existing Product
+		err := db.Where("sku = ?", incoming.SKU).First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := db.Create(&incoming).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			existing.Price = incoming.Price
+			existing.Stock = incoming.Stock
+			if err := db.Save(&existing).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// upsertBatch inserts new SKUs and updates price and stock of existing ones in batches
+func upsertBatch(db *gorm.DB, feed []Product) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "sku"}},
+		DoUpdates: clause.AssignmentColumns([]string{"price", "stock", "updated_at"}),
+	}).CreateInBatches(cloneProducts(feed), batchSize).Error
+}
+
+// raisePricesRowByRow loads every product of the category and saves them one at a time
+func raisePricesRowByRow(db *gorm.DB, category string, factor float64) error {
+	var products []Product
+	if err := db.Where("category = ?", category).Find(&products).Error; err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		product.Price *= factor
+		if err := db.Save(&product).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// raisePricesBatch updates every product of the category with a single UPDATE
+func raisePricesBatch(db *gorm.DB, category string, factor float64) error {
+	return db.Model(&Product{}).
+		Where("category = ?", category).
+		Update("price", gorm.Expr("price * ?", factor)).Error
+}
+
+// printSummary compares the timings of both approaches
+func printSummary(timings []Timing) {
+	fmt.Println("\n--- Summary ---")
+	fmt.Printf("%-15s %12s %12s %10s\n", "Operation", "Row by row", "Batch", "Speedup")
+	for _, t := range timings {
+		fmt.Printf("%-15s %12v %12v %9.1fx\n", t.Operation,
+			t.RowByRow.Round(time.Millisecond), t.Batch.Round(time.Millisecond),
+			float64(t.RowByRow)/float64(t.Batch))
+	}
+}
+
+func main() {
+	// Start from an empty database so the timings are comparable between runs
+	os.Remove("batch.db")
+
+	db, err := gorm.Open(sqlite.Open("batch.db"), &gorm.Config{
+		// Silent, row by row operations would otherwise log thousands of statements
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	// Auto Migrate the schema
+	if err := db.AutoMigrate(&Product{}); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	catalog := generateProducts(rng, 0, productCount)
+	feed := generateFeed(rng)
+	var timings []Timing
+
+	fmt.Println("--- Insert ---")
+	insert := Timing{Operation: "Insert"}
+	insert.RowByRow = measure("Create row by row", func() error {
+		return insertRowByRow(db, catalog)
+	})
+	if err := clearProducts(db); err != nil {
+		log.Fatalf("Failed to clear products: %v", err)
+	}
+	insert.Batch = measure("CreateInBatches", func() error {
+		return db.CreateInBatches(cloneProducts(catalog), batchSize).Error
+	})
+	timings = append(timings, insert)
+
+	fmt.Println("\n--- Upsert by SKU ---")
+	upsert := Timing{Operation: "Upsert"}
+	upsert.RowByRow = measure("Find then Save or Create", func() error {
+		return upsertRowByRow(db, feed)
+	})
+	if err := resetProducts(db, catalog); err != nil {
+		log.Fatalf("Failed to reset products: %v", err)
+	}
+	upsert.Batch = measure("OnConflict with CreateInBatches", func() error {
+		return upsertBatch(db, feed)
+	})
+	timings = append(timings, upsert)
+
+	// Half of the feed updated existing rows, the other half was inserted
+	var total int64
+	db.Model(&Product{}).Count(&total)
+	var sample Product
+	db.Where("sku = ?", feed[0].SKU).First(&sample)
+	fmt.Printf("Products after upsert: %d, %s price is now $%.2f (feed: $%.2f)\n",
+		total, sample.SKU, sample.Price, feed[0].Price)
+
+	fmt.Println("\n--- Bulk Price Update ---")
+	bulk := Timing{Operation: "Price update"}
+	bulk.RowByRow = measure("Find then Save each row", func() error {
+		return raisePricesRowByRow(db, "Electronics", 1.1)
+	})
+	bulk.Batch = measure("Single UPDATE statement", func() error {
+		return raisePricesBatch(db, "Electronics", 1.1)
+	})
+	timings = append(timings, bulk)
+
+	printSummary(timings)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
+)
+
+// Product model
+type Product struct {
+	ID        uint    `gorm:"primaryKey"`
+	Name      string  `gorm:"size:100;not null"`
+	Price     float64 `gorm:"type:decimal(10,2);not null"`
+	Stock     int     `gorm:"default:0"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Database files standing in for a primary server and its read replica
+const (
+	primaryFile = "primary.db"
+	replicaFile = "replica.db"
+)
+
+// newConfig creates the GORM configuration. Every connection needs its own,
+// Open stores the connection pool and callbacks in it.
+func newConfig() *gorm.Config {
+	return &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+}
+
+// openDatabases returns the application connection, which routes queries with
+// dbresolver, and a direct replica connection used only to simulate replication
+func openDatabases() (*gorm.DB, *gorm.DB, error) {
+	// The dialector passed to Open is the primary, it receives all writes
+	db, err := gorm.Open(sqlite.Open(primaryFile), newConfig())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Reads are sent to the replicas, picked at random when there are several
+	err = db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{sqlite.Open(replicaFile)},
+		Policy:   dbresolver.RandomPolicy{},
+	}))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	replica, err := gorm.Open(sqlite.Open(replicaFile), newConfig())
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, replica, nil
+}
+
+// replicate copies the primary rows to the replica. A real replica receives
+// changes asynchronously, so until this runs, reads see the old data.
+func replicate(db, replica *gorm.DB) error {
+	var products []Product
+	if err := db.Clauses(dbresolver.Write).Find(&products).Error; err != nil {
+		return err
+	}
+	if len(products) == 0 {
+		return nil
+	}
+
+	return replica.Clauses(clause.OnConflict{UpdateAll: true}).Create(&products).Error
+}
+
+// printProducts lists products, labelled with where the query was routed
+func printProducts(label string, products []Product) {
+	fmt.Printf("%s: %d products\n", label, len(products))
+	for _, p := range products {
+		fmt.Printf("  ID: %d, Name: %s, Price: $%.2f, Stock: %d\n", p.ID, p.Name, p.Price, p.Stock)
+	}
+}
+
+func main() {
+	// Start with empty databases so the replication lag is visible
+	os.Remove(primaryFile)
+	os.Remove(replicaFile)
+
+	db, replica, err := openDatabases()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	// Migrations are writes and only run on the primary, the replica gets its schema separately
+	if err := db.AutoMigrate(&Product{}); err != nil {
+		log.Fatalf("Failed to migrate primary: %v", err)
+	}
+	if err := replica.AutoMigrate(&Product{}); err != nil {
+		log.Fatalf("Failed to migrate replica: %v", err)
+	}
+
+	fmt.Println("--- Writes Go to the Primary ---")
+	products := []Product{
+		{Name: "Laptop", Price: 1299.99, Stock: 10},
+		{Name: "Smartphone", Price: 799.99, Stock: 15},
+		{Name: "Coffee Maker", Price: 89.99, Stock: 5},
+	}
+	if err := db.Create(&products).Error; err != nil {
+		log.Fatalf("Failed to create products: %v", err)
+	}
+	fmt.Printf("Created %d products\n", len(products))
+
+	fmt.Println("\n--- Reads Go to the Replica ---")
+	var fromReplica []Product
+	db.Find(&fromReplica)
+	printProducts("Replica (not replicated yet)", fromReplica)
+
+	// Read-your-writes: force the query to the primary
+	var fromPrimary []Product
+	db.Clauses(dbresolver.Write).Find(&fromPrimary)
+	printProducts("Primary (Clauses(dbresolver.Write))", fromPrimary)
+
+	fmt.Println("\n--- After Replication ---")
+	if err := replicate(db, replica); err != nil {
+		log.Fatalf("Failed to replicate: %v", err)
+	}
+	db.Find(&fromReplica)
+	printProducts("Replica", fromReplica)
+
+	fmt.Println("\n--- Stale Reads After an Update ---")
+	if err := db.Model(&Product{}).Where("name = ?", "Laptop").Update("price", 1199.99).Error; err != nil {
+		log.Fatalf("Failed to update product: %v", err)
+	}
+
+	var laptop Product
+	db.Where("name = ?", "Laptop").First(&laptop)
+	fmt.Printf("Replica price: $%.2f (stale)\n", laptop.Price)
+	db.Clauses(dbresolver.Write).Where("name = ?", "Laptop").First(&laptop)
+	fmt.Printf("Primary price: $%.2f\n", laptop.Price)
+
+	fmt.Println("\n--- Transactions Use the Primary ---")
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Every statement in a transaction, reads included, runs on the primary
+		var product Product
+		if err := tx.Where("name = ?", "Laptop").First(&product).Error; err != nil {
+			return err
+		}
+		fmt.Printf("Price inside the transaction: $%.2f\n", product.Price)
+
+		return tx.Model(&product).Update("stock", gorm.Expr("stock - ?", 1)).Error
+	})
+	if err != nil {
+		log.Fatalf("Transaction failed: %v", err)
+	}
+
+	// Raw queries are routed by their statement, SELECT goes to the replica
+	var replicaStock, primaryStock int
+	db.Raw("SELECT stock FROM products WHERE name = ?", "Laptop").Scan(&replicaStock)
+	db.Clauses(dbresolver.Write).Raw("SELECT stock FROM products WHERE name = ?", "Laptop").Scan(&primaryStock)
+	fmt.Printf("Laptop stock: %d on the replica, %d on the primary\n", replicaStock, primaryStock)
+
+	if err := replicate(db, replica); err != nil {
+		log.Fatalf("Failed to replicate: %v", err)
+	}
+	db.Where("name = ?", "Laptop").First(&laptop)
+	fmt.Printf("After replication the replica has price $%.2f and stock %d\n", laptop.Price, laptop.Stock)
+}
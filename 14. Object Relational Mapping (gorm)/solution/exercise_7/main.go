@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TodoRequest is the body accepted when creating or replacing a todo
+type TodoRequest struct {
+	Title     string `json:"title" binding:"required,min=3,max=200"`
+	Completed bool   `json:"completed"`
+}
+
+// TodoHandler serves the todo API on top of a repository
+type TodoHandler struct {
+	repo TodoRepository
+}
+
+// NewTodoHandler creates a handler using the provided repository
+func NewTodoHandler(repo TodoRepository) *TodoHandler {
+	return &TodoHandler{repo: repo}
+}
+
+// validationMessage describes a failed validation rule in plain words
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return fmt.Sprintf("must be at least %s characters", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s characters", fe.Param())
+	default:
+		return fmt.Sprintf("failed the %s rule", fe.Tag())
+	}
+}
+
+// bindTodo decodes and validates the request body, writing a 400 response when it is invalid
+func bindTodo(c *gin.Context) (*TodoRequest, bool) {
+	var req TodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		var errs validator.ValidationErrors
+		if !errors.As(err, &errs) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON body"})
+			return nil, false
+		}
+
+		fields := gin.H{}
+		for _, fe := range errs {
+			fields[strings.ToLower(fe.Field())] = validationMessage(fe)
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "fields": fields})
+		return nil, false
+	}
+
+	// Validation runs before trimming, so a title of only spaces is checked here
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "fields": gin.H{"title": "must not be blank"}})
+		return nil, false
+	}
+	return &req, true
+}
+
+// parseID reads the todo ID from the URL, writing a 400 response when it is invalid
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid todo ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondError maps repository errors to HTTP responses
+func respondError(c *gin.Context, err error) {
+	if errors.Is(err, ErrTodoNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Todo not found"})
+		return
+	}
+	log.Printf("Todo repository error: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+}
+
+// List returns all todos, filtered with ?completed=true|false
+func (h *TodoHandler) List(c *gin.Context) {
+	var completed *bool
+	if value := c.Query("completed"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "completed must be true or false"})
+			return
+		}
+		completed = &parsed
+	}
+
+	todos, err := h.repo.FindAll(completed)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, todos)
+}
+
+// Get returns a single todo
+func (h *TodoHandler) Get(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	todo, err := h.repo.FindByID(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, todo)
+}
+
+// Create adds a new todo
+func (h *TodoHandler) Create(c *gin.Context) {
+	req, ok := bindTodo(c)
+	if !ok {
+		return
+	}
+
+	todo := Todo{Title: req.Title, Completed: req.Completed}
+	if err := h.repo.Create(&todo); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, todo)
+}
+
+// Update replaces the title and completion state of a todo
+func (h *TodoHandler) Update(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+	req, ok := bindTodo(c)
+	if !ok {
+		return
+	}
+
+	todo := Todo{ID: id, Title: req.Title, Completed: req.Completed}
+	if err := h.repo.Update(&todo); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, todo)
+}
+
+// Delete removes a todo
+func (h *TodoHandler) Delete(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.Delete(id); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Todo deleted"})
+}
+
+// ToggleAll completes all todos, or reopens them when all are completed
+func (h *TodoHandler) ToggleAll(c *gin.Context) {
+	todos, err := h.repo.ToggleAll()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, todos)
+}
+
+// setupRouter registers the todo routes
+func setupRouter(h *TodoHandler) *gin.Engine {
+	r := gin.Default()
+
+	api := r.Group("/api/v1")
+	{
+		api.GET("/todos", h.List)
+		api.GET("/todos/:id", h.Get)
+		api.POST("/todos", h.Create)
+		api.PUT("/todos/toggle-all", h.ToggleAll)
+		api.PUT("/todos/:id", h.Update)
+		api.DELETE("/todos/:id", h.Delete)
+	}
+	return r
+}
+
+func main() {
+	db, err := gorm.Open(sqlite.Open("todos.db"), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	// Auto Migrate the schema
+	if err := db.AutoMigrate(&Todo{}); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	repo := NewGormTodoRepository(db)
+	if err := SeedTodos(repo); err != nil {
+		log.Fatalf("Failed to seed todos: %v", err)
+	}
+
+	r := setupRouter(NewTodoHandler(repo))
+	if err := r.Run(":8080"); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrTodoNotFound is returned when no todo has the requested ID
+var ErrTodoNotFound = errors.New("todo not found")
+
+// Todo represents a todo item
+type Todo struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Title     string    `json:"title" gorm:"size:200;not null"`
+	Completed bool      `json:"completed" gorm:"not null;default:false;index"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TodoRepository persists todos, so the handlers don't depend on GORM
+type TodoRepository interface {
+	FindAll(completed *bool) ([]Todo, error)
+	FindByID(id uint) (*Todo, error)
+	Create(todo *Todo) error
+	Update(todo *Todo) error
+	Delete(id uint) error
+	ToggleAll() ([]Todo, error)
+}
+
+// GormTodoRepository implements TodoRepository with GORM
+type GormTodoRepository struct {
+	db *gorm.DB
+}
+
+// NewGormTodoRepository creates a repository using the provided database connection
+func NewGormTodoRepository(db *gorm.DB) *GormTodoRepository {
+	return &GormTodoRepository{db: db}
+}
+
+// FindAll retrieves all todos, optionally only the completed or open ones
+func (r *GormTodoRepository) FindAll(completed *bool) ([]Todo, error) {
+	todos := []Todo{}
+	query := r.db.Order("id")
+	if completed != nil {
+		query = query.Where("completed = ?", *completed)
+	}
+	err := query.Find(&todos).Error
+	return todos, err
+}
+
+// FindByID retrieves a todo by its ID
+func (r *GormTodoRepository) FindByID(id uint) (*Todo, error) {
+	var todo Todo
+	err := r.db.First(&todo, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTodoNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// Create adds a new todo
+func (r *GormTodoRepository) Create(todo *Todo) error {
+	return r.db.Create(todo).Error
+}
+
+// Update saves the title and completion state of an existing todo
+func (r *GormTodoRepository) Update(todo *Todo) error {
+	result := r.db.Model(todo).Select("title", "completed", "updated_at").Updates(todo)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTodoNotFound
+	}
+	return r.db.First(todo, todo.ID).Error
+}
+
+// Delete removes a todo by ID
+func (r *GormTodoRepository) Delete(id uint) error {
+	result := r.db.Delete(&Todo{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTodoNotFound
+	}
+	return nil
+}
+
+// ToggleAll completes every todo, or reopens them all when they are already completed.
+// The check and the update run in one transaction, so a concurrent change can't slip in between.
+func (r *GormTodoRepository) ToggleAll() ([]Todo, error) {
+	todos := []Todo{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var open int64
+		if err := tx.Model(&Todo{}).Where("completed = ?", false).Count(&open).Error; err != nil {
+			return err
+		}
+
+		// Mark everything completed while at least one todo is still open
+		err := tx.Model(&Todo{}).
+			Where("completed = ?", open == 0).
+			Update("completed", open > 0).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Order("id").Find(&todos).Error
+	})
+	return todos, err
+}
+
+// SeedTodos creates the initial todos when the table is empty
+func SeedTodos(repo TodoRepository) error {
+	todos, err := repo.FindAll(nil)
+	if err != nil || len(todos) > 0 {
+		return err
+	}
+
+	for _, title := range []string{"Learn Gin Framework", "Build a RESTful API", "Persist the todos with GORM"} {
+		if err := repo.Create(&Todo{Title: title}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Kinds of constraint violations. Callers test them with errors.Is, whatever
+// the database returned.
+var (
+	ErrDuplicate  = errors.New("duplicate")
+	ErrForeignKey = errors.New("foreign key violation")
+	ErrCheck      = errors.New("check constraint violation")
+	ErrNotNull    = errors.New("not null violation")
+)
+
+// ConstraintError describes a violated constraint. Table and Columns are
+// known for the UNIQUE and NOT NULL constraints, Constraint for the CHECK
+// constraints: SQLite names nothing for a foreign key.
+type ConstraintError struct {
+	Kind       error // ErrDuplicate, ErrForeignKey, ErrCheck or ErrNotNull
+	Table      string
+	Columns    []string
+	Constraint string
+	Err        error // The error of the driver
+}
+
+func (e *ConstraintError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Kind.Error())
+	if e.Table != "" {
+		fmt.Fprintf(&b, " on %s(%s)", e.Table, strings.Join(e.Columns, ", "))
+	}
+	if e.Constraint != "" {
+		fmt.Fprintf(&b, " (%s)", e.Constraint)
+	}
+	return b.String()
+}
+
+// Unwrap returns the kind and the driver error: errors.Is(err, ErrDuplicate)
+// matches, and errors.As still finds the sqlite3.Error
+func (e *ConstraintError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// HasColumns reports whether the violated constraint covers exactly these
+// columns, to tell two unique indexes of a table apart
+func (e *ConstraintError) HasColumns(columns ...string) bool {
+	if len(columns) != len(e.Columns) {
+		return false
+	}
+	for i, column := range columns {
+		if e.Columns[i] != column {
+			return false
+		}
+	}
+	return true
+}
+
+// TranslateError looks for a constraint violation of the driver in the
+// error chain, which GORM and the callers may have wrapped, and returns it
+// as a *ConstraintError. Other errors, nil included, are returned as is.
+func TranslateError(err error) error {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) || sqliteErr.Code != sqlite3.ErrConstraint {
+		return err
+	}
+
+	cerr := &ConstraintError{Err: err}
+	// The message names what failed, after the colon:
+	//
+	//	UNIQUE constraint failed: stock_items.sku, stock_items.warehouse_id
+	//	NOT NULL constraint failed: warehouses.code
+	//	CHECK constraint failed: chk_stock_items_quantity
+	//	FOREIGN KEY constraint failed
+	_, detail, _ := strings.Cut(sqliteErr.Error(), ": ")
+	switch sqliteErr.ExtendedCode {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+		cerr.Kind = ErrDuplicate
+		cerr.Table, cerr.Columns = parseColumns(detail)
+	case sqlite3.ErrConstraintNotNull:
+		cerr.Kind = ErrNotNull
+		cerr.Table, cerr.Columns = parseColumns(detail)
+	case sqlite3.ErrConstraintCheck:
+		cerr.Kind = ErrCheck
+		cerr.Constraint = detail
+	case sqlite3.ErrConstraintForeignKey:
+		cerr.Kind = ErrForeignKey
+	case sqlite3.ErrConstraintTrigger:
+		// ON DELETE RESTRICT is enforced by a trigger: deleting a row still
+		// referenced fails with this code, and the foreign key message
+		if !strings.HasPrefix(sqliteErr.Error(), "FOREIGN KEY") {
+			return err
+		}
+		cerr.Kind = ErrForeignKey
+	default:
+		return err
+	}
+	return cerr
+}
+
+// parseColumns splits "table.a, table.b" into the table and its columns
+func parseColumns(detail string) (string, []string) {
+	var table string
+	var columns []string
+	for _, qualified := range strings.Split(detail, ", ") {
+		t, column, ok := strings.Cut(qualified, ".")
+		if !ok {
+			continue
+		}
+		table = t
+		columns = append(columns, column)
+	}
+	return table, columns
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Warehouse has a unique code
+type Warehouse struct {
+	ID   uint   `gorm:"primaryKey"`
+	Code string `gorm:"size:10;not null;uniqueIndex"`
+	City string `gorm:"size:100;not null"`
+}
+
+// StockItem is the stock of a product in a warehouse. A SKU is stocked once
+// per warehouse: the composite unique index covers both columns, in this
+// order, and also serves the queries by SKU. The composite index on the
+// location finds what is stored in an aisle.
+type StockItem struct {
+	ID          uint      `gorm:"primaryKey"`
+	SKU         string    `gorm:"size:40;not null;uniqueIndex:idx_stock_sku_warehouse,priority:1"`
+	WarehouseID uint      `gorm:"not null;uniqueIndex:idx_stock_sku_warehouse,priority:2"`
+	Warehouse   Warehouse `gorm:"constraint:OnDelete:RESTRICT"`
+	Aisle       string    `gorm:"size:5;index:idx_stock_location,priority:1"`
+	Bin         int       `gorm:"index:idx_stock_location,priority:2"`
+	Quantity    int       `gorm:"not null;check:chk_stock_items_quantity,quantity >= 0"`
+	UpdatedAt   time.Time
+}
+
+// Errors of the stock service, in terms of the business
+var (
+	ErrAlreadyStocked   = errors.New("SKU already stocked in this warehouse")
+	ErrWarehouseExists  = errors.New("warehouse code already used")
+	ErrUnknownWarehouse = errors.New("unknown warehouse")
+	ErrNegativeStock    = errors.New("stock can't go below zero")
+)
+
+// StockService turns the constraint errors into errors callers can act on
+type StockService struct {
+	db *gorm.DB
+}
+
+func (s *StockService) AddWarehouse(w *Warehouse) error {
+	err := TranslateError(s.db.Create(w).Error)
+	if errors.Is(err, ErrDuplicate) {
+		return fmt.Errorf("%w: %s", ErrWarehouseExists, w.Code)
+	}
+	return err
+}
+
+func (s *StockService) AddItem(item *StockItem) error {
+	err := TranslateError(s.db.Create(item).Error)
+	var cerr *ConstraintError
+	switch {
+	case errors.As(err, &cerr) && cerr.Kind == ErrDuplicate && cerr.HasColumns("sku", "warehouse_id"):
+		return fmt.Errorf("%w: %s in warehouse %d", ErrAlreadyStocked, item.SKU, item.WarehouseID)
+	case errors.Is(err, ErrForeignKey):
+		return fmt.Errorf("%w: %d", ErrUnknownWarehouse, item.WarehouseID)
+	}
+	return err
+}
+
+// Move transfers a quantity between two warehouses. The CHECK constraint
+// rejects the withdrawal that would leave a negative stock, which rolls
+// back the transaction.
+func (s *StockService) Move(sku string, from, to uint, quantity int) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&StockItem{}).Where("sku = ? AND warehouse_id = ?", sku, from).
+			Update("quantity", gorm.Expr("quantity - ?", quantity)).Error; err != nil {
+			return fmt.Errorf("withdrawing %s from warehouse %d: %w", sku, from, err)
+		}
+		return tx.Model(&StockItem{}).Where("sku = ? AND warehouse_id = ?", sku, to).
+			Update("quantity", gorm.Expr("quantity + ?", quantity)).Error
+	})
+	// The driver error is wrapped twice, by the transaction and by us:
+	// TranslateError still finds it
+	err = TranslateError(err)
+	if errors.Is(err, ErrCheck) {
+		return fmt.Errorf("%w: moving %d %s from warehouse %d", ErrNegativeStock, quantity, sku, from)
+	}
+	return err
+}
+
+func openDatabase(config *gorm.Config) (*gorm.DB, error) {
+	// SQLite only checks the foreign keys when asked, on each connection
+	return gorm.Open(sqlite.Open("constraints.db?_foreign_keys=on"), config)
+}
+
+// printSchema prints the statements AutoMigrate ran for a table
+func printSchema(db *gorm.DB, table string) {
+	var statements []string
+	err := db.Raw("SELECT sql FROM sqlite_master WHERE tbl_name = ? AND sql IS NOT NULL ORDER BY type DESC, name", table).
+		Scan(&statements).Error
+	if err != nil {
+		log.Fatalf("Failed to read the schema: %v", err)
+	}
+	for _, statement := range statements {
+		fmt.Println(statement)
+	}
+}
+
+func report(label string, err error) {
+	var cerr *ConstraintError
+	fmt.Printf("%-32s %v\n", label+":", err)
+	if errors.As(err, &cerr) {
+		fmt.Printf("%-32s kind=%q table=%q columns=%v constraint=%q\n", "", cerr.Kind, cerr.Table, cerr.Columns, cerr.Constraint)
+	}
+}
+
+func main() {
+	os.Remove("constraints.db")
+	db, err := openDatabase(&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	if err := db.AutoMigrate(&Warehouse{}, &StockItem{}); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	fmt.Println("--- Schema Created From the Tags ---")
+	printSchema(db, "stock_items")
+
+	service := &StockService{db: db}
+	paris := Warehouse{Code: "PAR", City: "Paris"}
+	lyon := Warehouse{Code: "LYS", City: "Lyon"}
+	for _, w := range []*Warehouse{&paris, &lyon} {
+		if err := service.AddWarehouse(w); err != nil {
+			log.Fatalf("Failed to create warehouse: %v", err)
+		}
+	}
+	items := []StockItem{
+		{SKU: "LAPTOP-15", WarehouseID: paris.ID, Aisle: "A", Bin: 1, Quantity: 10},
+		{SKU: "LAPTOP-15", WarehouseID: lyon.ID, Aisle: "C", Bin: 4, Quantity: 2},
+		{SKU: "MOUSE-01", WarehouseID: paris.ID, Aisle: "A", Bin: 2, Quantity: 50},
+	}
+	for i := range items {
+		if err := service.AddItem(&items[i]); err != nil {
+			log.Fatalf("Failed to add item: %v", err)
+		}
+	}
+	fmt.Printf("\nCreated %d warehouses and %d stock items\n", 2, len(items))
+
+	fmt.Println("\n--- What the Driver Returns ---")
+	duplicate := StockItem{SKU: "LAPTOP-15", WarehouseID: paris.ID, Quantity: 1}
+	rawErr := db.Create(&duplicate).Error
+	fmt.Printf("%-32s %v (%T)\n", "Raw error:", rawErr, rawErr)
+
+	// GORM can translate the errors itself, but only into a few sentinel
+	// errors without the columns
+	translating, err := openDatabase(&gorm.Config{Logger: logger.Default.LogMode(logger.Silent), TranslateError: true})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	gormErr := translating.Create(&StockItem{SKU: "LAPTOP-15", WarehouseID: paris.ID, Quantity: 1}).Error
+	fmt.Printf("%-32s %v, is gorm.ErrDuplicatedKey: %v\n", "TranslateError: true:", gormErr, errors.Is(gormErr, gorm.ErrDuplicatedKey))
+
+	fmt.Println("\n--- Translated Constraint Errors ---")
+	report("Same SKU, same warehouse", TranslateError(rawErr))
+	report("Same warehouse code", TranslateError(db.Create(&Warehouse{Code: "PAR", City: "Paris"}).Error))
+	report("Missing city", TranslateError(db.Exec("INSERT INTO warehouses (code) VALUES ('BOD')").Error))
+	report("Unknown warehouse", TranslateError(db.Create(&StockItem{SKU: "DESK-02", WarehouseID: 99}).Error))
+	report("Negative quantity", TranslateError(db.Create(&StockItem{SKU: "DESK-02", WarehouseID: lyon.ID, Quantity: -1}).Error))
+	report("Delete a used warehouse", TranslateError(db.Delete(&lyon).Error))
+	report("Not a constraint", TranslateError(db.First(&StockItem{}, 999).Error))
+
+	fmt.Println("\n--- Errors of the Service ---")
+	errs := []error{
+		service.AddItem(&StockItem{SKU: "MOUSE-01", WarehouseID: paris.ID, Quantity: 5}),
+		service.AddItem(&StockItem{SKU: "MOUSE-01", WarehouseID: 42, Quantity: 5}),
+		service.AddWarehouse(&Warehouse{Code: "LYS", City: "Lyon"}),
+		service.Move("LAPTOP-15", lyon.ID, paris.ID, 5),
+		service.Move("LAPTOP-15", paris.ID, lyon.ID, 5),
+	}
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			fmt.Println("OK")
+		case errors.Is(err, ErrAlreadyStocked):
+			fmt.Println("409 Conflict:", err)
+		case errors.Is(err, ErrWarehouseExists):
+			fmt.Println("409 Conflict:", err)
+		case errors.Is(err, ErrUnknownWarehouse), errors.Is(err, ErrNegativeStock):
+			fmt.Println("422 Unprocessable Entity:", err)
+		default:
+			fmt.Println("500 Internal Server Error:", err)
+		}
+	}
+
+	var stock []StockItem
+	db.Where("sku = ?", "LAPTOP-15").Order("warehouse_id").Find(&stock)
+	for _, item := range stock {
+		fmt.Printf("  %s in warehouse %d: %d\n", item.SKU, item.WarehouseID, item.Quantity)
+	}
+}
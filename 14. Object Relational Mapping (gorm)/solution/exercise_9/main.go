@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// AddComment adds a comment to a product or an order. The association
+// fills owner_type and owner_id from the owner.
+func AddComment(db *gorm.DB, owner any, author, body string) (*Comment, error) {
+	comment := &Comment{Author: author, Body: body}
+	if err := db.Model(owner).Association("Comments").Append(comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// Attach adds an attachment to a product or an order
+func Attach(db *gorm.DB, owner any, fileName string, size int64) (*Attachment, error) {
+	attachment := &Attachment{FileName: fileName, Size: size}
+	if err := db.Model(owner).Association("Attachments").Append(attachment); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// withComments preloads the comments, oldest first, and the attachments
+func withComments(db *gorm.DB) *gorm.DB {
+	return db.Preload("Comments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at, id")
+	}).Preload("Attachments")
+}
+
+// LoadOwner goes the other way, from a comment to its owner. The type is
+// only known at run time, so GORM can't preload it.
+func LoadOwner(db *gorm.DB, comment Comment) (any, error) {
+	var owner any
+	switch comment.OwnerType {
+	case "product":
+		owner = &Product{}
+	case "order":
+		owner = &Order{}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownOwner, comment.OwnerType)
+	}
+	if err := db.First(owner, comment.OwnerID).Error; err != nil {
+		return nil, err
+	}
+	return owner, nil
+}
+
+func describe(owner any) string {
+	switch o := owner.(type) {
+	case *Product:
+		return "product " + o.Name
+	case *Order:
+		return "order " + o.Number
+	}
+	return fmt.Sprintf("%T", owner)
+}
+
+func count(db *gorm.DB, model any) int64 {
+	var n int64
+	db.Model(model).Count(&n)
+	return n
+}
+
+func main() {
+	os.Remove("polymorphic.db")
+	db, err := gorm.Open(sqlite.Open("polymorphic.db"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	if err := db.AutoMigrate(&Product{}, &Order{}, &Comment{}, &Attachment{}); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	laptop := Product{Name: "Laptop"}
+	// A product and its comments can also be created at once
+	phone := Product{Name: "Smartphone", Comments: []Comment{{Author: "alice", Body: "Battery lasts two days"}}}
+	order := Order{Number: "A-1001", Customer: "Bob"}
+	for _, model := range []any{&laptop, &phone, &order} {
+		if err := db.Create(model).Error; err != nil {
+			log.Fatalf("Failed to create %T: %v", model, err)
+		}
+	}
+
+	fmt.Println("--- Adding Comments and Attachments ---")
+	// The same product and order IDs: owner_type tells the comments apart
+	adds := []struct {
+		owner        any
+		author, body string
+	}{
+		{&laptop, "alice", "Great keyboard"},
+		{&laptop, "carol", "The fan is loud under load"},
+		{&order, "support", "Customer asked for gift wrapping"},
+	}
+	for _, add := range adds {
+		comment, err := AddComment(db, add.owner, add.author, add.body)
+		if err != nil {
+			log.Fatalf("Failed to add comment: %v", err)
+		}
+		fmt.Printf("Comment %d on %s: owner_type=%q owner_id=%d\n",
+			comment.ID, describe(add.owner), comment.OwnerType, comment.OwnerID)
+	}
+	if _, err := Attach(db, &laptop, "laptop-manual.pdf", 2_400_000); err != nil {
+		log.Fatalf("Failed to attach: %v", err)
+	}
+	if _, err := Attach(db, &order, "invoice-A-1001.pdf", 85_000); err != nil {
+		log.Fatalf("Failed to attach: %v", err)
+	}
+
+	fmt.Println("\n--- Preloading ---")
+	var products []Product
+	if err := withComments(db).Order("id").Find(&products).Error; err != nil {
+		log.Fatalf("Failed to load products: %v", err)
+	}
+	for _, p := range products {
+		fmt.Printf("Product %s: %d comments, %d attachments\n", p.Name, len(p.Comments), len(p.Attachments))
+		for _, c := range p.Comments {
+			fmt.Printf("  %s: %s\n", c.Author, c.Body)
+		}
+	}
+	var loadedOrder Order
+	if err := withComments(db).First(&loadedOrder, order.ID).Error; err != nil {
+		log.Fatalf("Failed to load order: %v", err)
+	}
+	fmt.Printf("Order %s: %d comments, attachment %s\n",
+		loadedOrder.Number, len(loadedOrder.Comments), loadedOrder.Attachments[0].FileName)
+
+	fmt.Println("\n--- From a Comment to Its Owner ---")
+	var comments []Comment
+	db.Order("id").Find(&comments)
+	for _, c := range comments {
+		owner, err := LoadOwner(db, c)
+		if err != nil {
+			log.Fatalf("Failed to load owner: %v", err)
+		}
+		fmt.Printf("Comment %d by %s is about %s\n", c.ID, c.Author, describe(owner))
+	}
+	if _, err := LoadOwner(db, Comment{OwnerType: "invoice", OwnerID: 1}); errors.Is(err, ErrUnknownOwner) {
+		fmt.Println("Error:", err)
+	}
+
+	fmt.Println("\n--- Cascading Cleanup ---")
+	fmt.Printf("Before: %d comments, %d attachments\n", count(db, &Comment{}), count(db, &Attachment{}))
+	if err := db.Delete(&laptop).Error; err != nil {
+		log.Fatalf("Failed to delete product: %v", err)
+	}
+	fmt.Printf("After deleting the laptop: %d comments, %d attachments\n", count(db, &Comment{}), count(db, &Attachment{}))
+	// The order has the same ID as the laptop: its comments are still there
+	if err := withComments(db).First(&loadedOrder, order.ID).Error; err != nil {
+		log.Fatalf("Failed to load order: %v", err)
+	}
+	fmt.Printf("Order %s (ID %d like the laptop) kept its %d comment\n", loadedOrder.Number, loadedOrder.ID, len(loadedOrder.Comments))
+
+	// A delete by ID has no value for the hooks: it is refused instead of
+	// leaving orphans behind
+	err = db.Delete(&Order{}, order.ID).Error
+	fmt.Println("Delete by ID:", err)
+	if err := db.Delete(&order).Error; err != nil {
+		log.Fatalf("Failed to delete order: %v", err)
+	}
+	fmt.Printf("After deleting the order: %d comments, %d attachments\n", count(db, &Comment{}), count(db, &Attachment{}))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Comments and attachments belong to a product or to an order. The
+// polymorphic tag gives each of them two columns: owner_id, and owner_type
+// naming the model. The value stored in owner_type is set with
+// polymorphicValue, otherwise it is the table name.
+
+// Product has comments and attachments
+type Product struct {
+	ID          uint         `gorm:"primaryKey"`
+	Name        string       `gorm:"size:100;not null"`
+	Comments    []Comment    `gorm:"polymorphic:Owner;polymorphicValue:product"`
+	Attachments []Attachment `gorm:"polymorphic:Owner;polymorphicValue:product"`
+}
+
+// Order has comments and attachments too
+type Order struct {
+	ID          uint         `gorm:"primaryKey"`
+	Number      string       `gorm:"size:20;not null;uniqueIndex"`
+	Customer    string       `gorm:"size:100;not null"`
+	Comments    []Comment    `gorm:"polymorphic:Owner;polymorphicValue:order"`
+	Attachments []Attachment `gorm:"polymorphic:Owner;polymorphicValue:order"`
+}
+
+// Comment is written about a product or an order. The database can't check
+// that owner_id exists: a foreign key references a single table. The index
+// serves the loading of the comments of an owner.
+type Comment struct {
+	ID        uint   `gorm:"primaryKey"`
+	OwnerType string `gorm:"size:20;not null;index:idx_comments_owner,priority:1"`
+	OwnerID   uint   `gorm:"not null;index:idx_comments_owner,priority:2"`
+	Author    string `gorm:"size:100;not null"`
+	Body      string `gorm:"type:text;not null"`
+	CreatedAt time.Time
+}
+
+// Attachment is a file attached to a product or an order
+type Attachment struct {
+	ID        uint   `gorm:"primaryKey"`
+	OwnerType string `gorm:"size:20;not null;index:idx_attachments_owner,priority:1"`
+	OwnerID   uint   `gorm:"not null;index:idx_attachments_owner,priority:2"`
+	FileName  string `gorm:"size:255;not null"`
+	Size      int64
+}
+
+// ErrUnknownOwner is returned for a comment whose owner type is not a model
+var ErrUnknownOwner = errors.New("unknown owner type")
+
+// ===== Cascading deletes =====
+
+// Without a foreign key, ON DELETE CASCADE can't remove the comments of a
+// deleted owner: the hooks do, in the transaction of the delete. They need
+// the ID of the owner, so delete a loaded value, not a bare ID.
+
+func (p *Product) BeforeDelete(tx *gorm.DB) error {
+	return requireID(p.ID)
+}
+
+func (p *Product) AfterDelete(tx *gorm.DB) error {
+	return deleteOwned(tx, "product", p.ID)
+}
+
+func (o *Order) BeforeDelete(tx *gorm.DB) error {
+	return requireID(o.ID)
+}
+
+func (o *Order) AfterDelete(tx *gorm.DB) error {
+	return deleteOwned(tx, "order", o.ID)
+}
+
+func requireID(id uint) error {
+	if id == 0 {
+		return errors.New("delete a loaded value: the hooks need its ID to delete its comments and attachments")
+	}
+	return nil
+}
+
+func deleteOwned(tx *gorm.DB, ownerType string, ownerID uint) error {
+	for _, model := range []any{&Comment{}, &Attachment{}} {
+		if err := tx.Where("owner_type = ? AND owner_id = ?", ownerType, ownerID).Delete(model).Error; err != nil {
+			return fmt.Errorf("deleting what belongs to %s %d: %w", ownerType, ownerID, err)
+		}
+	}
+	return nil
+}
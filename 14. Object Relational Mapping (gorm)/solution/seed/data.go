@@ -0,0 +1,95 @@
+package seed
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The generators return plain values: each exercise copies them into its
+// own models, which differ from one exercise to the other.
+
+// Product is a generated product. Price is in cents.
+type Product struct {
+	SKU         string
+	Name        string
+	Description string
+	Category    string
+	Brand       string
+	Price       int64
+	Stock       int
+	CreatedAt   time.Time
+}
+
+// PriceFloat returns the price in dollars, for the models storing a float
+func (p Product) PriceFloat() float64 {
+	return float64(p.Price) / 100
+}
+
+// NewProduct generates the i-th product, counting from 0
+func NewProduct(i int, f *Faker) Product {
+	name, adjective, noun := f.ProductName(i + 1)
+	return Product{
+		SKU:         fmt.Sprintf("SKU-%07d", i+1),
+		Name:        name,
+		Description: f.Description(adjective, noun),
+		Category:    f.Category(),
+		Brand:       f.Brand(),
+		Price:       int64(f.Between(99, 49_999)),
+		Stock:       f.IntN(200),
+		CreatedAt:   f.Time(365),
+	}
+}
+
+// Customer is a generated customer, with a unique email
+type Customer struct {
+	Name      string
+	Email     string
+	City      string
+	CreatedAt time.Time
+}
+
+// NewCustomer generates the i-th customer
+func NewCustomer(i int, f *Faker) Customer {
+	name := f.FullName()
+	local := strings.ToLower(strings.ReplaceAll(name, " ", "."))
+	return Customer{
+		Name:      name,
+		Email:     fmt.Sprintf("%s.%d@example.com", local, i+1),
+		City:      f.City(),
+		CreatedAt: f.Time(365),
+	}
+}
+
+// Order statuses, most orders being delivered
+var orderStatuses = []string{"delivered", "delivered", "delivered", "shipped", "paid", "cancelled"}
+
+// Order is a generated order. Customer and the Product of its lines are
+// indexes in the generated customers and products: with IDs starting at 1
+// and inserted in order, the ID is the index plus one.
+type Order struct {
+	Number   string
+	Customer int
+	Status   string
+	PlacedAt time.Time
+	Lines    []OrderLine
+}
+
+type OrderLine struct {
+	Product  int
+	Quantity int
+}
+
+// NewOrder generates the i-th order of the profile
+func NewOrder(i int, f *Faker, p Profile) Order {
+	order := Order{
+		Number:   fmt.Sprintf("ORD-%08d", i+1),
+		Customer: f.IntN(p.Customers),
+		Status:   f.Pick(orderStatuses),
+		PlacedAt: f.Time(365),
+	}
+	for range f.Between(1, 4) {
+		order.Lines = append(order.Lines, OrderLine{Product: f.IntN(p.Products), Quantity: f.Between(1, 5)})
+	}
+	return order
+}
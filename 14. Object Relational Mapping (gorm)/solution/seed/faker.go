@@ -0,0 +1,108 @@
+// Package seed fills the databases of the GORM exercises with fake data.
+//
+// The data is deterministic: the same profile and seed give the same rows
+// on every machine, so timings and query results can be compared between
+// runs. Each dataset draws from its own random source, so seeding more
+// customers doesn't change the products.
+package seed
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// Vocabulary of the generated names and descriptions. The search exercise
+// looks for some of these words.
+var (
+	adjectives = []string{"Wireless", "Ergonomic", "Portable", "Compact", "Waterproof",
+		"Smart", "Vintage", "Premium", "Stainless", "Foldable", "Rechargeable", "Organic"}
+	nouns = []string{"Headphones", "Keyboard", "Mouse", "Speaker", "Backpack", "Kettle",
+		"Lamp", "Blender", "Camera", "Watch", "Chair", "Bottle", "Charger", "Jacket"}
+	categories = []string{"Electronics", "Home Appliances", "Outdoor", "Office", "Fashion"}
+	features   = []string{"long battery life", "noise cancelling", "fast charging", "steel body",
+		"adjustable height", "bluetooth connectivity", "leather finish", "energy saving mode",
+		"quiet operation", "lightweight design", "water resistant coating", "two year warranty"}
+	brands     = []string{"Acme", "Nova", "Brewster", "Northwind", "Globex", "Initech"}
+	firstNames = []string{"Alice", "Bob", "Carol", "David", "Emma", "Farid", "Grace", "Hiro",
+		"Ines", "Jonas", "Kemal", "Lena", "Mateo", "Nora", "Oscar", "Priya"}
+	lastNames = []string{"Martin", "Smith", "Garcia", "Müller", "Rossi", "Kowalski", "Tanaka",
+		"Dubois", "Silva", "Novak", "Jensen", "Okafor"}
+	cities = []string{"Paris", "Lyon", "Berlin", "Madrid", "Rome", "Lisbon", "Warsaw", "Oslo"}
+)
+
+// Epoch is the start of the period of the generated dates
+var Epoch = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Faker generates fake values from a deterministic random source
+type Faker struct {
+	rng *rand.Rand
+}
+
+// NewFaker returns the faker of a dataset, such as "products". The same
+// seed and dataset always give the same values.
+func NewFaker(seed uint64, dataset string) *Faker {
+	h := fnv.New64a()
+	h.Write([]byte(dataset))
+	return &Faker{rng: rand.New(rand.NewPCG(seed, h.Sum64()))}
+}
+
+// IntN returns a number in [0, n)
+func (f *Faker) IntN(n int) int {
+	return f.rng.IntN(n)
+}
+
+// Between returns a number in [lo, hi]
+func (f *Faker) Between(lo, hi int) int {
+	return lo + f.rng.IntN(hi-lo+1)
+}
+
+// Pick returns one of the words
+func (f *Faker) Pick(words []string) string {
+	return words[f.rng.IntN(len(words))]
+}
+
+// Chance returns true with the probability p
+func (f *Faker) Chance(p float64) bool {
+	return f.rng.Float64() < p
+}
+
+// Time returns a time in the days after Epoch
+func (f *Faker) Time(days int) time.Time {
+	return Epoch.Add(time.Duration(f.rng.Int64N(int64(days) * int64(24*time.Hour))))
+}
+
+func (f *Faker) Category() string { return f.Pick(categories) }
+func (f *Faker) Brand() string    { return f.Pick(brands) }
+func (f *Faker) City() string     { return f.Pick(cities) }
+
+// FullName returns a first and a last name
+func (f *Faker) FullName() string {
+	return f.Pick(firstNames) + " " + f.Pick(lastNames)
+}
+
+// ProductName returns a name such as "Wireless Headphones 42". The number
+// keeps the names unique.
+func (f *Faker) ProductName(n int) (name, adjective, noun string) {
+	adjective, noun = f.Pick(adjectives), f.Pick(nouns)
+	return fmt.Sprintf("%s %s %d", adjective, noun, n), adjective, noun
+}
+
+// Description describes a product with three distinct features
+func (f *Faker) Description(adjective, noun string) string {
+	picked := f.rng.Perm(len(features))
+	return fmt.Sprintf("A %s %s with %s, %s and %s.", strings.ToLower(adjective), strings.ToLower(noun),
+		features[picked[0]], features[picked[1]], features[picked[2]])
+}
+
+// Sentence returns a few words of the vocabulary, for titles
+func (f *Faker) Sentence(words int) string {
+	parts := make([]string, words)
+	for i := range parts {
+		parts[i] = strings.ToLower(f.Pick(nouns))
+	}
+	parts[0] = f.Pick(adjectives)
+	return strings.Join(parts, " ")
+}
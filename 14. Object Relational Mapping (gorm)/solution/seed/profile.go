@@ -0,0 +1,64 @@
+package seed
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Profile sets the size of every dataset. Seed changes the generated values,
+// not their number.
+type Profile struct {
+	Name      string
+	Products  int
+	Customers int
+	Orders    int
+	Seed      uint64
+}
+
+// DefaultSeed gives the data the exercises were written with
+const DefaultSeed = 1
+
+// Profiles lists the sizes: small for trying things out, medium for
+// pagination, large for the search and performance measures
+var Profiles = []Profile{
+	{Name: "small", Products: 1_000, Customers: 100, Orders: 5_000, Seed: DefaultSeed},
+	{Name: "medium", Products: 10_000, Customers: 1_000, Orders: 50_000, Seed: DefaultSeed},
+	{Name: "large", Products: 100_000, Customers: 5_000, Orders: 500_000, Seed: DefaultSeed},
+}
+
+// Lookup returns the profile with this name
+func Lookup(name string) (Profile, error) {
+	i := slices.IndexFunc(Profiles, func(p Profile) bool { return p.Name == name })
+	if i < 0 {
+		return Profile{}, fmt.Errorf("unknown seed profile %q, choose one of %s", name, profileNames())
+	}
+	return Profiles[i], nil
+}
+
+func profileNames() string {
+	names := make([]string, len(Profiles))
+	for i, p := range Profiles {
+		names[i] = p.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// Command parses the arguments of the seed subcommand of the exercises:
+//
+//	go run . seed -profile large -seed 7
+func Command(args []string, defaultProfile string) (Profile, error) {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	name := fs.String("profile", defaultProfile, "size of the data: "+profileNames())
+	seed := fs.Uint64("seed", DefaultSeed, "seed of the random data")
+	if err := fs.Parse(args); err != nil {
+		return Profile{}, err
+	}
+	p, err := Lookup(*name)
+	if err != nil {
+		return Profile{}, err
+	}
+	p.Seed = *seed
+	return p, nil
+}
@@ -0,0 +1,109 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BatchSize is the number of rows per INSERT statement
+const BatchSize = 1000
+
+// Run records a seeded dataset, so seeding again does nothing
+type Run struct {
+	Dataset   string `gorm:"primaryKey;size:50"`
+	Profile   string `gorm:"size:20;not null"`
+	Seed      uint64 `gorm:"not null"`
+	Rows      int    `gorm:"not null"`
+	CreatedAt time.Time
+}
+
+func (Run) TableName() string {
+	return "seed_runs"
+}
+
+// Result tells what Insert did
+type Result struct {
+	Dataset  string
+	Rows     int
+	Skipped  bool // Already seeded with the same profile and seed
+	Duration time.Duration
+}
+
+func (r Result) String() string {
+	if r.Skipped {
+		return fmt.Sprintf("%s: %d rows already seeded", r.Dataset, r.Rows)
+	}
+	return fmt.Sprintf("%s: %d rows seeded in %v", r.Dataset, r.Rows, r.Duration.Round(time.Millisecond))
+}
+
+// Insert fills the table of T with n rows built by generate, once. When
+// seed_runs shows the dataset was seeded with the same profile and seed,
+// nothing is done; otherwise the table is emptied and filled again. The
+// table must exist, and T should have an auto-increment ID: the rows get
+// the IDs 1 to n, as the indexes in NewOrder expect.
+func Insert[T any](ctx context.Context, db *gorm.DB, dataset string, p Profile, n int, generate func(i int, f *Faker) T) (Result, error) {
+	result := Result{Dataset: dataset, Rows: n}
+	db = db.WithContext(ctx)
+	if err := db.AutoMigrate(&Run{}); err != nil {
+		return result, err
+	}
+	var previous Run
+	err := db.Where("dataset = ?", dataset).Limit(1).Find(&previous).Error
+	if err != nil {
+		return result, err
+	}
+	if previous.Profile == p.Name && previous.Seed == p.Seed && previous.Rows == n {
+		result.Skipped = true
+		return result, nil
+	}
+
+	start := time.Now()
+	// A single transaction: a failed seed leaves the previous data, and
+	// SQLite doesn't sync the file after every batch
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var model T
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(&model).Error; err != nil {
+			return fmt.Errorf("emptying %s: %w", dataset, err)
+		}
+		if err := restartIDs(tx, &model); err != nil {
+			return fmt.Errorf("restarting the IDs of %s: %w", dataset, err)
+		}
+
+		f := NewFaker(p.Seed, dataset)
+		batch := make([]T, 0, BatchSize)
+		for i := range n {
+			batch = append(batch, generate(i, f))
+			if len(batch) == BatchSize || i == n-1 {
+				if err := tx.Create(&batch).Error; err != nil {
+					return fmt.Errorf("inserting %s: %w", dataset, err)
+				}
+				batch = batch[:0]
+			}
+		}
+
+		run := Run{Dataset: dataset, Profile: p.Name, Seed: p.Seed, Rows: n}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&run).Error
+	})
+	result.Duration = time.Since(start)
+	return result, err
+}
+
+// restartIDs makes the next row of the table get the ID 1
+func restartIDs(tx *gorm.DB, model any) error {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	switch tx.Dialector.Name() {
+	case "sqlite":
+		// The counters of the AUTOINCREMENT columns
+		return tx.Exec("DELETE FROM sqlite_sequence WHERE name = ?", stmt.Schema.Table).Error
+	case "postgres":
+		return tx.Exec("SELECT setval(pg_get_serial_sequence(?, 'id'), 1, false)", stmt.Schema.Table).Error
+	}
+	return nil
+}
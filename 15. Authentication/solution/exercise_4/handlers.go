@@ -0,0 +1,230 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPasswordBytes is the most bcrypt takes into account. The max binding
+// counts characters, and a character may take up to 4 bytes, so the handlers
+// check the length in bytes themselves.
+const maxPasswordBytes = 72
+
+// RegisterRequest contains registration form data. Passwords are capped
+// at maxPasswordBytes.
+type RegisterRequest struct {
+	Email    string `json:"email" binding:"required,email,max=255"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+// LoginRequest contains login form data
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RefreshRequest contains the refresh token to rotate
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutRequest contains the refresh token to revoke
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// ForgotPasswordRequest contains the email to send a reset link to
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest contains the reset token and the new password
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+// AuthHandler exposes the auth service over HTTP
+type AuthHandler struct {
+	service *AuthService
+}
+
+// NewAuthHandler creates a handler using the provided service
+func NewAuthHandler(service *AuthService) *AuthHandler {
+	return &AuthHandler{service: service}
+}
+
+// respondError maps service errors to HTTP responses without leaking details
+func respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrEmailTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
+	case errors.Is(err, ErrInvalidCredentials):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+	case errors.Is(err, ErrTokenReused):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token already used, all sessions were revoked"})
+	case errors.Is(err, ErrInvalidToken):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+	default:
+		log.Printf("Auth error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
+
+// AuthMiddleware requires a valid, non revoked access token
+func AuthMiddleware(service *AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth := c.GetHeader("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing bearer token"})
+			return
+		}
+
+		claims, err := service.Authenticate(strings.TrimPrefix(auth, "Bearer "))
+		if err != nil {
+			respondError(c, err)
+			c.Abort()
+			return
+		}
+
+		c.Set("claims", claims)
+		c.Next()
+	}
+}
+
+// currentClaims returns the access token claims stored by AuthMiddleware
+func currentClaims(c *gin.Context) *Claims {
+	return c.MustGet("claims").(*Claims)
+}
+
+// Register creates an account
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.Password) > maxPasswordBytes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Password must be at most 72 bytes"})
+		return
+	}
+
+	user, err := h.service.Register(req.Email, req.Password)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, user)
+}
+
+// Login returns an access and refresh token pair
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		return
+	}
+
+	pair, err := h.service.Login(req.Email, req.Password)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pair)
+}
+
+// Refresh rotates the refresh token
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token required"})
+		return
+	}
+
+	pair, err := h.service.Refresh(req.RefreshToken)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, pair)
+}
+
+// Logout revokes the refresh token from the body, if any, and the current access token
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req LogoutRequest
+	// The body is optional, without it only the access token is revoked
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+			return
+		}
+	}
+
+	if err := h.service.Logout(currentClaims(c), req.RefreshToken); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ForgotPassword creates a reset token. The response is the same whether or not
+// the email exists.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := h.service.RequestPasswordReset(req.Email)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if token != "" {
+		// DEMO PURPOSE ONLY: a real application emails a link containing the token
+		log.Printf("Password reset token for %s: %s", req.Email, token)
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the email is registered, a reset link has been sent"})
+}
+
+// ResetPassword sets a new password using a reset token
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.Password) > maxPasswordBytes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Password must be at most 72 bytes"})
+		return
+	}
+
+	if err := h.service.ResetPassword(req.Token, req.Password); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password updated, please log in again"})
+}
+
+// Me returns the authenticated user
+func (h *AuthHandler) Me(c *gin.Context) {
+	userID, err := currentClaims(c).UserID()
+	if err != nil {
+		respondError(c, ErrInvalidToken)
+		return
+	}
+
+	user, err := h.service.FindUser(userID)
+	if err != nil {
+		respondError(c, ErrInvalidToken)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Token lifetimes
+const (
+	accessTTL  = 15 * time.Minute
+	refreshTTL = 7 * 24 * time.Hour
+	resetTTL   = 30 * time.Minute
+)
+
+// newPasswords picks the hashing algorithm for new passwords with PASSWORD_HASH
+// (argon2id or bcrypt). Hashes of the other algorithm are still accepted and
+// upgraded at the next login.
+func newPasswords() *Passwords {
+	argon := NewArgon2Hasher()
+	bcryptHasher := BcryptHasher{Cost: 12}
+	if os.Getenv("PASSWORD_HASH") == "bcrypt" {
+		return NewPasswords(bcryptHasher, argon)
+	}
+	return NewPasswords(argon, bcryptHasher)
+}
+
+// jwtSecret reads the signing key from JWT_SECRET
+func jwtSecret() []byte {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		log.Println("JWT_SECRET is not set, using a development key")
+		secret = "development-secret-key-do-not-use-in-production"
+	}
+	return []byte(secret)
+}
+
+func main() {
+	db, err := gorm.Open(sqlite.Open("auth.db"), &gorm.Config{
+		// Turns unique constraint violations into gorm.ErrDuplicatedKey
+		TranslateError: true,
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	// Auto Migrate the schema
+	if err := db.AutoMigrate(&User{}, &RefreshSession{}, &RevokedAccessToken{}, &PasswordResetToken{}); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	tokens := NewTokenManager(jwtSecret(), accessTTL, refreshTTL)
+	service, err := NewAuthService(db, newPasswords(), tokens, resetTTL)
+	if err != nil {
+		log.Fatalf("Failed to create auth service: %v", err)
+	}
+
+	// Expired blacklist entries and reset tokens are only kept until they expire
+	go func() {
+		for range time.Tick(time.Hour) {
+			if err := service.PurgeExpired(); err != nil {
+				log.Printf("Failed to purge expired tokens: %v", err)
+			}
+		}
+	}()
+
+	h := NewAuthHandler(service)
+	r := gin.Default()
+
+	auth := r.Group("/auth")
+	{
+		auth.POST("/register", h.Register)
+		auth.POST("/login", h.Login)
+		auth.POST("/refresh", h.Refresh)
+		auth.POST("/logout", AuthMiddleware(service), h.Logout)
+		auth.POST("/password/forgot", h.ForgotPassword)
+		auth.POST("/password/reset", h.ResetPassword)
+	}
+
+	api := r.Group("/api")
+	api.Use(AuthMiddleware(service))
+	{
+		api.GET("/me", h.Me)
+	}
+
+	if err := r.Run(":8080"); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import "time"
+
+// User is a registered account. Only the password hash is stored.
+type User struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Email        string    `json:"email" gorm:"size:255;not null;uniqueIndex"`
+	PasswordHash string    `json:"-" gorm:"size:255;not null"`
+	Role         string    `json:"role" gorm:"size:20;not null;default:user"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// RefreshSession is the server-side state of a refresh token, identified by its jti claim
+type RefreshSession struct {
+	JTI        string     `gorm:"primaryKey;size:36"`
+	UserID     uint       `gorm:"not null;index"`
+	ExpiresAt  time.Time  `gorm:"not null"`
+	RevokedAt  *time.Time // Set on rotation, logout and password reset
+	ReplacedBy string     `gorm:"size:36"` // Session issued when this one was rotated
+	CreatedAt  time.Time
+}
+
+// Active reports whether the refresh token can still be exchanged
+func (s *RefreshSession) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// RevokedAccessToken blacklists an access token until it expires on its own
+type RevokedAccessToken struct {
+	JTI       string    `gorm:"primaryKey;size:36"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+// PasswordResetToken is a single-use reset link. Like a password, only its hash is stored.
+type PasswordResetToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"not null;index"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
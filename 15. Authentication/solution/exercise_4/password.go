@@ -0,0 +1,139 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownHash is returned when no hasher recognizes a stored hash
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// PasswordHasher hashes passwords with one algorithm
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(hash, password string) (bool, error)
+	// Recognizes reports whether the hash was produced by this algorithm
+	Recognizes(hash string) bool
+}
+
+// BcryptHasher hashes passwords with bcrypt
+type BcryptHasher struct {
+	Cost int
+}
+
+// Hash returns the bcrypt hash, which embeds the cost and salt
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	return string(hash), err
+}
+
+// Verify compares the password with a bcrypt hash
+func (h BcryptHasher) Verify(hash, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Recognizes matches the $2a$, $2b$ and $2y$ bcrypt prefixes
+func (h BcryptHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$2")
+}
+
+// Argon2Hasher hashes passwords with argon2id
+type Argon2Hasher struct {
+	Time    uint32
+	Memory  uint32 // In KiB
+	Threads uint8
+	KeyLen  uint32
+}
+
+// NewArgon2Hasher uses the parameters recommended by RFC 9106 for memory constrained servers
+func NewArgon2Hasher() Argon2Hasher {
+	return Argon2Hasher{Time: 3, Memory: 64 * 1024, Threads: 4, KeyLen: 32}
+}
+
+// Hash returns the hash in the PHC string format, e.g. $argon2id$v=19$m=65536,t=3,p=4$salt$hash
+func (h Argon2Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Threads, h.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.Memory, h.Time, h.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify recomputes the hash with the parameters stored in it, so older hashes keep working
+func (h Argon2Hasher) Verify(hash, password string) (bool, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, fmt.Errorf("unsupported argon2 version %q", parts[2])
+	}
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false, fmt.Errorf("invalid argon2 parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, fmt.Errorf("invalid argon2 salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, fmt.Errorf("invalid argon2 key: %w", err)
+	}
+
+	computed := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, computed) == 1, nil
+}
+
+// Recognizes matches the argon2id prefix
+func (h Argon2Hasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+// Passwords hashes new passwords with the preferred algorithm and verifies
+// stored hashes with whichever algorithm produced them
+type Passwords struct {
+	preferred PasswordHasher
+	hashers   []PasswordHasher
+}
+
+// NewPasswords creates a password manager. The preferred hasher is always accepted.
+func NewPasswords(preferred PasswordHasher, others ...PasswordHasher) *Passwords {
+	return &Passwords{preferred: preferred, hashers: append([]PasswordHasher{preferred}, others...)}
+}
+
+// Hash hashes a password with the preferred algorithm
+func (p *Passwords) Hash(password string) (string, error) {
+	return p.preferred.Hash(password)
+}
+
+// Verify checks the password. needsRehash is true when it matched a hash made
+// by another algorithm, so the caller can upgrade it while the password is known.
+func (p *Passwords) Verify(hash, password string) (ok, needsRehash bool, err error) {
+	for _, hasher := range p.hashers {
+		if !hasher.Recognizes(hash) {
+			continue
+		}
+		ok, err := hasher.Verify(hash, password)
+		return ok, ok && hasher != p.preferred, err
+	}
+	return false, false, ErrUnknownHash
+}
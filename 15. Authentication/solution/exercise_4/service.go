@@ -0,0 +1,326 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEmailTaken         = errors.New("email already registered")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrTokenReused        = errors.New("refresh token already used")
+)
+
+// AuthService implements registration, login, token rotation and password reset
+type AuthService struct {
+	db        *gorm.DB
+	passwords *Passwords
+	tokens    *TokenManager
+	resetTTL  time.Duration
+	dummyHash string // Verified when the email is unknown, so both cases take as long
+}
+
+// NewAuthService creates an auth service using the provided database connection
+func NewAuthService(db *gorm.DB, passwords *Passwords, tokens *TokenManager, resetTTL time.Duration) (*AuthService, error) {
+	dummyHash, err := passwords.Hash("not-a-real-password")
+	if err != nil {
+		return nil, err
+	}
+	return &AuthService{db: db, passwords: passwords, tokens: tokens, resetTTL: resetTTL, dummyHash: dummyHash}, nil
+}
+
+// normalizeEmail makes email lookups case insensitive
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// hashToken hashes a random token before storing it, so a leaked table can't be used to log in
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Register creates a user with a hashed password
+func (s *AuthService) Register(email, password string) (*User, error) {
+	hash, err := s.passwords.Hash(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := User{Email: normalizeEmail(email), PasswordHash: hash, Role: "user"}
+	// The unique index decides, checking first would race with a concurrent registration
+	if err := s.db.Create(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrEmailTaken
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Login checks the credentials and starts a new refresh session
+func (s *AuthService) Login(email, password string) (*TokenPair, error) {
+	var user User
+	err := s.db.Where("email = ?", normalizeEmail(email)).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		s.passwords.Verify(s.dummyHash, password)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ok, needsRehash, err := s.passwords.Verify(user.PasswordHash, password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	// Upgrade hashes made by an older algorithm now that the password is known
+	if needsRehash {
+		if hash, err := s.passwords.Hash(password); err == nil {
+			s.db.Model(&user).Update("password_hash", hash)
+		}
+	}
+
+	return s.issueTokens(s.db, &user, uuid.NewString())
+}
+
+// issueTokens creates a refresh session with the given ID and the token pair for it
+func (s *AuthService) issueTokens(tx *gorm.DB, user *User, sessionID string) (*TokenPair, error) {
+	session := RefreshSession{JTI: sessionID, UserID: user.ID}
+	refreshToken, expiresAt, err := s.tokens.NewRefreshToken(user, session.JTI)
+	if err != nil {
+		return nil, err
+	}
+	session.ExpiresAt = expiresAt
+	if err := tx.Create(&session).Error; err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.tokens.NewAccessToken(user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.tokens.accessTTL.Seconds()),
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new pair and revokes the old session.
+// Presenting a revoked token means it was stolen or replayed, so every session
+// of the user is revoked and they have to log in again.
+func (s *AuthService) Refresh(refreshToken string) (*TokenPair, error) {
+	claims, err := s.tokens.Parse(refreshToken, RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var pair *TokenPair
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var session RefreshSession
+		if err := tx.Where("jti = ? AND user_id = ?", claims.ID, userID).First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidToken
+			}
+			return err
+		}
+		if session.RevokedAt != nil {
+			return ErrTokenReused
+		}
+		if !session.Active(time.Now()) {
+			return ErrInvalidToken
+		}
+
+		var user User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return ErrInvalidToken
+		}
+
+		// Conditional update, two concurrent refreshes with the same token can't both win
+		nextID := uuid.NewString()
+		result := tx.Model(&RefreshSession{}).
+			Where("jti = ? AND revoked_at IS NULL", session.JTI).
+			Updates(map[string]any{"revoked_at": time.Now(), "replaced_by": nextID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTokenReused
+		}
+
+		var err error
+		pair, err = s.issueTokens(tx, &user, nextID)
+		return err
+	})
+
+	// Revoke outside the transaction, returning the error rolls it back
+	if errors.Is(err, ErrTokenReused) {
+		if revokeErr := s.RevokeAllSessions(userID); revokeErr != nil {
+			return nil, revokeErr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Logout revokes the refresh session and blacklists the access token until it expires
+func (s *AuthService) Logout(access *Claims, refreshToken string) error {
+	userID, err := access.UserID()
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if refreshToken != "" {
+			claims, err := s.tokens.Parse(refreshToken, RefreshToken)
+			if err != nil {
+				return err
+			}
+			err = tx.Model(&RefreshSession{}).
+				Where("jti = ? AND user_id = ? AND revoked_at IS NULL", claims.ID, userID).
+				Update("revoked_at", time.Now()).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(&RevokedAccessToken{JTI: access.ID, ExpiresAt: access.ExpiresAt.Time}).Error
+	})
+}
+
+// RevokeAllSessions logs the user out of every device once their access tokens expire
+func (s *AuthService) RevokeAllSessions(userID uint) error {
+	return s.db.Model(&RefreshSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// Authenticate validates an access token and checks it hasn't been blacklisted
+func (s *AuthService) Authenticate(accessToken string) (*Claims, error) {
+	claims, err := s.tokens.Parse(accessToken, AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var revoked int64
+	if err := s.db.Model(&RevokedAccessToken{}).Where("jti = ?", claims.ID).Count(&revoked).Error; err != nil {
+		return nil, err
+	}
+	if revoked > 0 {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// FindUser retrieves a user by ID
+func (s *AuthService) FindUser(id uint) (*User, error) {
+	var user User
+	if err := s.db.First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// RequestPasswordReset creates a reset token for the email. It returns an empty
+// token without an error for unknown emails, so callers can't probe for accounts.
+func (s *AuthService) RequestPasswordReset(email string) (string, error) {
+	var user User
+	err := s.db.Where("email = ?", normalizeEmail(email)).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Only the most recent link works
+		if err := tx.Where("user_id = ? AND used_at IS NULL", user.ID).Delete(&PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: hashToken(token),
+			ExpiresAt: time.Now().Add(s.resetTTL),
+		}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ResetPassword sets a new password with a reset token and revokes every session
+func (s *AuthService) ResetPassword(token, newPassword string) error {
+	hash, err := s.passwords.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var reset PasswordResetToken
+		err := tx.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hashToken(token), time.Now()).
+			First(&reset).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidToken
+		}
+		if err != nil {
+			return err
+		}
+
+		// Mark the token used first, so it can only be redeemed once
+		result := tx.Model(&reset).Where("used_at IS NULL").Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvalidToken
+		}
+
+		if err := tx.Model(&User{}).Where("id = ?", reset.UserID).Update("password_hash", hash).Error; err != nil {
+			return err
+		}
+
+		// Whoever knew the old password may hold a refresh token
+		return tx.Model(&RefreshSession{}).
+			Where("user_id = ? AND revoked_at IS NULL", reset.UserID).
+			Update("revoked_at", time.Now()).Error
+	})
+}
+
+// PurgeExpired deletes blacklist entries and reset tokens that can no longer be used
+func (s *AuthService) PurgeExpired() error {
+	now := time.Now()
+	if err := s.db.Where("expires_at <= ?", now).Delete(&RevokedAccessToken{}).Error; err != nil {
+		return err
+	}
+	return s.db.Where("expires_at <= ? OR used_at IS NOT NULL", now).Delete(&PasswordResetToken{}).Error
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// TokenType distinguishes access tokens from refresh tokens signed with the same key
+type TokenType string
+
+const (
+	AccessToken  TokenType = "access"
+	RefreshToken TokenType = "refresh"
+)
+
+// Claims are the JWT claims of both token types. The subject is the user ID.
+type Claims struct {
+	Type TokenType `json:"typ"`
+	Role string    `json:"role,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// UserID returns the user ID stored in the subject claim
+func (c *Claims) UserID() (uint, error) {
+	id, err := strconv.ParseUint(c.Subject, 10, 64)
+	return uint(id), err
+}
+
+// TokenPair is returned by login and refresh
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // Access token lifetime in seconds
+}
+
+// TokenManager signs and validates JWTs with HMAC SHA256
+type TokenManager struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewTokenManager creates a token manager with the given key and token lifetimes
+func NewTokenManager(secret []byte, accessTTL, refreshTTL time.Duration) *TokenManager {
+	return &TokenManager{secret: secret, accessTTL: accessTTL, refreshTTL: refreshTTL}
+}
+
+// sign creates a token of the given type, returning it with its expiry
+func (m *TokenManager) sign(user *User, tokenType TokenType, jti string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := Claims{
+		Type: tokenType,
+		Role: user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	return token, expiresAt, err
+}
+
+// NewAccessToken issues a short-lived access token with a random jti, so it can be blacklisted
+func (m *TokenManager) NewAccessToken(user *User) (string, error) {
+	token, _, err := m.sign(user, AccessToken, uuid.NewString(), m.accessTTL)
+	return token, err
+}
+
+// NewRefreshToken issues a refresh token for a session, returning when it expires
+func (m *TokenManager) NewRefreshToken(user *User, sessionID string) (string, time.Time, error) {
+	return m.sign(user, RefreshToken, sessionID, m.refreshTTL)
+}
+
+// Parse validates the signature, algorithm, expiry and type of a token
+func (m *TokenManager) Parse(tokenStr string, expected TokenType) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (any, error) {
+		return m.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	// An access token must not be accepted as a refresh token and vice versa
+	if claims.Type != expected || claims.ID == "" {
+		return nil, fmt.Errorf("%w: expected a %s token", ErrInvalidToken, expected)
+	}
+	return claims, nil
+}
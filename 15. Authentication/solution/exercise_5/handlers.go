@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+)
+
+// Cookie names and lifetimes
+const (
+	sessionCookie = "session"
+	stateCookie   = "oauth_state"
+	loginTimeout  = 10 * time.Minute
+)
+
+// homeTemplate escapes the profile values, which come from the provider
+var homeTemplate = template.Must(template.New("home").Parse(`<!DOCTYPE html>
+<html>
+<head>
+	<title>OAuth2 Login Demo</title>
+	<style>
+		body { font-family: Arial, sans-serif; margin: 40px; line-height: 1.6; }
+		.btn { display: inline-block; margin: 5px; padding: 10px 20px; background-color: #4285F4;
+			color: white; text-decoration: none; border: none; border-radius: 5px; font-weight: bold; cursor: pointer; }
+		.profile img { width: 80px; height: 80px; border-radius: 50%; }
+	</style>
+</head>
+<body>
+	{{if .User}}
+	<div class="profile">
+		{{if .User.AvatarURL}}<img src="{{.User.AvatarURL}}" alt="Avatar">{{end}}
+		<h1>Welcome, {{.User.Name}}!</h1>
+		<p>Email: {{.User.Email}}</p>
+		<p>Signed in with {{.User.Provider}}</p>
+	</div>
+	<form method="POST" action="/logout"><button class="btn" type="submit">Logout</button></form>
+	{{else}}
+	<h1>Please Sign In</h1>
+	{{range .Providers}}<a class="btn" href="/auth/{{.Name}}/login">Sign in with {{.Label}}</a>{{end}}
+	{{if not .Providers}}<p>No provider is configured, set GITHUB_CLIENT_ID or GOOGLE_CLIENT_ID.</p>{{end}}
+	{{end}}
+</body>
+</html>`))
+
+// AuthHandler implements the login flow for the configured providers
+type AuthHandler struct {
+	store         *SessionStore
+	providers     map[string]*Provider
+	secureCookies bool // Only send cookies over HTTPS
+}
+
+// NewAuthHandler creates a handler for the providers
+func NewAuthHandler(store *SessionStore, providers []*Provider, secureCookies bool) *AuthHandler {
+	h := &AuthHandler{store: store, providers: map[string]*Provider{}, secureCookies: secureCookies}
+	for _, p := range providers {
+		h.providers[p.Name] = p
+	}
+	return h
+}
+
+// setCookie sets an HttpOnly cookie. SameSite=Lax still sends it on the
+// top-level redirect back from the provider.
+func (h *AuthHandler) setCookie(c *gin.Context, name, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(name, value, maxAge, "/", "", h.secureCookies, true)
+}
+
+// provider returns the provider named in the URL, writing a 404 response when it is unknown
+func (h *AuthHandler) provider(c *gin.Context) (*Provider, bool) {
+	p, ok := h.providers[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown provider"})
+	}
+	return p, ok
+}
+
+// LoadSession stores the logged in user in the context, if there is one
+func (h *AuthHandler) LoadSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token, err := c.Cookie(sessionCookie); err == nil {
+			session, err := h.store.FindSession(token)
+			switch {
+			case err == nil:
+				c.Set("user", &session.User)
+			case errors.Is(err, ErrSessionNotFound):
+				h.setCookie(c, sessionCookie, "", -1)
+			default:
+				log.Printf("Failed to load session: %v", err)
+			}
+		}
+		c.Next()
+	}
+}
+
+// currentUser returns the user stored by LoadSession
+func currentUser(c *gin.Context) (*User, bool) {
+	user, ok := c.Get("user")
+	if !ok {
+		return nil, false
+	}
+	return user.(*User), true
+}
+
+// Home shows the profile, or the login buttons when logged out
+func (h *AuthHandler) Home(c *gin.Context) {
+	user, _ := currentUser(c)
+	providers := make([]*Provider, 0, len(h.providers))
+	for _, name := range []string{"github", "google"} {
+		if p, ok := h.providers[name]; ok {
+			providers = append(providers, p)
+		}
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := homeTemplate.Execute(c.Writer, gin.H{"User": user, "Providers": providers}); err != nil {
+		log.Printf("Failed to render home page: %v", err)
+	}
+}
+
+// Login redirects to the provider. The state ties the callback to this
+// browser, the PKCE verifier ties the code to this server and the nonce ties
+// the ID token to this attempt.
+func (h *AuthHandler) Login(c *gin.Context) {
+	p, ok := h.provider(c)
+	if !ok {
+		return
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+	nonce, err := randomToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+
+	attempt := LoginAttempt{
+		State:     state,
+		Provider:  p.Name,
+		Verifier:  oauth2.GenerateVerifier(),
+		Nonce:     nonce,
+		ExpiresAt: time.Now().Add(loginTimeout),
+	}
+	if err := h.store.SaveAttempt(&attempt); err != nil {
+		log.Printf("Failed to save login attempt: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+	h.setCookie(c, stateCookie, state, int(loginTimeout.Seconds()))
+
+	// Only the SHA-256 challenge of the verifier is sent to the browser
+	opts := []oauth2.AuthCodeOption{oauth2.S256ChallengeOption(attempt.Verifier)}
+	if p.OIDC {
+		opts = append(opts, oidc.Nonce(nonce))
+	}
+	c.Redirect(http.StatusFound, p.Config.AuthCodeURL(state, opts...))
+}
+
+// Callback exchanges the authorization code and starts a local session
+func (h *AuthHandler) Callback(c *gin.Context) {
+	p, ok := h.provider(c)
+	if !ok {
+		return
+	}
+
+	// The user denied access or the provider rejected the request
+	if errCode := c.Query("error"); errCode != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errCode, "description": c.Query("error_description")})
+		return
+	}
+
+	// The state must match the cookie set by this browser at login, otherwise
+	// an attacker could make the victim's browser finish the attacker's login
+	cookieState, err := c.Cookie(stateCookie)
+	h.setCookie(c, stateCookie, "", -1)
+	state := c.Query("state")
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookieState), []byte(state)) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid OAuth state"})
+		return
+	}
+
+	attempt, err := h.store.TakeAttempt(state)
+	if err != nil || attempt.Provider != p.Name {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Login attempt expired, please try again"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	// The provider only accepts the code together with the matching verifier
+	token, err := p.Config.Exchange(ctx, c.Query("code"), oauth2.VerifierOption(attempt.Verifier))
+	if err != nil {
+		log.Printf("Failed to exchange %s code: %v", p.Name, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to exchange authorization code"})
+		return
+	}
+
+	profile, err := p.FetchProfile(ctx, token, attempt.Nonce)
+	if err != nil {
+		log.Printf("Failed to fetch %s profile: %v", p.Name, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to fetch user profile"})
+		return
+	}
+
+	user, err := h.store.UpsertUser(p.Name, profile)
+	if err != nil {
+		log.Printf("Failed to save user: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save user"})
+		return
+	}
+
+	// The provider tokens are not needed anymore, the app uses its own session
+	sessionToken, err := h.store.CreateSession(user.ID)
+	if err != nil {
+		log.Printf("Failed to create session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+	h.setCookie(c, sessionCookie, sessionToken, int(h.store.sessionTTL.Seconds()))
+
+	c.Redirect(http.StatusFound, "/")
+}
+
+// Logout ends the session. It is a POST, so other sites can't log the user out with a link.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if token, err := c.Cookie(sessionCookie); err == nil {
+		if err := h.store.DeleteSession(token); err != nil {
+			log.Printf("Failed to delete session: %v", err)
+		}
+	}
+	h.setCookie(c, sessionCookie, "", -1)
+	c.Redirect(http.StatusSeeOther, "/")
+}
+
+// Me returns the logged in user as JSON
+func (h *AuthHandler) Me(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not logged in"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
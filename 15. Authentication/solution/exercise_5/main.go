@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// sessionTTL is how long a user stays logged in
+const sessionTTL = 7 * 24 * time.Hour
+
+// configureProviders enables each provider whose client ID and secret are set.
+// The callback URL registered at the provider must be BASE_URL/auth/<name>/callback.
+func configureProviders(baseURL string) []*Provider {
+	var providers []*Provider
+
+	if id, secret := os.Getenv("GITHUB_CLIENT_ID"), os.Getenv("GITHUB_CLIENT_SECRET"); id != "" && secret != "" {
+		providers = append(providers, NewGitHubProvider(id, secret, baseURL+"/auth/github/callback"))
+	}
+
+	if id, secret := os.Getenv("GOOGLE_CLIENT_ID"), os.Getenv("GOOGLE_CLIENT_SECRET"); id != "" && secret != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		google, err := NewGoogleProvider(ctx, id, secret, baseURL+"/auth/google/callback")
+		if err != nil {
+			log.Fatalf("Failed to configure Google: %v", err)
+		}
+		providers = append(providers, google)
+	}
+
+	if len(providers) == 0 {
+		log.Println("Warning: no OAuth provider configured, set GITHUB_CLIENT_ID/GITHUB_CLIENT_SECRET or GOOGLE_CLIENT_ID/GOOGLE_CLIENT_SECRET")
+	}
+	return providers
+}
+
+func main() {
+	baseURL := strings.TrimSuffix(os.Getenv("BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+
+	db, err := gorm.Open(sqlite.Open("oauth.db"), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	// Auto Migrate the schema
+	if err := db.AutoMigrate(&User{}, &Session{}, &LoginAttempt{}); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	store := NewSessionStore(db, sessionTTL)
+	go func() {
+		for range time.Tick(time.Hour) {
+			if err := store.PurgeExpired(); err != nil {
+				log.Printf("Failed to purge expired sessions: %v", err)
+			}
+		}
+	}()
+
+	h := NewAuthHandler(store, configureProviders(baseURL), strings.HasPrefix(baseURL, "https://"))
+
+	r := gin.Default()
+	r.Use(h.LoadSession())
+
+	r.GET("/", h.Home)
+	r.GET("/auth/:provider/login", h.Login)
+	r.GET("/auth/:provider/callback", h.Callback)
+	r.POST("/logout", h.Logout)
+	r.GET("/api/me", h.Me)
+
+	log.Println("Starting server on :8080...")
+	if err := r.Run(":8080"); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
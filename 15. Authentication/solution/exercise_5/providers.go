@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+// ErrNonceMismatch is returned when the ID token wasn't issued for this login attempt
+var ErrNonceMismatch = errors.New("id token nonce mismatch")
+
+// Profile is the user information every provider returns
+type Profile struct {
+	ProviderUserID string
+	Email          string
+	Name           string
+	AvatarURL      string
+}
+
+// Provider is an identity provider supporting the authorization code flow
+type Provider struct {
+	Name   string
+	Label  string
+	Config *oauth2.Config
+	// OIDC providers return a signed ID token, which carries the nonce of the login attempt
+	OIDC bool
+	// FetchProfile returns the user behind the token
+	FetchProfile func(ctx context.Context, token *oauth2.Token, nonce string) (*Profile, error)
+}
+
+// NewGitHubProvider creates a GitHub provider. GitHub only supports OAuth2,
+// so the profile is fetched from the REST API with the access token.
+func NewGitHubProvider(clientID, clientSecret, redirectURL string) *Provider {
+	config := &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       []string{"read:user", "user:email"},
+		Endpoint:     github.Endpoint,
+	}
+
+	return &Provider{
+		Name:   "github",
+		Label:  "GitHub",
+		Config: config,
+		FetchProfile: func(ctx context.Context, token *oauth2.Token, _ string) (*Profile, error) {
+			client := config.Client(ctx, token)
+
+			var user struct {
+				ID        int64  `json:"id"`
+				Login     string `json:"login"`
+				Name      string `json:"name"`
+				Email     string `json:"email"`
+				AvatarURL string `json:"avatar_url"`
+			}
+			if err := getJSON(client, "https://api.github.com/user", &user); err != nil {
+				return nil, err
+			}
+
+			profile := &Profile{
+				ProviderUserID: strconv.FormatInt(user.ID, 10),
+				Email:          user.Email,
+				Name:           user.Name,
+				AvatarURL:      user.AvatarURL,
+			}
+			if profile.Name == "" {
+				profile.Name = user.Login
+			}
+
+			// The public profile has no email when the user keeps it private
+			if profile.Email == "" {
+				var emails []struct {
+					Email    string `json:"email"`
+					Primary  bool   `json:"primary"`
+					Verified bool   `json:"verified"`
+				}
+				if err := getJSON(client, "https://api.github.com/user/emails", &emails); err != nil {
+					return nil, err
+				}
+				for _, e := range emails {
+					if e.Primary && e.Verified {
+						profile.Email = e.Email
+					}
+				}
+			}
+			return profile, nil
+		},
+	}
+}
+
+// NewGoogleProvider creates a Google provider. Google supports OpenID Connect,
+// so the endpoints are discovered and the profile comes from the verified ID token.
+func NewGoogleProvider(ctx context.Context, clientID, clientSecret, redirectURL string) (*Provider, error) {
+	issuer, err := oidc.NewProvider(ctx, "https://accounts.google.com")
+	if err != nil {
+		return nil, fmt.Errorf("discovering google endpoints: %w", err)
+	}
+	verifier := issuer.Verifier(&oidc.Config{ClientID: clientID})
+
+	return &Provider{
+		Name:  "google",
+		Label: "Google",
+		Config: &oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Scopes:       []string{oidc.ScopeOpenID, "email", "profile"},
+			Endpoint:     issuer.Endpoint(),
+		},
+		OIDC: true,
+		FetchProfile: func(ctx context.Context, token *oauth2.Token, nonce string) (*Profile, error) {
+			rawIDToken, ok := token.Extra("id_token").(string)
+			if !ok {
+				return nil, errors.New("token response has no id_token")
+			}
+
+			// Checks the signature, issuer, audience and expiry
+			idToken, err := verifier.Verify(ctx, rawIDToken)
+			if err != nil {
+				return nil, fmt.Errorf("verifying id token: %w", err)
+			}
+			if idToken.Nonce != nonce {
+				return nil, ErrNonceMismatch
+			}
+
+			var claims struct {
+				Email         string `json:"email"`
+				EmailVerified bool   `json:"email_verified"`
+				Name          string `json:"name"`
+				Picture       string `json:"picture"`
+			}
+			if err := idToken.Claims(&claims); err != nil {
+				return nil, err
+			}
+
+			profile := &Profile{ProviderUserID: idToken.Subject, Name: claims.Name, AvatarURL: claims.Picture}
+			if claims.EmailVerified {
+				profile.Email = claims.Email
+			}
+			return profile, nil
+		},
+	}, nil
+}
+
+// getJSON decodes the JSON response of a GET request
+func getJSON(client *http.Client, url string, v any) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: unexpected status %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
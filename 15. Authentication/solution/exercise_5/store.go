@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidState    = errors.New("unknown or expired login attempt")
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// User is a local account linked to an identity at a provider
+type User struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	Provider       string    `json:"provider" gorm:"size:20;not null;uniqueIndex:idx_provider_user"`
+	ProviderUserID string    `json:"-" gorm:"size:100;not null;uniqueIndex:idx_provider_user"`
+	Email          string    `json:"email" gorm:"size:255"`
+	Name           string    `json:"name" gorm:"size:100"`
+	AvatarURL      string    `json:"avatar_url" gorm:"size:500"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Session is a logged in browser. The ID is the hash of the session cookie.
+type Session struct {
+	ID        string    `gorm:"primaryKey;size:64"`
+	UserID    uint      `gorm:"not null;index"`
+	User      User      `gorm:"constraint:OnDelete:CASCADE"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time
+}
+
+// LoginAttempt keeps the PKCE verifier and nonce between the redirect to the
+// provider and the callback. The state parameter identifies it.
+type LoginAttempt struct {
+	State     string    `gorm:"primaryKey;size:64"`
+	Provider  string    `gorm:"size:20;not null"`
+	Verifier  string    `gorm:"size:128;not null"`
+	Nonce     string    `gorm:"size:64;not null"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+// SessionStore persists users, sessions and login attempts with GORM
+type SessionStore struct {
+	db         *gorm.DB
+	sessionTTL time.Duration
+}
+
+// NewSessionStore creates a store using the provided database connection
+func NewSessionStore(db *gorm.DB, sessionTTL time.Duration) *SessionStore {
+	return &SessionStore{db: db, sessionTTL: sessionTTL}
+}
+
+// randomToken returns 32 random bytes encoded for use in URLs and cookies
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken hashes a session token, so the table alone can't be used to log in
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SaveAttempt stores a login attempt
+func (s *SessionStore) SaveAttempt(attempt *LoginAttempt) error {
+	return s.db.Create(attempt).Error
+}
+
+// TakeAttempt returns and deletes a login attempt, so each state is only accepted once
+func (s *SessionStore) TakeAttempt(state string) (*LoginAttempt, error) {
+	var attempt LoginAttempt
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("state = ? AND expires_at > ?", state, time.Now()).First(&attempt).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&attempt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidState
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// UpsertUser creates the user on their first login and refreshes the profile afterwards
+func (s *SessionStore) UpsertUser(provider string, profile *Profile) (*User, error) {
+	var user User
+	err := s.db.
+		Where(User{Provider: provider, ProviderUserID: profile.ProviderUserID}).
+		Assign(User{Email: profile.Email, Name: profile.Name, AvatarURL: profile.AvatarURL}).
+		FirstOrCreate(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CreateSession starts a session for the user and returns the cookie value
+func (s *SessionStore) CreateSession(userID uint) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	session := Session{ID: hashToken(token), UserID: userID, ExpiresAt: time.Now().Add(s.sessionTTL)}
+	if err := s.db.Create(&session).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// FindSession returns the active session for a cookie value, with its user
+func (s *SessionStore) FindSession(token string) (*Session, error) {
+	var session Session
+	err := s.db.Preload("User").
+		Where("id = ? AND expires_at > ?", hashToken(token), time.Now()).
+		First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// DeleteSession ends a session
+func (s *SessionStore) DeleteSession(token string) error {
+	return s.db.Delete(&Session{}, "id = ?", hashToken(token)).Error
+}
+
+// PurgeExpired deletes expired sessions and abandoned login attempts
+func (s *SessionStore) PurgeExpired() error {
+	now := time.Now()
+	if err := s.db.Where("expires_at <= ?", now).Delete(&Session{}).Error; err != nil {
+		return err
+	}
+	return s.db.Where("expires_at <= ?", now).Delete(&LoginAttempt{}).Error
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// generateSelfSigned creates an ECDSA P-256 key and a certificate signed by that same key,
+// valid for the given DNS names and IP addresses
+func generateSelfSigned(hosts []string, validFor time.Duration) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating key: %w", err)
+	}
+
+	// Serial numbers must be unique per issuer, a random 128-bit number is enough
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating serial number: %w", err)
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: hosts[0], Organization: []string{"Golang Training"}},
+		NotBefore:    now.Add(-time.Hour), // Tolerate clocks that are slightly behind
+		NotAfter:     now.Add(validFor),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	// Clients ignore the common name, the hosts must be subject alternative names
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	// Self-signed: the template is both the certificate and its issuer
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating certificate: %w", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// writeFiles saves the certificate and key in PEM format. The key is only readable by its owner.
+func writeFiles(dir string, cert *x509.Certificate, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+
+	if err := os.WriteFile(filepath.Join(dir, "cert.pem"), certPEM, 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "key.pem"), keyPEM, 0o600)
+}
+
+// loadCertificate reads a PEM encoded certificate back
+func loadCertificate(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no certificate found in " + path)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// printCertificate shows the fields a client checks during the handshake
+func printCertificate(cert *x509.Certificate) {
+	fingerprint := sha256.Sum256(cert.Raw)
+
+	fmt.Printf("Subject:     %s\n", cert.Subject)
+	fmt.Printf("Issuer:      %s\n", cert.Issuer)
+	fmt.Printf("DNS names:   %s\n", strings.Join(cert.DNSNames, ", "))
+	fmt.Printf("IP address:  %v\n", cert.IPAddresses)
+	fmt.Printf("Valid from:  %s\n", cert.NotBefore.Format(time.RFC1123))
+	fmt.Printf("Valid until: %s\n", cert.NotAfter.Format(time.RFC1123))
+	fmt.Printf("Key:         %s\n", cert.PublicKeyAlgorithm)
+	fmt.Printf("SHA-256:     %X\n", fingerprint)
+}
+
+// verify checks the certificate for a host, optionally trusting the certificate itself
+func verify(cert *x509.Certificate, host string, trusted bool) error {
+	opts := x509.VerifyOptions{DNSName: host, Roots: x509.NewCertPool()}
+	if trusted {
+		opts.Roots.AddCert(cert)
+	}
+	_, err := cert.Verify(opts)
+	return err
+}
+
+func main() {
+	hosts := flag.String("hosts", "localhost,127.0.0.1,::1", "comma-separated host names and IP addresses")
+	days := flag.Int("days", 365, "number of days the certificate is valid")
+	out := flag.String("out", ".", "directory for cert.pem and key.pem")
+	flag.Parse()
+
+	cert, key, err := generateSelfSigned(strings.Split(*hosts, ","), time.Duration(*days)*24*time.Hour)
+	if err != nil {
+		log.Fatalf("Failed to generate certificate: %v", err)
+	}
+	if err := writeFiles(*out, cert, key); err != nil {
+		log.Fatalf("Failed to write certificate: %v", err)
+	}
+	fmt.Printf("Wrote %s and %s\n\n", filepath.Join(*out, "cert.pem"), filepath.Join(*out, "key.pem"))
+
+	loaded, err := loadCertificate(filepath.Join(*out, "cert.pem"))
+	if err != nil {
+		log.Fatalf("Failed to load certificate: %v", err)
+	}
+	printCertificate(loaded)
+
+	// A self-signed certificate is rejected until the client explicitly trusts it
+	fmt.Println("\n--- Verification ---")
+	for _, check := range []struct {
+		host    string
+		trusted bool
+	}{
+		{"localhost", false},
+		{"localhost", true},
+		{"example.com", true},
+	} {
+		result := "OK"
+		if err := verify(loaded, check.host, check.trusted); err != nil {
+			result = err.Error()
+		}
+		fmt.Printf("%-12s trusted=%-5v %s\n", check.host, check.trusted, result)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto/tls"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Addresses of the plain HTTP redirect server and the HTTPS server
+const (
+	httpAddr  = ":8080"
+	httpsAddr = ":8443"
+	certFile  = "cert.pem"
+	keyFile   = "key.pem"
+)
+
+// newTLSConfig only allows TLS 1.2 and 1.3 with forward secrecy and AEAD ciphers
+func newTLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// Only used by TLS 1.2, the TLS 1.3 suites are all secure and can't be configured.
+		// HTTP/2 requires ECDHE with AES-128-GCM, so those come first.
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+	}
+}
+
+// redirectToHTTPS sends every plain HTTP request to the same URL on the HTTPS port.
+// 308 keeps the method and body, so a POST is not turned into a GET.
+func redirectToHTTPS(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host // No port in the Host header
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// HSTSMiddleware tells browsers to use HTTPS for this host without asking first.
+// The short max-age suits local practice, production sites use two years (63072000).
+func HSTSMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Strict-Transport-Security", "max-age=300; includeSubDomains")
+		c.Next()
+	}
+}
+
+// setupRouter registers the API routes
+func setupRouter() *gin.Engine {
+	r := gin.Default()
+	r.Use(HSTSMiddleware())
+
+	api := r.Group("/api/v1")
+	{
+		api.GET("/ping", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "pong"})
+		})
+
+		// Shows what the client and server agreed on during the handshake
+		api.GET("/tls", func(c *gin.Context) {
+			state := c.Request.TLS
+			c.JSON(http.StatusOK, gin.H{
+				"version":      tls.VersionName(state.Version),
+				"cipher_suite": tls.CipherSuiteName(state.CipherSuite),
+				"server_name":  state.ServerName,
+				"protocol":     state.NegotiatedProtocol,
+			})
+		})
+	}
+	return r
+}
+
+func main() {
+	if err := ensureCertificate(certFile, keyFile); err != nil {
+		log.Fatalf("Failed to create certificate: %v", err)
+	}
+
+	_, httpsPort, err := net.SplitHostPort(httpsAddr)
+	if err != nil {
+		log.Fatalf("Invalid HTTPS address: %v", err)
+	}
+
+	httpServer := &http.Server{
+		Addr:              httpAddr,
+		Handler:           redirectToHTTPS(httpsPort),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	httpsServer := &http.Server{
+		Addr:              httpsAddr,
+		Handler:           setupRouter(),
+		TLSConfig:         newTLSConfig(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	// Both servers run until one of them fails
+	errs := make(chan error, 2)
+	go func() {
+		log.Printf("Redirecting HTTP on %s to HTTPS", httpAddr)
+		errs <- httpServer.ListenAndServe()
+	}()
+	go func() {
+		log.Printf("Serving HTTPS on %s", httpsAddr)
+		errs <- httpsServer.ListenAndServeTLS(certFile, keyFile)
+	}()
+
+	log.Fatalf("Server stopped: %v", <-errs)
+}
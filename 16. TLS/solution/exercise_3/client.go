@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// APIError is returned for unsuccessful responses, as in module 07
+type APIError struct {
+	StatusCode int
+	URL        string
+	Message    string
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error (%d) on %s: %s", e.StatusCode, e.URL, e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Define sentinel errors
+var (
+	ErrNotFound  = errors.New("resource not found")
+	ErrForbidden = errors.New("forbidden")
+)
+
+// APIClient for making HTTP requests
+type APIClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewMTLSAPIClient creates a client that trusts only the given CA and, when
+// certFile is set, presents its client certificate during the handshake
+func NewMTLSAPIClient(baseURL, caFile, certFile, keyFile string) (*APIClient, error) {
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificate found in %s", caFile)
+	}
+
+	tlsConfig := &tls.Config{
+		RootCAs:    roots,
+		MinVersion: tls.VersionTLS13,
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		// Always present the certificate. With Certificates, Go only sends it when
+		// it is signed by one of the CAs the server asks for.
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return &cert, nil
+		}
+	}
+
+	return &APIClient{
+		BaseURL: baseURL,
+		HTTPClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+// GetUser fetches a user from the API
+func (c *APIClient) GetUser(userID string) (map[string]any, error) {
+	url := fmt.Sprintf("%s/users/%s", c.BaseURL, userID)
+
+	resp, err := c.HTTPClient.Get(url)
+	if err != nil {
+		// Handshake failures, such as a rejected client certificate, end up here
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var user map[string]any
+		if err := json.Unmarshal(body, &user); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		return user, nil
+
+	case http.StatusNotFound:
+		return nil, &APIError{StatusCode: resp.StatusCode, URL: url, Message: "User not found", Err: ErrNotFound}
+
+	case http.StatusForbidden:
+		return nil, &APIError{StatusCode: resp.StatusCode, URL: url, Message: "Client not allowed", Err: ErrForbidden}
+
+	default:
+		return nil, &APIError{
+			StatusCode: resp.StatusCode,
+			URL:        url,
+			Message:    fmt.Sprintf("API returned status %d", resp.StatusCode),
+			Err:        errors.New("unexpected API response"),
+		}
+	}
+}
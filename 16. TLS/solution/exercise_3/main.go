@@ -0,0 +1,162 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Directory of the generated certificates and address of the mTLS server
+const (
+	certDir    = "certs"
+	serverAddr = "127.0.0.1:8443"
+)
+
+// Common names of the client certificates allowed to call the API
+var allowedClients = map[string]bool{
+	"billing-service": true,
+}
+
+// newServerTLSConfig rejects any client without a certificate signed by the CA
+func newServerTLSConfig(caFile string) (*tls.Config, error) {
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificate found in %s", caFile)
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}, nil
+}
+
+// ClientIdentityMiddleware authorizes the verified client certificate by its common name.
+// The TLS layer already proved who the client is, this decides what it may do.
+func ClientIdentityMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.TLS == nil || len(c.Request.TLS.PeerCertificates) == 0 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Client certificate required"})
+			return
+		}
+
+		client := c.Request.TLS.PeerCertificates[0].Subject.CommonName
+		if !allowedClients[client] {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Client " + client + " is not allowed"})
+			return
+		}
+
+		c.Set("client", client)
+		c.Next()
+	}
+}
+
+// setupRouter registers the API routes
+func setupRouter() *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(gin.Recovery(), ClientIdentityMiddleware())
+
+	users := map[string]gin.H{
+		"1": {"id": 1, "name": "Alice", "email": "alice@example.com"},
+		"2": {"id": 2, "name": "Bob", "email": "bob@example.com"},
+	}
+
+	r.GET("/users/:id", func(c *gin.Context) {
+		user, ok := users[c.Param("id")]
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"user": user, "requested_by": c.GetString("client")})
+	})
+	return r
+}
+
+// startServer serves the API with mTLS until the program exits
+func startServer(tlsConfig *tls.Config) error {
+	// Listening before returning means the clients can connect right away
+	ln, err := net.Listen("tcp", serverAddr)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Handler:           setupRouter(),
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 5 * time.Second,
+		// Rejected handshakes are expected in this demo, don't log them
+		ErrorLog: log.New(discard{}, "", 0),
+	}
+	go func() {
+		certFile, keyFile := filepath.Join(certDir, "server.pem"), filepath.Join(certDir, "server-key.pem")
+		if err := server.ServeTLS(ln, certFile, keyFile); !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// discard drops the server error log
+type discard struct{}
+
+func (discard) Write(p []byte) (int, error) { return len(p), nil }
+
+// callAPI fetches a user with a client configured with the given certificate
+func callAPI(label, certName string) {
+	var certFile, keyFile string
+	if certName != "" {
+		certFile = filepath.Join(certDir, certName+".pem")
+		keyFile = filepath.Join(certDir, certName+"-key.pem")
+	}
+
+	fmt.Printf("\n--- %s ---\n", label)
+	client, err := NewMTLSAPIClient("https://localhost:8443", filepath.Join(certDir, "ca.pem"), certFile, keyFile)
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+
+	user, err := client.GetUser("1")
+	var apiErr *APIError
+	switch {
+	case err == nil:
+		fmt.Printf("User: %v\n", user)
+	case errors.As(err, &apiErr):
+		fmt.Printf("API error (%d): %s\n", apiErr.StatusCode, apiErr.Message)
+	default:
+		fmt.Printf("Connection rejected: %v\n", err)
+	}
+}
+
+func main() {
+	if err := generatePKI(certDir); err != nil {
+		log.Fatalf("Failed to generate certificates: %v", err)
+	}
+	fmt.Printf("Generated the CA, server and client certificates in %s/\n", certDir)
+
+	tlsConfig, err := newServerTLSConfig(filepath.Join(certDir, "ca.pem"))
+	if err != nil {
+		log.Fatalf("Failed to configure TLS: %v", err)
+	}
+	if err := startServer(tlsConfig); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+
+	callAPI("Client certificate signed by the CA", "client")
+	callAPI("No client certificate", "")
+	callAPI("Client certificate signed by an unknown CA", "rogue-client")
+	callAPI("Valid certificate, client not allowed", "other-client")
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CertificateAuthority signs the server and client certificates. Both sides
+// trust the CA instead of each other's certificate.
+type CertificateAuthority struct {
+	Cert *x509.Certificate
+	Key  *ecdsa.PrivateKey
+}
+
+// newTemplate creates a certificate template with a random serial number
+func newTemplate(commonName string, validFor time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"Golang Training"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validFor),
+	}, nil
+}
+
+// NewCertificateAuthority creates a self-signed CA that can only sign leaf certificates
+func NewCertificateAuthority(name string) (*CertificateAuthority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := newTemplate(name, 365*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.MaxPathLenZero = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &CertificateAuthority{Cert: cert, Key: key}, nil
+}
+
+// Issue signs a certificate for a server (ExtKeyUsageServerAuth, valid for the
+// hosts) or a client (ExtKeyUsageClientAuth, identified by its common name)
+func (ca *CertificateAuthority) Issue(commonName string, usage x509.ExtKeyUsage, hosts ...string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template, err := newTemplate(commonName, 30*24*time.Hour)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.Cert, &key.PublicKey, ca.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// writeCertificate saves a certificate to name.pem and its key, if any, to name-key.pem
+func writeCertificate(dir, name string, cert *x509.Certificate, key *ecdsa.PrivateKey) error {
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	if err := os.WriteFile(filepath.Join(dir, name+".pem"), certPEM, 0o644); err != nil {
+		return err
+	}
+	if key == nil {
+		return nil
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return os.WriteFile(filepath.Join(dir, name+"-key.pem"), keyPEM, 0o600)
+}
+
+// issued describes a certificate to sign and the file it is saved to
+type issued struct {
+	name       string
+	commonName string
+	usage      x509.ExtKeyUsage
+	hosts      []string
+}
+
+// generatePKI writes the CA, the server certificate and the client certificates
+// to the directory. The rogue client is signed by a CA the server doesn't trust.
+func generatePKI(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	ca, err := NewCertificateAuthority("Golang Training CA")
+	if err != nil {
+		return err
+	}
+	if err := writeCertificate(dir, "ca", ca.Cert, nil); err != nil {
+		return err
+	}
+
+	for _, c := range []issued{
+		{"server", "localhost", x509.ExtKeyUsageServerAuth, []string{"localhost", "127.0.0.1"}},
+		{"client", "billing-service", x509.ExtKeyUsageClientAuth, nil},
+		{"other-client", "reporting-service", x509.ExtKeyUsageClientAuth, nil},
+	} {
+		cert, key, err := ca.Issue(c.commonName, c.usage, c.hosts...)
+		if err != nil {
+			return err
+		}
+		if err := writeCertificate(dir, c.name, cert, key); err != nil {
+			return err
+		}
+	}
+
+	rogueCA, err := NewCertificateAuthority("Rogue CA")
+	if err != nil {
+		return err
+	}
+	cert, key, err := rogueCA.Issue("billing-service", x509.ExtKeyUsageClientAuth)
+	if err != nil {
+		return err
+	}
+	return writeCertificate(dir, "rogue-client", cert, key)
+}
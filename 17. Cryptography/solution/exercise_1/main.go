@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// ErrChecksumMismatch is returned when a file's content changed since its checksum was recorded
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// checksumFile hashes a file while streaming it, so large files don't need to fit in memory
+func checksumFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeChecksums prints a line per file in the sha256sum format: "<hex>  <path>"
+func writeChecksums(w io.Writer, paths []string) error {
+	for _, path := range paths {
+		sum, err := checksumFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fmt.Fprintf(w, "%s  %s\n", sum, path)
+	}
+	return nil
+}
+
+// verifyChecksums recomputes every file listed in a checksum file and reports the ones that differ
+func verifyChecksums(listPath string) (failed int, err error) {
+	f, err := os.Open(listPath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		want, path, ok := strings.Cut(scanner.Text(), "  ")
+		if !ok || len(want) != sha256.Size*2 {
+			return failed, fmt.Errorf("%s:%d: invalid checksum line", listPath, line)
+		}
+
+		got, err := checksumFile(path)
+		switch {
+		case err != nil:
+			fmt.Printf("%s: FAILED open or read (%v)\n", path, err)
+			failed++
+		case !strings.EqualFold(got, want):
+			fmt.Printf("%s: FAILED (%v)\n", path, ErrChecksumMismatch)
+			failed++
+		default:
+			fmt.Printf("%s: OK\n", path)
+		}
+	}
+	return failed, scanner.Err()
+}
+
+func main() {
+	check := flag.String("c", "", "verify the checksums listed in this file")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: exercise_1 FILE...      print SHA-256 checksums")
+		fmt.Fprintln(os.Stderr, "       exercise_1 -c SUMSFILE  verify checksums")
+	}
+	flag.Parse()
+
+	if *check != "" {
+		failed, err := verifyChecksums(*check)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if failed > 0 {
+			fmt.Fprintf(os.Stderr, "WARNING: %d computed checksum(s) did NOT match\n", failed)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := writeChecksums(os.Stdout, flag.Args()); err != nil {
+		log.Fatal(err)
+	}
+}
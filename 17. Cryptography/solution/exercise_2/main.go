@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limits applied to every delivery
+const (
+	maxBodySize = 1 << 20 // 1 MiB
+	tolerance   = 5 * time.Minute
+	webhookURL  = "http://localhost:8080/webhooks/orders"
+)
+
+// OrderEvent is the payload sent by the order system
+type OrderEvent struct {
+	Event   string  `json:"event" binding:"required"`
+	OrderID string  `json:"order_id" binding:"required"`
+	Amount  float64 `json:"amount"`
+}
+
+// webhookSecret reads the secret shared with the sender from WEBHOOK_SECRET
+func webhookSecret() []byte {
+	secret := os.Getenv("WEBHOOK_SECRET")
+	if secret == "" {
+		secret = "development-webhook-secret"
+	}
+	return []byte(secret)
+}
+
+// setupRouter registers the webhook endpoint behind the signature check
+func setupRouter(secret []byte) *gin.Engine {
+	r := gin.Default()
+
+	webhooks := r.Group("/webhooks")
+	webhooks.Use(WebhookSignatureMiddleware(secret, maxBodySize, tolerance))
+	{
+		webhooks.POST("/orders", func(c *gin.Context) {
+			var event OrderEvent
+			if err := c.ShouldBindJSON(&event); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			log.Printf("Received %s for order %s (%.2f)", event.Event, event.OrderID, event.Amount)
+			c.JSON(http.StatusOK, gin.H{"status": "received"})
+		})
+	}
+	return r
+}
+
+// send delivers a payload with the given signature headers and prints the response
+func send(label string, body []byte, signature string, timestamp int64) {
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, signature)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatalf("Failed to send webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	response, _ := io.ReadAll(resp.Body)
+	fmt.Printf("%-22s %d %s\n", label, resp.StatusCode, response)
+}
+
+// sendDemo plays the sender: a valid delivery, then the attacks the signature prevents
+func sendDemo(secret []byte) {
+	body := []byte(`{"event":"order.paid","order_id":"ORD-1001","amount":149.90}`)
+	now := time.Now().Unix()
+
+	send("Valid", body, Sign(secret, now, body), now)
+
+	tampered := []byte(`{"event":"order.paid","order_id":"ORD-1001","amount":1.00}`)
+	send("Tampered body", tampered, Sign(secret, now, body), now)
+
+	send("Wrong secret", body, Sign([]byte("guessed-secret"), now, body), now)
+
+	old := time.Now().Add(-time.Hour).Unix()
+	send("Replayed after 1 hour", body, Sign(secret, old, body), old)
+
+	send("No signature", body, "", now)
+}
+
+func main() {
+	sendMode := flag.Bool("send", false, "send signed sample deliveries to a running server")
+	flag.Parse()
+
+	secret := webhookSecret()
+	if *sendMode {
+		sendDemo(secret)
+		return
+	}
+
+	if err := setupRouter(secret).Run(":8080"); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUnknownKey          = errors.New("unknown encryption key")
+	ErrMalformedCiphertext = errors.New("malformed ciphertext")
+)
+
+// Keyring holds the AES-256 keys, identified by an ID stored with every
+// ciphertext. New values are encrypted with the current key, older values
+// stay readable as long as their key is in the keyring.
+type Keyring struct {
+	mu      sync.RWMutex
+	keys    map[string]cipher.AEAD
+	current string
+}
+
+// NewKeyring creates an empty keyring
+func NewKeyring() *Keyring {
+	return &Keyring{keys: map[string]cipher.AEAD{}}
+}
+
+// NewKey generates a random 256-bit key
+func NewKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// AddKey adds a key, the first one added becomes the current key
+func (k *Keyring) AddKey(id string, key []byte) error {
+	if id == "" || strings.Contains(id, ":") {
+		return fmt.Errorf("invalid key ID %q", id)
+	}
+	if len(key) != 32 {
+		return fmt.Errorf("key %s must be 32 bytes for AES-256, got %d", id, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[id] = aead
+	if k.current == "" {
+		k.current = id
+	}
+	return nil
+}
+
+// SetCurrent selects the key used for new values
+func (k *Keyring) SetCurrent(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	k.current = id
+	return nil
+}
+
+// Current returns the ID of the key used for new values
+func (k *Keyring) Current() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current
+}
+
+// RemoveKey retires a key once no value is encrypted with it anymore
+func (k *Keyring) RemoveKey(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if id == k.current {
+		return errors.New("can't remove the current key")
+	}
+	delete(k.keys, id)
+	return nil
+}
+
+// Encrypt returns "<key ID>:<base64 of nonce and ciphertext>". The additional data
+// is authenticated but not stored, decrypting requires the same value.
+func (k *Keyring) Encrypt(plaintext, additionalData []byte) (string, error) {
+	k.mu.RLock()
+	id, aead := k.current, k.keys[k.current]
+	k.mu.RUnlock()
+	if aead == nil {
+		return "", ErrUnknownKey
+	}
+
+	// A GCM nonce must never repeat for the same key, a random 96-bit nonce is
+	// safe for up to about 2^32 encryptions per key
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, plaintext, additionalData)
+	return id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt finds the key from the ID prefix and checks the authentication tag,
+// so a modified ciphertext or different additional data is an error
+func (k *Keyring) Decrypt(value string, additionalData []byte) ([]byte, error) {
+	id, encoded, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, ErrMalformedCiphertext
+	}
+
+	k.mu.RLock()
+	aead := k.keys[id]
+	k.mu.RUnlock()
+	if aead == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrMalformedCiphertext
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// Customer stores the SSN and card number encrypted, the other fields in plain text
+type Customer struct {
+	ID         uint   `gorm:"primaryKey"`
+	Name       string `gorm:"size:100;not null"`
+	Email      string `gorm:"size:255;not null"`
+	SSN        string `gorm:"serializer:encrypted"`
+	CardNumber string `gorm:"serializer:encrypted"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// RotateKeys re-encrypts every customer whose fields use an older key. Saving
+// a row encrypts it again with the current key.
+func RotateKeys(db *gorm.DB, keyring *Keyring, batchSize int) (int, error) {
+	prefix := keyring.Current() + ":%"
+	var customers []Customer
+	rotated := 0
+
+	result := db.
+		Where("(ssn <> '' AND ssn NOT LIKE ?) OR (card_number <> '' AND card_number NOT LIKE ?)", prefix, prefix).
+		FindInBatches(&customers, batchSize, func(tx *gorm.DB, batch int) error {
+			for i := range customers {
+				err := db.Model(&customers[i]).Select("ssn", "card_number").Updates(&customers[i]).Error
+				if err != nil {
+					return err
+				}
+			}
+			rotated += len(customers)
+			return nil
+		})
+	return rotated, result.Error
+}
+
+// printStored shows what is actually in the database
+func printStored(db *gorm.DB) {
+	var rows []struct {
+		Name       string
+		SSN        string
+		CardNumber string
+	}
+	db.Raw("SELECT name, ssn, card_number FROM customers ORDER BY id").Scan(&rows)
+	for _, row := range rows {
+		fmt.Printf("  %-14s ssn=%.30s...  card=%.30s...\n", row.Name, row.SSN, row.CardNumber)
+	}
+}
+
+// printDecrypted loads the customers through GORM, which decrypts the fields
+func printDecrypted(db *gorm.DB) {
+	var customers []Customer
+	if err := db.Order("id").Find(&customers).Error; err != nil {
+		fmt.Printf("  Failed to load customers: %v\n", err)
+		return
+	}
+	for _, c := range customers {
+		fmt.Printf("  %-14s ssn=%s  card=%s\n", c.Name, c.SSN, c.CardNumber)
+	}
+}
+
+// countByKey counts the SSNs encrypted with each key
+func countByKey(db *gorm.DB) string {
+	var ssns []string
+	db.Raw("SELECT ssn FROM customers").Scan(&ssns)
+
+	counts := map[string]int{}
+	for _, ssn := range ssns {
+		id, _, _ := strings.Cut(ssn, ":")
+		counts[id]++
+	}
+	return fmt.Sprint(counts)
+}
+
+func main() {
+	os.Remove("customers.db")
+
+	// In production the keys come from a secret manager, never from the source code
+	keyring := NewKeyring()
+	if err := keyring.AddKey("k1", NewKey()); err != nil {
+		log.Fatalf("Failed to add key: %v", err)
+	}
+	schema.RegisterSerializer("encrypted", EncryptedSerializer{keyring: keyring})
+
+	db, err := gorm.Open(sqlite.Open("customers.db"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	// Auto Migrate the schema
+	if err := db.AutoMigrate(&Customer{}); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	customers := []Customer{
+		{Name: "Alice Johnson", Email: "alice@example.com", SSN: "123-45-6789", CardNumber: "4111111111111111"},
+		{Name: "Bob Smith", Email: "bob@example.com", SSN: "987-65-4321", CardNumber: "5500000000000004"},
+		{Name: "Carol White", Email: "carol@example.com", SSN: "555-12-3456", CardNumber: "340000000000009"},
+	}
+	if err := db.Create(&customers).Error; err != nil {
+		log.Fatalf("Failed to create customers: %v", err)
+	}
+
+	fmt.Println("--- Stored in the Database ---")
+	printStored(db)
+	fmt.Println("\n--- Loaded with GORM ---")
+	printDecrypted(db)
+
+	// The column name is authenticated, a ciphertext moved to another column doesn't decrypt
+	fmt.Println("\n--- Tampering ---")
+	db.Exec("UPDATE customers SET ssn = card_number WHERE name = ?", "Bob Smith")
+	var bob Customer
+	err = db.Where("name = ?", "Bob Smith").First(&bob).Error
+	fmt.Printf("  Card number copied into the SSN column: %v\n", err)
+	bob = customers[1]
+	db.Model(&bob).Select("ssn").Updates(&bob)
+
+	fmt.Println("\n--- Key Rotation ---")
+	if err := keyring.AddKey("k2", NewKey()); err != nil {
+		log.Fatalf("Failed to add key: %v", err)
+	}
+	if err := keyring.SetCurrent("k2"); err != nil {
+		log.Fatalf("Failed to switch key: %v", err)
+	}
+
+	// New writes use k2 right away, existing rows still need k1
+	db.Create(&Customer{Name: "Dave Brown", Email: "dave@example.com", SSN: "111-22-3333", CardNumber: "6011000000000004"})
+	fmt.Printf("  Before rotation, SSNs per key: %s\n", countByKey(db))
+
+	rotated, err := RotateKeys(db, keyring, 2)
+	if err != nil {
+		log.Fatalf("Failed to rotate keys: %v", err)
+	}
+	fmt.Printf("  Re-encrypted %d customers, SSNs per key: %s\n", rotated, countByKey(db))
+
+	// Once nothing uses k1 it can be retired
+	if err := keyring.RemoveKey("k1"); err != nil {
+		log.Fatalf("Failed to remove key: %v", err)
+	}
+	fmt.Println("  Removed k1, the data is still readable:")
+	printDecrypted(db)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// EncryptedSerializer encrypts string fields tagged with gorm:"serializer:encrypted"
+// when they are written and decrypts them when they are read
+type EncryptedSerializer struct {
+	keyring *Keyring
+}
+
+// additionalData binds a ciphertext to its column, so it can't be copied to another one
+func additionalData(field *schema.Field) []byte {
+	return []byte(field.Schema.Table + "." + field.DBName)
+}
+
+// Scan decrypts the database value into the field
+func (s EncryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue any) error {
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("unsupported type %T for encrypted field %s", dbValue, field.Name)
+	}
+
+	var plaintext []byte
+	if stored != "" {
+		var err error
+		if plaintext, err = s.keyring.Decrypt(stored, additionalData(field)); err != nil {
+			return fmt.Errorf("decrypting %s: %w", field.DBName, err)
+		}
+	}
+
+	field.ReflectValueOf(ctx, dst).SetString(string(plaintext))
+	return nil
+}
+
+// Value encrypts the field with the current key. Empty strings are stored as is.
+func (s EncryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue any) (any, error) {
+	plaintext, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("encrypted field %s must be a string", field.Name)
+	}
+	if plaintext == "" {
+		return "", nil
+	}
+	return s.keyring.Encrypt([]byte(plaintext), additionalData(field))
+}
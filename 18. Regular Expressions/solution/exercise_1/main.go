@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrMalformedLine is returned for lines that don't match the log format
+var ErrMalformedLine = errors.New("malformed log line")
+
+// combinedLog matches the Apache/nginx combined log format, optionally followed
+// by the request duration ($request_time in nginx, %D in Apache).
+// Every part of interest is a named capture group.
+var combinedLog = regexp.MustCompile(
+	`^(?P<ip>\S+) \S+ (?P<user>\S+) \[(?P<time>[^\]]+)\] ` +
+		`"(?:(?P<method>[A-Z]+) (?P<path>[^ "]+) (?P<protocol>HTTP/[0-9.]+)|-)" ` +
+		`(?P<status>\d{3}) (?P<bytes>\d+|-) "(?P<referer>[^"]*)" "(?P<agent>[^"]*)"` +
+		`(?: (?P<duration>\d+(?:\.\d+)?))?$`)
+
+// Index of every named group, looked up once instead of on every line
+var (
+	ipGroup       = combinedLog.SubexpIndex("ip")
+	userGroup     = combinedLog.SubexpIndex("user")
+	timeGroup     = combinedLog.SubexpIndex("time")
+	methodGroup   = combinedLog.SubexpIndex("method")
+	pathGroup     = combinedLog.SubexpIndex("path")
+	protocolGroup = combinedLog.SubexpIndex("protocol")
+	statusGroup   = combinedLog.SubexpIndex("status")
+	bytesGroup    = combinedLog.SubexpIndex("bytes")
+	refererGroup  = combinedLog.SubexpIndex("referer")
+	agentGroup    = combinedLog.SubexpIndex("agent")
+	durationGroup = combinedLog.SubexpIndex("duration")
+)
+
+// LogEntry is a parsed access log line
+type LogEntry struct {
+	IP        string
+	User      string
+	Time      time.Time
+	Method    string
+	Path      string
+	Protocol  string
+	Status    int
+	Bytes     int64
+	Referer   string
+	UserAgent string
+	Duration  time.Duration // Zero when the log has no duration field
+}
+
+// parseDuration reads nginx durations in seconds ("0.123") and Apache durations in microseconds ("123000")
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if strings.Contains(value, ".") {
+		seconds, err := strconv.ParseFloat(value, 64)
+		return time.Duration(seconds * float64(time.Second)), err
+	}
+	micros, err := strconv.ParseInt(value, 10, 64)
+	return time.Duration(micros) * time.Microsecond, err
+}
+
+// ParseLine parses one line of a combined access log
+func ParseLine(line string) (*LogEntry, error) {
+	m := combinedLog.FindStringSubmatch(line)
+	if m == nil {
+		return nil, ErrMalformedLine
+	}
+
+	timestamp, err := time.Parse("02/Jan/2006:15:04:05 -0700", m[timeGroup])
+	if err != nil {
+		return nil, fmt.Errorf("%w: time: %v", ErrMalformedLine, err)
+	}
+	status, _ := strconv.Atoi(m[statusGroup]) // Always three digits
+	var size int64
+	if m[bytesGroup] != "-" {
+		size, _ = strconv.ParseInt(m[bytesGroup], 10, 64)
+	}
+	duration, err := parseDuration(m[durationGroup])
+	if err != nil {
+		return nil, fmt.Errorf("%w: duration: %v", ErrMalformedLine, err)
+	}
+
+	return &LogEntry{
+		IP:        m[ipGroup],
+		User:      m[userGroup],
+		Time:      timestamp,
+		Method:    m[methodGroup],
+		Path:      m[pathGroup],
+		Protocol:  m[protocolGroup],
+		Status:    status,
+		Bytes:     size,
+		Referer:   m[refererGroup],
+		UserAgent: m[agentGroup],
+		Duration:  duration,
+	}, nil
+}
+
+// ipv4 matches an IPv4 address, capturing the first three octets
+var ipv4 = regexp.MustCompile(`\b(\d{1,3}\.\d{1,3}\.\d{1,3})\.\d{1,3}\b`)
+
+// anonymize replaces the last octet of every IPv4 address, as required before sharing logs
+func anonymize(line string) string {
+	return ipv4.ReplaceAllString(line, "${1}.0")
+}
+
+// queryParam matches the name and value of URL query parameters
+var queryParam = regexp.MustCompile(`[?&]([^=&#\s]+)=([^&#\s"]*)`)
+
+func main() {
+	lines := []string{
+		`192.168.1.20 - - [17/Oct/2026:10:15:32 +0000] "GET /api/v1/todos?page=2&limit=20 HTTP/1.1" 200 1534 "-" "curl/8.5.0" 0.012`,
+		`10.0.0.7 - alice [17/Oct/2026:10:15:33 +0200] "POST /api/v1/todos HTTP/2.0" 201 87 "https://example.com/app" "Mozilla/5.0 (X11; Linux x86_64)" 45210`,
+		`203.0.113.9 - - [17/Oct/2026:10:15:34 +0000] "-" 400 0 "-" "-"`,
+		`this is not an access log line`,
+	}
+
+	fmt.Println("--- Named Capture Groups ---")
+	for i, name := range combinedLog.SubexpNames() {
+		if name != "" {
+			fmt.Printf("%2d: %s\n", i, name)
+		}
+	}
+
+	fmt.Println("\n--- Parsed Entries ---")
+	for _, line := range lines {
+		entry, err := ParseLine(line)
+		if err != nil {
+			fmt.Printf("Error: %v: %q\n", err, line)
+			continue
+		}
+		fmt.Printf("%s %-4s %-30s status=%d bytes=%d duration=%v user=%s\n",
+			entry.Time.UTC().Format(time.RFC3339), entry.Method, entry.Path, entry.Status,
+			entry.Bytes, entry.Duration, entry.User)
+	}
+
+	fmt.Println("\n--- Other regexp Functions ---")
+	fmt.Println("Anonymized:", anonymize(lines[0]))
+	for _, m := range queryParam.FindAllStringSubmatch(lines[0], -1) {
+		fmt.Printf("Query parameter %s = %s\n", m[1], m[2])
+	}
+	fmt.Println("Is a 5xx status:", regexp.MustCompile(`^5\d\d$`).MatchString("503"))
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxLineSize is the longest line accepted. bufio.Scanner stops with
+// bufio.ErrTooLong on longer lines, 64KB by default.
+const maxLineSize = 1024 * 1024
+
+// countingReader counts the bytes read, to report the throughput
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// openLog opens a log file, "-" for standard input. Files ending in .gz are decompressed while reading.
+func openLog(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gz, f}, nil
+}
+
+// Analyze reads the log line by line, so only one line is in memory at a time
+func Analyze(r io.Reader, stats *Stats, maxErrors int) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	var req Request
+	for scanner.Scan() {
+		stats.Lines++
+		line := scanner.Bytes() // Only valid until the next call to Scan
+		if len(line) == 0 {
+			stats.Malformed++
+			continue
+		}
+		if err := ParseLine(line, &req); err != nil {
+			stats.Malformed++
+			if stats.Malformed <= maxErrors {
+				fmt.Fprintf(os.Stderr, "line %d: %v: %.80q\n", stats.Lines, err, line)
+			}
+			continue
+		}
+		stats.Add(&req)
+	}
+	return scanner.Err()
+}
+
+// generate writes n random log lines, a few of them malformed
+func generate(w io.Writer, n int) error {
+	paths := []string{"/", "/api/v1/todos", "/api/v1/todos/42", "/api/v1/users/me", "/login", "/static/app.js", "/healthz"}
+	methods := []string{"GET", "GET", "GET", "GET", "POST", "PUT", "DELETE"}
+	statuses := []int{200, 200, 200, 200, 200, 200, 201, 204, 301, 304, 400, 401, 404, 500, 502}
+	agents := []string{"curl/8.5.0", "Mozilla/5.0 (X11; Linux x86_64)", "Go-http-client/1.1"}
+
+	bw := bufio.NewWriter(w)
+	start := time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+	for i := range n {
+		if rand.IntN(1000) == 0 {
+			fmt.Fprintln(bw, "garbage line written by a misconfigured proxy")
+			continue
+		}
+		path := paths[rand.IntN(len(paths))]
+		if rand.IntN(4) == 0 {
+			path += fmt.Sprintf("?page=%d", rand.IntN(10))
+		}
+		// Exponentially distributed latency with a 40ms mean
+		latency := rand.ExpFloat64() * 0.040
+		fmt.Fprintf(bw, "10.0.%d.%d - - [%s] \"%s %s HTTP/1.1\" %d %d \"-\" \"%s\" %.3f\n",
+			rand.IntN(256), rand.IntN(256), start.Add(time.Duration(i)*10*time.Millisecond).Format("02/Jan/2006:15:04:05 -0700"),
+			methods[rand.IntN(len(methods))], path, statuses[rand.IntN(len(statuses))], rand.IntN(50000),
+			agents[rand.IntN(len(agents))], latency)
+	}
+	return bw.Flush()
+}
+
+func main() {
+	generateLines := flag.Int("generate", 0, "write this many random log lines to the file instead of analyzing it")
+	top := flag.Int("top", 10, "number of paths to show")
+	maxErrors := flag.Int("errors", 5, "number of malformed lines to print")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] FILE (.gz or - for stdin)\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	path := flag.Arg(0)
+
+	if *generateLines > 0 {
+		f, err := os.Create(path)
+		if err != nil {
+			log.Fatalf("Failed to create log: %v", err)
+		}
+		if err := generate(f, *generateLines); err != nil {
+			log.Fatalf("Failed to generate log: %v", err)
+		}
+		if err := f.Close(); err != nil {
+			log.Fatalf("Failed to write log: %v", err)
+		}
+		fmt.Printf("Wrote %d lines to %s\n", *generateLines, path)
+		return
+	}
+
+	file, err := openLog(path)
+	if err != nil {
+		log.Fatalf("Failed to open log: %v", err)
+	}
+	defer file.Close()
+
+	stats := NewStats()
+	counter := &countingReader{r: file}
+	start := time.Now()
+	if err := Analyze(counter, stats, *maxErrors); err != nil {
+		log.Fatalf("Failed to read log: %v", err)
+	}
+	elapsed := time.Since(start)
+
+	stats.Report(os.Stdout, *top)
+	fmt.Printf("\nProcessed %.1f MB in %v (%.0f lines/s)\n",
+		float64(counter.n)/(1024*1024), elapsed.Round(time.Millisecond), float64(stats.Lines)/elapsed.Seconds())
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// ErrMalformedLine is returned for lines that don't match the log format
+var ErrMalformedLine = errors.New("malformed log line")
+
+// combinedLog matches the Apache/nginx combined log format, optionally followed
+// by the request duration ($request_time in nginx, %D in Apache)
+var combinedLog = regexp.MustCompile(
+	`^(?P<ip>\S+) \S+ (?P<user>\S+) \[(?P<time>[^\]]+)\] ` +
+		`"(?:(?P<method>[A-Z]+) (?P<path>[^ "]+) (?P<protocol>HTTP/[0-9.]+)|-)" ` +
+		`(?P<status>\d{3}) (?P<bytes>\d+|-) "(?P<referer>[^"]*)" "(?P<agent>[^"]*)"` +
+		`(?: (?P<duration>\d+(?:\.\d+)?))?$`)
+
+// Only the groups needed for the report
+var (
+	methodGroup   = combinedLog.SubexpIndex("method")
+	pathGroup     = combinedLog.SubexpIndex("path")
+	statusGroup   = combinedLog.SubexpIndex("status")
+	bytesGroup    = combinedLog.SubexpIndex("bytes")
+	durationGroup = combinedLog.SubexpIndex("duration")
+)
+
+// Request holds the fields of a log line used by the report
+type Request struct {
+	Method   string
+	Path     string
+	Status   int
+	Bytes    int64
+	Duration time.Duration
+}
+
+// parseDuration reads nginx durations in seconds ("0.123") and Apache durations in microseconds ("123000")
+func parseDuration(value []byte) (time.Duration, error) {
+	if len(value) == 0 {
+		return 0, nil
+	}
+	if bytes.IndexByte(value, '.') >= 0 {
+		seconds, err := strconv.ParseFloat(string(value), 64)
+		return time.Duration(seconds * float64(time.Second)), err
+	}
+	micros, err := strconv.ParseInt(string(value), 10, 64)
+	return time.Duration(micros) * time.Microsecond, err
+}
+
+// stripQuery removes the query string, so /todos?page=1 and /todos?page=2 count as the same path
+func stripQuery(path []byte) []byte {
+	if i := bytes.IndexByte(path, '?'); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
+
+// ParseLine parses a line into req. It works on the bytes returned by the scanner
+// and reuses req, so lines that are only counted don't allocate.
+func ParseLine(line []byte, req *Request) error {
+	m := combinedLog.FindSubmatch(line)
+	if m == nil {
+		return ErrMalformedLine
+	}
+
+	status, err := strconv.Atoi(string(m[statusGroup]))
+	if err != nil {
+		return ErrMalformedLine
+	}
+	var size int64
+	if !bytes.Equal(m[bytesGroup], []byte("-")) {
+		if size, err = strconv.ParseInt(string(m[bytesGroup]), 10, 64); err != nil {
+			return ErrMalformedLine
+		}
+	}
+	duration, err := parseDuration(m[durationGroup])
+	if err != nil {
+		return ErrMalformedLine
+	}
+
+	req.Method = string(m[methodGroup])
+	req.Path = string(stripQuery(m[pathGroup]))
+	req.Status = status
+	req.Bytes = size
+	req.Duration = duration
+	return nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the latency histogram. Keeping counts
+// per bucket instead of every duration uses constant memory, whatever the size
+// of the log, at the cost of approximate percentiles.
+var latencyBuckets = []time.Duration{
+	time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond,
+	50 * time.Millisecond, 75 * time.Millisecond, 100 * time.Millisecond,
+	150 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond,
+	500 * time.Millisecond, time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second,
+}
+
+// PathStats aggregates the requests to one path
+type PathStats struct {
+	Path          string
+	Requests      int
+	Errors        int // Responses with a 5xx status
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+}
+
+// Stats aggregates parsed requests
+type Stats struct {
+	Lines         int
+	Malformed     int
+	Bytes         int64
+	ByStatus      map[int]int
+	ByMethod      map[string]int
+	ByPath        map[string]*PathStats
+	Timed         int // Requests with a duration
+	TotalDuration time.Duration
+	MaxDuration   time.Duration
+	histogram     []int // One count per bucket, plus one for slower requests
+}
+
+// NewStats creates empty statistics
+func NewStats() *Stats {
+	return &Stats{
+		ByStatus:  make(map[int]int),
+		ByMethod:  make(map[string]int),
+		ByPath:    make(map[string]*PathStats),
+		histogram: make([]int, len(latencyBuckets)+1),
+	}
+}
+
+// Add records one request
+func (s *Stats) Add(req *Request) {
+	s.Bytes += req.Bytes
+	s.ByStatus[req.Status]++
+	if req.Method != "" {
+		s.ByMethod[req.Method]++
+	}
+
+	if req.Path != "" {
+		path, ok := s.ByPath[req.Path]
+		if !ok {
+			path = &PathStats{Path: req.Path}
+			s.ByPath[req.Path] = path
+		}
+		path.Requests++
+		if req.Status >= 500 {
+			path.Errors++
+		}
+		path.TotalDuration += req.Duration
+		path.MaxDuration = max(path.MaxDuration, req.Duration)
+	}
+
+	if req.Duration > 0 {
+		s.Timed++
+		s.TotalDuration += req.Duration
+		s.MaxDuration = max(s.MaxDuration, req.Duration)
+		bucket, _ := slices.BinarySearch(latencyBuckets, req.Duration)
+		s.histogram[bucket]++
+	}
+}
+
+// Percentile returns the upper bound of the bucket containing the p-th percentile,
+// or the slowest request when it is lower
+func (s *Stats) Percentile(p float64) time.Duration {
+	if s.Timed == 0 {
+		return 0
+	}
+	rank := int(float64(s.Timed)*p/100 + 0.5)
+	seen := 0
+	for i, count := range s.histogram {
+		seen += count
+		if seen >= rank && count > 0 {
+			if i == len(latencyBuckets) {
+				return s.MaxDuration
+			}
+			return min(latencyBuckets[i], s.MaxDuration)
+		}
+	}
+	return s.MaxDuration
+}
+
+// statusClasses groups the status codes by class (2xx, 3xx...)
+func (s *Stats) statusClasses() map[string]int {
+	classes := make(map[string]int)
+	for status, count := range s.ByStatus {
+		classes[fmt.Sprintf("%dxx", status/100)] += count
+	}
+	return classes
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// topPaths returns the n paths with the most requests
+func (s *Stats) topPaths(n int) []*PathStats {
+	paths := make([]*PathStats, 0, len(s.ByPath))
+	for _, p := range s.ByPath {
+		paths = append(paths, p)
+	}
+	slices.SortFunc(paths, func(a, b *PathStats) int {
+		return cmp.Or(cmp.Compare(b.Requests, a.Requests), cmp.Compare(a.Path, b.Path))
+	})
+	return paths[:min(n, len(paths))]
+}
+
+// Report writes the statistics
+func (s *Stats) Report(w io.Writer, top int) {
+	parsed := s.Lines - s.Malformed
+	fmt.Fprintf(w, "Lines: %d, parsed: %d, malformed: %d, bytes sent: %d\n", s.Lines, parsed, s.Malformed, s.Bytes)
+	if parsed == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\n--- Status Codes ---")
+	classes := s.statusClasses()
+	for _, class := range sortedKeys(classes) {
+		fmt.Fprintf(w, "%s: %d (%.1f%%)\n", class, classes[class], 100*float64(classes[class])/float64(parsed))
+	}
+	for _, status := range sortedKeys(s.ByStatus) {
+		fmt.Fprintf(w, "  %d: %d\n", status, s.ByStatus[status])
+	}
+
+	fmt.Fprintln(w, "\n--- Methods ---")
+	for _, method := range sortedKeys(s.ByMethod) {
+		fmt.Fprintf(w, "%-7s %d\n", method, s.ByMethod[method])
+	}
+
+	if s.Timed > 0 {
+		fmt.Fprintln(w, "\n--- Latency ---")
+		fmt.Fprintf(w, "avg: %v, p50: <=%v, p95: <=%v, p99: <=%v, max: %v\n",
+			(s.TotalDuration / time.Duration(s.Timed)).Round(time.Microsecond), s.Percentile(50), s.Percentile(95),
+			s.Percentile(99), s.MaxDuration)
+	}
+
+	fmt.Fprintf(w, "\n--- Top %d Paths ---\n", top)
+	fmt.Fprintf(w, "%-30s %10s %8s %12s %12s\n", "PATH", "REQUESTS", "5XX", "AVG", "MAX")
+	for _, p := range s.topPaths(top) {
+		fmt.Fprintf(w, "%-30s %10d %8d %12v %12v\n", p.Path, p.Requests, p.Errors,
+			(p.TotalDuration / time.Duration(p.Requests)).Round(time.Microsecond), p.MaxDuration)
+	}
+}
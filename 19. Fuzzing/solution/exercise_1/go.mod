@@ -0,0 +1,3 @@
+module golang-training/module-19/exercise-1
+
+go 1.25
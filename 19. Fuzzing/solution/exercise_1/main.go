@@ -0,0 +1,9 @@
+package main
+
+import "fmt"
+
+func main() {
+	for _, s := range []string{"hello", "racecar", "Hello, 世界", "\xff\xfe", "été"} {
+		fmt.Printf("%q reversed is %q, palindrome: %v\n", s, ReverseString(s), IsPalindrome(s))
+	}
+}
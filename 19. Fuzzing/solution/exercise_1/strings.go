@@ -0,0 +1,36 @@
+package main
+
+import "unicode/utf8"
+
+// ReverseString reverses a string rune by rune.
+//
+// Converting the string to []rune would replace bytes that aren't valid UTF-8
+// with U+FFFD, so reversing twice wouldn't give the original string back.
+// Reversing the invalid bytes one by one doesn't work either, they can form a
+// valid rune in the other order. Each run of invalid bytes is kept as a block.
+func ReverseString(s string) string {
+	reversed := make([]byte, len(s))
+	end := len(reversed)
+	for len(s) > 0 {
+		size := 0
+		for size < len(s) {
+			r, n := utf8.DecodeRuneInString(s[size:])
+			if r != utf8.RuneError || n != 1 {
+				if size == 0 {
+					size = n // A valid rune
+				}
+				break
+			}
+			size++ // An invalid byte, extend the block
+		}
+		end -= size
+		copy(reversed[end:], s[:size])
+		s = s[size:]
+	}
+	return string(reversed)
+}
+
+// IsPalindrome checks if a string reads the same in both directions
+func IsPalindrome(s string) bool {
+	return s == ReverseString(s)
+}
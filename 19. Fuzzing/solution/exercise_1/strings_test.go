@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+// FuzzReverseString checks properties that hold for any input, instead of
+// comparing with expected outputs:
+//   - reversing twice gives the original string
+//   - the result has the same length and, for valid UTF-8, is valid UTF-8
+func FuzzReverseString(f *testing.F) {
+	// Seed corpus, the fuzzer mutates these values. Failing inputs found by the
+	// fuzzer are saved to testdata/fuzz/FuzzReverseString and run by go test too.
+	for _, seed := range []string{"", "a", "hello", "Hello, 世界", "🙂👍", "e\u0301"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		reversed := ReverseString(s)
+		if len(reversed) != len(s) {
+			t.Fatalf("ReverseString(%q) has length %d, want %d", s, len(reversed), len(s))
+		}
+		if utf8.ValidString(s) && !utf8.ValidString(reversed) {
+			t.Fatalf("ReverseString(%q) = %q is not valid UTF-8", s, reversed)
+		}
+		if twice := ReverseString(reversed); twice != s {
+			t.Fatalf("ReverseString(ReverseString(%q)) = %q", s, twice)
+		}
+	})
+}
+
+// FuzzIsPalindrome checks that a valid string followed by its reverse is a
+// palindrome and that a string and its reverse are both palindromes or not
+func FuzzIsPalindrome(f *testing.F) {
+	for _, seed := range []string{"", "racecar", "ab", "世界"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		// Concatenating invalid UTF-8 can form a new rune where the strings meet
+		// (testdata/fuzz/FuzzIsPalindrome/2aefc0a0f6dc9e44), the property was wrong
+		if mirrored := s + ReverseString(s); utf8.ValidString(s) && !IsPalindrome(mirrored) {
+			t.Fatalf("IsPalindrome(%q) = false, want true", mirrored)
+		}
+		if IsPalindrome(s) != IsPalindrome(ReverseString(s)) {
+			t.Fatalf("IsPalindrome(%q) and IsPalindrome of its reverse differ", s)
+		}
+	})
+}
+
+// TestReverseStringRegressions keeps the inputs the fuzzer found with the
+// original []rune implementation and the first fix, named after the bug they caught
+func TestReverseStringRegressions(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		// testdata/fuzz/FuzzReverseString/def578230616f8b9: the invalid byte
+		// became "\uFFFD", so reversing twice returned "\xef\xbf\xbd"
+		{"invalid byte", "\xff", "\xff"},
+		{"invalid bytes keep their order around runes", "a\xffé", "é\xffa"},
+		// testdata/fuzz/FuzzReverseString/9f9e3a0ebee52075: reversing the bytes one
+		// by one turned "\x86\x86\xea" into "\xea\x86\x86", the valid rune 'ꆆ'
+		{"invalid bytes stay in order", "\x86\x86\xea", "\x86\x86\xea"},
+		// testdata/fuzz/FuzzIsPalindrome/d21e0498e22959f3: "\xee" + its reverse
+		// was "\xee\uFFFD", which isn't a palindrome
+		{"invalid byte palindrome", "\xee\xee", "\xee\xee"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReverseString(tt.in); got != tt.want {
+				t.Errorf("ReverseString(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import "fmt"
+
+func main() {
+	lines := []string{
+		"Name, Age, City",
+		"John, 30, New York",
+		`"Smith, John", 42, "Paris | France"`,
+		"Invalid line with no commas",
+		`"unterminated, 1, 2`,
+	}
+
+	for _, line := range lines {
+		transformed, err := TransformLine(line)
+		if err != nil {
+			fmt.Printf("%-40q error: %v\n", line, err)
+			continue
+		}
+		fields, _ := ParsePipeLine(transformed)
+		fmt.Printf("%-40q -> %-35q %q\n", line, transformed, fields)
+	}
+}
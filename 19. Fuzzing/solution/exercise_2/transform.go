@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrFormat is returned for lines that can't be transformed
+var ErrFormat = errors.New("invalid line format")
+
+// pipeEscaper escapes the separator and the escape character in fields
+var pipeEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`)
+
+// TransformLine converts a CSV line to the pipe-delimited format, trimming the
+// whitespace around every field.
+//
+// The version of module 07 split the line on commas. Fuzzing showed it broke
+// quoted fields ("Smith, John") and fields containing a pipe, so the line is
+// now read with encoding/csv and pipes are escaped.
+func TransformLine(line string) (string, error) {
+	reader := csv.NewReader(strings.NewReader(line))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true // Accept a space before a quoted field
+	records, err := reader.ReadAll()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	if len(records) != 1 {
+		return "", fmt.Errorf("%w: expected one record, got %d", ErrFormat, len(records))
+	}
+	fields := records[0]
+	if len(fields) < 2 {
+		return "", fmt.Errorf("%w: line does not contain delimiters", ErrFormat)
+	}
+
+	for i, field := range fields {
+		fields[i] = pipeEscaper.Replace(strings.TrimSpace(field))
+	}
+	return strings.Join(fields, "|"), nil
+}
+
+// ParsePipeLine splits a line produced by TransformLine back into its fields
+func ParsePipeLine(line string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '|':
+			fields = append(fields, field.String())
+			field.Reset()
+		case '\\':
+			if i+1 == len(line) || (line[i+1] != '\\' && line[i+1] != '|') {
+				return nil, fmt.Errorf("%w: invalid escape at position %d", ErrFormat, i)
+			}
+			i++
+			field.WriteByte(line[i])
+		default:
+			field.WriteByte(line[i])
+		}
+	}
+	return append(fields, field.String()), nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import "fmt"
+
+func main() {
+	calc := NewCalculator()
+
+	for _, input := range []string{"1 + 2 * 3", "2 ^ 3 ^ 2", "-(4 - 10) / 4", "1.5e3 * 2", "10 / (5 - 5)", "2 * (3 + 4", "1e999"} {
+		node, err := Parse(input)
+		if err != nil {
+			fmt.Printf("%-16s error: %v\n", input, err)
+			continue
+		}
+		result, err := node.Eval(calc)
+		if err != nil {
+			fmt.Printf("%-16s %s error: %v\n", input, node, err)
+			continue
+		}
+		fmt.Printf("%-16s %s = %g\n", input, node, result)
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrSyntax is returned for expressions that can't be parsed
+	ErrSyntax = errors.New("syntax error")
+	// ErrTooDeep is returned for expressions nested deeper than maxDepth, it wraps ErrSyntax
+	ErrTooDeep = fmt.Errorf("%w: expression nested too deeply", ErrSyntax)
+)
+
+// Node is a node of the expression tree
+type Node interface {
+	// Eval computes the value of the node with the operations of the calculator
+	Eval(calc *Calculator) (float64, error)
+	// String returns the expression fully parenthesized, it parses back to the same tree
+	String() string
+}
+
+// Number is a number literal
+type Number struct {
+	Value float64
+}
+
+func (n Number) Eval(calc *Calculator) (float64, error) {
+	return n.Value, nil
+}
+
+func (n Number) String() string {
+	return strconv.FormatFloat(n.Value, 'g', -1, 64)
+}
+
+// Negate is the unary minus
+type Negate struct {
+	Operand Node
+}
+
+func (n Negate) Eval(calc *Calculator) (float64, error) {
+	value, err := n.Operand.Eval(calc)
+	if err != nil {
+		return 0, err
+	}
+	return calc.Calculate(0, value, "-")
+}
+
+func (n Negate) String() string {
+	return "(-" + n.Operand.String() + ")"
+}
+
+// Binary is an operation registered in the calculator
+type Binary struct {
+	Op          string
+	Left, Right Node
+}
+
+func (b Binary) Eval(calc *Calculator) (float64, error) {
+	left, err := b.Left.Eval(calc)
+	if err != nil {
+		return 0, err
+	}
+	right, err := b.Right.Eval(calc)
+	if err != nil {
+		return 0, err
+	}
+	return calc.Calculate(left, right, b.Op)
+}
+
+func (b Binary) String() string {
+	return "(" + b.Left.String() + " " + b.Op + " " + b.Right.String() + ")"
+}
+
+// maxDepth limits the nesting of parentheses, unary minus and powers. Each
+// level is a recursive call, and String copies the whole subtree at every
+// level: 200 000 minus signs took 30 seconds to print.
+const maxDepth = 1000
+
+// parser is a recursive descent parser for the grammar:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = power { ("*" | "/") power }
+//	power   = unary [ "^" power ]
+//	unary   = "-" unary | primary
+//	primary = number | "(" expr ")"
+type parser struct {
+	input string
+	pos   int
+	depth int
+}
+
+// Parse parses an arithmetic expression such as "2 * (3 + 4) ^ 2"
+func Parse(input string) (Node, error) {
+	p := &parser{input: input}
+	node, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != 0 {
+		return nil, p.errorf("unexpected %q", p.peek())
+	}
+	return node, nil
+}
+
+// Evaluate parses an expression and computes it with the calculator
+func Evaluate(calc *Calculator, input string) (float64, error) {
+	node, err := Parse(input)
+	if err != nil {
+		return 0, err
+	}
+	return node.Eval(calc)
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w at position %d: %s", ErrSyntax, p.pos, fmt.Sprintf(format, args...))
+}
+
+// peek skips whitespace and returns the next byte, 0 at the end of the input
+func (p *parser) peek() byte {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+	if p.pos == len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+// enter counts a nesting level, the caller must call leave when it returns
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > maxDepth {
+		return fmt.Errorf("%w at position %d", ErrTooDeep, p.pos)
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) expr() (Node, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '+' || op == '-'; op = p.peek() {
+		p.pos++
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		left = Binary{Op: string(op), Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) term() (Node, error) {
+	left, err := p.power()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '*' || op == '/'; op = p.peek() {
+		p.pos++
+		right, err := p.power()
+		if err != nil {
+			return nil, err
+		}
+		left = Binary{Op: string(op), Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) power() (Node, error) {
+	base, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != '^' {
+		return base, nil
+	}
+	p.pos++
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	exponent, err := p.power() // Right associative: 2^3^2 is 2^(3^2)
+	if err != nil {
+		return nil, err
+	}
+	return Binary{Op: "^", Left: base, Right: exponent}, nil
+}
+
+func (p *parser) unary() (Node, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	if p.peek() == '-' {
+		p.pos++
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return Negate{Operand: operand}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (Node, error) {
+	switch c := p.peek(); {
+	case c == '(':
+		p.pos++
+		node, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return node, nil
+	case c == '.' || (c >= '0' && c <= '9'):
+		return p.number()
+	case c == 0:
+		return nil, p.errorf("unexpected end of expression")
+	default:
+		return nil, p.errorf("unexpected %q", c)
+	}
+}
+
+// number reads a decimal number with an optional exponent, like 1.5e-3
+func (p *parser) number() (Node, error) {
+	start := p.pos
+	end := start
+	for end < len(p.input) && strings.IndexByte("0123456789.eE", p.input[end]) >= 0 {
+		end++
+		// A sign is part of the number right after the exponent
+		if end < len(p.input) && (p.input[end-1] == 'e' || p.input[end-1] == 'E') &&
+			(p.input[end] == '+' || p.input[end] == '-') {
+			end++
+		}
+	}
+
+	value, err := strconv.ParseFloat(p.input[start:end], 64)
+	if err != nil {
+		return nil, p.errorf("invalid number %q", p.input[start:end])
+	}
+	p.pos = end
+	return Number{Value: value}, nil
+}
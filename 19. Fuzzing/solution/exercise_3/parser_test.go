@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// FuzzParse checks that any input either fails with ErrSyntax or parses to a
+// tree whose String form parses back to the same tree with the same value
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{"1 + 2 * 3", "2 ^ 3 ^ 2", "-(4 - 10) / 4", "1.5e-3", "((1))", "10 / 0", "1 +", ")("} {
+		f.Add(seed)
+	}
+	calc := NewCalculator()
+
+	f.Fuzz(func(t *testing.T, input string) {
+		node, err := Parse(input)
+		if err != nil {
+			if !errors.Is(err, ErrSyntax) {
+				t.Fatalf("Parse(%q) returned %v, want ErrSyntax", input, err)
+			}
+			return
+		}
+
+		// The printed form adds parentheses, it can be nested deeper than the input
+		printed := node.String()
+		reparsed, err := Parse(printed)
+		if errors.Is(err, ErrTooDeep) {
+			t.Skip()
+		}
+		if err != nil {
+			t.Fatalf("Parse(%q) failed on the printed form of %q: %v", printed, input, err)
+		}
+		if reparsed.String() != printed {
+			t.Fatalf("%q printed as %q, then as %q", input, printed, reparsed.String())
+		}
+
+		want, wantErr := node.Eval(calc)
+		got, gotErr := reparsed.Eval(calc)
+		if (wantErr == nil) != (gotErr == nil) || !(got == want || math.IsNaN(got) && math.IsNaN(want)) {
+			t.Fatalf("%q = %v (%v), printed form %q = %v (%v)", input, want, wantErr, printed, got, gotErr)
+		}
+	})
+}
+
+// TestParseRegressions keeps the inputs that broke the parser. The fuzzer
+// mostly generates short inputs, so deep nesting is tested explicitly.
+func TestParseRegressions(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"empty", "", true},
+		{"trailing operator", "1 +", true},
+		{"lone parenthesis", "(", true},
+		{"out of range number", "1e999", true},
+		{"nested at the limit", strings.Repeat("(", maxDepth-1) + "1" + strings.Repeat(")", maxDepth-1), false},
+		// 200 000 minus signs took 30 seconds to print before the depth limit
+		{"deep unary minus", strings.Repeat("-", 200000) + "1", true},
+		{"deep parentheses", strings.Repeat("(", 200000), true},
+		{"deep powers", strings.Repeat("2^", 200000) + "2", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse returned %v, want error: %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrSyntax) {
+				t.Fatalf("Parse returned %v, want ErrSyntax", err)
+			}
+		})
+	}
+}
+
+// TestParsePrintedTooDeep documents why FuzzParse skips ErrTooDeep: each
+// minus sign prints as "(-", two levels instead of one
+func TestParsePrintedTooDeep(t *testing.T) {
+	node, err := Parse(strings.Repeat("-", 600) + "1")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if _, err := Parse(node.String()); !errors.Is(err, ErrTooDeep) {
+		t.Fatalf("Parse of the printed form returned %v, want ErrTooDeep", err)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// processBatch simulates work that must not be interrupted halfway
+func processBatch(n int) {
+	fmt.Printf("Processing batch %d...\n", n)
+	time.Sleep(700 * time.Millisecond)
+	fmt.Printf("Batch %d done\n", n)
+}
+
+func main() {
+	// ctx is cancelled on the first Ctrl+C (SIGINT) or SIGTERM (kill, docker stop, systemd)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("Running with PID %d, press Ctrl+C or run: kill %d\n", os.Getpid(), os.Getpid())
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	batch := 0
+	for {
+		select {
+		case <-ctx.Done():
+			// Restore the default behaviour, a second Ctrl+C kills the program right away
+			stop()
+			fmt.Printf("\n%v, finishing the current work (Ctrl+C again to force)\n", context.Cause(ctx))
+			time.Sleep(2 * time.Second) // Flush buffers, close connections...
+			fmt.Println("Stopped cleanly")
+			return
+		case <-ticker.C:
+			batch++
+			// The batch runs to completion, the signal is handled on the next iteration
+			processBatch(batch)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Duration is a time.Duration written as "500ms" or "10s" in JSON
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"10s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Config is read from a JSON file at startup and on SIGHUP. QueueSize and
+// HTTPAddr are only read at startup, changing them requires a restart.
+type Config struct {
+	Workers         int      `json:"workers"`
+	QueueSize       int      `json:"queue_size"`
+	JobInterval     Duration `json:"job_interval"`
+	HTTPAddr        string   `json:"http_addr"`
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
+}
+
+// LoadConfig reads and validates the configuration file, missing fields keep their default value
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{
+		Workers:         2,
+		QueueSize:       10,
+		JobInterval:     Duration(time.Second),
+		HTTPAddr:        ":8080",
+		ShutdownTimeout: Duration(10 * time.Second),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	var errs []error
+	if cfg.Workers < 1 || cfg.Workers > 100 {
+		errs = append(errs, fmt.Errorf("workers must be between 1 and 100, got %d", cfg.Workers))
+	}
+	if cfg.QueueSize < 1 {
+		errs = append(errs, fmt.Errorf("queue_size must be positive, got %d", cfg.QueueSize))
+	}
+	if cfg.JobInterval <= 0 {
+		errs = append(errs, errors.New("job_interval must be positive"))
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("shutdown_timeout must be positive"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"golang-training/module-11/healthcheck"
+)
+
+// Daemon owns the subsystems and starts and stops them in dependency order:
+// the scheduler and the HTTP server submit jobs to the worker pool, so the
+// pool starts first and stops last.
+type Daemon struct {
+	configPath string
+	config     atomic.Pointer[Config]
+	draining   atomic.Bool
+
+	pool      *WorkerPool
+	scheduler *Scheduler
+	health    *healthcheck.Health
+	server    *http.Server
+	serverErr chan error
+}
+
+// NewDaemon creates the subsystems from the configuration
+func NewDaemon(cfg *Config, configPath string) *Daemon {
+	d := &Daemon{
+		configPath: configPath,
+		pool:       NewWorkerPool(cfg.QueueSize),
+		serverErr:  make(chan error, 1),
+	}
+	d.config.Store(cfg)
+	d.scheduler = NewScheduler(d.pool, func() time.Duration {
+		return time.Duration(d.config.Load().JobInterval)
+	})
+	d.health = d.healthChecks()
+	d.server = &http.Server{
+		Addr:              cfg.HTTPAddr,
+		Handler:           d.routes(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return d
+}
+
+// healthChecks creates the checks of /livez, /readyz and /healthz. The
+// checks only read counters, so their results are cached briefly.
+func (d *Daemon) healthChecks() *healthcheck.Health {
+	health := healthcheck.New(time.Second, time.Second)
+
+	// Without workers, queued jobs never run: a restart fixes that
+	health.Add("workers", healthcheck.Liveness, func(ctx context.Context) error {
+		if d.pool.Stats().Workers == 0 {
+			return fmt.Errorf("%w: no worker running", healthcheck.ErrUnhealthy)
+		}
+		return nil
+	})
+
+	// A full queue rejects new jobs: stop sending traffic until it drains
+	health.Add("queue", healthcheck.Readiness, func(ctx context.Context) error {
+		queued, size := d.pool.Stats().Queued, d.config.Load().QueueSize
+		if queued >= size {
+			return fmt.Errorf("%w: queue full, %d/%d jobs", healthcheck.ErrUnhealthy, queued, size)
+		}
+		return nil
+	})
+	return health
+}
+
+func (d *Daemon) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	// Load balancers stop sending traffic when /readyz fails
+	d.health.Register(mux)
+
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"config":   d.config.Load(),
+			"pool":     d.pool.Stats(),
+			"draining": d.draining.Load(),
+		})
+	})
+
+	mux.HandleFunc("POST /jobs", func(w http.ResponseWriter, r *http.Request) {
+		duration, err := time.ParseDuration(r.URL.Query().Get("duration"))
+		if err != nil || duration <= 0 || duration > time.Minute {
+			http.Error(w, "duration must be between 0 and 1m, like ?duration=2s", http.StatusBadRequest)
+			return
+		}
+		id, err := d.pool.Submit("http", duration)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, "job %d queued\n", id)
+	})
+
+	return mux
+}
+
+// Start starts the worker pool, then the scheduler, then the HTTP server
+func (d *Daemon) Start() error {
+	cfg := d.config.Load()
+	d.pool.Resize(cfg.Workers)
+	log.Printf("Worker pool started with %d workers", cfg.Workers)
+
+	d.scheduler.Start()
+	log.Printf("Scheduler started, one job every %v", time.Duration(cfg.JobInterval))
+
+	// Listening before serving reports a busy port right away
+	listener, err := net.Listen("tcp", cfg.HTTPAddr)
+	if err != nil {
+		return fmt.Errorf("starting HTTP server: %w", err)
+	}
+	go func() {
+		if err := d.server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			d.serverErr <- err
+		}
+	}()
+	log.Printf("HTTP server listening on %s", listener.Addr())
+	return nil
+}
+
+// Errors reports fatal errors of the subsystems
+func (d *Daemon) Errors() <-chan error {
+	return d.serverErr
+}
+
+// Reload reads the configuration file again. An invalid file is ignored and
+// the current configuration stays active.
+func (d *Daemon) Reload() {
+	current := d.config.Load()
+	cfg, err := LoadConfig(d.configPath)
+	if err != nil {
+		log.Printf("Reload failed, keeping the current configuration: %v", err)
+		return
+	}
+
+	if cfg.HTTPAddr != current.HTTPAddr || cfg.QueueSize != current.QueueSize {
+		log.Printf("http_addr and queue_size changes need a restart, keeping %s and %d", current.HTTPAddr, current.QueueSize)
+		cfg.HTTPAddr, cfg.QueueSize = current.HTTPAddr, current.QueueSize
+	}
+
+	d.config.Store(cfg)
+	d.pool.Resize(cfg.Workers)
+	log.Printf("Configuration reloaded: %d workers, one job every %v, shutdown timeout %v",
+		cfg.Workers, time.Duration(cfg.JobInterval), time.Duration(cfg.ShutdownTimeout))
+}
+
+// Shutdown stops the subsystems in the reverse order of their dependencies:
+// first the producers of jobs (HTTP server, scheduler), then the worker pool,
+// which drains the jobs already queued. Every step shares the deadline of ctx.
+func (d *Daemon) Shutdown(ctx context.Context) error {
+	d.draining.Store(true)
+	d.health.Drain()
+
+	steps := []struct {
+		name string
+		stop func(context.Context) error
+	}{
+		{"HTTP server", d.server.Shutdown},
+		{"scheduler", d.scheduler.Stop},
+		{"worker pool", d.pool.Shutdown},
+	}
+
+	var errs []error
+	for _, step := range steps {
+		start := time.Now()
+		if err := step.stop(ctx); err != nil {
+			log.Printf("Failed to stop %s: %v", step.name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", step.name, err))
+			continue
+		}
+		log.Printf("Stopped %s in %v", step.name, time.Since(start).Round(time.Millisecond))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,7 @@
+module golang-training/module-20/exercise-2
+
+go 1.25
+
+require golang-training/module-11/healthcheck v0.0.0
+
+replace golang-training/module-11/healthcheck => "../../../11. Http Server/solution/healthcheck"
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// run returns the exit code, so deferred calls run before os.Exit
+func run() int {
+	configPath := flag.String("config", "config.json", "configuration file, reloaded on SIGHUP")
+	pidPath := flag.String("pid", "daemon.pid", "PID file")
+	flag.Parse()
+
+	cfg, err := LoadConfig(*configPath)
+	if err != nil {
+		log.Printf("Failed to load configuration: %v", err)
+		return 1
+	}
+
+	if err := WritePIDFile(*pidPath); err != nil {
+		log.Printf("Failed to write PID file: %v", err)
+		return 1
+	}
+
+	// SIGINT and SIGTERM cancel ctx, SIGHUP is delivered to its own channel
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	daemon := NewDaemon(cfg, *configPath)
+	if err := daemon.Start(); err != nil {
+		log.Printf("Failed to start: %v", err)
+		RemovePIDFile(*pidPath)
+		return 1
+	}
+	pid := os.Getpid()
+	log.Printf("Daemon running with PID %d. Reload: kill -HUP %d, stop: kill %d", pid, pid, pid)
+
+	exitCode := 0
+loop:
+	for {
+		select {
+		case <-hup:
+			log.Println("Received SIGHUP, reloading the configuration")
+			daemon.Reload()
+		case <-ctx.Done():
+			log.Printf("%v, shutting down", context.Cause(ctx))
+			break loop
+		case err := <-daemon.Errors():
+			log.Printf("HTTP server failed: %v", err)
+			exitCode = 1
+			break loop
+		}
+	}
+	stop() // A second signal kills the process if the shutdown hangs
+
+	// The timeout may have been changed by a reload
+	timeout := time.Duration(daemon.config.Load().ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := daemon.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Shutdown incomplete: %v", err)
+		exitCode = 1
+	}
+	if err := RemovePIDFile(*pidPath); err != nil {
+		log.Printf("Failed to remove PID file: %v", err)
+	}
+	log.Println("Daemon stopped")
+	return exitCode
+}
+
+func main() {
+	os.Exit(run())
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ErrAlreadyRunning is returned when the PID file belongs to a running process
+var ErrAlreadyRunning = errors.New("daemon is already running")
+
+// processRunning checks if a process exists by sending it signal 0, which
+// only checks permissions. EPERM means it exists but belongs to another user.
+func processRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// WritePIDFile writes the PID of the process, so scripts can send it signals.
+// The file is created with O_EXCL: when it already exists, another instance is
+// running, or a previous one crashed and the file can be replaced.
+func WritePIDFile(path string) error {
+	for range 2 {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = fmt.Fprintf(f, "%d\n", os.Getpid())
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			return err
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err == nil && processRunning(pid) {
+			return fmt.Errorf("%w with PID %d (%s)", ErrAlreadyRunning, pid, path)
+		}
+		log.Printf("Removing stale PID file %s", path)
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return fmt.Errorf("failed to create PID file %s", path)
+}
+
+// RemovePIDFile removes the PID file if it still contains the PID of this process
+func RemovePIDFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(data)) != strconv.Itoa(os.Getpid()) {
+		return fmt.Errorf("PID file %s belongs to another process", path)
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrPoolClosed = errors.New("worker pool is shutting down")
+	ErrQueueFull  = errors.New("job queue is full")
+)
+
+// Job represents a unit of work
+type Job struct {
+	ID       int64
+	Source   string
+	Duration time.Duration
+}
+
+// PoolStats is a snapshot of the pool
+type PoolStats struct {
+	Workers   int   `json:"workers"`
+	Queued    int   `json:"queued"`
+	Running   int64 `json:"running"`
+	Processed int64 `json:"processed"`
+}
+
+// WorkerPool runs jobs from a queue with a number of workers that can change at runtime
+type WorkerPool struct {
+	jobs      chan Job
+	wg        sync.WaitGroup
+	running   atomic.Int64
+	processed atomic.Int64
+	nextJobID atomic.Int64
+
+	mu       sync.Mutex
+	closed   bool
+	stops    []chan struct{} // One per worker, closing it stops the worker after its current job
+	workerID int
+}
+
+// NewWorkerPool creates a pool without workers, call Resize to start them
+func NewWorkerPool(queueSize int) *WorkerPool {
+	return &WorkerPool{jobs: make(chan Job, queueSize)}
+}
+
+// Resize starts or stops workers to reach n workers
+func (p *WorkerPool) Resize(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+
+	for len(p.stops) < n {
+		p.workerID++
+		stop := make(chan struct{})
+		p.stops = append(p.stops, stop)
+		p.wg.Add(1)
+		go p.worker(p.workerID, stop)
+	}
+	for len(p.stops) > n {
+		last := len(p.stops) - 1
+		close(p.stops[last])
+		p.stops = p.stops[:last]
+	}
+}
+
+func (p *WorkerPool) worker(id int, stop <-chan struct{}) {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-stop:
+			log.Printf("Worker %d stopped", id)
+			return
+		case job, ok := <-p.jobs:
+			if !ok {
+				return // Queue closed and drained
+			}
+			p.running.Add(1)
+			time.Sleep(job.Duration) // Simulate processing time
+			p.running.Add(-1)
+			p.processed.Add(1)
+			log.Printf("Worker %d processed job %d from %s in %v", id, job.ID, job.Source, job.Duration)
+		}
+	}
+}
+
+// Submit queues a job without blocking
+func (p *WorkerPool) Submit(source string, duration time.Duration) (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0, ErrPoolClosed
+	}
+
+	job := Job{ID: p.nextJobID.Add(1), Source: source, Duration: duration}
+	select {
+	case p.jobs <- job:
+		return job.ID, nil
+	default:
+		return 0, ErrQueueFull
+	}
+}
+
+// Shutdown stops accepting jobs and waits until the workers have processed
+// the queued jobs, or until ctx is done
+func (p *WorkerPool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs) // Workers exit once the queue is empty
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d jobs not processed: %w", len(p.jobs)+int(p.running.Load()), ctx.Err())
+	}
+}
+
+// Stats returns the current state of the pool
+func (p *WorkerPool) Stats() PoolStats {
+	p.mu.Lock()
+	workers := len(p.stops)
+	p.mu.Unlock()
+
+	return PoolStats{
+		Workers:   workers,
+		Queued:    len(p.jobs),
+		Running:   p.running.Load(),
+		Processed: p.processed.Load(),
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"time"
+)
+
+// Scheduler submits a job to the pool at a regular interval
+type Scheduler struct {
+	pool     *WorkerPool
+	interval func() time.Duration // Read before every run, so a reload applies to the next one
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewScheduler creates a scheduler, call Start to run it
+func NewScheduler(pool *WorkerPool, interval func() time.Duration) *Scheduler {
+	return &Scheduler{
+		pool:     pool,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs the scheduler in a goroutine
+func (s *Scheduler) Start() {
+	go func() {
+		defer close(s.done)
+
+		timer := time.NewTimer(s.interval())
+		defer timer.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-timer.C:
+				duration := time.Duration(200+rand.IntN(1300)) * time.Millisecond
+				if _, err := s.pool.Submit("scheduler", duration); err != nil {
+					if !errors.Is(err, ErrQueueFull) {
+						return
+					}
+					log.Printf("Scheduler skipped a run: %v", err)
+				}
+				timer.Reset(s.interval())
+			}
+		}
+	}()
+}
+
+// Stop stops scheduling jobs and waits for the scheduler goroutine to exit
+func (s *Scheduler) Stop(ctx context.Context) error {
+	close(s.stop)
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
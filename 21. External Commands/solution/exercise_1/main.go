@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// describe shows how callers tell the failures apart
+func describe(err error) string {
+	var cmdErr *CommandError
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, exec.ErrNotFound):
+		return "the command is not installed"
+	case errors.Is(err, ErrTimeout):
+		return "the command took too long"
+	case errors.As(err, &cmdErr) && cmdErr.ExitCode > 0:
+		return fmt.Sprintf("the command reported a failure (exit code %d)", cmdErr.ExitCode)
+	default:
+		return "unexpected error"
+	}
+}
+
+func main() {
+	ctx := context.Background()
+	// A value coming from a user, passed as an argument it is never run by a shell
+	userInput := "hello; rm -rf ~"
+
+	commands := []struct {
+		name    string
+		args    []string
+		timeout time.Duration
+	}{
+		{"echo", []string{userInput}, time.Second},
+		{"ls", []string{"/does-not-exist"}, time.Second},
+		{"sh", []string{"-c", "echo working; exit 3"}, time.Second},
+		{"sleep", []string{"5"}, 500 * time.Millisecond},
+		{"sh", []string{"-c", "trap '' TERM; sleep 5"}, 500 * time.Millisecond}, // Ignores SIGTERM
+		{"this-command-does-not-exist", nil, time.Second},
+	}
+
+	for _, c := range commands {
+		fmt.Printf("$ %s %q\n", c.name, c.args)
+		result, err := Run(ctx, c.timeout, time.Second, c.name, c.args...)
+		if result.Stdout != "" {
+			fmt.Printf("  stdout: %s", result.Stdout)
+		}
+		if err != nil {
+			fmt.Printf("  error: %v\n", err)
+		}
+		fmt.Printf("  took %v: %s\n\n", result.Duration.Round(time.Millisecond), describe(err))
+	}
+}
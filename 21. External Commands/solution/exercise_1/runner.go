@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrTimeout is returned when a command runs longer than its timeout
+var ErrTimeout = errors.New("command timed out")
+
+// Result holds the output of a command, also returned when it failed
+type Result struct {
+	Stdout   string
+	Stderr   string
+	Duration time.Duration
+}
+
+// CommandError describes a command that failed to start or exited with an error
+type CommandError struct {
+	Command  string
+	ExitCode int // -1 when the command didn't start or was killed by a signal
+	Stderr   string
+	Err      error
+}
+
+func (e *CommandError) Error() string {
+	msg := fmt.Sprintf("command %q failed", e.Command)
+	if e.ExitCode >= 0 {
+		msg += fmt.Sprintf(" with exit code %d", e.ExitCode)
+	}
+	msg += ": " + e.Err.Error()
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// Run runs a command and captures its output. The arguments are passed to
+// the command as they are, no shell is involved, so they can't inject other
+// commands. After the timeout the command receives SIGTERM, then SIGKILL if it
+// still runs after gracePeriod.
+func Run(ctx context.Context, timeout, gracePeriod time.Duration, name string, args ...string) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	// The default Cancel kills the command right away, ask it to stop first
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+	cmd.WaitDelay = gracePeriod
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	result := &Result{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Duration: time.Since(start),
+	}
+	if err == nil {
+		return result, nil
+	}
+
+	cmdErr := &CommandError{
+		Command:  cmd.String(),
+		ExitCode: -1,
+		Stderr:   strings.TrimSpace(result.Stderr),
+		Err:      err,
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		cmdErr.Err = fmt.Errorf("%w after %v: %v", ErrTimeout, timeout, err)
+	case errors.As(err, &exitErr):
+		cmdErr.ExitCode = exitErr.ExitCode()
+	}
+	return result, cmdErr
+}
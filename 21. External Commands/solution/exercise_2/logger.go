@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogLevel defines different logging severity levels
+type LogLevel int
+
+const (
+	DEBUG LogLevel = iota
+	INFO
+	WARNING
+	ERROR
+	FATAL
+)
+
+func (l LogLevel) String() string {
+	return [...]string{"DEBUG", "INFO", "WARNING", "ERROR", "FATAL"}[l]
+}
+
+// Logger provides structured logging functionality. Unlike the module 07
+// version it is safe for concurrent use, stdout and stderr are logged by two goroutines.
+type Logger struct {
+	Level   LogLevel
+	LogFile *os.File
+	mu      sync.Mutex
+}
+
+// NewLogger creates a new logger with the specified minimum level
+func NewLogger(level LogLevel, logPath string) (*Logger, error) {
+	var logFile *os.File
+	var err error
+
+	if logPath != "" {
+		logFile, err = os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+	}
+
+	return &Logger{
+		Level:   level,
+		LogFile: logFile,
+	}, nil
+}
+
+// Log writes a log entry with the given level and message
+func (l *Logger) Log(level LogLevel, format string, args ...interface{}) {
+	if level < l.Level {
+		return
+	}
+
+	timestamp := time.Now().Format("2006-01-02 15:04:05.000")
+	message := fmt.Sprintf(format, args...)
+	logEntry := fmt.Sprintf("[%s] [%s] %s\n", timestamp, level, message)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Write to console
+	fmt.Print(logEntry)
+
+	// Write to file if available
+	if l.LogFile != nil {
+		l.LogFile.WriteString(logEntry)
+	}
+}
+
+// Close closes the log file if it's open
+func (l *Logger) Close() error {
+	if l.LogFile != nil {
+		return l.LogFile.Close()
+	}
+	return nil
+}
+
+func (l *Logger) Debug(format string, args ...interface{}) {
+	l.Log(DEBUG, format, args...)
+}
+
+func (l *Logger) Info(format string, args ...interface{}) {
+	l.Log(INFO, format, args...)
+}
+
+func (l *Logger) Warning(format string, args ...interface{}) {
+	l.Log(WARNING, format, args...)
+}
+
+func (l *Logger) Error(format string, args ...interface{}) {
+	l.Log(ERROR, format, args...)
+}
+
+func (l *Logger) Fatal(format string, args ...interface{}) {
+	l.Log(FATAL, format, args...)
+}
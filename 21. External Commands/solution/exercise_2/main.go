@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// buildScript simulates a build writing progress to stdout and warnings to stderr
+const buildScript = `
+for step in compile test package; do
+	echo "running $step"
+	sleep 0.3
+	[ "$step" = test ] && echo "2 tests skipped" >&2
+done
+echo "build failed: disk full" >&2
+exit 4`
+
+func main() {
+	logger, err := NewLogger(DEBUG, "commands.log")
+	if err != nil {
+		fmt.Printf("Failed to create logger: %v\n", err)
+		return
+	}
+	defer logger.Close()
+
+	ctx := context.Background()
+
+	if err := StreamCommand(ctx, logger, "ls", "-la"); err != nil {
+		logger.Error("%v", err)
+	}
+
+	if err := StreamCommand(ctx, logger, "sh", "-c", buildScript); err != nil {
+		logger.Error("%v", err)
+	}
+
+	// The output is logged until the timeout stops the command
+	timeoutCtx, cancel := context.WithTimeout(ctx, 1200*time.Millisecond)
+	defer cancel()
+	if err := StreamCommand(timeoutCtx, logger, "sh", "-c", "i=0; while true; do i=$((i+1)); echo tick $i; sleep 0.5; done"); err != nil {
+		logger.Error("%v", err)
+	}
+}
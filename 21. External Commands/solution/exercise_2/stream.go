@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxLineSize is the longest line logged as one entry
+const maxLineSize = 1024 * 1024
+
+// logLines logs every line read from r until it is closed
+func logLines(r io.Reader, log func(line string)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		log(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// StreamCommand runs a command and logs its output while it runs, stdout at
+// INFO and stderr at WARNING level, each line prefixed with the command name.
+// The error includes the exit code when the command failed.
+func StreamCommand(ctx context.Context, logger *Logger, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+	cmd.WaitDelay = 2 * time.Second
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Starting %q", cmd.Args)
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting %s: %w", name, err)
+	}
+
+	// Both pipes must be read at the same time: a command blocks when the
+	// buffer of the pipe nobody reads is full
+	var wg sync.WaitGroup
+	var stdoutErr, stderrErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		stdoutErr = logLines(stdout, func(line string) { logger.Info("[%s] %s", name, line) })
+	}()
+	go func() {
+		defer wg.Done()
+		stderrErr = logLines(stderr, func(line string) { logger.Warning("[%s] %s", name, line) })
+	}()
+
+	// Wait closes the pipes, everything must be read before calling it
+	wg.Wait()
+	err = cmd.Wait()
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		logger.Info("%s finished in %v", name, elapsed)
+	case ctx.Err() != nil:
+		return fmt.Errorf("%s stopped after %v: %w", name, elapsed, ctx.Err())
+	case errors.As(err, &exitErr):
+		return fmt.Errorf("%s exited with code %d after %v: %w", name, exitErr.ExitCode(), elapsed, err)
+	default:
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return errors.Join(stdoutErr, stderrErr)
+}
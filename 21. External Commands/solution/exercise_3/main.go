@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const text = `the quick brown fox jumps over the lazy dog
+the dog sleeps and the fox runs
+a fox is quick and a dog is lazy`
+
+// run prints the pipeline, its output and its error
+func run(ctx context.Context, input string, commands ...[]string) {
+	stages := make([]string, len(commands))
+	for i, args := range commands {
+		stages[i] = strings.Join(args, " ")
+	}
+	fmt.Printf("$ %s\n", strings.Join(stages, " | "))
+
+	start := time.Now()
+	output, err := Pipeline(ctx, strings.NewReader(input), commands...)
+	if output != "" {
+		fmt.Print(output)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		var stageErr *StageError
+		if errors.As(err, &stageErr) {
+			fmt.Printf("Failed stage: %d, exit code: %d, timeout: %v\n",
+				stageErr.Stage, stageErr.ExitCode, errors.Is(err, context.DeadlineExceeded))
+		}
+	}
+	fmt.Printf("(%v)\n\n", time.Since(start).Round(time.Millisecond))
+}
+
+func main() {
+	ctx := context.Background()
+
+	// Word frequency, the input comes from the Go program
+	run(ctx, text,
+		[]string{"tr", "-s", "[:space:]", `\n`},
+		[]string{"sort"},
+		[]string{"uniq", "-c"},
+		[]string{"sort", "-rn"},
+		[]string{"head", "-n", "3"},
+	)
+
+	// yes never stops on its own, it must stop when head exits
+	run(ctx, "", []string{"yes", "go"}, []string{"head", "-n", "3"})
+
+	// The first stage fails, the output of the others is still returned
+	run(ctx, "", []string{"cat", "/does-not-exist"}, []string{"wc", "-l"})
+
+	// A stage that isn't installed
+	run(ctx, text, []string{"grep", "fox"}, []string{"not-a-command"})
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	run(timeoutCtx, "", []string{"sleep", "10"}, []string{"cat"})
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// StageError describes the command of a pipeline that failed
+type StageError struct {
+	Stage    int // Position of the command in the pipeline, starting at 1
+	Command  string
+	ExitCode int // -1 when the command didn't start or was killed by a signal
+	Stderr   string
+	Err      error
+}
+
+func (e *StageError) Error() string {
+	msg := fmt.Sprintf("stage %d (%s) failed", e.Stage, e.Command)
+	if e.ExitCode >= 0 {
+		msg += fmt.Sprintf(" with exit code %d", e.ExitCode)
+	}
+	msg += ": " + e.Err.Error()
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// brokenPipe reports if a command stopped because the next one stopped reading,
+// like "yes" in "yes | head -n 3". A shell doesn't report it as a failure.
+func brokenPipe(err error) bool {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		status, ok := exitErr.Sys().(syscall.WaitStatus)
+		return ok && status.Signaled() && status.Signal() == syscall.SIGPIPE
+	}
+	return errors.Is(err, io.ErrClosedPipe)
+}
+
+// Pipeline runs commands connected like "cmd1 | cmd2 | cmd3": stdin is the
+// input of the first command, the output of the last one is returned. Each
+// connection is an io.Pipe. When a command fails, the error of the first
+// failing stage is returned, like "set -o pipefail" in bash.
+func Pipeline(ctx context.Context, stdin io.Reader, commands ...[]string) (string, error) {
+	if len(commands) == 0 {
+		return "", errors.New("empty pipeline")
+	}
+
+	cmds := make([]*exec.Cmd, len(commands))
+	stderrs := make([]bytes.Buffer, len(commands))
+	for i, args := range commands {
+		cmds[i] = exec.CommandContext(ctx, args[0], args[1:]...)
+		cmds[i].Stderr = &stderrs[i]
+	}
+	cmds[0].Stdin = stdin
+	var stdout bytes.Buffer
+	cmds[len(cmds)-1].Stdout = &stdout
+
+	// readers[i] and writers[i] connect cmds[i] to cmds[i+1]
+	readers := make([]*io.PipeReader, len(cmds)-1)
+	writers := make([]*io.PipeWriter, len(cmds)-1)
+	for i := range readers {
+		readers[i], writers[i] = io.Pipe()
+		cmds[i].Stdout = writers[i]
+		cmds[i+1].Stdin = readers[i]
+	}
+
+	errs := make([]error, len(cmds))
+	var wg sync.WaitGroup
+	for i, cmd := range cmds {
+		if err := cmd.Start(); err != nil {
+			errs[i] = err
+			// Unblock the neighbours of the command that didn't start
+			if i > 0 {
+				readers[i-1].CloseWithError(io.ErrClosedPipe)
+			}
+			if i < len(writers) {
+				writers[i].CloseWithError(err)
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = cmd.Wait()
+			// The next command reads EOF once this one has exited
+			if i < len(writers) {
+				writers[i].Close()
+			}
+			// The previous command gets an error instead of blocking forever
+			// when this one exits without reading everything
+			if i > 0 {
+				readers[i-1].CloseWithError(io.ErrClosedPipe)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err == nil || (i < len(cmds)-1 && brokenPipe(err)) {
+			continue
+		}
+
+		stageErr := &StageError{
+			Stage:    i + 1,
+			Command:  strings.Join(commands[i], " "),
+			ExitCode: -1,
+			Stderr:   strings.TrimSpace(stderrs[i].String()),
+			Err:      err,
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			stageErr.ExitCode = exitErr.ExitCode()
+		}
+		if ctx.Err() != nil {
+			stageErr.Err = fmt.Errorf("%w: %v", ctx.Err(), err)
+		}
+		return stdout.String(), stageErr
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"embed"
+	"flag"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// The static and templates directories are compiled into the binary, the
+// program can be copied anywhere without them
+//
+//go:embed static templates
+var embedded embed.FS
+
+// A single file can be embedded in a string or a []byte
+//
+//go:embed VERSION
+var version string
+
+// Todo represents a task shown on the home page
+type Todo struct {
+	Title     string
+	Completed bool
+}
+
+// Page is the data passed to the layout
+type Page struct {
+	Title   string
+	Version string
+	Data    any
+}
+
+// Site renders the pages from a file system, embedded or on disk
+type Site struct {
+	files fs.FS
+	dev   bool
+	pages map[string]*template.Template
+}
+
+// NewSite parses every page together with the layout. In development mode the
+// templates are parsed again on every request, so changes show up without a rebuild.
+func NewSite(files fs.FS, dev bool) (*Site, error) {
+	s := &Site{files: files, dev: dev}
+	pages, err := s.parse()
+	if err != nil {
+		return nil, err
+	}
+	s.pages = pages
+	return s, nil
+}
+
+// parse returns the template of every page by name
+func (s *Site) parse() (map[string]*template.Template, error) {
+	names, err := fs.Glob(s.files, "templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make(map[string]*template.Template)
+	for _, name := range names {
+		if name == "templates/layout.html" {
+			continue
+		}
+		// Each page has its own "content" block, so each one gets its own template set
+		tmpl, err := template.ParseFS(s.files, "templates/layout.html", name)
+		if err != nil {
+			return nil, err
+		}
+		pages[strings.TrimSuffix(strings.TrimPrefix(name, "templates/"), ".html")] = tmpl
+	}
+	return pages, nil
+}
+
+// render writes a page, or a 500 error when its template fails
+func (s *Site) render(w http.ResponseWriter, name string, page Page) {
+	pages := s.pages
+	if s.dev {
+		var err error
+		if pages, err = s.parse(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	tmpl, ok := pages[name]
+	if !ok {
+		http.Error(w, "page not found: "+name, http.StatusInternalServerError)
+		return
+	}
+	page.Version = strings.TrimSpace(version)
+	if err := tmpl.ExecuteTemplate(w, "layout", page); err != nil {
+		log.Printf("Failed to render %s: %v", name, err)
+	}
+}
+
+// listFiles returns the path of every embedded file
+func listFiles(files fs.FS) ([]string, error) {
+	var paths []string
+	err := fs.WalkDir(files, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+func main() {
+	dev := flag.Bool("dev", false, "serve the files from disk instead of the binary")
+	addr := flag.String("addr", ":8080", "listen address")
+	flag.Parse()
+
+	var files fs.FS = embedded
+	if *dev {
+		files = os.DirFS(".")
+		log.Println("Development mode: serving static files and templates from disk")
+	}
+
+	site, err := NewSite(files, *dev)
+	if err != nil {
+		log.Fatalf("Failed to parse templates: %v", err)
+	}
+	embeddedFiles, err := listFiles(embedded)
+	if err != nil {
+		log.Fatalf("Failed to list embedded files: %v", err)
+	}
+
+	// fs.Sub removes the "static" prefix, /static/css/style.css is static/css/style.css
+	static, err := fs.Sub(files, "static")
+	if err != nil {
+		log.Fatalf("Failed to open static files: %v", err)
+	}
+	staticHandler := http.StripPrefix("/static/", http.FileServerFS(static))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /static/", func(w http.ResponseWriter, r *http.Request) {
+		// Embedded files have no modification time, so browsers can't revalidate them
+		// with If-Modified-Since. Caching them for a while is safe, they change only with a new binary.
+		if !*dev {
+			w.Header().Set("Cache-Control", "public, max-age=3600")
+		}
+		staticHandler.ServeHTTP(w, r)
+	})
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		site.render(w, "index", Page{Title: "My Todos", Data: []Todo{
+			{Title: "Learn go:embed", Completed: true},
+			{Title: "Serve templates from the binary"},
+			{Title: "Deploy a single file"},
+		}})
+	})
+	mux.HandleFunc("GET /about", func(w http.ResponseWriter, r *http.Request) {
+		site.render(w, "about", Page{Title: "About", Data: embeddedFiles})
+	})
+
+	log.Printf("Version %s listening on %s", strings.TrimSpace(version), *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
@@ -0,0 +1,4 @@
+// Toggle a todo when it is clicked
+document.querySelectorAll(".todo").forEach((item) => {
+  item.addEventListener("click", () => item.classList.toggle("done"));
+});
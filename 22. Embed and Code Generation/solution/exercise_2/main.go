@@ -0,0 +1,109 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+	"testing/fstest"
+)
+
+// The SQL files are compiled into the binary, deploying the binary is enough
+// to migrate the database
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// withFile copies the files of base into an in-memory fs.FS and adds or replaces one
+func withFile(base fs.FS, name, content string) (fstest.MapFS, error) {
+	files := fstest.MapFS{}
+	entries, err := fs.ReadDir(base, ".")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		data, err := fs.ReadFile(base, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		files[entry.Name()] = &fstest.MapFile{Data: data}
+	}
+	files[name] = &fstest.MapFile{Data: []byte(content)}
+	return files, nil
+}
+
+// printColumns prints the columns of tables created by the migrations
+func printColumns(manager *MigrationManager, tables ...string) {
+	for _, table := range tables {
+		columnTypes, err := manager.db.Migrator().ColumnTypes(table)
+		if err != nil {
+			fmt.Printf("  %s: %v\n", table, err)
+			continue
+		}
+		names := make([]string, len(columnTypes))
+		for i, column := range columnTypes {
+			names[i] = column.Name()
+		}
+		fmt.Printf("  %s: %s\n", table, strings.Join(names, ", "))
+	}
+}
+
+func main() {
+	os.Remove("app.db")
+
+	// The runner only sees fs.FS, it reads *.sql at the root of the directory
+	migrations, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		log.Fatalf("Failed to open migrations: %v", err)
+	}
+
+	manager, err := NewMigrationManager("app.db")
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	fmt.Println("Step 1: Status of a new database")
+	if err := manager.PrintStatus(migrations); err != nil {
+		log.Fatalf("Failed to read status: %v", err)
+	}
+
+	fmt.Println("\nStep 2: Applying the embedded migrations")
+	applied, err := manager.RunSQLMigrations(migrations)
+	if err != nil {
+		log.Fatalf("Failed to migrate: %v", err)
+	}
+	fmt.Printf("  Applied %d migrations: %v\n", len(applied), applied)
+	printColumns(manager, "users", "todos")
+
+	fmt.Println("\nStep 3: Running again applies nothing")
+	applied, err = manager.RunSQLMigrations(migrations)
+	if err != nil {
+		log.Fatalf("Failed to migrate: %v", err)
+	}
+	fmt.Printf("  Applied %d migrations\n", len(applied))
+	if err := manager.PrintStatus(migrations); err != nil {
+		log.Fatalf("Failed to read status: %v", err)
+	}
+
+	// Any fs.FS works, fstest.MapFS builds one in memory
+	fmt.Println("\nStep 4: A migration edited after it was applied")
+	edited, err := withFile(migrations, "0002_add_age_to_users.sql", "ALTER TABLE users ADD COLUMN birthday DATE;\n")
+	if err != nil {
+		log.Fatalf("Failed to copy migrations: %v", err)
+	}
+	_, err = manager.RunSQLMigrations(edited)
+	fmt.Printf("  %v (ErrMigrationChanged: %v)\n", err, errors.Is(err, ErrMigrationChanged))
+
+	fmt.Println("\nStep 5: A failing migration is rolled back")
+	broken, err := withFile(migrations, "0005_add_bio_to_users.sql",
+		"ALTER TABLE users ADD COLUMN bio TEXT;\nALTER TABLE missing ADD COLUMN x TEXT;\n")
+	if err != nil {
+		log.Fatalf("Failed to copy migrations: %v", err)
+	}
+	_, err = manager.RunSQLMigrations(broken)
+	fmt.Printf("  %v\n", err)
+	printColumns(manager, "users")
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ErrMigrationChanged is returned when an applied migration file was modified
+var ErrMigrationChanged = errors.New("applied migration was modified")
+
+// SchemaMigration records a migration file that was applied
+type SchemaMigration struct {
+	Version   string `gorm:"primaryKey;size:200"` // File name, like 0001_create_users.sql
+	Checksum  string `gorm:"size:64;not null"`
+	AppliedAt time.Time
+}
+
+// MigrationManager handles database migrations. It is the manager of module 14,
+// reading SQL files from any fs.FS instead of migrating Go structs.
+type MigrationManager struct {
+	db *gorm.DB
+}
+
+// NewMigrationManager creates a new migration manager
+func NewMigrationManager(dsn string) (*MigrationManager, error) {
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &MigrationManager{db: db}, nil
+}
+
+// Migration is a SQL file of the migrations directory
+type Migration struct {
+	Version  string
+	SQL      string
+	Checksum string
+}
+
+// loadMigrations reads the .sql files of fsys, sorted by name
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql") // Sorted, hence the numbered prefixes
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	for _, name := range names {
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(content)
+		migrations = append(migrations, Migration{
+			Version:  path.Base(name),
+			SQL:      string(content),
+			Checksum: hex.EncodeToString(sum[:]),
+		})
+	}
+	return migrations, nil
+}
+
+// appliedMigrations returns the applied migrations by version
+func (m *MigrationManager) appliedMigrations() (map[string]SchemaMigration, error) {
+	if err := m.db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+
+	var rows []SchemaMigration
+	if err := m.db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[string]SchemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// RunSQLMigrations applies the migrations of fsys that weren't applied yet and
+// returns their versions. Each migration runs in a transaction together with
+// its record in schema_migrations, a failing migration leaves no trace.
+func (m *MigrationManager) RunSQLMigrations(fsys fs.FS) ([]string, error) {
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("loading migrations: %w", err)
+	}
+	applied, err := m.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	// Refuse to run when the history was rewritten, the database wouldn't match the files
+	for _, migration := range migrations {
+		if row, ok := applied[migration.Version]; ok && row.Checksum != migration.Checksum {
+			return nil, fmt.Errorf("%w: %s, add a new migration instead", ErrMigrationChanged, migration.Version)
+		}
+	}
+
+	var done []string
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(migration.SQL).Error; err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{
+				Version:   migration.Version,
+				Checksum:  migration.Checksum,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("applying %s: %w", migration.Version, err)
+		}
+		done = append(done, migration.Version)
+	}
+	return done, nil
+}
+
+// PrintStatus shows which migrations are applied
+func (m *MigrationManager) PrintStatus(fsys fs.FS) error {
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		return err
+	}
+	applied, err := m.appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		status := "pending"
+		if row, ok := applied[migration.Version]; ok {
+			status = "applied " + row.AppliedAt.Format(time.DateTime)
+		}
+		fmt.Printf("  %-32s %s\n", migration.Version, status)
+	}
+	return nil
+}
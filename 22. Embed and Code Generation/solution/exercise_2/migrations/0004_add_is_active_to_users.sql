@@ -0,0 +1,2 @@
+-- Existing users are active, the application deactivates them later
+ALTER TABLE users ADD COLUMN is_active BOOLEAN NOT NULL DEFAULT TRUE;
@@ -0,0 +1,232 @@
+// Command enumgen generates typed Go constants from a YAML file describing
+// enums. It is meant to be run by go generate:
+//
+//	//go:generate go run ./cmd/enumgen -input enums.yaml -output enums_gen.go
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Value is a value of an enum, written as a plain string or with a doc
+// comment, which follows the name of the constant in the generated code
+type Value struct {
+	Name string `yaml:"name"`
+	Doc  string `yaml:"doc"`
+}
+
+// UnmarshalYAML accepts "pending" as well as "{name: pending, doc: ...}"
+func (v *Value) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		v.Name = node.Value
+		return nil
+	}
+	type plain Value // Without the UnmarshalYAML method, to avoid a recursive call
+	return node.Decode((*plain)(v))
+}
+
+// Enum is an enum of the YAML file
+type Enum struct {
+	Name   string  `yaml:"name"`
+	Doc    string  `yaml:"doc"`
+	Values []Value `yaml:"values"`
+}
+
+// File is the YAML file
+type File struct {
+	Enums []Enum `yaml:"enums"`
+}
+
+// valueName matches the values, they are written in snake_case
+var valueName = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+// validate reports every problem of the file at once
+func (f *File) validate() error {
+	var errs []error
+	enums := make(map[string]bool)
+	for _, enum := range f.Enums {
+		if !token.IsIdentifier(enum.Name) || !token.IsExported(enum.Name) {
+			errs = append(errs, fmt.Errorf("enum name %q must be an exported Go identifier", enum.Name))
+		}
+		if enums[enum.Name] {
+			errs = append(errs, fmt.Errorf("enum %s is declared twice", enum.Name))
+		}
+		enums[enum.Name] = true
+		if len(enum.Values) == 0 {
+			errs = append(errs, fmt.Errorf("enum %s has no values", enum.Name))
+		}
+
+		values := make(map[string]bool)
+		for _, value := range enum.Values {
+			if !valueName.MatchString(value.Name) {
+				errs = append(errs, fmt.Errorf("enum %s: value %q must be snake_case", enum.Name, value.Name))
+			}
+			if values[value.Name] {
+				errs = append(errs, fmt.Errorf("enum %s: value %q is declared twice", enum.Name, value.Name))
+			}
+			values[value.Name] = true
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// camelCase converts very_high to VeryHigh
+func camelCase(s string) string {
+	var b strings.Builder
+	for part := range strings.SplitSeq(s, "_") {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+// lowerFirst converts OrderStatus to orderStatus
+func lowerFirst(s string) string {
+	return string(unicode.ToLower(rune(s[0]))) + s[1:]
+}
+
+// receiver returns the receiver name of the methods, o for OrderStatus
+func receiver(s string) string {
+	return strings.ToLower(s[:1])
+}
+
+var code = template.Must(template.New("enums").Funcs(template.FuncMap{
+	"camel":    camelCase,
+	"lower":    lowerFirst,
+	"receiver": receiver,
+}).Parse(`// Code generated by enumgen from {{.Input}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidEnum is returned when parsing an unknown enum value
+var ErrInvalidEnum = errors.New("invalid enum value")
+{{range .Enums}}{{$enum := .Name}}{{$r := receiver .Name}}
+{{with .Doc}}// {{.}}
+{{end}}type {{.Name}} int
+
+// The zero value isn't a valid {{.Name}}, so a missing value is detected
+const (
+{{- range $i, $v := .Values}}
+	{{with $v.Doc}}// {{$enum}}{{camel $v.Name}} {{.}}
+	{{end}}{{$enum}}{{camel $v.Name}}{{if eq $i 0}} {{$enum}} = iota + 1{{end}}
+{{- end}}
+)
+
+var {{lower .Name}}Names = [...]string{
+	{{- range $i, $v := .Values}}
+	{{$enum}}{{camel $v.Name}}: "{{$v.Name}}",
+	{{- end}}
+}
+
+// {{.Name}}Values returns every {{.Name}} in declaration order
+func {{.Name}}Values() []{{.Name}} {
+	return []{{.Name}}{ {{- range $i, $v := .Values}}{{if $i}}, {{end}}{{$enum}}{{camel $v.Name}}{{end -}} }
+}
+
+// IsValid reports whether {{$r}} is a declared {{.Name}}
+func ({{$r}} {{.Name}}) IsValid() bool {
+	return {{$r}} > 0 && int({{$r}}) < len({{lower .Name}}Names)
+}
+
+// String returns the name of the value as written in {{$.Input}}
+func ({{$r}} {{.Name}}) String() string {
+	if !{{$r}}.IsValid() {
+		return fmt.Sprintf("{{.Name}}(%d)", int({{$r}}))
+	}
+	return {{lower .Name}}Names[{{$r}}]
+}
+
+// Parse{{.Name}} returns the {{.Name}} with the given name
+func Parse{{.Name}}(name string) ({{.Name}}, error) {
+	for i, n := range {{lower .Name}}Names {
+		if i > 0 && n == name {
+			return {{.Name}}(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q is not a valid {{.Name}}", ErrInvalidEnum, name)
+}
+
+// MarshalText writes the name of the value, JSON uses it for values and map keys
+func ({{$r}} {{.Name}}) MarshalText() ([]byte, error) {
+	if !{{$r}}.IsValid() {
+		return nil, fmt.Errorf("%w: {{.Name}}(%d)", ErrInvalidEnum, int({{$r}}))
+	}
+	return []byte({{lower .Name}}Names[{{$r}}]), nil
+}
+
+// UnmarshalText parses the name of the value
+func ({{$r}} *{{.Name}}) UnmarshalText(text []byte) error {
+	value, err := Parse{{.Name}}(string(text))
+	if err != nil {
+		return err
+	}
+	*{{$r}} = value
+	return nil
+}
+{{end}}`))
+
+func main() {
+	input := flag.String("input", "enums.yaml", "YAML file describing the enums")
+	output := flag.String("output", "enums_gen.go", "generated Go file")
+	// go generate sets GOPACKAGE to the package of the file containing the directive
+	pkg := flag.String("package", os.Getenv("GOPACKAGE"), "package of the generated file")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("enumgen: ")
+
+	if *pkg == "" {
+		*pkg = "main"
+	}
+
+	data, err := os.ReadFile(*input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var file File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		log.Fatalf("%s: %v", *input, err)
+	}
+	if err := file.validate(); err != nil {
+		log.Fatalf("%s:\n%v", *input, err)
+	}
+
+	var buf bytes.Buffer
+	err = code.Execute(&buf, map[string]any{
+		"Input":   *input,
+		"Package": *pkg,
+		"Enums":   file.Enums,
+	})
+	if err != nil {
+		log.Fatalf("executing template: %v", err)
+	}
+
+	// gofmt the result, a template can't get the alignment right
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		os.Stderr.Write(buf.Bytes())
+		log.Fatalf("generated code is invalid: %v", err)
+	}
+	if err := os.WriteFile(*output, source, 0644); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %d enums to %s", len(file.Enums), *output)
+}
@@ -0,0 +1,204 @@
+// Code generated by enumgen from enums.yaml. DO NOT EDIT.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidEnum is returned when parsing an unknown enum value
+var ErrInvalidEnum = errors.New("invalid enum value")
+
+// OrderStatus is the state of an order
+type OrderStatus int
+
+// The zero value isn't a valid OrderStatus, so a missing value is detected
+const (
+	OrderStatusPending OrderStatus = iota + 1
+	OrderStatusPaid
+	OrderStatusShipped
+	OrderStatusDelivered
+	// OrderStatusCancelled means the order was cancelled before delivery and the payment refunded
+	OrderStatusCancelled
+)
+
+var orderStatusNames = [...]string{
+	OrderStatusPending:   "pending",
+	OrderStatusPaid:      "paid",
+	OrderStatusShipped:   "shipped",
+	OrderStatusDelivered: "delivered",
+	OrderStatusCancelled: "cancelled",
+}
+
+// OrderStatusValues returns every OrderStatus in declaration order
+func OrderStatusValues() []OrderStatus {
+	return []OrderStatus{OrderStatusPending, OrderStatusPaid, OrderStatusShipped, OrderStatusDelivered, OrderStatusCancelled}
+}
+
+// IsValid reports whether o is a declared OrderStatus
+func (o OrderStatus) IsValid() bool {
+	return o > 0 && int(o) < len(orderStatusNames)
+}
+
+// String returns the name of the value as written in enums.yaml
+func (o OrderStatus) String() string {
+	if !o.IsValid() {
+		return fmt.Sprintf("OrderStatus(%d)", int(o))
+	}
+	return orderStatusNames[o]
+}
+
+// ParseOrderStatus returns the OrderStatus with the given name
+func ParseOrderStatus(name string) (OrderStatus, error) {
+	for i, n := range orderStatusNames {
+		if i > 0 && n == name {
+			return OrderStatus(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q is not a valid OrderStatus", ErrInvalidEnum, name)
+}
+
+// MarshalText writes the name of the value, JSON uses it for values and map keys
+func (o OrderStatus) MarshalText() ([]byte, error) {
+	if !o.IsValid() {
+		return nil, fmt.Errorf("%w: OrderStatus(%d)", ErrInvalidEnum, int(o))
+	}
+	return []byte(orderStatusNames[o]), nil
+}
+
+// UnmarshalText parses the name of the value
+func (o *OrderStatus) UnmarshalText(text []byte) error {
+	value, err := ParseOrderStatus(string(text))
+	if err != nil {
+		return err
+	}
+	*o = value
+	return nil
+}
+
+// Role grants permissions to a user
+type Role int
+
+// The zero value isn't a valid Role, so a missing value is detected
+const (
+	RoleViewer Role = iota + 1
+	RoleEditor
+	RoleAdmin
+)
+
+var roleNames = [...]string{
+	RoleViewer: "viewer",
+	RoleEditor: "editor",
+	RoleAdmin:  "admin",
+}
+
+// RoleValues returns every Role in declaration order
+func RoleValues() []Role {
+	return []Role{RoleViewer, RoleEditor, RoleAdmin}
+}
+
+// IsValid reports whether r is a declared Role
+func (r Role) IsValid() bool {
+	return r > 0 && int(r) < len(roleNames)
+}
+
+// String returns the name of the value as written in enums.yaml
+func (r Role) String() string {
+	if !r.IsValid() {
+		return fmt.Sprintf("Role(%d)", int(r))
+	}
+	return roleNames[r]
+}
+
+// ParseRole returns the Role with the given name
+func ParseRole(name string) (Role, error) {
+	for i, n := range roleNames {
+		if i > 0 && n == name {
+			return Role(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q is not a valid Role", ErrInvalidEnum, name)
+}
+
+// MarshalText writes the name of the value, JSON uses it for values and map keys
+func (r Role) MarshalText() ([]byte, error) {
+	if !r.IsValid() {
+		return nil, fmt.Errorf("%w: Role(%d)", ErrInvalidEnum, int(r))
+	}
+	return []byte(roleNames[r]), nil
+}
+
+// UnmarshalText parses the name of the value
+func (r *Role) UnmarshalText(text []byte) error {
+	value, err := ParseRole(string(text))
+	if err != nil {
+		return err
+	}
+	*r = value
+	return nil
+}
+
+// Priority orders the todos of a user
+type Priority int
+
+// The zero value isn't a valid Priority, so a missing value is detected
+const (
+	PriorityLow Priority = iota + 1
+	PriorityMedium
+	PriorityHigh
+	PriorityVeryHigh
+)
+
+var priorityNames = [...]string{
+	PriorityLow:      "low",
+	PriorityMedium:   "medium",
+	PriorityHigh:     "high",
+	PriorityVeryHigh: "very_high",
+}
+
+// PriorityValues returns every Priority in declaration order
+func PriorityValues() []Priority {
+	return []Priority{PriorityLow, PriorityMedium, PriorityHigh, PriorityVeryHigh}
+}
+
+// IsValid reports whether p is a declared Priority
+func (p Priority) IsValid() bool {
+	return p > 0 && int(p) < len(priorityNames)
+}
+
+// String returns the name of the value as written in enums.yaml
+func (p Priority) String() string {
+	if !p.IsValid() {
+		return fmt.Sprintf("Priority(%d)", int(p))
+	}
+	return priorityNames[p]
+}
+
+// ParsePriority returns the Priority with the given name
+func ParsePriority(name string) (Priority, error) {
+	for i, n := range priorityNames {
+		if i > 0 && n == name {
+			return Priority(i), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q is not a valid Priority", ErrInvalidEnum, name)
+}
+
+// MarshalText writes the name of the value, JSON uses it for values and map keys
+func (p Priority) MarshalText() ([]byte, error) {
+	if !p.IsValid() {
+		return nil, fmt.Errorf("%w: Priority(%d)", ErrInvalidEnum, int(p))
+	}
+	return []byte(priorityNames[p]), nil
+}
+
+// UnmarshalText parses the name of the value
+func (p *Priority) UnmarshalText(text []byte) error {
+	value, err := ParsePriority(string(text))
+	if err != nil {
+		return err
+	}
+	*p = value
+	return nil
+}
@@ -0,0 +1,5 @@
+module golang-training/module-22/exercise-3
+
+go 1.25
+
+require gopkg.in/yaml.v3 v3.0.1
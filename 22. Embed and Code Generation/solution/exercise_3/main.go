@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Regenerate enums_gen.go after editing enums.yaml with: go generate ./...
+//
+//go:generate go run ./cmd/enumgen -input enums.yaml -output enums_gen.go
+
+// Order uses the generated types, they are written by name in JSON
+type Order struct {
+	ID       int           `json:"id"`
+	Status   OrderStatus   `json:"status"`
+	Priority Priority      `json:"priority"`
+	Counts   map[Role]int  `json:"approvals_by_role,omitempty"`
+	History  []OrderStatus `json:"history,omitempty"`
+}
+
+func main() {
+	fmt.Println("--- Values ---")
+	for _, status := range OrderStatusValues() {
+		fmt.Printf("%d %s\n", status, status)
+	}
+
+	fmt.Println("\n--- Parsing ---")
+	for _, name := range []string{"editor", "superuser"} {
+		role, err := ParseRole(name)
+		fmt.Printf("ParseRole(%q) = %v, %v (ErrInvalidEnum: %v)\n", name, role, err, errors.Is(err, ErrInvalidEnum))
+	}
+	fmt.Println("Zero value:", OrderStatus(0), OrderStatus(0).IsValid())
+
+	fmt.Println("\n--- JSON ---")
+	order := Order{
+		ID:       42,
+		Status:   OrderStatusShipped,
+		Priority: PriorityVeryHigh,
+		Counts:   map[Role]int{RoleAdmin: 1, RoleEditor: 2},
+		History:  []OrderStatus{OrderStatusPending, OrderStatusPaid, OrderStatusShipped},
+	}
+	data, err := json.Marshal(order)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Println(string(data))
+
+	var decoded Order
+	err = json.Unmarshal([]byte(`{"id": 7, "status": "refunded", "priority": "low"}`), &decoded)
+	fmt.Println("Unknown status:", err)
+
+	_, err = json.Marshal(Order{ID: 8})
+	fmt.Println("Missing status:", err)
+}
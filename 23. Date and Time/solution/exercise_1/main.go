@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Embeds the time zone database, the program works on machines without one
+)
+
+// ErrUnknownFormat is returned when no layout matches the input
+var ErrUnknownFormat = errors.New("unknown date format")
+
+// layouts are tried in order. Go layouts are written with the reference time
+// Mon Jan 2 15:04:05 MST 2006, remembered as 01/02 03:04:05PM '06 -0700.
+var layouts = []struct {
+	name   string
+	layout string
+}{
+	{"RFC 3339", time.RFC3339Nano},
+	{"RFC 1123", time.RFC1123Z},
+	{"RFC 1123", time.RFC1123},
+	{"date and time", time.DateTime},
+	{"ISO 8601 without zone", "2006-01-02T15:04:05"},
+	{"date", time.DateOnly},
+	{"European", "02/01/2006 15:04"},
+	{"European", "02.01.2006"},
+	{"long", "January 2, 2006"},
+	{"short", "2 Jan 2006"},
+	{"access log", "02/Jan/2006:15:04:05 -0700"},
+}
+
+// ParseFlexible parses a date written in one of the supported layouts, or a
+// Unix timestamp in seconds. Layouts without a zone are read in loc.
+// Ambiguous formats like 03/04/2025 are only accepted in one order (day first).
+func ParseFlexible(value string, loc *time.Location) (time.Time, string, error) {
+	value = strings.TrimSpace(value)
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil && len(value) >= 9 {
+		return time.Unix(seconds, 0).In(loc), "Unix timestamp", nil
+	}
+
+	for _, l := range layouts {
+		if t, err := time.ParseInLocation(l.layout, value, loc); err == nil {
+			return t, l.name, nil
+		}
+	}
+	return time.Time{}, "", fmt.Errorf("%w: %q", ErrUnknownFormat, value)
+}
+
+// humanize describes how long ago or how far in the future t is
+func humanize(t, now time.Time) string {
+	d := now.Sub(t)
+	suffix := "ago"
+	if d < 0 {
+		d, suffix = -d, "from now"
+	}
+
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%d minutes %s", int(d.Minutes()), suffix)
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%d hours %s", int(d.Hours()), suffix)
+	default:
+		return fmt.Sprintf("%d days %s", int(d.Hours()/24), suffix)
+	}
+}
+
+func main() {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	now := time.Date(2026, time.October, 17, 14, 30, 0, 0, paris)
+
+	inputs := []string{
+		"2026-10-17T09:15:00Z",
+		"2026-10-17T09:15:00.123456+09:00",
+		"Sat, 17 Oct 2026 09:15:00 -0400",
+		"2026-10-17 09:15:00",
+		"2026-12-24",
+		"24/12/2026 18:30",
+		"24.12.2026",
+		"December 24, 2026",
+		"17/Oct/2026:10:15:32 +0000",
+		"1791000000",
+		"2026-02-30",
+		"yesterday",
+	}
+
+	fmt.Println("--- Parsing ---")
+	for _, input := range inputs {
+		t, layout, err := ParseFlexible(input, paris)
+		if err != nil {
+			fmt.Printf("%-36s error: %v\n", input, err)
+			continue
+		}
+		fmt.Printf("%-36s %-22s %s (%s)\n", input, layout, t.Format(time.RFC3339), humanize(t, now))
+	}
+
+	fmt.Println("\n--- Formatting ---")
+	t := time.Date(2026, time.March, 5, 8, 4, 9, 500_000_000, paris)
+	for _, layout := range []string{
+		time.RFC3339,
+		time.RFC3339Nano,
+		time.RFC1123,
+		time.Kitchen,
+		time.DateOnly,
+		"Monday, January 2, 2006",
+		"Mon 02/01/06 15h04",
+		"2006-01-02 15:04:05.000 MST",
+		"3:04PM -07:00",
+	} {
+		fmt.Printf("%-36s %s\n", layout, t.Format(layout))
+	}
+
+	fmt.Println("\n--- Same Instant in Other Zones ---")
+	for _, name := range []string{"UTC", "America/New_York", "Asia/Tokyo", "Australia/Adelaide"} {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			fmt.Println("Error:", err)
+			continue
+		}
+		zone, offset := t.In(loc).Zone()
+		fmt.Printf("%-20s %s  (%s, UTC%+.1f)\n", name, t.In(loc).Format(time.DateTime), zone, float64(offset)/3600)
+	}
+	// Equal compares instants, == also compares the location
+	fmt.Println("Equal in UTC:", t.Equal(t.UTC()), " == in UTC:", t == t.UTC())
+}
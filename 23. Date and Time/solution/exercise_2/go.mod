@@ -0,0 +1,3 @@
+module golang-training/module-23/exercise-2
+
+go 1.25
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"time"
+	_ "time/tzdata" // Embeds the time zone database, the program works on machines without one
+)
+
+const layout = "Mon 2006-01-02 15:04 MST"
+
+func main() {
+	// 1. A daily job across the start of daylight saving time in Paris
+	fmt.Println("--- Daily Backup at 09:00 Europe/Paris ---")
+	backup, err := NewDailySchedule("09:00", "Europe/Paris")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	start := time.Date(2026, time.March, 27, 12, 0, 0, 0, backup.Location)
+	naive := backup.Next(start.Add(-24 * time.Hour))
+	for _, run := range backup.Upcoming(start, 4) {
+		naive = naive.Add(24 * time.Hour)
+		fmt.Printf("schedule: %s   +24h: %s   UTC: %s\n",
+			run.Format(layout), naive.In(backup.Location).Format(layout), run.UTC().Format("15:04"))
+	}
+
+	// 2. Wall clock times that don't exist or exist twice
+	fmt.Println("\n--- Skipped and Repeated Times ---")
+	nightly, err := NewDailySchedule("02:30", "America/New_York")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	for _, day := range []time.Time{
+		time.Date(2026, time.March, 7, 12, 0, 0, 0, time.UTC),    // Clocks go forward on March 8
+		time.Date(2026, time.October, 31, 12, 0, 0, 0, time.UTC), // Clocks go back on November 1
+	} {
+		for _, run := range nightly.Upcoming(day, 2) {
+			fmt.Printf("02:30 New York job runs at %s (%s UTC)\n", run.Format(layout), run.UTC().Format("15:04"))
+		}
+	}
+	early, err := NewDailySchedule("01:30", "America/New_York")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	first := early.Next(time.Date(2026, time.October, 31, 12, 0, 0, 0, time.UTC))
+	fmt.Printf("01:30 on November 1 happens at %s UTC and at %s UTC, the job runs at %s\n",
+		first.UTC().Format("15:04"), first.Add(time.Hour).UTC().Format("15:04"), first.Format(layout))
+
+	// 3. A weekly meeting set in New York, seen from other time zones. The US
+	// and Europe change their clocks on different dates, so for a few weeks
+	// the meeting moves by one hour for the attendees in Paris.
+	fmt.Println("\n--- Monday Meeting at 10:00 America/New_York ---")
+	meeting, err := NewDailySchedule("10:00", "America/New_York", time.Monday)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	zones := []string{"Europe/Paris", "Asia/Tokyo", "Australia/Sydney"}
+	locations := make([]*time.Location, len(zones))
+	for i, zone := range zones {
+		if locations[i], err = time.LoadLocation(zone); err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+	}
+
+	fmt.Printf("%-12s", "New York")
+	for _, zone := range zones {
+		fmt.Printf(" %-18s", zone)
+	}
+	fmt.Println()
+	for _, run := range meeting.Upcoming(time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC), 7) {
+		fmt.Printf("%-12s", run.Format("Jan 02 15:04"))
+		for _, loc := range locations {
+			fmt.Printf(" %-18s", run.In(loc).Format("Mon 15:04 MST"))
+		}
+		fmt.Println()
+	}
+
+	// 4. When do the clocks change?
+	fmt.Println("\n--- Offset Changes in 2026 ---")
+	from := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+	for _, loc := range append([]*time.Location{meeting.Location}, locations...) {
+		transitions := Transitions(loc, from, to)
+		if len(transitions) == 0 {
+			fmt.Printf("%-18s no change\n", loc)
+		}
+		for _, tr := range transitions {
+			fmt.Printf("%-18s %s  %s -> %s\n", loc, tr.At.Format("2006-01-02 15:04"), tr.Before, tr.After)
+		}
+	}
+
+	// Durations measure elapsed time, calendar arithmetic uses AddDate
+	fmt.Println("\n--- Length of a Day ---")
+	for _, day := range []int{28, 29, 30} {
+		midnight := time.Date(2026, time.March, day, 0, 0, 0, 0, backup.Location)
+		fmt.Printf("March %d in Paris lasts %v\n", day, midnight.AddDate(0, 0, 1).Sub(midnight))
+	}
+}
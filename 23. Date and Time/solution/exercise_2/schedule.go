@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidClock is returned for a time of day outside 00:00-23:59
+var ErrInvalidClock = errors.New("invalid time of day")
+
+// Clock is a wall clock time of day, without a date or a time zone
+type Clock struct {
+	Hour   int
+	Minute int
+}
+
+// ParseClock parses a time of day written as 15:04
+func ParseClock(value string) (Clock, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return Clock{}, fmt.Errorf("%w: %q", ErrInvalidClock, value)
+	}
+	return Clock{Hour: t.Hour(), Minute: t.Minute()}, nil
+}
+
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d", c.Hour, c.Minute)
+}
+
+// DailySchedule runs at the same wall clock time every day in a time zone.
+// The next run is computed from the calendar date, not by adding 24 hours:
+// a day lasts 23 or 25 hours when daylight saving time starts or ends.
+type DailySchedule struct {
+	At       Clock
+	Location *time.Location
+	Weekdays []time.Weekday // Empty means every day
+}
+
+// NewDailySchedule creates a schedule running at a time of day in a time zone
+func NewDailySchedule(at, zone string, weekdays ...time.Weekday) (*DailySchedule, error) {
+	clock, err := ParseClock(at)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, fmt.Errorf("loading time zone: %w", err)
+	}
+	return &DailySchedule{At: clock, Location: loc, Weekdays: weekdays}, nil
+}
+
+// Next returns the first run strictly after t
+func (s *DailySchedule) Next(t time.Time) time.Time {
+	local := t.In(s.Location)
+	year, month, day := local.Date()
+
+	// Eight days are enough to find any weekday
+	for i := 0; i <= 8; i++ {
+		// time.Date normalizes the day overflow (January 32 is February 1) and
+		// the times skipped by daylight saving time (02:30 becomes 03:30)
+		run := s.at(year, month, day+i)
+		if run.After(t) && s.runsOn(run.Weekday()) {
+			return run
+		}
+	}
+	panic("unreachable: a weekday repeats every 7 days")
+}
+
+// Upcoming returns the next n runs after t
+func (s *DailySchedule) Upcoming(t time.Time, n int) []time.Time {
+	runs := make([]time.Time, 0, n)
+	for range n {
+		t = s.Next(t)
+		runs = append(runs, t)
+	}
+	return runs
+}
+
+// at returns the run of a calendar day. time.Date doesn't specify the result
+// for wall clock times that are skipped or repeated by daylight saving time,
+// and it differs between time zones, so both cases are handled here.
+func (s *DailySchedule) at(year int, month time.Month, day int) time.Time {
+	run := time.Date(year, month, day, s.At.Hour, s.At.Minute, 0, 0, s.Location)
+
+	// Skipped when the clocks go forward: use the offset from before the
+	// change, 02:30 becomes 03:30
+	if run.Hour() != s.At.Hour || run.Minute() != s.At.Minute {
+		_, before := run.Add(-12 * time.Hour).Zone()
+		wall := time.Date(year, month, day, s.At.Hour, s.At.Minute, 0, 0, time.UTC)
+		return wall.Add(-time.Duration(before) * time.Second).In(s.Location)
+	}
+
+	// Repeated when the clocks go back: use the first occurrence
+	if earlier := run.Add(-time.Hour); earlier.Hour() == run.Hour() && earlier.Minute() == run.Minute() {
+		return earlier
+	}
+	return run
+}
+
+func (s *DailySchedule) runsOn(weekday time.Weekday) bool {
+	if len(s.Weekdays) == 0 {
+		return true
+	}
+	for _, w := range s.Weekdays {
+		if w == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition is a change of UTC offset in a time zone
+type Transition struct {
+	At     time.Time
+	Before string
+	After  string
+}
+
+// Transitions returns the offset changes of a time zone between two instants,
+// found by comparing offsets hour by hour
+func Transitions(loc *time.Location, from, to time.Time) []Transition {
+	var transitions []Transition
+	_, previous := from.In(loc).Zone()
+	for t := from.Truncate(time.Hour).Add(time.Hour); t.Before(to); t = t.Add(time.Hour) {
+		name, offset := t.In(loc).Zone()
+		if offset != previous {
+			transitions = append(transitions, Transition{
+				At:     t.In(loc),
+				Before: formatOffset(previous),
+				After:  fmt.Sprintf("%s %s", name, formatOffset(offset)),
+			})
+			previous = offset
+		}
+	}
+	return transitions
+}
+
+func formatOffset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign, seconds = '-', -seconds
+	}
+	return fmt.Sprintf("UTC%c%02d:%02d", sign, seconds/3600, seconds%3600/60)
+}
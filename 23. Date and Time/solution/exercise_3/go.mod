@@ -0,0 +1,3 @@
+module golang-training/module-23/exercise-3
+
+go 1.25
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// phase is a part of the simulated job with its own processing speed
+type phase struct {
+	name    string
+	items   int
+	perItem time.Duration
+}
+
+func main() {
+	workers := flag.Int("workers", 3, "Number of workers")
+	refresh := flag.Duration("refresh", 500*time.Millisecond, "Progress refresh interval")
+	flag.Parse()
+
+	phases := []phase{
+		{"download", 200, 10 * time.Millisecond},
+		{"resize", 200, 40 * time.Millisecond},
+		{"upload", 200, 10 * time.Millisecond},
+	}
+	total := 0
+	for _, p := range phases {
+		total += p.items
+	}
+
+	fmt.Println("--- Progress ---")
+	stopwatch := NewStopwatch()
+	meter := NewRateMeter(250*time.Millisecond, 4) // Rate over the last second
+	progress := time.NewTicker(*refresh)
+	defer progress.Stop()
+
+	for _, p := range phases {
+		done := make(chan struct{})
+		go func() {
+			process(p, *workers, meter)
+			close(done)
+		}()
+
+	wait:
+		for {
+			select {
+			case <-progress.C:
+				printProgress(p.name, meter, total, stopwatch.Elapsed())
+			case <-done:
+				break wait
+			}
+		}
+		fmt.Printf("%-8s finished in %v\n", p.name, stopwatch.Lap().Round(time.Millisecond))
+	}
+	stopwatch.Stop()
+	meter.Stop()
+
+	fmt.Println("\n--- Summary ---")
+	for i, lap := range stopwatch.Laps() {
+		p := phases[i]
+		fmt.Printf("%-8s %4d items %8v %8.1f items/s\n",
+			p.name, p.items, lap.Round(time.Millisecond), float64(p.items)/lap.Seconds())
+	}
+	fmt.Printf("%-8s %4d items %8v %8.1f items/s\n",
+		"total", total, stopwatch.Elapsed().Round(time.Millisecond), float64(total)/stopwatch.Elapsed().Seconds())
+
+	fmt.Println("\n--- Slow Receiver ---")
+	slowReceiver()
+}
+
+// process handles the items of a phase with a pool of workers
+func process(p phase, workers int, meter *RateMeter) {
+	items := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range items {
+				time.Sleep(p.perItem)
+				meter.Add(1)
+			}
+		}()
+	}
+	for i := range p.items {
+		items <- i
+	}
+	close(items)
+	wg.Wait()
+}
+
+func printProgress(name string, meter *RateMeter, total int, elapsed time.Duration) {
+	count := meter.Count()
+	rate := meter.Rate()
+	eta := "unknown"
+	if d, ok := ETA(int64(total)-count, rate); ok {
+		eta = d.Round(100 * time.Millisecond).String()
+	}
+	fmt.Printf("%-8s %3d/%d %5.1f%%  %6.1f items/s (avg %6.1f)  elapsed %-6v ETA %s\n",
+		name, count, total, float64(count)*100/float64(total),
+		rate, meter.AverageRate(), elapsed.Round(100*time.Millisecond), eta)
+}
+
+// slowReceiver shows that a ticker drops the ticks a slow receiver misses
+// instead of queuing them: at most one tick is pending
+func slowReceiver() {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	start := time.Now()
+	for range 4 {
+		tick := <-ticker.C
+		fmt.Printf("tick at %4v, received at %4v\n",
+			tick.Sub(start).Round(10*time.Millisecond), time.Since(start).Round(10*time.Millisecond))
+		time.Sleep(250 * time.Millisecond) // Slower than the ticker
+	}
+	fmt.Printf("4 ticks received in %v, a 100ms ticker would have sent %d\n",
+		time.Since(start).Round(10*time.Millisecond), time.Since(start)/(100*time.Millisecond))
+}
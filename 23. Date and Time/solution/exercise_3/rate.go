@@ -0,0 +1,115 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type sample struct {
+	at    time.Time
+	count int64
+}
+
+// RateMeter counts events and computes their rate over a sliding window.
+// A time.Ticker takes a sample of the counter at every interval, the rate is
+// computed from the oldest and newest samples of the window.
+type RateMeter struct {
+	count   atomic.Int64
+	started time.Time
+	ticker  *time.Ticker
+	done    chan struct{}
+	stopped sync.WaitGroup
+
+	mu      sync.Mutex
+	samples []sample // The last window+1 samples, oldest first
+	window  int
+}
+
+// NewRateMeter creates a meter sampling at every interval and averaging
+// over the last window intervals
+func NewRateMeter(interval time.Duration, window int) *RateMeter {
+	now := time.Now()
+	m := &RateMeter{
+		started: now,
+		ticker:  time.NewTicker(interval),
+		done:    make(chan struct{}),
+		samples: []sample{{at: now}},
+		window:  window,
+	}
+
+	m.stopped.Add(1)
+	go m.run()
+	return m
+}
+
+func (m *RateMeter) run() {
+	defer m.stopped.Done()
+	for {
+		select {
+		case <-m.ticker.C:
+			// Ticks are dropped when the receiver is late, so the real time
+			// of the sample is used instead of assuming one interval passed
+			m.record(time.Now())
+		case <-m.done:
+			return
+		}
+	}
+}
+
+func (m *RateMeter) record(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.samples = append(m.samples, sample{at: now, count: m.count.Load()})
+	if len(m.samples) > m.window+1 {
+		m.samples = m.samples[1:]
+	}
+}
+
+// Add counts n events
+func (m *RateMeter) Add(n int64) {
+	m.count.Add(n)
+}
+
+// Count returns the number of events counted so far
+func (m *RateMeter) Count() int64 {
+	return m.count.Load()
+}
+
+// Rate returns the number of events per second over the window
+func (m *RateMeter) Rate() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	first, last := m.samples[0], m.samples[len(m.samples)-1]
+	seconds := last.at.Sub(first.at).Seconds()
+	if seconds == 0 {
+		return 0
+	}
+	return float64(last.count-first.count) / seconds
+}
+
+// AverageRate returns the number of events per second since the start
+func (m *RateMeter) AverageRate() float64 {
+	seconds := time.Since(m.started).Seconds()
+	if seconds == 0 {
+		return 0
+	}
+	return float64(m.Count()) / seconds
+}
+
+// Stop stops the ticker and waits for the sampling goroutine to exit
+func (m *RateMeter) Stop() {
+	m.ticker.Stop()
+	close(m.done)
+	m.stopped.Wait()
+}
+
+// ETA estimates the time needed for the remaining events at the given rate
+func ETA(remaining int64, rate float64) (time.Duration, bool) {
+	if rate <= 0 {
+		return 0, false
+	}
+	return time.Duration(float64(remaining) / rate * float64(time.Second)), true
+}
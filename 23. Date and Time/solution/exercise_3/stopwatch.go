@@ -0,0 +1,64 @@
+package main
+
+import "time"
+
+// Stopwatch measures elapsed time with laps. It uses the monotonic clock
+// reading of time.Now, so changing the system clock doesn't affect it.
+// A Stopwatch is not safe for concurrent use.
+type Stopwatch struct {
+	started time.Time     // When the current run started
+	elapsed time.Duration // Time accumulated by the previous runs
+	running bool
+	laps    []time.Duration
+	lapEnd  time.Duration // Elapsed time at the end of the last lap
+}
+
+// NewStopwatch creates a started stopwatch
+func NewStopwatch() *Stopwatch {
+	s := &Stopwatch{}
+	s.Start()
+	return s
+}
+
+// Start starts or resumes the stopwatch
+func (s *Stopwatch) Start() {
+	if !s.running {
+		s.started = time.Now()
+		s.running = true
+	}
+}
+
+// Stop pauses the stopwatch, the time until Start is not counted
+func (s *Stopwatch) Stop() {
+	if s.running {
+		s.elapsed += time.Since(s.started)
+		s.running = false
+	}
+}
+
+// Reset stops the stopwatch and clears the elapsed time and the laps
+func (s *Stopwatch) Reset() {
+	*s = Stopwatch{}
+}
+
+// Elapsed returns the total running time
+func (s *Stopwatch) Elapsed() time.Duration {
+	if s.running {
+		return s.elapsed + time.Since(s.started)
+	}
+	return s.elapsed
+}
+
+// Lap records and returns the time since the previous lap
+func (s *Stopwatch) Lap() time.Duration {
+	elapsed := s.Elapsed()
+	lap := elapsed - s.lapEnd
+	s.lapEnd = elapsed
+	s.laps = append(s.laps, lap)
+	return lap
+}
+
+// Laps returns the recorded laps
+func (s *Stopwatch) Laps() []time.Duration {
+	return s.laps
+}
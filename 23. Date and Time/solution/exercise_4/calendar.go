@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Calendar knows the weekend days and the holidays of a country or a company.
+// The holidays of a year are computed from the rules on first use and cached.
+// A Calendar is safe for concurrent use.
+type Calendar struct {
+	Name    string
+	weekend map[time.Weekday]bool
+	rules   []Rule
+	extra   map[Date]string // One-off closing days
+
+	mu    sync.Mutex
+	years map[int]map[Date]string
+}
+
+// NewCalendar creates a calendar with a Saturday and Sunday weekend
+func NewCalendar(name string, rules ...Rule) *Calendar {
+	return &Calendar{
+		Name:    name,
+		weekend: map[time.Weekday]bool{time.Saturday: true, time.Sunday: true},
+		rules:   rules,
+		extra:   make(map[Date]string),
+		years:   make(map[int]map[Date]string),
+	}
+}
+
+// SetWeekend replaces the weekend days
+func (c *Calendar) SetWeekend(days ...time.Weekday) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.weekend = make(map[time.Weekday]bool, len(days))
+	for _, day := range days {
+		c.weekend[day] = true
+	}
+}
+
+// AddHoliday adds a one-off closing day
+func (c *Calendar) AddHoliday(date Date, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.extra[date] = name
+	clear(c.years) // The cached years may contain the date
+}
+
+// Merge returns a calendar where a day is off when it is off in any of the
+// calendars, for example to settle a payment between two countries
+func Merge(name string, calendars ...*Calendar) *Calendar {
+	merged := NewCalendar(name)
+	merged.weekend = make(map[time.Weekday]bool)
+	for _, c := range calendars {
+		c.mu.Lock()
+		for day := range c.weekend {
+			merged.weekend[day] = true
+		}
+		for date, holiday := range c.extra {
+			merged.extra[date] = holiday
+		}
+		merged.rules = append(merged.rules, c.rules...)
+		c.mu.Unlock()
+	}
+	return merged
+}
+
+// holidaysOf returns the holidays computed from the rules of a year. The
+// caller must hold c.mu.
+func (c *Calendar) holidaysOf(year int) map[Date]string {
+	if holidays, ok := c.years[year]; ok {
+		return holidays
+	}
+
+	holidays := make(map[Date]string)
+	for _, rule := range c.rules {
+		h := rule.Holiday(year)
+		if name, ok := holidays[h.Date]; ok {
+			h.Name = name + ", " + h.Name // Two holidays on the same day
+		}
+		holidays[h.Date] = h.Name
+	}
+	c.years[year] = holidays
+	return holidays
+}
+
+// IsHoliday returns the name of the holiday on a date
+func (c *Calendar) IsHoliday(date Date) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if name, ok := c.extra[date]; ok {
+		return name, true
+	}
+	// An observed holiday can move to the previous or the next year: New
+	// Year's Day on a Saturday is observed on Friday December 31
+	for _, year := range []int{date.Year, date.Year + 1, date.Year - 1} {
+		if name, ok := c.holidaysOf(year)[date]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// IsWeekend reports whether a date falls on a weekend day
+func (c *Calendar) IsWeekend(date Date) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.weekend[date.Weekday()]
+}
+
+// IsBusinessDay reports whether a date is neither a weekend day nor a holiday
+func (c *Calendar) IsBusinessDay(date Date) bool {
+	if c.IsWeekend(date) {
+		return false
+	}
+	_, holiday := c.IsHoliday(date)
+	return !holiday
+}
+
+// Holidays returns the holidays of a year sorted by date
+func (c *Calendar) Holidays(year int) []Holiday {
+	var holidays []Holiday
+	for date := NewDate(year, time.January, 1); date.Year == year; date = date.AddDays(1) {
+		if name, ok := c.IsHoliday(date); ok {
+			holidays = append(holidays, Holiday{Date: date, Name: name})
+		}
+	}
+	return holidays
+}
+
+// NextBusinessDay returns the date if it is a business day, otherwise the
+// first business day after it
+func (c *Calendar) NextBusinessDay(date Date) Date {
+	for !c.IsBusinessDay(date) {
+		date = date.AddDays(1)
+	}
+	return date
+}
+
+// AddBusinessDays returns the date n business days after date, or before
+// it when n is negative. Counting starts from the next day, so adding one
+// business day to a Friday gives the next Monday.
+func (c *Calendar) AddBusinessDays(date Date, n int) Date {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		date = date.AddDays(step)
+		if c.IsBusinessDay(date) {
+			n--
+		}
+	}
+	return date
+}
+
+// BusinessDaysBetween counts the business days from start, included, to
+// end, excluded. It is negative when end is before start.
+func (c *Calendar) BusinessDaysBetween(start, end Date) int {
+	sign := 1
+	if end.Before(start) {
+		start, end, sign = end, start, -1
+	}
+	count := 0
+	for date := start; date.Before(end); date = date.AddDays(1) {
+		if c.IsBusinessDay(date) {
+			count++
+		}
+	}
+	return sign * count
+}
+
+// calendarFile is the JSON format of a company calendar
+type calendarFile struct {
+	Name     string   `json:"name"`
+	Weekend  []string `json:"weekend"`
+	Holidays []struct {
+		Date string `json:"date"`
+		Name string `json:"name"`
+	} `json:"holidays"`
+}
+
+// LoadCalendar reads a calendar of one-off closing days from a JSON file
+func LoadCalendar(path string) (*Calendar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading calendar: %w", err)
+	}
+	var file calendarFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing calendar %s: %w", path, err)
+	}
+
+	calendar := NewCalendar(file.Name)
+	if file.Weekend != nil {
+		days := make([]time.Weekday, 0, len(file.Weekend))
+		for _, name := range file.Weekend {
+			day, err := parseWeekday(name)
+			if err != nil {
+				return nil, fmt.Errorf("calendar %s: %w", path, err)
+			}
+			days = append(days, day)
+		}
+		calendar.SetWeekend(days...)
+	}
+	for _, h := range file.Holidays {
+		date, err := ParseDate(h.Date)
+		if err != nil {
+			return nil, fmt.Errorf("calendar %s, holiday %q: %w", path, h.Name, err)
+		}
+		calendar.AddHoliday(date, h.Name)
+	}
+	return calendar, nil
+}
+
+func parseWeekday(name string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), name) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday %q", name)
+}
+
+// weekendNames returns the weekend days from Monday to Sunday, for display
+func (c *Calendar) weekendNames() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var names []string
+	for i := 1; i <= 7; i++ {
+		if day := time.Weekday(i % 7); c.weekend[day] {
+			names = append(names, day.String())
+		}
+	}
+	return names
+}
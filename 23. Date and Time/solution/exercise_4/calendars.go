@@ -0,0 +1,44 @@
+package main
+
+import "time"
+
+// UnitedStates returns the calendar of the US federal holidays
+func UnitedStates() *Calendar {
+	return NewCalendar("United States",
+		Observed{Fixed{"New Year's Day", time.January, 1}},
+		NthWeekday{"Martin Luther King Jr. Day", time.January, time.Monday, 3},
+		NthWeekday{"Washington's Birthday", time.February, time.Monday, 3},
+		NthWeekday{"Memorial Day", time.May, time.Monday, -1},
+		Observed{Fixed{"Juneteenth", time.June, 19}},
+		Observed{Fixed{"Independence Day", time.July, 4}},
+		NthWeekday{"Labor Day", time.September, time.Monday, 1},
+		NthWeekday{"Columbus Day", time.October, time.Monday, 2},
+		Observed{Fixed{"Veterans Day", time.November, 11}},
+		NthWeekday{"Thanksgiving Day", time.November, time.Thursday, 4},
+		Observed{Fixed{"Christmas Day", time.December, 25}},
+	)
+}
+
+// France returns the calendar of the French public holidays. Holidays falling
+// on a weekend are not moved.
+func France() *Calendar {
+	return NewCalendar("France",
+		Fixed{"Jour de l'an", time.January, 1},
+		EasterOffset{"Lundi de Pâques", 1},
+		Fixed{"Fête du Travail", time.May, 1},
+		Fixed{"Victoire 1945", time.May, 8},
+		EasterOffset{"Ascension", 39},
+		EasterOffset{"Lundi de Pentecôte", 50},
+		Fixed{"Fête nationale", time.July, 14},
+		Fixed{"Assomption", time.August, 15},
+		Fixed{"Toussaint", time.November, 1},
+		Fixed{"Armistice 1918", time.November, 11},
+		Fixed{"Noël", time.December, 25},
+	)
+}
+
+// calendars are the calendars available with the -calendar flag
+var calendars = map[string]func() *Calendar{
+	"us": UnitedStates,
+	"fr": France,
+}
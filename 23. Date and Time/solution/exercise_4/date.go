@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Date is a calendar day without a time of day or a time zone. Comparing
+// time.Time values for days is error-prone: two times on the same day differ
+// by their hours, and midnight doesn't exist on some days in some zones.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// NewDate returns a date, normalizing out of range values like time.Date
+// does: January 32 is February 1
+func NewDate(year int, month time.Month, day int) Date {
+	return DateOf(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+// DateOf returns the day of t in its own time zone
+func DateOf(t time.Time) Date {
+	year, month, day := t.Date()
+	return Date{Year: year, Month: month, Day: day}
+}
+
+// ParseDate parses a date written as 2006-01-02
+func ParseDate(value string) (Date, error) {
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return Date{}, fmt.Errorf("parsing date: %w", err)
+	}
+	return DateOf(t), nil
+}
+
+// Time returns midnight UTC of the date
+func (d Date) Time() time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, time.UTC)
+}
+
+// AddDays returns the date n days later, n can be negative
+func (d Date) AddDays(n int) Date {
+	return NewDate(d.Year, d.Month, d.Day+n)
+}
+
+// Weekday returns the day of the week
+func (d Date) Weekday() time.Weekday {
+	return d.Time().Weekday()
+}
+
+// Before reports whether d is before other
+func (d Date) Before(other Date) bool {
+	return d.Time().Before(other.Time())
+}
+
+// DaysUntil returns the number of days from d to other
+func (d Date) DaysUntil(other Date) int {
+	// Both are midnight UTC, so every day lasts exactly 24 hours
+	return int(other.Time().Sub(d.Time()).Hours() / 24)
+}
+
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func main() {
+	name := flag.String("calendar", "", "Calendar to use: us, fr, or both separated by a comma")
+	company := flag.String("company", "", "JSON file with company closing days, merged with the calendar")
+	from := flag.String("from", "", "Start date (2006-01-02)")
+	add := flag.Int("add", 0, "Number of business days to add to -from")
+	to := flag.String("to", "", "Count the business days from -from to this date")
+	year := flag.Int("year", 0, "List the holidays of this year")
+	flag.Parse()
+
+	if *name == "" {
+		demo()
+		return
+	}
+
+	calendar, err := buildCalendar(*name, *company)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Calendar: %s, weekend: %s\n", calendar.Name, strings.Join(calendar.weekendNames(), ", "))
+
+	if *year != 0 {
+		printHolidays(calendar, *year)
+	}
+	if *from == "" {
+		return
+	}
+	start, err := ParseDate(*from)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	describe(calendar, start)
+
+	if *add != 0 {
+		result := calendar.AddBusinessDays(start, *add)
+		fmt.Printf("%s %+d business days = %s (%d calendar days)\n", start, *add, format(result), start.DaysUntil(result))
+	}
+	if *to != "" {
+		end, err := ParseDate(*to)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Business days from %s to %s: %d (%d calendar days)\n",
+			start, end, calendar.BusinessDaysBetween(start, end), start.DaysUntil(end))
+	}
+}
+
+// buildCalendar merges the calendars named in a comma separated list, and
+// the company calendar if a file is given
+func buildCalendar(names, companyFile string) (*Calendar, error) {
+	var selected []*Calendar
+	for _, name := range strings.Split(names, ",") {
+		newCalendar, ok := calendars[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown calendar %q", name)
+		}
+		selected = append(selected, newCalendar())
+	}
+	if companyFile != "" {
+		company, err := LoadCalendar(companyFile)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, company)
+	}
+	if len(selected) == 1 {
+		return selected[0], nil
+	}
+
+	parts := make([]string, len(selected))
+	for i, c := range selected {
+		parts[i] = c.Name
+	}
+	return Merge(strings.Join(parts, " + "), selected...), nil
+}
+
+func demo() {
+	us, fr := UnitedStates(), France()
+
+	fmt.Println("--- Holidays in 2026 ---")
+	printHolidays(us, 2026)
+	printHolidays(fr, 2026)
+
+	fmt.Println("\n--- Easter Sunday ---")
+	for year := 2025; year <= 2030; year++ {
+		fmt.Printf("%d: %s\n", year, Easter(year))
+	}
+
+	fmt.Println("\n--- Observed Holidays ---")
+	for _, date := range []Date{NewDate(2026, 7, 3), NewDate(2027, 12, 24), NewDate(2027, 12, 31)} {
+		describe(us, date)
+	}
+
+	fmt.Println("\n--- Invoice Due in 30 Business Days ---")
+	issued := NewDate(2026, 11, 20)
+	for _, c := range []*Calendar{us, fr} {
+		due := c.AddBusinessDays(issued, 30)
+		fmt.Printf("%-14s issued %s, due %s (%d calendar days)\n", c.Name, issued, format(due), issued.DaysUntil(due))
+	}
+
+	fmt.Println("\n--- Payment Settled Two Business Days Later in Both Countries ---")
+	both := Merge("United States + France", us, fr)
+	for _, trade := range []Date{NewDate(2026, 5, 6), NewDate(2026, 7, 2), NewDate(2026, 11, 10)} {
+		fmt.Printf("Trade %s -> US %s, France %s, both %s\n", format(trade),
+			us.AddBusinessDays(trade, 2), fr.AddBusinessDays(trade, 2), both.AddBusinessDays(trade, 2))
+	}
+
+	fmt.Println("\n--- Warehouse Deliveries ---")
+	warehouse, err := LoadCalendar("company.json")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	shipping := Merge("Warehouse (France)", fr, warehouse)
+	for _, ordered := range []Date{NewDate(2026, 12, 18), NewDate(2026, 12, 22), NewDate(2026, 12, 28)} {
+		delivery := shipping.AddBusinessDays(ordered, 3)
+		fmt.Printf("Ordered %s, delivered %s\n", format(ordered), format(delivery))
+	}
+	fmt.Printf("Working days in December 2026: %d\n",
+		shipping.BusinessDaysBetween(NewDate(2026, 12, 1), NewDate(2027, 1, 1)))
+	fmt.Printf("Working days in 2026: US %d, France %d, warehouse %d\n",
+		us.BusinessDaysBetween(NewDate(2026, 1, 1), NewDate(2027, 1, 1)),
+		fr.BusinessDaysBetween(NewDate(2026, 1, 1), NewDate(2027, 1, 1)),
+		shipping.BusinessDaysBetween(NewDate(2026, 1, 1), NewDate(2027, 1, 1)))
+}
+
+func printHolidays(c *Calendar, year int) {
+	fmt.Printf("%s:\n", c.Name)
+	for _, h := range c.Holidays(year) {
+		fmt.Printf("  %s %-9s %s\n", h.Date, h.Date.Weekday(), h.Name)
+	}
+}
+
+// describe prints whether a date is a business day and why not
+func describe(c *Calendar, date Date) {
+	switch name, holiday := c.IsHoliday(date); {
+	case holiday:
+		fmt.Printf("%s is a holiday in %s: %s\n", format(date), c.Name, name)
+	case c.IsWeekend(date):
+		fmt.Printf("%s is a weekend day in %s\n", format(date), c.Name)
+	default:
+		fmt.Printf("%s is a business day in %s\n", format(date), c.Name)
+	}
+}
+
+// format prints a date with its weekday
+func format(d Date) string {
+	return fmt.Sprintf("%s %s", d.Weekday().String()[:3], d)
+}
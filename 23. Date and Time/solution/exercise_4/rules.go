@@ -0,0 +1,98 @@
+package main
+
+import "time"
+
+// Holiday is a day off with its name
+type Holiday struct {
+	Date Date
+	Name string
+}
+
+// Rule computes the date of a holiday in a given year
+type Rule interface {
+	Holiday(year int) Holiday
+}
+
+// Fixed is a holiday on the same date every year, like Christmas
+type Fixed struct {
+	Name  string
+	Month time.Month
+	Day   int
+}
+
+// Holiday implements Rule
+func (r Fixed) Holiday(year int) Holiday {
+	return Holiday{Date: NewDate(year, r.Month, r.Day), Name: r.Name}
+}
+
+// NthWeekday is a holiday on the Nth weekday of a month, like Thanksgiving on
+// the fourth Thursday of November. N = -1 means the last one.
+type NthWeekday struct {
+	Name    string
+	Month   time.Month
+	Weekday time.Weekday
+	N       int
+}
+
+// Holiday implements Rule
+func (r NthWeekday) Holiday(year int) Holiday {
+	if r.N < 0 {
+		// Go back from the last day of the month
+		last := NewDate(year, r.Month+1, 0)
+		offset := (int(last.Weekday()) - int(r.Weekday) + 7) % 7
+		return Holiday{Date: last.AddDays(-offset - 7*(-r.N-1)), Name: r.Name}
+	}
+
+	first := NewDate(year, r.Month, 1)
+	offset := (int(r.Weekday) - int(first.Weekday()) + 7) % 7
+	return Holiday{Date: first.AddDays(offset + 7*(r.N-1)), Name: r.Name}
+}
+
+// EasterOffset is a holiday a number of days after Easter Sunday, like
+// Easter Monday (1) or Good Friday (-2)
+type EasterOffset struct {
+	Name string
+	Days int
+}
+
+// Holiday implements Rule
+func (r EasterOffset) Holiday(year int) Holiday {
+	return Holiday{Date: Easter(year).AddDays(r.Days), Name: r.Name}
+}
+
+// Observed moves a holiday falling on a weekend to the closest weekday:
+// Saturday to Friday and Sunday to Monday, as in the United States
+type Observed struct {
+	Rule Rule
+}
+
+// Holiday implements Rule
+func (r Observed) Holiday(year int) Holiday {
+	h := r.Rule.Holiday(year)
+	switch h.Date.Weekday() {
+	case time.Saturday:
+		h.Date = h.Date.AddDays(-1)
+		h.Name += " (observed)"
+	case time.Sunday:
+		h.Date = h.Date.AddDays(1)
+		h.Name += " (observed)"
+	}
+	return h
+}
+
+// Easter returns the date of Easter Sunday in the Gregorian calendar, using
+// the anonymous Gregorian algorithm (Meeus/Jones/Butcher)
+func Easter(year int) Date {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return NewDate(year, time.Month(month), day)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+func main() {
+	fmt.Println("--- Infinite Sequences ---")
+	for n := range Naturals() {
+		if n > 5 {
+			break
+		}
+		fmt.Print(n, " ")
+	}
+	fmt.Println()
+	for f := range Fibonacci() {
+		if f > 100 {
+			break
+		}
+		fmt.Print(f, " ")
+	}
+	fmt.Println()
+
+	fmt.Println("\n--- Values Are Produced On Demand ---")
+	log := func(format string, args ...any) {
+		fmt.Printf("  [iterator] "+format+"\n", args...)
+	}
+	for n := range Countdown(5, log) {
+		fmt.Println("  [loop] got", n)
+		if n == 3 {
+			fmt.Println("  [loop] break")
+			break
+		}
+	}
+
+	fmt.Println("\n--- Tree Traversal ---")
+	var tree Tree[string]
+	tree.Insert("kiwi", "apple", "mango", "banana", "cherry", "apple", "fig")
+	fmt.Printf("Ascending (%d): %v\n", tree.Len(), slices.Collect(tree.All()))
+	fmt.Print("Descending: ")
+	for fruit := range tree.Backward() {
+		fmt.Print(fruit, " ")
+	}
+	fmt.Printf("\nFirst fruit after 'c': ")
+	for fruit := range tree.All() {
+		if fruit > "c" {
+			fmt.Println(fruit) // The rest of the tree is never visited
+			break
+		}
+	}
+
+	fmt.Println("\n--- Pulling Two Sequences ---")
+	fmt.Println("Multiples of 3 and 5:", slices.Collect(MergeSorted(Multiples(3, 20), Multiples(5, 20))))
+	fmt.Print("Merged Fibonacci and squares: ")
+	for n := range MergeSorted(Fibonacci(), Squares()) {
+		if n > 60 {
+			break
+		}
+		fmt.Print(n, " ")
+	}
+	fmt.Println()
+
+	fmt.Println("\n--- Iterators in the Standard Library ---")
+	stock := map[string]int{"pear": 3, "apple": 12, "plum": 0, "grape": 7}
+	fmt.Println("Sorted keys:", slices.Sorted(maps.Keys(stock)))
+	for i, fruit := range slices.Backward([]string{"first", "second", "third"}) {
+		fmt.Printf("%d:%s ", i, fruit)
+	}
+	fmt.Println()
+	for field := range strings.FieldsSeq("  lazy   split  without a slice ") {
+		fmt.Printf("[%s]", field)
+	}
+	fmt.Println()
+	for line := range strings.Lines("first line\nsecond line\n") {
+		fmt.Printf("%q ", line)
+	}
+	fmt.Println()
+	fmt.Println("Chunks:", slices.Collect(slices.Chunk([]int{1, 2, 3, 4, 5, 6, 7}, 3)))
+}
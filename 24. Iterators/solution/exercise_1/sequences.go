@@ -0,0 +1,93 @@
+package main
+
+import "iter"
+
+// Naturals returns the infinite sequence 1, 2, 3, ... The caller stops it
+// with break, nothing is computed in advance.
+func Naturals() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for n := 1; ; n++ {
+			if !yield(n) {
+				return
+			}
+		}
+	}
+}
+
+// Fibonacci returns the infinite Fibonacci sequence 0, 1, 1, 2, 3, 5, ...
+func Fibonacci() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		a, b := 0, 1
+		for {
+			if !yield(a) {
+				return
+			}
+			a, b = b, a+b
+		}
+	}
+}
+
+// Squares returns the infinite sequence of squares 0, 1, 4, 9, ...
+func Squares() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for n := 0; ; n++ {
+			if !yield(n * n) {
+				return
+			}
+		}
+	}
+}
+
+// Countdown returns n, n-1, ..., 1 and logs each value it produces, to show
+// when the values are computed
+func Countdown(n int, log func(string, ...any)) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		log("countdown starts")
+		defer log("countdown cleans up")
+		for i := n; i > 0; i-- {
+			log("producing %d", i)
+			if !yield(i) {
+				log("consumer stopped at %d", i)
+				return
+			}
+		}
+	}
+}
+
+// MergeSorted merges two sorted sequences into one sorted sequence. It uses
+// iter.Pull to read both sequences one value at a time.
+func MergeSorted(a, b iter.Seq[int]) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		nextA, stopA := iter.Pull(a)
+		defer stopA()
+		nextB, stopB := iter.Pull(b)
+		defer stopB()
+
+		va, okA := nextA()
+		vb, okB := nextB()
+		for okA || okB {
+			if okA && (!okB || va <= vb) {
+				if !yield(va) {
+					return
+				}
+				va, okA = nextA()
+			} else {
+				if !yield(vb) {
+					return
+				}
+				vb, okB = nextB()
+			}
+		}
+	}
+}
+
+// Multiples returns the multiples of n up to max
+func Multiples(n, max int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for m := n; m <= max; m += n {
+			if !yield(m) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"cmp"
+	"iter"
+)
+
+// Tree is a binary search tree. Its iterators walk the tree recursively,
+// which would need an explicit stack without range over functions.
+type Tree[T cmp.Ordered] struct {
+	root *node[T]
+	size int
+}
+
+type node[T cmp.Ordered] struct {
+	value       T
+	left, right *node[T]
+}
+
+// Insert adds values, duplicates are ignored
+func (t *Tree[T]) Insert(values ...T) {
+	for _, value := range values {
+		t.insert(value)
+	}
+}
+
+func (t *Tree[T]) insert(value T) {
+	link := &t.root
+	for *link != nil {
+		switch c := cmp.Compare(value, (*link).value); {
+		case c < 0:
+			link = &(*link).left
+		case c > 0:
+			link = &(*link).right
+		default:
+			return
+		}
+	}
+	*link = &node[T]{value: value}
+	t.size++
+}
+
+// Len returns the number of values
+func (t *Tree[T]) Len() int {
+	return t.size
+}
+
+// All returns the values in ascending order
+func (t *Tree[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		t.root.ascend(yield)
+	}
+}
+
+// Backward returns the values in descending order
+func (t *Tree[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		t.root.descend(yield)
+	}
+}
+
+// ascend returns false when yield asked to stop, so every level of the
+// recursion stops too
+func (n *node[T]) ascend(yield func(T) bool) bool {
+	if n == nil {
+		return true
+	}
+	return n.left.ascend(yield) && yield(n.value) && n.right.ascend(yield)
+}
+
+func (n *node[T]) descend(yield func(T) bool) bool {
+	if n == nil {
+		return true
+	}
+	return n.right.descend(yield) && yield(n.value) && n.left.descend(yield)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ErrUnexpectedStatus is returned when the API answers with an error status
+var ErrUnexpectedStatus = errors.New("unexpected status")
+
+// BookClient reads the books of the book store API
+type BookClient struct {
+	baseURL    string
+	pageSize   int
+	httpClient *http.Client
+}
+
+// NewBookClient creates a client fetching pageSize books per request
+func NewBookClient(baseURL string, pageSize int) *BookClient {
+	return &BookClient{
+		baseURL:    baseURL,
+		pageSize:   pageSize,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Page fetches one page of books
+func (c *BookClient) Page(ctx context.Context, page int) (*BookPage, error) {
+	query := url.Values{
+		"page":      {strconv.Itoa(page)},
+		"page_size": {strconv.Itoa(c.pageSize)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/books?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching page %d: %w", page, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching page %d: %w: %s", page, ErrUnexpectedStatus, resp.Status)
+	}
+	var result BookPage
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decoding page %d: %w", page, err)
+	}
+	return &result, nil
+}
+
+// Pages returns the pages of books, fetched one at a time when the loop asks
+// for the next one. An error is yielded once and ends the sequence.
+func (c *BookClient) Pages(ctx context.Context) iter.Seq2[*BookPage, error] {
+	return func(yield func(*BookPage, error) bool) {
+		for page := 1; ; page++ {
+			result, err := c.Page(ctx, page)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if len(result.Books) == 0 || !yield(result, nil) {
+				return
+			}
+			if page*result.PageSize >= result.Total {
+				return // Last page, don't ask for an empty one
+			}
+		}
+	}
+}
+
+// Books returns every book of the store. The pages are fetched transparently:
+// the caller ranges over books, and stopping the loop stops the requests.
+//
+//	for book, err := range client.Books(ctx) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(book.Title)
+//	}
+func (c *BookClient) Books(ctx context.Context) iter.Seq2[Book, error] {
+	return func(yield func(Book, error) bool) {
+		for page, err := range c.Pages(ctx) {
+			if err != nil {
+				yield(Book{}, err)
+				return
+			}
+			for _, book := range page.Books {
+				if !yield(book, nil) {
+					return
+				}
+			}
+		}
+	}
+}
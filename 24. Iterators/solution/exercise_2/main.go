@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http/httptest"
+	"time"
+)
+
+func main() {
+	pageSize := flag.Int("page-size", 10, "Books per request")
+	flag.Parse()
+
+	// The book store runs in the same process, so the requests can be counted
+	store := NewBookStore()
+	server := httptest.NewServer(store)
+	defer server.Close()
+
+	client := NewBookClient(server.URL, *pageSize)
+	ctx := context.Background()
+
+	fmt.Println("--- Every Book ---")
+	count := 0
+	for book, err := range client.Books(ctx) {
+		if err != nil {
+			fmt.Println("Error:", err)
+			break
+		}
+		count++
+		fmt.Printf("%3d. %-52s %d\n", book.ID, book.Title, book.Year)
+	}
+	fmt.Printf("%d books, requests: %d\n", count, store.Requests.Swap(0))
+
+	fmt.Println("\n--- First Three Books Before 1990 ---")
+	found := 0
+	for book, err := range client.Books(ctx) {
+		if err != nil {
+			fmt.Println("Error:", err)
+			break
+		}
+		if book.Year < 1990 {
+			fmt.Printf("%s (%s, %d)\n", book.Title, book.Author, book.Year)
+			if found++; found == 3 {
+				break // No more pages are fetched
+			}
+		}
+	}
+	fmt.Printf("Requests: %d\n", store.Requests.Swap(0))
+
+	fmt.Println("\n--- Page by Page ---")
+	for page, err := range client.Pages(ctx) {
+		if err != nil {
+			fmt.Println("Error:", err)
+			break
+		}
+		fmt.Printf("Page %d: %d books, first %q\n", page.Page, len(page.Books), page.Books[0].Title)
+	}
+	store.Requests.Store(0)
+
+	fmt.Println("\n--- Server Error on Page 3 ---")
+	store.FailPage.Store(3)
+	count = 0
+	for _, err := range client.Books(ctx) {
+		if err != nil {
+			fmt.Printf("Error after %d books: %v\n", count, err)
+			break
+		}
+		count++
+	}
+	store.FailPage.Store(0)
+
+	fmt.Println("\n--- Cancelled Context ---")
+	ctx, cancel := context.WithCancel(context.Background())
+	count = 0
+	for _, err := range client.Books(ctx) {
+		if err != nil {
+			fmt.Printf("Error after %d books: %v\n", count, err)
+			break
+		}
+		if count++; count == 15 {
+			cancel() // The next request fails
+		}
+	}
+	cancel()
+
+	fmt.Println("\n--- Timeout ---")
+	ctx, cancel = context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	for _, err := range client.Books(ctx) {
+		if err != nil {
+			fmt.Println("Error:", err)
+			break
+		}
+	}
+}
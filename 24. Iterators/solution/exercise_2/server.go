@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 50
+)
+
+// Book represents a book entity
+type Book struct {
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	Year   int    `json:"year"`
+}
+
+// BookPage is one page of the books list
+type BookPage struct {
+	Books    []Book `json:"books"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+	Total    int    `json:"total"`
+}
+
+// BookStore is the book store of module 11 with a paginated list
+type BookStore struct {
+	mu    sync.RWMutex
+	books []Book
+
+	Requests atomic.Int64 // Number of list requests, to show how many pages are fetched
+	FailPage atomic.Int64 // When set, this page answers 500, to show error handling
+}
+
+// NewBookStore creates a new book store with some initial data
+func NewBookStore() *BookStore {
+	titles := []struct {
+		title, author string
+		year          int
+	}{
+		{"The Go Programming Language", "Alan Donovan & Brian Kernighan", 2015},
+		{"Go in Action", "William Kennedy", 2016},
+		{"Concurrency in Go", "Katherine Cox-Buday", 2017},
+		{"Learning Go", "Jon Bodner", 2021},
+		{"100 Go Mistakes and How to Avoid Them", "Teiva Harsanyi", 2022},
+		{"Let's Go", "Alex Edwards", 2018},
+		{"The C Programming Language", "Brian Kernighan & Dennis Ritchie", 1978},
+		{"Structure and Interpretation of Computer Programs", "Harold Abelson & Gerald Jay Sussman", 1985},
+		{"The Pragmatic Programmer", "Andrew Hunt & David Thomas", 1999},
+		{"Designing Data-Intensive Applications", "Martin Kleppmann", 2017},
+		{"Clean Code", "Robert C. Martin", 2008},
+		{"Refactoring", "Martin Fowler", 1999},
+		{"Code Complete", "Steve McConnell", 1993},
+		{"The Mythical Man-Month", "Frederick P. Brooks Jr.", 1975},
+		{"Site Reliability Engineering", "Betsy Beyer et al.", 2016},
+		{"Introduction to Algorithms", "Thomas H. Cormen et al.", 1990},
+		{"The Art of Computer Programming", "Donald Knuth", 1968},
+		{"Design Patterns", "Erich Gamma et al.", 1994},
+		{"Working Effectively with Legacy Code", "Michael Feathers", 2004},
+		{"Domain-Driven Design", "Eric Evans", 2003},
+		{"Release It!", "Michael T. Nygard", 2007},
+		{"The Linux Programming Interface", "Michael Kerrisk", 2010},
+		{"Programming Pearls", "Jon Bentley", 1986},
+		{"A Philosophy of Software Design", "John Ousterhout", 2018},
+		{"Database Internals", "Alex Petrov", 2019},
+		{"Powerful Command-Line Applications in Go", "Ricardo Gerardi", 2021},
+		{"Network Programming with Go", "Adam Woodbeck", 2021},
+		{"Cloud Native Go", "Matthew A. Titmus", 2021},
+		{"Black Hat Go", "Tom Steele et al.", 2020},
+		{"Distributed Services with Go", "Travis Jeffery", 2021},
+		{"Writing An Interpreter In Go", "Thorsten Ball", 2016},
+		{"Efficient Go", "Bartłomiej Płotka", 2022},
+		{"Mastering Go", "Mihalis Tsoukalos", 2024},
+		{"Hands-On Software Architecture with Golang", "Jyotiswarup Raiturkar", 2018},
+		{"Grokking Algorithms", "Aditya Bhargava", 2016},
+		{"Computer Systems: A Programmer's Perspective", "Randal Bryant & David O'Hallaron", 2003},
+		{"Operating Systems: Three Easy Pieces", "Remzi & Andrea Arpaci-Dusseau", 2018},
+	}
+
+	store := &BookStore{}
+	for i, t := range titles {
+		store.books = append(store.books, Book{ID: i + 1, Title: t.title, Author: t.author, Year: t.year})
+	}
+	return store
+}
+
+// ServeHTTP handles GET /books?page=N&page_size=M
+func (s *BookStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet || r.URL.Path != "/books" {
+		http.NotFound(w, r)
+		return
+	}
+	s.Requests.Add(1)
+
+	page, pageSize := parsePagination(r.URL.Query().Get("page"), r.URL.Query().Get("page_size"))
+	if int64(page) == s.FailPage.Load() {
+		http.Error(w, "Database unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	s.mu.RLock()
+	result := BookPage{Books: []Book{}, Page: page, PageSize: pageSize, Total: len(s.books)}
+	if start := (page - 1) * pageSize; start < len(s.books) {
+		result.Books = append(result.Books, s.books[start:min(start+pageSize, len(s.books))]...)
+	}
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// parsePagination reads the page and page_size query values, falling back to defaults
+func parsePagination(pageValue, pageSizeValue string) (int, int) {
+	page, err := strconv.Atoi(pageValue)
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(pageSizeValue)
+	if err != nil || pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return page, pageSize
+}
@@ -0,0 +1,64 @@
+package main
+
+// The channel pipeline of module 10, exercise 2. Every stage is a goroutine
+// and every value crosses a channel.
+
+// generator creates a channel and sends numbers 1 to max on it
+func generator(max int) <-chan int {
+	out := make(chan int)
+
+	go func() {
+		for i := 1; i <= max; i++ {
+			out <- i
+		}
+		close(out)
+	}()
+
+	return out
+}
+
+// square receives numbers from a channel, squares them, and sends results to a new channel
+func square(in <-chan int) <-chan int {
+	out := make(chan int)
+
+	go func() {
+		for num := range in {
+			out <- num * num
+		}
+		close(out)
+	}()
+
+	return out
+}
+
+// filter receives numbers from a channel, filters out odd numbers, and sends results to a new channel
+func filter(in <-chan int) <-chan int {
+	out := make(chan int)
+
+	go func() {
+		for num := range in {
+			if num%2 == 0 { // Only keep even numbers
+				out <- num
+			}
+		}
+		close(out)
+	}()
+
+	return out
+}
+
+// sum adds up all numbers from the input channel and sends the total on the output channel
+func sum(in <-chan int) <-chan int {
+	out := make(chan int)
+
+	go func() {
+		total := 0
+		for num := range in {
+			total += num
+		}
+		out <- total
+		close(out)
+	}()
+
+	return out
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+func isEven(n int) bool { return n%2 == 0 }
+
+func squareOf(n int) int { return n * n }
+
+func main() {
+	fmt.Println("--- Same Pipeline, Two Ways ---")
+	// Module 10: generate 1..10, square, keep even numbers, sum
+	fmt.Println("Channels: ", <-sum(filter(square(generator(10)))))
+	fmt.Println("Iterators:", Sum(Filter(Map(Range(1, 11), squareOf), isEven)))
+
+	fmt.Println("\n--- Combinators ---")
+	words := strings.Fields("the quick brown fox jumps over the lazy dog")
+	long := Filter(slices.Values(words), func(w string) bool { return len(w) > 3 })
+	fmt.Println("Long words:   ", slices.Collect(long))
+	fmt.Println("Upper, 3 max: ", slices.Collect(Take(Map(slices.Values(words), strings.ToUpper), 3)))
+	fmt.Println("Total letters:", Reduce(slices.Values(words), 0, func(n int, w string) int { return n + len(w) }))
+	for i, w := range Enumerate(TakeWhile(slices.Values(words), func(w string) bool { return w != "fox" })) {
+		fmt.Printf("%d:%s ", i, w)
+	}
+	fmt.Println()
+
+	// Nothing runs until the loop asks for values, so infinite sources are fine
+	naturals := Range(1, int(^uint(0)>>1))
+	fmt.Println("First 5 even squares:", slices.Collect(Take(Filter(Map(naturals, squareOf), isEven), 5)))
+
+	fmt.Println("\n--- Speed ---")
+	const n = 1_000_000
+	start := time.Now()
+	channelResult := <-sum(filter(square(generator(n))))
+	channelTime := time.Since(start)
+
+	start = time.Now()
+	iteratorResult := Sum(Filter(Map(Range(1, n+1), squareOf), isEven))
+	iteratorTime := time.Since(start)
+
+	fmt.Printf("Channels:  %d in %v\n", channelResult, channelTime.Round(time.Millisecond))
+	fmt.Printf("Iterators: %d in %v\n", iteratorResult, iteratorTime.Round(time.Microsecond))
+	fmt.Printf("Iterators are %.0fx faster: no goroutine switch and no channel operation per value\n",
+		float64(channelTime)/float64(iteratorTime))
+
+	fmt.Println("\n--- Stopping Early ---")
+	before := runtime.NumGoroutine()
+	squares := filter(square(generator(n)))
+	for range 3 {
+		<-squares // Read three values and leave
+	}
+	time.Sleep(10 * time.Millisecond)
+	fmt.Printf("Channels:  %d goroutines still blocked, sending values nobody reads\n", runtime.NumGoroutine()-before)
+
+	before = runtime.NumGoroutine()
+	for range Take(Filter(Map(Range(1, n+1), squareOf), isEven), 3) {
+	}
+	fmt.Printf("Iterators: %d goroutines left, break returns from every stage\n", runtime.NumGoroutine()-before)
+}
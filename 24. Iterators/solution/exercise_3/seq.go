@@ -0,0 +1,93 @@
+package main
+
+import "iter"
+
+// Range returns the integers from start to end, end excluded
+func Range(start, end int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i := start; i < end; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+// Map returns the values of seq transformed by f
+func Map[T, U any](seq iter.Seq[T], f func(T) U) iter.Seq[U] {
+	return func(yield func(U) bool) {
+		for v := range seq {
+			if !yield(f(v)) {
+				return
+			}
+		}
+	}
+}
+
+// Filter returns the values of seq for which keep returns true
+func Filter[T any](seq iter.Seq[T], keep func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range seq {
+			if keep(v) && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Take returns the first n values of seq. It stops seq after them, so it
+// can be used on infinite sequences.
+func Take[T any](seq iter.Seq[T], n int) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if n <= 0 {
+			return
+		}
+		count := 0
+		for v := range seq {
+			if !yield(v) {
+				return
+			}
+			if count++; count == n {
+				return
+			}
+		}
+	}
+}
+
+// TakeWhile returns the values of seq until keep returns false
+func TakeWhile[T any](seq iter.Seq[T], keep func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range seq {
+			if !keep(v) || !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Reduce combines the values of seq into one, starting from initial
+func Reduce[T, A any](seq iter.Seq[T], initial A, f func(A, T) A) A {
+	acc := initial
+	for v := range seq {
+		acc = f(acc, v)
+	}
+	return acc
+}
+
+// Enumerate pairs the values of seq with their index
+func Enumerate[T any](seq iter.Seq[T]) iter.Seq2[int, T] {
+	return func(yield func(int, T) bool) {
+		i := 0
+		for v := range seq {
+			if !yield(i, v) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// Sum adds up the values of seq
+func Sum(seq iter.Seq[int]) int {
+	return Reduce(seq, 0, func(total, n int) int { return total + n })
+}
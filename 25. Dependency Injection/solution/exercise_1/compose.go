@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"golang-training/module-11/healthcheck"
+	"golang-training/module-25/exercise-1/config"
+	"golang-training/module-25/exercise-1/handler"
+	"golang-training/module-25/exercise-1/payments"
+	"golang-training/module-25/exercise-1/service"
+	"golang-training/module-25/exercise-1/store"
+)
+
+// Compose builds the whole application by hand. This is the composition
+// root, the only place that knows every concrete type: each constructor
+// receives what it needs as parameters, so the order of the calls below is
+// the dependency graph, read from top to bottom:
+//
+//	config -> logger -> database -> repositories -> gateway, services, health -> handler -> server -> app
+//
+// The returned cleanup closes what was opened, the database. It runs after
+// App.Run returned, when nothing uses the database any more.
+func Compose(cfg config.Config) (*App, func(), error) {
+	logger := NewLogger(cfg)
+
+	db, closeDB, err := store.Open(cfg, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	products := store.NewProductRepository(db)
+	orders := store.NewOrderRepository(db)
+
+	// The service asks for a payments.Gateway: here, the fake one
+	gateway := payments.NewFakeGateway(cfg, logger)
+	orderService := service.NewOrderService(products, orders, gateway, logger)
+	monitor := service.NewStockMonitor(cfg, products, logger)
+	health := NewHealth(products)
+
+	server := NewServer(cfg, handler.New(orderService, products, health, logger))
+	return NewApp(cfg, logger, monitor, health, server), closeDB, nil
+}
+
+// NewHealth creates the checks of the health endpoints. The handler serves
+// them and the App drains them at shutdown: both receive the same Health.
+func NewHealth(products *store.ProductRepository) *healthcheck.Health {
+	health := healthcheck.New(2*time.Second, 5*time.Second)
+	health.Add("database", healthcheck.Readiness, healthcheck.Ping(products.Ping))
+	return health
+}
+
+// NewLogger creates the logger every component receives. The components add
+// their name with logger.With; none of them uses slog.Default.
+func NewLogger(cfg config.Config) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: cfg.LogLevel}
+	if cfg.LogFormat == "json" {
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts))
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, opts))
+}
+
+func NewServer(cfg config.Config, h http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           h,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
+
+// App runs the components that have a lifecycle. They start in dependency
+// order and stop in the reverse order: the stock monitor uses the database
+// and the server uses the services, so the monitor starts before the server
+// accepts requests and stops after the last request finished. The database
+// is opened before both and closed after both, by the cleanup of Compose.
+type App struct {
+	logger          *slog.Logger
+	monitor         *service.StockMonitor
+	health          *healthcheck.Health
+	server          *http.Server
+	shutdownTimeout time.Duration
+
+	ready chan struct{}
+	addr  net.Addr
+}
+
+func NewApp(cfg config.Config, logger *slog.Logger, monitor *service.StockMonitor, health *healthcheck.Health, server *http.Server) *App {
+	return &App{
+		logger:          logger,
+		monitor:         monitor,
+		health:          health,
+		server:          server,
+		shutdownTimeout: cfg.ShutdownTimeout,
+		ready:           make(chan struct{}),
+	}
+}
+
+// Ready is closed when the server accepts connections
+func (a *App) Ready() <-chan struct{} {
+	return a.ready
+}
+
+// Addr returns the address the server listens on, once Ready is closed
+func (a *App) Addr() net.Addr {
+	return a.addr
+}
+
+// Run starts the components and serves until ctx is cancelled, then stops
+// them within the shutdown timeout
+func (a *App) Run(ctx context.Context) error {
+	// Listening before serving reports a busy port right away
+	listener, err := net.Listen("tcp", a.server.Addr)
+	if err != nil {
+		return fmt.Errorf("starting HTTP server: %w", err)
+	}
+
+	a.monitor.Start()
+	defer a.monitor.Stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- a.server.Serve(listener)
+	}()
+	a.addr = listener.Addr()
+	a.logger.Info("HTTP server listening", "addr", a.addr)
+	close(a.ready)
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("HTTP server: %w", err)
+	case <-ctx.Done():
+	}
+
+	a.logger.Info("shutting down", "timeout", a.shutdownTimeout)
+	// /readyz fails from now on, while the requests in progress finish
+	a.health.Drain()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+	defer cancel()
+	if err := a.server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("stopping HTTP server: %w", err)
+	}
+	if err := <-serverErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	a.logger.Info("HTTP server stopped")
+	return nil
+}
@@ -0,0 +1,88 @@
+// Package config holds the settings of the shop. It is the first link of
+// the dependency chain: every other component receives the values it needs
+// from a Config, instead of reading the environment itself.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// Config holds the application configuration
+type Config struct {
+	Addr            string        // Address of the HTTP server
+	DatabaseDSN     string        // SQLite database, in memory by default
+	LogLevel        slog.Level    // Lowest level logged
+	LogFormat       string        // "text" or "json"
+	PaymentLimit    int64         // The fake gateway declines charges above it, in cents
+	LowStock        int           // Stock level reported by the stock monitor
+	MonitorInterval time.Duration // Time between two stock checks
+	ShutdownTimeout time.Duration // Time given to the requests in progress on shutdown
+}
+
+// Default returns the configuration used when nothing is set
+func Default() Config {
+	return Config{
+		Addr:            "localhost:8080",
+		DatabaseDSN:     "file::memory:?cache=shared",
+		LogLevel:        slog.LevelInfo,
+		LogFormat:       "text",
+		PaymentLimit:    200000,
+		LowStock:        5,
+		MonitorInterval: 30 * time.Second,
+		ShutdownTimeout: 10 * time.Second,
+	}
+}
+
+// Load reads the SHOP_* environment variables over the defaults. The lookup
+// function is os.Getenv in main; tests pass a map lookup instead, so they
+// don't depend on the environment of the process.
+func Load(getenv func(string) string) (Config, error) {
+	cfg := Default()
+	var errs []error
+
+	if v := getenv("SHOP_ADDR"); v != "" {
+		cfg.Addr = v
+	}
+	if v := getenv("SHOP_DATABASE_DSN"); v != "" {
+		cfg.DatabaseDSN = v
+	}
+	if v := getenv("SHOP_LOG_LEVEL"); v != "" {
+		if err := cfg.LogLevel.UnmarshalText([]byte(v)); err != nil {
+			errs = append(errs, fmt.Errorf("SHOP_LOG_LEVEL: %w", err))
+		}
+	}
+	if v := getenv("SHOP_LOG_FORMAT"); v != "" {
+		if v != "text" && v != "json" {
+			errs = append(errs, fmt.Errorf("SHOP_LOG_FORMAT must be text or json, got %q", v))
+		}
+		cfg.LogFormat = v
+	}
+	if v := getenv("SHOP_PAYMENT_LIMIT"); v != "" {
+		limit, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || limit < 0 {
+			errs = append(errs, fmt.Errorf("SHOP_PAYMENT_LIMIT must be a number of cents, got %q", v))
+		}
+		cfg.PaymentLimit = limit
+	}
+	for name, field := range map[string]*time.Duration{
+		"SHOP_MONITOR_INTERVAL": &cfg.MonitorInterval,
+		"SHOP_SHUTDOWN_TIMEOUT": &cfg.ShutdownTimeout,
+	} {
+		if v := getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				errs = append(errs, fmt.Errorf("%s must be a positive duration like 10s, got %q", name, v))
+			}
+			*field = d
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return Config{}, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,19 @@
+module golang-training/module-25/exercise-1
+
+go 1.25
+
+require (
+	github.com/google/wire v0.7.0
+	golang-training/module-11/healthcheck v0.0.0
+	gorm.io/driver/sqlite v1.6.0
+	gorm.io/gorm v1.31.1
+)
+
+require (
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	github.com/mattn/go-sqlite3 v1.14.22 // indirect
+	golang.org/x/text v0.20.0 // indirect
+)
+
+replace golang-training/module-11/healthcheck => "../../../11. Http Server/solution/healthcheck"
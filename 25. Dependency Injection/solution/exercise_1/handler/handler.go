@@ -0,0 +1,120 @@
+// Package handler exposes the shop over HTTP
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang-training/module-11/healthcheck"
+	"golang-training/module-25/exercise-1/payments"
+	"golang-training/module-25/exercise-1/service"
+	"golang-training/module-25/exercise-1/store"
+)
+
+// Handler serves the shop API
+type Handler struct {
+	orders   *service.OrderService
+	products *store.ProductRepository
+	logger   *slog.Logger
+}
+
+// New creates the handler with its routes and the logging middleware
+func New(orders *service.OrderService, products *store.ProductRepository, health *healthcheck.Health, logger *slog.Logger) http.Handler {
+	h := &Handler{orders: orders, products: products, logger: logger.With("component", "http")}
+
+	mux := http.NewServeMux()
+	health.Register(mux)
+	mux.HandleFunc("GET /products", h.listProducts)
+	mux.HandleFunc("POST /orders", h.placeOrder)
+	mux.HandleFunc("GET /orders/{id}", h.getOrder)
+	return h.logRequests(mux)
+}
+
+func (h *Handler) listProducts(w http.ResponseWriter, r *http.Request) {
+	products, err := h.products.All(r.Context())
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, products)
+}
+
+func (h *Handler) placeOrder(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Customer string         `json:"customer"`
+		Lines    []service.Line `json:"lines"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON: " + err.Error()})
+		return
+	}
+	order, err := h.orders.Place(r.Context(), req.Customer, req.Lines)
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, order)
+}
+
+func (h *Handler) getOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid order ID"})
+		return
+	}
+	order, err := h.orders.Get(r.Context(), uint(id))
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, order)
+}
+
+// fail maps the errors of the services to HTTP statuses
+func (h *Handler) fail(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrInvalidOrder):
+		status = http.StatusBadRequest
+	case errors.Is(err, store.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, store.ErrOutOfStock):
+		status = http.StatusConflict
+	case errors.Is(err, payments.ErrDeclined):
+		status = http.StatusPaymentRequired
+	default:
+		h.logger.Error("request failed", "error", err)
+		err = errors.New("internal error")
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// statusRecorder remembers the status written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (h *Handler) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		h.logger.Info("request", "method", r.Method, "path", r.URL.Path, "status", rec.status, "duration", time.Since(start))
+	})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"golang-training/module-25/exercise-1/config"
+)
+
+func main() {
+	useWire := flag.Bool("wire", false, "build the application with the code generated by wire instead of Compose")
+	demo := flag.Bool("demo", false, "send a few requests to the server, then stop it")
+	flag.Parse()
+
+	cfg, err := config.Load(os.Getenv)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	if *demo {
+		// Any free port, so the demo doesn't conflict with a running server
+		cfg.Addr = "localhost:0"
+	}
+
+	// Both functions build the same graph: one written by hand, one generated
+	compose := Compose
+	if *useWire {
+		compose = InitializeApp
+	}
+	app, cleanup, err := compose(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	defer cleanup()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *demo {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		go func() {
+			defer cancel()
+			<-app.Ready()
+			runDemo("http://" + app.Addr().String())
+		}()
+	}
+
+	if err := app.Run(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		cleanup()
+		os.Exit(1)
+	}
+}
+
+// runDemo places a few orders: one paid, one out of stock, one declined by
+// the payment limit
+func runDemo(baseURL string) {
+	requests := []struct{ method, path, body string }{
+		{"GET", "/healthz", ""},
+		{"GET", "/products", ""},
+		{"POST", "/orders", `{"customer": "alice", "lines": [{"product_id": "P002", "quantity": 1}, {"product_id": "P003", "quantity": 2}]}`},
+		{"GET", "/orders/1", ""},
+		{"POST", "/orders", `{"customer": "bob", "lines": [{"product_id": "P004", "quantity": 5}]}`},
+		{"POST", "/orders", `{"customer": "carol", "lines": [{"product_id": "P001", "quantity": 2}]}`},
+		{"POST", "/orders", `{"customer": "dave", "lines": [{"product_id": "P999", "quantity": 1}]}`},
+		{"GET", "/products", ""},
+	}
+	for _, r := range requests {
+		req, err := http.NewRequest(r.method, baseURL+r.path, strings.NewReader(r.body))
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Printf("%s %s -> %s\n%s\n", r.method, r.path, resp.Status, strings.TrimSpace(string(body)))
+	}
+}
@@ -0,0 +1,46 @@
+// Package payments takes the payments of the orders
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+
+	"golang-training/module-25/exercise-1/config"
+)
+
+// ErrDeclined is returned when the payment provider refuses a charge
+var ErrDeclined = errors.New("payment declined")
+
+// Gateway is what the order service needs from a payment provider. The
+// service depends on this interface and the composition root chooses the
+// implementation: FakeGateway here, a real provider in production.
+type Gateway interface {
+	Charge(ctx context.Context, orderID uint, amount int64) (chargeID string, err error)
+}
+
+// FakeGateway accepts every charge up to a limit
+type FakeGateway struct {
+	limit  int64
+	logger *slog.Logger
+	next   atomic.Int64
+}
+
+func NewFakeGateway(cfg config.Config, logger *slog.Logger) *FakeGateway {
+	return &FakeGateway{limit: cfg.PaymentLimit, logger: logger.With("component", "payments")}
+}
+
+func (g *FakeGateway) Charge(ctx context.Context, orderID uint, amount int64) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if amount > g.limit {
+		g.logger.Warn("charge declined", "order", orderID, "amount", amount, "limit", g.limit)
+		return "", fmt.Errorf("%w: %d cents is above the limit", ErrDeclined, amount)
+	}
+	chargeID := fmt.Sprintf("ch_%04d", g.next.Add(1))
+	g.logger.Info("charged", "order", orderID, "amount", amount, "charge", chargeID)
+	return chargeID, nil
+}
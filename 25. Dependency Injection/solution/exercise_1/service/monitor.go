@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"golang-training/module-25/exercise-1/config"
+	"golang-training/module-25/exercise-1/store"
+)
+
+// StockMonitor checks the stock at a regular interval and logs the products
+// running low. It is a background component: it has to be started after the
+// database is open and stopped before it is closed.
+type StockMonitor struct {
+	products  *store.ProductRepository
+	logger    *slog.Logger
+	interval  time.Duration
+	threshold int
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewStockMonitor(cfg config.Config, products *store.ProductRepository, logger *slog.Logger) *StockMonitor {
+	return &StockMonitor{
+		products:  products,
+		logger:    logger.With("component", "stock-monitor"),
+		interval:  cfg.MonitorInterval,
+		threshold: cfg.LowStock,
+	}
+}
+
+// Start checks the stock once, then at every interval until Stop
+func (m *StockMonitor) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.done = make(chan struct{})
+
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			m.check(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	m.logger.Info("started", "interval", m.interval, "threshold", m.threshold)
+}
+
+func (m *StockMonitor) check(ctx context.Context) {
+	products, err := m.products.LowStock(ctx, m.threshold)
+	if err != nil {
+		if ctx.Err() == nil {
+			m.logger.Error("checking the stock", "error", err)
+		}
+		return
+	}
+	for _, p := range products {
+		m.logger.Warn("low stock", "product", p.ID, "name", p.Name, "stock", p.Stock)
+	}
+}
+
+// Stop stops the checks and waits for the one in progress
+func (m *StockMonitor) Stop() {
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	<-m.done
+	m.logger.Info("stopped")
+}
@@ -0,0 +1,92 @@
+// Package service holds the business logic of the shop. The services get
+// their repositories, payment gateway and logger from their constructors.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"golang-training/module-25/exercise-1/payments"
+	"golang-training/module-25/exercise-1/store"
+)
+
+// ErrInvalidOrder is returned for an order that can't be placed as written
+var ErrInvalidOrder = errors.New("invalid order")
+
+// Line is a product and a quantity asked by a customer
+type Line struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// OrderService places orders: it reserves the stock, saves the order and
+// takes the payment
+type OrderService struct {
+	products *store.ProductRepository
+	orders   *store.OrderRepository
+	gateway  payments.Gateway
+	logger   *slog.Logger
+}
+
+func NewOrderService(products *store.ProductRepository, orders *store.OrderRepository, gateway payments.Gateway, logger *slog.Logger) *OrderService {
+	return &OrderService{
+		products: products,
+		orders:   orders,
+		gateway:  gateway,
+		logger:   logger.With("component", "orders"),
+	}
+}
+
+// Place creates a paid order. When the payment fails, the stock is put back
+// and the order is kept with the payment_failed status.
+func (s *OrderService) Place(ctx context.Context, customer string, lines []Line) (*store.Order, error) {
+	if customer == "" || len(lines) == 0 {
+		return nil, fmt.Errorf("%w: a customer and at least one line are required", ErrInvalidOrder)
+	}
+	ids := make([]string, len(lines))
+	for i, line := range lines {
+		if line.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: quantity of %s must be positive", ErrInvalidOrder, line.ProductID)
+		}
+		ids[i] = line.ProductID
+	}
+	products, err := s.products.Find(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	order := &store.Order{Customer: customer, Status: store.StatusPending}
+	for _, line := range lines {
+		price := products[line.ProductID].Price
+		order.Items = append(order.Items, store.OrderItem{ProductID: line.ProductID, Quantity: line.Quantity, Price: price})
+		order.Total += price * int64(line.Quantity)
+	}
+
+	if err := s.products.Reserve(ctx, order.Items); err != nil {
+		return nil, err
+	}
+	if err := s.orders.Create(ctx, order); err != nil {
+		return nil, errors.Join(err, s.products.Release(ctx, order.Items))
+	}
+
+	chargeID, err := s.gateway.Charge(ctx, order.ID, order.Total)
+	if err != nil {
+		s.logger.Warn("payment failed, releasing the stock", "order", order.ID, "error", err)
+		err = fmt.Errorf("order %d: %w", order.ID, err)
+		return order, errors.Join(err,
+			s.products.Release(ctx, order.Items),
+			s.orders.SetStatus(ctx, order, store.StatusPaymentFailed, ""))
+	}
+	if err := s.orders.SetStatus(ctx, order, store.StatusPaid, chargeID); err != nil {
+		return nil, err
+	}
+	s.logger.Info("order placed", "order", order.ID, "customer", customer, "total", order.Total)
+	return order, nil
+}
+
+// Get returns an order with its items
+func (s *OrderService) Get(ctx context.Context, id uint) (*store.Order, error) {
+	return s.orders.Find(ctx, id)
+}
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// OrderRepository saves the orders and their items
+type OrderRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderRepository(db *gorm.DB) *OrderRepository {
+	return &OrderRepository{db: db}
+}
+
+// Create saves a new order with its items
+func (r *OrderRepository) Create(ctx context.Context, order *Order) error {
+	return r.db.WithContext(ctx).Create(order).Error
+}
+
+// Find returns an order with its items
+func (r *OrderRepository) Find(ctx context.Context, id uint) (*Order, error) {
+	var order Order
+	err := r.db.WithContext(ctx).Preload("Items").First(&order, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("order %d: %w", id, ErrNotFound)
+	}
+	return &order, err
+}
+
+// SetStatus changes the status and the charge of an order
+func (r *OrderRepository) SetStatus(ctx context.Context, order *Order, status, chargeID string) error {
+	err := r.db.WithContext(ctx).Model(order).Updates(map[string]any{"status": status, "charge_id": chargeID}).Error
+	if err != nil {
+		return fmt.Errorf("order %d: %w", order.ID, err)
+	}
+	return nil
+}
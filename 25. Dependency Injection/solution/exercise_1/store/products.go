@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ProductRepository reads the catalog and keeps the stock
+type ProductRepository struct {
+	db *gorm.DB
+}
+
+func NewProductRepository(db *gorm.DB) *ProductRepository {
+	return &ProductRepository{db: db}
+}
+
+// All returns the products ordered by ID
+func (r *ProductRepository) All(ctx context.Context) ([]Product, error) {
+	var products []Product
+	err := r.db.WithContext(ctx).Order("id").Find(&products).Error
+	return products, err
+}
+
+// Find returns the products with the IDs, by ID. A missing product is an
+// ErrNotFound error.
+func (r *ProductRepository) Find(ctx context.Context, ids []string) (map[string]Product, error) {
+	var products []Product
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+	for _, id := range ids {
+		if _, ok := byID[id]; !ok {
+			return nil, fmt.Errorf("product %s: %w", id, ErrNotFound)
+		}
+	}
+	return byID, nil
+}
+
+// LowStock returns the products with at most threshold items left
+func (r *ProductRepository) LowStock(ctx context.Context, threshold int) ([]Product, error) {
+	var products []Product
+	err := r.db.WithContext(ctx).Where("stock <= ?", threshold).Order("stock, id").Find(&products).Error
+	return products, err
+}
+
+// Reserve takes the items out of the stock, all of them or none. Each update
+// checks the stock in its WHERE clause, so two orders can't both take the
+// last item.
+func (r *ProductRepository) Reserve(ctx context.Context, items []OrderItem) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, item := range items {
+			result := tx.Model(&Product{}).
+				Where("id = ? AND stock >= ?", item.ProductID, item.Quantity).
+				Update("stock", gorm.Expr("stock - ?", item.Quantity))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("product %s: %w", item.ProductID, ErrOutOfStock)
+			}
+		}
+		return nil
+	})
+}
+
+// Release puts reserved items back in stock
+func (r *ProductRepository) Release(ctx context.Context, items []OrderItem) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, item := range items {
+			err := tx.Model(&Product{}).
+				Where("id = ?", item.ProductID).
+				Update("stock", gorm.Expr("stock + ?", item.Quantity)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Ping checks that the database answers, for the health check
+func (r *ProductRepository) Ping(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
@@ -0,0 +1,107 @@
+// Package store persists products and orders with GORM. The repositories
+// receive the database connection from their constructor; there is no
+// package-level connection, unlike the stock map of module 9's inventory
+// package, so two stores can exist side by side in tests.
+package store
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"golang-training/module-25/exercise-1/config"
+)
+
+// Errors returned by the repositories
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrOutOfStock = errors.New("out of stock")
+)
+
+// Product is an item for sale. Prices are in cents.
+type Product struct {
+	ID    string `json:"id" gorm:"primaryKey"`
+	Name  string `json:"name" gorm:"not null"`
+	Price int64  `json:"price" gorm:"not null"`
+	Stock int    `json:"stock" gorm:"not null"`
+}
+
+// Order statuses
+const (
+	StatusPending       = "pending"
+	StatusPaid          = "paid"
+	StatusPaymentFailed = "payment_failed"
+)
+
+// Order is a purchase of a customer
+type Order struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	Customer  string      `json:"customer" gorm:"not null"`
+	Items     []OrderItem `json:"items"`
+	Total     int64       `json:"total"`
+	Status    string      `json:"status" gorm:"not null;index"`
+	ChargeID  string      `json:"charge_id,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// OrderItem is a line of an order, with the price at the time of the order
+type OrderItem struct {
+	ID        uint   `json:"-" gorm:"primaryKey"`
+	OrderID   uint   `json:"-" gorm:"index"`
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	Price     int64  `json:"price"`
+}
+
+// Open connects to the database, creates the tables and adds the sample
+// products to an empty catalog. The returned function closes the
+// connection: the caller runs it after everything using the database has
+// stopped.
+func Open(cfg config.Config, log *slog.Logger) (*gorm.DB, func(), error) {
+	db, err := gorm.Open(sqlite.Open(cfg.DatabaseDSN), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening database: %w", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening database: %w", err)
+	}
+	// SQLite allows one writer at a time
+	sqlDB.SetMaxOpenConns(1)
+	closeDB := func() {
+		if err := sqlDB.Close(); err != nil {
+			log.Error("closing database", "error", err)
+			return
+		}
+		log.Info("database closed")
+	}
+
+	if err := db.AutoMigrate(&Product{}, &Order{}, &OrderItem{}); err != nil {
+		closeDB()
+		return nil, nil, fmt.Errorf("migrating database: %w", err)
+	}
+	if err := seed(db); err != nil {
+		closeDB()
+		return nil, nil, fmt.Errorf("adding sample products: %w", err)
+	}
+	log.Info("database ready", "dsn", cfg.DatabaseDSN)
+	return db, closeDB, nil
+}
+
+func seed(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&Product{}).Count(&count).Error; err != nil || count > 0 {
+		return err
+	}
+	return db.Create([]Product{
+		{ID: "P001", Name: "Laptop Pro", Price: 149900, Stock: 10},
+		{ID: "P002", Name: "Mechanical Keyboard", Price: 12900, Stock: 25},
+		{ID: "P003", Name: "Wireless Mouse", Price: 3900, Stock: 6},
+		{ID: "P004", Name: "USB-C Hub", Price: 4900, Stock: 3},
+	}).Error
+}
@@ -0,0 +1,37 @@
+//go:build wireinject
+
+package main
+
+import (
+	"github.com/google/wire"
+
+	"golang-training/module-25/exercise-1/config"
+	"golang-training/module-25/exercise-1/handler"
+	"golang-training/module-25/exercise-1/payments"
+	"golang-training/module-25/exercise-1/service"
+	"golang-training/module-25/exercise-1/store"
+)
+
+// InitializeApp builds the same application as Compose. Its body is
+// generated by wire from the providers listed in wire.go, the constructors
+// used in Compose: wire orders the calls by their parameters and results,
+// and fails at generation time when a provider is missing or unused.
+// After changing a constructor, regenerate wire_gen.go with go generate.
+func InitializeApp(cfg config.Config) (*App, func(), error) {
+	wire.Build(
+		NewLogger,
+		store.Open,
+		store.NewProductRepository,
+		store.NewOrderRepository,
+		payments.NewFakeGateway,
+		// The order service asks for the interface, the fake gateway provides it
+		wire.Bind(new(payments.Gateway), new(*payments.FakeGateway)),
+		service.NewOrderService,
+		service.NewStockMonitor,
+		NewHealth,
+		handler.New,
+		NewServer,
+		NewApp,
+	)
+	return nil, nil, nil
+}
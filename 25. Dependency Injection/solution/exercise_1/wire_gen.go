@@ -0,0 +1,42 @@
+// Code generated by Wire. DO NOT EDIT.
+
+//go:generate go run -mod=mod github.com/google/wire/cmd/wire
+//go:build !wireinject
+// +build !wireinject
+
+package main
+
+import (
+	"golang-training/module-25/exercise-1/config"
+	"golang-training/module-25/exercise-1/handler"
+	"golang-training/module-25/exercise-1/payments"
+	"golang-training/module-25/exercise-1/service"
+	"golang-training/module-25/exercise-1/store"
+)
+
+// Injectors from wire.go:
+
+// InitializeApp builds the same application as Compose. Its body is
+// generated by wire from the providers listed in wire.go, the constructors
+// used in Compose: wire orders the calls by their parameters and results,
+// and fails at generation time when a provider is missing or unused.
+// After changing a constructor, regenerate wire_gen.go with go generate.
+func InitializeApp(cfg config.Config) (*App, func(), error) {
+	logger := NewLogger(cfg)
+	db, cleanup, err := store.Open(cfg, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	productRepository := store.NewProductRepository(db)
+	stockMonitor := service.NewStockMonitor(cfg, productRepository, logger)
+	health := NewHealth(productRepository)
+	orderRepository := store.NewOrderRepository(db)
+	fakeGateway := payments.NewFakeGateway(cfg, logger)
+	orderService := service.NewOrderService(productRepository, orderRepository, fakeGateway, logger)
+	httpHandler := handler.New(orderService, productRepository, health, logger)
+	server := NewServer(cfg, httpHandler)
+	app := NewApp(cfg, logger, stockMonitor, health, server)
+	return app, func() {
+		cleanup()
+	}, nil
+}
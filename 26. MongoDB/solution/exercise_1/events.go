@@ -0,0 +1,57 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// The event bus of module 8: handlers subscribe to an event type, or to
+// every event with "*"
+
+type Event interface {
+	Type() string
+	Data() any
+	Timestamp() time.Time
+}
+
+type BaseEvent struct {
+	EventType string
+	EventData any
+	EventTime time.Time
+}
+
+func (e BaseEvent) Type() string         { return e.EventType }
+func (e BaseEvent) Data() any            { return e.EventData }
+func (e BaseEvent) Timestamp() time.Time { return e.EventTime }
+
+type EventHandlerFunc func(Event)
+
+// EventBus manages event subscriptions and publishing
+type EventBus struct {
+	handlers map[string][]EventHandlerFunc
+	mu       sync.RWMutex
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{handlers: make(map[string][]EventHandlerFunc)}
+}
+
+// SubscribeFunc registers a handler for an event type, or "*" for all
+func (b *EventBus) SubscribeFunc(eventType string, handler func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Publish sends an event to the handlers of its type, then to the handlers
+// of every event
+func (b *EventBus) Publish(event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, handler := range b.handlers[event.Type()] {
+		handler(event)
+	}
+	for _, handler := range b.handlers["*"] {
+		handler(event)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// defaultURI connects to the single member replica set started by:
+//
+//	docker run -d --name mongo -p 27017:27017 mongo:8 --replSet rs0
+//	docker exec mongo mongosh --quiet --eval "rs.initiate()"
+//
+// directConnection skips the discovery of the other members, which would
+// use the host name of the container.
+const defaultURI = "mongodb://localhost:27017/?replicaSet=rs0&directConnection=true"
+
+func ptr[T any](v T) *T { return &v }
+
+func printTodos(title string, todos []Todo) {
+	fmt.Println(title)
+	for _, t := range todos {
+		status := " "
+		if t.Completed {
+			status = "x"
+		}
+		due := ""
+		if t.DueDate != nil {
+			due = " due " + t.DueDate.Format("2006-01-02")
+		}
+		fmt.Printf("  [%s] P%d %-28s %-22s%s\n", status, t.Priority, t.Title, strings.Join(t.Tags, ","), due)
+	}
+}
+
+func main() {
+	uri := flag.String("uri", cmp.Or(os.Getenv("MONGODB_URI"), defaultURI), "MongoDB connection string")
+	dbName := flag.String("db", "golang_training", "database name")
+	flag.Parse()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Connect doesn't reach the server: Ping checks it is there
+	client, err := mongo.Connect(options.Client().ApplyURI(*uri).SetServerSelectionTimeout(3 * time.Second))
+	if err != nil {
+		log.Fatalf("Invalid connection string: %v", err)
+	}
+	defer client.Disconnect(context.Background())
+	if err := client.Ping(ctx, nil); err != nil {
+		log.Fatalf("MongoDB is not reachable at %s: %v\nStart it as described in the README of this module.", *uri, err)
+	}
+
+	store := NewTodoStore(client.Database(*dbName))
+	// Start from an empty collection at each run
+	if err := store.coll.Drop(ctx); err != nil {
+		log.Fatalf("Failed to drop todos: %v", err)
+	}
+	indexes, err := store.EnsureIndexes(ctx)
+	if err != nil {
+		log.Fatalf("Failed to create indexes: %v", err)
+	}
+	fmt.Println("Indexes:", strings.Join(indexes, ", "))
+
+	// The change stream publishes on the bus from its own goroutine: the
+	// handlers collect the lines, printed after the operations
+	bus := NewEventBus()
+	received := make(chan string, 100)
+	bus.SubscribeFunc("*", func(e Event) {
+		change := e.Data().(TodoChange)
+		line := fmt.Sprintf("%-15s %s", e.Type(), change.ID.Hex())
+		if change.Todo != nil {
+			line += " " + change.Todo.Title
+		}
+		if len(change.UpdatedFields) > 0 {
+			line += fmt.Sprintf(" %v", change.UpdatedFields)
+		}
+		received <- line
+	})
+	bus.SubscribeFunc(EventTodoCompleted, func(e Event) {
+		received <- "  well done: " + e.Data().(TodoChange).Todo.Title
+	})
+
+	watcher := NewWatcher(store, bus)
+	watching := true
+	if err := watcher.Open(ctx); err != nil {
+		// A standalone server: the rest works, without events
+		fmt.Printf("No change stream, the server must be a replica set member: %v\n", err)
+		watching = false
+	} else {
+		go func() {
+			if err := watcher.Run(ctx); err != nil {
+				log.Printf("Watcher stopped: %v", err)
+			}
+		}()
+	}
+
+	fmt.Println("\n--- CRUD ---")
+	now := time.Now().UTC()
+	todos := []*Todo{
+		{Title: "Write the quarterly report", Priority: 3, Tags: []string{"work", "writing"}, DueDate: ptr(now.AddDate(0, 0, -2))},
+		{Title: "Review pull requests", Priority: 2, Tags: []string{"work", "code"}},
+		{Title: "Book flights", Priority: 2, Tags: []string{"travel"}, DueDate: ptr(now.AddDate(0, 0, 10))},
+		{Title: "Read the Go memory model", Priority: 1, Tags: []string{"code", "reading"}},
+		{Title: "Renew passport", Priority: 3, Tags: []string{"travel", "admin"}, DueDate: ptr(now.AddDate(0, 0, -1))},
+	}
+	for _, todo := range todos {
+		if err := store.Create(ctx, todo); err != nil {
+			log.Fatal(err)
+		}
+	}
+	fmt.Printf("Created %d todos, the first has the ID %s\n", len(todos), todos[0].ID.Hex())
+
+	if _, err := store.Update(ctx, todos[1].ID.Hex(), TodoUpdate{Completed: ptr(true)}); err != nil {
+		log.Fatal(err)
+	}
+	updated, err := store.Update(ctx, todos[3].ID.Hex(), TodoUpdate{Priority: ptr(3), Tags: []string{"code", "reading", "go"}})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Updated: %s, priority %d, tags %v\n", updated.Title, updated.Priority, updated.Tags)
+	if err := store.Delete(ctx, todos[2].ID.Hex()); err != nil {
+		log.Fatal(err)
+	}
+	err = store.Delete(ctx, todos[2].ID.Hex())
+	fmt.Println("Deleting again:", err, errors.Is(err, ErrNotFound))
+	_, err = store.Get(ctx, "not-an-id")
+	fmt.Println("Get with a bad ID:", err, errors.Is(err, ErrInvalidID))
+
+	all, err := store.List(ctx, ListFilter{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	printTodos("\nAll todos, by priority:", all)
+	openCode, err := store.List(ctx, ListFilter{Completed: ptr(false), Tag: "code"})
+	if err != nil {
+		log.Fatal(err)
+	}
+	printTodos("Open todos tagged code:", openCode)
+	found, err := store.Search(ctx, "report passport")
+	if err != nil {
+		log.Fatal(err)
+	}
+	printTodos(`Search "report passport":`, found)
+
+	fmt.Println("\n--- Aggregation ---")
+	stats, err := store.Stats(ctx, time.Now().UTC())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%d todos, %d completed, %d overdue\n", stats.Total, stats.Completed, stats.Overdue)
+	for _, p := range stats.ByPriority {
+		fmt.Printf("  priority %d: %d open\n", p.Priority, p.Open)
+	}
+	for _, t := range stats.TopTags {
+		fmt.Printf("  #%-8s %d todos, %d open\n", t.Tag, t.Total, t.Open)
+	}
+
+	if !watching {
+		return
+	}
+	fmt.Println("\n--- Change Stream Events ---")
+	// 5 inserts, 2 updates, 1 delete, and the line of the completed todo
+	const expected = 9
+	timeout := time.After(5 * time.Second)
+	for range expected {
+		select {
+		case line := <-received:
+			fmt.Println(line)
+		case <-timeout:
+			fmt.Println("Timed out waiting for the events")
+			return
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// TagCount is the number of todos with a tag
+type TagCount struct {
+	Tag   string `bson:"_id"`
+	Total int    `bson:"total"`
+	Open  int    `bson:"open"`
+}
+
+// PriorityCount is the number of open todos of a priority
+type PriorityCount struct {
+	Priority int `bson:"_id"`
+	Open     int `bson:"open"`
+}
+
+// Stats summarizes the todos
+type Stats struct {
+	Total      int
+	Completed  int
+	Overdue    int
+	ByPriority []PriorityCount
+	TopTags    []TagCount
+}
+
+// Stats computes the statistics in the database with one aggregation
+// pipeline. $facet runs several pipelines on the same documents, so the
+// collection is read once for the three results.
+func (s *TodoStore) Stats(ctx context.Context, now time.Time) (Stats, error) {
+	// 1 when the condition holds, 0 otherwise, to count with $sum
+	countIf := func(cond any) bson.D {
+		return bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{cond, 1, 0}}}}}
+	}
+	open := bson.D{{Key: "$eq", Value: bson.A{"$completed", false}}}
+	// A missing due date compares lower than any date: $ifNull replaces it
+	// with now, which is not overdue
+	overdue := bson.D{{Key: "$and", Value: bson.A{
+		open,
+		bson.D{{Key: "$lt", Value: bson.A{bson.D{{Key: "$ifNull", Value: bson.A{"$due_date", now}}}, now}}},
+	}}}
+
+	pipeline := bson.A{
+		bson.D{{Key: "$facet", Value: bson.D{
+			{Key: "status", Value: bson.A{
+				bson.D{{Key: "$group", Value: bson.D{
+					{Key: "_id", Value: nil}, // A single group: the whole collection
+					{Key: "total", Value: bson.D{{Key: "$sum", Value: 1}}},
+					{Key: "completed", Value: countIf("$completed")},
+					{Key: "overdue", Value: countIf(overdue)},
+				}}},
+			}},
+			{Key: "priorities", Value: bson.A{
+				bson.D{{Key: "$match", Value: bson.D{{Key: "completed", Value: false}}}},
+				bson.D{{Key: "$group", Value: bson.D{
+					{Key: "_id", Value: "$priority"},
+					{Key: "open", Value: bson.D{{Key: "$sum", Value: 1}}},
+				}}},
+				bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: -1}}}},
+			}},
+			{Key: "tags", Value: bson.A{
+				// One document per element of tags, then one group per tag
+				bson.D{{Key: "$unwind", Value: "$tags"}},
+				bson.D{{Key: "$group", Value: bson.D{
+					{Key: "_id", Value: "$tags"},
+					{Key: "total", Value: bson.D{{Key: "$sum", Value: 1}}},
+					{Key: "open", Value: countIf(open)},
+				}}},
+				bson.D{{Key: "$sort", Value: bson.D{{Key: "total", Value: -1}, {Key: "_id", Value: 1}}}},
+				bson.D{{Key: "$limit", Value: 5}},
+			}},
+		}}},
+	}
+
+	cursor, err := s.coll.Aggregate(ctx, pipeline)
+	if err != nil {
+		return Stats{}, err
+	}
+	// $facet returns a single document holding the result of each pipeline
+	var results []struct {
+		Status []struct {
+			Total     int `bson:"total"`
+			Completed int `bson:"completed"`
+			Overdue   int `bson:"overdue"`
+		} `bson:"status"`
+		Priorities []PriorityCount `bson:"priorities"`
+		Tags       []TagCount      `bson:"tags"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return Stats{}, err
+	}
+
+	var stats Stats
+	if len(results) == 0 {
+		return stats, nil
+	}
+	result := results[0]
+	if len(result.Status) > 0 { // An empty collection has no group
+		stats.Total, stats.Completed, stats.Overdue = result.Status[0].Total, result.Status[0].Completed, result.Status[0].Overdue
+	}
+	stats.ByPriority, stats.TopTags = result.Priorities, result.Tags
+	return stats, nil
+}
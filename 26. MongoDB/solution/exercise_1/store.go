@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Todo is stored as a document of the todos collection. The bson tags name
+// the fields of the document, as the json tags do for the API.
+type Todo struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id"` // omitempty: MongoDB creates it on insert
+	Title     string        `bson:"title" json:"title"`
+	Completed bool          `bson:"completed" json:"completed"`
+	Priority  int           `bson:"priority" json:"priority"` // 1 (low) to 3 (high)
+	Tags      []string      `bson:"tags,omitempty" json:"tags,omitempty"`
+	DueDate   *time.Time    `bson:"due_date,omitempty" json:"due_date,omitempty"`
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+var (
+	ErrNotFound  = errors.New("todo not found")
+	ErrInvalidID = errors.New("invalid todo ID")
+)
+
+// TodoStore persists todos in a MongoDB collection
+type TodoStore struct {
+	coll *mongo.Collection
+}
+
+func NewTodoStore(db *mongo.Database) *TodoStore {
+	return &TodoStore{coll: db.Collection("todos")}
+}
+
+// EnsureIndexes creates the indexes of the queries below. Creating an index
+// that exists with the same keys and options does nothing, so it runs at
+// every startup.
+func (s *TodoStore) EnsureIndexes(ctx context.Context) ([]string, error) {
+	return s.coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// The filter and the sort of List
+			Keys:    bson.D{{Key: "completed", Value: 1}, {Key: "priority", Value: -1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("completed_priority_created"),
+		},
+		{
+			// An index on an array field indexes each element: a multikey index
+			Keys:    bson.D{{Key: "tags", Value: 1}},
+			Options: options.Index().SetName("tags"),
+		},
+		{
+			// Needed by $text in Search
+			Keys:    bson.D{{Key: "title", Value: "text"}},
+			Options: options.Index().SetName("title_text"),
+		},
+	})
+}
+
+// parseID converts the hexadecimal form of an ObjectID used in URLs
+func parseID(id string) (bson.ObjectID, error) {
+	oid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return oid, fmt.Errorf("%w: %q", ErrInvalidID, id)
+	}
+	return oid, nil
+}
+
+// Create inserts the todo and sets its ID
+func (s *TodoStore) Create(ctx context.Context, todo *Todo) error {
+	now := time.Now().UTC().Truncate(time.Millisecond) // BSON dates have millisecond precision
+	todo.CreatedAt, todo.UpdatedAt = now, now
+	result, err := s.coll.InsertOne(ctx, todo)
+	if err != nil {
+		return fmt.Errorf("inserting todo: %w", err)
+	}
+	todo.ID = result.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (s *TodoStore) Get(ctx context.Context, id string) (*Todo, error) {
+	oid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	var todo Todo
+	err = s.coll.FindOne(ctx, bson.D{{Key: "_id", Value: oid}}).Decode(&todo)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	return &todo, err
+}
+
+// ListFilter selects todos. Nil and empty fields don't filter.
+type ListFilter struct {
+	Completed *bool
+	Tag       string
+}
+
+// List returns the todos, the most important first
+func (s *TodoStore) List(ctx context.Context, f ListFilter) ([]Todo, error) {
+	// A filter is a document: only the conditions set are added
+	filter := bson.D{}
+	if f.Completed != nil {
+		filter = append(filter, bson.E{Key: "completed", Value: *f.Completed})
+	}
+	if f.Tag != "" {
+		// Matches the documents whose tags array contains the tag
+		filter = append(filter, bson.E{Key: "tags", Value: f.Tag})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "created_at", Value: 1}})
+	cursor, err := s.coll.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	todos := []Todo{}
+	// All decodes every document and closes the cursor
+	if err := cursor.All(ctx, &todos); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// Search finds todos by words of their title, the best matches first
+func (s *TodoStore) Search(ctx context.Context, text string) ([]Todo, error) {
+	filter := bson.D{{Key: "$text", Value: bson.D{{Key: "$search", Value: text}}}}
+	score := bson.D{{Key: "score", Value: bson.D{{Key: "$meta", Value: "textScore"}}}}
+	cursor, err := s.coll.Find(ctx, filter, options.Find().SetProjection(score).SetSort(score))
+	if err != nil {
+		return nil, err
+	}
+	todos := []Todo{}
+	if err := cursor.All(ctx, &todos); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// TodoUpdate holds the fields to change; nil fields are left alone
+type TodoUpdate struct {
+	Title     *string
+	Completed *bool
+	Priority  *int
+	Tags      []string
+}
+
+// Update changes the fields set in update and returns the updated todo.
+// Only these fields are written, with $set: a concurrent update of another
+// field is not overwritten, unlike replacing the whole document.
+func (s *TodoStore) Update(ctx context.Context, id string, update TodoUpdate) (*Todo, error) {
+	oid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	set := bson.D{{Key: "updated_at", Value: time.Now().UTC()}}
+	if update.Title != nil {
+		set = append(set, bson.E{Key: "title", Value: *update.Title})
+	}
+	if update.Completed != nil {
+		set = append(set, bson.E{Key: "completed", Value: *update.Completed})
+	}
+	if update.Priority != nil {
+		set = append(set, bson.E{Key: "priority", Value: *update.Priority})
+	}
+	if update.Tags != nil {
+		set = append(set, bson.E{Key: "tags", Value: update.Tags})
+	}
+
+	var todo Todo
+	err = s.coll.FindOneAndUpdate(ctx,
+		bson.D{{Key: "_id", Value: oid}},
+		bson.D{{Key: "$set", Value: set}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&todo)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	return &todo, err
+}
+
+func (s *TodoStore) Delete(ctx context.Context, id string) error {
+	oid, err := parseID(id)
+	if err != nil {
+		return err
+	}
+	result, err := s.coll.DeleteOne(ctx, bson.D{{Key: "_id", Value: oid}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
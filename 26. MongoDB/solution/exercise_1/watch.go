@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Event types published for the changes of the todos collection
+const (
+	EventTodoCreated   = "todo.created"
+	EventTodoUpdated   = "todo.updated"
+	EventTodoCompleted = "todo.completed"
+	EventTodoDeleted   = "todo.deleted"
+)
+
+// ErrInvalidated is returned by Run when the collection was dropped or
+// renamed: the change stream can't go on
+var ErrInvalidated = errors.New("change stream invalidated")
+
+const (
+	resumeDelay = time.Second
+	maxResumes  = 5 // Consecutive failures before Run gives up
+)
+
+// TodoChange is the data of the published events
+type TodoChange struct {
+	ID            bson.ObjectID
+	Todo          *Todo    // The document after the change, nil for a delete
+	UpdatedFields []string // For an update
+}
+
+// changeEvent is a document of the change stream
+type changeEvent struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		ID bson.ObjectID `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument      *Todo `bson:"fullDocument"`
+	UpdateDescription struct {
+		UpdatedFields bson.M `bson:"updatedFields"`
+	} `bson:"updateDescription"`
+}
+
+// Watcher publishes the changes of the todos on an event bus. Change
+// streams read the oplog of a replica set: a standalone server refuses
+// them.
+type Watcher struct {
+	coll        *mongo.Collection
+	bus         *EventBus
+	stream      *mongo.ChangeStream
+	resumeToken bson.Raw // Position after the last published event
+}
+
+func NewWatcher(store *TodoStore, bus *EventBus) *Watcher {
+	return &Watcher{coll: store.coll, bus: bus}
+}
+
+// Open starts the change stream: every change made after Open returns is
+// published by Run
+func (w *Watcher) Open(ctx context.Context) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "operationType", Value: bson.D{
+			{Key: "$in", Value: bson.A{"insert", "update", "replace", "delete", "invalidate"}},
+		}}}}},
+	}
+	// The update events hold the changed fields only; UpdateLookup adds the
+	// current document
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if w.resumeToken != nil {
+		// Start after the last event seen: none is lost or published twice
+		opts.SetResumeAfter(w.resumeToken)
+	}
+	stream, err := w.coll.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return err
+	}
+	w.stream = stream
+	return nil
+}
+
+// Run publishes the changes until ctx is cancelled. The driver already
+// resumes once after a network error or an election; when the stream still
+// fails, Run opens it again from the last event, a few times.
+func (w *Watcher) Run(ctx context.Context) error {
+	failures := 0
+	for {
+		for w.stream.Next(ctx) {
+			var event changeEvent
+			if err := w.stream.Decode(&event); err != nil {
+				log.Printf("watcher: skipping an undecodable event: %v", err)
+				continue
+			}
+			if event.OperationType == "invalidate" {
+				w.stream.Close(context.Background())
+				return ErrInvalidated
+			}
+			w.publish(event)
+			w.resumeToken = w.stream.ResumeToken()
+			failures = 0
+		}
+		err := w.stream.Err()
+		w.stream.Close(context.Background())
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		for {
+			failures++
+			if failures > maxResumes {
+				return err
+			}
+			log.Printf("watcher: change stream failed, resuming: %v", err)
+			select {
+			case <-time.After(resumeDelay):
+			case <-ctx.Done():
+				return nil
+			}
+			if err = w.Open(ctx); err == nil {
+				break
+			}
+		}
+	}
+}
+
+func (w *Watcher) publish(event changeEvent) {
+	change := TodoChange{ID: event.DocumentKey.ID, Todo: event.FullDocument}
+	var eventType string
+	switch event.OperationType {
+	case "insert":
+		eventType = EventTodoCreated
+	case "update", "replace":
+		eventType = EventTodoUpdated
+		for field := range event.UpdateDescription.UpdatedFields {
+			change.UpdatedFields = append(change.UpdatedFields, field)
+		}
+		slices.Sort(change.UpdatedFields)
+		if completed, _ := event.UpdateDescription.UpdatedFields["completed"].(bool); completed {
+			eventType = EventTodoCompleted
+		}
+	case "delete":
+		eventType = EventTodoDeleted
+	default:
+		return
+	}
+	w.bus.Publish(BaseEvent{EventType: eventType, EventData: change, EventTime: time.Now()})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"golang-training/module-27/exercise-1/queue"
+)
+
+// newAdminMux serves the admin endpoints of the queue:
+//
+//	GET  /admin/jobs?status=failed&limit=20  jobs of a status, pending by default
+//	GET  /admin/jobs/stats                    number of jobs of each status
+//	POST /admin/jobs/{id}/retry               put a failed job back in the queue
+func newAdminMux(q *queue.Queue) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/jobs", func(w http.ResponseWriter, r *http.Request) {
+		status := queue.Status(r.URL.Query().Get("status"))
+		switch status {
+		case "":
+			status = queue.StatusPending
+		case queue.StatusPending, queue.StatusRunning, queue.StatusDone, queue.StatusFailed:
+		default:
+			writeError(w, http.StatusBadRequest, "unknown status "+strconv.Quote(string(status)))
+			return
+		}
+		limit := 50
+		if v := r.URL.Query().Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 500 {
+				writeError(w, http.StatusBadRequest, "limit must be between 1 and 500")
+				return
+			}
+			limit = n
+		}
+		jobs, err := q.List(r.Context(), status, limit)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"status": status, "jobs": jobs})
+	})
+
+	mux.HandleFunc("GET /admin/jobs/stats", func(w http.ResponseWriter, r *http.Request) {
+		counts, err := q.Counts(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, counts)
+	})
+
+	mux.HandleFunc("POST /admin/jobs/{id}/retry", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid job ID")
+			return
+		}
+		job, err := q.Retry(r.Context(), uint(id))
+		switch {
+		case errors.Is(err, queue.ErrNotFound):
+			writeError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, queue.ErrNotFailed):
+			writeError(w, http.StatusConflict, err.Error())
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err.Error())
+		default:
+			writeJSON(w, http.StatusOK, job)
+		}
+	})
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
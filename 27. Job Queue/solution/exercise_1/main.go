@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang-training/module-27/exercise-1/queue"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Payloads of the job types
+type (
+	EmailPayload struct {
+		To      string `json:"to"`
+		Subject string `json:"subject"`
+	}
+	ReportPayload struct {
+		Name string `json:"name"`
+	}
+	ImagePayload struct {
+		URL string `json:"url"`
+	}
+)
+
+// newHandlers returns the handler of each job type. They simulate work, and
+// the failures a real job meets.
+func newHandlers(logger *slog.Logger) map[string]queue.Handler {
+	return map[string]queue.Handler{
+		"email.send": func(ctx context.Context, job *queue.Job) error {
+			var p EmailPayload
+			if err := job.Decode(&p); err != nil {
+				return fmt.Errorf("%w: %v", queue.ErrPermanent, err)
+			}
+			select {
+			case <-time.After(50 * time.Millisecond):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			logger.Info("email sent", "to", p.To, "subject", p.Subject)
+			return nil
+		},
+		// The reporting service is down for the first two attempts
+		"report.generate": func(ctx context.Context, job *queue.Job) error {
+			var p ReportPayload
+			if err := job.Decode(&p); err != nil {
+				return fmt.Errorf("%w: %v", queue.ErrPermanent, err)
+			}
+			if job.Attempts < 3 {
+				return errors.New("reporting service unavailable")
+			}
+			logger.Info("report generated", "name", p.Name)
+			return nil
+		},
+		"image.resize": func(ctx context.Context, job *queue.Job) error {
+			var p ImagePayload
+			if err := job.Decode(&p); err != nil {
+				return fmt.Errorf("%w: %v", queue.ErrPermanent, err)
+			}
+			if p.URL == "" {
+				// Retrying won't add the URL
+				return fmt.Errorf("%w: no image URL", queue.ErrPermanent)
+			}
+			if strings.HasSuffix(p.URL, ".tiff") {
+				panic("unsupported image format")
+			}
+			return nil
+		},
+	}
+}
+
+// openDB opens the SQLite file shared by the workers. WAL lets readers work
+// while a worker writes, and the busy timeout makes a writer wait for the
+// others instead of failing with "database is locked".
+func openDB(path string) (*gorm.DB, error) {
+	return gorm.Open(sqlite.Open(path+"?_busy_timeout=5000&_journal_mode=WAL"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+}
+
+// newLogger logs without the time, to keep the demo output short
+func newLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+// startWorkers runs n workers until ctx is cancelled; wait returns once
+// they finished their current job
+func startWorkers(ctx context.Context, q *queue.Queue, n int, poll time.Duration, logger *slog.Logger) (wait func()) {
+	host, _ := os.Hostname()
+	handlers := newHandlers(logger)
+	var wg sync.WaitGroup
+	for i := range n {
+		// Unique across the processes sharing the database
+		worker := queue.NewWorker(q, fmt.Sprintf("%s-%d-%d", host, os.Getpid(), i+1), handlers, logger)
+		worker.PollInterval = poll
+		worker.Lease = 2 * time.Second
+		wg.Go(func() { worker.Run(ctx) })
+	}
+	return wg.Wait
+}
+
+func main() {
+	dbPath := flag.String("db", "jobs.db", "SQLite database of the queue")
+	workers := flag.Int("workers", 3, "number of workers")
+	addr := flag.String("addr", ":8080", "address of the admin endpoints")
+	demo := flag.Bool("demo", false, "enqueue sample jobs, run them for a few seconds and query the admin endpoints")
+	flag.Parse()
+
+	db, err := openDB(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	logger := newLogger(os.Stdout)
+
+	if *demo {
+		// Short delays, so retries happen during the demo
+		q, err := queue.New(db, queue.Config{MaxAttempts: 3, BackoffBase: 200 * time.Millisecond, BackoffMax: 5 * time.Second})
+		if err != nil {
+			log.Fatalf("Failed to create queue: %v", err)
+		}
+		runDemo(db, q, *workers, logger)
+		return
+	}
+
+	q, err := queue.New(db, queue.DefaultConfig)
+	if err != nil {
+		log.Fatalf("Failed to create queue: %v", err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	wait := startWorkers(ctx, q, *workers, time.Second, logger)
+
+	server := &http.Server{Addr: *addr, Handler: newAdminMux(q), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	logger.Info("admin endpoints listening", "addr", *addr, "workers", *workers)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+	wait()
+	logger.Info("stopped")
+}
+
+func runDemo(db *gorm.DB, q *queue.Queue, workers int, logger *slog.Logger) {
+	ctx := context.Background()
+	db.Where("1 = 1").Delete(&queue.Job{})
+
+	fmt.Println("--- Enqueue ---")
+	enqueue := func(jobType string, payload any, opts ...queue.EnqueueOption) *queue.Job {
+		job, err := q.Enqueue(ctx, jobType, payload, opts...)
+		if err != nil {
+			log.Fatalf("Failed to enqueue %s: %v", jobType, err)
+		}
+		fmt.Printf("#%d %-16s %s\n", job.ID, job.Type, job.Payload)
+		return job
+	}
+	for _, to := range []string{"alice@example.com", "bob@example.com", "carol@example.com"} {
+		enqueue("email.send", EmailPayload{To: to, Subject: "Your order shipped"})
+	}
+	enqueue("report.generate", ReportPayload{Name: "daily-sales"}, queue.MaxAttempts(4))
+	tiff := enqueue("image.resize", ImagePayload{URL: "https://example.com/scan.tiff"})
+	enqueue("image.resize", ImagePayload{})
+	enqueue("email.send", EmailPayload{To: "dave@example.com", Subject: "Reminder"}, queue.RunAt(time.Now().Add(1500*time.Millisecond)))
+	enqueue("video.encode", map[string]string{"file": "intro.mp4"})
+
+	// A worker claims a job, then crashes: its lease expires and another
+	// worker runs the job. Its late report is refused.
+	crashed, err := q.Claim(ctx, "default", "crashed-worker", 500*time.Millisecond)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("crashed-worker claimed #%d and stopped answering\n", crashed.ID)
+
+	fmt.Println("\n--- Workers ---")
+	runCtx, cancel := context.WithTimeout(ctx, 4*time.Second)
+	wait := startWorkers(runCtx, q, workers, 100*time.Millisecond, logger)
+	wait()
+	cancel()
+	err = q.Complete(ctx, crashed)
+	fmt.Printf("crashed-worker completing #%d: %v\n", crashed.ID, err)
+
+	fmt.Println("\n--- Admin Endpoints ---")
+	server := httptest.NewServer(newAdminMux(q))
+	defer server.Close()
+	request := func(method, path string) {
+		req, _ := http.NewRequest(method, server.URL+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		fmt.Printf("%s %s -> %d\n%s", method, path, resp.StatusCode, body)
+	}
+	request("GET", "/admin/jobs/stats")
+	request("GET", "/admin/jobs?status=failed&limit=5")
+	request("POST", fmt.Sprintf("/admin/jobs/%d/retry", tiff.ID))
+	request("POST", "/admin/jobs/1/retry")
+	request("GET", "/admin/jobs?status=pending")
+}
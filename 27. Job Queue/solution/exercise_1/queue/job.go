@@ -0,0 +1,46 @@
+// Package queue is a persistent job queue stored in a database table with
+// GORM. Jobs survive restarts, several workers share them, and failed jobs
+// are retried later with an increasing delay.
+package queue
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Status is the state of a job:
+//
+//	pending --claim--> running --success--> done
+//	   ^                  |
+//	   +----retry later---+--last attempt--> failed
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+// Job is a row of the jobs table
+type Job struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	Queue       string          `gorm:"size:50;not null;default:default;index:idx_jobs_claim,priority:1" json:"queue"`
+	Type        string          `gorm:"size:100;not null" json:"type"`
+	Payload     json.RawMessage `gorm:"type:text;not null" json:"payload"`
+	Status      Status          `gorm:"size:20;not null;index:idx_jobs_claim,priority:2" json:"status"`
+	RunAt       time.Time       `gorm:"not null;index:idx_jobs_claim,priority:3" json:"run_at"` // Not before this time
+	Attempts    int             `gorm:"not null" json:"attempts"`
+	MaxAttempts int             `gorm:"not null" json:"max_attempts"`
+	LastError   string          `gorm:"type:text" json:"last_error,omitempty"`
+	LockedBy    string          `gorm:"size:100" json:"locked_by,omitempty"`
+	LockedUntil *time.Time      `json:"locked_until,omitempty"` // The lease of the worker running the job
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// Decode reads the payload into v
+func (j *Job) Decode(v any) error {
+	return json.Unmarshal(j.Payload, v)
+}
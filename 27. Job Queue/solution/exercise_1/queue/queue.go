@@ -0,0 +1,256 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNoJob is returned by Claim when no job is ready
+	ErrNoJob = errors.New("no job ready")
+	// ErrLeaseLost is returned when a worker reports on a job it no longer
+	// holds: its lease expired and another worker claimed the job
+	ErrLeaseLost = errors.New("job lease lost")
+	ErrNotFound  = errors.New("job not found")
+	// ErrNotFailed is returned by Retry for a job that didn't fail
+	ErrNotFailed = errors.New("job has not failed")
+	// ErrPermanent marks the errors that retrying can't fix, such as an
+	// invalid payload: the job fails without using its other attempts
+	ErrPermanent = errors.New("permanent failure")
+)
+
+// Config sets the retry policy of a queue
+type Config struct {
+	MaxAttempts int           // Default attempts of a job
+	BackoffBase time.Duration // Delay after the first failure, doubled after each one
+	BackoffMax  time.Duration
+}
+
+// DefaultConfig retries a job 5 times, waiting 1s, 2s, 4s then 8s
+var DefaultConfig = Config{MaxAttempts: 5, BackoffBase: time.Second, BackoffMax: time.Hour}
+
+// Queue stores jobs in the jobs table
+type Queue struct {
+	db     *gorm.DB
+	config Config
+	now    func() time.Time
+}
+
+// New returns a queue using db, and creates its table
+func New(db *gorm.DB, config Config) (*Queue, error) {
+	if err := db.AutoMigrate(&Job{}); err != nil {
+		return nil, err
+	}
+	// UTC: SQLite compares the times as text, which only works with a
+	// single time zone
+	return &Queue{db: db, config: config, now: func() time.Time { return time.Now().UTC() }}, nil
+}
+
+// EnqueueOption changes a job before it is stored
+type EnqueueOption func(*Job)
+
+// RunAt delays the job until t
+func RunAt(t time.Time) EnqueueOption {
+	return func(j *Job) { j.RunAt = t }
+}
+
+// MaxAttempts replaces the default number of attempts of the queue
+func MaxAttempts(n int) EnqueueOption {
+	return func(j *Job) { j.MaxAttempts = n }
+}
+
+// OnQueue puts the job in a named queue, for workers dedicated to it
+func OnQueue(name string) EnqueueOption {
+	return func(j *Job) { j.Queue = name }
+}
+
+// Enqueue stores a job of the given type. The payload is saved as JSON:
+// the job must hold everything the handler needs, as it may run in
+// another process.
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload any, opts ...EnqueueOption) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("encoding the payload of %s: %w", jobType, err)
+	}
+	job := &Job{
+		Queue:       "default",
+		Type:        jobType,
+		Payload:     data,
+		Status:      StatusPending,
+		RunAt:       q.now(),
+		MaxAttempts: q.config.MaxAttempts,
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+	job.RunAt = job.RunAt.UTC()
+	if err := q.db.WithContext(ctx).Create(job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Claim gives the next ready job of the queue to a worker for the lease
+// duration. A job is ready when it is pending and its time came, or when
+// it is running but the lease of its worker expired: the worker crashed or
+// hangs, and the job is given to another one. A job whose lease expired on
+// its last attempt is marked failed instead, so a job crashing every worker
+// doesn't run forever.
+//
+// The job is selected and marked running by a single UPDATE ... RETURNING:
+// SQLite runs one write at a time, so two workers can't claim the same job.
+// Postgres would need FOR UPDATE SKIP LOCKED in the subquery, so that
+// workers skip the rows locked by the others instead of waiting.
+func (q *Queue) Claim(ctx context.Context, queue, worker string, lease time.Duration) (*Job, error) {
+	now := q.now()
+	err := q.db.WithContext(ctx).Model(&Job{}).
+		Where("queue = ? AND status = ? AND locked_until < ? AND attempts >= max_attempts", queue, StatusRunning, now).
+		Updates(map[string]any{
+			"status":       StatusFailed,
+			"last_error":   "lease expired on the last attempt",
+			"finished_at":  now,
+			"locked_by":    "",
+			"locked_until": nil,
+			"updated_at":   now,
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ready := q.db.Model(&Job{}).Select("id").
+		Where("queue = ?", queue).
+		Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_until < ? AND attempts < max_attempts)",
+			StatusPending, now, StatusRunning, now).
+		Order("run_at, id").
+		Limit(1)
+
+	var claimed []Job
+	err = q.db.WithContext(ctx).Model(&claimed).
+		Clauses(clause.Returning{}).
+		Where("id = (?)", ready).
+		Updates(map[string]any{
+			"status":       StatusRunning,
+			"locked_by":    worker,
+			"locked_until": now.Add(lease),
+			"attempts":     gorm.Expr("attempts + 1"),
+			"updated_at":   now,
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(claimed) == 0 {
+		return nil, ErrNoJob
+	}
+	return &claimed[0], nil
+}
+
+// Complete marks a claimed job done
+func (q *Queue) Complete(ctx context.Context, job *Job) error {
+	now := q.now()
+	return q.release(ctx, job, map[string]any{
+		"status":      StatusDone,
+		"finished_at": now,
+		"last_error":  "",
+	})
+}
+
+// Fail records the error of an attempt. The job runs again after the
+// backoff delay, or is marked failed after its last attempt, for an admin
+// to look at.
+func (q *Queue) Fail(ctx context.Context, job *Job, cause error) error {
+	now := q.now()
+	updates := map[string]any{"last_error": cause.Error()}
+	if job.Attempts >= job.MaxAttempts || errors.Is(cause, ErrPermanent) {
+		updates["status"] = StatusFailed
+		updates["finished_at"] = now
+	} else {
+		updates["status"] = StatusPending
+		updates["run_at"] = now.Add(q.Backoff(job.Attempts))
+	}
+	return q.release(ctx, job, updates)
+}
+
+// release updates a job held by the worker and ends its lease. The
+// condition on locked_by and attempts checks the job wasn't claimed again
+// meanwhile: the result of an attempt whose lease expired is dropped.
+func (q *Queue) release(ctx context.Context, job *Job, updates map[string]any) error {
+	updates["locked_by"] = ""
+	updates["locked_until"] = nil
+	updates["updated_at"] = q.now()
+	result := q.db.WithContext(ctx).Model(&Job{}).
+		Where("id = ? AND status = ? AND locked_by = ? AND attempts = ?", job.ID, StatusRunning, job.LockedBy, job.Attempts).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("job %d: %w", job.ID, ErrLeaseLost)
+	}
+	return nil
+}
+
+// Backoff returns the delay before the attempt following the given one:
+// BackoffBase, then twice as long after each failure, up to BackoffMax
+func (q *Queue) Backoff(attempts int) time.Duration {
+	delay := q.config.BackoffBase
+	for range attempts - 1 {
+		delay *= 2
+		if delay >= q.config.BackoffMax {
+			return q.config.BackoffMax
+		}
+	}
+	return delay
+}
+
+// List returns the jobs with a status, the oldest first
+func (q *Queue) List(ctx context.Context, status Status, limit int) ([]Job, error) {
+	var jobs []Job
+	err := q.db.WithContext(ctx).Where("status = ?", status).Order("run_at, id").Limit(limit).Find(&jobs).Error
+	return jobs, err
+}
+
+// Counts returns the number of jobs of each status
+func (q *Queue) Counts(ctx context.Context) (map[Status]int, error) {
+	var rows []struct {
+		Status Status
+		Count  int
+	}
+	err := q.db.WithContext(ctx).Model(&Job{}).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error
+	counts := map[Status]int{StatusPending: 0, StatusRunning: 0, StatusDone: 0, StatusFailed: 0}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, err
+}
+
+// Retry puts a failed job back in the queue, with its attempts reset
+func (q *Queue) Retry(ctx context.Context, id uint) (*Job, error) {
+	var job Job
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&job, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("job %d: %w", id, ErrNotFound)
+			}
+			return err
+		}
+		if job.Status != StatusFailed {
+			return fmt.Errorf("job %d is %s: %w", id, job.Status, ErrNotFailed)
+		}
+		return tx.Model(&job).Updates(map[string]any{
+			"status":      StatusPending,
+			"attempts":    0,
+			"run_at":      q.now(),
+			"finished_at": nil,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
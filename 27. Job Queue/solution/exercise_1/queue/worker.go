@@ -0,0 +1,104 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Handler runs a job. Its context is cancelled when the lease of the job
+// expires, as another worker may then claim it.
+type Handler func(ctx context.Context, job *Job) error
+
+// Worker claims the jobs of a queue and runs their handler
+type Worker struct {
+	ID           string
+	Queue        string        // "default" when empty
+	PollInterval time.Duration // Wait when no job is ready
+	Lease        time.Duration // Longest time a job may run
+
+	queue    *Queue
+	handlers map[string]Handler
+	logger   *slog.Logger
+}
+
+// NewWorker returns a worker of q with the handlers of each job type
+func NewWorker(q *Queue, id string, handlers map[string]Handler, logger *slog.Logger) *Worker {
+	return &Worker{
+		ID:           id,
+		Queue:        "default",
+		PollInterval: time.Second,
+		Lease:        30 * time.Second,
+		queue:        q,
+		handlers:     handlers,
+		logger:       logger.With("worker", id),
+	}
+}
+
+// Run processes jobs until ctx is cancelled. The job running then is
+// finished first: stopping doesn't waste an attempt.
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		// Claim without ctx: a claim made just as ctx is cancelled must
+		// still return its job, which is then run
+		job, err := w.queue.Claim(context.Background(), w.Queue, w.ID, w.Lease)
+		switch {
+		case err == nil:
+			w.process(job)
+			continue // Another job may be ready already
+		case !errors.Is(err, ErrNoJob):
+			w.logger.Error("claiming a job", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.PollInterval):
+		}
+	}
+}
+
+// process runs the handler of a job and records the result
+func (w *Worker) process(job *Job) {
+	logger := w.logger.With("job", job.ID, "type", job.Type, "attempt", job.Attempts)
+	ctx, cancel := context.WithTimeout(context.Background(), w.Lease)
+	defer cancel()
+
+	start := time.Now()
+	err := w.run(ctx, job)
+	if err == nil {
+		err = w.queue.Complete(context.Background(), job)
+		if err != nil {
+			logger.Error("completing the job", "error", err)
+			return
+		}
+		logger.Info("job done", "duration", time.Since(start).Round(time.Millisecond))
+		return
+	}
+
+	if failErr := w.queue.Fail(context.Background(), job, err); failErr != nil {
+		logger.Error("recording the failure", "error", failErr, "cause", err)
+		return
+	}
+	if job.Attempts >= job.MaxAttempts || errors.Is(err, ErrPermanent) {
+		logger.Warn("job failed", "error", err)
+	} else {
+		logger.Info("job will be retried", "error", err, "in", w.queue.Backoff(job.Attempts))
+	}
+}
+
+// run calls the handler, turning a panic into an error: a bad job must not
+// stop the worker
+func (w *Worker) run(ctx context.Context, job *Job) (err error) {
+	handler, ok := w.handlers[job.Type]
+	if !ok {
+		return fmt.Errorf("%w: no handler for %q", ErrPermanent, job.Type)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
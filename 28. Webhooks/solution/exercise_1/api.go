@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"golang-training/module-28/exercise-1/webhooks"
+)
+
+// newMux serves the application and the management of the webhooks:
+//
+//	POST   /orders                       create an order: order.created
+//	POST   /todos                        create a todo
+//	POST   /todos/{id}/complete          complete a todo: todo.completed
+//	POST   /webhooks                     register an endpoint, returns its secret
+//	GET    /webhooks                     list the endpoints
+//	GET    /webhooks/{id}                an endpoint
+//	DELETE /webhooks/{id}                remove an endpoint
+//	GET    /webhooks/{id}/deliveries     latest deliveries, with their attempts
+//	POST   /deliveries/{id}/redeliver    send a failed delivery again
+func newMux(service *Service, dispatcher *webhooks.Dispatcher) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /orders", func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Customer   string `json:"customer"`
+			TotalCents int64  `json:"total_cents"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Customer == "" || input.TotalCents <= 0 {
+			writeError(w, http.StatusBadRequest, "customer and a positive total_cents are required")
+			return
+		}
+		order, err := service.CreateOrder(r.Context(), input.Customer, input.TotalCents)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusCreated, order)
+	})
+
+	mux.HandleFunc("POST /todos", func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Title string `json:"title"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Title == "" {
+			writeError(w, http.StatusBadRequest, "title is required")
+			return
+		}
+		todo, err := service.CreateTodo(r.Context(), input.Title)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusCreated, todo)
+	})
+
+	mux.HandleFunc("POST /todos/{id}/complete", func(w http.ResponseWriter, r *http.Request) {
+		id, ok := pathID(w, r)
+		if !ok {
+			return
+		}
+		todo, err := service.CompleteTodo(r.Context(), id)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, todo)
+	})
+
+	mux.HandleFunc("POST /webhooks", func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			URL    string   `json:"url"`
+			Events []string `json:"events"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON")
+			return
+		}
+		endpoint, err := dispatcher.Register(r.Context(), input.URL, input.Events)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		// The only response including the secret: the owner stores it to
+		// verify the signatures
+		writeJSON(w, http.StatusCreated, struct {
+			*webhooks.Endpoint
+			Secret string `json:"secret"`
+		}{endpoint, endpoint.Secret})
+	})
+
+	mux.HandleFunc("GET /webhooks", func(w http.ResponseWriter, r *http.Request) {
+		endpoints, err := dispatcher.Endpoints(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, endpoints)
+	})
+
+	mux.HandleFunc("GET /webhooks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, ok := pathID(w, r)
+		if !ok {
+			return
+		}
+		endpoint, err := dispatcher.Endpoint(r.Context(), id)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, endpoint)
+	})
+
+	mux.HandleFunc("DELETE /webhooks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, ok := pathID(w, r)
+		if !ok {
+			return
+		}
+		if err := dispatcher.Delete(r.Context(), id); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("GET /webhooks/{id}/deliveries", func(w http.ResponseWriter, r *http.Request) {
+		id, ok := pathID(w, r)
+		if !ok {
+			return
+		}
+		if _, err := dispatcher.Endpoint(r.Context(), id); err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		deliveries, err := dispatcher.Deliveries(r.Context(), id, 20)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, deliveries)
+	})
+
+	mux.HandleFunc("POST /deliveries/{id}/redeliver", func(w http.ResponseWriter, r *http.Request) {
+		id, ok := pathID(w, r)
+		if !ok {
+			return
+		}
+		delivery, err := dispatcher.Redeliver(r.Context(), id)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, delivery)
+	})
+	return mux
+}
+
+// pathID reads the {id} of the path, and answers 400 when it is invalid
+func pathID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// writeServiceError maps the errors of the service and the dispatcher to
+// status codes
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, webhooks.ErrNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, webhooks.ErrInvalidEndpoint):
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, webhooks.ErrNotFailed):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
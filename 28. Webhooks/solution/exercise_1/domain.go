@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang-training/module-28/exercise-1/webhooks"
+	"gorm.io/gorm"
+)
+
+// Event types published by the application
+const (
+	EventOrderCreated  = "order.created"
+	EventTodoCompleted = "todo.completed"
+)
+
+var ErrNotFound = errors.New("not found")
+
+type Order struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Customer   string    `gorm:"size:100;not null" json:"customer"`
+	TotalCents int64     `gorm:"not null" json:"total_cents"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type Todo struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Title       string     `gorm:"size:200;not null" json:"title"`
+	Completed   bool       `gorm:"not null" json:"completed"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// Service changes the orders and the todos, and publishes an event for
+// each change in the same transaction
+type Service struct {
+	db     *gorm.DB
+	events *webhooks.Dispatcher
+}
+
+func NewService(db *gorm.DB, events *webhooks.Dispatcher) (*Service, error) {
+	if err := db.AutoMigrate(&Order{}, &Todo{}); err != nil {
+		return nil, err
+	}
+	return &Service{db: db, events: events}, nil
+}
+
+// CreateOrder saves an order and publishes order.created
+func (s *Service) CreateOrder(ctx context.Context, customer string, totalCents int64) (*Order, error) {
+	order := &Order{Customer: customer, TotalCents: totalCents}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+		_, err := s.events.PublishTx(tx, webhooks.NewEvent(EventOrderCreated, order))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// CreateTodo saves a todo. No event is published for it.
+func (s *Service) CreateTodo(ctx context.Context, title string) (*Todo, error) {
+	todo := &Todo{Title: title}
+	if err := s.db.WithContext(ctx).Create(todo).Error; err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// CompleteTodo marks a todo completed and publishes todo.completed.
+// Completing it again publishes nothing.
+func (s *Service) CompleteTodo(ctx context.Context, id uint) (*Todo, error) {
+	var todo Todo
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&todo, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("todo %d: %w", id, ErrNotFound)
+			}
+			return err
+		}
+		if todo.Completed {
+			return nil
+		}
+		now := time.Now().UTC()
+		if err := tx.Model(&todo).Updates(map[string]any{"completed": true, "completed_at": now}).Error; err != nil {
+			return err
+		}
+		_, err := s.events.PublishTx(tx, webhooks.NewEvent(EventTodoCompleted, todo))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang-training/module-28/exercise-1/webhooks"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openDB opens the SQLite file of the application. The busy timeout makes
+// the deliveries sent at the same time wait for each other's writes.
+func openDB(path string) (*gorm.DB, error) {
+	return gorm.Open(sqlite.Open(path+"?_busy_timeout=5000&_journal_mode=WAL"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+}
+
+func main() {
+	dbPath := flag.String("db", "webhooks.db", "SQLite database")
+	addr := flag.String("addr", ":8080", "address of the API")
+	demo := flag.Bool("demo", false, "deliver sample events to local receivers and print the delivery history")
+	flag.Parse()
+
+	db, err := openDB(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+
+	config := webhooks.DefaultConfig
+	if *demo {
+		// Short delays, so retries happen during the demo
+		config = webhooks.Config{MaxAttempts: 4, BackoffBase: 200 * time.Millisecond, BackoffMax: 2 * time.Second, Timeout: 2 * time.Second, BatchSize: 20}
+	}
+	dispatcher, err := webhooks.New(db, config)
+	if err != nil {
+		log.Fatalf("Failed to create dispatcher: %v", err)
+	}
+	service, err := NewService(db, dispatcher)
+	if err != nil {
+		log.Fatalf("Failed to create service: %v", err)
+	}
+	if *demo {
+		runDemo(db, service, dispatcher)
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dispatcher.Run(ctx, time.Second)
+	}()
+
+	server := &http.Server{Addr: *addr, Handler: newMux(service, dispatcher), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	log.Printf("API listening on %s", *addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+	<-done
+}
+
+// ===== Demo receivers =====
+
+// receiver is the server of an application receiving the webhooks. It
+// verifies the signatures and drops the events it already processed.
+type receiver struct {
+	name   string
+	secret string
+	// answer returns the status of the nth request, after the event was
+	// processed; 0 for 200 OK
+	answer func(n int) int
+
+	mu       sync.Mutex
+	requests int
+	seen     map[string]bool
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "reading the body", http.StatusBadRequest)
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if err := webhooks.Verify(rc.secret, r.Header, body, 5*time.Minute, time.Now()); err != nil {
+		fmt.Printf("  [%s] rejected: %v\n", rc.name, err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	rc.requests++
+
+	var event webhooks.Event
+	json.Unmarshal(body, &event)
+	if rc.seen[event.ID] {
+		fmt.Printf("  [%s] %s %s again, ignored\n", rc.name, event.Type, event.ID[:12])
+	} else {
+		rc.seen[event.ID] = true
+		fmt.Printf("  [%s] %s %s processed\n", rc.name, event.Type, event.ID[:12])
+	}
+	if status := rc.answer(rc.requests); status != 0 {
+		http.Error(w, http.StatusText(status), status)
+	}
+}
+
+func runDemo(db *gorm.DB, service *Service, dispatcher *webhooks.Dispatcher) {
+	ctx := context.Background()
+	for _, model := range []any{&webhooks.Attempt{}, &webhooks.Delivery{}, &webhooks.Endpoint{}, &Order{}, &Todo{}} {
+		db.Where("1 = 1").Delete(model)
+	}
+
+	app := httptest.NewServer(newMux(service, dispatcher))
+	defer app.Close()
+	request := func(method, path string, body any) []byte {
+		var payload io.Reader
+		if body != nil {
+			data, _ := json.Marshal(body)
+			payload = bytes.NewReader(data)
+		}
+		req, _ := http.NewRequest(method, app.URL+path, payload)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		fmt.Printf("%s %s -> %d\n", method, path, resp.StatusCode)
+		return data
+	}
+
+	receivers := []struct {
+		*receiver
+		events []string
+	}{
+		{&receiver{name: "billing", answer: func(int) int { return 0 }}, []string{"*"}},
+		// Processes the event, then fails to answer twice, as a server
+		// timing out: the retries bring the same event again
+		{&receiver{name: "crm", answer: func(n int) int {
+			if n <= 2 {
+				return http.StatusServiceUnavailable
+			}
+			return 0
+		}}, []string{EventOrderCreated}},
+		{&receiver{name: "analytics", answer: func(int) int { return http.StatusInternalServerError }}, []string{EventTodoCompleted}},
+		{&receiver{name: "legacy", answer: func(int) int { return http.StatusGone }}, []string{EventOrderCreated, EventTodoCompleted}},
+	}
+
+	fmt.Println("--- Register Endpoints ---")
+	endpointIDs := make(map[string]uint)
+	for _, r := range receivers {
+		r.seen = make(map[string]bool)
+		server := httptest.NewServer(r.receiver)
+		defer server.Close()
+		var registered struct {
+			ID     uint   `json:"id"`
+			Secret string `json:"secret"`
+		}
+		json.Unmarshal(request("POST", "/webhooks", map[string]any{"url": server.URL + "/hooks", "events": r.events}), &registered)
+		r.secret = registered.Secret
+		endpointIDs[r.name] = registered.ID
+		fmt.Printf("  %s: endpoint %d for %v, secret %s...\n", r.name, registered.ID, r.events, registered.Secret[:10])
+	}
+	fmt.Printf("%s\n", request("POST", "/webhooks", map[string]any{"url": "ftp://example.com", "events": []string{"*"}}))
+
+	fmt.Println("--- Domain Events ---")
+	request("POST", "/orders", map[string]any{"customer": "Alice", "total_cents": 12999})
+	request("POST", "/todos", map[string]any{"title": "Ship the order"})
+	request("POST", "/todos/1/complete", nil)
+	request("POST", "/todos/1/complete", nil) // Already completed: no event
+
+	// Publishing an event again stores nothing
+	event := webhooks.NewEvent(EventOrderCreated, Order{ID: 99, Customer: "Bob", TotalCents: 500})
+	first, _ := dispatcher.Publish(ctx, event)
+	again, _ := dispatcher.Publish(ctx, event)
+	fmt.Printf("Publishing %s: %d deliveries, then %d\n", event.ID[:12], first, again)
+
+	// A request not signed with the secret of the receiver
+	forged, _ := http.NewRequest("POST", "http://billing.invalid", strings.NewReader(`{"type":"order.created"}`))
+	forged.Header.Set(webhooks.HeaderID, "evt_forged")
+	forged.Header.Set(webhooks.HeaderTimestamp, fmt.Sprint(time.Now().Unix()))
+	forged.Header.Set(webhooks.HeaderSignature, webhooks.Sign("guessed", "evt_forged", time.Now(), []byte(`{"type":"order.created"}`)))
+	recorder := httptest.NewRecorder()
+	receivers[0].ServeHTTP(recorder, forged)
+
+	fmt.Println("\n--- Deliveries ---")
+	runCtx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	dispatcher.Run(runCtx, 100*time.Millisecond)
+	cancel()
+
+	fmt.Println("\n--- Delivery History ---")
+	for _, r := range receivers {
+		var deliveries []webhooks.Delivery
+		json.Unmarshal(request("GET", fmt.Sprintf("/webhooks/%d/deliveries", endpointIDs[r.name]), nil), &deliveries)
+		for _, delivery := range deliveries {
+			var codes []string
+			for _, attempt := range delivery.History {
+				codes = append(codes, fmt.Sprint(attempt.StatusCode))
+			}
+			fmt.Printf("  #%d %-15s %-9s %d attempts [%s] %s\n", delivery.ID, delivery.EventType, delivery.Status,
+				delivery.Attempts, strings.Join(codes, " "), delivery.LastError)
+		}
+	}
+
+	fmt.Println("\n--- Management ---")
+	var endpoints []webhooks.Endpoint
+	json.Unmarshal(request("GET", "/webhooks", nil), &endpoints)
+	for _, endpoint := range endpoints {
+		fmt.Printf("  endpoint %d active=%t %s\n", endpoint.ID, endpoint.Active, endpoint.DisabledReason)
+	}
+	var analytics []webhooks.Delivery
+	json.Unmarshal(request("GET", fmt.Sprintf("/webhooks/%d/deliveries", endpointIDs["analytics"]), nil), &analytics)
+	if len(analytics) > 0 {
+		// The analytics team fixed their server: send the event again
+		var redelivered webhooks.Delivery
+		json.Unmarshal(request("POST", fmt.Sprintf("/deliveries/%d/redeliver", analytics[0].ID), nil), &redelivered)
+		fmt.Printf("  #%d is %s again, with %d attempts\n", redelivered.ID, redelivered.Status, redelivered.MaxAttempts)
+		fmt.Printf("%s", request("POST", fmt.Sprintf("/deliveries/%d/redeliver", analytics[0].ID), nil))
+	}
+	request("DELETE", fmt.Sprintf("/webhooks/%d", endpointIDs["legacy"]), nil)
+	fmt.Printf("%s", request("GET", fmt.Sprintf("/webhooks/%d", endpointIDs["legacy"]), nil))
+}
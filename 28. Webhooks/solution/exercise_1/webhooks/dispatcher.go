@@ -0,0 +1,403 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidEndpoint is returned by Register for a URL or an event list
+	// that can't be used
+	ErrInvalidEndpoint = errors.New("invalid endpoint")
+	// ErrNotFailed is returned by Redeliver for a delivery that didn't fail
+	ErrNotFailed = errors.New("delivery has not failed")
+)
+
+// Config sets the retry policy of the deliveries
+type Config struct {
+	MaxAttempts int           // Attempts of a delivery before it fails
+	BackoffBase time.Duration // Delay after the first failure, doubled after each one
+	BackoffMax  time.Duration
+	Timeout     time.Duration // Of one request
+	BatchSize   int           // Deliveries sent at the same time
+}
+
+// DefaultConfig tries a delivery 8 times over about an hour: 30s, 1m, 2m,
+// 4m, 8m, 16m, then 32m after the last failures
+var DefaultConfig = Config{
+	MaxAttempts: 8,
+	BackoffBase: 30 * time.Second,
+	BackoffMax:  6 * time.Hour,
+	Timeout:     10 * time.Second,
+	BatchSize:   20,
+}
+
+// Dispatcher stores the endpoints and the deliveries, and sends them
+type Dispatcher struct {
+	db     *gorm.DB
+	client *http.Client
+	config Config
+	now    func() time.Time
+}
+
+// New returns a dispatcher using db, and creates its tables
+func New(db *gorm.DB, config Config) (*Dispatcher, error) {
+	if err := db.AutoMigrate(&Endpoint{}, &Delivery{}, &Attempt{}); err != nil {
+		return nil, err
+	}
+	return &Dispatcher{
+		db: db,
+		// No redirects: the endpoint registered is the one that receives
+		// the events, and a redirect could point to an internal address
+		client: &http.Client{
+			Timeout: config.Timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		config: config,
+		// UTC: SQLite compares the times as text
+		now: func() time.Time { return time.Now().UTC() },
+	}, nil
+}
+
+// ===== Endpoints =====
+
+// Register adds an endpoint receiving the events of the given types, with
+// a new signing secret
+func (d *Dispatcher) Register(ctx context.Context, rawURL string, events []string) (*Endpoint, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("%w: %q is not an http(s) URL", ErrInvalidEndpoint, rawURL)
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%w: no event types", ErrInvalidEndpoint)
+	}
+	secret, err := NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	endpoint := &Endpoint{URL: u.String(), Secret: secret, Events: events, Active: true}
+	if err := d.db.WithContext(ctx).Create(endpoint).Error; err != nil {
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+// Endpoints returns the registered endpoints
+func (d *Dispatcher) Endpoints(ctx context.Context) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	err := d.db.WithContext(ctx).Order("id").Find(&endpoints).Error
+	return endpoints, err
+}
+
+// Endpoint returns an endpoint by ID
+func (d *Dispatcher) Endpoint(ctx context.Context, id uint) (*Endpoint, error) {
+	var endpoint Endpoint
+	if err := d.db.WithContext(ctx).First(&endpoint, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("endpoint %d: %w", id, ErrNotFound)
+		}
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+// Delete removes an endpoint and its deliveries
+func (d *Dispatcher) Delete(ctx context.Context, id uint) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&Endpoint{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("endpoint %d: %w", id, ErrNotFound)
+		}
+		deliveries := tx.Model(&Delivery{}).Select("id").Where("endpoint_id = ?", id)
+		if err := tx.Where("delivery_id IN (?)", deliveries).Delete(&Attempt{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("endpoint_id = ?", id).Delete(&Delivery{}).Error
+	})
+}
+
+// Deliveries returns the latest deliveries of an endpoint, with their
+// attempts
+func (d *Dispatcher) Deliveries(ctx context.Context, endpointID uint, limit int) ([]Delivery, error) {
+	var deliveries []Delivery
+	err := d.db.WithContext(ctx).
+		Preload("History", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Where("endpoint_id = ?", endpointID).
+		Order("id DESC").Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ===== Publishing =====
+
+// NewEvent returns an event of the given type with a new ID
+func NewEvent(eventType string, data any) Event {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return Event{ID: "evt_" + hex.EncodeToString(b), Type: eventType, OccurredAt: time.Now().UTC(), Data: data}
+}
+
+// Publish stores a delivery of the event for every endpoint subscribed to
+// its type, and returns their number. The deliveries are sent later, by
+// Run.
+func (d *Dispatcher) Publish(ctx context.Context, event Event) (int, error) {
+	return d.PublishTx(d.db.WithContext(ctx), event)
+}
+
+// PublishTx publishes the event in the transaction tx. Publishing in the
+// transaction of the change the event is about stores both or neither: an
+// order can't be saved without its event, nor an event sent for an order
+// rolled back.
+func (d *Dispatcher) PublishTx(tx *gorm.DB, event Event) (int, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, fmt.Errorf("encoding event %s: %w", event.ID, err)
+	}
+	var endpoints []Endpoint
+	if err := tx.Where("active = ?", true).Find(&endpoints).Error; err != nil {
+		return 0, err
+	}
+	var deliveries []Delivery
+	for _, endpoint := range endpoints {
+		if !endpoint.Subscribed(event.Type) {
+			continue
+		}
+		deliveries = append(deliveries, Delivery{
+			EndpointID:    endpoint.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       payload,
+			Status:        DeliveryPending,
+			NextAttemptAt: d.now(),
+			MaxAttempts:   d.config.MaxAttempts,
+		})
+	}
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+	// An event published again, by a retried request, adds nothing
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries)
+	return int(result.RowsAffected), result.Error
+}
+
+// ===== Delivery =====
+
+// Run sends the due deliveries every poll interval, until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context, poll time.Duration) {
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		d.DeliverDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue sends a batch of the deliveries whose time came, at the same
+// time so a slow endpoint doesn't delay the others, and returns how many
+// were sent
+func (d *Dispatcher) DeliverDue(ctx context.Context) (int, error) {
+	var due []Delivery
+	err := d.db.WithContext(ctx).Preload("Endpoint").
+		Where("status = ? AND next_attempt_at <= ?", DeliveryPending, d.now()).
+		Order("next_attempt_at, id").Limit(d.config.BatchSize).
+		Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	sent := 0
+	for i := range due {
+		delivery := &due[i]
+		if !d.claim(ctx, delivery) {
+			continue // Taken by another dispatcher
+		}
+		sent++
+		wg.Go(func() {
+			if err := d.attempt(ctx, delivery); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				errs = append(errs, fmt.Errorf("delivery %d: %w", delivery.ID, err))
+			}
+		})
+	}
+	wg.Wait()
+	return sent, errors.Join(errs...)
+}
+
+// claim counts the attempt and moves the next one past its timeout, so
+// another dispatcher polling the same table leaves the delivery alone. If
+// the process dies during the request, the delivery is sent again then:
+// the event is delivered at least once.
+func (d *Dispatcher) claim(ctx context.Context, delivery *Delivery) bool {
+	result := d.db.WithContext(ctx).Model(&Delivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", delivery.ID, DeliveryPending, delivery.Attempts).
+		Updates(map[string]any{
+			"attempts":        delivery.Attempts + 1,
+			"next_attempt_at": d.now().Add(2 * d.config.Timeout),
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false
+	}
+	delivery.Attempts++
+	return true
+}
+
+// attempt sends the delivery once, records the attempt and schedules the
+// next one
+func (d *Dispatcher) attempt(ctx context.Context, delivery *Delivery) error {
+	endpoint := &delivery.Endpoint
+	start := d.now()
+	statusCode, retryAfter, sendErr := d.send(ctx, endpoint, delivery)
+	record := Attempt{
+		DeliveryID: delivery.ID,
+		At:         start,
+		StatusCode: statusCode,
+		DurationMS: d.now().Sub(start).Milliseconds(),
+	}
+	if sendErr != nil {
+		record.Error = sendErr.Error()
+	}
+
+	updates := map[string]any{"last_error": record.Error}
+	switch {
+	case sendErr == nil:
+		updates["status"] = DeliveryDelivered
+		updates["delivered_at"] = d.now()
+	case statusCode == http.StatusGone:
+		// The receiver says the endpoint no longer exists: stop sending it
+		// anything, instead of failing every event for hours
+		updates["status"] = DeliveryFailed
+		if err := d.disable(ctx, endpoint.ID, "410 Gone"); err != nil {
+			return err
+		}
+	case delivery.Attempts >= delivery.MaxAttempts:
+		updates["status"] = DeliveryFailed
+	default:
+		updates["next_attempt_at"] = d.now().Add(max(d.Backoff(delivery.Attempts), retryAfter))
+	}
+
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Delivery{}).Where("id = ?", delivery.ID).Updates(updates).Error
+	})
+}
+
+// send posts the payload to the endpoint. Only a 2xx answer counts as
+// delivered.
+func (d *Dispatcher) send(ctx context.Context, endpoint *Endpoint, delivery *Delivery) (statusCode int, retryAfter time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, 0, err
+	}
+	now := d.now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "training-webhooks/1.0")
+	req.Header.Set(HeaderID, delivery.EventID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(endpoint.Secret, delivery.EventID, now, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	// The start of the body explains the error to the endpoint owner
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, 0, nil
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+	message := resp.Status
+	if text := strings.TrimSpace(string(body)); text != "" {
+		message += ": " + text
+	}
+	return resp.StatusCode, retryAfter, errors.New(message)
+}
+
+// disable deactivates an endpoint and fails its pending deliveries
+func (d *Dispatcher) disable(ctx context.Context, endpointID uint, reason string) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&Endpoint{}).Where("id = ?", endpointID).
+			Updates(map[string]any{"active": false, "disabled_reason": reason}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&Delivery{}).
+			Where("endpoint_id = ? AND status = ?", endpointID, DeliveryPending).
+			Updates(map[string]any{"status": DeliveryFailed, "last_error": "endpoint disabled: " + reason}).Error
+	})
+}
+
+// Backoff returns the delay after the given attempt: BackoffBase, then
+// twice as long after each failure, up to BackoffMax
+func (d *Dispatcher) Backoff(attempts int) time.Duration {
+	delay := d.config.BackoffBase
+	for range attempts - 1 {
+		delay *= 2
+		if delay >= d.config.BackoffMax {
+			return d.config.BackoffMax
+		}
+	}
+	return delay
+}
+
+// Redeliver sends a failed delivery again, with all its attempts, once the
+// endpoint owner fixed their server. Its history is kept.
+func (d *Dispatcher) Redeliver(ctx context.Context, id uint) (*Delivery, error) {
+	var delivery Delivery
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Endpoint").First(&delivery, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("delivery %d: %w", id, ErrNotFound)
+			}
+			return err
+		}
+		if delivery.Status != DeliveryFailed {
+			return fmt.Errorf("delivery %d is %s: %w", id, delivery.Status, ErrNotFailed)
+		}
+		if !delivery.Endpoint.Active {
+			return fmt.Errorf("%w: endpoint %d is disabled", ErrInvalidEndpoint, delivery.EndpointID)
+		}
+		return tx.Model(&delivery).Updates(map[string]any{
+			"status":          DeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": d.now(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
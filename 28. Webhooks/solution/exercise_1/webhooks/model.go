@@ -0,0 +1,80 @@
+// Package webhooks delivers domain events to the HTTP endpoints registered
+// by other applications. Each event is stored as one delivery per endpoint,
+// signed with the secret of the endpoint, and sent again with an increasing
+// delay until the endpoint accepts it.
+package webhooks
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// Endpoint is a URL registered to receive events
+type Endpoint struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	URL            string    `gorm:"size:2048;not null" json:"url"`
+	Secret         string    `gorm:"size:100;not null" json:"-"`                       // Only shown once, when the endpoint is registered
+	Events         []string  `gorm:"serializer:json;type:text;not null" json:"events"` // Event types, or "*" for all
+	Active         bool      `gorm:"not null" json:"active"`
+	DisabledReason string    `gorm:"size:200" json:"disabled_reason,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Subscribed reports whether the endpoint receives the events of a type
+func (e *Endpoint) Subscribed(eventType string) bool {
+	return e.Active && (slices.Contains(e.Events, "*") || slices.Contains(e.Events, eventType))
+}
+
+// Event is something that happened in the application, such as an order
+// created. Its ID lets receivers drop the events they already processed:
+// delivery is at least once, so an event may arrive twice.
+type Event struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// DeliveryStatus is the state of a delivery:
+//
+//	pending --2xx--> delivered
+//	   ^       |
+//	   +-retry-+--last attempt or 410 Gone--> failed
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivery is an event to send to an endpoint. The unique index makes
+// publishing an event twice harmless.
+type Delivery struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	EndpointID    uint            `gorm:"not null;uniqueIndex:idx_deliveries_event,priority:1" json:"endpoint_id"`
+	Endpoint      Endpoint        `json:"-"`
+	EventID       string          `gorm:"size:40;not null;uniqueIndex:idx_deliveries_event,priority:2" json:"event_id"`
+	EventType     string          `gorm:"size:100;not null" json:"event_type"`
+	Payload       json.RawMessage `gorm:"type:text;not null" json:"payload"` // The body sent, the same at every attempt
+	Status        DeliveryStatus  `gorm:"size:20;not null;index:idx_deliveries_due,priority:1" json:"status"`
+	NextAttemptAt time.Time       `gorm:"not null;index:idx_deliveries_due,priority:2" json:"next_attempt_at"`
+	Attempts      int             `gorm:"not null" json:"attempts"`
+	MaxAttempts   int             `gorm:"not null" json:"max_attempts"`
+	LastError     string          `gorm:"type:text" json:"last_error,omitempty"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	History       []Attempt       `json:"history,omitempty"`
+}
+
+// Attempt records one request of a delivery, for the endpoint owner to see
+// what their server answered
+type Attempt struct {
+	ID         uint      `gorm:"primaryKey" json:"-"`
+	DeliveryID uint      `gorm:"not null;index" json:"-"`
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code,omitempty"` // 0 when no response came
+	Error      string    `gorm:"type:text" json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+}
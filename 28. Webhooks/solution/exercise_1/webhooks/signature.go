@@ -0,0 +1,71 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of a webhook request
+const (
+	HeaderID        = "Webhook-Id"        // The event ID, to drop duplicates
+	HeaderTimestamp = "Webhook-Timestamp" // Unix seconds of the attempt
+	HeaderSignature = "Webhook-Signature" // v1=<hex HMAC-SHA256>, several separated by spaces
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrStaleTimestamp is returned for a request signed too long ago: an
+	// attacker may be sending again a request they recorded
+	ErrStaleTimestamp = errors.New("webhook timestamp outside the tolerance")
+)
+
+// NewSecret returns a random signing secret
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Sign returns the signature of a request: the HMAC-SHA256, keyed with the
+// secret of the endpoint, of "id.timestamp.body". Signing the ID and the
+// timestamp with the body prevents reusing a signature for another event,
+// or later.
+func Sign(secret, id string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(id + "." + strconv.FormatInt(timestamp.Unix(), 10) + "."))
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a received request, as the receiver of a
+// webhook does. The signature header may hold several signatures: during
+// a rotation, the sender signs with the old and the new secret.
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration, now time.Time) error {
+	seconds, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	timestamp := time.Unix(seconds, 0)
+	if now.Sub(timestamp).Abs() > tolerance {
+		return ErrStaleTimestamp
+	}
+	expected := Sign(secret, header.Get(HeaderID), timestamp, body)
+	for _, signature := range strings.Fields(header.Get(HeaderSignature)) {
+		// A constant-time comparison doesn't tell an attacker how many
+		// characters they guessed right
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// maxBodySize is the largest webhook accepted. Provider events are small;
+// the limit stops a client sending gigabytes before the signature check.
+const maxBodySize = 64 << 10
+
+// WebhookHandler receives the events of the provider. It answers as soon as
+// the event is stored: the provider waits a few seconds at most, then
+// counts the delivery as failed and sends it again.
+type WebhookHandler struct {
+	Secret    string
+	Tolerance time.Duration
+	Store     *EventStore
+	Pool      *WorkerPool
+	Now       func() time.Time
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The raw bytes, as signed: no json.Decoder on r.Body before the check
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "body too large")
+		return
+	}
+	if err := VerifySignature(h.Secret, r.Header.Get(SignatureHeader), body, h.Tolerance, h.Now()); err != nil {
+		// Logged for us; the client only learns that it failed
+		log.Printf("webhook rejected from %s: %v", r.RemoteAddr, err)
+		writeError(w, http.StatusBadRequest, "invalid signature")
+		return
+	}
+
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil || event.ID == "" || event.Type == "" {
+		writeError(w, http.StatusBadRequest, "invalid event")
+		return
+	}
+
+	fresh, err := h.Store.Record(r.Context(), event, body)
+	if err != nil {
+		// 5xx: the provider sends the event again later
+		writeError(w, http.StatusInternalServerError, "storing the event")
+		return
+	}
+	if !fresh {
+		// Already received: acknowledge it, or the provider keeps retrying
+		writeJSON(w, http.StatusOK, map[string]string{"status": "duplicate"})
+		return
+	}
+	if err := h.Pool.Submit(event); err != nil {
+		// Not queued: forget it, and let the provider retry when the
+		// workers caught up
+		if forgetErr := h.Store.Forget(r.Context(), event.ID); forgetErr != nil {
+			err = errors.Join(err, forgetErr)
+		}
+		log.Printf("event %s not queued: %v", event.ID, err)
+		w.Header().Set("Retry-After", "30")
+		writeError(w, http.StatusServiceUnavailable, "busy, retry later")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "accepted"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// PaymentData is the data of the payment events
+type PaymentData struct {
+	OrderID  string `json:"order_id"`
+	Amount   int64  `json:"amount"` // In cents
+	Currency string `json:"currency"`
+}
+
+// Ledger is the state of the orders, changed by the payment events
+type Ledger struct {
+	mu     sync.Mutex
+	orders map[string]string // Order ID to status
+}
+
+// next lists the statuses an order can move to. The workers process events
+// at the same time, and the provider doesn't promise their order: the
+// refund may be processed before the payment. A refunded order stays
+// refunded, whatever arrives after.
+var next = map[string][]string{
+	"":     {"paid", "refunded"},
+	"paid": {"refunded"},
+}
+
+// handlers returns the handler of each event type. They take some time, as
+// a real handler updating a database or calling a service would.
+func (l *Ledger) handlers() map[string]EventHandler {
+	set := func(status string) EventHandler {
+		return func(ctx context.Context, event Event) error {
+			var data PaymentData
+			if err := json.Unmarshal(event.Data, &data); err != nil {
+				return err
+			}
+			if data.OrderID == "" {
+				return errors.New("no order ID")
+			}
+			time.Sleep(100 * time.Millisecond)
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if slices.Contains(next[l.orders[data.OrderID]], status) {
+				l.orders[data.OrderID] = status
+			}
+			return nil
+		}
+	}
+	return map[string]EventHandler{
+		"payment.succeeded": set("paid"),
+		"payment.refunded":  set("refunded"),
+	}
+}
+
+func openDB(path string) (*gorm.DB, error) {
+	return gorm.Open(sqlite.Open(path+"?_busy_timeout=5000&_journal_mode=WAL"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+}
+
+// requeuePending submits the events a previous run stored but didn't process
+func requeuePending(ctx context.Context, store *EventStore, pool *WorkerPool) error {
+	events, err := store.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := pool.Submit(event); err != nil {
+			return err
+		}
+	}
+	if len(events) > 0 {
+		log.Printf("Queued %d events received before the restart", len(events))
+	}
+	return nil
+}
+
+func main() {
+	dbPath := flag.String("db", "events.db", "SQLite database of the received events")
+	addr := flag.String("addr", ":8080", "address of the webhook endpoint")
+	demo := flag.Bool("demo", false, "send sample webhooks as the provider would, and print the results")
+	flag.Parse()
+
+	db, err := openDB(*dbPath)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	store, err := NewEventStore(db)
+	if err != nil {
+		log.Fatalf("Failed to create the event store: %v", err)
+	}
+	ledger := &Ledger{orders: make(map[string]string)}
+	pool := NewWorkerPool(4, 100, store, ledger.handlers())
+	if *demo {
+		runDemo(db, store, pool, ledger)
+		return
+	}
+
+	secret := os.Getenv("WEBHOOK_SECRET")
+	if secret == "" {
+		log.Fatal("WEBHOOK_SECRET is not set")
+	}
+	if err := requeuePending(context.Background(), store, pool); err != nil {
+		log.Fatalf("Failed to queue the pending events: %v", err)
+	}
+	handler := &WebhookHandler{Secret: secret, Tolerance: 5 * time.Minute, Store: store, Pool: pool, Now: time.Now}
+	mux := http.NewServeMux()
+	mux.Handle("POST /webhooks/provider", handler)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	server := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		// The server first, so no event is submitted to a closed pool
+		server.Shutdown(shutdownCtx)
+		if err := pool.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Stopping the workers: %v", err)
+		}
+	}()
+	log.Printf("Receiving webhooks on %s/webhooks/provider", *addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+	<-ctx.Done()
+}
+
+func runDemo(db *gorm.DB, store *EventStore, pool *WorkerPool, ledger *Ledger) {
+	ctx := context.Background()
+	db.Where("1 = 1").Delete(&ReceivedEvent{})
+	log.SetFlags(0)
+	const secret = "whsec_demo"
+
+	handler := &WebhookHandler{Secret: secret, Tolerance: 5 * time.Minute, Store: store, Pool: pool, Now: time.Now}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	event := func(id, eventType, orderID string, amount int64) []byte {
+		data, _ := json.Marshal(PaymentData{OrderID: orderID, Amount: amount, Currency: "usd"})
+		body, _ := json.Marshal(Event{ID: id, Type: eventType, Created: time.Now().Unix(), Data: data})
+		return body
+	}
+	// send posts a body with a signature header, as the provider does
+	send := func(label string, body []byte, signature string) {
+		req, _ := http.NewRequest(http.MethodPost, server.URL, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if signature != "" {
+			req.Header.Set(SignatureHeader, signature)
+		}
+		start := time.Now()
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer resp.Body.Close()
+		answer, _ := io.ReadAll(resp.Body)
+		fmt.Printf("%-34s -> %d in %-6v %s", label, resp.StatusCode, time.Since(start).Round(time.Millisecond), answer)
+	}
+
+	fmt.Println("--- Deliveries ---")
+	paid := event("evt_001", "payment.succeeded", "A1001", 12999)
+	send("payment.succeeded A1001", paid, SignatureHeaderValue(secret, time.Now(), paid))
+	// The provider didn't get the answer in time, and sends it again
+	send("same event, sent again", paid, SignatureHeaderValue(secret, time.Now(), paid))
+
+	second := event("evt_002", "payment.succeeded", "A1002", 4500)
+	send("payment.succeeded A1002", second, SignatureHeaderValue(secret, time.Now(), second))
+	refund := event("evt_003", "payment.refunded", "A1001", 12999)
+	send("payment.refunded A1001", refund, SignatureHeaderValue(secret, time.Now(), refund))
+	unknown := event("evt_004", "customer.updated", "", 0)
+	send("customer.updated, not handled", unknown, SignatureHeaderValue(secret, time.Now(), unknown))
+	broken := event("evt_005", "payment.succeeded", "", 100)
+	send("payment.succeeded without order", broken, SignatureHeaderValue(secret, time.Now(), broken))
+
+	fmt.Println("\n--- Rejected ---")
+	send("no signature", paid, "")
+	send("signed with another secret", paid, SignatureHeaderValue("whsec_guessed", time.Now(), paid))
+	// An attacker changes the amount of a request they intercepted
+	tampered := bytes.Replace(second, []byte("4500"), []byte("1"), 1)
+	send("body changed after signing", tampered, SignatureHeaderValue(secret, time.Now(), second))
+	// A valid request recorded 10 minutes ago, sent again
+	replayed := event("evt_006", "payment.succeeded", "A1003", 999)
+	send("replay of a request 10 minutes old", replayed, SignatureHeaderValue(secret, time.Now().Add(-10*time.Minute), replayed))
+
+	// Decoding the body and encoding it again changes its bytes: verify the
+	// raw body, never a re-encoded one
+	var decoded map[string]any
+	json.Unmarshal(second, &decoded)
+	reencoded, _ := json.Marshal(decoded)
+	err := VerifySignature(secret, SignatureHeaderValue(secret, time.Now(), second), reencoded, time.Minute, time.Now())
+	fmt.Printf("\nVerifying a re-encoded body: %v\n", err)
+
+	// The answers came before the workers finished; wait for them
+	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := pool.Shutdown(shutdownCtx); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("\n--- Received Events ---")
+	events, err := store.All(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, e := range events {
+		fmt.Printf("%s %-18s %-9s %s\n", e.EventID, e.Type, e.Status, e.Error)
+	}
+	fmt.Println("\n--- Orders ---")
+	for _, id := range []string{"A1001", "A1002", "A1003"} {
+		status := ledger.orders[id]
+		if status == "" {
+			status = "unknown"
+		}
+		fmt.Printf("%s %s\n", id, status)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+var (
+	ErrPoolClosed = errors.New("worker pool is shutting down")
+	ErrQueueFull  = errors.New("event queue is full")
+)
+
+// EventHandler processes one event type
+type EventHandler func(ctx context.Context, event Event) error
+
+// WorkerPool processes the received events in the background, as the pool
+// of module 20: the webhook request only stores the event and queues it,
+// so the provider gets its answer before its timeout.
+type WorkerPool struct {
+	events   chan Event
+	store    *EventStore
+	handlers map[string]EventHandler
+	wg       sync.WaitGroup
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewWorkerPool starts n workers
+func NewWorkerPool(n, queueSize int, store *EventStore, handlers map[string]EventHandler) *WorkerPool {
+	p := &WorkerPool{events: make(chan Event, queueSize), store: store, handlers: handlers}
+	for id := range n {
+		p.wg.Go(func() { p.worker(id + 1) })
+	}
+	return p
+}
+
+func (p *WorkerPool) worker(id int) {
+	for event := range p.events {
+		err := p.process(event)
+		if err != nil {
+			log.Printf("Worker %d: event %s (%s) failed: %v", id, event.ID, event.Type, err)
+		}
+		if err := p.store.Finish(context.Background(), event.ID, err); err != nil {
+			log.Printf("Worker %d: recording event %s: %v", id, event.ID, err)
+		}
+	}
+}
+
+// process runs the handler of the event. Events of other types are
+// acknowledged and ignored: providers add types, and answering an error
+// would only make them retry.
+func (p *WorkerPool) process(event Event) (err error) {
+	handler, ok := p.handlers[event.Type]
+	if !ok {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(context.Background(), event)
+}
+
+// Submit queues an event without blocking
+func (p *WorkerPool) Submit(event Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	select {
+	case p.events <- event:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Shutdown stops accepting events and waits until the workers processed the
+// queued ones, or until ctx is done. The events not processed stay received
+// in the store, and are queued again at the next start.
+func (p *WorkerPool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.events)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d events not processed: %w", len(p.events), ctx.Err())
+	}
+}
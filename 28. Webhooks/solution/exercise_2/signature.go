@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader is set by the provider on every webhook, as
+// Stripe-Signature: "t=1792228323,v1=5f0c...". There may be several v1
+// values while the provider rotates the secret.
+const SignatureHeader = "Provider-Signature"
+
+var (
+	ErrMissingSignature = errors.New("missing signature header")
+	ErrInvalidSignature = errors.New("signature does not match the body")
+	// ErrTimestampOutOfRange is returned for a request signed too long ago,
+	// or in the future: a valid request recorded and sent again later is a
+	// replay
+	ErrTimestampOutOfRange = errors.New("signature timestamp outside the tolerance")
+)
+
+// computeSignature returns the hex HMAC-SHA256 of "timestamp.body"
+func computeSignature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignatureHeaderValue builds the header the provider sends, used by the
+// demo to play the provider
+func SignatureHeaderValue(secret string, timestamp time.Time, body []byte) string {
+	t := timestamp.Unix()
+	return "t=" + strconv.FormatInt(t, 10) + ",v1=" + computeSignature(secret, t, body)
+}
+
+// VerifySignature checks that body was signed by the provider less than
+// tolerance ago. body must be the raw bytes received: decoding the JSON and
+// encoding it again changes the spacing or the order of the keys, and the
+// signature no longer matches.
+func VerifySignature(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	if header == "" {
+		return ErrMissingSignature
+	}
+	var timestamp int64
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			t, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return ErrInvalidSignature
+			}
+			timestamp = t
+		case "v1":
+			signatures = append(signatures, value)
+		}
+		// Other schemes, such as a future v2, are ignored
+	}
+	if timestamp == 0 || len(signatures) == 0 {
+		return ErrMissingSignature
+	}
+	// The timestamp is part of the signed data: an attacker can't make an
+	// old request look recent without the secret
+	if now.Sub(time.Unix(timestamp, 0)).Abs() > tolerance {
+		return ErrTimestampOutOfRange
+	}
+	expected := []byte(computeSignature(secret, timestamp, body))
+	for _, signature := range signatures {
+		// Constant time: the time taken doesn't reveal how much of the
+		// signature was right
+		if hmac.Equal([]byte(signature), expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Event is the payload of the provider, such as:
+//
+//	{"id": "evt_1", "type": "payment.succeeded", "created": 1792228323, "data": {...}}
+type Event struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Created int64           `json:"created"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// EventStatus is the state of a received event
+type EventStatus string
+
+const (
+	EventReceived  EventStatus = "received" // Stored, waiting for a worker
+	EventProcessed EventStatus = "processed"
+	EventFailed    EventStatus = "failed"
+)
+
+// ReceivedEvent is a row of the received_events table. The primary key is
+// the event ID of the provider: an event sent twice is stored once.
+type ReceivedEvent struct {
+	EventID     string          `gorm:"primaryKey;size:100"`
+	Type        string          `gorm:"size:100;not null"`
+	Payload     json.RawMessage `gorm:"type:text;not null"`
+	Status      EventStatus     `gorm:"size:20;not null;index"`
+	Error       string          `gorm:"type:text"`
+	ReceivedAt  time.Time       `gorm:"not null"`
+	ProcessedAt *time.Time
+}
+
+// EventStore remembers the events received, to process each one once
+type EventStore struct {
+	db *gorm.DB
+}
+
+func NewEventStore(db *gorm.DB) (*EventStore, error) {
+	if err := db.AutoMigrate(&ReceivedEvent{}); err != nil {
+		return nil, err
+	}
+	return &EventStore{db: db}, nil
+}
+
+// Record stores an event, and reports false when it was already received.
+// The insert is the check: two deliveries of the same event arriving at
+// the same time can't both see it as new.
+func (s *EventStore) Record(ctx context.Context, event Event, payload []byte) (bool, error) {
+	result := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&ReceivedEvent{
+		EventID:    event.ID,
+		Type:       event.Type,
+		Payload:    payload,
+		Status:     EventReceived,
+		ReceivedAt: time.Now().UTC(),
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Forget deletes an event that couldn't be handed to the workers, so the
+// provider's next attempt is accepted as new
+func (s *EventStore) Forget(ctx context.Context, eventID string) error {
+	return s.db.WithContext(ctx).Where("event_id = ? AND status = ?", eventID, EventReceived).Delete(&ReceivedEvent{}).Error
+}
+
+// Finish records the result of processing an event
+func (s *EventStore) Finish(ctx context.Context, eventID string, cause error) error {
+	now := time.Now().UTC()
+	updates := map[string]any{"status": EventProcessed, "processed_at": now, "error": ""}
+	if cause != nil {
+		updates["status"] = EventFailed
+		updates["error"] = cause.Error()
+	}
+	return s.db.WithContext(ctx).Model(&ReceivedEvent{}).Where("event_id = ?", eventID).Updates(updates).Error
+}
+
+// Pending returns the events stored but not processed, left by a process
+// stopped before its workers got to them
+func (s *EventStore) Pending(ctx context.Context) ([]Event, error) {
+	var rows []ReceivedEvent
+	if err := s.db.WithContext(ctx).Where("status = ?", EventReceived).Order("received_at").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	events := make([]Event, 0, len(rows))
+	for _, row := range rows {
+		var event Event
+		if err := json.Unmarshal(row.Payload, &event); err != nil {
+			return nil, errors.Join(err, s.Finish(ctx, row.EventID, err))
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// All returns the events received, the oldest first
+func (s *EventStore) All(ctx context.Context) ([]ReceivedEvent, error) {
+	var rows []ReceivedEvent
+	err := s.db.WithContext(ctx).Order("received_at, event_id").Find(&rows).Error
+	return rows, err
+}
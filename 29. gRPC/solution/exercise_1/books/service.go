@@ -0,0 +1,175 @@
+// Package books implements the BookService. It knows nothing about the
+// protocols: the gRPC server and the REST layer both call it, and it
+// reports its errors as gRPC statuses that the REST layer maps to HTTP.
+package books
+
+import (
+	"context"
+	"encoding/base64"
+	"slices"
+	"strconv"
+	"sync"
+
+	bookv1 "golang-training/module-29/exercise-1/proto/book/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Service keeps the books in memory
+type Service struct {
+	// Embedding the generated struct makes the methods added to the proto
+	// later answer UNIMPLEMENTED instead of breaking the build
+	bookv1.UnimplementedBookServiceServer
+
+	mu     sync.Mutex
+	books  map[int64]*bookv1.Book
+	nextID int64
+}
+
+// NewService returns a service with the books of module 11
+func NewService() *Service {
+	s := &Service{books: make(map[int64]*bookv1.Book), nextID: 1}
+	for _, book := range []*bookv1.Book{
+		{Title: "The Go Programming Language", Author: "Alan Donovan & Brian Kernighan", Year: 2015, Isbn: "9780134190440"},
+		{Title: "Go in Action", Author: "William Kennedy", Year: 2016, Isbn: "9781617291784"},
+	} {
+		book.Id = s.nextID
+		s.books[book.Id] = book
+		s.nextID++
+	}
+	return s
+}
+
+// notFound is the error of an unknown ID, the same for every method
+func notFound(id int64) error {
+	return status.Errorf(codes.NotFound, "book %d not found", id)
+}
+
+// isbnTaken reports whether another book than id has the ISBN. The caller
+// holds the lock.
+func (s *Service) isbnTaken(isbn string, id int64) bool {
+	for _, book := range s.books {
+		if book.Isbn == isbn && book.Id != id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) GetBook(ctx context.Context, req *bookv1.GetBookRequest) (*bookv1.Book, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	book, ok := s.books[req.GetId()]
+	if !ok {
+		return nil, notFound(req.GetId())
+	}
+	// The stored message must not be shared with the caller, who may
+	// modify it
+	return proto.Clone(book).(*bookv1.Book), nil
+}
+
+// encodePageToken hides the position of a page from clients, so it can
+// change without breaking them: they only send back what they received
+func encodePageToken(afterID int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(afterID, 10)))
+}
+
+func decodePageToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		var id int64
+		if id, err = strconv.ParseInt(string(data), 10, 64); err == nil {
+			return id, nil
+		}
+	}
+	return 0, status.Error(codes.InvalidArgument, "invalid page_token")
+}
+
+func (s *Service) ListBooks(ctx context.Context, req *bookv1.ListBooksRequest) (*bookv1.ListBooksResponse, error) {
+	pageSize := int(req.GetPageSize())
+	switch {
+	case pageSize < 0:
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	case pageSize == 0:
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+	afterID, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matching []*bookv1.Book
+	for _, book := range s.books {
+		if book.Id > afterID && (req.GetAuthor() == "" || book.Author == req.GetAuthor()) {
+			matching = append(matching, book)
+		}
+	}
+	slices.SortFunc(matching, func(a, b *bookv1.Book) int { return int(a.Id - b.Id) })
+
+	resp := &bookv1.ListBooksResponse{}
+	for i, book := range matching {
+		if i == pageSize {
+			resp.NextPageToken = encodePageToken(resp.Books[i-1].Id)
+			break
+		}
+		resp.Books = append(resp.Books, proto.Clone(book).(*bookv1.Book))
+	}
+	return resp, nil
+}
+
+func (s *Service) CreateBook(ctx context.Context, req *bookv1.CreateBookRequest) (*bookv1.Book, error) {
+	if err := ValidateBook(req.GetBook()); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isbnTaken(req.GetBook().GetIsbn(), 0) {
+		return nil, status.Errorf(codes.AlreadyExists, "a book with ISBN %s already exists", req.GetBook().GetIsbn())
+	}
+	book := proto.Clone(req.GetBook()).(*bookv1.Book)
+	book.Id = s.nextID
+	s.nextID++
+	s.books[book.Id] = book
+	return proto.Clone(book).(*bookv1.Book), nil
+}
+
+func (s *Service) UpdateBook(ctx context.Context, req *bookv1.UpdateBookRequest) (*bookv1.Book, error) {
+	if err := ValidateBook(req.GetBook()); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := req.GetBook().GetId()
+	if _, ok := s.books[id]; !ok {
+		return nil, notFound(id)
+	}
+	if s.isbnTaken(req.GetBook().GetIsbn(), id) {
+		return nil, status.Errorf(codes.AlreadyExists, "a book with ISBN %s already exists", req.GetBook().GetIsbn())
+	}
+	book := proto.Clone(req.GetBook()).(*bookv1.Book)
+	s.books[id] = book
+	return proto.Clone(book).(*bookv1.Book), nil
+}
+
+func (s *Service) DeleteBook(ctx context.Context, req *bookv1.DeleteBookRequest) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.books[req.GetId()]; !ok {
+		return nil, notFound(req.GetId())
+	}
+	delete(s.books, req.GetId())
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,71 @@
+package books
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	bookv1 "golang-training/module-29/exercise-1/proto/book/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// validISBN reports whether isbn is an ISBN-13: 13 digits whose weighted
+// sum, with weights 1 and 3 in turn, is a multiple of 10
+func validISBN(isbn string) bool {
+	if len(isbn) != 13 {
+		return false
+	}
+	sum := 0
+	for i, r := range isbn {
+		if r < '0' || r > '9' {
+			return false
+		}
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(r-'0') * weight
+	}
+	return sum%10 == 0
+}
+
+// ValidateBook checks the fields of a book set by clients. Both protocols
+// call it through the service, so a gRPC and a REST client get the same
+// violations: an INVALID_ARGUMENT status with a BadRequest detail listing
+// every invalid field, which the REST layer turns into a 400 body.
+func ValidateBook(book *bookv1.Book) error {
+	if book == nil {
+		return status.Error(codes.InvalidArgument, "book is required")
+	}
+	var violations []*errdetails.BadRequest_FieldViolation
+	invalid := func(field, description string) {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: field, Description: description})
+	}
+
+	if title := strings.TrimSpace(book.GetTitle()); title == "" {
+		invalid("title", "required")
+	} else if utf8.RuneCountInString(title) > 200 {
+		invalid("title", "at most 200 characters")
+	}
+	if strings.TrimSpace(book.GetAuthor()) == "" {
+		invalid("author", "required")
+	}
+	if year := int(book.GetYear()); year < 1450 || year > time.Now().Year()+1 {
+		invalid("year", fmt.Sprintf("must be between 1450 and %d", time.Now().Year()+1))
+	}
+	if !validISBN(book.GetIsbn()) {
+		invalid("isbn", "must be an ISBN-13 of 13 digits with a valid check digit")
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	st, err := status.New(codes.InvalidArgument, "invalid book").WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "invalid book")
+	}
+	return st.Err()
+}
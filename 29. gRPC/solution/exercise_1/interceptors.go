@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Chain combines interceptors into one, the first one being the outermost.
+// The gRPC server and the REST handler share the result.
+func Chain(interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		next := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, inner := interceptors[i], next
+			next = func(ctx context.Context, req any) (any, error) {
+				return interceptor(ctx, req, info, inner)
+			}
+		}
+		return next(ctx, req)
+	}
+}
+
+// Recover turns a panic of a method into an INTERNAL error, instead of
+// crashing the server
+func Recover(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("panic in %s: %v\n%s", info.FullMethod, r, debug.Stack())
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// Log logs one line per call with its protocol, method, code and duration
+func Log(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	// grpc.Method only knows the calls received by the gRPC server
+	protocol := "rest"
+	if _, ok := grpc.Method(ctx); ok {
+		protocol = "grpc"
+	}
+	log.Printf("[%s] %s %s %v", protocol, info.FullMethod, status.Code(err), time.Since(start).Round(time.Microsecond))
+	return resp, err
+}
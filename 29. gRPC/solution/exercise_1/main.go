@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"golang-training/module-29/exercise-1/books"
+	bookv1 "golang-training/module-29/exercise-1/proto/book/v1"
+	"golang-training/module-29/exercise-1/rest"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// Servers serves one BookService over gRPC and over REST
+type Servers struct {
+	GRPC *grpc.Server
+	HTTP *http.Server
+}
+
+// NewServers creates the servers of svc. Both protocols go through the same
+// interceptors and the same service, so they validate the same way and
+// return the same errors.
+func NewServers(svc bookv1.BookServiceServer) *Servers {
+	interceptor := Chain(Recover, Log)
+
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(interceptor))
+	bookv1.RegisterBookServiceServer(grpcServer, svc)
+	// Reflection lets tools such as grpcurl list and call the methods
+	// without the .proto file
+	reflection.Register(grpcServer)
+
+	httpServer := &http.Server{
+		Handler:           rest.NewHandler(svc, interceptor),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return &Servers{GRPC: grpcServer, HTTP: httpServer}
+}
+
+// Serve serves gRPC and REST on their listeners until one of them fails
+func (s *Servers) Serve(grpcListener, httpListener net.Listener) error {
+	errs := make(chan error, 2)
+	go func() { errs <- s.GRPC.Serve(grpcListener) }()
+	go func() { errs <- s.HTTP.Serve(httpListener) }()
+	return <-errs
+}
+
+// Shutdown lets the calls in progress finish on both protocols
+func (s *Servers) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.GRPC.GracefulStop()
+		close(stopped)
+	}()
+	err := s.HTTP.Shutdown(ctx)
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.GRPC.Stop()
+	}
+	return err
+}
+
+// ===== Demo =====
+
+// describe prints the code, the message and the invalid fields of an error
+func describe(err error) string {
+	st := status.Convert(err)
+	text := st.Code().String() + ": " + st.Message()
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			var fields []string
+			for _, v := range badRequest.GetFieldViolations() {
+				fields = append(fields, v.GetField()+" "+v.GetDescription())
+			}
+			text += " [" + strings.Join(fields, "; ") + "]"
+		}
+	}
+	return text
+}
+
+// scenario runs the same calls with a client of either protocol: the
+// generated interface hides which one it is
+func scenario(ctx context.Context, client bookv1.BookServiceClient) {
+	report := func(call string, result string, err error) {
+		if err != nil {
+			result = describe(err)
+		}
+		fmt.Printf("  %-30s -> %s\n", call, result)
+	}
+
+	book := &bookv1.Book{Title: "Learning Go", Author: "Jon Bodner", Year: 2021, Isbn: "9781492077213"}
+	created, err := client.CreateBook(ctx, &bookv1.CreateBookRequest{Book: book})
+	report("CreateBook", fmt.Sprintf("created book %d", created.GetId()), err)
+
+	_, err = client.CreateBook(ctx, &bookv1.CreateBookRequest{Book: &bookv1.Book{Author: "Nobody", Year: 3000, Isbn: "9781492077214"}})
+	report("CreateBook invalid", "", err)
+
+	_, err = client.CreateBook(ctx, &bookv1.CreateBookRequest{Book: book})
+	report("CreateBook same ISBN", "", err)
+
+	got, err := client.GetBook(ctx, &bookv1.GetBookRequest{Id: created.GetId()})
+	report("GetBook", fmt.Sprintf("%q by %s", got.GetTitle(), got.GetAuthor()), err)
+
+	page, err := client.ListBooks(ctx, &bookv1.ListBooksRequest{PageSize: 2})
+	report("ListBooks page_size=2", fmt.Sprintf("%d books, next page %q", len(page.GetBooks()), page.GetNextPageToken()), err)
+	page, err = client.ListBooks(ctx, &bookv1.ListBooksRequest{PageSize: 2, PageToken: page.GetNextPageToken()})
+	report("ListBooks next page", fmt.Sprintf("%d books, next page %q", len(page.GetBooks()), page.GetNextPageToken()), err)
+	_, err = client.ListBooks(ctx, &bookv1.ListBooksRequest{PageToken: "forged"})
+	report("ListBooks forged token", "", err)
+
+	book.Id, book.Year = created.GetId(), 2024
+	updated, err := client.UpdateBook(ctx, &bookv1.UpdateBookRequest{Book: book})
+	report("UpdateBook", fmt.Sprintf("year %d", updated.GetYear()), err)
+
+	_, err = client.DeleteBook(ctx, &bookv1.DeleteBookRequest{Id: created.GetId()})
+	report("DeleteBook", "deleted", err)
+	_, err = client.GetBook(ctx, &bookv1.GetBookRequest{Id: created.GetId()})
+	report("GetBook deleted", "", err)
+}
+
+func runDemo() {
+	// The interceptor logs the calls of both protocols
+	log.SetFlags(0)
+	log.SetOutput(os.Stdout)
+	log.SetPrefix("    log: ")
+
+	grpcListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatal(err)
+	}
+	httpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatal(err)
+	}
+	servers := NewServers(books.NewService())
+	go servers.Serve(grpcListener, httpListener)
+	defer servers.Shutdown(context.Background())
+
+	conn, err := grpc.NewClient(grpcListener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	defer conn.Close()
+	baseURL := "http://" + httpListener.Addr().String()
+
+	ctx := context.Background()
+	fmt.Println("--- gRPC client ---")
+	scenario(ctx, bookv1.NewBookServiceClient(conn))
+	fmt.Println("--- REST client ---")
+	scenario(ctx, rest.NewClient(baseURL))
+
+	fmt.Println("--- Raw HTTP ---")
+	for _, r := range []struct{ method, path, body string }{
+		{"GET", "/v1/books/1", ""},
+		{"GET", "/v1/books/999", ""},
+		{"GET", "/v1/books/abc", ""},
+		{"POST", "/v1/books", `{"title": "Go", "autor": "typo"}`},
+		{"POST", "/v1/books", `{"title": "", "author": "A", "year": 2020, "isbn": "123"}`},
+		{"PATCH", "/v1/books/1", ""},
+	} {
+		req, _ := http.NewRequest(r.method, baseURL+r.path, strings.NewReader(r.body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Printf("  %-6s %-14s -> %s\n    %s\n", r.method, r.path, resp.Status, strings.TrimSpace(string(body)))
+	}
+}
+
+func main() {
+	grpcAddr := flag.String("grpc-addr", ":9090", "address of the gRPC server")
+	httpAddr := flag.String("http-addr", ":8080", "address of the REST server")
+	demo := flag.Bool("demo", false, "call the service with a gRPC and a REST client")
+	flag.Parse()
+
+	if *demo {
+		runDemo()
+		return
+	}
+
+	grpcListener, err := net.Listen("tcp", *grpcAddr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	httpListener, err := net.Listen("tcp", *httpAddr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	servers := NewServers(books.NewService())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		log.Printf("gRPC on %s, try: grpcurl -plaintext localhost%s list", *grpcAddr, *grpcAddr)
+		log.Printf("REST on %s, try: curl localhost%s/v1/books", *httpAddr, *httpAddr)
+		if err := servers.Serve(grpcListener, httpListener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := servers.Shutdown(shutdownCtx); err != nil {
+		log.Fatal(err)
+	}
+}
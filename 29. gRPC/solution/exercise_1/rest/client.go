@@ -0,0 +1,129 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	bookv1 "golang-training/module-29/exercise-1/proto/book/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Client calls the REST API. It implements the generated
+// bookv1.BookServiceClient interface, and returns the same status errors
+// as the gRPC client, so code written against the interface works with
+// both protocols.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+var _ bookv1.BookServiceClient = (*Client)(nil)
+
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), HTTPClient: http.DefaultClient}
+}
+
+// do sends a request with in as the JSON body, and decodes the response
+// into out. The call options of gRPC don't apply and are ignored.
+func (c *Client) do(ctx context.Context, method, path string, in, out proto.Message) error {
+	var body io.Reader
+	if in != nil {
+		data, err := protojson.Marshal(in)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+
+	if resp.StatusCode >= 400 {
+		var errorBody ErrorBody
+		json.Unmarshal(data, &errorBody) // A body from a proxy may not be JSON
+		return statusOf(resp.StatusCode, errorBody)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	// Fields added to the API later are ignored, so old clients keep working
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, out); err != nil {
+		return status.Errorf(codes.Internal, "invalid response: %v", err)
+	}
+	return nil
+}
+
+func (c *Client) GetBook(ctx context.Context, in *bookv1.GetBookRequest, _ ...grpc.CallOption) (*bookv1.Book, error) {
+	book := &bookv1.Book{}
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/v1/books/%d", in.GetId()), nil, book); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func (c *Client) ListBooks(ctx context.Context, in *bookv1.ListBooksRequest, _ ...grpc.CallOption) (*bookv1.ListBooksResponse, error) {
+	query := url.Values{}
+	if in.GetPageSize() != 0 {
+		query.Set("page_size", strconv.Itoa(int(in.GetPageSize())))
+	}
+	if in.GetPageToken() != "" {
+		query.Set("page_token", in.GetPageToken())
+	}
+	if in.GetAuthor() != "" {
+		query.Set("author", in.GetAuthor())
+	}
+	resp := &bookv1.ListBooksResponse{}
+	if err := c.do(ctx, http.MethodGet, "/v1/books?"+query.Encode(), nil, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) CreateBook(ctx context.Context, in *bookv1.CreateBookRequest, _ ...grpc.CallOption) (*bookv1.Book, error) {
+	book := &bookv1.Book{}
+	if err := c.do(ctx, http.MethodPost, "/v1/books", in.GetBook(), book); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func (c *Client) UpdateBook(ctx context.Context, in *bookv1.UpdateBookRequest, _ ...grpc.CallOption) (*bookv1.Book, error) {
+	book := &bookv1.Book{}
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/v1/books/%d", in.GetBook().GetId()), in.GetBook(), book); err != nil {
+		return nil, err
+	}
+	return book, nil
+}
+
+func (c *Client) DeleteBook(ctx context.Context, in *bookv1.DeleteBookRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	if err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/v1/books/%d", in.GetId()), nil, nil); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
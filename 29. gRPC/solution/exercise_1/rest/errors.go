@@ -0,0 +1,142 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// HTTPStatus returns the HTTP status of a gRPC code, as grpc-gateway and
+// the Google APIs map them
+func HTTPStatus(c codes.Code) int {
+	switch c {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // Client Closed Request, from nginx
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default: // Unknown, Internal, DataLoss
+		return http.StatusInternalServerError
+	}
+}
+
+// Code returns the gRPC code of an HTTP status. Several codes share a
+// status, so the mapping loses information: the error body carries the
+// exact code, and Code is only the fallback for the responses without
+// one, such as the errors of a proxy.
+func Code(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case 499:
+		return codes.Canceled
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	switch {
+	case httpStatus < 400:
+		return codes.OK
+	case httpStatus < 500:
+		return codes.FailedPrecondition
+	default:
+		return codes.Internal
+	}
+}
+
+// FieldViolation is an invalid field of a request
+type FieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// ErrorBody is the JSON body of the errors, in the format of the Google
+// APIs:
+//
+//	{"error": {"code": 404, "status": "NOT_FOUND", "message": "book 9 not found"}}
+type ErrorBody struct {
+	Error struct {
+		Code    int              `json:"code"`   // HTTP status
+		Status  string           `json:"status"` // Name of the gRPC code
+		Message string           `json:"message"`
+		Details []FieldViolation `json:"details,omitempty"`
+	} `json:"error"`
+}
+
+// writeError answers the status of err: its HTTP status, its code and the
+// invalid fields of its BadRequest detail
+func writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	var body ErrorBody
+	body.Error.Code = HTTPStatus(st.Code())
+	body.Error.Status = code.Code(st.Code()).String()
+	body.Error.Message = st.Message()
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range badRequest.GetFieldViolations() {
+				body.Error.Details = append(body.Error.Details, FieldViolation{Field: v.GetField(), Description: v.GetDescription()})
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(body.Error.Code)
+	json.NewEncoder(w).Encode(body)
+}
+
+// statusOf turns an error body back into the status of the service
+func statusOf(httpStatus int, body ErrorBody) error {
+	c := Code(httpStatus)
+	if value, ok := code.Code_value[body.Error.Status]; ok {
+		c = codes.Code(value)
+	}
+	message := body.Error.Message
+	if message == "" {
+		message = http.StatusText(httpStatus)
+	}
+	st := status.New(c, message)
+	if len(body.Error.Details) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, v := range body.Error.Details {
+			badRequest.FieldViolations = append(badRequest.FieldViolations,
+				&errdetails.BadRequest_FieldViolation{Field: v.Field, Description: v.Description})
+		}
+		if withDetails, err := st.WithDetails(badRequest); err == nil {
+			st = withDetails
+		}
+	}
+	return st.Err()
+}
@@ -0,0 +1,199 @@
+// Package rest serves the BookService as JSON over HTTP, translating each
+// route to a method of the service by hand, as grpc-gateway generates it
+// from annotations in the proto:
+//
+//	GET    /v1/books/{id}  GetBook
+//	GET    /v1/books       ListBooks, with page_size, page_token and author
+//	POST   /v1/books       CreateBook, with the book as the body
+//	PUT    /v1/books/{id}  UpdateBook, with the book as the body
+//	DELETE /v1/books/{id}  DeleteBook
+//
+// The service is called in process through the interceptor of the gRPC
+// server, so logging and panic recovery behave the same on both protocols.
+package rest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	bookv1 "golang-training/module-29/exercise-1/proto/book/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxBody is the largest request body read
+const maxBody = 1 << 20
+
+var (
+	// JSON names are the names of the proto fields, such as page_size,
+	// like the other APIs of the course. Fields with their zero value are
+	// sent too, so clients don't have to know the defaults.
+	marshal   = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+	unmarshal = protojson.UnmarshalOptions{}
+)
+
+// Handler serves the REST API of a BookService
+type Handler struct {
+	svc         bookv1.BookServiceServer
+	interceptor grpc.UnaryServerInterceptor
+	mux         *http.ServeMux
+}
+
+// NewHandler returns the REST API of svc. interceptor is the one of the
+// gRPC server, or nil.
+func NewHandler(svc bookv1.BookServiceServer, interceptor grpc.UnaryServerInterceptor) *Handler {
+	h := &Handler{svc: svc, interceptor: interceptor, mux: http.NewServeMux()}
+
+	h.mux.HandleFunc("GET /v1/books/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := pathID(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		book, err := invoke(h, r.Context(), bookv1.BookService_GetBook_FullMethodName,
+			&bookv1.GetBookRequest{Id: id}, h.svc.GetBook)
+		respond(w, http.StatusOK, book, err)
+	})
+
+	h.mux.HandleFunc("GET /v1/books", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		req := &bookv1.ListBooksRequest{PageToken: query.Get("page_token"), Author: query.Get("author")}
+		if value := query.Get("page_size"); value != "" {
+			size, err := strconv.ParseInt(value, 10, 32)
+			if err != nil {
+				writeError(w, status.Error(codes.InvalidArgument, "page_size must be a number"))
+				return
+			}
+			req.PageSize = int32(size)
+		}
+		resp, err := invoke(h, r.Context(), bookv1.BookService_ListBooks_FullMethodName, req, h.svc.ListBooks)
+		respond(w, http.StatusOK, resp, err)
+	})
+
+	h.mux.HandleFunc("POST /v1/books", func(w http.ResponseWriter, r *http.Request) {
+		book := &bookv1.Book{}
+		if err := readMessage(r, book); err != nil {
+			writeError(w, err)
+			return
+		}
+		book.Id = 0 // Chosen by the server
+		created, err := invoke(h, r.Context(), bookv1.BookService_CreateBook_FullMethodName,
+			&bookv1.CreateBookRequest{Book: book}, h.svc.CreateBook)
+		if err == nil {
+			w.Header().Set("Location", fmt.Sprintf("/v1/books/%d", created.GetId()))
+		}
+		respond(w, http.StatusCreated, created, err)
+	})
+
+	h.mux.HandleFunc("PUT /v1/books/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := pathID(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		book := &bookv1.Book{}
+		if err := readMessage(r, book); err != nil {
+			writeError(w, err)
+			return
+		}
+		if book.Id != 0 && book.Id != id {
+			writeError(w, status.Error(codes.InvalidArgument, "the id of the body differs from the id of the path"))
+			return
+		}
+		book.Id = id
+		updated, err := invoke(h, r.Context(), bookv1.BookService_UpdateBook_FullMethodName,
+			&bookv1.UpdateBookRequest{Book: book}, h.svc.UpdateBook)
+		respond(w, http.StatusOK, updated, err)
+	})
+
+	h.mux.HandleFunc("DELETE /v1/books/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := pathID(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		_, err = invoke(h, r.Context(), bookv1.BookService_DeleteBook_FullMethodName,
+			&bookv1.DeleteBookRequest{Id: id}, h.svc.DeleteBook)
+		respond(w, http.StatusNoContent, nil, err)
+	})
+
+	// Unknown routes get an error body like the others
+	h.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, status.Errorf(codes.NotFound, "no route for %s %s", r.Method, r.URL.Path))
+	})
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// invoke calls a method of the service through the interceptor, as the
+// gRPC server does
+func invoke[Req, Resp any](h *Handler, ctx context.Context, method string, req Req, call func(context.Context, Req) (Resp, error)) (Resp, error) {
+	handler := func(ctx context.Context, req any) (any, error) {
+		return call(ctx, req.(Req))
+	}
+	var resp any
+	var err error
+	if h.interceptor == nil {
+		resp, err = handler(ctx, req)
+	} else {
+		resp, err = h.interceptor(ctx, req, &grpc.UnaryServerInfo{Server: h.svc, FullMethod: method}, handler)
+	}
+	if err != nil {
+		var zero Resp
+		return zero, err
+	}
+	return resp.(Resp), nil
+}
+
+func pathID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, status.Error(codes.InvalidArgument, "invalid book id")
+	}
+	return id, nil
+}
+
+// readMessage decodes the JSON body into msg. Unknown fields are rejected,
+// as misspelled fields would otherwise be ignored silently.
+func readMessage(r *http.Request, msg proto.Message) error {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxBody+1))
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "could not read the body")
+	}
+	if len(data) > maxBody {
+		return status.Errorf(codes.InvalidArgument, "body larger than %d bytes", maxBody)
+	}
+	if err := unmarshal.Unmarshal(data, msg); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid JSON: %v", err)
+	}
+	return nil
+}
+
+// respond writes msg with the status of a success, or the error
+func respond(w http.ResponseWriter, httpStatus int, msg proto.Message, err error) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if httpStatus == http.StatusNoContent {
+		w.WriteHeader(httpStatus)
+		return
+	}
+	data, err := marshal.Marshal(msg)
+	if err != nil {
+		writeError(w, status.Error(codes.Internal, err.Error()))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	w.Write(data)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+
+	"golang-training/module-09/exercise-2/models"
+	orderv1 "golang-training/module-29/exercise-2/proto/order/v1"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Codec encodes orders to bytes, for a file, a queue or the network
+type Codec interface {
+	Name() string
+	Encode(o *models.Order) ([]byte, error)
+	Decode(data []byte) (*models.Order, error)
+}
+
+// Codecs are compared by the program and the benchmarks
+var Codecs = []Codec{JSONCodec{}, GobCodec{}, ProtoCodec{}}
+
+// JSONCodec is readable by anyone and any language, and self-describing:
+// every value repeats its field names
+type JSONCodec struct{}
+
+func (JSONCodec) Name() string { return "JSON" }
+
+func (JSONCodec) Encode(o *models.Order) ([]byte, error) {
+	return json.Marshal(o)
+}
+
+func (JSONCodec) Decode(data []byte) (*models.Order, error) {
+	var o models.Order
+	err := json.Unmarshal(data, &o)
+	return &o, err
+}
+
+// GobCodec encodes Go values for Go programs only. A stream sends the
+// description of each type once, then the values: one value per stream, as
+// here, pays for the description every time.
+type GobCodec struct{}
+
+func (GobCodec) Name() string { return "gob" }
+
+func (GobCodec) Encode(o *models.Order) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(o)
+	return buf.Bytes(), err
+}
+
+func (GobCodec) Decode(data []byte) (*models.Order, error) {
+	var o models.Order
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&o)
+	return &o, err
+}
+
+// ProtoCodec encodes the message of the order: field numbers instead of
+// names, and variable-length integers. The schema is needed to read it.
+type ProtoCodec struct{}
+
+func (ProtoCodec) Name() string { return "protobuf" }
+
+func (ProtoCodec) Encode(o *models.Order) ([]byte, error) {
+	return proto.Marshal(OrderToProto(o))
+}
+
+func (ProtoCodec) Decode(data []byte) (*models.Order, error) {
+	var msg orderv1.Order
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	return OrderFromProto(&msg)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"golang-training/module-09/exercise-2/models"
+)
+
+func TestRoundTrip(t *testing.T) {
+	order := sampleOrder("ord-test")
+	for _, codec := range Codecs {
+		t.Run(codec.Name(), func(t *testing.T) {
+			data, err := codec.Encode(order)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := codec.Decode(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(order, got) {
+				t.Errorf("decoded order differs:\n got %+v\nwant %+v", got, order)
+			}
+		})
+	}
+}
+
+func TestOrderFromProtoRejectsUnknownStatus(t *testing.T) {
+	msg := OrderToProto(sampleOrder("ord-test"))
+	msg.Status = 42
+	if _, err := OrderFromProto(msg); err == nil {
+		t.Error("expected an error for an unknown status")
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	order := sampleOrder("ord-bench")
+	for _, codec := range Codecs {
+		b.Run(codec.Name(), func(b *testing.B) {
+			var size int
+			for b.Loop() {
+				data, err := codec.Encode(order)
+				if err != nil {
+					b.Fatal(err)
+				}
+				size = len(data)
+			}
+			b.ReportMetric(float64(size), "bytes/order")
+		})
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	order := sampleOrder("ord-bench")
+	for _, codec := range Codecs {
+		data, err := codec.Encode(order)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(codec.Name(), func(b *testing.B) {
+			var got *models.Order
+			for b.Loop() {
+				if got, err = codec.Decode(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+			_ = got
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"golang-training/module-09/exercise-2/models"
+	orderv1 "golang-training/module-29/exercise-2/proto/order/v1"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The generated messages are the wire format, not the model: converting at
+// the edge keeps the methods and the rules of the models in module 09, and
+// lets the schema evolve without changing them.
+
+var statusToProto = map[models.OrderStatus]orderv1.OrderStatus{
+	models.StatusPending:   orderv1.OrderStatus_ORDER_STATUS_PENDING,
+	models.StatusPaid:      orderv1.OrderStatus_ORDER_STATUS_PAID,
+	models.StatusShipped:   orderv1.OrderStatus_ORDER_STATUS_SHIPPED,
+	models.StatusDelivered: orderv1.OrderStatus_ORDER_STATUS_DELIVERED,
+	models.StatusCancelled: orderv1.OrderStatus_ORDER_STATUS_CANCELLED,
+	models.StatusRefunded:  orderv1.OrderStatus_ORDER_STATUS_REFUNDED,
+}
+
+var statusFromProto = func() map[orderv1.OrderStatus]models.OrderStatus {
+	m := make(map[orderv1.OrderStatus]models.OrderStatus)
+	for status, value := range statusToProto {
+		m[value] = status
+	}
+	return m
+}()
+
+func moneyToProto(m models.Money) *orderv1.Money {
+	return &orderv1.Money{Amount: m.Amount, Currency: m.Currency}
+}
+
+func moneyFromProto(m *orderv1.Money) models.Money {
+	return models.NewMoney(m.GetAmount(), m.GetCurrency())
+}
+
+// timeToProto keeps the zero time as a missing field
+func timeToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func timeFromProto(t *timestamppb.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.AsTime()
+}
+
+// ProductToProto converts a product of the catalog
+func ProductToProto(p models.Product) *orderv1.Product {
+	return &orderv1.Product{Id: p.ID, Name: p.Name, Price: moneyToProto(p.Price), Weight: p.Weight}
+}
+
+// OrderToProto converts an order to its message
+func OrderToProto(o *models.Order) *orderv1.Order {
+	a := o.Shipping.Address
+	msg := &orderv1.Order{
+		OrderId:    o.OrderID,
+		CustomerId: o.CustomerID,
+		Subtotal:   moneyToProto(o.Subtotal),
+		Shipping: &orderv1.Shipping{
+			Address:  &orderv1.Address{Name: a.Name, Street: a.Street, City: a.City, PostalCode: a.PostalCode, Country: a.Country},
+			Method:   o.Shipping.Method,
+			Zone:     o.Shipping.Zone,
+			Cost:     moneyToProto(o.Shipping.Cost),
+			Earliest: timeToProto(o.Shipping.Earliest),
+			Latest:   timeToProto(o.Shipping.Latest),
+		},
+		TotalAmount: moneyToProto(o.TotalAmount),
+		Status:      statusToProto[o.Status],
+		PaymentId:   o.PaymentID,
+	}
+	for _, item := range o.Items {
+		msg.Items = append(msg.Items, &orderv1.Item{ProductId: item.ProductID, Quantity: int32(item.Quantity)})
+	}
+	for _, change := range o.History {
+		msg.History = append(msg.History, &orderv1.StatusChange{
+			OrderId: change.OrderID,
+			From:    statusToProto[change.From],
+			To:      statusToProto[change.To],
+			At:      timeToProto(change.At),
+			Reason:  change.Reason,
+		})
+	}
+	return msg
+}
+
+// OrderFromProto converts a message back to an order. A status unknown to
+// this version of the program is an error, rather than a silent default.
+func OrderFromProto(msg *orderv1.Order) (*models.Order, error) {
+	status, ok := statusFromProto[msg.GetStatus()]
+	if !ok {
+		return nil, fmt.Errorf("order %s: unknown status %v", msg.GetOrderId(), msg.GetStatus())
+	}
+	s := msg.GetShipping()
+	a := s.GetAddress()
+	o := &models.Order{
+		OrderID:    msg.GetOrderId(),
+		CustomerID: msg.GetCustomerId(),
+		Subtotal:   moneyFromProto(msg.GetSubtotal()),
+		Shipping: models.Shipping{
+			Address:  models.Address{Name: a.GetName(), Street: a.GetStreet(), City: a.GetCity(), PostalCode: a.GetPostalCode(), Country: a.GetCountry()},
+			Method:   s.GetMethod(),
+			Zone:     s.GetZone(),
+			Cost:     moneyFromProto(s.GetCost()),
+			Earliest: timeFromProto(s.GetEarliest()),
+			Latest:   timeFromProto(s.GetLatest()),
+		},
+		TotalAmount: moneyFromProto(msg.GetTotalAmount()),
+		Status:      status,
+		PaymentID:   msg.GetPaymentId(),
+	}
+	for _, item := range msg.GetItems() {
+		o.Items = append(o.Items, models.Item{ProductID: item.GetProductId(), Quantity: int(item.GetQuantity())})
+	}
+	for _, change := range msg.GetHistory() {
+		o.History = append(o.History, models.StatusChange{
+			OrderID: change.GetOrderId(),
+			From:    statusFromProto[change.GetFrom()], // "" for the creation
+			To:      statusFromProto[change.GetTo()],
+			At:      timeFromProto(change.GetAt()),
+			Reason:  change.GetReason(),
+		})
+	}
+	return o, nil
+}
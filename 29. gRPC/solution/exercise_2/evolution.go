@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+
+	evolutionv1 "golang-training/module-29/exercise-2/proto/evolution/v1"
+	evolutionv2 "golang-training/module-29/exercise-2/proto/evolution/v2"
+	evolutionv3 "golang-training/module-29/exercise-2/proto/evolution/v3"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// runEvolution shows programs built with different versions of the
+// OrderSummary schema reading each other's messages, as happens during a
+// deployment or with messages stored long ago
+func runEvolution() error {
+	old := &evolutionv1.OrderSummary{OrderId: "ord-1", CustomerId: "cust-1", TotalCents: 4500, DiscountCents: 500, Status: "paid"}
+	oldData, err := proto.Marshal(old)
+	if err != nil {
+		return err
+	}
+
+	// New reader, old message: the removed field is kept as unknown bytes,
+	// and the added fields have their zero value
+	var v2 evolutionv2.OrderSummary
+	if err := proto.Unmarshal(oldData, &v2); err != nil {
+		return err
+	}
+	fmt.Printf("v2 reads v1:  order %s, total %d, currency %q, tags %v, %d unknown bytes\n",
+		v2.OrderId, v2.TotalCents, v2.Currency, v2.Tags, len(v2.ProtoReflect().GetUnknown()))
+
+	// The unknown bytes are written again: a v2 service passing the message
+	// on doesn't lose the discount for the v1 services after it
+	v2.Status = "shipped"
+	passedOn, err := proto.Marshal(&v2)
+	if err != nil {
+		return err
+	}
+	var back evolutionv1.OrderSummary
+	if err := proto.Unmarshal(passedOn, &back); err != nil {
+		return err
+	}
+	fmt.Printf("v1 reads v2 passing v1 on: status %s, discount %d kept\n", back.Status, back.DiscountCents)
+
+	// Old reader, new message: the added fields are unknown to it, and skipped
+	newer := &evolutionv2.OrderSummary{OrderId: "ord-2", CustomerId: "cust-2", TotalCents: 1200, Status: "paid", Currency: "EUR", Tags: []string{"gift"}}
+	newData, err := proto.Marshal(newer)
+	if err != nil {
+		return err
+	}
+	var v1 evolutionv1.OrderSummary
+	if err := proto.Unmarshal(newData, &v1); err != nil {
+		return err
+	}
+	fmt.Printf("v1 reads v2:  order %s, total %d, discount %d, %d unknown bytes\n",
+		v1.OrderId, v1.TotalCents, v1.DiscountCents, len(v1.ProtoReflect().GetUnknown()))
+
+	// A reused number: nothing fails, the value is silently misread. The
+	// reservation in v2 makes the compiler refuse this schema.
+	var v3 evolutionv3.OrderSummary
+	if err := proto.Unmarshal(oldData, &v3); err != nil {
+		return err
+	}
+	fmt.Printf("v3 reads v1:  order %s gets %d loyalty points, from its discount in cents\n", v3.OrderId, v3.LoyaltyPoints)
+
+	// JSON uses the names: renaming or removing a field breaks JSON readers
+	// even when the binary encoding still works
+	jsonData, err := protojson.Marshal(old)
+	if err != nil {
+		return err
+	}
+	err = protojson.Unmarshal(jsonData, &evolutionv2.OrderSummary{})
+	fmt.Printf("v2 reads v1 as JSON: %v\n", err)
+	err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(jsonData, &evolutionv2.OrderSummary{})
+	fmt.Printf("v2 reads v1 as JSON, discarding unknown fields: error %v\n", err)
+	return nil
+}
@@ -0,0 +1,9 @@
+module golang-training/module-29/exercise-2
+
+go 1.25
+
+require golang-training/module-09/exercise-2 v0.0.0
+
+require google.golang.org/protobuf v1.36.10
+
+replace golang-training/module-09/exercise-2 => "../../../09. Packages and Modules/solution/exercise_2"
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+
+	"golang-training/module-09/exercise-2/models"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+)
+
+// sampleOrder returns a shipped order with three items, like the orders of
+// the shop of module 09
+func sampleOrder(id string) *models.Order {
+	placed := time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC)
+	items := []models.Item{
+		{ProductID: "laptop-14", Quantity: 1},
+		{ProductID: "usb-c-hub", Quantity: 2},
+		{ProductID: "sleeve-14", Quantity: 1},
+	}
+	shipping := models.Shipping{
+		Address:  models.Address{Name: "Ada Lovelace", Street: "12 Analytical Way", City: "London", PostalCode: "NW1 6XE", Country: "GB"},
+		Method:   "express",
+		Zone:     "international",
+		Cost:     models.MustParseMoney("45.00", "USD"),
+		Earliest: placed.Add(48 * time.Hour),
+		Latest:   placed.Add(96 * time.Hour),
+	}
+	order, err := models.NewOrder(id, "cust-42", items, models.MustParseMoney("1519.97", "USD"), shipping)
+	if err != nil {
+		log.Fatal(err)
+	}
+	order.PaymentID = "ch_3PqR8sT2uV"
+	order.TransitionTo(models.StatusPaid, "payment ch_3PqR8sT2uV")
+	order.TransitionTo(models.StatusShipped, "tracking 1Z999AA10123456784")
+	// The history is stamped with time.Now: fixed times make the sizes of
+	// every run the same
+	for i := range order.History {
+		order.History[i].At = placed.Add(time.Duration(i) * time.Hour)
+	}
+	return order
+}
+
+// streamSizes returns the size of n orders written one after the other:
+// JSON lines, one gob stream, and length-prefixed protobuf messages
+func streamSizes(orders []*models.Order) (map[string]int, error) {
+	var jsonBuf, gobBuf, protoBuf bytes.Buffer
+	jsonEncoder := json.NewEncoder(&jsonBuf)
+	gobEncoder := gob.NewEncoder(&gobBuf)
+	for _, o := range orders {
+		if err := jsonEncoder.Encode(o); err != nil {
+			return nil, err
+		}
+		if err := gobEncoder.Encode(o); err != nil {
+			return nil, err
+		}
+		// Protobuf messages don't mark their end: a stream prefixes each
+		// one with its length
+		if _, err := protodelim.MarshalTo(&protoBuf, OrderToProto(o)); err != nil {
+			return nil, err
+		}
+	}
+	return map[string]int{"JSON": jsonBuf.Len(), "gob": gobBuf.Len(), "protobuf": protoBuf.Len()}, nil
+}
+
+func main() {
+	order := sampleOrder("ord-7f3a9c21")
+	const n = 1000
+	orders := make([]*models.Order, n)
+	for i := range orders {
+		orders[i] = sampleOrder(fmt.Sprintf("ord-%08x", i))
+	}
+	streams, err := streamSizes(orders)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("--- Sizes of %s ---\n", order)
+	fmt.Printf("%-10s %12s %18s  %s\n", "codec", "one order", "stream of 1000", "round trip")
+	for _, codec := range Codecs {
+		data, err := codec.Encode(order)
+		if err != nil {
+			log.Fatalf("%s: %v", codec.Name(), err)
+		}
+		decoded, err := codec.Decode(data)
+		roundTrip := "equal"
+		if err != nil {
+			roundTrip = err.Error()
+		} else if !reflect.DeepEqual(order, decoded) {
+			roundTrip = "different"
+		}
+		fmt.Printf("%-10s %10d B %14.1f B/order  %s\n", codec.Name(), len(data), float64(streams[codec.Name()])/n, roundTrip)
+	}
+	fmt.Println("Compare the speeds with: go test -bench . -benchmem")
+
+	fmt.Println("--- Schema evolution ---")
+	if err := runEvolution(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,161 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: evolution/v1/summary.proto
+
+package evolutionv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// OrderSummary as the first version of the service writes it
+type OrderSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	CustomerId    string                 `protobuf:"bytes,2,opt,name=customer_id,json=customerId,proto3" json:"customer_id,omitempty"`
+	TotalCents    int64                  `protobuf:"varint,3,opt,name=total_cents,json=totalCents,proto3" json:"total_cents,omitempty"`
+	DiscountCents int64                  `protobuf:"varint,4,opt,name=discount_cents,json=discountCents,proto3" json:"discount_cents,omitempty"`
+	Status        string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderSummary) Reset() {
+	*x = OrderSummary{}
+	mi := &file_evolution_v1_summary_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderSummary) ProtoMessage() {}
+
+func (x *OrderSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_evolution_v1_summary_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderSummary.ProtoReflect.Descriptor instead.
+func (*OrderSummary) Descriptor() ([]byte, []int) {
+	return file_evolution_v1_summary_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *OrderSummary) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *OrderSummary) GetCustomerId() string {
+	if x != nil {
+		return x.CustomerId
+	}
+	return ""
+}
+
+func (x *OrderSummary) GetTotalCents() int64 {
+	if x != nil {
+		return x.TotalCents
+	}
+	return 0
+}
+
+func (x *OrderSummary) GetDiscountCents() int64 {
+	if x != nil {
+		return x.DiscountCents
+	}
+	return 0
+}
+
+func (x *OrderSummary) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+var File_evolution_v1_summary_proto protoreflect.FileDescriptor
+
+const file_evolution_v1_summary_proto_rawDesc = "" +
+	"\n" +
+	"\x1aevolution/v1/summary.proto\x12\fevolution.v1\"\xaa\x01\n" +
+	"\fOrderSummary\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x1f\n" +
+	"\vcustomer_id\x18\x02 \x01(\tR\n" +
+	"customerId\x12\x1f\n" +
+	"\vtotal_cents\x18\x03 \x01(\x03R\n" +
+	"totalCents\x12%\n" +
+	"\x0ediscount_cents\x18\x04 \x01(\x03R\rdiscountCents\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06statusBEZCgolang-training/module-29/exercise-2/proto/evolution/v1;evolutionv1b\x06proto3"
+
+var (
+	file_evolution_v1_summary_proto_rawDescOnce sync.Once
+	file_evolution_v1_summary_proto_rawDescData []byte
+)
+
+func file_evolution_v1_summary_proto_rawDescGZIP() []byte {
+	file_evolution_v1_summary_proto_rawDescOnce.Do(func() {
+		file_evolution_v1_summary_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_evolution_v1_summary_proto_rawDesc), len(file_evolution_v1_summary_proto_rawDesc)))
+	})
+	return file_evolution_v1_summary_proto_rawDescData
+}
+
+var file_evolution_v1_summary_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_evolution_v1_summary_proto_goTypes = []any{
+	(*OrderSummary)(nil), // 0: evolution.v1.OrderSummary
+}
+var file_evolution_v1_summary_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_evolution_v1_summary_proto_init() }
+func file_evolution_v1_summary_proto_init() {
+	if File_evolution_v1_summary_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_evolution_v1_summary_proto_rawDesc), len(file_evolution_v1_summary_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_evolution_v1_summary_proto_goTypes,
+		DependencyIndexes: file_evolution_v1_summary_proto_depIdxs,
+		MessageInfos:      file_evolution_v1_summary_proto_msgTypes,
+	}.Build()
+	File_evolution_v1_summary_proto = out.File
+	file_evolution_v1_summary_proto_goTypes = nil
+	file_evolution_v1_summary_proto_depIdxs = nil
+}
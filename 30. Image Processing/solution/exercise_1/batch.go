@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang-training/module-30/imaging"
+)
+
+// Options describe the conversion applied to every image of a batch
+type Options struct {
+	MaxWidth, MaxHeight int
+	Filter              imaging.Filter
+	Format              imaging.Format // Empty keeps the format of each image
+	Quality             int
+	Watermark           image.Image // Nil for none
+	Position            imaging.Position
+	Opacity             float64
+	MaxPixels           int
+}
+
+// Job is one image to convert
+type Job struct {
+	In, Out string
+}
+
+// Result describes the conversion of one image
+type Result struct {
+	Job
+	InSize, OutSize int64
+	From, To        image.Point
+	Duration        time.Duration
+	Err             error
+}
+
+// Plan lists the images of dir and the paths of their conversions in out,
+// keeping the tree of subdirectories. Files of other types are ignored.
+func Plan(dir, out string, format imaging.Format) ([]Job, error) {
+	var jobs []Job
+	taken := make(map[string]bool)
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		from, err := imaging.FormatOf(path)
+		if err != nil {
+			return nil
+		}
+		to := orDefault(format, from)
+		rel, _ := filepath.Rel(dir, path)
+		target := filepath.Join(out, strings.TrimSuffix(rel, filepath.Ext(rel))+to.Ext())
+		// photo.png and photo.jpg both become photo.jpg: keep the original
+		// extension in the name of the second one
+		if taken[target] {
+			target = filepath.Join(out, rel+to.Ext())
+		}
+		taken[target] = true
+		jobs = append(jobs, Job{In: path, Out: target})
+		return nil
+	})
+	return jobs, err
+}
+
+func orDefault(format, fallback imaging.Format) imaging.Format {
+	if format == "" {
+		return fallback
+	}
+	return format
+}
+
+// Run converts the jobs with a pool of workers. Each result is passed to
+// done as soon as it is ready, from one goroutine at a time, and a failed
+// image doesn't stop the others.
+func Run(ctx context.Context, jobs []Job, opts Options, workers int, done func(Result)) []Result {
+	queue := make(chan Job)
+	results := make(chan Result)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for job := range queue {
+				results <- convert(job, opts)
+			}
+		})
+	}
+	go func() {
+		defer close(queue)
+		for _, job := range jobs {
+			select {
+			case queue <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var all []Result
+	for r := range results {
+		if done != nil {
+			done(r)
+		}
+		all = append(all, r)
+	}
+	return all
+}
+
+// convert decodes, resizes, watermarks and encodes one image. The output
+// is written to a temporary file renamed at the end, so an interrupted
+// batch leaves no half-written image.
+func convert(job Job, opts Options) (r Result) {
+	r.Job = job
+	start := time.Now()
+	defer func() { r.Duration = time.Since(start) }()
+	fail := func(err error) Result {
+		r.Err = err
+		return r
+	}
+
+	f, err := os.Open(job.In)
+	if err != nil {
+		return fail(err)
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil {
+		r.InSize = info.Size()
+	}
+	img, format, err := imaging.Decode(f, opts.MaxPixels)
+	if err != nil {
+		return fail(err)
+	}
+	r.From = img.Bounds().Size()
+
+	width, height := imaging.Fit(r.From.X, r.From.Y, opts.MaxWidth, opts.MaxHeight)
+	if width != r.From.X || height != r.From.Y {
+		img = imaging.Resize(img, width, height, opts.Filter)
+	}
+	if opts.Watermark != nil {
+		img = imaging.Watermark(img, opts.Watermark, opts.Position, max(width, height)/50, opts.Opacity)
+	}
+	r.To = img.Bounds().Size()
+
+	if err := os.MkdirAll(filepath.Dir(job.Out), 0o755); err != nil {
+		return fail(err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(job.Out), ".convert-*")
+	if err != nil {
+		return fail(err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := imaging.Encode(tmp, img, orDefault(opts.Format, format), opts.Quality); err != nil {
+		tmp.Close()
+		return fail(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fail(err)
+	}
+	if err := os.Rename(tmp.Name(), job.Out); err != nil {
+		return fail(err)
+	}
+	if info, err := os.Stat(job.Out); err == nil {
+		r.OutSize = info.Size()
+	}
+	return r
+}
+
+func (r Result) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("FAIL  %s: %v", r.In, r.Err)
+	}
+	return fmt.Sprintf("ok    %s %dx%d %s -> %s %dx%d %s in %v", r.In, r.From.X, r.From.Y, kib(r.InSize),
+		r.Out, r.To.X, r.To.Y, kib(r.OutSize), r.Duration.Round(time.Millisecond))
+}
+
+func kib(n int64) string {
+	return fmt.Sprintf("%.1f KiB", float64(n)/1024)
+}
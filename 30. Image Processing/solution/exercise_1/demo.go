@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+
+	"golang-training/module-30/imaging"
+)
+
+// demo converts generated images to JPEG thumbnails with a watermark, among
+// them a broken file and a PNG claiming a huge size, then compares the two
+// filters on a checkerboard
+func demo(opts Options, workers int) error {
+	dir, err := os.MkdirTemp("", "imaging")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	in := filepath.Join(dir, "in")
+	if err := os.MkdirAll(filepath.Join(in, "photos"), 0o755); err != nil {
+		return err
+	}
+
+	files := map[string]image.Image{
+		"gradient.png":       gradient(1600, 1200),
+		"photos/rings.jpg":   rings(2400, 1600),
+		"photos/rings.png":   rings(640, 480),
+		"photos/palette.gif": rings(300, 300),
+	}
+	for name, img := range files {
+		if err := save(filepath.Join(in, name), img); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(in, "broken.png"), []byte("not a PNG"), 0o644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(in, "huge.png"), hugePNG(60000, 60000), 0o644); err != nil {
+		return err
+	}
+
+	opts.Format, opts.MaxWidth, opts.MaxHeight = imaging.JPEG, 400, 400
+	if opts.Watermark == nil {
+		opts.Watermark = logo(200)
+	}
+	jobs, err := Plan(in, filepath.Join(dir, "out"), opts.Format)
+	if err != nil {
+		return err
+	}
+	fmt.Println("== Batch to 400x400 JPEG with a watermark")
+	batch(context.Background(), jobs, opts, workers)
+
+	fmt.Println("== Checkerboard of 1-pixel squares, 512x512 to 100x100")
+	board := checkerboard(512)
+	for _, filter := range []imaging.Filter{imaging.NearestNeighbor, imaging.Bilinear} {
+		mean, stddev := grayStats(imaging.Resize(board, 100, 100, filter))
+		fmt.Printf("  %-8s mean gray %5.1f, deviation %5.1f\n", filter, mean, stddev)
+	}
+	fmt.Println("  the board is 50% gray from afar: nearest picks black or white squares, bilinear averages them")
+	return nil
+}
+
+func save(path string, img image.Image) error {
+	format, err := imaging.FormatOf(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return imaging.Encode(f, img, format, 90)
+}
+
+func gradient(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.RGBA{uint8(255 * x / w), uint8(255 * y / h), 160, 255})
+		}
+	}
+	return img
+}
+
+func rings(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			d := math.Hypot(float64(x-w/2), float64(y-h/2))
+			v := uint8(127 + 127*math.Sin(d/8))
+			img.Set(x, y, color.RGBA{v, 90, 255 - v, 255})
+		}
+	}
+	return img
+}
+
+func checkerboard(size int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, size, size))
+	for y := range size {
+		for x := range size {
+			if (x+y)%2 == 0 {
+				img.SetGray(x, y, color.Gray{255})
+			}
+		}
+	}
+	return img
+}
+
+// logo is a white disc with a transparent background
+func logo(size int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	r := float64(size) / 2
+	for y := range size {
+		for x := range size {
+			if math.Hypot(float64(x)-r+0.5, float64(y)-r+0.5) < r {
+				img.Set(x, y, color.NRGBA{255, 255, 255, 255})
+			}
+		}
+	}
+	return img
+}
+
+// hugePNG is a 1x1 PNG whose header claims width×height: the header is
+// all DecodeConfig reads
+func hugePNG(width, height uint32) []byte {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1)))
+	data := buf.Bytes()
+	// The IHDR chunk follows the 8-byte signature: length, type, then width
+	// and height, and its CRC after 13 bytes of data
+	binary.BigEndian.PutUint32(data[16:], width)
+	binary.BigEndian.PutUint32(data[20:], height)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func grayStats(img image.Image) (mean, stddev float64) {
+	b := img.Bounds()
+	var sum, sq float64
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			g := float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+			sum += g
+			sq += g * g
+		}
+	}
+	n := float64(b.Dx() * b.Dy())
+	mean = sum / n
+	return mean, math.Sqrt(sq/n - mean*mean)
+}
@@ -0,0 +1,7 @@
+module golang-training/module-30/exercise-1
+
+go 1.25
+
+require golang-training/module-30/imaging v0.0.0
+
+replace golang-training/module-30/imaging => ../imaging
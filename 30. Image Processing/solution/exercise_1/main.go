@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"runtime"
+	"time"
+
+	"golang-training/module-30/imaging"
+)
+
+func main() {
+	in := flag.String("in", "", "directory of the images to convert; empty runs the demo")
+	out := flag.String("out", "converted", "directory of the converted images")
+	width := flag.Int("width", 1024, "most width of the converted images, 0 for any")
+	height := flag.Int("height", 1024, "most height of the converted images, 0 for any")
+	filterName := flag.String("filter", "bilinear", "resize filter: nearest or bilinear")
+	formatName := flag.String("format", "", "output format: jpeg, png or gif; by default the format of each image")
+	quality := flag.Int("quality", 85, "JPEG quality, 1 to 100")
+	watermark := flag.String("watermark", "", "image drawn over every converted image, such as a PNG logo")
+	positionName := flag.String("position", "bottom-right", "position of the watermark")
+	opacity := flag.Float64("opacity", 0.5, "opacity of the watermark, 0 to 1")
+	workers := flag.Int("j", runtime.NumCPU(), "images converted at once")
+	flag.Parse()
+
+	opts := Options{MaxWidth: *width, MaxHeight: *height, Quality: *quality, Opacity: *opacity, MaxPixels: 50_000_000}
+	var err error
+	if opts.Filter, err = imaging.ParseFilter(*filterName); err != nil {
+		log.Fatal(err)
+	}
+	if *formatName != "" {
+		if opts.Format, err = imaging.ParseFormat(*formatName); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if opts.Position, err = imaging.ParsePosition(*positionName); err != nil {
+		log.Fatal(err)
+	}
+	if *watermark != "" {
+		f, err := os.Open(*watermark)
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Watermark, _, err = imaging.Decode(f, opts.MaxPixels)
+		f.Close()
+		if err != nil {
+			log.Fatalf("watermark: %v", err)
+		}
+	}
+
+	if *in == "" {
+		if err := demo(opts, max(*workers, 1)); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Ctrl+C stops handing out images; those in progress finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	jobs, err := Plan(*in, *out, opts.Format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if failed := batch(ctx, jobs, opts, max(*workers, 1)); failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// batch converts the jobs, prints each result as it comes and a summary,
+// and returns the number of failed images
+func batch(ctx context.Context, jobs []Job, opts Options, workers int) int {
+	start := time.Now()
+	results := Run(ctx, jobs, opts, workers, func(r Result) { fmt.Println(r) })
+
+	var in, out int64
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			continue
+		}
+		in += r.InSize
+		out += r.OutSize
+	}
+	fmt.Printf("%d images converted, %d failed, %d not started; %s -> %s in %v with %d workers\n",
+		len(results)-failed, failed, len(jobs)-len(results), kib(in), kib(out),
+		time.Since(start).Round(time.Millisecond), workers)
+	return failed
+}
@@ -0,0 +1,126 @@
+// Package imaging decodes, resizes, watermarks and converts images with the
+// image packages of the standard library only. The resize filters are
+// written by hand, to show what golang.org/x/image/draw does for you.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// ErrTooLarge is returned for an image with more pixels than allowed: a
+// small PNG can claim 50000×50000 pixels and need 10 GB once decoded
+var ErrTooLarge = errors.New("image too large")
+
+// Format is an image file format
+type Format string
+
+const (
+	JPEG Format = "jpeg"
+	PNG  Format = "png"
+	GIF  Format = "gif"
+)
+
+// ParseFormat reads a format name, as image.Decode returns it or as a user
+// types it
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "jpeg", "jpg":
+		return JPEG, nil
+	case "png":
+		return PNG, nil
+	case "gif":
+		return GIF, nil
+	}
+	return "", fmt.Errorf("unsupported image format %q", name)
+}
+
+// FormatOf returns the format of a file name from its extension
+func FormatOf(name string) (Format, error) {
+	return ParseFormat(filepath.Ext(name))
+}
+
+// Ext returns the usual file extension of the format, with its dot
+func (f Format) Ext() string {
+	if f == JPEG {
+		return ".jpg"
+	}
+	return "." + string(f)
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	return "image/" + string(f)
+}
+
+// Decode reads a JPEG, PNG or GIF image. The size in the header is checked
+// against maxPixels before the pixels are decoded; 0 means no limit.
+func Decode(r io.Reader, maxPixels int) (image.Image, Format, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+	// The decoders are registered by the imports of image/jpeg, image/png
+	// and image/gif; image.Decode picks one from the first bytes
+	cfg, name, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	if maxPixels > 0 && cfg.Width*cfg.Height > maxPixels {
+		return nil, "", fmt.Errorf("%dx%d: %w", cfg.Width, cfg.Height, ErrTooLarge)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	format, err := ParseFormat(name)
+	return img, format, err
+}
+
+// Encode writes img in format. quality, from 1 to 100, only applies to
+// JPEG. JPEG has no transparency: the transparent pixels are flattened on
+// white, or they would turn black.
+func Encode(w io.Writer, img image.Image, format Format, quality int) error {
+	switch format {
+	case JPEG:
+		return jpeg.Encode(w, Flatten(img, color.White), &jpeg.Options{Quality: quality})
+	case PNG:
+		return png.Encode(w, img)
+	case GIF:
+		// GIF has 256 colors: the encoder maps the image to a palette,
+		// with dithering
+		return gif.Encode(w, img, &gif.Options{NumColors: 256, Drawer: draw.FloydSteinberg})
+	}
+	return fmt.Errorf("unsupported image format %q", format)
+}
+
+// Flatten draws img over a background color, removing its transparency
+func Flatten(img image.Image, background color.Color) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	return dst
+}
+
+// toRGBA converts any image to *image.RGBA with its origin at 0,0, whose
+// pixels are read directly from Pix instead of through the slow At method
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
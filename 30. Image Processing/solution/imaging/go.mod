@@ -0,0 +1,3 @@
+module golang-training/module-30/imaging
+
+go 1.25
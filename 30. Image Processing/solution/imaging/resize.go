@@ -0,0 +1,130 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// Filter chooses how resized pixels are computed
+type Filter int
+
+const (
+	// NearestNeighbor copies the closest source pixel: fast and sharp, but
+	// blocky when enlarging and noisy when shrinking
+	NearestNeighbor Filter = iota
+	// Bilinear mixes the 4 closest source pixels by their distance
+	Bilinear
+)
+
+// ParseFilter reads a filter name: nearest or bilinear
+func ParseFilter(name string) (Filter, error) {
+	switch name {
+	case "nearest":
+		return NearestNeighbor, nil
+	case "bilinear":
+		return Bilinear, nil
+	}
+	return 0, fmt.Errorf("unknown filter %q", name)
+}
+
+func (f Filter) String() string {
+	if f == Bilinear {
+		return "bilinear"
+	}
+	return "nearest"
+}
+
+// Fit returns the largest size with the aspect ratio of width×height that
+// fits in maxWidth×maxHeight, never enlarging; 0 means no bound
+func Fit(width, height, maxWidth, maxHeight int) (int, int) {
+	scale := 1.0
+	if maxWidth > 0 {
+		scale = min(scale, float64(maxWidth)/float64(width))
+	}
+	if maxHeight > 0 {
+		scale = min(scale, float64(maxHeight)/float64(height))
+	}
+	return max(1, int(math.Round(float64(width)*scale))), max(1, int(math.Round(float64(height)*scale)))
+}
+
+// Resize returns img scaled to width×height with filter
+func Resize(img image.Image, width, height int, filter Filter) *image.RGBA {
+	src := toRGBA(img)
+	if filter == Bilinear {
+		// Bilinear reads 4 pixels: shrunk more than twice, it skips most
+		// of the source and aliases like nearest neighbor. Halving first
+		// averages every pixel.
+		for src.Rect.Dx() >= 2*width && src.Rect.Dy() >= 2*height {
+			src = halve(src)
+		}
+		return bilinear(src, width, height)
+	}
+	return nearest(src, width, height)
+}
+
+// nearest maps the center of each destination pixel to the source, and
+// copies the pixel it falls in
+func nearest(src *image.RGBA, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	for y := range height {
+		sy := min((2*y+1)*sh/(2*height), sh-1)
+		for x := range width {
+			sx := min((2*x+1)*sw/(2*width), sw-1)
+			copy(dst.Pix[dst.PixOffset(x, y):][:4], src.Pix[src.PixOffset(sx, sy):][:4])
+		}
+	}
+	return dst
+}
+
+// bilinear maps the center of each destination pixel to the source, and
+// mixes the 4 source pixels around it, weighted by their closeness. The
+// pixels of image.RGBA are premultiplied by their alpha, so mixing a
+// transparent pixel doesn't darken its neighbors.
+func bilinear(src *image.RGBA, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	xScale, yScale := float64(sw)/float64(width), float64(sh)/float64(height)
+	for y := range height {
+		fy := max((float64(y)+0.5)*yScale-0.5, 0)
+		y0 := min(int(fy), sh-1)
+		y1 := min(y0+1, sh-1)
+		wy := fy - float64(y0)
+		for x := range width {
+			fx := max((float64(x)+0.5)*xScale-0.5, 0)
+			x0 := min(int(fx), sw-1)
+			x1 := min(x0+1, sw-1)
+			wx := fx - float64(x0)
+
+			p00 := src.Pix[src.PixOffset(x0, y0):]
+			p10 := src.Pix[src.PixOffset(x1, y0):]
+			p01 := src.Pix[src.PixOffset(x0, y1):]
+			p11 := src.Pix[src.PixOffset(x1, y1):]
+			d := dst.Pix[dst.PixOffset(x, y):]
+			for c := range 4 {
+				top := float64(p00[c])*(1-wx) + float64(p10[c])*wx
+				bottom := float64(p01[c])*(1-wx) + float64(p11[c])*wx
+				d[c] = uint8(top*(1-wy) + bottom*wy + 0.5)
+			}
+		}
+	}
+	return dst
+}
+
+// halve averages each block of 2×2 pixels into one
+func halve(src *image.RGBA) *image.RGBA {
+	w, h := src.Rect.Dx()/2, src.Rect.Dy()/2
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			a := src.Pix[src.PixOffset(2*x, 2*y):]
+			b := src.Pix[src.PixOffset(2*x, 2*y+1):]
+			d := dst.Pix[dst.PixOffset(x, y):]
+			for c := range 4 {
+				d[c] = uint8((int(a[c]) + int(a[c+4]) + int(b[c]) + int(b[c+4]) + 2) / 4)
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,71 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Position places a watermark on an image
+type Position int
+
+const (
+	BottomRight Position = iota
+	BottomLeft
+	TopRight
+	TopLeft
+	Center
+)
+
+// ParsePosition reads a position name, such as bottom-right
+func ParsePosition(name string) (Position, error) {
+	for p, n := range positionNames {
+		if n == name {
+			return Position(p), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown position %q", name)
+}
+
+var positionNames = []string{"bottom-right", "bottom-left", "top-right", "top-left", "center"}
+
+func (p Position) String() string {
+	return positionNames[p]
+}
+
+// Watermark returns a copy of img with mark drawn over it at pos, margin
+// pixels from the edges, with its opacity multiplied by opacity (0 to 1).
+// A mark wider or taller than a quarter of the image is scaled down first.
+func Watermark(img image.Image, mark image.Image, pos Position, margin int, opacity float64) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+
+	mw, mh := mark.Bounds().Dx(), mark.Bounds().Dy()
+	if w, h := Fit(mw, mh, max(b.Dx()/4, 1), max(b.Dy()/4, 1)); w != mw || h != mh {
+		mark = Resize(mark, w, h, Bilinear)
+	}
+	size := mark.Bounds().Size()
+
+	var at image.Point
+	switch pos {
+	case TopLeft:
+		at = image.Pt(margin, margin)
+	case TopRight:
+		at = image.Pt(b.Dx()-size.X-margin, margin)
+	case BottomLeft:
+		at = image.Pt(margin, b.Dy()-size.Y-margin)
+	case BottomRight:
+		at = image.Pt(b.Dx()-size.X-margin, b.Dy()-size.Y-margin)
+	case Center:
+		at = image.Pt((b.Dx()-size.X)/2, (b.Dy()-size.Y)/2)
+	}
+
+	// A uniform mask scales the alpha of every pixel of the mark; draw.Over
+	// blends the mark with what is under it
+	mask := image.NewUniform(color.Alpha{A: uint8(min(max(opacity, 0), 1)*255 + 0.5)})
+	r := image.Rectangle{Min: at, Max: at.Add(size)}
+	draw.DrawMask(dst, r, mark, mark.Bounds().Min, mask, image.Point{}, draw.Over)
+	return dst
+}